@@ -0,0 +1,25 @@
+// Command openapi writes the API's OpenAPI document, generated from the
+// source. It is run by go generate ./internal/docs.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/developia-II/ecommerce-backend/internal/openapi"
+)
+
+func main() {
+	root := flag.String("root", ".", "module root")
+	out := flag.String("o", "openapi.json", "file to write")
+	flag.Parse()
+
+	spec, err := openapi.Generate(*root)
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI document: %v", err)
+	}
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+}
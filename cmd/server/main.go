@@ -1,28 +1,61 @@
 package main
 
 import (
-	"os"
+	"compress/gzip"
+	"context"
+	"net/http"
 	"strings"
 
+	"github.com/developia-II/ecommerce-backend/internal/config"
 	"github.com/developia-II/ecommerce-backend/internal/database"
+	"github.com/developia-II/ecommerce-backend/internal/events"
 	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
+	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		logrus.Info("No .env file found (using environment variables)")
-	}
-
 	logrus.Info("Starting server...")
 	logrus.SetLevel(logrus.InfoLevel)
 	gin.SetMode(gin.ReleaseMode)
+	// Binding errors name fields as clients send them, and binding tags can
+	// use the domain rules
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		utils.UseJSONFieldNames(v)
+		utils.RegisterRules(v)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load configuration")
+	}
+	for _, warning := range cfg.Warnings() {
+		logrus.Warn(warning)
+	}
+	utils.SetJWTSecret(cfg.JWTSecret)
+	if err := installProviders(cfg); err != nil {
+		logrus.WithError(err).Fatal("Failed to set up providers")
+	}
+	if cfg.IsProduction() {
+		// One JSON object per line, for the log pipeline to index
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	}
+	logrus.WithField("env", cfg.Env).Info("Configuration loaded")
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Telemetry, cfg.Env)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to set up tracing")
+	}
+	defer shutdownTracing(context.Background())
 
 	logrus.Info("Attempting to connect to database...")
-	db, err := database.ConnectToDB()
+	db, err := database.ConnectToDB(cfg.Mongo)
 	if err != nil {
 		logrus.WithError(err).Warn("Failed to connect to DB - running without database")
 		db = nil
@@ -30,31 +63,71 @@ func main() {
 		logrus.Info("Successfully connected to DB")
 	}
 
+	if webhooks := events.NewWebhookDispatcher(cfg.Webhooks); webhooks != nil {
+		events.Subscribe(events.AllEvents, webhooks.Handle)
+		logrus.WithField("endpoints", len(webhooks.URLs)).Info("Outbound webhooks enabled")
+	}
+
 	logrus.Info("Setting up Gin router...")
-	router := gin.Default()
+	router := gin.New()
+	// Only proxies we run may say who the client is; otherwise anyone could
+	// pick their own IP with X-Forwarded-For and dodge rate limits and blocks
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logrus.WithError(err).Fatal("Failed to set trusted proxies")
+	}
+	// Every request gets an ID, a trace and one access log line; Recovery
+	// comes after so panics are logged and measured as 500s
+	router.Use(middleware.RequestID(), middleware.Telemetry(), middleware.AccessLog(), gin.Recovery())
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "https://vendora-f.vercel.app/"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.IdempotencyKeyHeader, middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Idempotent-Replayed", "Retry-After", middleware.RequestIDHeader, middleware.APIVersionHeader, "Deprecation", "Sunset", "Link"},
 		AllowCredentials: true,
 	}))
+	router.Use(middleware.Gzip(gzip.DefaultCompression))
 
 	logrus.Info("Calling handlers.SetupRoutes...")
-	handlers.SetupRoutes(router, db)
+	handlers.SetupRoutes(router, db, cfg)
 	logrus.Info("Routes registered successfully")
 
-	PORT := os.Getenv("PORT")
-	if PORT == "" {
-		PORT = "8080"
-	}
-
+	PORT := cfg.Port
 	if !strings.HasPrefix(PORT, ":") {
 		PORT = ":" + PORT
 	}
 
 	logrus.Info("Starting server on port: " + PORT)
-	if err := router.Run(PORT); err != nil {
+	if err := http.ListenAndServe(PORT, middleware.VersionedAPI(router)); err != nil {
 		logrus.WithError(err).Fatal("Failed to start HTTP server")
 	}
 }
+
+// installProviders sets up the email, SMS, shipping and geocoding providers
+// the utils package sends through, from the validated configuration.
+func installProviders(cfg *config.Config) error {
+	email, err := utils.NewEmailProvider(cfg.Email)
+	if err != nil {
+		return err
+	}
+	utils.SetEmailProvider(email)
+
+	sms, err := utils.NewSMSRouterFromConfig(cfg.SMS)
+	if err != nil {
+		return err
+	}
+	utils.SetSMSRouter(sms)
+
+	shipping, err := utils.NewShippingProvider(cfg.Shipping)
+	if err != nil {
+		return err
+	}
+	utils.SetShippingProvider(shipping)
+
+	geocoder, err := utils.NewGeocoder(cfg.Geocoder)
+	if err != nil {
+		return err
+	}
+	utils.SetGeocoder(geocoder)
+	return nil
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AnalyticsRepository interface {
+	RebuildPlatformDay(ctx context.Context, day time.Time) (models.PlatformDay, error)
+	ListPlatformDays(ctx context.Context, from, to time.Time) ([]models.PlatformDay, error)
+	ListCohorts(ctx context.Context, from, to time.Time, segment string) ([]models.CohortCounts, []models.CohortActivity, error)
+	ListCheckoutReach(ctx context.Context, from, to time.Time) ([]models.CheckoutReach, []models.CheckoutReach, error)
+}
+
+type MongoAnalyticsRepository struct {
+	DB *mongo.Database
+}
+
+func NewAnalyticsRepository(db *mongo.Database) AnalyticsRepository {
+	return &MongoAnalyticsRepository{DB: db}
+}
+
+// RebuildPlatformDay recomputes the UTC day holding day from orders,
+// transactions and vendor accounts, and replaces its row in
+// platformDailyStats.
+func (r *MongoAnalyticsRepository) RebuildPlatformDay(ctx context.Context, day time.Time) (models.PlatformDay, error) {
+	start := models.BucketStart(day, models.GranularityDay)
+	end := start.AddDate(0, 0, 1)
+	window := bson.M{"$gte": start, "$lt": end}
+	stats := models.PlatformDay{Day: start, Categories: []models.CategoryDay{}}
+
+	// 1. GMV and category performance of the day's paid orders
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": window, "paymentStatus": bson.M{"$in": models.CapturedPaymentStatuses}}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{"_id": nil, "gmv": bson.M{"$sum": "$total"}, "orders": bson.M{"$sum": 1}}},
+			},
+			"categories": bson.A{
+				bson.M{"$unwind": "$items"},
+				bson.M{"$group": bson.M{
+					"_id":       bson.M{"$ifNull": bson.A{"$items.categoryId", primitive.NilObjectID}},
+					"revenue":   bson.M{"$sum": "$items.subtotal"},
+					"unitsSold": bson.M{"$sum": "$items.quantity"},
+					"orders":    bson.M{"$addToSet": "$_id"},
+				}},
+				bson.M{"$lookup": bson.M{
+					"from":         "categories",
+					"localField":   "_id",
+					"foreignField": "_id",
+					"as":           "category",
+				}},
+				bson.M{"$project": bson.M{
+					"_id":        0,
+					"categoryId": "$_id",
+					"name":       bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$category.name", 0}}, "Uncategorized"}},
+					"revenue":    1,
+					"unitsSold":  1,
+					"orders":     bson.M{"$size": "$orders"},
+				}},
+				bson.M{"$sort": bson.D{{Key: "revenue", Value: -1}, {Key: "categoryId", Value: 1}}},
+			},
+		}}},
+	}
+	var facets []struct {
+		Totals []struct {
+			GMV    models.Money `bson:"gmv"`
+			Orders int          `bson:"orders"`
+		} `bson:"totals"`
+		Categories []models.CategoryDay `bson:"categories"`
+	}
+	if err := r.aggregate(ctx, "orders", pipeline, &facets); err != nil {
+		return stats, err
+	}
+	if len(facets) > 0 {
+		if len(facets[0].Totals) > 0 {
+			stats.GMV, stats.Orders = facets[0].Totals[0].GMV, facets[0].Totals[0].Orders
+		}
+		if facets[0].Categories != nil {
+			stats.Categories = facets[0].Categories
+		}
+	}
+
+	// 2. Refunds issued during the day, on orders from any day
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"refunds.createdAt": window}}},
+		{{Key: "$unwind", Value: "$refunds"}},
+		{{Key: "$match", Value: bson.M{"refunds.createdAt": window, "refunds.status": models.RefundStatusSucceeded}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "volume": bson.M{"$sum": "$refunds.amount"}, "count": bson.M{"$sum": 1}}}},
+	}
+	var refunds []struct {
+		Volume models.Money `bson:"volume"`
+		Count  int          `bson:"count"`
+	}
+	if err := r.aggregate(ctx, "orders", pipeline, &refunds); err != nil {
+		return stats, err
+	}
+	if len(refunds) > 0 {
+		stats.RefundVolume, stats.Refunds = refunds[0].Volume, refunds[0].Count
+	}
+
+	// 3. Platform fees. Refund clawbacks take their share of the fee off the
+	// sale itself, so the sales' fees are already net of refunds.
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"type": models.TransactionTypeSale, "createdAt": window}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "fees": bson.M{"$sum": "$fee"}}}},
+	}
+	var fees []struct {
+		Fees models.Money `bson:"fees"`
+	}
+	if err := r.aggregate(ctx, "transactions", pipeline, &fees); err != nil {
+		return stats, err
+	}
+	if len(fees) > 0 {
+		stats.PlatformRevenue = fees[0].Fees
+	}
+
+	// 4. Vendor growth
+	vendors := r.DB.Collection("vendorAccounts")
+	newVendors, err := vendors.CountDocuments(ctx, bson.M{"activatedAt": window})
+	if err != nil {
+		return stats, err
+	}
+	allVendors, err := vendors.CountDocuments(ctx, bson.M{"activatedAt": bson.M{"$lt": end}})
+	if err != nil {
+		return stats, err
+	}
+	stats.NewVendors, stats.Vendors = int(newVendors), int(allVendors)
+
+	stats.ComputedAt = time.Now()
+	_, err = r.DB.Collection("platformDailyStats").ReplaceOne(ctx,
+		bson.M{"_id": stats.Day}, stats, options.Replace().SetUpsert(true))
+	return stats, err
+}
+
+func (r *MongoAnalyticsRepository) aggregate(ctx context.Context, collection string, pipeline mongo.Pipeline, out interface{}) error {
+	cursor, err := r.DB.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, out)
+}
+
+// ListPlatformDays returns the precomputed days starting in [from, to),
+// oldest first. Days that haven't been computed are missing.
+func (r *MongoAnalyticsRepository) ListPlatformDays(ctx context.Context, from, to time.Time) ([]models.PlatformDay, error) {
+	filter := bson.M{"_id": bson.M{"$gte": models.BucketStart(from, models.GranularityDay), "$lt": to}}
+	cursor, err := r.DB.Collection("platformDailyStats").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	days := []models.PlatformDay{}
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// cohortSegments are how users are split within a cohort, by
+// models.CohortSegment*.
+var cohortSegments = map[string]interface{}{
+	models.CohortSegmentInterests: bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$interests.isSet", true}}, "personalized", "not_personalized",
+	}},
+	models.CohortSegmentOnboarding: bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$onboardingCompleted", true}}, "onboarded", "not_onboarded",
+	}},
+}
+
+// ListCohorts counts the users who signed up each month in [from, to), and
+// the months they went on to pay for orders in, split by segment when one
+// is given. Deleted accounts still count toward the cohort they joined.
+func (r *MongoAnalyticsRepository) ListCohorts(ctx context.Context, from, to time.Time, segment string) ([]models.CohortCounts, []models.CohortActivity, error) {
+	var segmentOf interface{} = bson.M{"$literal": ""}
+	if expr, ok := cohortSegments[segment]; ok {
+		segmentOf = expr
+	}
+	month := func(field string) bson.M {
+		return bson.M{"$dateTrunc": bson.M{"date": field, "unit": "month", "timezone": "UTC"}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"role": bson.M{"$ne": "admin"}, "createdAt": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$project", Value: bson.M{"month": month("$createdAt"), "segment": segmentOf}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "orders",
+			"let":  bson.M{"userId": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":         bson.M{"$eq": bson.A{"$userId", "$$userId"}},
+					"paymentStatus": bson.M{"$in": models.CapturedPaymentStatuses},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "active": month("$createdAt")}},
+			},
+			"as": "orders",
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"counts": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":          bson.M{"month": "$month", "segment": "$segment"},
+					"users":        bson.M{"$sum": 1},
+					"buyers":       bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{bson.M{"$size": "$orders"}, 0}}, 1, 0}}},
+					"repeatBuyers": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{bson.M{"$size": "$orders"}, 1}}, 1, 0}}},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "month": "$_id.month", "segment": "$_id.segment", "users": 1, "buyers": 1, "repeatBuyers": 1}},
+			},
+			"activity": bson.A{
+				bson.M{"$project": bson.M{"month": 1, "segment": 1, "active": bson.M{"$setUnion": bson.A{"$orders.active"}}}},
+				bson.M{"$unwind": "$active"},
+				bson.M{"$group": bson.M{
+					"_id":    bson.M{"month": "$month", "segment": "$segment", "active": "$active"},
+					"buyers": bson.M{"$sum": 1},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "month": "$_id.month", "segment": "$_id.segment", "active": "$_id.active", "buyers": 1}},
+			},
+		}}},
+	}
+	var facets []struct {
+		Counts   []models.CohortCounts   `bson:"counts"`
+		Activity []models.CohortActivity `bson:"activity"`
+	}
+	if err := r.aggregate(ctx, "users", pipeline, &facets); err != nil {
+		return nil, nil, err
+	}
+	if len(facets) == 0 {
+		return nil, nil, nil
+	}
+	return facets[0].Counts, facets[0].Activity, nil
+}
+
+// ListCheckoutReach counts buyers by the furthest checkout stage they
+// reached on days in [from, to): overall, and per payment provider from the
+// first payment step on.
+func (r *MongoAnalyticsRepository) ListCheckoutReach(ctx context.Context, from, to time.Time) ([]models.CheckoutReach, []models.CheckoutReach, error) {
+	paymentStage := models.CheckoutStage(models.CheckoutStepPaymentIntentCreated)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"day": bson.M{"$gte": models.BucketStart(from, models.GranularityDay), "$lt": to}}}},
+		{{Key: "$facet", Value: bson.M{
+			"overall": bson.A{
+				bson.M{"$group": bson.M{"_id": "$userId", "furthest": bson.M{"$max": "$stage"}}},
+				bson.M{"$group": bson.M{"_id": "$furthest", "users": bson.M{"$sum": 1}}},
+				bson.M{"$project": bson.M{"_id": 0, "furthest": "$_id", "users": 1}},
+			},
+			"byProvider": bson.A{
+				bson.M{"$match": bson.M{"stage": bson.M{"$gte": paymentStage}, "provider": bson.M{"$ne": ""}}},
+				bson.M{"$group": bson.M{"_id": bson.M{"user": "$userId", "provider": "$provider"}, "furthest": bson.M{"$max": "$stage"}}},
+				bson.M{"$group": bson.M{"_id": bson.M{"provider": "$_id.provider", "furthest": "$furthest"}, "users": bson.M{"$sum": 1}}},
+				bson.M{"$project": bson.M{"_id": 0, "provider": "$_id.provider", "furthest": "$_id.furthest", "users": 1}},
+			},
+		}}},
+	}
+
+	var out []struct {
+		Overall    []models.CheckoutReach `bson:"overall"`
+		ByProvider []models.CheckoutReach `bson:"byProvider"`
+	}
+	if err := r.aggregate(ctx, "checkoutEvents", pipeline, &out); err != nil {
+		return nil, nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil, nil
+	}
+	return out[0].Overall, out[0].ByProvider, nil
+}
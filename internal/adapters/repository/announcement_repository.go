@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AnnouncementRepository interface {
+	CreateAnnouncement(ctx context.Context, announcement models.StoreAnnouncement) (models.StoreAnnouncement, error)
+	GetVendorAnnouncements(ctx context.Context, vendorID primitive.ObjectID) ([]models.StoreAnnouncement, error)
+	GetAnnouncement(ctx context.Context, vendorID, id primitive.ObjectID) (*models.StoreAnnouncement, error)
+	UpdateAnnouncement(ctx context.Context, vendorID, id primitive.ObjectID, fields bson.M) (*models.StoreAnnouncement, error)
+	DeleteAnnouncement(ctx context.Context, vendorID, id primitive.ObjectID) error
+	GetLiveAnnouncements(ctx context.Context, vendorIDs []primitive.ObjectID, at time.Time) ([]models.StoreAnnouncement, error)
+}
+
+var ErrAnnouncementNotFound = errors.New("announcement not found")
+
+type MongoAnnouncementRepository struct {
+	DB *mongo.Database
+}
+
+func NewAnnouncementRepository(db *mongo.Database) AnnouncementRepository {
+	return &MongoAnnouncementRepository{DB: db}
+}
+
+func (r *MongoAnnouncementRepository) CreateAnnouncement(ctx context.Context, announcement models.StoreAnnouncement) (models.StoreAnnouncement, error) {
+	announcement.ID = primitive.NewObjectID()
+	announcement.CreatedAt = time.Now()
+	announcement.UpdatedAt = announcement.CreatedAt
+
+	_, err := r.DB.Collection("storeAnnouncements").InsertOne(ctx, announcement)
+	return announcement, err
+}
+
+func (r *MongoAnnouncementRepository) GetVendorAnnouncements(ctx context.Context, vendorID primitive.ObjectID) ([]models.StoreAnnouncement, error) {
+	opts := options.Find().SetSort(bson.M{"startsAt": -1})
+	cursor, err := r.DB.Collection("storeAnnouncements").Find(ctx, bson.M{"vendorID": vendorID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	announcements := []models.StoreAnnouncement{}
+	if err := cursor.All(ctx, &announcements); err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+func (r *MongoAnnouncementRepository) GetAnnouncement(ctx context.Context, vendorID, id primitive.ObjectID) (*models.StoreAnnouncement, error) {
+	var announcement models.StoreAnnouncement
+	err := r.DB.Collection("storeAnnouncements").FindOne(ctx, bson.M{"_id": id, "vendorID": vendorID}).Decode(&announcement)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &announcement, err
+}
+
+func (r *MongoAnnouncementRepository) UpdateAnnouncement(ctx context.Context, vendorID, id primitive.ObjectID, fields bson.M) (*models.StoreAnnouncement, error) {
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var announcement models.StoreAnnouncement
+	err := r.DB.Collection("storeAnnouncements").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "vendorID": vendorID},
+		bson.M{"$set": fields},
+		opts,
+	).Decode(&announcement)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAnnouncementNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+func (r *MongoAnnouncementRepository) DeleteAnnouncement(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	res, err := r.DB.Collection("storeAnnouncements").DeleteOne(ctx, bson.M{"_id": id, "vendorID": vendorID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrAnnouncementNotFound
+	}
+	return nil
+}
+
+// GetLiveAnnouncements returns the announcements of the given vendors that
+// are active and inside their schedule at the given time.
+func (r *MongoAnnouncementRepository) GetLiveAnnouncements(ctx context.Context, vendorIDs []primitive.ObjectID, at time.Time) ([]models.StoreAnnouncement, error) {
+	announcements := []models.StoreAnnouncement{}
+	if len(vendorIDs) == 0 {
+		return announcements, nil
+	}
+
+	filter := bson.M{
+		"vendorID": bson.M{"$in": vendorIDs},
+		"active":   true,
+		"startsAt": bson.M{"$lte": at},
+		"$or": []bson.M{
+			{"endsAt": bson.M{"$exists": false}},
+			{"endsAt": nil},
+			{"endsAt": bson.M{"$gt": at}},
+		},
+	}
+	opts := options.Find().SetSort(bson.M{"startsAt": -1})
+	cursor, err := r.DB.Collection("storeAnnouncements").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &announcements); err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository is append-only: entries are inserted and read, never
+// updated or deleted.
+type AuditRepository interface {
+	Record(ctx context.Context, entry models.AuditLog) error
+	List(ctx context.Context, filter bson.M, limit, skip int64) ([]models.AuditLog, int64, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*models.AuditLog, error)
+}
+
+type MongoAuditRepository struct {
+	DB *mongo.Database
+}
+
+func NewAuditRepository(db *mongo.Database) AuditRepository {
+	return &MongoAuditRepository{DB: db}
+}
+
+func (r *MongoAuditRepository) Record(ctx context.Context, entry models.AuditLog) error {
+	entry.ID = primitive.NewObjectID()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.DB.Collection("auditLogs").InsertOne(ctx, entry)
+	return err
+}
+
+func (r *MongoAuditRepository) List(ctx context.Context, filter bson.M, limit, skip int64) ([]models.AuditLog, int64, error) {
+	collection := r.DB.Collection("auditLogs")
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditLog{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *MongoAuditRepository) Get(ctx context.Context, id primitive.ObjectID) (*models.AuditLog, error) {
+	var entry models.AuditLog
+	err := r.DB.Collection("auditLogs").FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &entry, err
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AutoDiscountRepository interface {
+	CreateAutoDiscount(ctx context.Context, discount models.AutoDiscount) (models.AutoDiscount, error)
+	ListAutoDiscounts(ctx context.Context) ([]models.AutoDiscount, error)
+	GetAutoDiscount(ctx context.Context, id primitive.ObjectID) (*models.AutoDiscount, error)
+	UpdateAutoDiscount(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.AutoDiscount, error)
+	DeleteAutoDiscount(ctx context.Context, id primitive.ObjectID) error
+	ListLiveAutoDiscounts(ctx context.Context, now time.Time) ([]models.AutoDiscount, error)
+	GetBuyerHistory(ctx context.Context, userID primitive.ObjectID) (models.BuyerHistory, error)
+	RecordRedemption(ctx context.Context, redemption models.AutoDiscountRedemption) error
+	GetReport(ctx context.Context, id primitive.ObjectID) (models.AutoDiscountReport, error)
+	ListRedemptions(ctx context.Context, id primitive.ObjectID, limit int64) ([]models.AutoDiscountRedemption, error)
+}
+
+var ErrAutoDiscountNotFound = errors.New("automatic discount not found")
+
+type MongoAutoDiscountRepository struct {
+	DB *mongo.Database
+}
+
+func NewAutoDiscountRepository(db *mongo.Database) AutoDiscountRepository {
+	return &MongoAutoDiscountRepository{DB: db}
+}
+
+func (r *MongoAutoDiscountRepository) CreateAutoDiscount(ctx context.Context, discount models.AutoDiscount) (models.AutoDiscount, error) {
+	discount.ID = primitive.NewObjectID()
+	discount.CreatedAt = time.Now()
+	discount.UpdatedAt = discount.CreatedAt
+
+	_, err := r.DB.Collection("autoDiscounts").InsertOne(ctx, discount)
+	return discount, err
+}
+
+func (r *MongoAutoDiscountRepository) ListAutoDiscounts(ctx context.Context) ([]models.AutoDiscount, error) {
+	return r.findAutoDiscounts(ctx, bson.M{})
+}
+
+func (r *MongoAutoDiscountRepository) GetAutoDiscount(ctx context.Context, id primitive.ObjectID) (*models.AutoDiscount, error) {
+	var discount models.AutoDiscount
+	err := r.DB.Collection("autoDiscounts").FindOne(ctx, bson.M{"_id": id}).Decode(&discount)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &discount, err
+}
+
+func (r *MongoAutoDiscountRepository) UpdateAutoDiscount(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.AutoDiscount, error) {
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var discount models.AutoDiscount
+	err := r.DB.Collection("autoDiscounts").FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": fields}, opts).Decode(&discount)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAutoDiscountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &discount, nil
+}
+
+func (r *MongoAutoDiscountRepository) DeleteAutoDiscount(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.DB.Collection("autoDiscounts").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrAutoDiscountNotFound
+	}
+	return nil
+}
+
+// ListLiveAutoDiscounts lists the active discounts whose window includes now.
+func (r *MongoAutoDiscountRepository) ListLiveAutoDiscounts(ctx context.Context, now time.Time) ([]models.AutoDiscount, error) {
+	return r.findAutoDiscounts(ctx, bson.M{
+		"active": true,
+		"$and": []bson.M{
+			{"$or": []bson.M{{"startsAt": bson.M{"$exists": false}}, {"startsAt": nil}, {"startsAt": bson.M{"$lte": now}}}},
+			{"$or": []bson.M{{"expiresAt": bson.M{"$exists": false}}, {"expiresAt": nil}, {"expiresAt": bson.M{"$gt": now}}}},
+		},
+	})
+}
+
+// GetBuyerHistory counts the buyer's orders, leaving out cancelled ones,
+// and finds when the latest was placed.
+func (r *MongoAutoDiscountRepository) GetBuyerHistory(ctx context.Context, userID primitive.ObjectID) (models.BuyerHistory, error) {
+	filter := bson.M{"userId": userID, "status": bson.M{"$ne": models.StatusCancelled}}
+	count, err := r.DB.Collection("orders").CountDocuments(ctx, filter)
+	if err != nil || count == 0 {
+		return models.BuyerHistory{}, err
+	}
+
+	var latest models.Order
+	opts := options.FindOne().SetSort(bson.M{"createdAt": -1}).SetProjection(bson.M{"createdAt": 1})
+	if err := r.DB.Collection("orders").FindOne(ctx, filter, opts).Decode(&latest); err != nil {
+		return models.BuyerHistory{}, err
+	}
+	return models.BuyerHistory{Orders: count, LastOrderAt: &latest.CreatedAt}, nil
+}
+
+// RecordRedemption logs an automatic discount taken off an order and adds
+// it to the discount's running totals.
+func (r *MongoAutoDiscountRepository) RecordRedemption(ctx context.Context, redemption models.AutoDiscountRedemption) error {
+	redemption.ID = primitive.NewObjectID()
+	redemption.CreatedAt = time.Now()
+	if _, err := r.DB.Collection("autoDiscountRedemptions").InsertOne(ctx, redemption); err != nil {
+		return err
+	}
+	_, err := r.DB.Collection("autoDiscounts").UpdateOne(ctx,
+		bson.M{"_id": redemption.AutoDiscountID},
+		bson.M{"$inc": bson.M{"redemptionCount": 1, "discountTotal": redemption.Discount}},
+	)
+	return err
+}
+
+// GetReport adds up every redemption of the discount.
+func (r *MongoAutoDiscountRepository) GetReport(ctx context.Context, id primitive.ObjectID) (models.AutoDiscountReport, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"autoDiscountId": id}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           nil,
+			"redemptions":   bson.M{"$sum": 1},
+			"buyers":        bson.M{"$addToSet": "$userId"},
+			"discountTotal": bson.M{"$sum": "$discount"},
+			"orderTotal":    bson.M{"$sum": "$orderTotal"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"redemptions":   1,
+			"buyers":        bson.M{"$size": "$buyers"},
+			"discountTotal": 1,
+			"orderTotal":    1,
+		}}},
+	}
+	cursor, err := r.DB.Collection("autoDiscountRedemptions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.AutoDiscountReport{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var report models.AutoDiscountReport
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&report); err != nil {
+			return models.AutoDiscountReport{}, err
+		}
+	}
+	return report, cursor.Err()
+}
+
+func (r *MongoAutoDiscountRepository) ListRedemptions(ctx context.Context, id primitive.ObjectID, limit int64) ([]models.AutoDiscountRedemption, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
+	cursor, err := r.DB.Collection("autoDiscountRedemptions").Find(ctx, bson.M{"autoDiscountId": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	redemptions := []models.AutoDiscountRedemption{}
+	if err := cursor.All(ctx, &redemptions); err != nil {
+		return nil, err
+	}
+	return redemptions, nil
+}
+
+func (r *MongoAutoDiscountRepository) findAutoDiscounts(ctx context.Context, filter bson.M) ([]models.AutoDiscount, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := r.DB.Collection("autoDiscounts").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	discounts := []models.AutoDiscount{}
+	if err := cursor.All(ctx, &discounts); err != nil {
+		return nil, err
+	}
+	return discounts, nil
+}
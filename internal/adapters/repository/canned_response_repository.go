@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CannedResponseRepository stores vendors' reply templates and their away
+// message.
+type CannedResponseRepository interface {
+	List(ctx context.Context, vendorID primitive.ObjectID) ([]models.CannedResponse, error)
+	Get(ctx context.Context, vendorID, id primitive.ObjectID) (*models.CannedResponse, error)
+	Count(ctx context.Context, vendorID primitive.ObjectID) (int64, error)
+	Create(ctx context.Context, response *models.CannedResponse) error
+	Update(ctx context.Context, vendorID, id primitive.ObjectID, input models.CannedResponseInput) (*models.CannedResponse, error)
+	Delete(ctx context.Context, vendorID, id primitive.ObjectID) error
+	IncrementUse(ctx context.Context, id primitive.ObjectID) error
+	GetAutoReply(ctx context.Context, vendorID primitive.ObjectID) (*models.AutoReply, error)
+	SaveAutoReply(ctx context.Context, reply models.AutoReply) error
+}
+
+var ErrCannedResponseNotFound = errors.New("canned response not found")
+
+type MongoCannedResponseRepository struct {
+	DB *mongo.Database
+}
+
+func NewCannedResponseRepository(db *mongo.Database) CannedResponseRepository {
+	return &MongoCannedResponseRepository{DB: db}
+}
+
+// List returns the vendor's templates, most used first.
+func (r *MongoCannedResponseRepository) List(ctx context.Context, vendorID primitive.ObjectID) ([]models.CannedResponse, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "useCount", Value: -1}, {Key: "title", Value: 1}})
+	cursor, err := r.DB.Collection("cannedResponses").Find(ctx, bson.M{"vendorId": vendorID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	responses := []models.CannedResponse{}
+	if err := cursor.All(ctx, &responses); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// Get returns one of the vendor's templates, or nil if they have no such
+// template.
+func (r *MongoCannedResponseRepository) Get(ctx context.Context, vendorID, id primitive.ObjectID) (*models.CannedResponse, error) {
+	var response models.CannedResponse
+	err := r.DB.Collection("cannedResponses").FindOne(ctx, bson.M{"_id": id, "vendorId": vendorID}).Decode(&response)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *MongoCannedResponseRepository) Count(ctx context.Context, vendorID primitive.ObjectID) (int64, error) {
+	return r.DB.Collection("cannedResponses").CountDocuments(ctx, bson.M{"vendorId": vendorID})
+}
+
+func (r *MongoCannedResponseRepository) Create(ctx context.Context, response *models.CannedResponse) error {
+	now := time.Now()
+	response.ID = primitive.NewObjectID()
+	response.UseCount = 0
+	response.CreatedAt = now
+	response.UpdatedAt = now
+	_, err := r.DB.Collection("cannedResponses").InsertOne(ctx, response)
+	return err
+}
+
+func (r *MongoCannedResponseRepository) Update(ctx context.Context, vendorID, id primitive.ObjectID, input models.CannedResponseInput) (*models.CannedResponse, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var response models.CannedResponse
+	err := r.DB.Collection("cannedResponses").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "vendorId": vendorID},
+		bson.M{"$set": bson.M{"title": input.Title, "body": input.Body, "updatedAt": time.Now()}},
+		opts,
+	).Decode(&response)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCannedResponseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *MongoCannedResponseRepository) Delete(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	result, err := r.DB.Collection("cannedResponses").DeleteOne(ctx, bson.M{"_id": id, "vendorId": vendorID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCannedResponseNotFound
+	}
+	return nil
+}
+
+// IncrementUse counts a template being sent, which orders the vendor's list.
+func (r *MongoCannedResponseRepository) IncrementUse(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DB.Collection("cannedResponses").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"useCount": 1}},
+	)
+	return err
+}
+
+// GetAutoReply returns the vendor's away message settings, or nil if they
+// never set one up.
+func (r *MongoCannedResponseRepository) GetAutoReply(ctx context.Context, vendorID primitive.ObjectID) (*models.AutoReply, error) {
+	var reply models.AutoReply
+	err := r.DB.Collection("autoReplies").FindOne(ctx, bson.M{"vendorId": vendorID}).Decode(&reply)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+func (r *MongoCannedResponseRepository) SaveAutoReply(ctx context.Context, reply models.AutoReply) error {
+	reply.UpdatedAt = time.Now()
+	_, err := r.DB.Collection("autoReplies").ReplaceOne(ctx,
+		bson.M{"vendorId": reply.VendorID},
+		reply,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
@@ -16,6 +16,7 @@ type CartRepository interface {
 	GetCart(ctx context.Context, userID primitive.ObjectID) (models.Cart, error)
 	UpdateQuantity(ctx context.Context, userID, productID primitive.ObjectID, quantity int) error
 	ClearCart(ctx context.Context, userID primitive.ObjectID) error
+	SetCoupon(ctx context.Context, userID primitive.ObjectID, code string) error
 }
 
 type MongoCartRepository struct {
@@ -61,6 +62,10 @@ func (r *MongoCartRepository) AddToCart(ctx context.Context, userID primitive.Ob
 			cart.Items[i].Name = item.Name
 			// We can also update Price if we want the cart to reflect latest price on re-add
 			// cart.Items[i].Price = item.Price
+			if item.FlashSaleID != nil {
+				cart.Items[i].FlashSaleID = item.FlashSaleID
+				cart.Items[i].Price = item.Price
+			}
 			found = true
 			break
 		}
@@ -124,7 +129,19 @@ func (r *MongoCartRepository) ClearCart(ctx context.Context, userID primitive.Ob
 			"items":     []models.CartItem{},
 			"updatedAt": time.Now(),
 		},
+		"$unset": bson.M{"couponCode": ""},
 	}
 	_, err := collection.UpdateOne(ctx, filter, update)
 	return err
 }
+
+// SetCoupon saves the promo code to apply at checkout, or removes it when
+// code is empty.
+func (r *MongoCartRepository) SetCoupon(ctx context.Context, userID primitive.ObjectID, code string) error {
+	update := bson.M{"$set": bson.M{"couponCode": code, "updatedAt": time.Now()}}
+	if code == "" {
+		update = bson.M{"$unset": bson.M{"couponCode": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	_, err := r.DB.Collection("carts").UpdateOne(ctx, bson.M{"userId": userID}, update)
+	return err
+}
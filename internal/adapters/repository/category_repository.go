@@ -0,0 +1,730 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CategoryRepository interface {
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error)
+	Create(ctx context.Context, category models.Category) (models.Category, error)
+	IsSlugAvailable(ctx context.Context, slug string, excludeID primitive.ObjectID) (bool, error)
+	GenerateUniqueSlug(ctx context.Context, name string, excludeID primitive.ObjectID) (string, error)
+	GetActiveBySlug(ctx context.Context, slug string) (*models.Category, error)
+	ListActiveChildren(ctx context.Context, parentID primitive.ObjectID) ([]models.Category, error)
+	ListActive(ctx context.Context) ([]models.Category, error)
+	List(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D) ([]models.Category, int64, error)
+	SetFeatured(ctx context.Context, id primitive.ObjectID, featured bool) (bool, error)
+	Reorder(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) error
+	GetLandingFacets(ctx context.Context, categoryIDs []primitive.ObjectID, childIDs []primitive.ObjectID, attributes []models.CategoryAttribute) (models.CategoryFacets, []models.Product, error)
+	SetAttributes(ctx context.Context, id primitive.ObjectID, attributes []models.CategoryAttribute) (bool, error)
+	SetMedia(ctx context.Context, id primitive.ObjectID, field, url string) (bool, error)
+	IsDescendant(ctx context.Context, id, ancestorID primitive.ObjectID) (bool, error)
+	SetActive(ctx context.Context, id primitive.ObjectID, active bool) (int64, error)
+	Delete(ctx context.Context, id primitive.ObjectID, opts models.CategoryDeleteOptions) (models.CategoryDeleteResult, error)
+	Merge(ctx context.Context, sourceID, targetID primitive.ObjectID) (int64, error)
+	Import(ctx context.Context, rows []models.CategoryImportRow) (models.CategoryImportResult, error)
+}
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrCategorySlugTaken   = errors.New("category slug is already taken")
+	ErrCategoryHasProducts = errors.New("category has products")
+	ErrTargetInSubtree     = errors.New("target category is being deleted")
+	ErrImportParentMissing = errors.New("parent category not found")
+	ErrImportCycle         = errors.New("import would put a category under its own subtree")
+)
+
+// categoryDisplaySort is the order categories are listed in: by sortOrder,
+// then name.
+var categoryDisplaySort = bson.D{{Key: "sortOrder", Value: 1}, {Key: "name", Value: 1}}
+
+// maxCategoryDepth bounds parent-chain walks so a corrupt cycle can't loop.
+const maxCategoryDepth = 16
+
+type MongoCategoryRepository struct {
+	DB *mongo.Database
+}
+
+func NewCategoryRepository(db *mongo.Database) CategoryRepository {
+	return &MongoCategoryRepository{DB: db}
+}
+
+func (r *MongoCategoryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+	var category models.Category
+	err := r.DB.Collection("categories").FindOne(ctx, bson.M{"_id": id}).Decode(&category)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &category, err
+}
+
+// Create inserts a category. A slug another category got first, past the
+// availability check, fails with ErrCategorySlugTaken.
+func (r *MongoCategoryRepository) Create(ctx context.Context, category models.Category) (models.Category, error) {
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+	_, err := r.DB.Collection("categories").InsertOne(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return category, ErrCategorySlugTaken
+	}
+	return category, err
+}
+
+// IsSlugAvailable reports whether no category other than excludeID uses
+// slug. Pass primitive.NilObjectID for a new category.
+func (r *MongoCategoryRepository) IsSlugAvailable(ctx context.Context, slug string, excludeID primitive.ObjectID) (bool, error) {
+	count, err := r.DB.Collection("categories").CountDocuments(ctx, bson.M{"slug": slug, "_id": bson.M{"$ne": excludeID}})
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// GenerateUniqueSlug derives a URL-safe slug from name, appending -2, -3, ...
+// until one is free.
+func (r *MongoCategoryRepository) GenerateUniqueSlug(ctx context.Context, name string, excludeID primitive.ObjectID) (string, error) {
+	base := utils.GenerateSlug(name)
+	if base == "" {
+		base = "category"
+	}
+
+	candidate := base
+	for i := 2; i <= maxSlugSuffixTries; i++ {
+		ok, err := r.IsSlugAvailable(ctx, candidate, excludeID)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return fmt.Sprintf("%s-%s", base, primitive.NewObjectID().Hex()[18:]), nil
+}
+
+// GetActiveBySlug returns the active category with the slug, or nil.
+func (r *MongoCategoryRepository) GetActiveBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+	err := r.DB.Collection("categories").FindOne(ctx, bson.M{"slug": slug, "isActive": true}).Decode(&category)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &category, err
+}
+
+// ListActiveChildren returns a category's active direct subcategories in
+// display order.
+func (r *MongoCategoryRepository) ListActiveChildren(ctx context.Context, parentID primitive.ObjectID) ([]models.Category, error) {
+	cursor, err := r.DB.Collection("categories").Find(ctx,
+		bson.M{"parentId": parentID, "isActive": true},
+		options.Find().SetSort(categoryDisplaySort),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	children := []models.Category{}
+	if err := cursor.All(ctx, &children); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// ListActive returns every active category, for building the hierarchy.
+func (r *MongoCategoryRepository) ListActive(ctx context.Context) ([]models.Category, error) {
+	cursor, err := r.DB.Collection("categories").Find(ctx, bson.M{"isActive": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// List returns a page of the categories matching filter and how many match
+// in all. A nil sort is display order; a limit of 0 returns them all.
+func (r *MongoCategoryRepository) List(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D) ([]models.Category, int64, error) {
+	collection := r.DB.Collection("categories")
+
+	if sort == nil {
+		sort = categoryDisplaySort
+	}
+	opts := options.Find().SetSort(sort).SetSkip(skip)
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return categories, total, nil
+}
+
+// SetFeatured marks a category as featured on the storefront or not. It
+// reports whether the category exists.
+func (r *MongoCategoryRepository) SetFeatured(ctx context.Context, id primitive.ObjectID, featured bool) (bool, error) {
+	res, err := r.DB.Collection("categories").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"isFeatured": featured, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// Reorder gives sibling categories sortOrder 1, 2, ... in the order of ids.
+// Every ID must be a category directly under parentID (nil for top-level),
+// else nothing changes and ErrCategoryNotFound is returned.
+func (r *MongoCategoryRepository) Reorder(ctx context.Context, parentID *primitive.ObjectID, ids []primitive.ObjectID) error {
+	collection := r.DB.Collection("categories")
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}, "parentId": parentID})
+	if err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrCategoryNotFound
+	}
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"sortOrder": i + 1, "updatedAt": now}})
+	}
+	_, err = collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// GetLandingFacets counts the active products filed under any of
+// categoryIDs by brand, sub-category, price and rating in one pass, and
+// returns the best-rated of them as featured products. childIDs are the
+// sub-categories to count, and attributes the filterable attributes whose
+// values to count.
+func (r *MongoCategoryRepository) GetLandingFacets(ctx context.Context, categoryIDs []primitive.ObjectID, childIDs []primitive.ObjectID, attributes []models.CategoryAttribute) (models.CategoryFacets, []models.Product, error) {
+	facets := models.CategoryFacets{
+		Brands:      []models.FacetCount{},
+		SubCategory: []models.FacetCount{},
+		Price:       []models.PriceRange{},
+		Rating:      []models.FacetCount{},
+		Attributes:  []models.AttributeFacet{},
+	}
+	featured := []models.Product{}
+
+	facetStages := bson.M{
+		"totals": bson.A{
+			bson.M{"$group": bson.M{
+				"_id":     nil,
+				"total":   bson.M{"$sum": 1},
+				"inStock": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$or": bson.A{bson.M{"$gt": bson.A{"$stock", 0}}, "$allowBackorder"}}, 1, 0}}},
+			}},
+		},
+		"brands": bson.A{
+			bson.M{"$match": bson.M{"brand": bson.M{"$nin": bson.A{"", nil}}}},
+			bson.M{"$group": bson.M{"_id": "$brand", "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": 50},
+		},
+		"subCategories": bson.A{
+			bson.M{"$project": bson.M{"ids": bson.M{"$setUnion": bson.A{
+				bson.A{"$categoryId"},
+				bson.M{"$ifNull": bson.A{"$subCategoryIds", bson.A{}}},
+			}}}},
+			bson.M{"$unwind": "$ids"},
+			bson.M{"$match": bson.M{"ids": bson.M{"$in": childIDs}}},
+			bson.M{"$group": bson.M{"_id": bson.M{"$toString": "$ids"}, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		},
+		"price": bson.A{
+			bson.M{"$bucketAuto": bson.M{
+				"groupBy": bson.M{"$cond": bson.A{
+					bson.M{"$and": bson.A{bson.M{"$gt": bson.A{"$salePrice", 0}}, bson.M{"$lt": bson.A{"$salePrice", "$price"}}}},
+					"$salePrice", "$price",
+				}},
+				"buckets": models.LandingPriceBuckets,
+			}},
+			bson.M{"$project": bson.M{"_id": 0, "min": "$_id.min", "max": "$_id.max", "count": 1}},
+		},
+		"rating": bson.A{
+			bson.M{"$match": bson.M{"reviewCount": bson.M{"$gt": 0}}},
+			bson.M{"$group": bson.M{"_id": bson.M{"$floor": "$rating"}, "count": bson.M{"$sum": 1}}},
+		},
+		"featured": bson.A{
+			bson.M{"$match": bson.M{"reviewCount": bson.M{"$gt": 0}}},
+			bson.M{"$sort": bson.D{{Key: "rating", Value: -1}, {Key: "reviewCount", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": models.LandingProductLimit},
+			bson.M{"$project": bson.M{"costPrice": 0}},
+		},
+	}
+
+	// Values of each filterable attribute, from metadata or variant options
+	for i, a := range attributes {
+		facetStages[fmt.Sprintf("attr%d", i)] = bson.A{
+			bson.M{"$project": bson.M{"values": bson.M{"$setUnion": bson.A{
+				bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$metadata." + a.Key, false}}, bson.A{"$metadata." + a.Key}, bson.A{}}},
+				bson.M{"$ifNull": bson.A{"$variants.options." + a.Key, bson.A{}}},
+			}}}},
+			bson.M{"$unwind": "$values"},
+			bson.M{"$match": bson.M{"values": bson.M{"$nin": bson.A{"", nil}}}},
+			bson.M{"$group": bson.M{"_id": "$values", "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": 50},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: NotDeleted(bson.M{
+			"status": models.ProductStatusActive,
+			"$or": bson.A{
+				bson.M{"categoryId": bson.M{"$in": categoryIDs}},
+				bson.M{"subCategoryIds": bson.M{"$in": categoryIDs}},
+			},
+		})}},
+		{{Key: "$facet", Value: facetStages}},
+	}
+	cursor, err := r.DB.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return facets, featured, err
+	}
+	defer cursor.Close(ctx)
+
+	var out []struct {
+		Totals []struct {
+			Total   int `bson:"total"`
+			InStock int `bson:"inStock"`
+		} `bson:"totals"`
+		Brands        []models.FacetCount `bson:"brands"`
+		SubCategories []models.FacetCount `bson:"subCategories"`
+		Price         []models.PriceRange `bson:"price"`
+		Rating        []struct {
+			Stars float64 `bson:"_id"`
+			Count int     `bson:"count"`
+		} `bson:"rating"`
+		Featured   []models.Product               `bson:"featured"`
+		Attributes map[string][]models.FacetCount `bson:",inline"` // attr0, attr1, ...
+	}
+	if err := cursor.All(ctx, &out); err != nil {
+		return facets, featured, err
+	}
+	if len(out) == 0 {
+		return facets, featured, nil
+	}
+	f := out[0]
+	if len(f.Totals) > 0 {
+		facets.Total, facets.InStock = f.Totals[0].Total, f.Totals[0].InStock
+	}
+	if f.Brands != nil {
+		facets.Brands = f.Brands
+	}
+	if f.SubCategories != nil {
+		facets.SubCategory = f.SubCategories
+	}
+	if f.Price != nil {
+		facets.Price = f.Price
+	}
+	byStars := map[int]int{}
+	for _, r := range f.Rating {
+		byStars[int(r.Stars)] += r.Count
+	}
+	facets.Rating = models.RatingAtLeast(byStars)
+	if f.Featured != nil {
+		featured = f.Featured
+	}
+	for i, a := range attributes {
+		values := f.Attributes[fmt.Sprintf("attr%d", i)]
+		if values == nil {
+			values = []models.FacetCount{}
+		}
+		facets.Attributes = append(facets.Attributes, models.AttributeFacet{Key: a.Key, Name: a.Name, Unit: a.Unit, Values: values})
+	}
+	return facets, featured, nil
+}
+
+// SetAttributes replaces a category's attribute schema. It reports whether
+// the category exists.
+func (r *MongoCategoryRepository) SetAttributes(ctx context.Context, id primitive.ObjectID, attributes []models.CategoryAttribute) (bool, error) {
+	res, err := r.DB.Collection("categories").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"attributes": attributes, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// SetMedia sets a category's image or icon URL (field is "image" or
+// "icon"), or removes it when url is empty. It reports whether the category
+// exists.
+func (r *MongoCategoryRepository) SetMedia(ctx context.Context, id primitive.ObjectID, field, url string) (bool, error) {
+	update := bson.M{"$set": bson.M{field: url, "updatedAt": time.Now()}}
+	if url == "" {
+		update = bson.M{"$unset": bson.M{field: ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	res, err := r.DB.Collection("categories").UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// IsDescendant reports whether id sits anywhere below ancestorID, or is
+// ancestorID itself. Used to stop a category being re-parented under its own
+// subtree.
+func (r *MongoCategoryRepository) IsDescendant(ctx context.Context, id, ancestorID primitive.ObjectID) (bool, error) {
+	current := id
+	for i := 0; i < maxCategoryDepth; i++ {
+		if current == ancestorID {
+			return true, nil
+		}
+		category, err := r.GetByID(ctx, current)
+		if err != nil {
+			return false, err
+		}
+		if category == nil || category.ParentID == nil {
+			return false, nil
+		}
+		current = *category.ParentID
+	}
+	return false, nil
+}
+
+// SetActive activates or deactivates a category. Deactivating also
+// deactivates its direct subcategories, which would otherwise be orphaned in
+// navigation. It returns the number of categories changed.
+func (r *MongoCategoryRepository) SetActive(ctx context.Context, id primitive.ObjectID, active bool) (int64, error) {
+	collection := r.DB.Collection("categories")
+	set := bson.M{"$set": bson.M{"isActive": active, "updatedAt": time.Now()}}
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": id}, set)
+	if err != nil {
+		return 0, err
+	}
+	if res.MatchedCount == 0 {
+		return 0, ErrCategoryNotFound
+	}
+	changed := res.ModifiedCount
+
+	if !active {
+		children, err := collection.UpdateMany(ctx, bson.M{"parentId": id, "isActive": true}, set)
+		if err != nil {
+			return changed, err
+		}
+		changed += children.ModifiedCount
+	}
+	return changed, nil
+}
+
+// moveProducts points every product referencing fromID, either as its main
+// category or as a subcategory, at toID instead.
+func (r *MongoCategoryRepository) moveProducts(ctx context.Context, fromID, toID primitive.ObjectID) (int64, error) {
+	products := r.DB.Collection("products")
+	now := time.Now()
+
+	main, err := products.UpdateMany(ctx,
+		bson.M{"categoryId": fromID},
+		bson.M{"$set": bson.M{"categoryId": toID, "updatedAt": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Two steps: $addToSet and $pull can't touch the same array in one update
+	if _, err := products.UpdateMany(ctx,
+		bson.M{"subCategoryIds": fromID},
+		bson.M{"$addToSet": bson.M{"subCategoryIds": toID}},
+	); err != nil {
+		return 0, err
+	}
+	sub, err := products.UpdateMany(ctx,
+		bson.M{"subCategoryIds": fromID},
+		bson.M{"$pull": bson.M{"subCategoryIds": fromID}, "$set": bson.M{"updatedAt": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return main.ModifiedCount + sub.ModifiedCount, nil
+}
+
+// Delete removes a category in one transaction, handling its
+// sub-categories as opts.Subcategories says and moving the products filed
+// under anything deleted to opts.ReassignTo.
+func (r *MongoCategoryRepository) Delete(ctx context.Context, id primitive.ObjectID, opts models.CategoryDeleteOptions) (models.CategoryDeleteResult, error) {
+	var result models.CategoryDeleteResult
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return result, fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		result = models.CategoryDeleteResult{}
+		categories := r.DB.Collection("categories")
+
+		var category models.Category
+		if err := categories.FindOne(sessCtx, bson.M{"_id": id}).Decode(&category); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, err
+		}
+
+		// 1. What gets deleted: the category, and its subtree if asked
+		ids := []primitive.ObjectID{id}
+		children, err := categories.CountDocuments(sessCtx, bson.M{"parentId": id})
+		if err != nil {
+			return nil, err
+		}
+		if children > 0 {
+			switch opts.Subcategories {
+			case models.SubcategoriesReparent:
+			case models.SubcategoriesDelete:
+				level := []primitive.ObjectID{id}
+				for depth := 0; depth < maxCategoryDepth && len(level) > 0; depth++ {
+					cursor, err := categories.Find(sessCtx, bson.M{"parentId": bson.M{"$in": level}}, options.Find().SetProjection(bson.M{"_id": 1}))
+					if err != nil {
+						return nil, err
+					}
+					var found []struct {
+						ID primitive.ObjectID `bson:"_id"`
+					}
+					if err := cursor.All(sessCtx, &found); err != nil {
+						return nil, err
+					}
+					level = level[:0]
+					for _, f := range found {
+						level = append(level, f.ID)
+					}
+					ids = append(ids, level...)
+				}
+			default:
+				return nil, ErrCategoryHasChildren
+			}
+		}
+
+		// 2. Move products out of everything being deleted
+		if opts.ReassignTo != nil {
+			for _, deleted := range ids {
+				if deleted == *opts.ReassignTo {
+					return nil, ErrTargetInSubtree
+				}
+			}
+			for _, deleted := range ids {
+				moved, err := r.moveProducts(sessCtx, deleted, *opts.ReassignTo)
+				if err != nil {
+					return nil, err
+				}
+				result.ReassignedProducts += moved
+			}
+		} else {
+			products, err := r.DB.Collection("products").CountDocuments(sessCtx, bson.M{"$or": bson.A{
+				bson.M{"categoryId": bson.M{"$in": ids}},
+				bson.M{"subCategoryIds": bson.M{"$in": ids}},
+			}})
+			if err != nil {
+				return nil, err
+			}
+			if products > 0 {
+				return nil, ErrCategoryHasProducts
+			}
+		}
+
+		// 3. Move direct sub-categories up a level
+		if children > 0 && opts.Subcategories == models.SubcategoriesReparent {
+			update := bson.M{"$set": bson.M{"parentId": category.ParentID, "updatedAt": time.Now()}}
+			if category.ParentID == nil {
+				update = bson.M{"$unset": bson.M{"parentId": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+			}
+			res, err := categories.UpdateMany(sessCtx, bson.M{"parentId": id}, update)
+			if err != nil {
+				return nil, err
+			}
+			result.ReparentedCategories = res.ModifiedCount
+		}
+
+		// 4. Delete
+		res, err := categories.DeleteMany(sessCtx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedCategories = int(res.DeletedCount)
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(ctx, callback)
+	return result, err
+}
+
+// Merge folds sourceID into targetID: products and subcategories move across
+// and the source category is deleted.
+func (r *MongoCategoryRepository) Merge(ctx context.Context, sourceID, targetID primitive.ObjectID) (int64, error) {
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	var moved int64
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		// 1. Re-parent subcategories
+		if _, err := r.DB.Collection("categories").UpdateMany(sessCtx,
+			bson.M{"parentId": sourceID},
+			bson.M{"$set": bson.M{"parentId": targetID, "updatedAt": time.Now()}},
+		); err != nil {
+			return nil, err
+		}
+
+		// 2. Move products
+		var err error
+		if moved, err = r.moveProducts(sessCtx, sourceID, targetID); err != nil {
+			return nil, err
+		}
+
+		// 3. Remove the source
+		res, err := r.DB.Collection("categories").DeleteOne(sessCtx, bson.M{"_id": sourceID})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, nil
+	}
+
+	_, err = session.WithTransaction(ctx, callback)
+	return moved, err
+}
+
+// Import creates or updates categories by slug, so running the same import
+// twice changes nothing the second time. rows must list parents before their
+// children (see models.OrderCategoryImport). Blank descriptions and zero sort
+// orders leave existing values alone. Categories are written one by one: if
+// the import fails part way, fix the cause and run it again.
+func (r *MongoCategoryRepository) Import(ctx context.Context, rows []models.CategoryImportRow) (models.CategoryImportResult, error) {
+	var result models.CategoryImportResult
+	collection := r.DB.Collection("categories")
+	ids := map[string]primitive.ObjectID{}
+
+	bySlug := func(slug string) (*models.Category, error) {
+		var category models.Category
+		err := collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&category)
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return &category, err
+	}
+
+	for _, row := range rows {
+		// 1. The parent, from this import or already in the catalog
+		var parentID *primitive.ObjectID
+		if row.ParentSlug != "" {
+			id, ok := ids[row.ParentSlug]
+			if !ok {
+				parent, err := bySlug(row.ParentSlug)
+				if err != nil {
+					return result, err
+				}
+				if parent == nil {
+					return result, fmt.Errorf("%w: %q (parent of %q)", ErrImportParentMissing, row.ParentSlug, row.Slug)
+				}
+				id = parent.ID
+			}
+			parentID = &id
+		}
+
+		existing, err := bySlug(row.Slug)
+		if err != nil {
+			return result, err
+		}
+		now := time.Now()
+
+		// 2. New categories
+		if existing == nil {
+			category := models.Category{
+				ID:          primitive.NewObjectID(),
+				Name:        row.Name,
+				Slug:        row.Slug,
+				Description: row.Description,
+				ParentID:    parentID,
+				SortOrder:   row.SortOrder,
+				IsActive:    true,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			if _, err := collection.InsertOne(ctx, category); err != nil {
+				return result, err
+			}
+			ids[row.Slug] = category.ID
+			result.Created++
+			continue
+		}
+
+		// 3. Existing ones, if anything differs
+		ids[row.Slug] = existing.ID
+		set := bson.M{}
+		if existing.Name != row.Name {
+			set["name"] = row.Name
+		}
+		if row.Description != "" && existing.Description != row.Description {
+			set["description"] = row.Description
+		}
+		if row.SortOrder != 0 && existing.SortOrder != row.SortOrder {
+			set["sortOrder"] = row.SortOrder
+		}
+		update := bson.M{}
+		switch {
+		case parentID == nil && existing.ParentID != nil:
+			update["$unset"] = bson.M{"parentId": ""}
+		case parentID != nil && (existing.ParentID == nil || *existing.ParentID != *parentID):
+			cyclic, err := r.IsDescendant(ctx, *parentID, existing.ID)
+			if err != nil {
+				return result, err
+			}
+			if cyclic {
+				return result, fmt.Errorf("%w: %q", ErrImportCycle, row.Slug)
+			}
+			set["parentId"] = *parentID
+		}
+		if len(set) == 0 && len(update) == 0 {
+			result.Unchanged++
+			continue
+		}
+		set["updatedAt"] = now
+		update["$set"] = set
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": existing.ID}, update); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+	return result, nil
+}
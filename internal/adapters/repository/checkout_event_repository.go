@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CheckoutEventRepository records buyers' progress through checkout for
+// the funnel report.
+type CheckoutEventRepository interface {
+	Record(ctx context.Context, event models.CheckoutEvent) error
+}
+
+type MongoCheckoutEventRepository struct {
+	DB *mongo.Database
+}
+
+func NewCheckoutEventRepository(db *mongo.Database) CheckoutEventRepository {
+	return &MongoCheckoutEventRepository{DB: db}
+}
+
+// Record counts event in the buyer's row for its step, provider and day.
+func (r *MongoCheckoutEventRepository) Record(ctx context.Context, event models.CheckoutEvent) error {
+	now := time.Now()
+	set := bson.M{"lastAt": now}
+	if !event.OrderID.IsZero() {
+		set["orderId"] = event.OrderID
+	}
+	_, err := r.DB.Collection("checkoutEvents").UpdateOne(ctx,
+		bson.M{
+			"userId":   event.UserID,
+			"day":      models.BucketStart(now, models.GranularityDay),
+			"step":     event.Step,
+			"provider": event.Provider,
+		},
+		bson.M{
+			"$set":         set,
+			"$setOnInsert": bson.M{"stage": models.CheckoutStage(event.Step), "firstAt": now},
+			"$inc":         bson.M{"count": 1},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
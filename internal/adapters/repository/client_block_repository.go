@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClientBlockRepository stores the clients admins have blocked.
+type ClientBlockRepository interface {
+	ListActive(ctx context.Context) ([]models.ClientBlock, error)
+	Block(ctx context.Context, block *models.ClientBlock) error
+	Unblock(ctx context.Context, key string) (*models.ClientBlock, error)
+}
+
+var ErrClientBlockNotFound = errors.New("client is not blocked")
+
+type MongoClientBlockRepository struct {
+	DB *mongo.Database
+}
+
+func NewClientBlockRepository(db *mongo.Database) ClientBlockRepository {
+	return &MongoClientBlockRepository{DB: db}
+}
+
+// ListActive returns the blocks in force now, newest first.
+func (r *MongoClientBlockRepository) ListActive(ctx context.Context) ([]models.ClientBlock, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"expiresAt": nil}, // Matches missing and null
+		bson.M{"expiresAt": bson.M{"$gt": time.Now()}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := r.DB.Collection("clientBlocks").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	blocks := []models.ClientBlock{}
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// Block blocks the client, replacing any earlier block on it.
+func (r *MongoClientBlockRepository) Block(ctx context.Context, block *models.ClientBlock) error {
+	block.CreatedAt = time.Now()
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+	return r.DB.Collection("clientBlocks").FindOneAndReplace(ctx,
+		bson.M{"key": block.Key},
+		bson.M{
+			"key":       block.Key,
+			"reason":    block.Reason,
+			"blockedBy": block.BlockedBy,
+			"expiresAt": block.ExpiresAt,
+			"createdAt": block.CreatedAt,
+		},
+		opts,
+	).Decode(block)
+}
+
+// Unblock lifts the block on the client, returning it.
+func (r *MongoClientBlockRepository) Unblock(ctx context.Context, key string) (*models.ClientBlock, error) {
+	var block models.ClientBlock
+	err := r.DB.Collection("clientBlocks").FindOneAndDelete(ctx, bson.M{"key": key}).Decode(&block)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrClientBlockNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
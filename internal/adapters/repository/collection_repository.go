@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CollectionRepository interface {
+	CreateCollection(ctx context.Context, collection models.StoreCollection) (models.StoreCollection, error)
+	GetVendorCollections(ctx context.Context, vendorID primitive.ObjectID, visibleOnly bool) ([]models.StoreCollection, error)
+	GetCollection(ctx context.Context, vendorID, id primitive.ObjectID) (*models.StoreCollection, error)
+	GetCollectionBySlug(ctx context.Context, vendorID primitive.ObjectID, slug string) (*models.StoreCollection, error)
+	UpdateCollection(ctx context.Context, vendorID, id primitive.ObjectID, fields bson.M) (*models.StoreCollection, error)
+	DeleteCollection(ctx context.Context, vendorID, id primitive.ObjectID) error
+	GenerateUniqueSlug(ctx context.Context, vendorID primitive.ObjectID, name string) (string, error)
+}
+
+var ErrCollectionNotFound = errors.New("collection not found")
+
+type MongoCollectionRepository struct {
+	DB *mongo.Database
+}
+
+func NewCollectionRepository(db *mongo.Database) CollectionRepository {
+	return &MongoCollectionRepository{DB: db}
+}
+
+func (r *MongoCollectionRepository) CreateCollection(ctx context.Context, collection models.StoreCollection) (models.StoreCollection, error) {
+	collection.ID = primitive.NewObjectID()
+	collection.CreatedAt = time.Now()
+	collection.UpdatedAt = collection.CreatedAt
+	if collection.ProductIDs == nil {
+		collection.ProductIDs = []primitive.ObjectID{}
+	}
+
+	_, err := r.DB.Collection("storeCollections").InsertOne(ctx, collection)
+	return collection, err
+}
+
+func (r *MongoCollectionRepository) GetVendorCollections(ctx context.Context, vendorID primitive.ObjectID, visibleOnly bool) ([]models.StoreCollection, error) {
+	filter := bson.M{"vendorID": vendorID}
+	if visibleOnly {
+		filter["visible"] = true
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "createdAt", Value: 1}})
+	cursor, err := r.DB.Collection("storeCollections").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	collections := []models.StoreCollection{}
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r *MongoCollectionRepository) GetCollection(ctx context.Context, vendorID, id primitive.ObjectID) (*models.StoreCollection, error) {
+	var collection models.StoreCollection
+	err := r.DB.Collection("storeCollections").FindOne(ctx, bson.M{"_id": id, "vendorID": vendorID}).Decode(&collection)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &collection, err
+}
+
+func (r *MongoCollectionRepository) GetCollectionBySlug(ctx context.Context, vendorID primitive.ObjectID, slug string) (*models.StoreCollection, error) {
+	var collection models.StoreCollection
+	err := r.DB.Collection("storeCollections").FindOne(ctx, bson.M{"vendorID": vendorID, "slug": slug}).Decode(&collection)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &collection, err
+}
+
+func (r *MongoCollectionRepository) UpdateCollection(ctx context.Context, vendorID, id primitive.ObjectID, fields bson.M) (*models.StoreCollection, error) {
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var collection models.StoreCollection
+	err := r.DB.Collection("storeCollections").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "vendorID": vendorID},
+		bson.M{"$set": fields},
+		opts,
+	).Decode(&collection)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *MongoCollectionRepository) DeleteCollection(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	res, err := r.DB.Collection("storeCollections").DeleteOne(ctx, bson.M{"_id": id, "vendorID": vendorID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// GenerateUniqueSlug derives a slug from name that is unused among the
+// vendor's collections, appending -2, -3, ... as needed.
+func (r *MongoCollectionRepository) GenerateUniqueSlug(ctx context.Context, vendorID primitive.ObjectID, name string) (string, error) {
+	base := utils.GenerateSlug(name)
+	if base == "" {
+		base = "collection"
+	}
+
+	candidate := base
+	for i := 2; i <= maxSlugSuffixTries; i++ {
+		count, err := r.DB.Collection("storeCollections").CountDocuments(ctx, bson.M{"vendorID": vendorID, "slug": candidate})
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return fmt.Sprintf("%s-%s", base, primitive.NewObjectID().Hex()[18:]), nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommissionRepository interface {
+	Create(ctx context.Context, commission models.CategoryCommission) (models.CategoryCommission, error)
+	ListForCategory(ctx context.Context, categoryID primitive.ObjectID) ([]models.CategoryCommission, error)
+	ListForCategories(ctx context.Context, categoryIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.CategoryCommission, error)
+}
+
+type MongoCommissionRepository struct {
+	DB *mongo.Database
+}
+
+func NewCommissionRepository(db *mongo.Database) CommissionRepository {
+	return &MongoCommissionRepository{DB: db}
+}
+
+func (r *MongoCommissionRepository) Create(ctx context.Context, commission models.CategoryCommission) (models.CategoryCommission, error) {
+	if commission.ID.IsZero() {
+		commission.ID = primitive.NewObjectID()
+	}
+	_, err := r.DB.Collection("categoryCommissions").InsertOne(ctx, commission)
+	return commission, err
+}
+
+// ListForCategory returns a category's commission history, latest effective
+// date first.
+func (r *MongoCommissionRepository) ListForCategory(ctx context.Context, categoryID primitive.ObjectID) ([]models.CategoryCommission, error) {
+	cursor, err := r.DB.Collection("categoryCommissions").Find(ctx,
+		bson.M{"categoryId": categoryID},
+		options.Find().SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	history := []models.CategoryCommission{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ListForCategories returns the commission histories of several categories,
+// keyed by category. Categories without one are left out.
+func (r *MongoCommissionRepository) ListForCategories(ctx context.Context, categoryIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.CategoryCommission, error) {
+	out := map[primitive.ObjectID][]models.CategoryCommission{}
+	if len(categoryIDs) == 0 {
+		return out, nil
+	}
+	cursor, err := r.DB.Collection("categoryCommissions").Find(ctx, bson.M{"categoryId": bson.M{"$in": categoryIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var all []models.CategoryCommission
+	if err := cursor.All(ctx, &all); err != nil {
+		return nil, err
+	}
+	for _, c := range all {
+		out[c.CategoryID] = append(out[c.CategoryID], c)
+	}
+	return out, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CouponRepository interface {
+	CreateCoupon(ctx context.Context, coupon models.Coupon) (models.Coupon, error)
+	ListCoupons(ctx context.Context, filter bson.M) ([]models.Coupon, error)
+	GetCoupon(ctx context.Context, filter bson.M) (*models.Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error)
+	UpdateCoupon(ctx context.Context, filter bson.M, fields bson.M) (*models.Coupon, error)
+	DeleteCoupon(ctx context.Context, filter bson.M) error
+	ReserveCoupon(ctx context.Context, id primitive.ObjectID) error
+	ReleaseCoupon(ctx context.Context, id primitive.ObjectID) error
+	CountUserRedemptions(ctx context.Context, couponID, userID primitive.ObjectID) (int64, error)
+	RecordRedemption(ctx context.Context, redemption models.CouponRedemption) error
+	ListRedemptions(ctx context.Context, couponID primitive.ObjectID, limit int64) ([]models.CouponRedemption, error)
+}
+
+var (
+	ErrCouponNotFound   = errors.New("coupon not found")
+	ErrCouponCodeExists = errors.New("a coupon with that code already exists")
+)
+
+type MongoCouponRepository struct {
+	DB *mongo.Database
+}
+
+func NewCouponRepository(db *mongo.Database) CouponRepository {
+	return &MongoCouponRepository{DB: db}
+}
+
+func (r *MongoCouponRepository) CreateCoupon(ctx context.Context, coupon models.Coupon) (models.Coupon, error) {
+	coupon.ID = primitive.NewObjectID()
+	coupon.CreatedAt = time.Now()
+	coupon.UpdatedAt = coupon.CreatedAt
+
+	_, err := r.DB.Collection("coupons").InsertOne(ctx, coupon)
+	if mongo.IsDuplicateKeyError(err) {
+		return coupon, ErrCouponCodeExists
+	}
+	return coupon, err
+}
+
+func (r *MongoCouponRepository) ListCoupons(ctx context.Context, filter bson.M) ([]models.Coupon, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := r.DB.Collection("coupons").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	coupons := []models.Coupon{}
+	if err := cursor.All(ctx, &coupons); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (r *MongoCouponRepository) GetCoupon(ctx context.Context, filter bson.M) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.DB.Collection("coupons").FindOne(ctx, filter).Decode(&coupon)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &coupon, err
+}
+
+func (r *MongoCouponRepository) GetCouponByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	return r.GetCoupon(ctx, bson.M{"code": models.NormalizeCouponCode(code)})
+}
+
+func (r *MongoCouponRepository) UpdateCoupon(ctx context.Context, filter bson.M, fields bson.M) (*models.Coupon, error) {
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var coupon models.Coupon
+	err := r.DB.Collection("coupons").FindOneAndUpdate(ctx, filter, bson.M{"$set": fields}, opts).Decode(&coupon)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCouponNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrCouponCodeExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *MongoCouponRepository) DeleteCoupon(ctx context.Context, filter bson.M) error {
+	res, err := r.DB.Collection("coupons").DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+// ReserveCoupon counts a use of the coupon if it has any left, returning
+// models.ErrCouponUsedUp if not. The check and count are one update, so a
+// rush of orders can't overspend it.
+func (r *MongoCouponRepository) ReserveCoupon(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.DB.Collection("coupons").UpdateOne(ctx,
+		bson.M{
+			"_id": id,
+			"$or": []bson.M{
+				{"usageLimit": bson.M{"$exists": false}},
+				{"usageLimit": 0},
+				{"$expr": bson.M{"$lt": []string{"$usedCount", "$usageLimit"}}},
+			},
+		},
+		bson.M{"$inc": bson.M{"usedCount": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return models.ErrCouponUsedUp
+	}
+	return nil
+}
+
+// ReleaseCoupon gives back a use reserved for an order that wasn't placed.
+func (r *MongoCouponRepository) ReleaseCoupon(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DB.Collection("coupons").UpdateOne(ctx,
+		bson.M{"_id": id, "usedCount": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"usedCount": -1}},
+	)
+	return err
+}
+
+func (r *MongoCouponRepository) CountUserRedemptions(ctx context.Context, couponID, userID primitive.ObjectID) (int64, error) {
+	return r.DB.Collection("couponRedemptions").CountDocuments(ctx, bson.M{"couponId": couponID, "userId": userID})
+}
+
+func (r *MongoCouponRepository) RecordRedemption(ctx context.Context, redemption models.CouponRedemption) error {
+	redemption.ID = primitive.NewObjectID()
+	redemption.CreatedAt = time.Now()
+	_, err := r.DB.Collection("couponRedemptions").InsertOne(ctx, redemption)
+	return err
+}
+
+func (r *MongoCouponRepository) ListRedemptions(ctx context.Context, couponID primitive.ObjectID, limit int64) ([]models.CouponRedemption, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
+	cursor, err := r.DB.Collection("couponRedemptions").Find(ctx, bson.M{"couponId": couponID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	redemptions := []models.CouponRedemption{}
+	if err := cursor.All(ctx, &redemptions); err != nil {
+		return nil, err
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DashboardRepository interface {
+	GetAdminDashboard(ctx context.Context, from, to time.Time, topCategories int) (models.AdminDashboard, error)
+}
+
+type MongoDashboardRepository struct {
+	DB *mongo.Database
+}
+
+func NewDashboardRepository(db *mongo.Database) DashboardRepository {
+	return &MongoDashboardRepository{DB: db}
+}
+
+// GetAdminDashboard computes the platform dashboard for orders, users and
+// vendors created in [from, to).
+func (r *MongoDashboardRepository) GetAdminDashboard(ctx context.Context, from, to time.Time, topCategories int) (models.AdminDashboard, error) {
+	dash := models.AdminDashboard{
+		From:           from,
+		To:             to,
+		OrdersByStatus: map[string]int64{},
+		TopCategories:  []models.CategorySales{},
+		GeneratedAt:    time.Now(),
+	}
+	window := bson.M{"$gte": from, "$lt": to}
+
+	// 1. Orders: GMV and status breakdown in one pass
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": window}}},
+		{{Key: "$facet", Value: bson.M{
+			"byStatus": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"gmv": bson.A{
+				bson.M{"$match": bson.M{"status": bson.M{"$in": models.PaidOrderStatuses}}},
+				bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$total"}, "count": bson.M{"$sum": 1}}},
+			},
+		}}},
+	}
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return dash, err
+	}
+	var facets []struct {
+		ByStatus []struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		} `bson:"byStatus"`
+		GMV []struct {
+			Total models.Money `bson:"total"`
+			Count int64        `bson:"count"`
+		} `bson:"gmv"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return dash, err
+	}
+	if len(facets) > 0 {
+		f := facets[0]
+		for _, s := range f.ByStatus {
+			dash.OrdersByStatus[s.Status] = s.Count
+			dash.OrderCount += s.Count
+		}
+		if len(f.GMV) > 0 {
+			dash.GMV = f.GMV[0].Total
+			if f.GMV[0].Count > 0 {
+				dash.AverageOrderValue = f.GMV[0].Total / models.Money(f.GMV[0].Count)
+			}
+		}
+	}
+
+	// Refunds issued in the window, partial ones included, whenever the
+	// order was placed; as the platform analytics count them
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"refunds.createdAt": window}}},
+		{{Key: "$unwind", Value: "$refunds"}},
+		{{Key: "$match", Value: bson.M{"refunds.createdAt": window, "refunds.status": models.RefundStatusSucceeded}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "volume": bson.M{"$sum": "$refunds.amount"}, "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err = r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return dash, err
+	}
+	var refunds []struct {
+		Volume models.Money `bson:"volume"`
+		Count  int64        `bson:"count"`
+	}
+	if err := cursor.All(ctx, &refunds); err != nil {
+		return dash, err
+	}
+	if len(refunds) > 0 {
+		dash.RefundVolume, dash.RefundCount = refunds[0].Volume, refunds[0].Count
+	}
+
+	// 2. Growth and backlog counts
+	if dash.NewUsers, err = r.DB.Collection("users").CountDocuments(ctx, bson.M{
+		"role":      bson.M{"$ne": "admin"},
+		"createdAt": window,
+	}); err != nil {
+		return dash, err
+	}
+	if dash.NewVendors, err = r.DB.Collection("vendorAccounts").CountDocuments(ctx, bson.M{"activatedAt": window}); err != nil {
+		return dash, err
+	}
+	if dash.PendingApplications, err = r.DB.Collection("sellerApplications").CountDocuments(ctx, bson.M{
+		"status": bson.M{"$in": []string{"pending", "under_review"}},
+	}); err != nil {
+		return dash, err
+	}
+
+	// 3. Top categories by paid item revenue
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": window, "status": bson.M{"$in": models.PaidOrderStatuses}}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "items.productId",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$product.categoryId",
+			"revenue":   bson.M{"$sum": "$items.subtotal"},
+			"unitsSold": bson.M{"$sum": "$items.quantity"},
+			"orders":    bson.M{"$addToSet": "$_id"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"revenue": -1}}},
+		{{Key: "$limit", Value: topCategories}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "categories",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        bson.M{"$toString": "$_id"},
+			"name":       bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$category.name", 0}}, "Uncategorized"}},
+			"revenue":    1,
+			"unitsSold":  1,
+			"orderCount": bson.M{"$size": "$orders"},
+		}}},
+	}
+	cursor, err = r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return dash, err
+	}
+	if err := cursor.All(ctx, &dash.TopCategories); err != nil {
+		return dash, err
+	}
+
+	return dash, nil
+}
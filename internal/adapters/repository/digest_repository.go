@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DigestRepository gathers what goes into the weekly digest emails.
+type DigestRepository interface {
+	// ClaimDigest marks the user's digest for an audience as sent unless one
+	// went out after cutoff, so that only one server instance sends it.
+	ClaimDigest(ctx context.Context, userID primitive.ObjectID, audience string, cutoff time.Time) (bool, error)
+	ListDigestVendorIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	GetVendorSalesSummary(ctx context.Context, vendorID primitive.ObjectID, since time.Time, topProducts int) (models.VendorSalesSummary, error)
+	GetLowStockProducts(ctx context.Context, vendorID primitive.ObjectID, limit int64) ([]models.Product, error)
+	ForEachDigestWishlist(ctx context.Context, fn func(models.DigestWishlist) error) error
+	GetRecommendations(ctx context.Context, categoryIDs, exclude []primitive.ObjectID, limit int64) ([]models.Product, error)
+	SaveWishlistPriceSnapshot(ctx context.Context, userID primitive.ObjectID, prices map[string]models.Money) error
+}
+
+type MongoDigestRepository struct {
+	DB *mongo.Database
+}
+
+func NewDigestRepository(db *mongo.Database) DigestRepository {
+	return &MongoDigestRepository{DB: db}
+}
+
+func (r *MongoDigestRepository) ClaimDigest(ctx context.Context, userID primitive.ObjectID, audience string, cutoff time.Time) (bool, error) {
+	field := "digestSentAt." + audience
+	res, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{
+		"_id": userID,
+		"$or": bson.A{
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{field: bson.M{"$lt": cutoff}},
+		},
+	}, bson.M{"$set": bson.M{field: time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// ListDigestVendorIDs returns approved vendors whose accounts aren't banned
+// or suspended.
+func (r *MongoDigestRepository) ListDigestVendorIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	filter := bson.M{
+		"role":          "vendor",
+		"vendorStatus":  "approved",
+		"accountStatus": bson.M{"$nin": bson.A{models.AccountStatusBanned, models.AccountStatusSuspended}},
+	}
+	cursor, err := r.DB.Collection("users").Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids, nil
+}
+
+// GetVendorSalesSummary totals the vendor's lines on paid orders placed
+// since the given time, with their best sellers by revenue.
+func (r *MongoDigestRepository) GetVendorSalesSummary(ctx context.Context, vendorID primitive.ObjectID, since time.Time, topProducts int) (models.VendorSalesSummary, error) {
+	summary := models.VendorSalesSummary{TopProducts: []models.VendorProductSales{}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"items.vendorId": vendorID,
+			"status":         bson.M{"$in": models.PaidOrderStatuses},
+			"createdAt":      bson.M{"$gte": since},
+		}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$match", Value: bson.M{"items.vendorId": vendorID}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":       nil,
+					"revenue":   bson.M{"$sum": "$items.subtotal"},
+					"unitsSold": bson.M{"$sum": "$items.quantity"},
+					"orders":    bson.M{"$addToSet": "$_id"},
+				}},
+				bson.M{"$project": bson.M{"revenue": 1, "unitsSold": 1, "orderCount": bson.M{"$size": "$orders"}}},
+			},
+			"topProducts": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":       "$items.productId",
+					"name":      bson.M{"$first": "$items.name"},
+					"unitsSold": bson.M{"$sum": "$items.quantity"},
+					"revenue":   bson.M{"$sum": "$items.subtotal"},
+				}},
+				bson.M{"$sort": bson.M{"revenue": -1}},
+				bson.M{"$limit": topProducts},
+			},
+		}}},
+	}
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return summary, err
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Totals      []models.VendorSalesSummary `bson:"totals"`
+		TopProducts []models.VendorProductSales `bson:"topProducts"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return summary, err
+	}
+	if len(facets) > 0 {
+		if len(facets[0].Totals) > 0 {
+			summary = facets[0].Totals[0]
+		}
+		summary.TopProducts = facets[0].TopProducts
+		if summary.TopProducts == nil {
+			summary.TopProducts = []models.VendorProductSales{}
+		}
+	}
+	return summary, nil
+}
+
+// GetLowStockProducts returns the vendor's active products at or below their
+// low-stock threshold, emptiest first.
+func (r *MongoDigestRepository) GetLowStockProducts(ctx context.Context, vendorID primitive.ObjectID, limit int64) ([]models.Product, error) {
+	filter := bson.M{
+		"vendorId": vendorID,
+		"status":   models.ProductStatusActive,
+		"$expr":    bson.M{"$lte": bson.A{"$stock", "$lowStockThreshold"}},
+	}
+	opts := options.Find().SetSort(bson.M{"stock": 1}).SetLimit(limit)
+	cursor, err := r.DB.Collection("products").Find(ctx, NotDeleted(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// ForEachDigestWishlist streams every non-empty wishlist with its products
+// looked up.
+func (r *MongoDigestRepository) ForEachDigestWishlist(ctx context.Context, fn func(models.DigestWishlist) error) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"productIds.0": bson.M{"$exists": true}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "productIds",
+			"foreignField": "_id",
+			"as":           "products",
+		}}},
+		{{Key: "$project", Value: bson.M{"userId": 1, "products": 1, "digestPrices": 1}}},
+	}
+	cursor, err := r.DB.Collection("wishlists").Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var wishlist models.DigestWishlist
+		if err := cursor.Decode(&wishlist); err != nil {
+			return err
+		}
+		if err := fn(wishlist); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// GetRecommendations returns top-selling active products from the given
+// categories, leaving out ones the buyer already has.
+func (r *MongoDigestRepository) GetRecommendations(ctx context.Context, categoryIDs, exclude []primitive.ObjectID, limit int64) ([]models.Product, error) {
+	if len(categoryIDs) == 0 {
+		return []models.Product{}, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"categoryId": bson.M{"$in": categoryIDs},
+			"_id":        bson.M{"$nin": exclude},
+			"status":     models.ProductStatusActive,
+			"stock":      bson.M{"$gt": 0},
+			"deletedAt":  nil,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "totalSales", Value: -1}, {Key: "rating", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.DB.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *MongoDigestRepository) SaveWishlistPriceSnapshot(ctx context.Context, userID primitive.ObjectID, prices map[string]models.Money) error {
+	_, err := r.DB.Collection("wishlists").UpdateOne(ctx, bson.M{"userId": userID}, bson.M{
+		"$set": bson.M{"digestPrices": prices},
+	})
+	return err
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DisputeRepository interface {
+	CreateDispute(ctx context.Context, dispute models.Dispute) (models.Dispute, error)
+	GetDispute(ctx context.Context, id primitive.ObjectID) (*models.Dispute, error)
+	ListDisputes(ctx context.Context, filter bson.M, limit, skip int64) ([]models.Dispute, int64, error)
+	Transition(ctx context.Context, id primitive.ObjectID, from []string, to string, fields bson.M, message *models.DisputeMessage) (*models.Dispute, error)
+	AddMessage(ctx context.Context, id primitive.ObjectID, message models.DisputeMessage) (*models.Dispute, error)
+	RequestInfo(ctx context.Context, id primitive.ObjectID, parties []string, message models.DisputeMessage) (*models.Dispute, error)
+}
+
+var (
+	ErrDisputeExists       = errors.New("this order has already been disputed with this vendor")
+	ErrDisputeStateChanged = errors.New("dispute status changed")
+)
+
+type MongoDisputeRepository struct {
+	DB *mongo.Database
+}
+
+func NewDisputeRepository(db *mongo.Database) DisputeRepository {
+	return &MongoDisputeRepository{DB: db}
+}
+
+// CreateDispute stores a new dispute. An order can be disputed once per
+// vendor.
+func (r *MongoDisputeRepository) CreateDispute(ctx context.Context, dispute models.Dispute) (models.Dispute, error) {
+	dispute.ID = primitive.NewObjectID()
+	dispute.CreatedAt = time.Now()
+	dispute.UpdatedAt = dispute.CreatedAt
+
+	_, err := r.DB.Collection("disputes").InsertOne(ctx, dispute)
+	if mongo.IsDuplicateKeyError(err) {
+		return dispute, ErrDisputeExists
+	}
+	return dispute, err
+}
+
+func (r *MongoDisputeRepository) GetDispute(ctx context.Context, id primitive.ObjectID) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.DB.Collection("disputes").FindOne(ctx, bson.M{"_id": id}).Decode(&dispute)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// ListDisputes returns disputes matching filter, most recently active first.
+// The threads are left out.
+func (r *MongoDisputeRepository) ListDisputes(ctx context.Context, filter bson.M, limit, skip int64) ([]models.Dispute, int64, error) {
+	coll := r.DB.Collection("disputes")
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip).
+		SetProjection(bson.M{"messages": 0})
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	disputes := []models.Dispute{}
+	if err := cursor.All(ctx, &disputes); err != nil {
+		return nil, 0, err
+	}
+	return disputes, total, nil
+}
+
+// Transition moves a dispute to status to, setting fields and adding message
+// to the thread, but only while it is in one of the from statuses. It returns
+// ErrDisputeStateChanged if it has moved on, so two settlements can't both
+// go through.
+func (r *MongoDisputeRepository) Transition(ctx context.Context, id primitive.ObjectID, from []string, to string, fields bson.M, message *models.DisputeMessage) (*models.Dispute, error) {
+	set := bson.M{"status": to, "updatedAt": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+	update := bson.M{"$set": set}
+	if message != nil {
+		message.CreatedAt = time.Now()
+		update["$push"] = bson.M{"messages": message}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var dispute models.Dispute
+	err := r.DB.Collection("disputes").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		update, opts,
+	).Decode(&dispute)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDisputeStateChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// AddMessage adds to the thread of a dispute that is still active. A reply
+// from a party an admin asked for information answers the request.
+func (r *MongoDisputeRepository) AddMessage(ctx context.Context, id primitive.ObjectID, message models.DisputeMessage) (*models.Dispute, error) {
+	message.CreatedAt = time.Now()
+	return r.updateActive(ctx, id, bson.M{
+		"$push": bson.M{"messages": message},
+		"$pull": bson.M{"infoRequested": message.Role},
+		"$set":  bson.M{"updatedAt": message.CreatedAt},
+	})
+}
+
+// RequestInfo adds an admin's request for more information to the thread
+// and waits on parties to reply.
+func (r *MongoDisputeRepository) RequestInfo(ctx context.Context, id primitive.ObjectID, parties []string, message models.DisputeMessage) (*models.Dispute, error) {
+	message.CreatedAt = time.Now()
+	return r.updateActive(ctx, id, bson.M{
+		"$push":     bson.M{"messages": message},
+		"$addToSet": bson.M{"infoRequested": bson.M{"$each": parties}},
+		"$set":      bson.M{"updatedAt": message.CreatedAt},
+	})
+}
+
+// updateActive applies update to the dispute while it is active, returning
+// ErrDisputeStateChanged once it has been settled.
+func (r *MongoDisputeRepository) updateActive(ctx context.Context, id primitive.ObjectID, update bson.M) (*models.Dispute, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var dispute models.Dispute
+	err := r.DB.Collection("disputes").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": models.DisputeActiveStatuses}},
+		update,
+		opts,
+	).Decode(&dispute)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDisputeStateChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmailLogRepository interface {
+	Record(ctx context.Context, entry models.EmailLog) error
+	List(ctx context.Context, filter bson.M, limit, skip int64) ([]models.EmailLog, int64, error)
+}
+
+type MongoEmailLogRepository struct {
+	DB *mongo.Database
+}
+
+func NewEmailLogRepository(db *mongo.Database) EmailLogRepository {
+	return &MongoEmailLogRepository{DB: db}
+}
+
+func (r *MongoEmailLogRepository) Record(ctx context.Context, entry models.EmailLog) error {
+	entry.ID = primitive.NewObjectID()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	_, err := r.DB.Collection("emailLogs").InsertOne(ctx, entry)
+	return err
+}
+
+func (r *MongoEmailLogRepository) List(ctx context.Context, filter bson.M, limit, skip int64) ([]models.EmailLog, int64, error) {
+	collection := r.DB.Collection("emailLogs")
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.EmailLog{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrTooManyExports = errors.New("too many exports in progress")
+)
+
+// ExportRepository keeps export jobs and hands them to the workers that
+// build them.
+type ExportRepository interface {
+	Create(ctx context.Context, job *models.ExportJob) error
+	Get(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error)
+	ListForUser(ctx context.Context, userID primitive.ObjectID, limit int64) ([]models.ExportJob, error)
+	Claim(ctx context.Context, lease time.Duration) (*models.ExportJob, error)
+	MarkReady(ctx context.Context, job models.ExportJob) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, cause error, final bool) error
+	ListExpired(ctx context.Context, now time.Time) ([]models.ExportJob, error)
+	MarkExpired(ctx context.Context, id primitive.ObjectID) error
+}
+
+type MongoExportRepository struct {
+	DB *mongo.Database
+}
+
+func NewExportRepository(db *mongo.Database) ExportRepository {
+	return &MongoExportRepository{DB: db}
+}
+
+// Create queues job, unless its requester already has
+// models.ExportMaxPending exports queued or running.
+func (r *MongoExportRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	pending, err := r.DB.Collection("exportJobs").CountDocuments(ctx, bson.M{
+		"requestedBy": job.RequestedBy,
+		"status":      bson.M{"$in": bson.A{models.ExportStatusQueued, models.ExportStatusRunning}},
+	})
+	if err != nil {
+		return err
+	}
+	if pending >= models.ExportMaxPending {
+		return ErrTooManyExports
+	}
+
+	job.Status = models.ExportStatusQueued
+	job.CreatedAt = time.Now()
+	res, err := r.DB.Collection("exportJobs").InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoExportRepository) Get(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.DB.Collection("exportJobs").FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListForUser returns the user's exports, newest first.
+func (r *MongoExportRepository) ListForUser(ctx context.Context, userID primitive.ObjectID, limit int64) ([]models.ExportJob, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit)
+	cursor, err := r.DB.Collection("exportJobs").Find(ctx, bson.M{"requestedBy": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []models.ExportJob{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Claim marks the oldest queued export running for lease and returns it,
+// or nil if there is none. A running export whose lease ran out, because
+// the worker building it stopped, can be claimed again.
+func (r *MongoExportRepository) Claim(ctx context.Context, lease time.Duration) (*models.ExportJob, error) {
+	now := time.Now()
+	filter := bson.M{
+		"attempts": bson.M{"$lt": models.ExportMaxAttempts},
+		"$or": bson.A{
+			bson.M{"status": models.ExportStatusQueued},
+			bson.M{"status": models.ExportStatusRunning, "lockedUntil": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.ExportStatusRunning, "lockedUntil": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.ExportJob
+	err := r.DB.Collection("exportJobs").FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// MarkReady records the finished file of job.
+func (r *MongoExportRepository) MarkReady(ctx context.Context, job models.ExportJob) error {
+	_, err := r.DB.Collection("exportJobs").UpdateOne(ctx,
+		bson.M{"_id": job.ID},
+		bson.M{
+			"$set": bson.M{
+				"status":      models.ExportStatusReady,
+				"fileUrl":     job.FileURL,
+				"fileName":    job.FileName,
+				"size":        job.Size,
+				"rows":        job.Rows,
+				"expiresAt":   job.ExpiresAt,
+				"completedAt": job.CompletedAt,
+			},
+			"$unset": bson.M{"lockedUntil": "", "error": ""},
+		},
+	)
+	return err
+}
+
+// MarkFailed records why an export couldn't be built. Unless final, it is
+// queued again for another attempt.
+func (r *MongoExportRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, cause error, final bool) error {
+	status := models.ExportStatusQueued
+	set := bson.M{"error": cause.Error()}
+	if final {
+		status = models.ExportStatusFailed
+		set["completedAt"] = time.Now()
+	}
+	set["status"] = status
+	_, err := r.DB.Collection("exportJobs").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": set, "$unset": bson.M{"lockedUntil": ""}},
+	)
+	return err
+}
+
+// ListExpired returns ready exports whose download window closed by now.
+func (r *MongoExportRepository) ListExpired(ctx context.Context, now time.Time) ([]models.ExportJob, error) {
+	cursor, err := r.DB.Collection("exportJobs").Find(ctx, bson.M{
+		"status":    models.ExportStatusReady,
+		"expiresAt": bson.M{"$lte": now},
+	}, options.Find().SetLimit(100))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.ExportJob
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// MarkExpired records that an export's file has been deleted.
+func (r *MongoExportRepository) MarkExpired(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DB.Collection("exportJobs").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"status": models.ExportStatusExpired},
+			"$unset": bson.M{"fileUrl": ""},
+		},
+	)
+	return err
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FlashSaleRepository interface {
+	CreateFlashSale(ctx context.Context, sale models.FlashSale) (models.FlashSale, error)
+	GetVendorFlashSales(ctx context.Context, vendorID primitive.ObjectID) ([]models.FlashSale, error)
+	GetFlashSale(ctx context.Context, id primitive.ObjectID) (*models.FlashSale, error)
+	CancelFlashSale(ctx context.Context, vendorID, id primitive.ObjectID) error
+	HasOverlappingFlashSale(ctx context.Context, productID primitive.ObjectID, start, end time.Time) (bool, error)
+	ListPublicFlashSales(ctx context.Context, now, until time.Time, limit int64) ([]models.FlashSale, error)
+	GetUserPurchased(ctx context.Context, saleID, userID primitive.ObjectID) (int, error)
+	ClaimFlashSale(ctx context.Context, sale models.FlashSale, userID primitive.ObjectID, quantity int) error
+	ReleaseFlashSale(ctx context.Context, sale models.FlashSale, userID primitive.ObjectID, quantity int) error
+}
+
+type MongoFlashSaleRepository struct {
+	DB *mongo.Database
+}
+
+func NewFlashSaleRepository(db *mongo.Database) FlashSaleRepository {
+	return &MongoFlashSaleRepository{DB: db}
+}
+
+func (r *MongoFlashSaleRepository) CreateFlashSale(ctx context.Context, sale models.FlashSale) (models.FlashSale, error) {
+	sale.ID = primitive.NewObjectID()
+	sale.CreatedAt = time.Now()
+	sale.UpdatedAt = sale.CreatedAt
+
+	_, err := r.DB.Collection("flashSales").InsertOne(ctx, sale)
+	return sale, err
+}
+
+func (r *MongoFlashSaleRepository) GetVendorFlashSales(ctx context.Context, vendorID primitive.ObjectID) ([]models.FlashSale, error) {
+	opts := options.Find().SetSort(bson.M{"startsAt": -1}).SetLimit(200)
+	return r.findFlashSales(ctx, bson.M{"vendorId": vendorID}, opts)
+}
+
+func (r *MongoFlashSaleRepository) GetFlashSale(ctx context.Context, id primitive.ObjectID) (*models.FlashSale, error) {
+	var sale models.FlashSale
+	err := r.DB.Collection("flashSales").FindOne(ctx, bson.M{"_id": id}).Decode(&sale)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &sale, err
+}
+
+// CancelFlashSale calls off one of the vendor's flash sales that hasn't
+// ended. Units already claimed stay with the orders that claimed them.
+func (r *MongoFlashSaleRepository) CancelFlashSale(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	res, err := r.DB.Collection("flashSales").UpdateOne(ctx,
+		bson.M{"_id": id, "vendorId": vendorID, "cancelled": false, "endsAt": bson.M{"$gt": time.Now()}},
+		bson.M{"$set": bson.M{"cancelled": true, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return models.ErrFlashSaleNotFound
+	}
+	return nil
+}
+
+// HasOverlappingFlashSale reports whether the product already has a flash
+// sale, not cancelled, whose window overlaps start to end.
+func (r *MongoFlashSaleRepository) HasOverlappingFlashSale(ctx context.Context, productID primitive.ObjectID, start, end time.Time) (bool, error) {
+	count, err := r.DB.Collection("flashSales").CountDocuments(ctx, bson.M{
+		"productId": productID,
+		"cancelled": false,
+		"startsAt":  bson.M{"$lt": end},
+		"endsAt":    bson.M{"$gt": start},
+	})
+	return count > 0, err
+}
+
+// ListPublicFlashSales lists flash sales running now or starting before
+// until, soonest first.
+func (r *MongoFlashSaleRepository) ListPublicFlashSales(ctx context.Context, now, until time.Time, limit int64) ([]models.FlashSale, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "startsAt", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit)
+	return r.findFlashSales(ctx, bson.M{
+		"cancelled": false,
+		"startsAt":  bson.M{"$lte": until},
+		"endsAt":    bson.M{"$gt": now},
+	}, opts)
+}
+
+// GetUserPurchased is how many units of the sale the buyer has claimed.
+func (r *MongoFlashSaleRepository) GetUserPurchased(ctx context.Context, saleID, userID primitive.ObjectID) (int, error) {
+	var purchase models.FlashSalePurchase
+	err := r.DB.Collection("flashSalePurchases").FindOne(ctx, bson.M{"flashSaleId": saleID, "userId": userID}).Decode(&purchase)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return purchase.Quantity, err
+}
+
+// ClaimFlashSale takes quantity units of a live sale for the buyer, within
+// its stock and per-customer limit. Each counter is moved by one
+// conditional update, so concurrent checkouts can't push either past its
+// cap; the per-buyer counter relies on the unique (flashSaleId, userId)
+// index to turn an over-limit upsert into a duplicate key error.
+func (r *MongoFlashSaleRepository) ClaimFlashSale(ctx context.Context, sale models.FlashSale, userID primitive.ObjectID, quantity int) error {
+	if err := sale.CheckLimit(0, quantity); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	res, err := r.DB.Collection("flashSales").UpdateOne(ctx,
+		bson.M{
+			"_id":       sale.ID,
+			"cancelled": false,
+			"startsAt":  bson.M{"$lte": now},
+			"endsAt":    bson.M{"$gt": now},
+			"$expr":     bson.M{"$lte": bson.A{bson.M{"$add": bson.A{"$sold", quantity}}, "$quantity"}},
+		},
+		bson.M{"$inc": bson.M{"sold": quantity}, "$set": bson.M{"updatedAt": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if sale.State(now) == models.FlashSaleLive {
+			return models.ErrFlashSaleSoldOut
+		}
+		return models.ErrFlashSaleNotLive
+	}
+
+	if sale.PerUserLimit == 0 {
+		return nil
+	}
+	_, err = r.DB.Collection("flashSalePurchases").UpdateOne(ctx,
+		bson.M{"flashSaleId": sale.ID, "userId": userID, "quantity": bson.M{"$lte": sale.PerUserLimit - quantity}},
+		bson.M{"$inc": bson.M{"quantity": quantity}, "$set": bson.M{"updatedAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	if err == nil {
+		return nil
+	}
+	// Give the units back either way
+	if _, relErr := r.DB.Collection("flashSales").UpdateOne(ctx,
+		bson.M{"_id": sale.ID},
+		bson.M{"$inc": bson.M{"sold": -quantity}},
+	); relErr != nil {
+		return relErr
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return models.ErrFlashSaleUserLimit
+	}
+	return err
+}
+
+// ReleaseFlashSale gives back units claimed for an order that then failed.
+func (r *MongoFlashSaleRepository) ReleaseFlashSale(ctx context.Context, sale models.FlashSale, userID primitive.ObjectID, quantity int) error {
+	_, err := r.DB.Collection("flashSales").UpdateOne(ctx,
+		bson.M{"_id": sale.ID},
+		bson.M{"$inc": bson.M{"sold": -quantity}},
+	)
+	if err != nil || sale.PerUserLimit == 0 {
+		return err
+	}
+	_, err = r.DB.Collection("flashSalePurchases").UpdateOne(ctx,
+		bson.M{"flashSaleId": sale.ID, "userId": userID},
+		bson.M{"$inc": bson.M{"quantity": -quantity}},
+	)
+	return err
+}
+
+func (r *MongoFlashSaleRepository) findFlashSales(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.FlashSale, error) {
+	cursor, err := r.DB.Collection("flashSales").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sales := []models.FlashSale{}
+	if err := cursor.All(ctx, &sales); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IdempotencyRepository stores requests made with an Idempotency-Key so
+// retries get the first response instead of repeating the work.
+type IdempotencyRepository interface {
+	Begin(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error
+	Release(ctx context.Context, id primitive.ObjectID) error
+}
+
+type MongoIdempotencyRepository struct {
+	DB *mongo.Database
+}
+
+func NewIdempotencyRepository(db *mongo.Database) IdempotencyRepository {
+	return &MongoIdempotencyRepository{DB: db}
+}
+
+// Begin claims the user's key for the request. If the key was claimed
+// already, it returns that earlier record and leaves record untouched.
+func (r *MongoIdempotencyRepository) Begin(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	collection := r.DB.Collection("idempotencyKeys")
+	result, err := collection.InsertOne(ctx, record)
+	if err == nil {
+		record.ID = result.InsertedID.(primitive.ObjectID)
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var existing models.IdempotencyRecord
+	err = collection.FindOne(ctx, bson.M{"userId": record.UserID, "key": record.Key}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Released between the insert and the lookup; let the client retry
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Complete saves the response to replay.
+func (r *MongoIdempotencyRepository) Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error {
+	_, err := r.DB.Collection("idempotencyKeys").UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"completed":   true,
+		"status":      status,
+		"contentType": contentType,
+		"body":        body,
+	}})
+	return err
+}
+
+// Release frees the key so the request can be tried again.
+func (r *MongoIdempotencyRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DB.Collection("idempotencyKeys").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
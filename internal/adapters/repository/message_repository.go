@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MessageRepository interface {
+	GetOrCreateConversation(ctx context.Context, conversation models.Conversation) (models.Conversation, error)
+	GetConversation(ctx context.Context, id primitive.ObjectID) (*models.Conversation, error)
+	ListConversations(ctx context.Context, userID primitive.ObjectID, limit, skip int64) ([]models.Conversation, int64, error)
+	AddMessage(ctx context.Context, conversation models.Conversation, message models.Message) (models.Message, error)
+	ListMessages(ctx context.Context, conversationID primitive.ObjectID, after models.PageCursor, limit int64) ([]models.Message, error)
+	ListMessagesSince(ctx context.Context, conversationID primitive.ObjectID, since time.Time, limit int64) ([]models.Message, error)
+	MarkDelivered(ctx context.Context, conversationID, userID primitive.ObjectID) (int64, error)
+	MarkRead(ctx context.Context, conversation models.Conversation, userID primitive.ObjectID) error
+	CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	ClaimAutoReply(ctx context.Context, conversationID primitive.ObjectID, now time.Time) (bool, error)
+	GetVendorResponseStats(ctx context.Context, vendorID primitive.ObjectID, since time.Time) (models.MessageResponseStats, error)
+}
+
+type MongoMessageRepository struct {
+	DB *mongo.Database
+}
+
+func NewMessageRepository(db *mongo.Database) MessageRepository {
+	return &MongoMessageRepository{DB: db}
+}
+
+// GetOrCreateConversation returns the conversation between the buyer and
+// vendor about its product or order, starting it if there isn't one yet.
+func (r *MongoMessageRepository) GetOrCreateConversation(ctx context.Context, conversation models.Conversation) (models.Conversation, error) {
+	filter := bson.M{
+		"buyerId":   conversation.BuyerID,
+		"vendorId":  conversation.VendorID,
+		"subject":   conversation.Subject,
+		"productId": conversation.ProductID,
+		"orderId":   conversation.OrderID,
+	}
+	now := time.Now()
+	update := bson.M{"$setOnInsert": bson.M{
+		"title":        conversation.Title,
+		"buyerUnread":  0,
+		"vendorUnread": 0,
+		"createdAt":    now,
+		"updatedAt":    now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved models.Conversation
+	err := r.DB.Collection("conversations").FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved)
+	if mongo.IsDuplicateKeyError(err) {
+		// Started by the other side at the same moment
+		err = r.DB.Collection("conversations").FindOne(ctx, filter).Decode(&saved)
+	}
+	return saved, err
+}
+
+func (r *MongoMessageRepository) GetConversation(ctx context.Context, id primitive.ObjectID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.DB.Collection("conversations").FindOne(ctx, bson.M{"_id": id}).Decode(&conversation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// ListConversations returns the user's conversations, on either side, most
+// recently active first.
+func (r *MongoMessageRepository) ListConversations(ctx context.Context, userID primitive.ObjectID, limit, skip int64) ([]models.Conversation, int64, error) {
+	coll := r.DB.Collection("conversations")
+	filter := bson.M{"$or": bson.A{bson.M{"buyerId": userID}, bson.M{"vendorId": userID}}}
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit).SetSkip(skip)
+	cursor, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	conversations := []models.Conversation{}
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, 0, err
+	}
+	for i := range conversations {
+		conversations[i].Unread = conversations[i].UnreadFor(userID)
+	}
+	return conversations, total, nil
+}
+
+// AddMessage stores a message and moves the conversation on: its preview,
+// its place in lists and the recipient's unread count. It also keeps track
+// of how long the buyer has been waiting, so the vendor's first real reply
+// records their response time.
+func (r *MongoMessageRepository) AddMessage(ctx context.Context, conversation models.Conversation, message models.Message) (models.Message, error) {
+	coll := r.DB.Collection("conversations")
+	fromVendor := message.SenderID == conversation.VendorID
+
+	message.ID = primitive.NewObjectID()
+	message.ConversationID = conversation.ID
+	message.CreatedAt = time.Now()
+	if fromVendor && !message.AutoReply {
+		var before models.Conversation
+		err := coll.FindOneAndUpdate(ctx,
+			bson.M{"_id": conversation.ID, "awaitingReplySince": bson.M{"$exists": true}},
+			bson.M{"$unset": bson.M{"awaitingReplySince": ""}},
+		).Decode(&before)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return message, err
+		}
+		if before.AwaitingReplySince != nil {
+			// At least a second, so an instant reply still counts
+			message.ResponseTime = int64(message.CreatedAt.Sub(*before.AwaitingReplySince).Seconds())
+			if message.ResponseTime < 1 {
+				message.ResponseTime = 1
+			}
+		}
+	}
+	if _, err := r.DB.Collection("messages").InsertOne(ctx, message); err != nil {
+		return message, err
+	}
+
+	if !fromVendor {
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": conversation.ID, "awaitingReplySince": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"awaitingReplySince": message.CreatedAt}},
+		)
+		if err != nil {
+			return message, err
+		}
+	}
+
+	unread := "vendorUnread"
+	if fromVendor {
+		unread = "buyerUnread"
+	}
+	_, err := coll.UpdateOne(ctx,
+		bson.M{"_id": conversation.ID},
+		bson.M{
+			"$set": bson.M{
+				"lastMessage": models.MessagePreview{
+					SenderID:  message.SenderID,
+					Body:      message.Preview(),
+					CreatedAt: message.CreatedAt,
+				},
+				"updatedAt": message.CreatedAt,
+			},
+			"$inc": bson.M{unread: 1},
+		},
+	)
+	return message, err
+}
+
+// ListMessages returns up to limit of a conversation's messages after the
+// cursor, newest first.
+func (r *MongoMessageRepository) ListMessages(ctx context.Context, conversationID primitive.ObjectID, after models.PageCursor, limit int64) ([]models.Message, error) {
+	filter := after.After(bson.M{"conversationId": conversationID})
+	opts := options.Find().SetSort(models.CursorSort).SetLimit(limit)
+	cursor, err := r.DB.Collection("messages").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []models.Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ListMessagesSince returns up to limit of a conversation's messages sent
+// after since, oldest first, for clients polling instead of holding a live
+// connection.
+func (r *MongoMessageRepository) ListMessagesSince(ctx context.Context, conversationID primitive.ObjectID, since time.Time, limit int64) ([]models.Message, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit)
+	cursor, err := r.DB.Collection("messages").Find(ctx,
+		bson.M{"conversationId": conversationID, "createdAt": bson.M{"$gt": since}},
+		opts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []models.Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkDelivered marks the messages the user has received in the conversation
+// as delivered, returning how many hadn't been.
+func (r *MongoMessageRepository) MarkDelivered(ctx context.Context, conversationID, userID primitive.ObjectID) (int64, error) {
+	res, err := r.DB.Collection("messages").UpdateMany(ctx,
+		bson.M{"conversationId": conversationID, "senderId": bson.M{"$ne": userID}, "deliveredAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deliveredAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// MarkRead marks the messages the user has received in the conversation as
+// read, and so delivered, and clears their unread count.
+func (r *MongoMessageRepository) MarkRead(ctx context.Context, conversation models.Conversation, userID primitive.ObjectID) error {
+	if _, err := r.MarkDelivered(ctx, conversation.ID, userID); err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err := r.DB.Collection("messages").UpdateMany(ctx,
+		bson.M{"conversationId": conversation.ID, "senderId": bson.M{"$ne": userID}, "readAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"readAt": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	unread := "vendorUnread"
+	if userID == conversation.BuyerID {
+		unread = "buyerUnread"
+	}
+	_, err = r.DB.Collection("conversations").UpdateOne(ctx,
+		bson.M{"_id": conversation.ID},
+		bson.M{"$set": bson.M{unread: 0}},
+	)
+	return err
+}
+
+// CountUnread adds up the user's unread messages across their conversations.
+func (r *MongoMessageRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{bson.M{"buyerId": userID}, bson.M{"vendorId": userID}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"unread": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$buyerId", userID}}, "$buyerUnread", "$vendorUnread",
+			}}},
+		}}},
+	}
+	cursor, err := r.DB.Collection("conversations").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Unread int64 `bson:"unread"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Unread, cursor.Err()
+}
+
+// ClaimAutoReply marks the conversation as having had the vendor's away
+// message now. It reports false when one already went out within
+// models.AutoReplyCooldown, so only one is sent however many messages come in.
+func (r *MongoMessageRepository) ClaimAutoReply(ctx context.Context, conversationID primitive.ObjectID, now time.Time) (bool, error) {
+	result, err := r.DB.Collection("conversations").UpdateOne(ctx,
+		bson.M{
+			"_id": conversationID,
+			"$or": bson.A{
+				bson.M{"autoRepliedAt": bson.M{"$exists": false}},
+				bson.M{"autoRepliedAt": bson.M{"$lte": now.Add(-models.AutoReplyCooldown)}},
+			},
+		},
+		bson.M{"$set": bson.M{"autoRepliedAt": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// GetVendorResponseStats averages how long the vendor took to answer buyers
+// in replies sent since the given time.
+func (r *MongoMessageRepository) GetVendorResponseStats(ctx context.Context, vendorID primitive.ObjectID, since time.Time) (models.MessageResponseStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"senderId":        vendorID,
+			"createdAt":       bson.M{"$gte": since},
+			"responseSeconds": bson.M{"$gt": 0},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"replies": bson.M{"$sum": 1},
+			"avg":     bson.M{"$avg": "$responseSeconds"},
+		}}},
+	}
+	cursor, err := r.DB.Collection("messages").Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.MessageResponseStats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Replies int64   `bson:"replies"`
+		Avg     float64 `bson:"avg"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return models.MessageResponseStats{}, err
+		}
+	}
+	return models.MessageResponseStats{
+		Replies:          result.Replies,
+		AvgResponseHours: result.Avg / time.Hour.Seconds(),
+	}, cursor.Err()
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ModerationRepository interface {
+	AddFlag(ctx context.Context, product models.Product, flag models.ModerationFlag) (*models.ModerationCase, error)
+	HasOpenReport(ctx context.Context, productID, reporterID primitive.ObjectID) (bool, error)
+	ListCases(ctx context.Context, filter bson.M, limit, skip int64) ([]models.ModerationCase, int64, error)
+	GetCase(ctx context.Context, id primitive.ObjectID) (*models.ModerationCase, error)
+	ApplyAction(ctx context.Context, modCase models.ModerationCase, action, note string, moderatorID primitive.ObjectID) (*models.ModerationCase, error)
+	SubmitAppeal(ctx context.Context, id, vendorID primitive.ObjectID, message string) (*models.ModerationCase, error)
+	ResolveAppeal(ctx context.Context, modCase models.ModerationCase, approve bool, note string, adminID primitive.ObjectID) (*models.ModerationCase, error)
+}
+
+var (
+	ErrCaseNotOpen      = errors.New("moderation case is not open")
+	ErrAppealNotAllowed = errors.New("this case cannot be appealed")
+	ErrAppealNotPending = errors.New("appeal is not pending")
+	ErrUnknownModAction = errors.New("unknown moderation action")
+)
+
+type MongoModerationRepository struct {
+	DB *mongo.Database
+}
+
+func NewModerationRepository(db *mongo.Database) ModerationRepository {
+	return &MongoModerationRepository{DB: db}
+}
+
+// AddFlag attaches a flag to the product's open case, opening one if needed,
+// and marks an otherwise unmoderated product as flagged.
+func (r *MongoModerationRepository) AddFlag(ctx context.Context, product models.Product, flag models.ModerationFlag) (*models.ModerationCase, error) {
+	now := time.Now()
+	if flag.CreatedAt.IsZero() {
+		flag.CreatedAt = now
+	}
+
+	update := bson.M{
+		"$push": bson.M{"flags": flag},
+		"$set":  bson.M{"updatedAt": now, "productName": product.Name},
+		"$setOnInsert": bson.M{
+			"productID": product.ID,
+			"vendorID":  product.VendorID,
+			"status":    models.CaseStatusOpen,
+			"createdAt": now,
+		},
+	}
+	reports := 0
+	if flag.Source == models.FlagSourceUserReport {
+		reports = 1
+	}
+	update["$inc"] = bson.M{"reportCount": reports}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var modCase models.ModerationCase
+	err := r.DB.Collection("moderationCases").FindOneAndUpdate(ctx,
+		bson.M{"productID": product.ID, "status": models.CaseStatusOpen},
+		update,
+		opts,
+	).Decode(&modCase)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.DB.Collection("products").UpdateOne(ctx,
+		bson.M{"_id": product.ID, "moderationStatus": bson.M{"$in": bson.A{nil, "", models.ModerationEditsRequested}}},
+		bson.M{"$set": bson.M{"moderationStatus": models.ModerationFlagged}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &modCase, nil
+}
+
+func (r *MongoModerationRepository) HasOpenReport(ctx context.Context, productID, reporterID primitive.ObjectID) (bool, error) {
+	count, err := r.DB.Collection("moderationCases").CountDocuments(ctx, bson.M{
+		"productID":        productID,
+		"status":           models.CaseStatusOpen,
+		"flags.reporterID": reporterID,
+	})
+	return count > 0, err
+}
+
+func (r *MongoModerationRepository) ListCases(ctx context.Context, filter bson.M, limit, skip int64) ([]models.ModerationCase, int64, error) {
+	collection := r.DB.Collection("moderationCases")
+
+	// Most-reported first, then oldest, so the worst listings surface quickly
+	opts := options.Find().
+		SetSort(bson.D{{Key: "reportCount", Value: -1}, {Key: "createdAt", Value: 1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	cases := []models.ModerationCase{}
+	if err := cursor.All(ctx, &cases); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cases, total, nil
+}
+
+func (r *MongoModerationRepository) GetCase(ctx context.Context, id primitive.ObjectID) (*models.ModerationCase, error) {
+	var modCase models.ModerationCase
+	err := r.DB.Collection("moderationCases").FindOne(ctx, bson.M{"_id": id}).Decode(&modCase)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &modCase, err
+}
+
+// ApplyAction closes an open case with the moderator's decision and applies
+// it to the product in the same transaction.
+func (r *MongoModerationRepository) ApplyAction(ctx context.Context, modCase models.ModerationCase, action, note string, moderatorID primitive.ObjectID) (*models.ModerationCase, error) {
+	productSet := bson.M{"updatedAt": time.Now()}
+	productUnset := bson.M{}
+	caseStatus := models.CaseStatusActioned
+
+	switch action {
+	case models.ModerationActionUnpublish:
+		productSet["status"] = models.ProductStatusRemoved
+		productSet["moderationStatus"] = models.ModerationUnpublished
+	case models.ModerationActionDelete:
+		productSet["status"] = models.ProductStatusRemoved
+		productSet["moderationStatus"] = models.ModerationDeleted
+	case models.ModerationActionRequestEdits:
+		productSet["moderationStatus"] = models.ModerationEditsRequested
+	case models.ModerationActionDismiss:
+		caseStatus = models.CaseStatusDismissed
+		productUnset["moderationStatus"] = ""
+	default:
+		return nil, ErrUnknownModAction
+	}
+
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	var updated models.ModerationCase
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+
+		// 1. Remember the product's status so an approved appeal can restore it
+		var product models.Product
+		if err := r.DB.Collection("products").FindOne(sessCtx, bson.M{"_id": modCase.ProductID}).Decode(&product); err != nil {
+			return nil, fmt.Errorf("product not found")
+		}
+
+		// 2. Close the case
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := r.DB.Collection("moderationCases").FindOneAndUpdate(sessCtx,
+			bson.M{"_id": modCase.ID, "status": models.CaseStatusOpen},
+			bson.M{"$set": bson.M{
+				"status":                caseStatus,
+				"action":                action,
+				"actionNote":            note,
+				"moderatorID":           moderatorID,
+				"actionedAt":            now,
+				"previousProductStatus": product.Status,
+				"updatedAt":             now,
+			}},
+			opts,
+		).Decode(&updated)
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCaseNotOpen
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 3. Apply the decision to the product
+		productUpdate := bson.M{"$set": productSet}
+		if len(productUnset) > 0 {
+			productUpdate["$unset"] = productUnset
+		}
+		if _, err := r.DB.Collection("products").UpdateOne(sessCtx, bson.M{"_id": modCase.ProductID}, productUpdate); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if _, err := session.WithTransaction(ctx, callback); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SubmitAppeal records the vendor's appeal against an actioned case. Each
+// case may be appealed once.
+func (r *MongoModerationRepository) SubmitAppeal(ctx context.Context, id, vendorID primitive.ObjectID, message string) (*models.ModerationCase, error) {
+	appeal := models.ModerationAppeal{
+		Message:     message,
+		Status:      "pending",
+		SubmittedAt: time.Now(),
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.ModerationCase
+	err := r.DB.Collection("moderationCases").FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":      id,
+			"vendorID": vendorID,
+			"status":   models.CaseStatusActioned,
+			"appeal":   bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"appeal": appeal, "updatedAt": time.Now()}},
+		opts,
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAppealNotAllowed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ResolveAppeal approves or rejects a pending appeal. Approving restores the
+// product to the status it had before the action.
+func (r *MongoModerationRepository) ResolveAppeal(ctx context.Context, modCase models.ModerationCase, approve bool, note string, adminID primitive.ObjectID) (*models.ModerationCase, error) {
+	status := "rejected"
+	if approve {
+		status = "approved"
+	}
+
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	var updated models.ModerationCase
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+
+		// 1. Resolve the appeal
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := r.DB.Collection("moderationCases").FindOneAndUpdate(sessCtx,
+			bson.M{"_id": modCase.ID, "appeal.status": "pending"},
+			bson.M{"$set": bson.M{
+				"appeal.status":     status,
+				"appeal.resolvedAt": now,
+				"appeal.resolvedBy": adminID,
+				"appeal.note":       note,
+				"updatedAt":         now,
+			}},
+			opts,
+		).Decode(&updated)
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAppealNotPending
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !approve {
+			return nil, nil
+		}
+
+		// 2. Reinstate the product
+		productSet := bson.M{"updatedAt": now}
+		if prev := updated.PreviousProductStatus; prev != "" && prev != models.ProductStatusRemoved {
+			productSet["status"] = prev
+		}
+		_, err = r.DB.Collection("products").UpdateOne(sessCtx,
+			bson.M{"_id": modCase.ProductID},
+			bson.M{"$set": productSet, "$unset": bson.M{"moderationStatus": ""}},
+		)
+		return nil, err
+	}
+
+	if _, err := session.WithTransaction(ctx, callback); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
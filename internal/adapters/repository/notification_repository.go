@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n *models.Notification) error
+	ListForUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, limit, skip int64) ([]models.Notification, int64, error)
+	ListPage(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, after models.PageCursor, limit int64) ([]models.Notification, error)
+	ListSince(ctx context.Context, userID primitive.ObjectID, since time.Time, limit int64) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	MarkRead(ctx context.Context, id, userID primitive.ObjectID) (bool, error)
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	Delete(ctx context.Context, id, userID primitive.ObjectID) (bool, error)
+}
+
+type MongoNotificationRepository struct {
+	DB *mongo.Database
+}
+
+func NewNotificationRepository(db *mongo.Database) NotificationRepository {
+	return &MongoNotificationRepository{DB: db}
+}
+
+// Unread counts are kept per user in notificationCounters and moved with
+// atomic $inc as notifications are created, read and deleted, so the badge
+// endpoint doesn't count documents on every poll.
+
+func (r *MongoNotificationRepository) Create(ctx context.Context, n *models.Notification) error {
+	n.ID = primitive.NewObjectID()
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if _, err := r.DB.Collection("notifications").InsertOne(ctx, n); err != nil {
+		return err
+	}
+	if n.ReadAt == nil {
+		return r.adjustUnread(ctx, n.UserID, 1)
+	}
+	return nil
+}
+
+// adjustUnread moves the user's unread counter by delta. A counter created
+// here, for a user with notifications from before counters existed, is
+// seeded from a full count instead.
+func (r *MongoNotificationRepository) adjustUnread(ctx context.Context, userID primitive.ObjectID, delta int64) error {
+	res, err := r.DB.Collection("notificationCounters").UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"unread": delta}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	if res.UpsertedCount > 0 {
+		_, err = r.recountUnread(ctx, userID)
+	}
+	return err
+}
+
+// recountUnread resets the user's counter from the notifications themselves.
+func (r *MongoNotificationRepository) recountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	count, err := r.DB.Collection("notifications").CountDocuments(ctx, bson.M{
+		"userId": userID,
+		"readAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = r.DB.Collection("notificationCounters").UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"unread": count}},
+		options.Update().SetUpsert(true),
+	)
+	return count, err
+}
+
+func (r *MongoNotificationRepository) ListForUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, limit, skip int64) ([]models.Notification, int64, error) {
+	collection := r.DB.Collection("notifications")
+	filter := bson.M{"userId": userID}
+	if unreadOnly {
+		filter["readAt"] = bson.M{"$exists": false}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// ListPage returns up to limit of the user's notifications after the
+// cursor, newest first.
+func (r *MongoNotificationRepository) ListPage(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, after models.PageCursor, limit int64) ([]models.Notification, error) {
+	filter := bson.M{"userId": userID}
+	if unreadOnly {
+		filter["readAt"] = bson.M{"$exists": false}
+	}
+
+	opts := options.Find().
+		SetSort(models.CursorSort).
+		SetLimit(limit)
+	cursor, err := r.DB.Collection("notifications").Find(ctx, after.After(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// ListSince returns the user's notifications created after since, oldest
+// first, for clients catching up by polling.
+func (r *MongoNotificationRepository) ListSince(ctx context.Context, userID primitive.ObjectID, since time.Time, limit int64) ([]models.Notification, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.DB.Collection("notifications").Find(ctx, bson.M{
+		"userId":    userID,
+		"createdAt": bson.M{"$gt": since},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// CountUnread reads the user's unread counter, seeding it on first use.
+func (r *MongoNotificationRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var counter struct {
+		Unread int64 `bson:"unread"`
+	}
+	err := r.DB.Collection("notificationCounters").FindOne(ctx, bson.M{"_id": userID}).Decode(&counter)
+	if err == mongo.ErrNoDocuments {
+		return r.recountUnread(ctx, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if counter.Unread < 0 {
+		// Drifted, e.g. a decrement landed before the counter was seeded
+		return r.recountUnread(ctx, userID)
+	}
+	return counter.Unread, nil
+}
+
+// MarkRead marks one of the user's notifications read. It reports false if
+// the notification doesn't exist or belongs to someone else.
+func (r *MongoNotificationRepository) MarkRead(ctx context.Context, id, userID primitive.ObjectID) (bool, error) {
+	collection := r.DB.Collection("notifications")
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "userId": userID, "readAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"readAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	if res.ModifiedCount > 0 {
+		return true, r.adjustUnread(ctx, userID, -1)
+	}
+
+	// Already read, or not the user's
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *MongoNotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	res, err := r.DB.Collection("notifications").UpdateMany(ctx,
+		bson.M{"userId": userID, "readAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"readAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if res.ModifiedCount > 0 {
+		if err := r.adjustUnread(ctx, userID, -res.ModifiedCount); err != nil {
+			return res.ModifiedCount, err
+		}
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r *MongoNotificationRepository) Delete(ctx context.Context, id, userID primitive.ObjectID) (bool, error) {
+	var deleted models.Notification
+	err := r.DB.Collection("notifications").FindOneAndDelete(ctx, bson.M{"_id": id, "userId": userID}).Decode(&deleted)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if deleted.ReadAt == nil {
+		return true, r.adjustUnread(ctx, userID, -1)
+	}
+	return true, nil
+}
@@ -2,26 +2,50 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"time"
 
+	"github.com/developia-II/ecommerce-backend/internal/events"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderRepository interface {
-	PlaceOrder(ctx context.Context, userID primitive.ObjectID, input models.PlaceOrderInput, cart models.Cart) (models.Order, error)
-	GetOrdersByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error)
+	PlaceOrder(ctx context.Context, userID primitive.ObjectID, input models.PlaceOrderInput, cart models.Cart, shipping models.ShippingQuote, discount models.CouponDiscount) (models.Order, error)
 	GetOrderById(ctx context.Context, orderID primitive.ObjectID) (models.Order, error)
-	GetOrdersByVendorID(ctx context.Context, vendorID primitive.ObjectID) ([]models.Order, error)
-	UpdateOrderStatus(ctx context.Context, orderID primitive.ObjectID, status models.OrderStatus, trackingNumber string) error
-	GetVendorStats(ctx context.Context, vendorID primitive.ObjectID) (models.VendorStats, error)
+	ListOrders(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D, projection bson.M) ([]models.Order, int64, error)
+	ListOrdersPage(ctx context.Context, filter bson.M, after models.PageCursor, limit int64, projection bson.M) ([]models.Order, error)
+	UpdateOrderStatus(ctx context.Context, orderID primitive.ObjectID, status models.OrderStatus, trackingNumber string, actor models.OrderStatusActor, outbox ...events.Event) error
+	MarkPaid(ctx context.Context, orderID primitive.ObjectID, paymentID string, actor models.OrderStatusActor) error
+	GetStatusHistory(ctx context.Context, orderID primitive.ObjectID) ([]models.OrderStatusEvent, error)
+	SetTracking(ctx context.Context, orderID primitive.ObjectID, carrier, trackingNumber string) error
+	AddShippingLabel(ctx context.Context, orderID primitive.ObjectID, label models.ShippingLabel) error
+	GetOrderByTracking(ctx context.Context, trackingNumber string) (*models.Order, error)
+	SaveTracking(ctx context.Context, order models.Order) error
+	SavePickup(ctx context.Context, order models.Order) error
+	GetVendorStats(ctx context.Context, vendorID primitive.ObjectID, q models.VendorStatsQuery) (models.VendorStats, error)
+	GetVendorInventorySales(ctx context.Context, vendorID primitive.ObjectID, from, to time.Time) ([]models.InventorySales, error)
 	GetBuyerStats(ctx context.Context, userID primitive.ObjectID) (models.BuyerOverviewStats, error)
+	GetVendorFulfillmentStats(ctx context.Context, vendorID primitive.ObjectID) (models.VendorFulfillmentStats, error)
+	ReserveRefund(ctx context.Context, orderID primitive.ObjectID, refund models.OrderRefund) error
+	CompleteRefund(ctx context.Context, orderID, refundID primitive.ObjectID, stripeRefundID string, actor models.OrderStatusActor, outbox ...events.Event) (models.Order, error)
+	ReleaseRefund(ctx context.Context, orderID primitive.ObjectID, refund models.OrderRefund) error
+	CancelOrder(ctx context.Context, order models.Order, restock bool, actor models.OrderStatusActor, outbox ...events.Event) error
 }
 
+var (
+	// ErrRefundExceedsTotal is returned when a refund would take the order's
+	// refunded total past what the buyer paid.
+	ErrRefundExceedsTotal = errors.New("refund exceeds the amount left on the order")
+	// ErrOrderStatusChanged is returned when an order moved on while an
+	// admin was acting on it.
+	ErrOrderStatusChanged = errors.New("order status has changed")
+)
+
 type MongoOrderRepository struct {
 	DB *mongo.Database
 }
@@ -30,130 +54,193 @@ func NewOrderRepository(db *mongo.Database) OrderRepository {
 	return &MongoOrderRepository{DB: db}
 }
 
-func (r *MongoOrderRepository) PlaceOrder(ctx context.Context, userID primitive.ObjectID, input models.PlaceOrderInput, cart models.Cart) (models.Order, error) {
-	var err error
+// PlaceOrder turns the cart into an order in one transaction: it takes each
+// line's stock, writes the order with its first status event and the event
+// announcing it, and empties the cart. Either all of that happens or none
+// of it does. A line that can't be bought fails the whole order with
+// models.ErrProductUnavailable or models.ErrOutOfStock.
+func (r *MongoOrderRepository) PlaceOrder(ctx context.Context, userID primitive.ObjectID, input models.PlaceOrderInput, cart models.Cart, shipping models.ShippingQuote, discount models.CouponDiscount) (models.Order, error) {
 	if len(cart.Items) == 0 {
 		return models.Order{}, fmt.Errorf("cart is empty")
 	}
 
-	productColl := r.DB.Collection("products")
-	orderColl := r.DB.Collection("orders")
-	cartColl := r.DB.Collection("carts")
+	// Vendors who offered a shipping method have promised to dispatch within
+	// its handling time
+	now := time.Now()
+	for i := range shipping.Shipments {
+		if shipping.Shipments[i].Method != "" {
+			shipBy := models.AddBusinessDays(now, shipping.Shipments[i].HandlingDays)
+			shipping.Shipments[i].ShipBy = &shipBy
+		}
+	}
+
+	order := models.Order{
+		ID:                primitive.NewObjectID(),
+		UserID:            userID,
+		Discount:          discount.Amount,
+		CouponCode:        discount.Code,
+		ShippingFee:       models.FromFloat(shipping.Fee),
+		Status:            models.StatusPending,
+		PaymentStatus:     "pending",
+		PaymentMethod:     input.PaymentMethod,
+		ShippingAddress:   input.ShippingAddress,
+		ShipTo:            input.ShipTo,
+		ShippingRegion:    shipping.Region,
+		Shipments:         shipping.Shipments,
+		EstimatedDelivery: shipping.EstimatedDelivery,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if !discount.AutoDiscountID.IsZero() {
+		order.AutoDiscountID = &discount.AutoDiscountID
+	}
+
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return models.Order{}, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer session.EndSession(ctx)
 
-	var orderItems []models.OrderItem
-	var subtotal float64
-	var processedProducts []struct {
-		ID  primitive.ObjectID
-		Qty int
+	for attempt := 1; ; attempt++ {
+		if order.OrderNumber, err = nextOrderNumber(ctx, r.DB); err != nil {
+			return models.Order{}, err
+		}
+		var placed interface{}
+		placed, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+			return r.placeOrder(sessCtx, order, cart, discount)
+		})
+		if err == nil {
+			return placed.(models.Order), nil
+		}
+		// A number can only be taken twice if the sequence was reset; the
+		// next one along will be free
+		if !mongo.IsDuplicateKeyError(err) || attempt == orderNumberAttempts {
+			return models.Order{}, err
+		}
 	}
+}
+
+// placeOrder is PlaceOrder's transaction. It starts again from order each
+// time, so the driver can retry it after a write conflict with another
+// checkout taking the same stock.
+func (r *MongoOrderRepository) placeOrder(sessCtx mongo.SessionContext, order models.Order, cart models.Cart, discount models.CouponDiscount) (models.Order, error) {
+	productColl := r.DB.Collection("products")
 
+	// 1. Take each line's stock
+	var subtotal models.Money
 	for _, item := range cart.Items {
 		var product models.Product
-		err := productColl.FindOne(ctx, bson.M{"_id": item.ProductID}).Decode(&product)
+		err := productColl.FindOne(sessCtx, NotDeleted(bson.M{"_id": item.ProductID})).Decode(&product)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Order{}, fmt.Errorf("%w: %s", models.ErrProductUnavailable, item.Name)
+		}
 		if err != nil {
-			return models.Order{}, fmt.Errorf("product %s not found", item.Name)
+			return models.Order{}, err
 		}
 
-		filter := bson.M{
-			"_id":   item.ProductID,
-			"stock": bson.M{"$gte": item.Quantity},
+		res, err := productColl.UpdateOne(sessCtx,
+			bson.M{"_id": item.ProductID, "stock": bson.M{"$gte": item.Quantity}},
+			bson.M{
+				"$inc": bson.M{"stock": -item.Quantity},
+				"$set": bson.M{"updatedAt": order.CreatedAt},
+			},
+		)
+		if err != nil {
+			return models.Order{}, err
 		}
-		update := bson.M{
-			"$inc": bson.M{"stock": -item.Quantity},
-			"$set": bson.M{"updatedAt": time.Now()},
+		if res.MatchedCount == 0 {
+			return models.Order{}, fmt.Errorf("%w for %s", models.ErrOutOfStock, item.Name)
 		}
 
-		res, err := productColl.UpdateOne(ctx, filter, update)
-		if err != nil {
-			for _, p := range processedProducts {
-				productColl.UpdateOne(ctx, bson.M{"_id": p.ID}, bson.M{"$inc": bson.M{"stock": p.Qty}})
+		// A running sale campaign has already written its price to SalePrice.
+		// Flash sale lines carry the price of the units claimed for them.
+		price := product.EffectivePrice()
+		if item.FlashSaleID != nil {
+			price = item.Price
+		}
+		itemSubtotal := price.Mul(item.Quantity)
+		orderItem := models.OrderItem{
+			ProductID:   item.ProductID,
+			VendorID:    product.VendorID,
+			CategoryID:  product.CategoryID,
+			Name:        product.Name,
+			Image:       item.Image,
+			Price:       price,
+			Quantity:    item.Quantity,
+			Subtotal:    itemSubtotal,
+			Discount:    discount.ByProduct[item.ProductID],
+			FlashSaleID: item.FlashSaleID,
+		}
+		// Sales are the vendor's own; coupons are theirs only when they
+		// issued them
+		if price < product.Price {
+			orderItem.ListPrice = product.Price
+			if product.Sale != nil && item.FlashSaleID == nil {
+				orderItem.SaleCampaignID = &product.Sale.CampaignID
 			}
-			return models.Order{}, err
 		}
-		if res.ModifiedCount == 0 {
-			for _, p := range processedProducts {
-				productColl.UpdateOne(ctx, bson.M{"_id": p.ID}, bson.M{"$inc": bson.M{"stock": p.Qty}})
+		if orderItem.Discount > 0 {
+			orderItem.DiscountFundedBy = models.FundedByPlatform
+			if discount.VendorFunded {
+				orderItem.DiscountFundedBy = models.FundedByVendor
 			}
-			return models.Order{}, fmt.Errorf("insufficient stock for %s", item.Name)
 		}
-
-		processedProducts = append(processedProducts, struct {
-			ID  primitive.ObjectID
-			Qty int
-		}{item.ProductID, item.Quantity})
-
-		itemSubtotal := product.Price * float64(item.Quantity)
-		orderItems = append(orderItems, models.OrderItem{
-			ProductID: item.ProductID,
-			VendorID:  product.VendorID,
-			Name:      product.Name,
-			Image:     item.Image,
-			Price:     product.Price,
-			Quantity:  item.Quantity,
-			Subtotal:  itemSubtotal,
-		})
+		order.Items = append(order.Items, orderItem)
 		subtotal += itemSubtotal
 	}
 
-	shippingFee := 25.0
-	if subtotal > 500 {
-		shippingFee = 0
+	// Tax is on what the buyer pays for the goods, after the coupon
+	order.Subtotal = subtotal
+	order.Tax = (subtotal - discount.Amount).Percent(5)
+	order.Total = subtotal - discount.Amount + order.ShippingFee + order.Tax
+
+	// 2. Write the order, its history and the event announcing it
+	if _, err := r.DB.Collection("orders").InsertOne(sessCtx, order); err != nil {
+		return models.Order{}, err
+	}
+	if err := appendStatusEvent(sessCtx, r.DB, order.ID, "", order.Status, models.ActingUser(models.OrderActorBuyer, order.UserID)); err != nil {
+		return models.Order{}, err
+	}
+	placed := order.EventData()
+	placed["total"] = order.Total.Float64()
+	placed["items"] = order.EventItems()
+	if err := writeOutbox(sessCtx, r.DB, []events.Event{events.New(events.OrderPlaced, placed)}); err != nil {
+		return models.Order{}, err
 	}
-	tax := subtotal * 0.05
-	total := subtotal + shippingFee + tax
 
-	orderNumber := fmt.Sprintf("VEN-%d%d", time.Now().Unix()%100000, rand.Intn(900)+100)
-	order := models.Order{
-		ID:              primitive.NewObjectID(),
-		OrderNumber:     orderNumber,
-		UserID:          userID,
-		Items:           orderItems,
-		Subtotal:        subtotal,
-		ShippingFee:     shippingFee,
-		Tax:             tax,
-		Total:           total,
-		Status:          models.StatusPending,
-		PaymentStatus:   "pending",
-		PaymentMethod:   input.PaymentMethod,
-		ShippingAddress: input.ShippingAddress,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-
-	ctxInsert, cancelInsert := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelInsert()
-
-	_, err = orderColl.InsertOne(ctxInsert, order)
+	// 3. Empty the cart
+	_, err := r.DB.Collection("carts").UpdateOne(sessCtx, bson.M{"userId": order.UserID}, bson.M{
+		"$set":   bson.M{"items": []models.CartItem{}, "updatedAt": order.CreatedAt},
+		"$unset": bson.M{"couponCode": ""},
+	})
 	if err != nil {
-		fmt.Printf("Order creation failed: %v. Rolling back stock.\n", err)
-		// Rollback all stock
-		for _, p := range processedProducts {
-			orderColl.Database().Collection("products").UpdateOne(context.Background(), bson.M{"_id": p.ID}, bson.M{"$inc": bson.M{"stock": p.Qty}})
-		}
 		return models.Order{}, err
 	}
-
-	fmt.Printf("Order %s successfully created and saved to DB\n", order.OrderNumber)
-
-	// 5. Clear Cart (Non-critical lookup)
-	_, _ = cartColl.UpdateOne(ctx, bson.M{"userId": userID}, bson.M{"$set": bson.M{"items": []models.CartItem{}, "updatedAt": time.Now()}})
-
 	return order, nil
 }
 
-func (r *MongoOrderRepository) GetOrdersByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.Order, error) {
-	collection := r.DB.Collection("orders")
-	cursor, err := collection.Find(ctx, bson.M{"userId": userID})
-	if err != nil {
-		return nil, err
+// orderNumberAttempts is how many order numbers PlaceOrder tries before
+// giving up.
+const orderNumberAttempts = 3
+
+// nextOrderNumber takes the next number in the order sequence. The counter
+// is bumped outside any transaction, so a number given to an order that
+// then fails is skipped rather than reused, and concurrent checkouts don't
+// conflict over it.
+func nextOrderNumber(ctx context.Context, db *mongo.Database) (string, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
 	}
-	defer cursor.Close(ctx)
-
-	var orders []models.Order
-	if err := cursor.All(ctx, &orders); err != nil {
-		return nil, err
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := db.Collection("counters").FindOneAndUpdate(ctx,
+		bson.M{"_id": "orderNumber"},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		opts,
+	).Decode(&counter)
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate order number: %w", err)
 	}
-	return orders, nil
+	return models.FormatOrderNumber(counter.Seq), nil
 }
 
 func (r *MongoOrderRepository) GetOrderById(ctx context.Context, orderID primitive.ObjectID) (models.Order, error) {
@@ -163,27 +250,59 @@ func (r *MongoOrderRepository) GetOrderById(ctx context.Context, orderID primiti
 	return order, err
 }
 
-func (r *MongoOrderRepository) GetOrdersByVendorID(ctx context.Context, vendorID primitive.ObjectID) ([]models.Order, error) {
+// ListOrders returns a page of the orders matching filter and how many
+// match. A nil sort is newest first; a nil projection fetches every field.
+func (r *MongoOrderRepository) ListOrders(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D, projection bson.M) ([]models.Order, int64, error) {
 	collection := r.DB.Collection("orders")
-	// Find orders where at least one item belongs to this vendor
-	cursor, err := collection.Find(ctx, bson.M{"items.vendorId": vendorID})
+	if sort == nil {
+		sort = models.CursorSort
+	}
+	opts := options.Find().SetSort(sort).SetSkip(skip).SetLimit(limit)
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// ListOrdersPage returns up to limit orders matching filter after the
+// cursor, newest first. A nil projection fetches every field.
+func (r *MongoOrderRepository) ListOrdersPage(ctx context.Context, filter bson.M, after models.PageCursor, limit int64, projection bson.M) ([]models.Order, error) {
+	opts := options.Find().SetSort(models.CursorSort).SetLimit(limit)
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+	cursor, err := r.DB.Collection("orders").Find(ctx, after.After(filter), opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var orders []models.Order
+	orders := []models.Order{}
 	if err := cursor.All(ctx, &orders); err != nil {
 		return nil, err
 	}
 	return orders, nil
 }
 
-func (r *MongoOrderRepository) UpdateOrderStatus(ctx context.Context, orderID primitive.ObjectID, status models.OrderStatus, trackingNumber string) error {
-	collection := r.DB.Collection("orders")
-
+// UpdateOrderStatus moves the order to status, recording actor's change in
+// its history and writing outbox, the events announcing it, in the same
+// transaction.
+func (r *MongoOrderRepository) UpdateOrderStatus(ctx context.Context, orderID primitive.ObjectID, status models.OrderStatus, trackingNumber string, actor models.OrderStatusActor, outbox ...events.Event) error {
 	updateData := bson.M{
-		"status":    status,
 		"updatedAt": time.Now(),
 	}
 
@@ -191,67 +310,260 @@ func (r *MongoOrderRepository) UpdateOrderStatus(ctx context.Context, orderID pr
 		updateData["trackingNumber"] = trackingNumber
 	}
 
-	_, err := collection.UpdateOne(ctx,
+	// Timestamps feed the vendor trust signals (average ship time)
+	switch status {
+	case models.StatusShipped:
+		updateData["shippedAt"] = time.Now()
+	case models.StatusDelivered:
+		updateData["deliveredAt"] = time.Now()
+	case models.StatusCancelled:
+		updateData["cancelledAt"] = time.Now()
+	}
+
+	return WithEvents(ctx, r.DB, func(sessCtx mongo.SessionContext) error {
+		return r.changeStatus(sessCtx, bson.M{"_id": orderID}, status, updateData, actor)
+	}, outbox...)
+}
+
+// MarkPaid records that the order's payment went through, recording the
+// change in its history.
+func (r *MongoOrderRepository) MarkPaid(ctx context.Context, orderID primitive.ObjectID, paymentID string, actor models.OrderStatusActor) error {
+	set := bson.M{"paymentStatus": "paid", "updatedAt": time.Now()}
+	if paymentID != "" {
+		set["paymentId"] = paymentID
+	}
+	return WithEvents(ctx, r.DB, func(sessCtx mongo.SessionContext) error {
+		return r.changeStatus(sessCtx, bson.M{"_id": orderID}, models.StatusPaid, set, actor)
+	})
+}
+
+// SetTracking records the carrier and tracking number an order shipped with.
+func (r *MongoOrderRepository) SetTracking(ctx context.Context, orderID primitive.ObjectID, carrier, trackingNumber string) error {
+	_, err := r.DB.Collection("orders").UpdateOne(ctx,
 		bson.M{"_id": orderID},
-		bson.M{"$set": updateData})
+		bson.M{"$set": bson.M{"carrier": carrier, "trackingNumber": trackingNumber, "updatedAt": time.Now()}})
 	return err
 }
 
-func (r *MongoOrderRepository) GetVendorStats(ctx context.Context, vendorID primitive.ObjectID) (models.VendorStats, error) {
-	orderColl := r.DB.Collection("orders")
-	prodColl := r.DB.Collection("products")
+// AddShippingLabel saves a vendor's label on the order and makes its tracking
+// number the order's.
+func (r *MongoOrderRepository) AddShippingLabel(ctx context.Context, orderID primitive.ObjectID, label models.ShippingLabel) error {
+	_, err := r.DB.Collection("orders").UpdateOne(ctx,
+		bson.M{"_id": orderID},
+		bson.M{
+			"$push": bson.M{"labels": label},
+			"$set": bson.M{
+				"carrier":        label.Carrier,
+				"trackingNumber": label.TrackingNumber,
+				"updatedAt":      time.Now(),
+			},
+		})
+	return err
+}
 
-	// 1. Basic Counts & Revenue
-	cursor, err := orderColl.Find(ctx, bson.M{"items.vendorId": vendorID})
+// GetOrderByTracking finds the order a parcel belongs to, by the order's
+// tracking number or one of its labels'. It returns nil if there is none.
+func (r *MongoOrderRepository) GetOrderByTracking(ctx context.Context, trackingNumber string) (*models.Order, error) {
+	var order models.Order
+	err := r.DB.Collection("orders").FindOne(ctx, bson.M{"$or": []bson.M{
+		{"trackingNumber": trackingNumber},
+		{"labels.trackingNumber": trackingNumber},
+	}}).Decode(&order)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
 	if err != nil {
-		return models.VendorStats{}, err
+		return nil, err
 	}
-	defer cursor.Close(ctx)
+	return &order, nil
+}
 
-	var orders []models.Order
-	if err := cursor.All(ctx, &orders); err != nil {
-		return models.VendorStats{}, err
+// SaveTracking stores the order's carrier tracking statuses and timeline.
+func (r *MongoOrderRepository) SaveTracking(ctx context.Context, order models.Order) error {
+	_, err := r.DB.Collection("orders").UpdateOne(ctx,
+		bson.M{"_id": order.ID},
+		bson.M{"$set": bson.M{
+			"trackingStatus": order.TrackingStatus,
+			"labels":         order.Labels,
+			"timeline":       order.Timeline,
+			"updatedAt":      time.Now(),
+		}})
+	return err
+}
+
+// GetVendorInventorySales totals the units and revenue of each of the
+// vendor's products in paid orders placed in [from, to), and when each last
+// sold before to. Products that never sold are missing.
+func (r *MongoOrderRepository) GetVendorInventorySales(ctx context.Context, vendorID primitive.ObjectID, from, to time.Time) ([]models.InventorySales, error) {
+	inRange := bson.M{"$gte": bson.A{"$createdAt", from}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"items.vendorId": vendorID,
+			"status":         bson.M{"$in": models.PaidOrderStatuses},
+			"createdAt":      bson.M{"$lt": to},
+		}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$match", Value: bson.M{"items.vendorId": vendorID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$items.productId",
+			"unitsSold":  bson.M{"$sum": bson.M{"$cond": bson.A{inRange, "$items.quantity", 0}}},
+			"revenue":    bson.M{"$sum": bson.M{"$cond": bson.A{inRange, "$items.subtotal", 0}}},
+			"lastSoldAt": bson.M{"$max": "$createdAt"},
+		}}},
+	}
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var sales []models.InventorySales
+	if err := cursor.All(ctx, &sales); err != nil {
+		return nil, err
 	}
+	return sales, nil
+}
 
+// GetVendorStats reports the vendor's sales over q's range, broken down into
+// buckets of q.Granularity.
+func (r *MongoOrderRepository) GetVendorStats(ctx context.Context, vendorID primitive.ObjectID, q models.VendorStatsQuery) (models.VendorStats, error) {
 	stats := models.VendorStats{
-		StatusBreakdown:  make(map[string]int),
-		SalesPerformance: make([]models.DailySales, 0),
+		From:            q.From,
+		To:              q.To,
+		Granularity:     q.Granularity,
+		TopProducts:     []models.ProductSales{},
+		Sales:           []models.SalesBucket{},
+		StatusBreakdown: map[string]int{},
 	}
 
-	dailyMap := make(map[string]*models.DailySales)
+	// The vendor's own lines of paid orders, each tagged with its bucket
+	vendorLines := bson.A{
+		bson.M{"$match": bson.M{"status": bson.M{"$in": models.PaidOrderStatuses}}},
+		bson.M{"$unwind": "$items"},
+		bson.M{"$match": bson.M{"items.vendorId": vendorID}},
+		bson.M{"$set": bson.M{"bucket": bson.M{"$dateTrunc": bson.M{
+			"date":        "$createdAt",
+			"unit":        q.Granularity,
+			"startOfWeek": "monday",
+			"timezone":    "UTC",
+		}}}},
+	}
+	fromVendorLines := func(stages ...interface{}) bson.A {
+		return append(append(bson.A{}, vendorLines...), stages...)
+	}
+	productSales := func(id interface{}) bson.M {
+		return bson.M{"$group": bson.M{
+			"_id":       id,
+			"productId": bson.M{"$first": "$items.productId"},
+			"name":      bson.M{"$last": "$items.name"},
+			"revenue":   bson.M{"$sum": "$items.subtotal"},
+			"unitsSold": bson.M{"$sum": "$items.quantity"},
+		}}
+	}
 
-	for _, order := range orders {
-		stats.TotalOrders++
-		stats.StatusBreakdown[string(order.Status)]++
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"items.vendorId": vendorID,
+			"createdAt":      bson.M{"$gte": q.From, "$lt": q.To},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"byStatus": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"buckets": fromVendorLines(
+				bson.M{"$group": bson.M{
+					"_id":       bson.M{"bucket": "$bucket", "order": "$_id"},
+					"revenue":   bson.M{"$sum": "$items.subtotal"},
+					"unitsSold": bson.M{"$sum": "$items.quantity"},
+				}},
+				bson.M{"$group": bson.M{
+					"_id":       "$_id.bucket",
+					"revenue":   bson.M{"$sum": "$revenue"},
+					"orders":    bson.M{"$sum": 1},
+					"unitsSold": bson.M{"$sum": "$unitsSold"},
+				}},
+			),
+			"bucketProducts": fromVendorLines(
+				productSales(bson.M{"bucket": "$bucket", "product": "$items.productId"}),
+				bson.M{"$sort": bson.D{{Key: "revenue", Value: -1}, {Key: "productId", Value: 1}}},
+				bson.M{"$group": bson.M{
+					"_id": "$_id.bucket",
+					"products": bson.M{"$push": bson.M{
+						"productId": "$productId",
+						"name":      "$name",
+						"revenue":   "$revenue",
+						"unitsSold": "$unitsSold",
+					}},
+				}},
+				bson.M{"$project": bson.M{"products": bson.M{"$slice": bson.A{"$products", q.TopProducts}}}},
+			),
+			"topProducts": fromVendorLines(
+				productSales("$items.productId"),
+				bson.M{"$sort": bson.D{{Key: "revenue", Value: -1}, {Key: "productId", Value: 1}}},
+				bson.M{"$limit": q.TopProducts},
+			),
+		}}},
+	}
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return stats, err
+	}
+	var facets []struct {
+		ByStatus []struct {
+			Status string `bson:"_id"`
+			Count  int    `bson:"count"`
+		} `bson:"byStatus"`
+		Buckets        []models.SalesBucket `bson:"buckets"`
+		BucketProducts []struct {
+			Start    time.Time             `bson:"_id"`
+			Products []models.ProductSales `bson:"products"`
+		} `bson:"bucketProducts"`
+		TopProducts []models.ProductSales `bson:"topProducts"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return stats, err
+	}
 
-		vendorTotal := 0.0
-		for _, item := range order.Items {
-			if item.VendorID == vendorID {
-				vendorTotal += item.Subtotal
+	buckets := map[int64]*models.SalesBucket{}
+	for _, start := range models.SalesBuckets(q.From, q.To, q.Granularity) {
+		stats.Sales = append(stats.Sales, models.SalesBucket{Start: start, TopProducts: []models.ProductSales{}})
+	}
+	for i := range stats.Sales {
+		buckets[stats.Sales[i].Start.Unix()] = &stats.Sales[i]
+	}
+	if len(facets) > 0 {
+		f := facets[0]
+		for _, s := range f.ByStatus {
+			stats.StatusBreakdown[s.Status] = s.Count
+		}
+		for _, b := range f.Buckets {
+			bucket, ok := buckets[b.Start.Unix()]
+			if !ok {
+				continue
 			}
+			bucket.Revenue, bucket.Orders, bucket.UnitsSold = b.Revenue, b.Orders, b.UnitsSold
+			if b.Orders > 0 {
+				bucket.AvgOrderValue = b.Revenue / models.Money(b.Orders)
+			}
+			stats.TotalRevenue += b.Revenue
+			stats.TotalOrders += b.Orders
+			stats.UnitsSold += b.UnitsSold
 		}
-		stats.TotalRevenue += vendorTotal
-
-		// Sales Performance (Daily)
-		dateStr := order.CreatedAt.Format("2006-01-02")
-		if _, ok := dailyMap[dateStr]; !ok {
-			dailyMap[dateStr] = &models.DailySales{Date: dateStr}
+		for _, b := range f.BucketProducts {
+			if bucket, ok := buckets[b.Start.Unix()]; ok {
+				bucket.TopProducts = b.Products
+			}
+		}
+		if f.TopProducts != nil {
+			stats.TopProducts = f.TopProducts
 		}
-		dailyMap[dateStr].Revenue += vendorTotal
-		dailyMap[dateStr].Orders++
-	}
-
-	// Transform daily map to sorted slice (simplified: just convert)
-	for _, v := range dailyMap {
-		stats.SalesPerformance = append(stats.SalesPerformance, *v)
 	}
-
 	if stats.TotalOrders > 0 {
-		stats.AvgOrderValue = stats.TotalRevenue / float64(stats.TotalOrders)
+		stats.AvgOrderValue = stats.TotalRevenue / models.Money(stats.TotalOrders)
 	}
 
-	// 2. Total Products
-	prodCount, _ := prodColl.CountDocuments(ctx, bson.M{"vendorId": vendorID})
+	prodCount, err := r.DB.Collection("products").CountDocuments(ctx, bson.M{"vendorId": vendorID})
+	if err != nil {
+		return stats, err
+	}
 	stats.TotalProducts = int(prodCount)
 
 	return stats, nil
@@ -277,7 +589,7 @@ func (r *MongoOrderRepository) GetBuyerStats(ctx context.Context, userID primiti
 
 	if len(orders) > 0 {
 		stats.TotalAcquisitions = len(orders)
-		var totalSpent float64
+		var totalSpent models.Money
 		var lastOrder time.Time
 
 		for _, o := range orders {
@@ -301,3 +613,248 @@ func (r *MongoOrderRepository) GetBuyerStats(ctx context.Context, userID primiti
 
 	return stats, nil
 }
+
+func (r *MongoOrderRepository) GetVendorFulfillmentStats(ctx context.Context, vendorID primitive.ObjectID) (models.VendorFulfillmentStats, error) {
+	collection := r.DB.Collection("orders")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"items.vendorId": vendorID,
+			"status":         bson.M{"$ne": models.StatusPending},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": 1},
+			"fulfilled": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$in": []interface{}{"$status", []models.OrderStatus{models.StatusShipped, models.StatusOutForDelivery, models.StatusDelivered}}}, 1, 0,
+			}}},
+			"cancelled": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{"$status", models.StatusCancelled}}, 1, 0,
+			}}},
+			"avgShipMs": bson.M{"$avg": bson.M{"$cond": []interface{}{
+				bson.M{"$ifNull": []interface{}{"$shippedAt", false}},
+				bson.M{"$subtract": []interface{}{"$shippedAt", "$createdAt"}},
+				nil,
+			}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.VendorFulfillmentStats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total     int     `bson:"total"`
+		Fulfilled int     `bson:"fulfilled"`
+		Cancelled int     `bson:"cancelled"`
+		AvgShipMs float64 `bson:"avgShipMs"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return models.VendorFulfillmentStats{}, err
+	}
+
+	stats := models.VendorFulfillmentStats{}
+	if len(results) == 0 {
+		return stats, nil
+	}
+
+	res := results[0]
+	stats.TotalOrders = res.Total
+	stats.FulfilledOrders = res.Fulfilled
+	stats.CancelledOrders = res.Cancelled
+	stats.AvgShipHours = res.AvgShipMs / float64(time.Hour/time.Millisecond)
+	if res.Total > 0 {
+		stats.FulfillmentRate = float64(res.Fulfilled) * 100 / float64(res.Total)
+	}
+	return stats, nil
+}
+
+// ReserveRefund records a pending refund against the order before the money
+// moves, so two concurrent refunds can never exceed the order total.
+func (r *MongoOrderRepository) ReserveRefund(ctx context.Context, orderID primitive.ObjectID, refund models.OrderRefund) error {
+	res, err := r.DB.Collection("orders").UpdateOne(ctx,
+		bson.M{
+			"_id": orderID,
+			"$expr": bson.M{"$lte": bson.A{
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refundedAmount", 0}}, refund.Amount}},
+				bson.M{"$add": bson.A{"$total", 0.001}},
+			}},
+		},
+		bson.M{
+			"$inc":  bson.M{"refundedAmount": refund.Amount},
+			"$push": bson.M{"refunds": refund},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrRefundExceedsTotal
+	}
+	return nil
+}
+
+// CompleteRefund marks a reserved refund as paid out and updates the order's
+// payment status, moving it to refunded once nothing is left to refund.
+// outbox, the events announcing it, are written in the same transaction.
+func (r *MongoOrderRepository) CompleteRefund(ctx context.Context, orderID, refundID primitive.ObjectID, stripeRefundID string, actor models.OrderStatusActor, outbox ...events.Event) (models.Order, error) {
+	collection := r.DB.Collection("orders")
+	now := time.Now()
+
+	err := WithEvents(ctx, r.DB, func(sessCtx mongo.SessionContext) error {
+		_, err := collection.UpdateOne(sessCtx,
+			bson.M{"_id": orderID, "refunds._id": refundID},
+			bson.M{"$set": bson.M{
+				"refunds.$.status":         models.RefundStatusSucceeded,
+				"refunds.$.stripeRefundId": stripeRefundID,
+				"updatedAt":                now,
+			}},
+		)
+		if err != nil {
+			return err
+		}
+
+		order, err := r.GetOrderById(sessCtx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if order.RefundableAmount() == 0 {
+			return r.changeStatus(sessCtx, bson.M{"_id": orderID}, models.StatusRefunded, bson.M{"paymentStatus": "refunded"}, actor)
+		}
+		_, err = collection.UpdateOne(sessCtx, bson.M{"_id": orderID}, bson.M{"$set": bson.M{"paymentStatus": "partially_refunded"}})
+		return err
+	}, outbox...)
+	if err != nil {
+		return models.Order{}, err
+	}
+	return r.GetOrderById(ctx, orderID)
+}
+
+// ReleaseRefund undoes a reservation whose payout failed.
+func (r *MongoOrderRepository) ReleaseRefund(ctx context.Context, orderID primitive.ObjectID, refund models.OrderRefund) error {
+	_, err := r.DB.Collection("orders").UpdateOne(ctx,
+		bson.M{"_id": orderID},
+		bson.M{
+			"$inc":  bson.M{"refundedAmount": -refund.Amount},
+			"$pull": bson.M{"refunds": bson.M{"_id": refund.ID}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
+
+// CancelOrder cancels the order if it is still in the status it was read
+// with, optionally putting its items back in stock. outbox, the events
+// announcing it, are written in the same transaction.
+func (r *MongoOrderRepository) CancelOrder(ctx context.Context, order models.Order, restock bool, actor models.OrderStatusActor, outbox ...events.Event) error {
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+		err := r.changeStatus(sessCtx,
+			bson.M{"_id": order.ID, "status": order.Status},
+			models.StatusCancelled,
+			bson.M{"cancelledAt": now, "updatedAt": now},
+			actor,
+		)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrOrderStatusChanged
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if restock {
+			for _, item := range order.Items {
+				if _, err := r.DB.Collection("products").UpdateOne(sessCtx,
+					bson.M{"_id": item.ProductID},
+					bson.M{"$inc": bson.M{"stock": item.Quantity}},
+				); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, writeOutbox(sessCtx, r.DB, outbox)
+	}
+
+	_, err = session.WithTransaction(ctx, callback)
+	return err
+}
+
+// SavePickup stores the order's shipments and timeline after a collection
+// is readied or made.
+func (r *MongoOrderRepository) SavePickup(ctx context.Context, order models.Order) error {
+	_, err := r.DB.Collection("orders").UpdateOne(ctx,
+		bson.M{"_id": order.ID},
+		bson.M{"$set": bson.M{
+			"shipments": order.Shipments,
+			"timeline":  order.Timeline,
+			"updatedAt": time.Now(),
+		}})
+	return err
+}
+
+// changeStatus moves the order matching filter to status, setting set too,
+// and adds the change to its history, as part of the transaction ctx belongs
+// to. The status it moves from is read by the same write, so two changes
+// can't both claim to have started from it. Setting the status it already
+// has adds nothing to the history.
+func (r *MongoOrderRepository) changeStatus(ctx mongo.SessionContext, filter bson.M, status models.OrderStatus, set bson.M, actor models.OrderStatusActor) error {
+	set["status"] = status
+	var before struct {
+		ID     primitive.ObjectID `bson:"_id"`
+		Status models.OrderStatus `bson:"status"`
+	}
+	opts := options.FindOneAndUpdate().SetProjection(bson.M{"status": 1})
+	err := r.DB.Collection("orders").FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, opts).Decode(&before)
+	if err != nil {
+		return err
+	}
+	if before.Status == status {
+		return nil
+	}
+	return appendStatusEvent(ctx, r.DB, before.ID, before.Status, status, actor)
+}
+
+// appendStatusEvent adds a change to the end of an order's history.
+func appendStatusEvent(ctx context.Context, db *mongo.Database, orderID primitive.ObjectID, from, to models.OrderStatus, actor models.OrderStatusActor) error {
+	collection := db.Collection("orderStatusEvents")
+	sequence := 1
+	var latest models.OrderStatusEvent
+	opts := options.FindOne().SetSort(bson.M{"sequence": -1}).SetProjection(bson.M{"sequence": 1})
+	err := collection.FindOne(ctx, bson.M{"orderId": orderID}, opts).Decode(&latest)
+	switch {
+	case err == nil:
+		sequence = latest.Sequence + 1
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return err
+	}
+	_, err = collection.InsertOne(ctx, models.NewOrderStatusEvent(orderID, sequence, from, to, actor))
+	return err
+}
+
+// GetStatusHistory returns every change of the order's status, oldest first.
+// Orders placed before history was kept start from the first change made
+// after.
+func (r *MongoOrderRepository) GetStatusHistory(ctx context.Context, orderID primitive.ObjectID) ([]models.OrderStatusEvent, error) {
+	opts := options.Find().SetSort(bson.M{"sequence": 1})
+	cursor, err := r.DB.Collection("orderStatusEvents").Find(ctx, bson.M{"orderId": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	history := []models.OrderStatusEvent{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OutboxRepository hands the relay the domain events waiting in the outbox.
+// Events get there by being written in the transaction that made the change
+// they announce, with WithEvents or a repository method that takes them.
+type OutboxRepository interface {
+	Claim(ctx context.Context, lease time.Duration) (*models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id primitive.ObjectID) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, cause error) error
+}
+
+type MongoOutboxRepository struct {
+	DB *mongo.Database
+}
+
+func NewOutboxRepository(db *mongo.Database) OutboxRepository {
+	return &MongoOutboxRepository{DB: db}
+}
+
+// WithEvents runs fn in a transaction and writes outbox to the outbox in the
+// same transaction, so the events are published if and only if fn's writes
+// are committed.
+func WithEvents(ctx context.Context, db *mongo.Database, fn func(sessCtx mongo.SessionContext) error, outbox ...events.Event) error {
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := fn(sessCtx); err != nil {
+			return nil, err
+		}
+		return nil, writeOutbox(sessCtx, db, outbox)
+	})
+	return err
+}
+
+// writeOutbox adds events to the outbox as part of the transaction ctx
+// belongs to.
+func writeOutbox(ctx context.Context, db *mongo.Database, outbox []events.Event) error {
+	if len(outbox) == 0 {
+		return nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, len(outbox))
+	for i, e := range outbox {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encode %s event: %w", e.Type, err)
+		}
+		docs[i] = models.OutboxEvent{EventID: e.ID, Type: e.Type, Payload: string(payload), CreatedAt: now}
+	}
+	_, err := db.Collection("outbox").InsertMany(ctx, docs)
+	return err
+}
+
+// Claim locks the oldest unpublished event for lease and returns it, or nil
+// if there is nothing to publish. An event whose lease ran out, because the
+// relay holding it stopped, can be claimed again.
+func (r *MongoOutboxRepository) Claim(ctx context.Context, lease time.Duration) (*models.OutboxEvent, error) {
+	now := time.Now()
+	filter := bson.M{
+		"publishedAt": nil,
+		"attempts":    bson.M{"$lt": models.OutboxMaxAttempts},
+		"$or":         bson.A{bson.M{"lockedUntil": nil}, bson.M{"lockedUntil": bson.M{"$lte": now}}},
+	}
+	update := bson.M{
+		"$set": bson.M{"lockedUntil": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var e models.OutboxEvent
+	err := r.DB.Collection("outbox").FindOneAndUpdate(ctx, filter, update, opts).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// MarkPublished records that every subscriber has had the event.
+func (r *MongoOutboxRepository) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DB.Collection("outbox").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"publishedAt": time.Now()},
+			"$unset": bson.M{"lockedUntil": "", "lastError": ""},
+		},
+	)
+	return err
+}
+
+// MarkFailed records why the event couldn't be published. It is tried again
+// once its lease runs out, up to models.OutboxMaxAttempts times.
+func (r *MongoOutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, cause error) error {
+	_, err := r.DB.Collection("outbox").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"lastError": cause.Error()}},
+	)
+	return err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,13 +14,16 @@ import (
 )
 
 type ProductRepository interface {
-	FetchProductsPublic(ctx context.Context, filter bson.M, sort bson.M, limit, skip int) ([]models.Product, int64, error)
+	FetchProductsPublic(ctx context.Context, filter bson.M, sort bson.M, limit, skip int, projection bson.M) ([]models.Product, int64, error)
+	FetchProductsPublicPage(ctx context.Context, filter bson.M, after models.PageCursor, limit int, projection bson.M) ([]models.Product, error)
+	CountProductsPublic(ctx context.Context, filter bson.M) (int64, error)
 	FetchProductsPublicById(ctx context.Context, filter bson.M) (models.Product, error)
 	CreateProduct(ctx context.Context, product models.Product) (models.Product, error)
-	GetVendorProducts(ctx context.Context, filter bson.M, limit, skip int64) ([]models.Product, int64, error)
+	GetVendorProducts(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D, projection bson.M) ([]models.Product, int64, error)
 	UpdateProduct(ctx context.Context, filter bson.M, update models.UpdateProductInput) (bool, error)
 	GetProduct(ctx context.Context, filter bson.M) (models.Product, error)
 	DeleteProduct(ctx context.Context, productID primitive.ObjectID, vendorID primitive.ObjectID) error
+	ListFeedProducts(ctx context.Context) ([]models.Product, error)
 }
 
 type MongoProductRepository struct {
@@ -30,27 +34,20 @@ func NewProductRepository(db *mongo.Database) ProductRepository {
 	return &MongoProductRepository{DB: db}
 }
 
-func (r *MongoProductRepository) FetchProductsPublic(ctx context.Context, filter bson.M, sort bson.M, limit, skip int) ([]models.Product, int64, error) {
+// FetchProductsPublic returns a page of the products matching filter, and
+// how many match. A nil projection fetches every field.
+func (r *MongoProductRepository) FetchProductsPublic(ctx context.Context, filter bson.M, sort bson.M, limit, skip int, projection bson.M) ([]models.Product, int64, error) {
+	filter = NotDeleted(filter)
 	collection := r.DB.Collection("products")
 
 	pipeline := []bson.M{
 		{"$match": filter},
-		{"$lookup": bson.M{
-			"from":         "users",
-			"localField":   "vendorId",
-			"foreignField": "_id",
-			"as":           "vendor",
-		}},
-		{"$unwind": bson.M{"path": "$vendor", "preserveNullAndEmptyArrays": true}},
-		{"$addFields": bson.M{
-			"vendorName":     "$vendor.name",
-			"vendorLocation": "$vendor.profile.location",
-		}},
-		{"$project": bson.M{"vendor": 0, "costPrice": 0}},
 		{"$sort": sort},
 		{"$skip": int64(skip)},
 		{"$limit": int64(limit)},
 	}
+	pipeline = append(pipeline, publicProductVendorStages()...)
+	pipeline = append(pipeline, projectStages(projection)...)
 
 	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -71,7 +68,65 @@ func (r *MongoProductRepository) FetchProductsPublic(ctx context.Context, filter
 	return products, total, nil
 }
 
+// FetchProductsPublicPage returns up to limit products matching filter after
+// the cursor, newest first. A nil projection fetches every field.
+func (r *MongoProductRepository) FetchProductsPublicPage(ctx context.Context, filter bson.M, after models.PageCursor, limit int, projection bson.M) ([]models.Product, error) {
+	filter = NotDeleted(filter)
+	pipeline := []bson.M{
+		{"$match": after.After(filter)},
+		{"$sort": models.CursorSort},
+		{"$limit": int64(limit)},
+	}
+	pipeline = append(pipeline, publicProductVendorStages()...)
+	pipeline = append(pipeline, projectStages(projection)...)
+
+	cursor, err := r.DB.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *MongoProductRepository) CountProductsPublic(ctx context.Context, filter bson.M) (int64, error) {
+	return r.DB.Collection("products").CountDocuments(ctx, NotDeleted(filter))
+}
+
+// publicProductVendorStages adds the vendor's name and location to products
+// and drops fields the storefront mustn't see.
+func publicProductVendorStages() []bson.M {
+	return []bson.M{
+		{"$lookup": bson.M{
+			"from":         "users",
+			"localField":   "vendorId",
+			"foreignField": "_id",
+			"as":           "vendor",
+		}},
+		{"$unwind": bson.M{"path": "$vendor", "preserveNullAndEmptyArrays": true}},
+		{"$addFields": bson.M{
+			"vendorName":     "$vendor.name",
+			"vendorLocation": "$vendor.profile.location",
+		}},
+		{"$project": bson.M{"vendor": 0, "costPrice": 0}},
+	}
+}
+
+// projectStages trims a pipeline's documents to projection, after any
+// fields added by lookups. A nil projection keeps them whole.
+func projectStages(projection bson.M) []bson.M {
+	if projection == nil {
+		return nil
+	}
+	return []bson.M{{"$project": projection}}
+}
+
 func (r *MongoProductRepository) FetchProductsPublicById(ctx context.Context, filter bson.M) (models.Product, error) {
+	filter = NotDeleted(filter)
 	collection := r.DB.Collection("products")
 
 	pipeline := []bson.M{
@@ -87,7 +142,18 @@ func (r *MongoProductRepository) FetchProductsPublicById(ctx context.Context, fi
 			"vendorName":     "$vendor.name",
 			"vendorLocation": "$vendor.profile.location",
 		}},
-		{"$project": bson.M{"vendor": 0, "costPrice": 0}},
+		{"$lookup": bson.M{
+			"from":         "stores",
+			"localField":   "vendorId",
+			"foreignField": "vendorID",
+			"as":           "store",
+		}},
+		{"$unwind": bson.M{"path": "$store", "preserveNullAndEmptyArrays": true}},
+		{"$addFields": bson.M{
+			"storeSlug":     "$store.slug",
+			"storePolicies": "$store.policies",
+		}},
+		{"$project": bson.M{"vendor": 0, "store": 0, "costPrice": 0}},
 		{"$limit": 1},
 	}
 
@@ -147,9 +213,19 @@ func (r *MongoProductRepository) CreateProduct(ctx context.Context, product mode
 	return result.(models.Product), nil
 }
 
-func (r *MongoProductRepository) GetVendorProducts(ctx context.Context, filter bson.M, limit, skip int64) ([]models.Product, int64, error) {
+// GetVendorProducts returns a page of the products matching filter and how
+// many match. A nil sort is newest first; a nil projection fetches every
+// field.
+func (r *MongoProductRepository) GetVendorProducts(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D, projection bson.M) ([]models.Product, int64, error) {
+	filter = NotDeleted(filter)
 	collection := r.DB.Collection("products")
-	opts := options.Find().SetSkip(skip).SetLimit(limit).SetSort(bson.M{"createdAt": -1})
+	if sort == nil {
+		sort = models.CursorSort
+	}
+	opts := options.Find().SetSkip(skip).SetLimit(limit).SetSort(sort)
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
 
 	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
@@ -171,6 +247,7 @@ func (r *MongoProductRepository) GetVendorProducts(ctx context.Context, filter b
 }
 
 func (r *MongoProductRepository) UpdateProduct(ctx context.Context, filter bson.M, input models.UpdateProductInput) (bool, error) {
+	filter = NotDeleted(filter)
 	collection := r.DB.Collection("products")
 	update := bson.M{"$set": input}
 
@@ -183,6 +260,7 @@ func (r *MongoProductRepository) UpdateProduct(ctx context.Context, filter bson.
 }
 
 func (r *MongoProductRepository) GetProduct(ctx context.Context, filter bson.M) (models.Product, error) {
+	filter = NotDeleted(filter)
 	collection := r.DB.Collection("products")
 	var product models.Product
 	if err := collection.FindOne(ctx, filter).Decode(&product); err != nil {
@@ -191,32 +269,29 @@ func (r *MongoProductRepository) GetProduct(ctx context.Context, filter bson.M)
 	return product, nil
 }
 
+// DeleteProduct soft-deletes the vendor's product; see trash_repository.go.
 func (r *MongoProductRepository) DeleteProduct(ctx context.Context, productID primitive.ObjectID, vendorID primitive.ObjectID) error {
-	session, err := r.DB.Client().StartSession()
-	if err != nil {
-		return fmt.Errorf("failed to start session: %v", err)
+	err := softDelete(ctx, r.DB, models.TrashProducts, bson.M{"_id": productID, "vendorId": vendorID}, vendorID)
+	if errors.Is(err, ErrTrashNotFound) {
+		return fmt.Errorf("product not found or unauthorized")
 	}
-	defer session.EndSession(ctx)
-
-	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
-		collection := r.DB.Collection("products")
-		filter := bson.M{"_id": productID, "vendorId": vendorID}
-		res, err := collection.DeleteOne(sessCtx, filter)
-		if err != nil {
-			return nil, err
-		}
-		if res.DeletedCount == 0 {
-			return nil, fmt.Errorf("product not found or unauthorized")
-		}
+	return err
+}
 
-		vendorAcc := r.DB.Collection("vendorAccounts")
-		_, err = vendorAcc.UpdateOne(sessCtx, bson.M{"userID": vendorID}, bson.M{"$inc": bson.M{"productCount": -1}})
-		if err != nil {
-			return nil, err
-		}
-		return nil, nil
+// ListFeedProducts returns every active product, for the shopping feeds.
+func (r *MongoProductRepository) ListFeedProducts(ctx context.Context) ([]models.Product, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"costPrice": 0, "translations": 0})
+	cursor, err := r.DB.Collection("products").Find(ctx, NotDeleted(bson.M{"status": models.ProductStatusActive}), opts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	_, err = session.WithTransaction(ctx, callback)
-	return err
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
 }
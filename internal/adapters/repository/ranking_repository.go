@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RankingRepository interface {
+	RecordView(ctx context.Context, productID primitive.ObjectID) error
+	RebuildTrending(ctx context.Context, now time.Time) error
+	RebuildBestSellers(ctx context.Context, now time.Time) error
+	ListRankedProductIDs(ctx context.Context, kind string, categoryID primitive.ObjectID, limit int64) ([]primitive.ObjectID, error)
+}
+
+type MongoRankingRepository struct {
+	DB *mongo.Database
+}
+
+func NewRankingRepository(db *mongo.Database) RankingRepository {
+	return &MongoRankingRepository{DB: db}
+}
+
+// RecordView counts a product page view in today's bucket.
+func (r *MongoRankingRepository) RecordView(ctx context.Context, productID primitive.ObjectID) error {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	_, err := r.DB.Collection("productViews").UpdateOne(ctx,
+		bson.M{"productId": productID, "day": day},
+		bson.M{"$inc": bson.M{"count": 1}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// paidItemsSince unwinds the lines of paid orders placed since the given
+// time into {productId, units, views: 0} rows.
+func paidItemsSince(since time.Time) []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"status": bson.M{"$in": models.PaidOrderStatuses}, "createdAt": bson.M{"$gte": since}}},
+		{"$unwind": "$items"},
+		{"$project": bson.M{"_id": 0, "productId": "$items.productId", "units": "$items.quantity", "views": bson.M{"$literal": 0}}},
+	}
+}
+
+// rankAndMerge finishes a ranking pipeline whose rows are grouped
+// {_id: productId, units, views}: it keeps active products, scores them and
+// merges them into productRankings.
+func rankAndMerge(kind string, score interface{}, now time.Time) []bson.M {
+	return []bson.M{
+		{"$lookup": bson.M{
+			"from":         "products",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$match": NotDeleted(bson.M{"status": models.ProductStatusActive})}, bson.M{"$project": bson.M{"categoryId": 1, "subCategoryIds": 1}}},
+			"as":           "product",
+		}},
+		{"$unwind": "$product"},
+		{"$project": bson.M{
+			"_id":       bson.M{"$concat": bson.A{kind + ":", bson.M{"$toString": "$_id"}}},
+			"kind":      kind,
+			"productId": "$_id",
+			"categoryIds": bson.M{"$concatArrays": bson.A{
+				bson.A{"$product.categoryId"},
+				bson.M{"$ifNull": bson.A{"$product.subCategoryIds", bson.A{}}},
+			}},
+			"unitsSold":  "$units",
+			"views":      "$views",
+			"score":      score,
+			"computedAt": now,
+		}},
+		{"$match": bson.M{"score": bson.M{"$gt": 0}}},
+		{"$merge": bson.M{"into": "productRankings", "on": "_id", "whenMatched": "replace", "whenNotMatched": "insert"}},
+	}
+}
+
+// RebuildTrending scores products by units sold and page views over the
+// trending window, weighting a sale as models.TrendingUnitWeight views.
+func (r *MongoRankingRepository) RebuildTrending(ctx context.Context, now time.Time) error {
+	// Stored dates have millisecond precision; match what gets written
+	now = now.Truncate(time.Millisecond)
+	since := now.Add(-models.TrendingWindow)
+
+	pipeline := paidItemsSince(since)
+	pipeline = append(pipeline,
+		bson.M{"$unionWith": bson.M{
+			"coll": "productViews",
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"day": bson.M{"$gte": since.UTC().Truncate(24 * time.Hour)}}},
+				bson.M{"$project": bson.M{"_id": 0, "productId": 1, "units": bson.M{"$literal": 0}, "views": "$count"}},
+			},
+		}},
+		bson.M{"$group": bson.M{"_id": "$productId", "units": bson.M{"$sum": "$units"}, "views": bson.M{"$sum": "$views"}}},
+	)
+	score := bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{"$units", models.TrendingUnitWeight}}, "$views"}}
+	pipeline = append(pipeline, rankAndMerge(models.RankingTrending, score, now)...)
+
+	return r.rebuild(ctx, models.RankingTrending, pipeline, now)
+}
+
+// RebuildBestSellers scores products by units sold over the best-sellers
+// window.
+func (r *MongoRankingRepository) RebuildBestSellers(ctx context.Context, now time.Time) error {
+	now = now.Truncate(time.Millisecond)
+	pipeline := paidItemsSince(now.Add(-models.BestSellersWindow))
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": "$productId", "units": bson.M{"$sum": "$units"}, "views": bson.M{"$sum": "$views"}}},
+	)
+	pipeline = append(pipeline, rankAndMerge(models.RankingBestSellers, "$units", now)...)
+
+	return r.rebuild(ctx, models.RankingBestSellers, pipeline, now)
+}
+
+// rebuild runs a merging pipeline over orders, then drops rankings of this
+// kind that the run didn't refresh.
+func (r *MongoRankingRepository) rebuild(ctx context.Context, kind string, pipeline []bson.M, now time.Time) error {
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	cursor.Close(ctx)
+
+	_, err = r.DB.Collection("productRankings").DeleteMany(ctx, bson.M{"kind": kind, "computedAt": bson.M{"$lt": now}})
+	return err
+}
+
+// ListRankedProductIDs returns the top products of a ranking, optionally in
+// one category.
+func (r *MongoRankingRepository) ListRankedProductIDs(ctx context.Context, kind string, categoryID primitive.ObjectID, limit int64) ([]primitive.ObjectID, error) {
+	filter := bson.M{"kind": kind}
+	if !categoryID.IsZero() {
+		filter["categoryIds"] = categoryID
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "score", Value: -1}, {Key: "productId", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"productId": 1})
+	cursor, err := r.DB.Collection("productRankings").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rankings []models.ProductRanking
+	if err := cursor.All(ctx, &rankings); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(rankings))
+	for i, ranking := range rankings {
+		ids[i] = ranking.ProductID
+	}
+	return ids, nil
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recommendationHistory bounds how far back order history is read.
+const recommendationHistory = 180 * 24 * time.Hour
+
+// PurchasedProduct is a product a user has bought, with how many times.
+type PurchasedProduct struct {
+	ProductID  primitive.ObjectID `bson:"_id"`
+	CategoryID primitive.ObjectID `bson:"categoryId"`
+	Lines      int                `bson:"lines"`
+}
+
+type RecommendationRepository interface {
+	ResolveCategories(ctx context.Context, refs []string) ([]primitive.ObjectID, error)
+	GetPurchasedProducts(ctx context.Context, userID primitive.ObjectID) ([]PurchasedProduct, error)
+	GetCoPurchases(ctx context.Context, userID primitive.ObjectID, productIDs []primitive.ObjectID, limit int64) (map[primitive.ObjectID]int, error)
+	GetCandidates(ctx context.Context, categoryIDs, productIDs []primitive.ObjectID, limit int64) ([]models.Product, error)
+}
+
+type MongoRecommendationRepository struct {
+	DB *mongo.Database
+}
+
+func NewRecommendationRepository(db *mongo.Database) RecommendationRepository {
+	return &MongoRecommendationRepository{DB: db}
+}
+
+// ResolveCategories maps interest entries to active categories. Onboarding
+// stores whatever the client sent, so an entry may be a category ID, slug or
+// name.
+func (r *MongoRecommendationRepository) ResolveCategories(ctx context.Context, refs []string) ([]primitive.ObjectID, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	var or bson.A
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		if id, err := primitive.ObjectIDFromHex(ref); err == nil {
+			or = append(or, bson.M{"_id": id})
+			continue
+		}
+		or = append(or,
+			bson.M{"slug": strings.ToLower(ref)},
+			bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(ref) + "$", "$options": "i"}},
+		)
+	}
+	if len(or) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.DB.Collection("categories").Find(ctx,
+		bson.M{"isActive": true, "$or": or},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids, nil
+}
+
+// GetPurchasedProducts returns what the user bought on paid orders in the
+// recent past, with each product's category.
+func (r *MongoRecommendationRepository) GetPurchasedProducts(ctx context.Context, userID primitive.ObjectID) ([]PurchasedProduct, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"userId":    userID,
+			"status":    bson.M{"$in": models.PaidOrderStatuses},
+			"createdAt": bson.M{"$gte": time.Now().Add(-recommendationHistory)},
+		}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$group", Value: bson.M{"_id": "$items.productId", "lines": bson.M{"$sum": 1}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"categoryId": 1}}},
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: "$product"}},
+		{{Key: "$project", Value: bson.M{"lines": 1, "categoryId": "$product.categoryId"}}},
+	}
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	purchased := []PurchasedProduct{}
+	if err := cursor.All(ctx, &purchased); err != nil {
+		return nil, err
+	}
+	return purchased, nil
+}
+
+// GetCoPurchases finds other buyers who bought any of productIDs and counts,
+// per product, how many of them also bought it: "people who bought this also
+// bought".
+func (r *MongoRecommendationRepository) GetCoPurchases(ctx context.Context, userID primitive.ObjectID, productIDs []primitive.ObjectID, limit int64) (map[primitive.ObjectID]int, error) {
+	out := map[primitive.ObjectID]int{}
+	if len(productIDs) == 0 {
+		return out, nil
+	}
+	paid := bson.M{"$in": models.PaidOrderStatuses}
+	since := time.Now().Add(-recommendationHistory)
+
+	pipeline := mongo.Pipeline{
+		// 1. Other buyers of the same products
+		{{Key: "$match", Value: bson.M{
+			"items.productId": bson.M{"$in": productIDs},
+			"userId":          bson.M{"$ne": userID},
+			"status":          paid,
+			"createdAt":       bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$userId"}}},
+		{{Key: "$limit", Value: 500}},
+		// 2. Everything else those buyers bought
+		{{Key: "$lookup", Value: bson.M{
+			"from": "orders",
+			"let":  bson.M{"buyer": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr":     bson.M{"$eq": bson.A{"$userId", "$$buyer"}},
+					"status":    paid,
+					"createdAt": bson.M{"$gte": since},
+				}},
+				bson.M{"$unwind": "$items"},
+				bson.M{"$match": bson.M{"items.productId": bson.M{"$nin": productIDs}}},
+				bson.M{"$group": bson.M{"_id": "$items.productId"}},
+			},
+			"as": "bought",
+		}}},
+		{{Key: "$unwind", Value: "$bought"}},
+		// 3. Count distinct co-buyers per product
+		{{Key: "$group", Value: bson.M{"_id": "$bought._id", "buyers": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"buyers": -1}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.DB.Collection("orders").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Buyers    int                `bson:"buyers"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.ProductID] = row.Buyers
+	}
+	return out, nil
+}
+
+// GetCandidates returns in-stock active products that are in one of the
+// categories or among productIDs, best-selling first.
+func (r *MongoRecommendationRepository) GetCandidates(ctx context.Context, categoryIDs, productIDs []primitive.ObjectID, limit int64) ([]models.Product, error) {
+	var or bson.A
+	if len(categoryIDs) > 0 {
+		or = append(or, bson.M{"categoryId": bson.M{"$in": categoryIDs}}, bson.M{"subCategoryIds": bson.M{"$in": categoryIDs}})
+	}
+	if len(productIDs) > 0 {
+		or = append(or, bson.M{"_id": bson.M{"$in": productIDs}})
+	}
+	if len(or) == 0 {
+		return []models.Product{}, nil
+	}
+
+	filter := bson.M{
+		"status": models.ProductStatusActive,
+		"$and": bson.A{
+			bson.M{"$or": or},
+			bson.M{"$or": bson.A{bson.M{"stock": bson.M{"$gt": 0}}, bson.M{"allowBackorder": true}}},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "totalSales", Value: -1}, {Key: "rating", Value: -1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"costPrice": 0})
+	cursor, err := r.DB.Collection("products").Find(ctx, NotDeleted(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := []models.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReportRepository interface {
+	Create(ctx context.Context, report *models.ContentReport) error
+	List(ctx context.Context, filter bson.M, limit, skip int64) ([]models.ContentReport, int64, error)
+	Get(ctx context.Context, id primitive.ObjectID) (*models.ContentReport, error)
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.ContentReport, error)
+	CountActiveForTarget(ctx context.Context, targetType string, targetID primitive.ObjectID) (int64, error)
+	CountByReporterSince(ctx context.Context, reporterID primitive.ObjectID, since time.Time) (int64, error)
+}
+
+var (
+	ErrDuplicateReport = errors.New("you have already reported this content")
+	ErrReportNotFound  = errors.New("report not found")
+)
+
+type MongoReportRepository struct {
+	DB *mongo.Database
+}
+
+func NewReportRepository(db *mongo.Database) ReportRepository {
+	return &MongoReportRepository{DB: db}
+}
+
+// Create files a new report. A reporter with an active report on the same
+// content gets ErrDuplicateReport, enforced by a partial unique index.
+func (r *MongoReportRepository) Create(ctx context.Context, report *models.ContentReport) error {
+	now := time.Now()
+	report.ID = primitive.NewObjectID()
+	report.Status = models.ReportStatusOpen
+	report.Active = true
+	report.CreatedAt = now
+	report.UpdatedAt = now
+
+	_, err := r.DB.Collection("contentReports").InsertOne(ctx, report)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateReport
+	}
+	return err
+}
+
+func (r *MongoReportRepository) List(ctx context.Context, filter bson.M, limit, skip int64) ([]models.ContentReport, int64, error) {
+	collection := r.DB.Collection("contentReports")
+
+	// Oldest first so nothing sits in the queue forever
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []models.ContentReport{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reports, total, nil
+}
+
+func (r *MongoReportRepository) Get(ctx context.Context, id primitive.ObjectID) (*models.ContentReport, error) {
+	var report models.ContentReport
+	err := r.DB.Collection("contentReports").FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &report, err
+}
+
+// UpdateStatus applies a triage decision to a report that is still active and
+// returns the updated report.
+func (r *MongoReportRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.ContentReport, error) {
+	fields["updatedAt"] = time.Now()
+	if status, _ := fields["status"].(string); status != "" {
+		fields["active"] = status == models.ReportStatusOpen || status == models.ReportStatusInvestigating
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var report models.ContentReport
+	err := r.DB.Collection("contentReports").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "active": true},
+		bson.M{"$set": fields},
+		opts,
+	).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *MongoReportRepository) CountActiveForTarget(ctx context.Context, targetType string, targetID primitive.ObjectID) (int64, error) {
+	return r.DB.Collection("contentReports").CountDocuments(ctx, bson.M{
+		"targetType": targetType,
+		"targetID":   targetID,
+		"active":     true,
+	})
+}
+
+// CountByReporterSince counts the reports a user has filed since the given
+// time, for rate limiting.
+func (r *MongoReportRepository) CountByReporterSince(ctx context.Context, reporterID primitive.ObjectID, since time.Time) (int64, error) {
+	return r.DB.Collection("contentReports").CountDocuments(ctx, bson.M{
+		"reporterID": reporterID,
+		"createdAt":  bson.M{"$gte": since},
+	})
+}
@@ -14,10 +14,17 @@ import (
 
 type ReviewRepository interface {
 	CreateReview(ctx context.Context, userID primitive.ObjectID, userName string, userImage string, input models.CreateReviewInput) (models.Review, error)
-	GetProductReviews(ctx context.Context, productID primitive.ObjectID) ([]models.Review, error)
-	GetVendorReviews(ctx context.Context, vendorID primitive.ObjectID) ([]models.Review, error)
+	GetReviewByID(ctx context.Context, reviewID primitive.ObjectID) (models.Review, error)
+	ListReviews(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D) ([]models.Review, int64, error)
+	ListReviewsPage(ctx context.Context, filter bson.M, after models.PageCursor, limit int64) ([]models.Review, error)
 	AddVendorResponse(ctx context.Context, reviewID primitive.ObjectID, vendorID primitive.ObjectID, response string) error
 	GetAverageRating(ctx context.Context, productID primitive.ObjectID) (float64, int, error)
+	GetVendorAverageRating(ctx context.Context, vendorID primitive.ObjectID) (float64, int, error)
+	GetVendorReviewStats(ctx context.Context, vendorID primitive.ObjectID) (models.VendorReviewStats, error)
+	RefreshProductRating(ctx context.Context, productID primitive.ObjectID) error
+	ListReviewsToAnalyze(ctx context.Context, limit int64) ([]models.Review, error)
+	SaveReviewInsights(ctx context.Context, reviewID primitive.ObjectID, insights models.ReviewInsights) error
+	GetReviewInsights(ctx context.Context, filter bson.M, from, to time.Time) ([]models.ReviewSentimentCount, []models.ReviewTopicCount, error)
 }
 
 type MongoReviewRepository struct {
@@ -71,44 +78,55 @@ func (r *MongoReviewRepository) CreateReview(ctx context.Context, userID primiti
 	go func() {
 		bgCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		avg, total, _ := r.GetAverageRating(bgCtx, input.ProductID)
-		productColl.UpdateOne(bgCtx, bson.M{"_id": input.ProductID}, bson.M{
-			"$set": bson.M{
-				"rating":      avg,
-				"reviewCount": total,
-			},
-		})
+		r.RefreshProductRating(bgCtx, input.ProductID)
 	}()
 
 	return review, nil
 }
 
-func (r *MongoReviewRepository) GetProductReviews(ctx context.Context, productID primitive.ObjectID) ([]models.Review, error) {
+func (r *MongoReviewRepository) GetReviewByID(ctx context.Context, reviewID primitive.ObjectID) (models.Review, error) {
+	var review models.Review
+	err := r.DB.Collection("reviews").FindOne(ctx, NotDeleted(bson.M{"_id": reviewID})).Decode(&review)
+	return review, err
+}
+
+// ListReviews returns a page of the reviews matching filter and how many
+// match. A nil sort is newest first.
+func (r *MongoReviewRepository) ListReviews(ctx context.Context, filter bson.M, limit, skip int64, sort bson.D) ([]models.Review, int64, error) {
 	collection := r.DB.Collection("reviews")
-	opts := options.Find().SetSort(bson.M{"createdAt": -1})
-	cursor, err := collection.Find(ctx, bson.M{"productId": productID}, opts)
+	filter = NotDeleted(filter)
+	if sort == nil {
+		sort = models.CursorSort
+	}
+	opts := options.Find().SetSort(sort).SetSkip(skip).SetLimit(limit)
+	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer cursor.Close(ctx)
 
-	var reviews []models.Review
+	reviews := []models.Review{}
 	if err := cursor.All(ctx, &reviews); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return reviews, nil
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
 }
 
-func (r *MongoReviewRepository) GetVendorReviews(ctx context.Context, vendorID primitive.ObjectID) ([]models.Review, error) {
-	collection := r.DB.Collection("reviews")
-	opts := options.Find().SetSort(bson.M{"createdAt": -1})
-	cursor, err := collection.Find(ctx, bson.M{"vendorId": vendorID}, opts)
+// ListReviewsPage returns up to limit reviews matching filter after the
+// cursor, newest first.
+func (r *MongoReviewRepository) ListReviewsPage(ctx context.Context, filter bson.M, after models.PageCursor, limit int64) ([]models.Review, error) {
+	opts := options.Find().SetSort(models.CursorSort).SetLimit(limit)
+	cursor, err := r.DB.Collection("reviews").Find(ctx, after.After(NotDeleted(filter)), opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var reviews []models.Review
+	reviews := []models.Review{}
 	if err := cursor.All(ctx, &reviews); err != nil {
 		return nil, err
 	}
@@ -119,7 +137,7 @@ func (r *MongoReviewRepository) AddVendorResponse(ctx context.Context, reviewID
 	collection := r.DB.Collection("reviews")
 	now := time.Now()
 	res, err := collection.UpdateOne(ctx,
-		bson.M{"_id": reviewID, "vendorId": vendorID},
+		NotDeleted(bson.M{"_id": reviewID, "vendorId": vendorID}),
 		bson.M{"$set": bson.M{
 			"response":   response,
 			"responseAt": &now,
@@ -137,7 +155,7 @@ func (r *MongoReviewRepository) AddVendorResponse(ctx context.Context, reviewID
 func (r *MongoReviewRepository) GetAverageRating(ctx context.Context, productID primitive.ObjectID) (float64, int, error) {
 	collection := r.DB.Collection("reviews")
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{"productId": productID}}},
+		{{Key: "$match", Value: NotDeleted(bson.M{"productId": productID})}},
 		{{Key: "$group", Value: bson.M{
 			"_id":       "$productId",
 			"avgRating": bson.M{"$avg": "$rating"},
@@ -165,3 +183,192 @@ func (r *MongoReviewRepository) GetAverageRating(ctx context.Context, productID
 
 	return results[0].AvgRating, results[0].Total, nil
 }
+
+// RefreshProductRating recomputes the product's rating and review count
+// from its reviews.
+func (r *MongoReviewRepository) RefreshProductRating(ctx context.Context, productID primitive.ObjectID) error {
+	avg, total, err := r.GetAverageRating(ctx, productID)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Collection("products").UpdateOne(ctx, bson.M{"_id": productID}, bson.M{
+		"$set": bson.M{
+			"rating":      avg,
+			"reviewCount": total,
+		},
+	})
+	return err
+}
+
+func (r *MongoReviewRepository) GetVendorAverageRating(ctx context.Context, vendorID primitive.ObjectID) (float64, int, error) {
+	collection := r.DB.Collection("reviews")
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: NotDeleted(bson.M{"vendorId": vendorID})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$vendorId",
+			"avgRating": bson.M{"$avg": "$rating"},
+			"total":     bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		AvgRating float64 `bson:"avgRating"`
+		Total     int     `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, 0, err
+	}
+
+	if len(results) == 0 {
+		return 0, 0, nil
+	}
+
+	return results[0].AvgRating, results[0].Total, nil
+}
+
+func (r *MongoReviewRepository) GetVendorReviewStats(ctx context.Context, vendorID primitive.ObjectID) (models.VendorReviewStats, error) {
+	collection := r.DB.Collection("reviews")
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: NotDeleted(bson.M{"vendorId": vendorID})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$rating",
+			"count": bson.M{"$sum": 1},
+			"responded": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$gt": []interface{}{bson.M{"$strLenCP": bson.M{"$ifNull": []interface{}{"$response", ""}}}, 0}}, 1, 0,
+			}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.VendorReviewStats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Rating    int `bson:"_id"`
+		Count     int `bson:"count"`
+		Responded int `bson:"responded"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return models.VendorReviewStats{}, err
+	}
+
+	stats := models.VendorReviewStats{Breakdown: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+	ratingSum, responded := 0, 0
+	for _, res := range results {
+		stats.Breakdown[res.Rating] = res.Count
+		stats.TotalReviews += res.Count
+		ratingSum += res.Rating * res.Count
+		responded += res.Responded
+	}
+	if stats.TotalReviews > 0 {
+		stats.AverageRating = float64(ratingSum) / float64(stats.TotalReviews)
+		stats.ResponseRate = float64(responded) * 100 / float64(stats.TotalReviews)
+	}
+	return stats, nil
+}
+
+// ListReviewsToAnalyze returns up to limit reviews the analyzer hasn't
+// seen, or saw in an older version, oldest first.
+func (r *MongoReviewRepository) ListReviewsToAnalyze(ctx context.Context, limit int64) ([]models.Review, error) {
+	filter := NotDeleted(bson.M{"$or": bson.A{
+		bson.M{"insights": bson.M{"$exists": false}},
+		bson.M{"insights.version": bson.M{"$lt": models.ReviewAnalyzerVersion}},
+	}})
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"comment": 1, "rating": 1, "createdAt": 1})
+	cursor, err := r.DB.Collection("reviews").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *MongoReviewRepository) SaveReviewInsights(ctx context.Context, reviewID primitive.ObjectID, insights models.ReviewInsights) error {
+	_, err := r.DB.Collection("reviews").UpdateOne(ctx,
+		bson.M{"_id": reviewID},
+		bson.M{"$set": bson.M{"insights": insights}},
+	)
+	return err
+}
+
+// GetReviewInsights counts the analyzed reviews matching filter over
+// [from, to) and over the range of the same length before it, by
+// sentiment label and by topic.
+func (r *MongoReviewRepository) GetReviewInsights(ctx context.Context, filter bson.M, from, to time.Time) ([]models.ReviewSentimentCount, []models.ReviewTopicCount, error) {
+	prevFrom := from.Add(-to.Sub(from))
+	match := NotDeleted(bson.M{"insights": bson.M{"$exists": true}, "createdAt": bson.M{"$gte": prevFrom, "$lt": to}})
+	for k, v := range filter {
+		match[k] = v
+	}
+	period := bson.M{"$cond": bson.A{
+		bson.M{"$gte": bson.A{"$createdAt", from}}, models.ReviewPeriodCurrent, models.ReviewPeriodPrevious,
+	}}
+	countIf := func(cond bson.M) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$project", Value: bson.M{"period": period, "insights": 1}}},
+		{{Key: "$facet", Value: bson.M{
+			"sentiments": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":          bson.M{"period": "$period", "label": "$insights.label"},
+					"reviews":      bson.M{"$sum": 1},
+					"sentimentSum": bson.M{"$sum": "$insights.sentiment"},
+				}},
+				bson.M{"$project": bson.M{
+					"_id": 0, "period": "$_id.period", "label": "$_id.label",
+					"reviews": 1, "sentimentSum": 1,
+				}},
+			},
+			"topics": bson.A{
+				bson.M{"$unwind": "$insights.topics"},
+				bson.M{"$group": bson.M{
+					"_id":          bson.M{"period": "$period", "topic": "$insights.topics.topic"},
+					"mentions":     bson.M{"$sum": 1},
+					"positive":     countIf(bson.M{"$gte": bson.A{"$insights.topics.sentiment", models.SentimentThreshold}}),
+					"negative":     countIf(bson.M{"$lte": bson.A{"$insights.topics.sentiment", -models.SentimentThreshold}}),
+					"sentimentSum": bson.M{"$sum": "$insights.topics.sentiment"},
+				}},
+				bson.M{"$project": bson.M{
+					"_id": 0, "period": "$_id.period", "topic": "$_id.topic",
+					"mentions": 1, "positive": 1, "negative": 1, "sentimentSum": 1,
+				}},
+			},
+		}}},
+	}
+	cursor, err := r.DB.Collection("reviews").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Sentiments []models.ReviewSentimentCount `bson:"sentiments"`
+		Topics     []models.ReviewTopicCount     `bson:"topics"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, nil, err
+	}
+	if len(facets) == 0 {
+		return nil, nil, nil
+	}
+	return facets[0].Sentiments, facets[0].Topics, nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SaleCampaignRepository interface {
+	CreateCampaign(ctx context.Context, campaign models.SaleCampaign) (models.SaleCampaign, error)
+	GetVendorCampaigns(ctx context.Context, vendorID primitive.ObjectID) ([]models.SaleCampaign, error)
+	GetCampaign(ctx context.Context, vendorID, id primitive.ObjectID) (*models.SaleCampaign, error)
+	UpdateScheduledCampaign(ctx context.Context, vendorID, id primitive.ObjectID, fields bson.M) (*models.SaleCampaign, error)
+	GetOpenCampaigns(ctx context.Context, vendorID primitive.ObjectID) ([]models.SaleCampaign, error)
+	CampaignProductIDs(ctx context.Context, campaign models.SaleCampaign) ([]primitive.ObjectID, error)
+	CancelCampaign(ctx context.Context, vendorID, id primitive.ObjectID) (*models.SaleCampaign, error)
+	StartDueCampaigns(ctx context.Context, now time.Time) (int, error)
+	EndDueCampaigns(ctx context.Context, now time.Time) (int, error)
+}
+
+var ErrSaleCampaignNotFound = errors.New("sale campaign not found")
+
+type MongoSaleCampaignRepository struct {
+	DB *mongo.Database
+}
+
+func NewSaleCampaignRepository(db *mongo.Database) SaleCampaignRepository {
+	return &MongoSaleCampaignRepository{DB: db}
+}
+
+func (r *MongoSaleCampaignRepository) CreateCampaign(ctx context.Context, campaign models.SaleCampaign) (models.SaleCampaign, error) {
+	campaign.ID = primitive.NewObjectID()
+	campaign.Status = models.SaleScheduled
+	campaign.CreatedAt = time.Now()
+	campaign.UpdatedAt = campaign.CreatedAt
+
+	_, err := r.DB.Collection("saleCampaigns").InsertOne(ctx, campaign)
+	return campaign, err
+}
+
+func (r *MongoSaleCampaignRepository) GetVendorCampaigns(ctx context.Context, vendorID primitive.ObjectID) ([]models.SaleCampaign, error) {
+	return r.findCampaigns(ctx, bson.M{"vendorId": vendorID})
+}
+
+func (r *MongoSaleCampaignRepository) GetCampaign(ctx context.Context, vendorID, id primitive.ObjectID) (*models.SaleCampaign, error) {
+	var campaign models.SaleCampaign
+	err := r.DB.Collection("saleCampaigns").FindOne(ctx, bson.M{"_id": id, "vendorId": vendorID}).Decode(&campaign)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &campaign, err
+}
+
+// UpdateScheduledCampaign changes a campaign that hasn't started yet,
+// returning ErrSaleCampaignNotFound if there is no such campaign or it has
+// already started.
+func (r *MongoSaleCampaignRepository) UpdateScheduledCampaign(ctx context.Context, vendorID, id primitive.ObjectID, fields bson.M) (*models.SaleCampaign, error) {
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var campaign models.SaleCampaign
+	err := r.DB.Collection("saleCampaigns").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "vendorId": vendorID, "status": models.SaleScheduled},
+		bson.M{"$set": fields},
+		opts,
+	).Decode(&campaign)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrSaleCampaignNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// GetOpenCampaigns lists the vendor's campaigns that are scheduled or
+// running, for checking a new one against.
+func (r *MongoSaleCampaignRepository) GetOpenCampaigns(ctx context.Context, vendorID primitive.ObjectID) ([]models.SaleCampaign, error) {
+	return r.findCampaigns(ctx, bson.M{
+		"vendorId": vendorID,
+		"status":   bson.M{"$in": []string{models.SaleScheduled, models.SaleRunning}},
+	})
+}
+
+// CampaignProductIDs resolves the products a campaign covers: the ones it
+// names and those currently in its collections, limited to the vendor's own.
+func (r *MongoSaleCampaignRepository) CampaignProductIDs(ctx context.Context, campaign models.SaleCampaign) ([]primitive.ObjectID, error) {
+	ids := append([]primitive.ObjectID{}, campaign.ProductIDs...)
+	if len(campaign.CollectionIDs) > 0 {
+		cursor, err := r.DB.Collection("storeCollections").Find(ctx,
+			bson.M{"_id": bson.M{"$in": campaign.CollectionIDs}, "vendorID": campaign.VendorID},
+			options.Find().SetProjection(bson.M{"productIDs": 1}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var collections []models.StoreCollection
+		if err := cursor.All(ctx, &collections); err != nil {
+			return nil, err
+		}
+		for _, collection := range collections {
+			ids = append(ids, collection.ProductIDs...)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := r.DB.Collection("products").Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "vendorId": campaign.VendorID},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	owned := make([]primitive.ObjectID, 0, len(products))
+	for _, p := range products {
+		owned = append(owned, p.ID)
+	}
+	return owned, nil
+}
+
+// CancelCampaign stops a scheduled or running campaign, taking its prices
+// off the products it was on.
+func (r *MongoSaleCampaignRepository) CancelCampaign(ctx context.Context, vendorID, id primitive.ObjectID) (*models.SaleCampaign, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var campaign models.SaleCampaign
+	err := r.DB.Collection("saleCampaigns").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "vendorId": vendorID, "status": bson.M{"$in": []string{models.SaleScheduled, models.SaleRunning}}},
+		bson.M{"$set": bson.M{"status": models.SaleCancelled, "updatedAt": time.Now()}},
+		opts,
+	).Decode(&campaign)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrSaleCampaignNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.revertPrices(ctx, campaign.ID); err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// StartDueCampaigns puts every scheduled campaign whose window has opened on
+// its products, returning how many were started. Each campaign is claimed
+// before its prices are written, so two instances running this at once
+// can't both start it.
+func (r *MongoSaleCampaignRepository) StartDueCampaigns(ctx context.Context, now time.Time) (int, error) {
+	started := 0
+	for {
+		var campaign models.SaleCampaign
+		err := r.DB.Collection("saleCampaigns").FindOneAndUpdate(ctx,
+			bson.M{"status": models.SaleScheduled, "startsAt": bson.M{"$lte": now}, "endsAt": bson.M{"$gt": now}},
+			bson.M{"$set": bson.M{"status": models.SaleRunning, "updatedAt": now}},
+		).Decode(&campaign)
+		if err == mongo.ErrNoDocuments {
+			return started, nil
+		}
+		if err != nil {
+			return started, err
+		}
+
+		applied, err := r.applyPrices(ctx, campaign)
+		if err != nil {
+			return started, err
+		}
+		_, err = r.DB.Collection("saleCampaigns").UpdateOne(ctx,
+			bson.M{"_id": campaign.ID},
+			bson.M{"$set": bson.M{"appliedCount": applied}},
+		)
+		if err != nil {
+			return started, err
+		}
+		started++
+	}
+}
+
+// EndDueCampaigns puts the old prices back on the products of every
+// campaign whose window has closed, returning how many were ended. A
+// campaign that was never started, say because the server was down for its
+// whole window, is just marked ended.
+func (r *MongoSaleCampaignRepository) EndDueCampaigns(ctx context.Context, now time.Time) (int, error) {
+	ended := 0
+	for {
+		var campaign models.SaleCampaign
+		err := r.DB.Collection("saleCampaigns").FindOneAndUpdate(ctx,
+			bson.M{"status": bson.M{"$in": []string{models.SaleScheduled, models.SaleRunning}}, "endsAt": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"status": models.SaleEnded, "updatedAt": now}},
+		).Decode(&campaign)
+		if err == mongo.ErrNoDocuments {
+			return ended, nil
+		}
+		if err != nil {
+			return ended, err
+		}
+		if err := r.revertPrices(ctx, campaign.ID); err != nil {
+			return ended, err
+		}
+		ended++
+	}
+}
+
+// applyPrices writes the campaign's sale price to each of its products that
+// isn't already on another campaign, keeping the price it replaces.
+func (r *MongoSaleCampaignRepository) applyPrices(ctx context.Context, campaign models.SaleCampaign) (int64, error) {
+	ids, err := r.CampaignProductIDs(ctx, campaign)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	cursor, err := r.DB.Collection("products").Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "sale": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"price": 1, "salePrice": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var products []models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(products))
+	for _, p := range products {
+		price := campaign.SalePriceFor(p)
+		if price == 0 {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			// Only if it hasn't been put on another sale since it was read
+			SetFilter(bson.M{"_id": p.ID, "sale": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"salePrice": price,
+				"sale": models.ProductSale{
+					CampaignID:        campaign.ID,
+					PreviousSalePrice: p.SalePrice,
+					EndsAt:            campaign.EndsAt,
+				},
+				"updatedAt": now,
+			}}))
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+	res, err := r.DB.Collection("products").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// revertPrices takes a campaign off every product it is on, putting back the
+// sale price each had before.
+func (r *MongoSaleCampaignRepository) revertPrices(ctx context.Context, campaignID primitive.ObjectID) error {
+	_, err := r.DB.Collection("products").UpdateMany(ctx,
+		bson.M{"sale.campaignId": campaignID},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"salePrice": "$sale.previousSalePrice", "updatedAt": time.Now()}}},
+			{{Key: "$unset", Value: "sale"}},
+		},
+	)
+	return err
+}
+
+func (r *MongoSaleCampaignRepository) findCampaigns(ctx context.Context, filter bson.M) ([]models.SaleCampaign, error) {
+	opts := options.Find().SetSort(bson.M{"startsAt": -1})
+	cursor, err := r.DB.Collection("saleCampaigns").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	campaigns := []models.SaleCampaign{}
+	if err := cursor.All(ctx, &campaigns); err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SearchRepository interface {
+	Suggest(ctx context.Context, query string, limit int64) (models.SearchSuggestions, error)
+	RecordSearch(ctx context.Context, event models.SearchEvent) error
+	RecordClick(ctx context.Context, searchID primitive.ObjectID, click models.SearchClick) (bool, error)
+	TopSearches(ctx context.Context, from, to time.Time, limit int64) ([]models.SearchQueryStat, error)
+	ZeroResultSearches(ctx context.Context, from, to time.Time, limit int64) ([]models.SearchQueryStat, error)
+	ListSynonyms(ctx context.Context, language string) ([]models.SearchSynonym, error)
+	GetSynonym(ctx context.Context, id primitive.ObjectID) (*models.SearchSynonym, error)
+	CreateSynonym(ctx context.Context, synonym *models.SearchSynonym) error
+	UpdateSynonym(ctx context.Context, synonym models.SearchSynonym) error
+	DeleteSynonym(ctx context.Context, id primitive.ObjectID) (bool, error)
+	Vocabulary(ctx context.Context, limit int64) ([]string, error)
+}
+
+// maxSearchClicks bounds the clicks kept per search event.
+const maxSearchClicks = 20
+
+type MongoSearchRepository struct {
+	DB *mongo.Database
+}
+
+func NewSearchRepository(db *mongo.Database) SearchRepository {
+	return &MongoSearchRepository{DB: db}
+}
+
+// Suggest returns up to limit products, brands, categories and stores with a
+// word starting with query. Products rank by units sold, brands and
+// categories by how much their active products sell, and stores by their
+// vendor's sales.
+func (r *MongoSearchRepository) Suggest(ctx context.Context, query string, limit int64) (models.SearchSuggestions, error) {
+	out := models.SearchSuggestions{}
+	match := bson.M{"$regex": models.WordPrefixPattern(query), "$options": "i"}
+	active := NotDeleted(bson.M{"status": models.ProductStatusActive})
+
+	// 1. Products
+	products := mongo.Pipeline{
+		{{Key: "$match", Value: NotDeleted(bson.M{"status": models.ProductStatusActive, "name": match})}},
+		{{Key: "$sort", Value: bson.D{{Key: "totalSales", Value: -1}, {Key: "rating", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{
+			"id":         bson.M{"$toString": "$_id"},
+			"text":       "$name",
+			"slug":       "$seo.slug",
+			"image":      bson.M{"$first": "$images"},
+			"popularity": "$totalSales",
+		}}},
+	}
+	if err := r.aggregateSuggestions(ctx, "products", products, models.SuggestionProduct, &out.Products); err != nil {
+		return out, err
+	}
+
+	// 2. Brands, from the brands of active products
+	brands := mongo.Pipeline{
+		{{Key: "$match", Value: NotDeleted(bson.M{"status": models.ProductStatusActive, "brand": match})}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$toLower": "$brand"}, "text": bson.M{"$first": "$brand"}, "popularity": bson.M{"$sum": "$totalSales"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "popularity", Value: -1}, {Key: "text", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	if err := r.aggregateSuggestions(ctx, "products", brands, models.SuggestionBrand, &out.Brands); err != nil {
+		return out, err
+	}
+
+	// 3. Categories, ranked by what their products sell
+	categories := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"isActive": true, "name": match}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "_id",
+			"foreignField": "categoryId",
+			"pipeline":     bson.A{bson.M{"$match": active}, bson.M{"$project": bson.M{"totalSales": 1}}},
+			"as":           "products",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"id":         bson.M{"$toString": "$_id"},
+			"text":       "$name",
+			"slug":       "$slug",
+			"image":      "$image",
+			"popularity": bson.M{"$sum": "$products.totalSales"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "popularity", Value: -1}, {Key: "text", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	if err := r.aggregateSuggestions(ctx, "categories", categories, models.SuggestionCategory, &out.Categories); err != nil {
+		return out, err
+	}
+
+	// 4. Stores, ranked by their vendor's sales
+	stores := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"name": match}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "vendorID",
+			"foreignField": "vendorId",
+			"pipeline":     bson.A{bson.M{"$match": active}, bson.M{"$project": bson.M{"totalSales": 1}}},
+			"as":           "products",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"id":         bson.M{"$toString": "$_id"},
+			"text":       "$name",
+			"slug":       "$slug",
+			"image":      "$logo",
+			"popularity": bson.M{"$sum": "$products.totalSales"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "popularity", Value: -1}, {Key: "text", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	if err := r.aggregateSuggestions(ctx, "stores", stores, models.SuggestionStore, &out.Stores); err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+func (r *MongoSearchRepository) aggregateSuggestions(ctx context.Context, collection string, pipeline mongo.Pipeline, kind string, into *[]models.SearchSuggestion) error {
+	cursor, err := r.DB.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	suggestions := []models.SearchSuggestion{}
+	if err := cursor.All(ctx, &suggestions); err != nil {
+		return err
+	}
+	for i := range suggestions {
+		suggestions[i].Type = kind
+	}
+	*into = suggestions
+	return nil
+}
+
+// RecordSearch logs a storefront search.
+func (r *MongoSearchRepository) RecordSearch(ctx context.Context, event models.SearchEvent) error {
+	_, err := r.DB.Collection("searchEvents").InsertOne(ctx, event)
+	return err
+}
+
+// RecordClick adds a result click to a search. It reports false when the
+// search doesn't exist or already has the click.
+func (r *MongoSearchRepository) RecordClick(ctx context.Context, searchID primitive.ObjectID, click models.SearchClick) (bool, error) {
+	res, err := r.DB.Collection("searchEvents").UpdateOne(ctx,
+		bson.M{
+			"_id":              searchID,
+			"clicks.productId": bson.M{"$ne": click.ProductID},
+			"$expr":            bson.M{"$lt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$clicks", bson.A{}}}}, maxSearchClicks}},
+		},
+		bson.M{"$push": bson.M{"clicks": click}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// TopSearches returns the most frequent queries between from and to.
+func (r *MongoSearchRepository) TopSearches(ctx context.Context, from, to time.Time, limit int64) ([]models.SearchQueryStat, error) {
+	return r.queryStats(ctx, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}}, limit)
+}
+
+// ZeroResultSearches returns the most frequent queries between from and to
+// that found nothing: the catalog gaps.
+func (r *MongoSearchRepository) ZeroResultSearches(ctx context.Context, from, to time.Time, limit int64) ([]models.SearchQueryStat, error) {
+	return r.queryStats(ctx, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}, "resultCount": 0}, limit)
+}
+
+func (r *MongoSearchRepository) queryStats(ctx context.Context, match bson.M, limit int64) ([]models.SearchQueryStat, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$query",
+			"searches":       bson.M{"$sum": 1},
+			"avgResults":     bson.M{"$avg": "$resultCount"},
+			"clickedThrough": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$clicks", bson.A{}}}}, 0}}, 1, 0}}},
+			"lastSearchedAt": bson.M{"$max": "$createdAt"},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"clickRate": bson.M{"$divide": bson.A{"$clickedThrough", "$searches"}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "searches", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.DB.Collection("searchEvents").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stats := []models.SearchQueryStat{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ListSynonyms returns the synonym sets, in one language when language is
+// set.
+func (r *MongoSearchRepository) ListSynonyms(ctx context.Context, language string) ([]models.SearchSynonym, error) {
+	filter := bson.M{}
+	if language != "" {
+		filter["language"] = language
+	}
+	cursor, err := r.DB.Collection("searchSynonyms").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "language", Value: 1}, {Key: "terms.0", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	synonyms := []models.SearchSynonym{}
+	if err := cursor.All(ctx, &synonyms); err != nil {
+		return nil, err
+	}
+	return synonyms, nil
+}
+
+func (r *MongoSearchRepository) GetSynonym(ctx context.Context, id primitive.ObjectID) (*models.SearchSynonym, error) {
+	var synonym models.SearchSynonym
+	err := r.DB.Collection("searchSynonyms").FindOne(ctx, bson.M{"_id": id}).Decode(&synonym)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &synonym, err
+}
+
+func (r *MongoSearchRepository) CreateSynonym(ctx context.Context, synonym *models.SearchSynonym) error {
+	res, err := r.DB.Collection("searchSynonyms").InsertOne(ctx, synonym)
+	if err != nil {
+		return err
+	}
+	synonym.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *MongoSearchRepository) UpdateSynonym(ctx context.Context, synonym models.SearchSynonym) error {
+	_, err := r.DB.Collection("searchSynonyms").UpdateByID(ctx, synonym.ID, bson.M{"$set": bson.M{
+		"language":  synonym.Language,
+		"terms":     synonym.Terms,
+		"updatedAt": synonym.UpdatedAt,
+	}})
+	return err
+}
+
+func (r *MongoSearchRepository) DeleteSynonym(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := r.DB.Collection("searchSynonyms").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// Vocabulary returns the distinct lower-cased words of active product names
+// and brands, most used first, for correcting misspelled queries.
+func (r *MongoSearchRepository) Vocabulary(ctx context.Context, limit int64) ([]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: NotDeleted(bson.M{"status": models.ProductStatusActive})}},
+		{{Key: "$project", Value: bson.M{"words": bson.M{"$split": bson.A{
+			bson.M{"$toLower": bson.M{"$concat": bson.A{"$name", " ", bson.M{"$ifNull": bson.A{"$brand", ""}}}}},
+			" ",
+		}}}}},
+		{{Key: "$unwind", Value: "$words"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$trim": bson.M{"input": "$words", "chars": ` ,.;:!?()[]"'`}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		// Words of three or more letters, digits, hyphens or apostrophes
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$regex": `^[\p{L}\p{N}][\p{L}\p{N}'-]{2,}$`}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.DB.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Word string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	words := make([]string, len(rows))
+	for i, row := range rows {
+		words[i] = row.Word
+	}
+	return words, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShippingRepository interface {
+	GetRateTable(ctx context.Context, vendorID primitive.ObjectID) (*models.ShippingRateTable, error)
+	GetRateTables(ctx context.Context, vendorIDs []primitive.ObjectID) (map[primitive.ObjectID]models.ShippingRateTable, error)
+	SaveRateTable(ctx context.Context, table models.ShippingRateTable) (models.ShippingRateTable, error)
+	DeleteRateTable(ctx context.Context, vendorID primitive.ObjectID) error
+	GetFreeShippingRule(ctx context.Context, vendorID primitive.ObjectID) (*models.FreeShippingRule, error)
+	GetFreeShippingRules(ctx context.Context, vendorIDs []primitive.ObjectID) (map[primitive.ObjectID]models.FreeShippingRule, error)
+	SaveFreeShippingRule(ctx context.Context, rule models.FreeShippingRule) (models.FreeShippingRule, error)
+	DeleteFreeShippingRule(ctx context.Context, vendorID primitive.ObjectID) error
+}
+
+type MongoShippingRepository struct {
+	DB *mongo.Database
+}
+
+func NewShippingRepository(db *mongo.Database) ShippingRepository {
+	return &MongoShippingRepository{DB: db}
+}
+
+// GetRateTable returns a vendor's rate table, or the platform's for
+// NilObjectID. It returns nil if none has been set.
+func (r *MongoShippingRepository) GetRateTable(ctx context.Context, vendorID primitive.ObjectID) (*models.ShippingRateTable, error) {
+	var table models.ShippingRateTable
+	err := r.DB.Collection("shippingRates").FindOne(ctx, bson.M{"vendorId": vendorID}).Decode(&table)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// GetRateTables returns the rate tables of the given vendors, keyed by
+// vendor. Vendors without one are left out.
+func (r *MongoShippingRepository) GetRateTables(ctx context.Context, vendorIDs []primitive.ObjectID) (map[primitive.ObjectID]models.ShippingRateTable, error) {
+	out := map[primitive.ObjectID]models.ShippingRateTable{}
+	if len(vendorIDs) == 0 {
+		return out, nil
+	}
+	cursor, err := r.DB.Collection("shippingRates").Find(ctx, bson.M{"vendorId": bson.M{"$in": vendorIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tables []models.ShippingRateTable
+	if err := cursor.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	for _, t := range tables {
+		out[t.VendorID] = t
+	}
+	return out, nil
+}
+
+// SaveRateTable replaces the owner's rate table.
+func (r *MongoShippingRepository) SaveRateTable(ctx context.Context, table models.ShippingRateTable) (models.ShippingRateTable, error) {
+	table.UpdatedAt = time.Now()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.ShippingRateTable
+	err := r.DB.Collection("shippingRates").FindOneAndUpdate(ctx,
+		bson.M{"vendorId": table.VendorID},
+		bson.M{"$set": bson.M{"zones": table.Zones, "updatedAt": table.UpdatedAt}},
+		opts,
+	).Decode(&saved)
+	return saved, err
+}
+
+// DeleteRateTable removes a vendor's rate table so they ship at platform
+// rates again.
+func (r *MongoShippingRepository) DeleteRateTable(ctx context.Context, vendorID primitive.ObjectID) error {
+	_, err := r.DB.Collection("shippingRates").DeleteOne(ctx, bson.M{"vendorId": vendorID})
+	return err
+}
+
+// GetFreeShippingRule returns a vendor's free-shipping rule, or the
+// platform's for NilObjectID. It returns nil if none has been set.
+func (r *MongoShippingRepository) GetFreeShippingRule(ctx context.Context, vendorID primitive.ObjectID) (*models.FreeShippingRule, error) {
+	var rule models.FreeShippingRule
+	err := r.DB.Collection("freeShippingRules").FindOne(ctx, bson.M{"vendorId": vendorID}).Decode(&rule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetFreeShippingRules returns the free-shipping rules of the given
+// vendors, keyed by vendor. Vendors without one are left out.
+func (r *MongoShippingRepository) GetFreeShippingRules(ctx context.Context, vendorIDs []primitive.ObjectID) (map[primitive.ObjectID]models.FreeShippingRule, error) {
+	out := map[primitive.ObjectID]models.FreeShippingRule{}
+	if len(vendorIDs) == 0 {
+		return out, nil
+	}
+	cursor, err := r.DB.Collection("freeShippingRules").Find(ctx, bson.M{"vendorId": bson.M{"$in": vendorIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.FreeShippingRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		out[rule.VendorID] = rule
+	}
+	return out, nil
+}
+
+// SaveFreeShippingRule replaces the owner's free-shipping rule.
+func (r *MongoShippingRepository) SaveFreeShippingRule(ctx context.Context, rule models.FreeShippingRule) (models.FreeShippingRule, error) {
+	rule.UpdatedAt = time.Now()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.FreeShippingRule
+	err := r.DB.Collection("freeShippingRules").FindOneAndUpdate(ctx,
+		bson.M{"vendorId": rule.VendorID},
+		bson.M{"$set": bson.M{"threshold": rule.Threshold, "regions": rule.Regions, "updatedAt": rule.UpdatedAt}},
+		opts,
+	).Decode(&saved)
+	return saved, err
+}
+
+// DeleteFreeShippingRule removes a vendor's free-shipping rule so the
+// platform's applies to them again.
+func (r *MongoShippingRepository) DeleteFreeShippingRule(ctx context.Context, vendorID primitive.ObjectID) error {
+	_, err := r.DB.Collection("freeShippingRules").DeleteOne(ctx, bson.M{"vendorId": vendorID})
+	return err
+}
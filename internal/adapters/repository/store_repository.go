@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StoreRepository interface {
+	CreateStore(ctx context.Context, store models.Store) (models.Store, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Store, error)
+	GetByVendorID(ctx context.Context, vendorID primitive.ObjectID) (*models.Store, error)
+	GetByVendorIDs(ctx context.Context, vendorIDs []primitive.ObjectID) ([]models.Store, error)
+	EnsureStore(ctx context.Context, vendorID primitive.ObjectID) (*models.Store, error)
+	GetByPreviousSlug(ctx context.Context, slug string) (*models.Store, error)
+	IsSlugAvailable(ctx context.Context, slug string, vendorID primitive.ObjectID) (bool, error)
+	GenerateUniqueSlug(ctx context.Context, name string, vendorID primitive.ObjectID) (string, error)
+	ChangeSlug(ctx context.Context, vendorID primitive.ObjectID, newSlug string) (*models.Store, error)
+	UpdateStore(ctx context.Context, vendorID primitive.ObjectID, fields bson.M) (*models.Store, error)
+}
+
+var (
+	ErrSlugTaken      = errors.New("store slug is already taken")
+	ErrSlugChangeUsed = errors.New("store slug has already been changed once")
+	ErrStoreNotFound  = errors.New("store not found")
+)
+
+const maxSlugSuffixTries = 50
+
+type MongoStoreRepository struct {
+	DB *mongo.Database
+}
+
+func NewStoreRepository(db *mongo.Database) StoreRepository {
+	return &MongoStoreRepository{DB: db}
+}
+
+func (r *MongoStoreRepository) CreateStore(ctx context.Context, store models.Store) (models.Store, error) {
+	collection := r.DB.Collection("stores")
+	_, _ = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	if store.ID.IsZero() {
+		store.ID = primitive.NewObjectID()
+	}
+
+	// The slug reserved during onboarding may have been claimed since; fall
+	// back to a fresh one derived from the store name.
+	available := false
+	if store.Slug != "" {
+		ok, err := r.IsSlugAvailable(ctx, store.Slug, store.VendorID)
+		if err != nil {
+			return store, err
+		}
+		available = ok
+	}
+	if !available {
+		slug, err := r.GenerateUniqueSlug(ctx, store.Name, store.VendorID)
+		if err != nil {
+			return store, err
+		}
+		store.Slug = slug
+	}
+
+	_, err := collection.InsertOne(ctx, store)
+	if mongo.IsDuplicateKeyError(err) {
+		return store, ErrSlugTaken
+	}
+	return store, err
+}
+
+func (r *MongoStoreRepository) GetBySlug(ctx context.Context, slug string) (*models.Store, error) {
+	var store models.Store
+	err := r.DB.Collection("stores").FindOne(ctx, NotDeleted(bson.M{"slug": slug})).Decode(&store)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &store, err
+}
+
+func (r *MongoStoreRepository) GetByVendorID(ctx context.Context, vendorID primitive.ObjectID) (*models.Store, error) {
+	var store models.Store
+	err := r.DB.Collection("stores").FindOne(ctx, bson.M{"vendorID": vendorID}).Decode(&store)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &store, err
+}
+
+// GetByVendorIDs returns the stores of the given vendors, in no particular
+// order. Vendors without a store are left out.
+func (r *MongoStoreRepository) GetByVendorIDs(ctx context.Context, vendorIDs []primitive.ObjectID) ([]models.Store, error) {
+	cursor, err := r.DB.Collection("stores").Find(ctx, NotDeleted(bson.M{"vendorID": bson.M{"$in": vendorIDs}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var stores []models.Store
+	if err := cursor.All(ctx, &stores); err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+// EnsureStore returns the vendor's store, creating it from their approved
+// seller application if they were onboarded before stores existed.
+func (r *MongoStoreRepository) EnsureStore(ctx context.Context, vendorID primitive.ObjectID) (*models.Store, error) {
+	store, err := r.GetByVendorID(ctx, vendorID)
+	if err != nil || store != nil {
+		return store, err
+	}
+
+	var app models.SellerApplication
+	err = r.DB.Collection("sellerApplications").FindOne(ctx, bson.M{
+		"userID": vendorID,
+		"status": "approved",
+	}).Decode(&app)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.CreateStore(ctx, models.NewStoreFromApplication(vendorID, &app))
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *MongoStoreRepository) GetByPreviousSlug(ctx context.Context, slug string) (*models.Store, error) {
+	var store models.Store
+	err := r.DB.Collection("stores").FindOne(ctx, NotDeleted(bson.M{"previousSlugs": slug})).Decode(&store)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &store, err
+}
+
+// IsSlugAvailable reports whether slug is free for vendorID. Slugs a store has
+// moved away from stay reserved so their redirects keep working.
+func (r *MongoStoreRepository) IsSlugAvailable(ctx context.Context, slug string, vendorID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"slug": slug},
+			{"previousSlugs": slug},
+		},
+	}
+	if !vendorID.IsZero() {
+		filter["vendorID"] = bson.M{"$ne": vendorID}
+	}
+
+	count, err := r.DB.Collection("stores").CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// GenerateUniqueSlug derives a URL-safe slug from name, appending -2, -3, ...
+// until one is free.
+func (r *MongoStoreRepository) GenerateUniqueSlug(ctx context.Context, name string, vendorID primitive.ObjectID) (string, error) {
+	base := utils.GenerateSlug(name)
+	if base == "" {
+		base = "store"
+	}
+
+	candidate := base
+	for i := 2; i <= maxSlugSuffixTries; i++ {
+		ok, err := r.IsSlugAvailable(ctx, candidate, vendorID)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return fmt.Sprintf("%s-%s", base, primitive.NewObjectID().Hex()[18:]), nil
+}
+
+// ChangeSlug moves a store to newSlug, keeping the old slug as a redirect.
+// Each store may change its slug only once.
+func (r *MongoStoreRepository) ChangeSlug(ctx context.Context, vendorID primitive.ObjectID, newSlug string) (*models.Store, error) {
+	store, err := r.EnsureStore(ctx, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, ErrStoreNotFound
+	}
+	if store.SlugChangedAt != nil {
+		return nil, ErrSlugChangeUsed
+	}
+
+	ok, err := r.IsSlugAvailable(ctx, newSlug, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSlugTaken
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Store
+	err = r.DB.Collection("stores").FindOneAndUpdate(ctx,
+		bson.M{"_id": store.ID, "slug": store.Slug, "slugChangedAt": bson.M{"$exists": false}},
+		bson.M{
+			"$set":  bson.M{"slug": newSlug, "slugChangedAt": now, "updatedAt": now},
+			"$push": bson.M{"previousSlugs": store.Slug},
+		},
+		opts,
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrSlugChangeUsed
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// UpdateStore sets the given fields on the vendor's store and returns the
+// updated document.
+func (r *MongoStoreRepository) UpdateStore(ctx context.Context, vendorID primitive.ObjectID, fields bson.M) (*models.Store, error) {
+	if _, err := r.EnsureStore(ctx, vendorID); err != nil {
+		return nil, err
+	}
+
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var store models.Store
+	err := r.DB.Collection("stores").FindOneAndUpdate(ctx,
+		bson.M{"vendorID": vendorID},
+		bson.M{"$set": fields},
+		opts,
+	).Decode(&store)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
@@ -2,7 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/developia-II/ecommerce-backend/internal/events"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,8 +19,15 @@ type TierRepository interface {
 	GetPendingUpgradeRequest(ctx context.Context, vendorID primitive.ObjectID) (*models.TierUpgradeRequest, error)
 	GetLatestUpgradeRequest(ctx context.Context, vendorID primitive.ObjectID) (*models.TierUpgradeRequest, error)
 	GetUpgradeHistory(ctx context.Context, vendorID primitive.ObjectID) ([]models.TierUpgradeRequest, error)
+	GetVendorAccount(ctx context.Context, vendorID primitive.ObjectID) (*models.VendorAccount, error)
+	ApproveUpgradeRequest(ctx context.Context, req models.TierUpgradeRequest, limits models.TierLimits, reviewerID primitive.ObjectID, outbox ...events.Event) error
+	AdjustVendorAccount(ctx context.Context, vendorID primitive.ObjectID, set, unset bson.M) (*models.VendorAccount, error)
 }
 
+// ErrUpgradeNotPending is returned when an approval races with another
+// reviewer and the request has already left the pending state.
+var ErrUpgradeNotPending = errors.New("upgrade request is no longer pending")
+
 type MongoTierRepository struct {
 	DB *mongo.Database
 }
@@ -74,3 +85,94 @@ func (r *MongoTierRepository) GetUpgradeHistory(ctx context.Context, vendorID pr
 	}
 	return history, nil
 }
+
+func (r *MongoTierRepository) GetVendorAccount(ctx context.Context, vendorID primitive.ObjectID) (*models.VendorAccount, error) {
+	collection := r.DB.Collection("vendorAccounts")
+	var acc models.VendorAccount
+	err := collection.FindOne(ctx, bson.M{"userID": vendorID}).Decode(&acc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &acc, err
+}
+
+// ApproveUpgradeRequest marks a pending request approved and applies the new
+// tier limits to the vendor account in a single transaction, so a vendor never
+// ends up on a new tier with a request that still reads pending (or vice versa).
+// outbox, the events announcing it, are written in the same transaction.
+func (r *MongoTierRepository) ApproveUpgradeRequest(ctx context.Context, req models.TierUpgradeRequest, limits models.TierLimits, reviewerID primitive.ObjectID, outbox ...events.Event) error {
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now()
+
+		// 1. Flip the request from pending to approved
+		reqUpdate := bson.M{
+			"status":     models.UpgradeStatusApproved,
+			"reviewedAt": now,
+			"updatedAt":  now,
+		}
+		if !reviewerID.IsZero() {
+			reqUpdate["reviewedBy"] = reviewerID
+		}
+		res, err := r.DB.Collection("tierUpgradeRequests").UpdateOne(sessCtx,
+			bson.M{"_id": req.ID, "status": models.UpgradeStatusPending},
+			bson.M{"$set": reqUpdate},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, ErrUpgradeNotPending
+		}
+
+		// 2. Apply the tier limits to the vendor account
+		res, err = r.DB.Collection("vendorAccounts").UpdateOne(sessCtx,
+			bson.M{"userID": req.VendorID},
+			bson.M{"$set": bson.M{
+				"tier":            req.RequestedTier,
+				"maxProducts":     limits.MaxProducts,
+				"maxMonthlySales": limits.MaxMonthlySales,
+				"transactionFee":  limits.TransactionFee,
+				"payoutHoldDays":  limits.PayoutHoldDays,
+				"tierUpgradedAt":  now,
+				"updatedAt":       now,
+			}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, fmt.Errorf("vendor account not found")
+		}
+		return nil, writeOutbox(sessCtx, r.DB, outbox)
+	}
+
+	_, err = session.WithTransaction(ctx, callback)
+	return err
+}
+
+// AdjustVendorAccount applies an admin's manual changes to a vendor account
+// and returns the updated account.
+func (r *MongoTierRepository) AdjustVendorAccount(ctx context.Context, vendorID primitive.ObjectID, set, unset bson.M) (*models.VendorAccount, error) {
+	set["updatedAt"] = time.Now()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var acc models.VendorAccount
+	err := r.DB.Collection("vendorAccounts").FindOneAndUpdate(ctx, bson.M{"userID": vendorID}, update, opts).Decode(&acc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
@@ -17,8 +17,12 @@ type TransactionRepository interface {
 	GetTransactions(ctx context.Context, vendorID primitive.ObjectID, limit int) ([]models.Transaction, error)
 	GetPayouts(ctx context.Context, vendorID primitive.ObjectID) ([]models.PayoutRequest, error)
 	RequestPayout(ctx context.Context, payout models.PayoutRequest) error
-	CreditVendorForSale(ctx context.Context, vendorID primitive.ObjectID, amount float64, orderID primitive.ObjectID, orderNumber string) error
+	CreditVendorForSale(ctx context.Context, vendorID primitive.ObjectID, items []models.SaleItem, orderID primitive.ObjectID, orderNumber string) error
 	MaturateFunds(ctx context.Context, vendorID primitive.ObjectID) error
+	ClawbackVendorForRefund(ctx context.Context, vendorID primitive.ObjectID, grossAmount models.Money, orderID primitive.ObjectID, orderNumber string) (models.Money, error)
+	HoldSaleForDispute(ctx context.Context, vendorID, orderID primitive.ObjectID) (bool, error)
+	ReleaseDisputeHold(ctx context.Context, vendorID, orderID primitive.ObjectID) error
+	GetOrderTransactions(ctx context.Context, vendorID, orderID primitive.ObjectID) ([]models.Transaction, error)
 }
 
 type MongoTransactionRepository struct {
@@ -106,7 +110,11 @@ func (r *MongoTransactionRepository) RequestPayout(ctx context.Context, payout m
 	return err
 }
 
-func (r *MongoTransactionRepository) CreditVendorForSale(ctx context.Context, vendorID primitive.ObjectID, amount float64, orderID primitive.ObjectID, orderNumber string) error {
+// CreditVendorForSale records a vendor's share of a paid order, less the
+// platform fee: each item's category commission if it has one, else the
+// vendor's TransactionFee. The fee is on the discounted amount, and what the
+// vendor's promotions cost them is kept with the sale.
+func (r *MongoTransactionRepository) CreditVendorForSale(ctx context.Context, vendorID primitive.ObjectID, items []models.SaleItem, orderID primitive.ObjectID, orderNumber string) error {
 	accountColl := r.DB.Collection("vendorAccounts")
 	txColl := r.DB.Collection("transactions")
 
@@ -118,8 +126,12 @@ func (r *MongoTransactionRepository) CreditVendorForSale(ctx context.Context, ve
 	}
 
 	// 2. Calculate fee and net
-	fee := amount * (account.TransactionFee / 100)
+	amount, fee := models.SaleFee(items, account.TransactionFee)
 	netAmount := amount - fee
+	var promoCost models.Money
+	for _, item := range items {
+		promoCost += item.PromoCost
+	}
 
 	// 3. Create Transaction Record
 	holdDuration := time.Duration(account.PayoutHoldDays) * 24 * time.Hour
@@ -133,6 +145,7 @@ func (r *MongoTransactionRepository) CreditVendorForSale(ctx context.Context, ve
 		Status:    models.TransactionStatusPending,
 		Amount:    netAmount,
 		Fee:       fee,
+		PromoCost: promoCost,
 		Currency:  "USD", // Default
 		Reference: orderNumber,
 		HoldUntil: &holdUntil,
@@ -150,8 +163,9 @@ func (r *MongoTransactionRepository) CreditVendorForSale(ctx context.Context, ve
 		"$inc": bson.M{
 			"pendingBalance":    netAmount,
 			"lifeTimeEarnings":  netAmount,
-			"currentMonthSales": amount, // Track gross for tier limits
-			"totalSales":        amount,
+			"lifeTimePromoCost": promoCost,
+			"currentMonthSales": amount.Float64(), // Track gross for tier limits
+			"totalSales":        amount.Float64(),
 		},
 		"$set": bson.M{"updatedAt": time.Now()},
 	}
@@ -169,6 +183,8 @@ func (r *MongoTransactionRepository) MaturateFunds(ctx context.Context, vendorID
 		"vendorId":  vendorID,
 		"status":    models.TransactionStatusPending,
 		"holdUntil": bson.M{"$lte": time.Now()},
+		// Disputed sales wait for the dispute whatever their hold date
+		"disputeHold": bson.M{"$ne": true},
 	}
 
 	cursor, err := txColl.Find(ctx, filter)
@@ -186,7 +202,7 @@ func (r *MongoTransactionRepository) MaturateFunds(ctx context.Context, vendorID
 		return nil // Nothing to maturate
 	}
 
-	var totalToMaturate float64
+	var totalToMaturate models.Money
 	var txIDs []primitive.ObjectID
 	for _, tx := range maturedTxs {
 		totalToMaturate += tx.Amount
@@ -222,3 +238,135 @@ func (r *MongoTransactionRepository) MaturateFunds(ctx context.Context, vendorID
 
 	return err
 }
+
+// ClawbackVendorForRefund takes back the vendor's net share of a refunded
+// sale. The fee is returned at the rate charged on the original sale. Funds
+// still on hold come out of the pending sale itself so they never mature;
+// otherwise the available balance is debited, and may go negative until
+// future sales cover it. It returns the net amount clawed back.
+func (r *MongoTransactionRepository) ClawbackVendorForRefund(ctx context.Context, vendorID primitive.ObjectID, grossAmount models.Money, orderID primitive.ObjectID, orderNumber string) (models.Money, error) {
+	session, err := r.DB.Client().StartSession()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	var netAmount models.Money
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		accountColl := r.DB.Collection("vendorAccounts")
+		txColl := r.DB.Collection("transactions")
+		now := time.Now()
+
+		// 1. Find the original sale to get the fee rate and hold state
+		var sale models.Transaction
+		err := txColl.FindOne(sessCtx, bson.M{
+			"vendorId": vendorID,
+			"orderId":  orderID,
+			"type":     models.TransactionTypeSale,
+		}).Decode(&sale)
+		if err != nil {
+			return nil, fmt.Errorf("sale transaction not found for refund: %v", err)
+		}
+
+		var fee models.Money
+		if gross := sale.Amount + sale.Fee; gross > 0 {
+			fee = grossAmount.Share(sale.Fee, gross)
+		}
+		netAmount = grossAmount - fee
+
+		// 2. Take it from the held sale, or from the available balance
+		balanceField := "availableBalance"
+		if sale.Status == models.TransactionStatusPending {
+			balanceField = "pendingBalance"
+			if _, err := txColl.UpdateOne(sessCtx, bson.M{"_id": sale.ID}, bson.M{
+				"$inc": bson.M{"amount": -netAmount, "fee": -fee},
+				"$set": bson.M{"updatedAt": now},
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		// 3. Record the refund
+		if _, err := txColl.InsertOne(sessCtx, models.Transaction{
+			ID:        primitive.NewObjectID(),
+			VendorID:  vendorID,
+			OrderID:   &orderID,
+			Type:      models.TransactionTypeRefund,
+			Status:    models.TransactionStatusCompleted,
+			Amount:    -netAmount,
+			Fee:       -fee,
+			Currency:  "USD",
+			Reference: orderNumber,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+
+		// 4. Update the vendor balance and sales totals
+		_, err = accountColl.UpdateOne(sessCtx, bson.M{"userID": vendorID}, bson.M{
+			"$inc": bson.M{
+				balanceField:        -netAmount,
+				"lifeTimeEarnings":  -netAmount,
+				"currentMonthSales": -grossAmount.Float64(),
+				"totalSales":        -grossAmount.Float64(),
+			},
+			"$set": bson.M{"updatedAt": now},
+		})
+		return nil, err
+	}
+
+	if _, err := session.WithTransaction(ctx, callback); err != nil {
+		return 0, err
+	}
+	return netAmount, nil
+}
+
+// HoldSaleForDispute stops the vendor's sale for the order from maturing
+// until ReleaseDisputeHold. It reports false when there is nothing left to
+// hold because the funds have already been released.
+func (r *MongoTransactionRepository) HoldSaleForDispute(ctx context.Context, vendorID, orderID primitive.ObjectID) (bool, error) {
+	res, err := r.DB.Collection("transactions").UpdateOne(ctx,
+		bson.M{
+			"vendorId": vendorID,
+			"orderId":  orderID,
+			"type":     models.TransactionTypeSale,
+			"status":   models.TransactionStatusPending,
+		},
+		bson.M{"$set": bson.M{"disputeHold": true, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// ReleaseDisputeHold lets the vendor's sale for the order mature again. Funds
+// past their hold date mature on the next MaturateFunds.
+func (r *MongoTransactionRepository) ReleaseDisputeHold(ctx context.Context, vendorID, orderID primitive.ObjectID) error {
+	_, err := r.DB.Collection("transactions").UpdateMany(ctx,
+		bson.M{"vendorId": vendorID, "orderId": orderID, "disputeHold": true},
+		bson.M{
+			"$unset": bson.M{"disputeHold": ""},
+			"$set":   bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
+
+// GetOrderTransactions returns the vendor's sale, refund and other
+// transactions for an order, oldest first.
+func (r *MongoTransactionRepository) GetOrderTransactions(ctx context.Context, vendorID, orderID primitive.ObjectID) ([]models.Transaction, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+	cursor, err := r.DB.Collection("transactions").Find(ctx, bson.M{"vendorId": vendorID, "orderId": orderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []models.Transaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
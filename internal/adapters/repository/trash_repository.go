@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotDeleted returns filter narrowed to documents that haven't been
+// soft-deleted, for reads of the kinds in models.TrashKinds. A filter that
+// already says something about deletedAt is returned as it is. filter
+// itself isn't changed.
+func NotDeleted(filter bson.M) bson.M {
+	if _, ok := filter["deletedAt"]; ok {
+		return filter
+	}
+	narrowed := make(bson.M, len(filter)+1)
+	for key, value := range filter {
+		narrowed[key] = value
+	}
+	// Matches a missing field as well as null
+	narrowed["deletedAt"] = nil
+	return narrowed
+}
+
+// TrashRepository soft-deletes users, products, reviews and stores,
+// restores them, and purges them once they've been deleted long enough.
+type TrashRepository interface {
+	SoftDelete(ctx context.Context, kind string, id, deletedBy primitive.ObjectID) error
+	Restore(ctx context.Context, kind string, id primitive.ObjectID) error
+	ListDeleted(ctx context.Context, kind string, limit, skip int64) ([]models.TrashedItem, int64, error)
+	Purge(ctx context.Context, kind string, deletedBefore time.Time) (int64, error)
+}
+
+var ErrTrashNotFound = errors.New("document not found")
+
+type MongoTrashRepository struct {
+	DB *mongo.Database
+}
+
+func NewTrashRepository(db *mongo.Database) TrashRepository {
+	return &MongoTrashRepository{DB: db}
+}
+
+// SoftDelete deletes the document, returning ErrTrashNotFound if there is
+// none or it is already deleted.
+func (r *MongoTrashRepository) SoftDelete(ctx context.Context, kind string, id, deletedBy primitive.ObjectID) error {
+	return softDelete(ctx, r.DB, kind, bson.M{"_id": id}, deletedBy)
+}
+
+// softDelete deletes the document matching filter. A product deleted no
+// longer counts towards its vendor's tier limit.
+func softDelete(ctx context.Context, db *mongo.Database, kind string, filter bson.M, deletedBy primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"deletedAt": time.Now(), "deletedBy": deletedBy}}
+	return inTrashTransaction(ctx, db, kind, NotDeleted(filter), update, -1)
+}
+
+// Restore brings back a soft-deleted document, returning ErrTrashNotFound
+// if it isn't in the trash.
+func (r *MongoTrashRepository) Restore(ctx context.Context, kind string, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}
+	update := bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}}
+	return inTrashTransaction(ctx, r.DB, kind, filter, update, 1)
+}
+
+// inTrashTransaction applies update to the document matching filter and,
+// for a product, moves its vendor's product count by productCount.
+func inTrashTransaction(ctx context.Context, db *mongo.Database, kind string, filter, update bson.M, productCount int) error {
+	if !models.IsTrashKind(kind) {
+		return fmt.Errorf("%s are not soft-deleted", kind)
+	}
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		var doc struct {
+			VendorID primitive.ObjectID `bson:"vendorId"`
+		}
+		err := db.Collection(kind).FindOneAndUpdate(sessCtx, filter, update).Decode(&doc)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrTrashNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if kind == models.TrashProducts {
+			_, err = db.Collection("vendorAccounts").UpdateOne(sessCtx, bson.M{"userID": doc.VendorID}, bson.M{"$inc": bson.M{"productCount": productCount}})
+		}
+		return nil, err
+	})
+	return err
+}
+
+// ListDeleted returns a page of the soft-deleted documents of kind, most
+// recently deleted first, and how many there are.
+func (r *MongoTrashRepository) ListDeleted(ctx context.Context, kind string, limit, skip int64) ([]models.TrashedItem, int64, error) {
+	if !models.IsTrashKind(kind) {
+		return nil, 0, fmt.Errorf("%s are not soft-deleted", kind)
+	}
+	collection := r.DB.Collection(kind)
+	filter := bson.M{"deletedAt": bson.M{"$ne": nil}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deletedAt", Value: -1}}).
+		SetSkip(skip).
+		SetLimit(limit).
+		SetProjection(bson.M{"name": 1, "comment": 1, "deletedAt": 1, "deletedBy": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		models.TrashedItem `bson:",inline"`
+		Name               string `bson:"name"`
+		Comment            string `bson:"comment"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+	items := make([]models.TrashedItem, len(docs))
+	for i, doc := range docs {
+		items[i] = doc.TrashedItem
+		items[i].Kind = kind
+		items[i].Label = doc.Name
+		if items[i].Label == "" {
+			items[i].Label = doc.Comment
+		}
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// Purge removes documents of kind soft-deleted before deletedBefore for
+// good, returning how many.
+func (r *MongoTrashRepository) Purge(ctx context.Context, kind string, deletedBefore time.Time) (int64, error) {
+	if !models.IsTrashKind(kind) {
+		return 0, fmt.Errorf("%s are not soft-deleted", kind)
+	}
+	res, err := r.DB.Collection(kind).DeleteMany(ctx, bson.M{"deletedAt": bson.M{"$lt": deletedBefore}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -21,6 +22,14 @@ type UserRepository interface {
 	UpdateVendorSuspension(ctx context.Context, id primitive.ObjectID, retries int, suspendUntil *time.Time) error
 	ListVendorsPublic(ctx context.Context, filter bson.M, limit, skip int) ([]models.User, int64, error)
 	FetchVendorPublic(ctx context.Context, filter bson.M) (models.User, error)
+	GetAccountState(ctx context.Context, id primitive.ObjectID) (status string, role string, err error)
+	UpdateAccountStatus(ctx context.Context, id primitive.ObjectID, status, reason string, suspendedUntil *time.Time) error
+	UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error
+	SetPhoneOTP(ctx context.Context, id primitive.ObjectID, phone, codeHash string, expiry time.Time) error
+	IncrementPhoneOTPAttempts(ctx context.Context, id primitive.ObjectID) error
+	ConfirmPhone(ctx context.Context, id primitive.ObjectID, phone string) error
+	UpdateNotificationPreferences(ctx context.Context, id primitive.ObjectID, prefs models.NotificationPreferences) error
+	SetSMSOptOutByPhone(ctx context.Context, phone string, optOut bool) (int64, error)
 }
 
 type MongoUserRepository struct {
@@ -59,6 +68,14 @@ func (r *MongoUserRepository) UpdateProfile(ctx context.Context, id primitive.Ob
 
 	update["$set"].(bson.M)["profile"] = profileUpdate
 
+	// A changed number has to be verified again before it gets texts
+	if _, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "phone": bson.M{"$ne": input.Phone}},
+		bson.M{"$set": bson.M{"phoneVerified": false}, "$unset": bson.M{"phoneVerifiedAt": ""}},
+	); err != nil {
+		return err
+	}
+
 	res, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 	if err != nil {
 		return err
@@ -157,8 +174,79 @@ func (r *MongoUserRepository) UpdateVendorSuspension(ctx context.Context, id pri
 	return err
 }
 
+// GetAccountState returns the user's effective account status and current
+// role. Expired suspensions read as active; deleted accounts as deleted.
+func (r *MongoUserRepository) GetAccountState(ctx context.Context, id primitive.ObjectID) (string, string, error) {
+	var user struct {
+		Role           string     `bson:"role"`
+		AccountStatus  string     `bson:"accountStatus"`
+		SuspendedUntil *time.Time `bson:"suspendedUntil"`
+		DeletedAt      *time.Time `bson:"deletedAt"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"role": 1, "accountStatus": 1, "suspendedUntil": 1, "deletedAt": 1})
+	if err := r.DB.Collection("users").FindOne(ctx, bson.M{"_id": id}, opts).Decode(&user); err != nil {
+		return "", "", err
+	}
+	if user.DeletedAt != nil {
+		return models.AccountStatusDeleted, user.Role, nil
+	}
+	return models.EffectiveAccountStatus(user.AccountStatus, user.SuspendedUntil, time.Now()), user.Role, nil
+}
+
+// UpdateAccountStatus suspends, bans or reactivates a user. Suspending or
+// banning also revokes their refresh token so they cannot mint new sessions.
+func (r *MongoUserRepository) UpdateAccountStatus(ctx context.Context, id primitive.ObjectID, status, reason string, suspendedUntil *time.Time) error {
+	set := bson.M{
+		"accountStatus": status,
+		"updatedAt":     time.Now(),
+	}
+	unset := bson.M{}
+	if reason != "" {
+		set["statusReason"] = reason
+	} else {
+		unset["statusReason"] = ""
+	}
+	if status == models.AccountStatusSuspended && suspendedUntil != nil {
+		set["suspendedUntil"] = suspendedUntil
+	} else {
+		unset["suspendedUntil"] = ""
+	}
+	if status != models.AccountStatusActive {
+		unset["refreshToken"] = ""
+		unset["refreshTokenExpiry"] = ""
+	}
+
+	res, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set, "$unset": unset})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"role":      role,
+			"updatedAt": time.Now(),
+		},
+	}
+
+	res, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
 func (r *MongoUserRepository) ListVendorsPublic(ctx context.Context, filter bson.M, limit, skip int) ([]models.User, int64, error) {
 	collection := r.DB.Collection("users")
+	filter = NotDeleted(filter)
 
 	pipeline := []bson.M{
 		{"$match": filter},
@@ -205,6 +293,7 @@ func (r *MongoUserRepository) ListVendorsPublic(ctx context.Context, filter bson
 
 func (r *MongoUserRepository) FetchVendorPublic(ctx context.Context, filter bson.M) (models.User, error) {
 	collection := r.DB.Collection("users")
+	filter = NotDeleted(filter)
 
 	pipeline := []bson.M{
 		{"$match": filter},
@@ -244,3 +333,68 @@ func (r *MongoUserRepository) FetchVendorPublic(ctx context.Context, filter bson
 
 	return users[0], nil
 }
+
+// SetPhoneOTP stores a new verification code for phone, resetting the
+// attempt counter.
+func (r *MongoUserRepository) SetPhoneOTP(ctx context.Context, id primitive.ObjectID, phone, codeHash string, expiry time.Time) error {
+	_, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"phoneOtpPhone":    phone,
+			"phoneOtpHash":     codeHash,
+			"phoneOtpExpiry":   expiry,
+			"phoneOtpSentAt":   time.Now(),
+			"phoneOtpAttempts": 0,
+		},
+	})
+	return err
+}
+
+func (r *MongoUserRepository) IncrementPhoneOTPAttempts(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"phoneOtpAttempts": 1}})
+	return err
+}
+
+// ConfirmPhone makes phone the user's verified number and clears the
+// pending code.
+func (r *MongoUserRepository) ConfirmPhone(ctx context.Context, id primitive.ObjectID, phone string) error {
+	now := time.Now()
+	_, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"phone":           phone,
+			"phoneVerified":   true,
+			"phoneVerifiedAt": now,
+			"updatedAt":       now,
+		},
+		"$unset": bson.M{
+			"phoneOtpPhone":    "",
+			"phoneOtpHash":     "",
+			"phoneOtpExpiry":   "",
+			"phoneOtpAttempts": "",
+		},
+	})
+	return err
+}
+
+func (r *MongoUserRepository) UpdateNotificationPreferences(ctx context.Context, id primitive.ObjectID, prefs models.NotificationPreferences) error {
+	_, err := r.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"notificationPreferences": prefs, "updatedAt": time.Now()},
+	})
+	return err
+}
+
+// SetSMSOptOutByPhone applies a STOP/START reply to every verified account
+// on the number and returns how many were changed.
+func (r *MongoUserRepository) SetSMSOptOutByPhone(ctx context.Context, phone string, optOut bool) (int64, error) {
+	set := bson.M{"notificationPreferences.smsOptOut": optOut, "updatedAt": time.Now()}
+	if optOut {
+		set["notificationPreferences.smsOptedOutAt"] = time.Now()
+	}
+	res, err := r.DB.Collection("users").UpdateMany(ctx,
+		bson.M{"phone": phone, "phoneVerified": true},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepository stores integrators' webhook subscriptions and the log of
+// deliveries made to them.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id primitive.ObjectID) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id primitive.ObjectID) error
+	SubscriptionsFor(ctx context.Context, eventType string) ([]models.WebhookSubscription, error)
+
+	RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, filter bson.M, limit, skip int64) ([]models.WebhookDelivery, int64, error)
+}
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type MongoWebhookRepository struct {
+	DB *mongo.Database
+}
+
+func NewWebhookRepository(db *mongo.Database) WebhookRepository {
+	return &MongoWebhookRepository{DB: db}
+}
+
+func (r *MongoWebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	sub.ID = primitive.NewObjectID()
+	sub.CreatedAt = time.Now()
+	sub.UpdatedAt = sub.CreatedAt
+	_, err := r.DB.Collection("webhookSubscriptions").InsertOne(ctx, sub)
+	return err
+}
+
+func (r *MongoWebhookRepository) GetSubscription(ctx context.Context, id primitive.ObjectID) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := r.DB.Collection("webhookSubscriptions").FindOne(ctx, bson.M{"_id": id}).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions returns every subscription, newest first.
+func (r *MongoWebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	return r.findSubscriptions(ctx, bson.M{}, opts)
+}
+
+// UpdateSubscription sets fields on the subscription, returning it updated.
+func (r *MongoWebhookRepository) UpdateSubscription(ctx context.Context, id primitive.ObjectID, fields bson.M) (*models.WebhookSubscription, error) {
+	fields["updatedAt"] = time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var sub models.WebhookSubscription
+	err := r.DB.Collection("webhookSubscriptions").FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": fields}, opts).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// DeleteSubscription removes the subscription. Its delivery log is kept
+// until it expires.
+func (r *MongoWebhookRepository) DeleteSubscription(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.DB.Collection("webhookSubscriptions").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// SubscriptionsFor returns the active subscriptions to eventType.
+func (r *MongoWebhookRepository) SubscriptionsFor(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	filter := bson.M{
+		"active": true,
+		"events": bson.M{"$in": bson.A{eventType, models.WebhookAllEvents}},
+	}
+	return r.findSubscriptions(ctx, filter, options.Find())
+}
+
+func (r *MongoWebhookRepository) findSubscriptions(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.WebhookSubscription, error) {
+	cursor, err := r.DB.Collection("webhookSubscriptions").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	subs := []models.WebhookSubscription{}
+	if err := cursor.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *MongoWebhookRepository) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.ID = primitive.NewObjectID()
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+	_, err := r.DB.Collection("webhookDeliveries").InsertOne(ctx, delivery)
+	return err
+}
+
+// UpdateDelivery saves the outcome of sending the delivery.
+func (r *MongoWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.DB.Collection("webhookDeliveries").UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{"$set": bson.M{
+		"status":         delivery.Status,
+		"attempts":       delivery.Attempts,
+		"responseStatus": delivery.ResponseStatus,
+		"error":          delivery.Error,
+		"deliveredAt":    delivery.DeliveredAt,
+	}})
+	return err
+}
+
+func (r *MongoWebhookRepository) GetDelivery(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.DB.Collection("webhookDeliveries").FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns deliveries matching filter, newest first.
+func (r *MongoWebhookRepository) ListDeliveries(ctx context.Context, filter bson.M, limit, skip int64) ([]models.WebhookDelivery, int64, error) {
+	collection := r.DB.Collection("webhookDeliveries")
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(limit).
+		SetSkip(skip)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,607 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+)
+
+// currencyCodes checks MERCHANT_FEED_CURRENCY against the validator's ISO
+// 4217 list.
+var currencyCodes = validator.New()
+
+// Environments APP_ENV can name
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Config is the server's settings, read once at startup.
+type Config struct {
+	Env        string
+	Port       string
+	JWTSecret  string
+	Mongo      MongoConfig
+	Stripe     StripeConfig
+	Cloudinary CloudinaryConfig
+	Email      EmailConfig
+	SMS        SMSConfig
+	Shipping   ShippingConfig
+	Geocoder   GeocoderConfig
+	Webhooks   WebhookConfig
+	Gemini     GeminiConfig
+	Telemetry  TelemetryConfig
+	API        APIConfig
+	RateLimits map[string]RateLimit // Policy name -> override, from RATE_LIMIT_<POLICY>
+	// Addresses or CIDR ranges of the proxies in front of the server, from
+	// comma-separated TRUSTED_PROXIES. Client IPs are only read from
+	// X-Forwarded-For when the request comes through one; when unset, the
+	// connecting address is the client.
+	TrustedProxies []string
+	// How long soft-deleted documents can be restored before they are
+	// purged, from SOFT_DELETE_RETENTION_DAYS
+	TrashRetention time.Duration
+	// ISO 4217 code the Merchant Center feed lists prices in, from
+	// MERCHANT_FEED_CURRENCY. Payments are taken in USD, so that is the
+	// default.
+	MerchantFeedCurrency string
+}
+
+type MongoConfig struct {
+	URI      string
+	Database string
+}
+
+type StripeConfig struct {
+	SecretKey     string
+	WebhookSecret string
+}
+
+type CloudinaryConfig struct {
+	CloudName string
+	APIKey    string
+	APISecret string
+}
+
+// EmailConfig picks the email provider, EMAIL_PROVIDER (brevo, the default,
+// smtp, sendgrid or ses), and holds its credentials.
+type EmailConfig struct {
+	Provider       string
+	SenderEmail    string
+	SenderName     string
+	BrevoAPIKey    string
+	SendGridAPIKey string
+	SMTP           SMTPConfig
+	SES            SESConfig
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+type SESConfig struct {
+	Region       string
+	AccessKeyID  string
+	SecretKey    string
+	SessionToken string
+}
+
+// SMSConfig routes texts by country calling code, from SMS_ROUTES, and
+// holds the credentials of the providers the routes use.
+type SMSConfig struct {
+	Routes []SMSRoute
+	Twilio TwilioConfig
+	Termii TermiiConfig
+}
+
+// SMSRoute sends numbers starting with Prefix ("*" for any) through
+// Provider (twilio or termii) from Sender.
+type SMSRoute struct {
+	Prefix   string
+	Provider string
+	Sender   string
+}
+
+type TwilioConfig struct {
+	AccountSID     string
+	AuthToken      string
+	WebhookBaseURL string // Public base URL Twilio posts inbound texts to, for checking signatures
+}
+
+type TermiiConfig struct {
+	APIKey  string
+	BaseURL string
+}
+
+// ShippingConfig picks the carrier aggregator, SHIPPING_PROVIDER (shippo or
+// easypost; unset prices from the rate tables alone), and holds the token
+// its tracking webhooks must carry.
+type ShippingConfig struct {
+	Provider       string
+	ShippoAPIKey   string
+	EasyPostAPIKey string
+	WebhookToken   string
+}
+
+// GeocoderConfig picks the geocoder, GEOCODER_PROVIDER (google; unset only
+// checks addresses for format).
+type GeocoderConfig struct {
+	Provider         string
+	GoogleMapsAPIKey string
+}
+
+// WebhookConfig lists the endpoints every domain event is posted to, and
+// the secret deliveries are signed with.
+type WebhookConfig struct {
+	URLs   []string
+	Secret string
+}
+
+// GeminiConfig holds the key for the Gemini API, used to check documents.
+type GeminiConfig struct {
+	APIKey string
+}
+
+// TelemetryConfig says where traces go and who may read /metrics.
+type TelemetryConfig struct {
+	ServiceName  string
+	OTLPEndpoint string  // Traces are dropped when unset
+	SampleRatio  float64 // Share of new traces kept, 0 to 1
+	MetricsToken string  // Bearer token for /metrics; open when unset
+}
+
+// APIConfig schedules the retirement of API v1 in favour of v2.
+type APIConfig struct {
+	V1DeprecatedAt time.Time // Sent in v1's Deprecation header; unset until v1 is deprecated
+	V1Sunset       time.Time // When v1 stops answering, sent in its Sunset header
+}
+
+// RateLimit overrides a rate limit policy's default: Requests per Window.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Configured reports whether uploads can go to Cloudinary.
+func (c CloudinaryConfig) Configured() bool {
+	return c.CloudName != "" && c.APIKey != "" && c.APISecret != ""
+}
+
+// Configured reports whether email can be sent.
+func (c EmailConfig) Configured() bool {
+	return c.SenderEmail != ""
+}
+
+// Load reads the environment, after filling in anything it doesn't set from
+// .env.<APP_ENV>.local, .env.<APP_ENV> and .env, in that order of
+// precedence, and validates the result.
+func Load() (*Config, error) {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		// APP_ENV may itself come from .env
+		if values, err := godotenv.Read(".env"); err == nil {
+			env = values["APP_ENV"]
+		}
+	}
+	env = strings.ToLower(strings.TrimSpace(env))
+	if env == "" {
+		env = EnvDevelopment
+	}
+
+	for _, file := range []string{".env." + env + ".local", ".env." + env, ".env"} {
+		if _, err := os.Stat(file); err == nil {
+			if err := godotenv.Load(file); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file, err)
+			}
+		}
+	}
+
+	cfg := &Config{
+		Env:       env,
+		Port:      get("PORT", "8080"),
+		JWTSecret: get("JWT_SECRET", ""),
+		Mongo: MongoConfig{
+			URI:      get("MONGO_URI", ""),
+			Database: get("MONGO_DATABASE", "vendora"),
+		},
+		Stripe: StripeConfig{
+			SecretKey:     get("STRIPE_SECRET_KEY", ""),
+			WebhookSecret: get("STRIPE_WEBHOOK_SECRET", ""),
+		},
+		Cloudinary: CloudinaryConfig{
+			CloudName: get("CLOUDINARY_CLOUD_NAME", ""),
+			APIKey:    get("CLOUDINARY_API_KEY", ""),
+			APISecret: get("CLOUDINARY_API_SECRET", ""),
+		},
+		Email: EmailConfig{
+			Provider:       strings.ToLower(get("EMAIL_PROVIDER", "brevo")),
+			SenderEmail:    get("SENDER_EMAIL", ""),
+			SenderName:     get("SENDER_NAME", ""),
+			BrevoAPIKey:    get("BREVO_API_KEY", ""),
+			SendGridAPIKey: get("SENDGRID_API_KEY", ""),
+			SMTP: SMTPConfig{
+				Host:     get("SMTP_HOST", ""),
+				Port:     get("SMTP_PORT", "587"),
+				Username: get("SMTP_USERNAME", ""),
+				Password: get("SMTP_PASSWORD", ""),
+			},
+			SES: SESConfig{
+				Region:       get("AWS_REGION", ""),
+				AccessKeyID:  get("AWS_ACCESS_KEY_ID", ""),
+				SecretKey:    get("AWS_SECRET_ACCESS_KEY", ""),
+				SessionToken: get("AWS_SESSION_TOKEN", ""),
+			},
+		},
+		SMS: SMSConfig{
+			Twilio: TwilioConfig{
+				AccountSID:     get("TWILIO_ACCOUNT_SID", ""),
+				AuthToken:      get("TWILIO_AUTH_TOKEN", ""),
+				WebhookBaseURL: strings.TrimRight(get("TWILIO_WEBHOOK_BASE_URL", ""), "/"),
+			},
+			Termii: TermiiConfig{
+				APIKey:  get("TERMII_API_KEY", ""),
+				BaseURL: strings.TrimRight(get("TERMII_BASE_URL", "https://api.ng-termii.com"), "/"),
+			},
+		},
+		Shipping: ShippingConfig{
+			Provider:       strings.ToLower(get("SHIPPING_PROVIDER", "")),
+			ShippoAPIKey:   get("SHIPPO_API_KEY", ""),
+			EasyPostAPIKey: get("EASYPOST_API_KEY", ""),
+			WebhookToken:   get("SHIPPING_WEBHOOK_TOKEN", ""),
+		},
+		Geocoder: GeocoderConfig{
+			Provider:         strings.ToLower(get("GEOCODER_PROVIDER", "")),
+			GoogleMapsAPIKey: get("GOOGLE_MAPS_API_KEY", ""),
+		},
+		Webhooks: WebhookConfig{
+			URLs:   list(get("WEBHOOK_URLS", "")),
+			Secret: get("WEBHOOK_SECRET", ""),
+		},
+		Gemini: GeminiConfig{
+			APIKey: get("GEMINI_API_KEY", ""),
+		},
+		Telemetry: TelemetryConfig{
+			ServiceName:  get("OTEL_SERVICE_NAME", "vendora-backend"),
+			OTLPEndpoint: get("OTEL_EXPORTER_OTLP_ENDPOINT", get("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")),
+			MetricsToken: get("METRICS_TOKEN", ""),
+		},
+	}
+	ratio, err := strconv.ParseFloat(get("TRACE_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		ratio = -1 // Reported by Validate
+	}
+	cfg.Telemetry.SampleRatio = ratio
+
+	if cfg.API.V1DeprecatedAt, err = dateFromEnv("API_V1_DEPRECATED_AT"); err != nil {
+		return nil, err
+	}
+	if cfg.API.V1Sunset, err = dateFromEnv("API_V1_SUNSET"); err != nil {
+		return nil, err
+	}
+
+	days, err := strconv.Atoi(get("SOFT_DELETE_RETENTION_DAYS", "30"))
+	if err != nil || days <= 0 {
+		return nil, fmt.Errorf("invalid configuration: SOFT_DELETE_RETENTION_DAYS must be a whole number of days, not %q", get("SOFT_DELETE_RETENTION_DAYS", ""))
+	}
+	cfg.TrashRetention = time.Duration(days) * 24 * time.Hour
+
+	if cfg.SMS.Routes, err = smsRoutesFromEnv(); err != nil {
+		return nil, err
+	}
+
+	rateLimits, err := rateLimitsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg.RateLimits = rateLimits
+	cfg.TrustedProxies = list(get("TRUSTED_PROXIES", ""))
+	cfg.MerchantFeedCurrency = strings.ToUpper(get("MERCHANT_FEED_CURRENCY", "USD"))
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// rateLimitsFromEnv reads overrides like RATE_LIMIT_SEARCH=120/1m, which
+// allows 120 search requests a minute per client.
+func rateLimitsFromEnv() (map[string]RateLimit, error) {
+	limits := map[string]RateLimit{}
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(key, "RATE_LIMIT_") || strings.TrimSpace(value) == "" {
+			continue
+		}
+		requests, window, ok := strings.Cut(strings.TrimSpace(value), "/")
+		n, err := strconv.Atoi(requests)
+		d, derr := time.ParseDuration(window)
+		if !ok || err != nil || derr != nil || n <= 0 || d <= 0 {
+			return nil, fmt.Errorf("invalid configuration: %s must look like 120/1m, not %q", key, value)
+		}
+		limits[strings.ToLower(strings.TrimPrefix(key, "RATE_LIMIT_"))] = RateLimit{Requests: n, Window: d}
+	}
+	return limits, nil
+}
+
+// smsRoutesFromEnv reads SMS_ROUTES, a comma-separated list of
+// prefix:provider:sender entries, e.g.
+// "234:termii:Vendora,*:twilio:+15551234567".
+func smsRoutesFromEnv() ([]SMSRoute, error) {
+	var routes []SMSRoute
+	for _, entry := range list(get("SMS_ROUTES", "")) {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid configuration: SMS_ROUTES entries must look like 234:termii:Vendora, not %q", entry)
+		}
+		routes = append(routes, SMSRoute{
+			Prefix:   strings.TrimPrefix(parts[0], "+"),
+			Provider: strings.ToLower(parts[1]),
+			Sender:   parts[2],
+		})
+	}
+	return routes, nil
+}
+
+// dateFromEnv reads a date like 2026-01-31 or an RFC 3339 timestamp. Dates
+// are midnight UTC.
+func dateFromEnv(key string) (time.Time, error) {
+	value := get(key, "")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid configuration: %s must be a date like 2026-01-31, not %q", key, value)
+}
+
+// IsProduction reports whether the server is running for real customers.
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Validate checks the settings the server can't run without. Outside
+// production, payments, uploads, email and the database may be left unset;
+// they are listed by Warnings instead. Optional services that are set up
+// must be set up completely.
+func (c *Config) Validate() error {
+	var problems []string
+	switch c.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		problems = append(problems, fmt.Sprintf("APP_ENV must be %s, %s or %s, not %q", EnvDevelopment, EnvStaging, EnvProduction, c.Env))
+	}
+	if port, err := strconv.Atoi(strings.TrimPrefix(c.Port, ":")); err != nil || port <= 0 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT must be a port number, not %q", c.Port))
+	}
+	if c.JWTSecret == "" {
+		problems = append(problems, "JWT_SECRET is not set")
+	} else if c.IsProduction() && len(c.JWTSecret) < 32 {
+		problems = append(problems, "JWT_SECRET must be at least 32 characters in production")
+	}
+	if c.Stripe.SecretKey != "" && !strings.HasPrefix(c.Stripe.SecretKey, "sk_") && !strings.HasPrefix(c.Stripe.SecretKey, "rk_") {
+		problems = append(problems, "STRIPE_SECRET_KEY is not a Stripe secret key")
+	}
+	if c.Stripe.WebhookSecret != "" && !strings.HasPrefix(c.Stripe.WebhookSecret, "whsec_") {
+		problems = append(problems, "STRIPE_WEBHOOK_SECRET is not a Stripe webhook signing secret")
+	}
+	if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+		problems = append(problems, "TRACE_SAMPLE_RATIO must be a number from 0 to 1")
+	}
+	if !c.API.V1Sunset.IsZero() && (c.API.V1DeprecatedAt.IsZero() || c.API.V1Sunset.Before(c.API.V1DeprecatedAt)) {
+		problems = append(problems, "API_V1_SUNSET must come after API_V1_DEPRECATED_AT")
+	}
+	problems = append(problems, c.Email.problems()...)
+	problems = append(problems, c.SMS.problems()...)
+	problems = append(problems, c.Shipping.problems()...)
+	problems = append(problems, c.Geocoder.problems()...)
+	for _, u := range c.Webhooks.URLs {
+		if !isHTTPURL(u) {
+			problems = append(problems, fmt.Sprintf("WEBHOOK_URLS must list http(s) URLs, not %q", u))
+		}
+	}
+	if c.IsProduction() && len(c.Webhooks.URLs) > 0 && c.Webhooks.Secret == "" {
+		problems = append(problems, "WEBHOOK_SECRET must be set with WEBHOOK_URLS in production")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES must list IP addresses or CIDR ranges, not %q", proxy))
+			}
+		}
+	}
+	if c.MerchantFeedCurrency != "" && currencyCodes.Var(c.MerchantFeedCurrency, "iso4217") != nil {
+		problems = append(problems, fmt.Sprintf("MERCHANT_FEED_CURRENCY must be an ISO 4217 currency code, not %q", c.MerchantFeedCurrency))
+	}
+	if c.IsProduction() {
+		for _, missing := range c.missing() {
+			problems = append(problems, missing+" is not set")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Warnings lists settings left unset outside production, and what won't
+// work without them.
+func (c *Config) Warnings() []string {
+	if c.IsProduction() {
+		return nil
+	}
+	var warnings []string
+	for _, missing := range c.missing() {
+		warnings = append(warnings, missing+" is not set; "+disabledWithout[missing])
+	}
+	return warnings
+}
+
+var disabledWithout = map[string]string{
+	"MONGO_URI":             "running without a database",
+	"STRIPE_SECRET_KEY":     "payments will fail",
+	"STRIPE_WEBHOOK_SECRET": "Stripe webhooks will be rejected",
+	"CLOUDINARY_*":          "uploads will fail",
+	"SENDER_EMAIL":          "emails will fail",
+}
+
+// missing lists the settings production requires that are unset.
+func (c *Config) missing() []string {
+	var missing []string
+	if c.Mongo.URI == "" {
+		missing = append(missing, "MONGO_URI")
+	}
+	if c.Stripe.SecretKey == "" {
+		missing = append(missing, "STRIPE_SECRET_KEY")
+	}
+	if c.Stripe.WebhookSecret == "" {
+		missing = append(missing, "STRIPE_WEBHOOK_SECRET")
+	}
+	if !c.Cloudinary.Configured() {
+		missing = append(missing, "CLOUDINARY_*")
+	}
+	if !c.Email.Configured() {
+		missing = append(missing, "SENDER_EMAIL")
+	}
+	return missing
+}
+
+// problems checks the chosen email provider has what it needs, once a
+// sender is set.
+func (c EmailConfig) problems() []string {
+	if !c.Configured() {
+		return nil
+	}
+	var problems []string
+	if _, err := mail.ParseAddress(c.SenderEmail); err != nil {
+		problems = append(problems, fmt.Sprintf("SENDER_EMAIL must be an email address, not %q", c.SenderEmail))
+	}
+	switch c.Provider {
+	case "brevo":
+		if c.BrevoAPIKey == "" {
+			problems = append(problems, "BREVO_API_KEY is not set")
+		}
+	case "smtp":
+		if c.SMTP.Host == "" {
+			problems = append(problems, "SMTP_HOST is not set")
+		}
+		if port, err := strconv.Atoi(c.SMTP.Port); err != nil || port <= 0 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("SMTP_PORT must be a port number, not %q", c.SMTP.Port))
+		}
+	case "sendgrid":
+		if c.SendGridAPIKey == "" {
+			problems = append(problems, "SENDGRID_API_KEY is not set")
+		}
+	case "ses":
+		if c.SES.Region == "" || c.SES.AccessKeyID == "" || c.SES.SecretKey == "" {
+			problems = append(problems, "AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for SES")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("EMAIL_PROVIDER must be brevo, smtp, sendgrid or ses, not %q", c.Provider))
+	}
+	return problems
+}
+
+// problems checks every provider SMS is routed through has its
+// credentials.
+func (c SMSConfig) problems() []string {
+	var problems []string
+	used := map[string]bool{}
+	for _, route := range c.Routes {
+		used[route.Provider] = true
+	}
+	for provider := range used {
+		switch provider {
+		case "twilio":
+			if c.Twilio.AccountSID == "" || c.Twilio.AuthToken == "" {
+				problems = append(problems, "TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN must be set to route SMS through Twilio")
+			}
+		case "termii":
+			if c.Termii.APIKey == "" {
+				problems = append(problems, "TERMII_API_KEY must be set to route SMS through Termii")
+			}
+			if !isHTTPURL(c.Termii.BaseURL) {
+				problems = append(problems, fmt.Sprintf("TERMII_BASE_URL must be an http(s) URL, not %q", c.Termii.BaseURL))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("SMS_ROUTES names unknown SMS provider %q", provider))
+		}
+	}
+	if c.Twilio.WebhookBaseURL != "" && !isHTTPURL(c.Twilio.WebhookBaseURL) {
+		problems = append(problems, fmt.Sprintf("TWILIO_WEBHOOK_BASE_URL must be an http(s) URL, not %q", c.Twilio.WebhookBaseURL))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// problems checks the chosen shipping provider has its API key.
+func (c ShippingConfig) problems() []string {
+	switch c.Provider {
+	case "":
+	case "shippo":
+		if c.ShippoAPIKey == "" {
+			return []string{"SHIPPO_API_KEY is not set"}
+		}
+	case "easypost":
+		if c.EasyPostAPIKey == "" {
+			return []string{"EASYPOST_API_KEY is not set"}
+		}
+	default:
+		return []string{fmt.Sprintf("SHIPPING_PROVIDER must be shippo or easypost, not %q", c.Provider)}
+	}
+	return nil
+}
+
+// problems checks the chosen geocoder has its API key.
+func (c GeocoderConfig) problems() []string {
+	switch c.Provider {
+	case "":
+	case "google":
+		if c.GoogleMapsAPIKey == "" {
+			return []string{"GOOGLE_MAPS_API_KEY is not set"}
+		}
+	default:
+		return []string{fmt.Sprintf("GEOCODER_PROVIDER must be google, not %q", c.Provider)}
+	}
+	return nil
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// list splits a comma-separated setting, dropping blanks.
+func list(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func get(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Amounts of money are stored as int64 counts of minor units (see
+// models.Money). Documents written before that hold doubles of major units,
+// which the models still read correctly, but aggregations that add them up
+// and queries that compare them would mix the two. MigrateMoney rewrites
+// them in place.
+
+// MoneyFields are the fields holding money in each collection. A path with
+// "[]." in it is a field of each element of an array, e.g. "items[].price"
+// is the price of every item.
+var MoneyFields = map[string][]string{
+	"products":                {"price", "salePrice", "costPrice", "sale.previousSalePrice", "variants[].price"},
+	"carts":                   {"items[].price"},
+	"orders":                  {"subtotal", "discount", "shippingFee", "tax", "total", "refundedAmount", "items[].price", "items[].subtotal", "items[].discount", "items[].listPrice", "refunds[].amount"},
+	"transactions":            {"amount", "fee", "promoCost"},
+	"payouts":                 {"amount"},
+	"vendorAccounts":          {"availableBalance", "pendingBalance", "lifeTimeEarnings", "lifeTimePromoCost"},
+	"disputes":                {"amount", "resolution.refundAmount"},
+	"couponRedemptions":       {"discount"},
+	"autoDiscounts":           {"discountTotal"},
+	"autoDiscountRedemptions": {"discount", "orderTotal"},
+	"flashSales":              {"price", "originalPrice"},
+}
+
+// MigrateMoney converts every double in MoneyFields to minor units, rounding
+// halves away from zero as models.FromFloat does. Only doubles are touched,
+// so it is safe to run again, and to run while the new code is writing
+// integers. It returns how many documents it changed in each collection.
+func MigrateMoney(ctx context.Context, db *mongo.Database) (map[string]int64, error) {
+	migrated := map[string]int64{}
+	for name, fields := range MoneyFields {
+		coll := db.Collection(name)
+		for _, field := range fields {
+			filter, update := moneyUpdate(field)
+			res, err := coll.UpdateMany(ctx, filter, update)
+			if err != nil {
+				return migrated, fmt.Errorf("migrate %s.%s: %w", name, field, err)
+			}
+			migrated[name] += res.ModifiedCount
+		}
+	}
+	return migrated, nil
+}
+
+// moneyUpdate is the filter matching documents where field still holds a
+// double, and the pipeline update converting it.
+func moneyUpdate(field string) (bson.M, mongo.Pipeline) {
+	array, elem, inArray := strings.Cut(field, "[].")
+	if !inArray {
+		return bson.M{field: bson.M{"$type": "double"}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{field: toMinorUnits("$" + field)}}}}
+	}
+	return bson.M{array + "." + elem: bson.M{"$type": "double"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{array: bson.M{"$map": bson.M{
+			"input": "$" + array,
+			"in": bson.M{"$mergeObjects": bson.A{
+				"$$this",
+				bson.M{elem: toMinorUnits("$$this." + elem)},
+			}},
+		}}}}}}
+}
+
+// toMinorUnits converts the value at path if it is a double and leaves it
+// alone otherwise.
+func toMinorUnits(path string) bson.M {
+	half := bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{path, 0}}, 0.5, -0.5}}
+	cents := bson.M{"$toLong": bson.M{"$trunc": bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{path, 100}}, half}}}}
+	return bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$type": path}, "double"}}, cents, path}}
+}
@@ -3,22 +3,17 @@ package database
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func ConnectToDB() (*mongo.Database, error) {
-	if err := godotenv.Load(); err != nil {
-		logrus.Info("No .env file found (using environment variables)")
-	}
-
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
+func ConnectToDB(cfg config.MongoConfig) (*mongo.Database, error) {
+	if cfg.URI == "" {
 		return nil, fmt.Errorf("MONGO_URI is not set")
 	}
 
@@ -32,7 +27,8 @@ func ConnectToDB() (*mongo.Database, error) {
 		SetMaxConnIdleTime(30*time.Second).
 		SetServerSelectionTimeout(10*time.Second).
 		SetConnectTimeout(10*time.Second).
-		ApplyURI(mongoURI))
+		SetMonitor(telemetry.MongoMonitor()).
+		ApplyURI(cfg.URI))
 	if err != nil {
 		return nil, fmt.Errorf("mongo connect failed: %w", err)
 	}
@@ -42,5 +38,5 @@ func ConnectToDB() (*mongo.Database, error) {
 	}
 
 	logrus.Info("MongoDB connection ready")
-	return client.Database("vendora"), nil
+	return client.Database(cfg.Database), nil
 }
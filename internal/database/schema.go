@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Schema validators make the database itself reject malformed documents in
+// the collections money and vendor standing depend on, whatever code path
+// writes them. They check the fields every document must have and the types
+// and values of the ones that matter; anything else is left to the models.
+//
+// They are applied with validationLevel "moderate": documents written before
+// a rule existed can still be updated, but new ones and ones that already
+// pass must keep passing.
+
+// number is any of BSON's numeric types: Go ints are written as int32 or
+// int64 depending on size, and $inc can turn either into a double.
+const number = "number"
+
+// money is how amounts are stored: whole minor units, as models.Money writes
+// them, once MigrateMoney has converted any older doubles.
+const money = "long"
+
+var orderStatuses = bson.A{"pending", "paid", "confirmed", "shipped", "out_for_delivery", "delivered", "cancelled", "refunded"}
+
+// CollectionSchemas are the $jsonSchema validators for each collection that
+// has one.
+var CollectionSchemas = map[string]bson.M{
+	"orders": {
+		"bsonType": "object",
+		"required": bson.A{"userId", "orderNumber", "items", "total", "status", "paymentStatus", "createdAt"},
+		"properties": bson.M{
+			"userId":      bson.M{"bsonType": "objectId"},
+			"orderNumber": bson.M{"bsonType": "string", "minLength": 1},
+			"items": bson.M{
+				"bsonType": "array",
+				"minItems": 1,
+				"items": bson.M{
+					"bsonType": "object",
+					"required": bson.A{"productId", "vendorId", "price", "quantity"},
+					"properties": bson.M{
+						"productId": bson.M{"bsonType": "objectId"},
+						"vendorId":  bson.M{"bsonType": "objectId"},
+						"price":     bson.M{"bsonType": money, "minimum": 0},
+						"quantity":  bson.M{"bsonType": number, "minimum": 1},
+						"subtotal":  bson.M{"bsonType": money, "minimum": 0},
+					},
+				},
+			},
+			"subtotal":       bson.M{"bsonType": money, "minimum": 0},
+			"shippingFee":    bson.M{"bsonType": money, "minimum": 0},
+			"tax":            bson.M{"bsonType": money, "minimum": 0},
+			"total":          bson.M{"bsonType": money, "minimum": 0},
+			"refundedAmount": bson.M{"bsonType": money, "minimum": 0},
+			"status":         bson.M{"enum": orderStatuses},
+			"paymentStatus":  bson.M{"enum": bson.A{"pending", "paid", "partially_refunded", "refunded"}},
+			"createdAt":      bson.M{"bsonType": "date"},
+		},
+	},
+	"products": {
+		"bsonType": "object",
+		"required": bson.A{"vendorId", "name", "price"},
+		"properties": bson.M{
+			"vendorId":  bson.M{"bsonType": "objectId"},
+			"name":      bson.M{"bsonType": "string", "minLength": 1},
+			"price":     bson.M{"bsonType": money, "minimum": 0},
+			"salePrice": bson.M{"bsonType": money, "minimum": 0},
+			"status":    bson.M{"enum": bson.A{"draft", "active", "archived", "removed", "flagged"}},
+			"images":    bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+		},
+	},
+	"vendorAccounts": {
+		"bsonType": "object",
+		"required": bson.A{"userID", "tier", "status"},
+		"properties": bson.M{
+			"userID":           bson.M{"bsonType": "objectId"},
+			"tier":             bson.M{"enum": bson.A{"individual", "verified", "business"}},
+			"status":           bson.M{"enum": bson.A{"active", "suspended", "banned"}},
+			"availableBalance": bson.M{"bsonType": money},
+			"pendingBalance":   bson.M{"bsonType": money},
+			"transactionFee":   bson.M{"bsonType": number, "minimum": 0, "maximum": 100},
+		},
+	},
+	"sellerApplications": {
+		"bsonType": "object",
+		"required": bson.A{"userID", "requestedTier", "storeName", "status"},
+		"properties": bson.M{
+			"userID":        bson.M{"bsonType": "objectId"},
+			"requestedTier": bson.M{"enum": bson.A{"individual", "verified", "business"}},
+			"storeName":     bson.M{"bsonType": "string", "minLength": 1},
+			"status":        bson.M{"enum": bson.A{"draft", "pending", "under_review", "approved", "rejected"}},
+			"termsAccepted": bson.M{"bsonType": "bool"},
+		},
+	},
+}
+
+// ApplySchemas installs CollectionSchemas on db, creating collections that
+// don't exist yet. It is safe to run again after a schema changes.
+func ApplySchemas(ctx context.Context, db *mongo.Database) error {
+	for name, schema := range CollectionSchemas {
+		validator := bson.M{"$jsonSchema": schema}
+		err := db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: name},
+			{Key: "validator", Value: validator},
+			{Key: "validationLevel", Value: "moderate"},
+			{Key: "validationAction", Value: "error"},
+		}).Err()
+
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceNotFound" {
+			err = db.CreateCollection(ctx, name, options.CreateCollection().
+				SetValidator(validator).
+				SetValidationLevel("moderate").
+				SetValidationAction("error"))
+		}
+		if err != nil {
+			return fmt.Errorf("apply %s schema: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package docs serves the generated OpenAPI document and a Swagger UI to
+// browse it.
+package docs
+
+//go:generate go run ../../cmd/openapi -root ../.. -o openapi.json
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec is the OpenAPI document, regenerated by go generate.
+//
+//go:embed openapi.json
+var Spec []byte
+
+const swaggerUIVersion = "5.17.14"
+
+var swaggerUI = []byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Vendora API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui", persistAuthorization: true });
+  </script>
+</body>
+</html>
+`)
+
+// Register serves the Swagger UI at /docs and the document at
+// /docs/openapi.json.
+func Register(router gin.IRouter) {
+	router.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/docs/")
+	})
+	router.GET("/docs/", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+	})
+	router.GET("/docs/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", Spec)
+	})
+}
@@ -0,0 +1,148 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Onboarding lifecycle event types.
+const (
+	DraftSaved           = "draft.saved"
+	ApplicationSubmitted = "application.submitted"
+	ApplicationApproved  = "application.approved"
+	ApplicationRejected  = "application.rejected"
+	VendorActivated      = "vendor.activated"
+	VendorApproved       = "vendor.approved"
+)
+
+// Marketplace event types.
+const (
+	ProductCreated      = "product.created"
+	OrderPlaced         = "order.placed"
+	OrderPaid           = "order.paid"
+	OrderStatusChanged  = "order.status_changed"
+	OrderRefunded       = "order.refunded"
+	TierRequestApproved = "tier_request.approved"
+	TierRequestRejected = "tier_request.rejected"
+	ReviewCreated       = "review.created"
+	ReviewResponded     = "review.responded"
+	MessageReceived     = "message.received"
+	PayoutSent          = "payout.sent"
+	DisputeOpened       = "dispute.opened"
+	DisputeUpdated      = "dispute.updated"
+	DisputeResolved     = "dispute.resolved"
+)
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+// Types is every event type published, for subscribers that choose among
+// them.
+var Types = []string{
+	DraftSaved, ApplicationSubmitted, ApplicationApproved, ApplicationRejected, VendorActivated, VendorApproved,
+	ProductCreated, OrderPlaced, OrderPaid, OrderStatusChanged, OrderRefunded,
+	TierRequestApproved, TierRequestRejected, ReviewCreated, ReviewResponded,
+	MessageReceived, PayoutSent, DisputeOpened, DisputeUpdated, DisputeResolved,
+}
+
+// Event is a domain event published on the bus.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurredAt"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// Handler reacts to a published event.
+type Handler func(ctx context.Context, e Event)
+
+// Bus is an in-process publish/subscribe event bus. Handlers run
+// asynchronously so publishers never wait on subscribers.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event type, or AllEvents.
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+// New creates an event that has just happened, for publishing now or
+// writing to the outbox to be published by the relay. Either way its ID
+// stays the same, so subscribers can tell a redelivery from a new event.
+func New(eventType string, data map[string]interface{}) Event {
+	return Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+}
+
+// Publish delivers an event to every matching handler in its own goroutine.
+// Handlers get a fresh context because the publisher's request context is
+// usually cancelled as soon as the response is written.
+func (b *Bus) Publish(eventType string, data map[string]interface{}) Event {
+	e := New(eventType, data)
+	for _, h := range b.handlersFor(e.Type) {
+		go b.run(context.Background(), h, e)
+	}
+	return e
+}
+
+// Dispatch delivers an event to every matching handler at the same time and
+// returns once they have all finished with it.
+func (b *Bus) Dispatch(ctx context.Context, e Event) {
+	var wg sync.WaitGroup
+	for _, h := range b.handlersFor(e.Type) {
+		wg.Add(1)
+		go func(h Handler) {
+			defer wg.Done()
+			b.run(ctx, h, e)
+		}(h)
+	}
+	wg.Wait()
+}
+
+func (b *Bus) handlersFor(eventType string) []Handler {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	handlers := append([]Handler{}, b.handlers[eventType]...)
+	return append(handlers, b.handlers[AllEvents]...)
+}
+
+// run calls a handler with up to a minute to finish, surviving its panics.
+func (b *Bus) run(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.WithField("event", e.Type).Errorf("event handler panicked: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	h(ctx, e)
+}
+
+// Default is the process-wide bus used by handlers.
+var Default = NewBus()
+
+// Subscribe registers a handler on the Default bus.
+func Subscribe(eventType string, h Handler) {
+	Default.Subscribe(eventType, h)
+}
+
+// Publish emits an event on the Default bus.
+func Publish(eventType string, data map[string]interface{}) Event {
+	return Default.Publish(eventType, data)
+}
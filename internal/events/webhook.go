@@ -0,0 +1,119 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookDispatcher forwards bus events to external HTTP endpoints (CRM,
+// email marketing, ...). Each delivery is a JSON POST of the Event, signed
+// with HMAC-SHA256 in the X-Vendora-Signature header when a secret is set.
+type WebhookDispatcher struct {
+	URLs       []string
+	Secret     string
+	Client     *http.Client
+	MaxRetries int
+}
+
+// NewWebhookDispatcher builds a dispatcher for the configured endpoints. It
+// returns nil when there are none.
+func NewWebhookDispatcher(cfg config.WebhookConfig) *WebhookDispatcher {
+	if len(cfg.URLs) == 0 {
+		return nil
+	}
+
+	return &WebhookDispatcher{
+		URLs:       cfg.URLs,
+		Secret:     cfg.Secret,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		MaxRetries: 3,
+	}
+}
+
+// Handle is a bus Handler that delivers the event to every configured URL.
+func (d *WebhookDispatcher) Handle(ctx context.Context, e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		logrus.WithError(err).WithField("event", e.Type).Error("Failed to encode webhook payload")
+		return
+	}
+
+	for _, url := range d.URLs {
+		if result := Deliver(ctx, d.Client, url, d.Secret, e.Type, e.ID, body, d.MaxRetries); result.Err != nil {
+			logrus.WithError(result.Err).WithFields(logrus.Fields{"event": e.Type, "url": url}).Warn("Webhook delivery failed")
+		}
+	}
+}
+
+// DeliveryResult is how a webhook delivery went.
+type DeliveryResult struct {
+	Attempts   int
+	StatusCode int // The endpoint's last answer, 0 if it never answered
+	Err        error
+}
+
+// Deliver POSTs body, an encoded event, to url, signed with secret when one
+// is set. Network errors, server errors and rate limiting are retried up to
+// maxRetries times with growing pauses; other client errors are not.
+func Deliver(ctx context.Context, client *http.Client, url, secret, eventType, deliveryID string, body []byte, maxRetries int) DeliveryResult {
+	var result DeliveryResult
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return result
+			case <-time.After(time.Duration(attempt*attempt) * time.Second):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Vendora-Event", eventType)
+		req.Header.Set("X-Vendora-Delivery", deliveryID)
+		if secret != "" {
+			req.Header.Set("X-Vendora-Signature", "sha256="+Sign(secret, body))
+		}
+
+		result.Attempts++
+		resp, err := client.Do(req)
+		if err != nil {
+			result.Err = err
+			continue
+		}
+		resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			result.Err = nil
+			return result
+		}
+		result.Err = fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+		// Client errors other than rate limiting will not succeed on retry
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return result
+		}
+	}
+	return result
+}
+
+// Sign returns the hex HMAC-SHA256 of body, for receivers to verify payloads.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
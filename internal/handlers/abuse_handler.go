@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// blockRefreshInterval is how soon a block made on one instance reaches the
+// others.
+const blockRefreshInterval = time.Minute
+
+type AbuseHandler struct {
+	Repo      repository.ClientBlockRepository
+	AuditRepo repository.AuditRepository
+	Limiter   *middleware.RateLimiter
+}
+
+func NewAbuseHandler(db *mongo.Database, limiter *middleware.RateLimiter) *AbuseHandler {
+	return &AbuseHandler{
+		Repo:      repository.NewClientBlockRepository(db),
+		AuditRepo: repository.NewAuditRepository(db),
+		Limiter:   limiter,
+	}
+}
+
+// StartBlockRefresh loads the blocks in force into the rate limiter now and
+// then every blockRefreshInterval until ctx is done.
+func (h *AbuseHandler) StartBlockRefresh(ctx context.Context) {
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		blocks, err := h.Repo.ListActive(ctx)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to load client blocks")
+			return
+		}
+		h.Limiter.SetBlocks(blocks)
+	}
+
+	go func() {
+		refresh()
+		ticker := time.NewTicker(blockRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// GetAbuse shows the rate limits in force, the clients this instance has
+// refused most, and the blocked clients.
+func (h *AbuseHandler) GetAbuse(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	blocks, err := h.Repo.ListActive(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch blocked clients"))
+		return
+	}
+
+	policies := make([]gin.H, 0, len(middleware.RateLimitPolicies))
+	for _, p := range middleware.RateLimitPolicies {
+		p = h.Limiter.Policy(p)
+		policies = append(policies, gin.H{"name": p.Name, "limit": p.Limit, "window": p.Window.String()})
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Abuse overview fetched", gin.H{
+		"policies":  policies,
+		"offenders": h.Limiter.Offenders(50),
+		"blocks":    blocks,
+	}))
+}
+
+// BlockClient shuts an IP address or user out of the API, for a number of
+// hours or until unblocked.
+func (h *AbuseHandler) BlockClient(c *gin.Context) {
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	var input models.BlockClientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	key := input.ClientKey()
+	if key == models.ClientKeyUser+adminID.Hex() || key == models.ClientKeyIP+c.ClientIP() {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You can't block yourself"))
+		return
+	}
+
+	block := models.ClientBlock{Key: key, Reason: input.Reason, BlockedBy: adminID}
+	if input.Hours > 0 {
+		expires := time.Now().Add(time.Duration(input.Hours) * time.Hour)
+		block.ExpiresAt = &expires
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Repo.Block(ctx, &block); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to block client"))
+		return
+	}
+	h.Limiter.Block(block)
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditClientBlocked,
+		TargetType: models.AuditTargetClientBlock,
+		TargetID:   block.ID,
+		After:      gin.H{"key": block.Key, "expiresAt": block.ExpiresAt},
+		Reason:     block.Reason,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Client blocked", gin.H{"block": block}))
+}
+
+// UnblockClient lifts a block. The key is as listed, e.g. ip:203.0.113.7 or
+// user:<id>.
+func (h *AbuseHandler) UnblockClient(c *gin.Context) {
+	key := c.Param("key")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	block, err := h.Repo.Unblock(ctx, key)
+	if errors.Is(err, repository.ErrClientBlockNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Client is not blocked"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to unblock client"))
+		return
+	}
+	h.Limiter.Unblock(key)
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditClientUnblocked,
+		TargetType: models.AuditTargetClientBlock,
+		TargetID:   block.ID,
+		Before:     gin.H{"key": block.Key, "reason": block.Reason},
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Client unblocked", nil))
+}
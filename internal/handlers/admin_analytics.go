@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// StartPlatformAnalytics brings the last models.AnalyticsRestateDays of
+// platform analytics up to date now and then every night at
+// models.NextAnalyticsRun until ctx is done. Days are replaced wholesale, so
+// overlapping runs from several instances are harmless.
+func StartPlatformAnalytics(ctx context.Context, repo repository.AnalyticsRepository) {
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+		defer cancel()
+		today := models.BucketStart(time.Now(), models.GranularityDay)
+		from := today.AddDate(0, 0, -models.AnalyticsRestateDays)
+		if n, err := rebuildPlatformDays(ctx, repo, from, today); err != nil {
+			logrus.WithError(err).WithField("rebuilt", n).Error("Failed to rebuild platform analytics")
+		}
+	}
+
+	go func() {
+		refresh()
+		for {
+			next := models.NextAnalyticsRun(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+				refresh()
+			}
+		}
+	}()
+}
+
+// rebuildPlatformDays recomputes every day starting in [from, to) and
+// returns how many it did.
+func rebuildPlatformDays(ctx context.Context, repo repository.AnalyticsRepository, from, to time.Time) (int, error) {
+	days := models.SalesBuckets(from, to, models.GranularityDay)
+	for i, day := range days {
+		if _, err := repo.RebuildPlatformDay(ctx, day); err != nil {
+			return i, fmt.Errorf("rebuild %s: %w", day.Format("2006-01-02"), err)
+		}
+	}
+	return len(days), nil
+}
+
+// GetPlatformAnalytics returns GMV, platform revenue and take rate, refund
+// rate and vendor growth over a range (?window= or ?from=/?to=, as for the
+// dashboard), in total and per ?granularity=day|week|month. It reads the
+// nightly precomputed days, so today is not included until tomorrow.
+func (h *AdminHandler) GetPlatformAnalytics(c *gin.Context) {
+	from, to, granularity, days, ok := h.analyticsDays(c)
+	if !ok {
+		return
+	}
+	analytics := models.RollUpPlatformDays(days, from, to, granularity)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Platform analytics fetched", gin.H{"analytics": analytics}))
+}
+
+// GetCategoryAnalytics returns the best-performing categories by paid
+// revenue over a range, in total and per bucket, as GetPlatformAnalytics.
+// ?limit= caps the categories listed, default 10.
+func (h *AdminHandler) GetCategoryAnalytics(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	from, to, granularity, days, ok := h.analyticsDays(c)
+	if !ok {
+		return
+	}
+	analytics := models.RollUpCategoryDays(days, from, to, granularity, limit)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Category analytics fetched", gin.H{"analytics": analytics}))
+}
+
+func (h *AdminHandler) analyticsDays(c *gin.Context) (time.Time, time.Time, string, []models.PlatformDay, bool) {
+	from, to, granularity, err := ParseStatsRange(c.Query("window"), c.Query("from"), c.Query("to"), c.Query("granularity"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return from, to, granularity, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	days, err := h.AnalyticsRepo.ListPlatformDays(ctx, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch analytics"))
+		return from, to, granularity, nil, false
+	}
+	return from, to, granularity, days, true
+}
+
+// RebuildPlatformAnalytics recomputes the precomputed days in ?from= to
+// ?to= (YYYY-MM-DD, to exclusive) straight away, e.g. to backfill history
+// or pick up a correction older than the nightly run restates.
+func (h *AdminHandler) RebuildPlatformAnalytics(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid from date; use YYYY-MM-DD"))
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid to date; use YYYY-MM-DD"))
+		return
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("'from' must be before 'to'"))
+		return
+	}
+	if n := len(models.SalesBuckets(from, to, models.GranularityDay)); n > maxSalesBuckets {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("Range spans %d days; rebuild at most %d at a time", n, maxSalesBuckets)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	n, err := rebuildPlatformDays(ctx, h.AnalyticsRepo, from, to)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to rebuild platform analytics")
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to rebuild analytics"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Analytics rebuilt", gin.H{"days": n}))
+}
+
+// Cohort reports scan every user in range and their orders, so results are
+// kept for a while; ?refresh=true recomputes.
+var cohortCache = utils.NewTTLCache[[]models.Cohort](15 * time.Minute)
+
+const (
+	defaultCohortMonths = 12
+	maxCohortMonths     = 36
+)
+
+// ParseCohortRange resolves the signup months of a cohort report from
+// from/to months (YYYY-MM, both inclusive). Without them it covers the
+// defaultCohortMonths up to and including now's. It returns the start of
+// the first month and the end of the last.
+func ParseCohortRange(fromStr, toStr string, now time.Time) (time.Time, time.Time, error) {
+	to := models.BucketStart(now, models.GranularityMonth)
+	if toStr != "" {
+		t, err := time.Parse("2006-01", toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'to' month; use YYYY-MM")
+		}
+		to = t
+	}
+	from := to.AddDate(0, 1-defaultCohortMonths, 0)
+	if fromStr != "" {
+		t, err := time.Parse("2006-01", fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'from' month; use YYYY-MM")
+		}
+		from = t
+	}
+	to = to.AddDate(0, 1, 0)
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("'from' must not be after 'to'")
+	}
+	if n := len(models.SalesBuckets(from, to, models.GranularityMonth)); n > maxCohortMonths {
+		return time.Time{}, time.Time{}, fmt.Errorf("range spans %d months; report at most %d cohorts at a time", n, maxCohortMonths)
+	}
+	return from, to, nil
+}
+
+// GetCohorts reports monthly signup cohorts: how many of each month's new
+// users went on to buy, to buy again, and to buy in each month since.
+// ?segment=interests splits each cohort by whether users picked interests,
+// ?segment=onboarding by whether they finished onboarding, to see whether
+// personalization improves retention.
+func (h *AdminHandler) GetCohorts(c *gin.Context) {
+	from, to, err := ParseCohortRange(c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+	segment := c.Query("segment")
+	if segment != "" && segment != models.CohortSegmentInterests && segment != models.CohortSegmentOnboarding {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("segment must be interests or onboarding"))
+		return
+	}
+
+	cacheKey := from.Format("2006-01") + "|" + to.Format("2006-01") + "|" + segment
+	if cohorts, ok := cohortCache.Get(cacheKey); ok && c.Query("refresh") != "true" {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Cohorts fetched", gin.H{"cohorts": cohorts, "segment": segment, "cached": true}))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	counts, activity, err := h.AnalyticsRepo.ListCohorts(ctx, from, to, segment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build cohorts"))
+		return
+	}
+	cohorts := models.BuildCohorts(counts, activity, time.Now())
+	cohortCache.Set(cacheKey, cohorts)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Cohorts fetched", gin.H{"cohorts": cohorts, "segment": segment, "cached": false}))
+}
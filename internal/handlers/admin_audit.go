@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordAudit fills in the actor and IP from the request and writes the
+// entry. Failures are logged rather than surfaced; the mutation itself has
+// already happened.
+func recordAudit(repo repository.AuditRepository, c *gin.Context, entry models.AuditLog) {
+	userIdStr, _ := c.Get("userId")
+	role, _ := c.Get("role")
+	if idStr, ok := userIdStr.(string); ok {
+		entry.ActorID, _ = primitive.ObjectIDFromHex(idStr)
+	}
+	entry.ActorRole, _ = role.(string)
+	entry.IP = c.ClientIP()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := repo.Record(ctx, entry); err != nil {
+		logrus.WithError(err).WithField("action", entry.Action).Error("Failed to record audit log")
+	}
+}
+
+func (h *AdminHandler) recordAudit(c *gin.Context, entry models.AuditLog) {
+	recordAudit(h.AuditRepo, c, entry)
+}
+
+// ListAuditLogs returns audit entries, newest first, filtered by actor,
+// action, target and date range.
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	page, limit := pageParams(c, 50, 200)
+
+	filter := bson.M{}
+	for param, field := range map[string]string{"actorId": "actorID", "targetId": "targetID"} {
+		if v := c.Query(param); v != "" {
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid "+param))
+				return
+			}
+			filter[field] = id
+		}
+	}
+	if action := c.Query("action"); action != "" {
+		filter["action"] = action
+	}
+	if targetType := c.Query("targetType"); targetType != "" {
+		filter["targetType"] = targetType
+	}
+
+	createdAt := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lte"} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				if t, err = time.Parse("2006-01-02", v); err != nil {
+					c.JSON(http.StatusBadRequest, utils.ErrorResponse("Dates must be RFC3339 or YYYY-MM-DD"))
+					return
+				}
+				if param == "to" {
+					t = t.Add(24*time.Hour - time.Nanosecond)
+				}
+			}
+			createdAt[op] = t
+		}
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	entries, total, err := h.AuditRepo.List(ctx, filter, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch audit logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Audit logs fetched", gin.H{
+		"logs": entries,
+		"meta": models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetAuditLog returns a single audit entry.
+func (h *AdminHandler) GetAuditLog(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid audit log ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	entry, err := h.AuditRepo.Get(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch audit log"))
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Audit log not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Audit log fetched", gin.H{"log": entry}))
+}
+
+// ListEmailLogs returns recent email send attempts, newest first, for
+// debugging delivery. Filter by recipient, template or status.
+func (h *AdminHandler) ListEmailLogs(c *gin.Context) {
+	page, limit := pageParams(c, 50, 200)
+
+	filter := bson.M{}
+	for _, field := range []string{"to", "template", "status", "provider"} {
+		if v := c.Query(field); v != "" {
+			filter[field] = v
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	entries, total, err := h.EmailLogRepo.List(ctx, filter, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch email logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Email logs fetched", gin.H{
+		"logs": entries,
+		"meta": models.NewPageMeta(total, page, limit),
+	}))
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"html"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
@@ -17,17 +20,125 @@ import (
 )
 
 type AdminHandler struct {
-	DB       *mongo.Database
-	TierRepo repository.TierRepository
+	DB             *mongo.Database
+	TierRepo       repository.TierRepository
+	DashboardRepo  repository.DashboardRepository
+	UserRepo       repository.UserRepository
+	AuditRepo      repository.AuditRepository
+	ModerationRepo repository.ModerationRepository
+	OrderRepo      repository.OrderRepository
+	TxRepo         repository.TransactionRepository
+	ReportRepo     repository.ReportRepository
+	EmailLogRepo   repository.EmailLogRepository
+	SearchRepo     repository.SearchRepository
+	AnalyticsRepo  repository.AnalyticsRepository
 }
 
 func NewAdminHandler(db *mongo.Database) *AdminHandler {
 	return &AdminHandler{
-		DB:       db,
-		TierRepo: repository.NewTierRepository(db),
+		DB:             db,
+		TierRepo:       repository.NewTierRepository(db),
+		DashboardRepo:  repository.NewDashboardRepository(db),
+		UserRepo:       repository.NewUserRepository(db),
+		AuditRepo:      repository.NewAuditRepository(db),
+		ModerationRepo: repository.NewModerationRepository(db),
+		OrderRepo:      repository.NewOrderRepository(db),
+		TxRepo:         repository.NewTransactionRepository(db),
+		ReportRepo:     repository.NewReportRepository(db),
+		EmailLogRepo:   repository.NewEmailLogRepository(db),
+		SearchRepo:     repository.NewSearchRepository(db),
+		AnalyticsRepo:  repository.NewAnalyticsRepository(db),
 	}
 }
 
+// dashboardCache keeps recent dashboard results per window; the underlying
+// pipelines scan every order in the window.
+var dashboardCache = utils.NewTTLCache[models.AdminDashboard](5 * time.Minute)
+
+// dashboardWindows are the preset lookback periods for the admin dashboard.
+var dashboardWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+	"1y":  365 * 24 * time.Hour,
+}
+
+// ParseDashboardWindow resolves the dashboard's time range from either a
+// preset window name or explicit from/to dates (RFC3339 or YYYY-MM-DD). An
+// empty window with no dates defaults to the last 30 days.
+func ParseDashboardWindow(window, fromStr, toStr string, now time.Time) (string, time.Time, time.Time, error) {
+	parse := func(v string) (time.Time, error) {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		return time.Parse("2006-01-02", v)
+	}
+
+	if fromStr != "" || toStr != "" {
+		to := now
+		if toStr != "" {
+			t, err := parse(toStr)
+			if err != nil {
+				return "", time.Time{}, time.Time{}, fmt.Errorf("invalid 'to' date")
+			}
+			to = t
+		}
+		if fromStr == "" {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("'from' is required with a custom range")
+		}
+		from, err := parse(fromStr)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("invalid 'from' date")
+		}
+		if !from.Before(to) {
+			return "", time.Time{}, time.Time{}, fmt.Errorf("'from' must be before 'to'")
+		}
+		return "custom", from, to, nil
+	}
+
+	if window == "" {
+		window = "30d"
+	}
+	d, ok := dashboardWindows[window]
+	if !ok {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("unknown window %q", window)
+	}
+	return window, now.Add(-d), now, nil
+}
+
+// GetDashboard returns GMV, order, growth, refund and category metrics over a
+// selectable time window. Results are cached for a few minutes.
+func (h *AdminHandler) GetDashboard(c *gin.Context) {
+	window, from, to, err := ParseDashboardWindow(c.Query("window"), c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	cacheKey := window
+	if window == "custom" {
+		cacheKey = from.Format(time.RFC3339) + "|" + to.Format(time.RFC3339)
+	}
+	if dash, ok := dashboardCache.Get(cacheKey); ok && c.Query("refresh") != "true" {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Dashboard fetched", gin.H{"dashboard": dash, "cached": true}))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	dash, err := h.DashboardRepo.GetAdminDashboard(ctx, from, to, 5)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build dashboard"))
+		return
+	}
+	dash.Window = window
+	dashboardCache.Set(cacheKey, dash)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Dashboard fetched", gin.H{"dashboard": dash, "cached": false}))
+}
+
 // GetPlatformStats returns a snapshot of key platform metrics.
 func (h *AdminHandler) GetPlatformStats(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -50,11 +161,11 @@ func (h *AdminHandler) GetPlatformStats(c *gin.Context) {
 	}
 	cursor, _ := ordersCol.Aggregate(ctx, pipeline)
 	var revenueResult []struct {
-		TotalRevenue float64 `bson:"totalRevenue"`
+		TotalRevenue models.Money `bson:"totalRevenue"`
 	}
 	cursor.All(ctx, &revenueResult)
 
-	totalRevenue := 0.0
+	var totalRevenue models.Money
 	if len(revenueResult) > 0 {
 		totalRevenue = revenueResult[0].TotalRevenue
 	}
@@ -323,6 +434,13 @@ func (h *AdminHandler) FlagProduct(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditProductFlagged,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		After:      gin.H{"status": "flagged"},
+	})
+
 	c.JSON(http.StatusOK, utils.SuccessResponse("Product successfully flagged", nil))
 }
 
@@ -345,6 +463,13 @@ func (h *AdminHandler) ApproveProduct(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditProductApproved,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   objID,
+		After:      gin.H{"status": "active"},
+	})
+
 	c.JSON(http.StatusOK, utils.SuccessResponse("Product successfully approved", nil))
 }
 
@@ -471,9 +596,14 @@ func (h *AdminHandler) GetOrder(c *gin.Context) {
 		"shippingAddress": order.ShippingAddress,
 		"trackingNumber": order.TrackingNumber,
 		"paymentId": order.PaymentID,
+		"refundedAmount": order.RefundedAmount,
+		"refunds": order.Refunds,
 		"createdAt": order.CreatedAt,
 		"updatedAt": order.UpdatedAt,
 	}
+	if history, err := h.OrderRepo.GetStatusHistory(ctx, objID); err == nil {
+		enrichedOrder["statusHistory"] = models.NewOrderStatusTimeline(history)
+	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Order details fetched", gin.H{"order": enrichedOrder}))
 }
@@ -534,38 +664,31 @@ func (h *AdminHandler) ApproveTierRequest(c *gin.Context) {
 	// 2. Determine new limits based on target tier
 	tierConfig := getTierConfig(req.RequestedTier)
 
-	// 3. Update vendor account tier
-	now := time.Now()
-	_, err = h.DB.Collection("vendorAccounts").UpdateOne(ctx,
-		bson.M{"userID": req.VendorID},
-		bson.M{"$set": bson.M{
-			"tier":            req.RequestedTier,
-			"maxProducts":     tierConfig.MaxProducts,
-			"maxMonthlySales": tierConfig.MaxMonthlySales,
-			"transactionFee":  tierConfig.TransactionFee,
-			"payoutHoldDays":  tierConfig.PayoutHoldDays,
-			"tierUpgradedAt":  now,
-			"updatedAt":       now,
-		}},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update vendor tier"))
+	// 3. Apply the new tier and mark the request approved in one transaction
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	approved := events.New(events.TierRequestApproved, map[string]interface{}{
+		"requestId": req.ID.Hex(),
+		"vendorId":  req.VendorID.Hex(),
+		"tier":      req.RequestedTier,
+	})
+	if err := h.TierRepo.ApproveUpgradeRequest(ctx, req, tierConfig, adminID, approved); err != nil {
+		if err == repository.ErrUpgradeNotPending {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Request is no longer pending"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to upgrade vendor tier"))
 		return
 	}
 
-	// 4. Mark request as approved
-	_, err = h.DB.Collection("tierUpgradeRequests").UpdateOne(ctx,
-		bson.M{"_id": reqID},
-		bson.M{"$set": bson.M{
-			"status":     models.UpgradeStatusApproved,
-			"reviewedAt": now,
-			"updatedAt":  now,
-		}},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to mark request as approved"))
-		return
-	}
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditTierApproved,
+		TargetType: models.AuditTargetVendor,
+		TargetID:   req.VendorID,
+		Before:     gin.H{"tier": req.CurrentTier, "requestId": req.ID},
+		After:      gin.H{"tier": req.RequestedTier},
+	})
 
 	c.JSON(http.StatusOK, utils.SuccessResponse(fmt.Sprintf("Vendor upgraded to %s tier successfully", req.RequestedTier), gin.H{
 		"newTier":    req.RequestedTier,
@@ -642,6 +765,23 @@ func (h *AdminHandler) RejectTierRequest(c *gin.Context) {
 
 	h.DB.Collection("vendorAccounts").UpdateOne(ctx, bson.M{"userID": req.VendorID}, vendorUpdate)
 
+	events.Publish(events.TierRequestRejected, map[string]interface{}{
+		"requestId": req.ID.Hex(),
+		"vendorId":  req.VendorID.Hex(),
+		"tier":      req.RequestedTier,
+		"reason":    input.Reason,
+		"suspended": isSuspended,
+	})
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditTierRejected,
+		TargetType: models.AuditTargetVendor,
+		TargetID:   req.VendorID,
+		Before:     gin.H{"verificationRetries": vendorAcc.VerificationRetries, "requestId": req.ID},
+		After:      gin.H{"verificationRetries": newRetries, "suspended": isSuspended},
+		Reason:     input.Reason,
+	})
+
 	c.JSON(http.StatusOK, utils.SuccessResponse("Upgrade request rejected", gin.H{
 		"retriesRemaining": 3 - newRetries,
 		"isSuspended":      isSuspended,
@@ -649,21 +789,14 @@ func (h *AdminHandler) RejectTierRequest(c *gin.Context) {
 }
 
 // getTierConfig determines the account limits for a given tier name.
-type tierLimits struct {
-	MaxProducts     int
-	MaxMonthlySales float64
-	TransactionFee  float64
-	PayoutHoldDays  int
-}
-
-func getTierConfig(tier string) tierLimits {
+func getTierConfig(tier string) models.TierLimits {
 	switch tier {
 	case "verified":
-		return tierLimits{MaxProducts: 200, MaxMonthlySales: 25000, TransactionFee: 3.5, PayoutHoldDays: 5}
+		return models.TierLimits{MaxProducts: 200, MaxMonthlySales: 25000, TransactionFee: 3.5, PayoutHoldDays: 5}
 	case "business":
-		return tierLimits{MaxProducts: 10000, MaxMonthlySales: 999999, TransactionFee: 2.0, PayoutHoldDays: 1}
+		return models.TierLimits{MaxProducts: 10000, MaxMonthlySales: 999999, TransactionFee: 2.0, PayoutHoldDays: 1}
 	default: // individual
-		return tierLimits{MaxProducts: 50, MaxMonthlySales: 5000, TransactionFee: 5.0, PayoutHoldDays: 7}
+		return models.TierLimits{MaxProducts: 50, MaxMonthlySales: 5000, TransactionFee: 5.0, PayoutHoldDays: 7}
 	}
 }
 
@@ -677,6 +810,11 @@ func (h *AdminHandler) UnsuspendVendor(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	var before struct {
+		Status string `bson:"status"`
+	}
+	_ = h.DB.Collection("vendorAccounts").FindOne(ctx, bson.M{"userID": vendorID}).Decode(&before)
+
 	update := bson.M{
 		"$set": bson.M{
 			"status":              "active",
@@ -693,6 +831,15 @@ func (h *AdminHandler) UnsuspendVendor(c *gin.Context) {
 		c.JSON(http.StatusNotFound, utils.ErrorResponse("Vendor not found or could not update"))
 		return
 	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditVendorUnsuspended,
+		TargetType: models.AuditTargetVendor,
+		TargetID:   vendorID,
+		Before:     gin.H{"status": before.Status},
+		After:      gin.H{"status": "active"},
+	})
+
 	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor account unsuspended successfully", nil))
 }
 
@@ -706,6 +853,11 @@ func (h *AdminHandler) BanVendor(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	var before struct {
+		Status string `bson:"status"`
+	}
+	_ = h.DB.Collection("vendorAccounts").FindOne(ctx, bson.M{"userID": vendorID}).Decode(&before)
+
 	update := bson.M{
 		"$set": bson.M{
 			"status":       "banned",
@@ -719,6 +871,14 @@ func (h *AdminHandler) BanVendor(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditVendorBanned,
+		TargetType: models.AuditTargetVendor,
+		TargetID:   vendorID,
+		Before:     gin.H{"status": before.Status},
+		After:      gin.H{"status": "banned"},
+	})
+
 	// Fetch user to send notification
 	var user models.User
 	err = h.DB.Collection("users").FindOne(ctx, bson.M{"_id": vendorID}).Decode(&user)
@@ -730,3 +890,152 @@ func (h *AdminHandler) BanVendor(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor account banned successfully", nil))
 }
+
+// UpdateVendor lets an admin adjust a vendor's tier, limits and status by
+// hand. Changing the tier applies that tier's default limits unless they are
+// overridden in the same request. Every change needs a reason code.
+func (h *AdminHandler) UpdateVendor(c *gin.Context) {
+	vendorID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid vendor ID"))
+		return
+	}
+
+	var input struct {
+		Tier            *string  `json:"tier" binding:"omitempty,oneof=individual verified business"`
+		MaxProducts     *int     `json:"maxProducts" binding:"omitempty,min=0"`
+		MaxMonthlySales *float64 `json:"maxMonthlySales" binding:"omitempty,min=0"`
+		TransactionFee  *float64 `json:"transactionFee" binding:"omitempty,min=0,max=100"`
+		PayoutHoldDays  *int     `json:"payoutHoldDays" binding:"omitempty,min=0,max=90"`
+		Status          *string  `json:"status" binding:"omitempty,oneof=active suspended"`
+		SuspendDays     int      `json:"suspendDays" binding:"min=0,max=365"`
+		ReasonCode      string   `json:"reasonCode" binding:"required"`
+		Note            string   `json:"note" binding:"max=1000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	validReason := false
+	for _, r := range models.VendorAdjustmentReasons {
+		if input.ReasonCode == r {
+			validReason = true
+			break
+		}
+	}
+	if !validReason {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("reasonCode must be one of: "+strings.Join(models.VendorAdjustmentReasons, ", ")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. Load the current account
+	account, err := h.TierRepo.GetVendorAccount(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor account"))
+		return
+	}
+	if account == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Vendor not found"))
+		return
+	}
+	if account.Status == "banned" {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Banned vendors cannot be adjusted"))
+		return
+	}
+
+	// 2. Build the update, starting from tier defaults when the tier changes
+	set := bson.M{}
+	unset := bson.M{}
+	if input.Tier != nil && *input.Tier != account.Tier {
+		limits := getTierConfig(*input.Tier)
+		set["tier"] = *input.Tier
+		set["maxProducts"] = limits.MaxProducts
+		set["maxMonthlySales"] = limits.MaxMonthlySales
+		set["transactionFee"] = limits.TransactionFee
+		set["payoutHoldDays"] = limits.PayoutHoldDays
+		set["tierUpgradedAt"] = time.Now()
+	}
+	if input.MaxProducts != nil {
+		set["maxProducts"] = *input.MaxProducts
+	}
+	if input.MaxMonthlySales != nil {
+		set["maxMonthlySales"] = *input.MaxMonthlySales
+	}
+	if input.TransactionFee != nil {
+		set["transactionFee"] = *input.TransactionFee
+	}
+	if input.PayoutHoldDays != nil {
+		set["payoutHoldDays"] = *input.PayoutHoldDays
+	}
+	statusChanged := input.Status != nil && *input.Status != account.Status
+	if statusChanged {
+		set["status"] = *input.Status
+		if *input.Status == "suspended" {
+			if input.SuspendDays > 0 {
+				set["suspendedUntil"] = time.Now().AddDate(0, 0, input.SuspendDays)
+			} else {
+				unset["suspendedUntil"] = ""
+			}
+		} else {
+			unset["suspendedUntil"] = ""
+		}
+	}
+	if len(set) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No changes provided"))
+		return
+	}
+
+	// 3. Apply it
+	updated, err := h.TierRepo.AdjustVendorAccount(ctx, vendorID, set, unset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update vendor"))
+		return
+	}
+	if updated == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Vendor not found"))
+		return
+	}
+
+	reason := input.ReasonCode
+	if note := strings.TrimSpace(input.Note); note != "" {
+		reason += ": " + note
+	}
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditVendorAdjusted,
+		TargetType: models.AuditTargetVendor,
+		TargetID:   vendorID,
+		Before: gin.H{
+			"tier":            account.Tier,
+			"maxProducts":     account.MaxProducts,
+			"maxMonthlySales": account.MaxMonthlySales,
+			"transactionFee":  account.TransactionFee,
+			"payoutHoldDays":  account.PayoutHoldDays,
+			"status":          account.Status,
+		},
+		After:  set,
+		Reason: reason,
+	})
+
+	// 4. Tell the vendor when their account is suspended or reinstated
+	if statusChanged {
+		var user models.User
+		if err := h.DB.Collection("users").FindOne(ctx, bson.M{"_id": vendorID}).Decode(&user); err == nil && user.Email != "" {
+			subject := "Your Vendora vendor account has been reinstated"
+			body := "<p>Hello " + html.EscapeString(user.Name) + ",</p><p>Your vendor account is active again. You can resume selling right away.</p>"
+			if *input.Status == "suspended" {
+				subject = "Your Vendora vendor account has been suspended"
+				body = "<p>Hello " + html.EscapeString(user.Name) + ",</p><p>Your vendor account has been suspended"
+				if input.SuspendDays > 0 {
+					body += fmt.Sprintf(" for %d days", input.SuspendDays)
+				}
+				body += ". Please contact support if you have questions.</p>"
+			}
+			go utils.SendEmail(user.Email, subject, body)
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor updated successfully", gin.H{"account": updated}))
+}
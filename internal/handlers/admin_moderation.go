@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListModerationQueue returns moderation cases, open ones by default, with
+// the most-reported listings first. Use ?appeal=pending for the appeals queue.
+func (h *AdminHandler) ListModerationQueue(c *gin.Context) {
+	page, limit := pageParams(c, 20, 100)
+
+	filter := bson.M{}
+	if c.Query("appeal") == "pending" {
+		filter["appeal.status"] = "pending"
+	} else if status := c.DefaultQuery("status", models.CaseStatusOpen); status != "all" {
+		filter["status"] = status
+	}
+	if source := c.Query("source"); source != "" {
+		filter["flags.source"] = source
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cases, total, err := h.ModerationRepo.ListCases(ctx, filter, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch moderation queue"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Moderation queue fetched", gin.H{
+		"cases": cases,
+		"meta":  models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetModerationCase returns a case together with the current product.
+func (h *AdminHandler) GetModerationCase(c *gin.Context) {
+	caseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid case ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	modCase, err := h.ModerationRepo.GetCase(ctx, caseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch case"))
+		return
+	}
+	if modCase == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Case not found"))
+		return
+	}
+
+	var product models.Product
+	_ = h.DB.Collection("products").FindOne(ctx, bson.M{"_id": modCase.ProductID}).Decode(&product)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Case fetched", gin.H{"case": modCase, "product": product}))
+}
+
+// ActOnModerationCase applies a moderator's decision to an open case:
+// unpublish, request_edits, delete or dismiss.
+func (h *AdminHandler) ActOnModerationCase(c *gin.Context) {
+	caseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid case ID"))
+		return
+	}
+
+	var input struct {
+		Action string `json:"action" binding:"required,oneof=unpublish request_edits delete dismiss"`
+		Note   string `json:"note" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Action must be one of unpublish, request_edits, delete or dismiss"))
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if input.Action != models.ModerationActionDismiss && input.Note == "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A note for the vendor is required"))
+		return
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	modCase, err := h.ModerationRepo.GetCase(ctx, caseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch case"))
+		return
+	}
+	if modCase == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Case not found"))
+		return
+	}
+
+	updated, err := h.ModerationRepo.ApplyAction(ctx, *modCase, input.Action, input.Note, adminID)
+	if err == repository.ErrCaseNotOpen {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This case has already been decided"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to apply moderation action"))
+		return
+	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     "product.moderation." + input.Action,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   modCase.ProductID,
+		Before:     gin.H{"status": updated.PreviousProductStatus},
+		After:      gin.H{"caseId": updated.ID, "action": input.Action},
+		Reason:     input.Note,
+	})
+
+	// Let the vendor know, except for dismissals which change nothing for them
+	if input.Action != models.ModerationActionDismiss {
+		subjects := map[string]string{
+			models.ModerationActionUnpublish:    "Your listing has been unpublished",
+			models.ModerationActionRequestEdits: "Changes requested on your listing",
+			models.ModerationActionDelete:       "Your listing has been removed",
+		}
+		message := "Our moderation team reviewed your listing <strong>" + html.EscapeString(modCase.ProductName) + "</strong>. Note from the moderator: " + html.EscapeString(input.Note)
+		go notifyVendorModeration(h.DB, modCase.VendorID, subjects[input.Action], message)
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Moderation action applied", gin.H{"case": updated}))
+}
+
+// ResolveModerationAppeal approves or rejects a vendor's appeal. Approving
+// reinstates the listing.
+func (h *AdminHandler) ResolveModerationAppeal(c *gin.Context) {
+	caseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid case ID"))
+		return
+	}
+
+	var input struct {
+		Decision string `json:"decision" binding:"required,oneof=approve reject"`
+		Note     string `json:"note" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Decision must be approve or reject"))
+		return
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	modCase, err := h.ModerationRepo.GetCase(ctx, caseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch case"))
+		return
+	}
+	if modCase == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Case not found"))
+		return
+	}
+
+	approve := input.Decision == "approve"
+	updated, err := h.ModerationRepo.ResolveAppeal(ctx, *modCase, approve, strings.TrimSpace(input.Note), adminID)
+	if err == repository.ErrAppealNotPending {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("There is no pending appeal on this case"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to resolve appeal"))
+		return
+	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     "product.moderation.appeal_" + input.Decision,
+		TargetType: models.AuditTargetProduct,
+		TargetID:   modCase.ProductID,
+		After:      gin.H{"caseId": updated.ID, "appealStatus": updated.Appeal.Status},
+		Reason:     input.Note,
+	})
+
+	subject := "Your appeal was not successful"
+	message := "We reviewed your appeal for <strong>" + html.EscapeString(modCase.ProductName) + "</strong> and the original decision stands."
+	if approve {
+		subject = "Your appeal was approved"
+		message = "We reviewed your appeal for <strong>" + html.EscapeString(modCase.ProductName) + "</strong> and have reinstated the listing."
+	}
+	if note := strings.TrimSpace(input.Note); note != "" {
+		message += " Note from the moderator: " + html.EscapeString(note)
+	}
+	go notifyVendorModeration(h.DB, modCase.VendorID, subject, message)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Appeal resolved", gin.H{"case": updated}))
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// isRefundable reports whether money was captured for the order and some of
+// it is still left to refund.
+func isRefundable(order models.Order) bool {
+	if order.PaymentID == "" {
+		return false
+	}
+	if order.PaymentStatus != "paid" && order.PaymentStatus != "partially_refunded" {
+		return false
+	}
+	return order.RefundableAmount() > 0
+}
+
+// refundOrder refunds amount on the order through Stripe and claws the
+// vendors' shares back from their balances.
+func (h *AdminHandler) refundOrder(ctx context.Context, c *gin.Context, order models.Order, amount models.Money, reason string) (models.Order, error) {
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	return refundPayment(ctx, h.OrderRepo, h.TxRepo, order, amount, reason, models.ActingUser(models.OrderActorAdmin, adminID), models.VendorRefundShares(order, amount))
+}
+
+// orderActor is the signed-in admin changing an order's status for reason.
+func (h *AdminHandler) orderActor(c *gin.Context, reason string) models.OrderStatusActor {
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+	actor := models.ActingUser(models.OrderActorAdmin, adminID)
+	actor.Reason = reason
+	return actor
+}
+
+// refundPayment refunds amount on the order through Stripe and claws shares
+// back from the vendors they are keyed by. The refund is reserved on the
+// order first and released again if Stripe rejects it. by is who issued it,
+// and is recorded as refunding the order if it refunds all of it.
+func refundPayment(ctx context.Context, orderRepo repository.OrderRepository, txRepo repository.TransactionRepository, order models.Order, amount models.Money, reason string, by models.OrderStatusActor, shares map[primitive.ObjectID]models.Money) (models.Order, error) {
+	full := amount >= order.RefundableAmount()
+	refund := models.OrderRefund{
+		ID:        primitive.NewObjectID(),
+		Amount:    amount,
+		Status:    models.RefundStatusPending,
+		Reason:    reason,
+		IssuedBy:  *by.UserID,
+		CreatedAt: time.Now(),
+	}
+
+	// 1. Reserve the refund on the order
+	if err := orderRepo.ReserveRefund(ctx, order.ID, refund); err != nil {
+		return models.Order{}, err
+	}
+
+	// 2. Move the money
+	stripeRefundID, err := issueStripeRefund(ctx, order.PaymentID, amount, full, refund.ID)
+	if err != nil {
+		if relErr := orderRepo.ReleaseRefund(context.Background(), order.ID, refund); relErr != nil {
+			logrus.WithError(relErr).WithField("orderId", order.ID.Hex()).Error("Failed to release refund reservation")
+		}
+		return models.Order{}, fmt.Errorf("stripe refund failed: %v", err)
+	}
+
+	// 3. Record it, with the event announcing it; from here on the buyer has
+	// been paid, so failures are logged rather than returned, and the event
+	// is published straight away instead
+	refundEvent := order.EventData()
+	refundEvent["amount"] = amount.Float64()
+	refundEvent["full"] = full
+	refunded := events.New(events.OrderRefunded, refundEvent)
+	by.Reason = reason
+	by.Metadata = map[string]string{"refundId": refund.ID.Hex(), "stripeRefundId": stripeRefundID}
+	updated, err := orderRepo.CompleteRefund(ctx, order.ID, refund.ID, stripeRefundID, by, refunded)
+	if err != nil {
+		logrus.WithError(err).WithField("orderId", order.ID.Hex()).Error("Failed to mark refund as completed")
+		events.Publish(refunded.Type, refunded.Data)
+		updated = order
+	}
+
+	// 4. Claw back the vendors' shares
+	for vendorID, share := range shares {
+		if _, err := txRepo.ClawbackVendorForRefund(ctx, vendorID, share, order.ID, order.OrderNumber); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"orderId":  order.ID.Hex(),
+				"vendorId": vendorID.Hex(),
+			}).Error("Failed to claw back vendor share of refund")
+		}
+	}
+	return updated, nil
+}
+
+// RefundOrder issues a full or partial refund on any paid order. Leaving the
+// amount out refunds everything that is left.
+func (h *AdminHandler) RefundOrder(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID format"))
+		return
+	}
+
+	var input struct {
+		Amount models.Money `json:"amount" binding:"min=0"`
+		Reason string       `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A reason for the refund is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	order, err := h.OrderRepo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	if !isRefundable(order) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("This order has no captured payment left to refund"))
+		return
+	}
+
+	amount := input.Amount
+	if amount == 0 {
+		amount = order.RefundableAmount()
+	}
+	if amount > order.RefundableAmount() {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("Refund cannot exceed the remaining $%s", order.RefundableAmount())))
+		return
+	}
+
+	updated, err := h.refundOrder(ctx, c, order, amount, strings.TrimSpace(input.Reason))
+	if err == repository.ErrRefundExceedsTotal {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Another refund was issued on this order; please reload and try again"))
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("orderId", orderID.Hex()).Error("Admin refund failed")
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to issue refund"))
+		return
+	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditOrderRefunded,
+		TargetType: models.AuditTargetOrder,
+		TargetID:   orderID,
+		Before:     gin.H{"refundedAmount": order.RefundedAmount, "paymentStatus": order.PaymentStatus},
+		After:      gin.H{"refundedAmount": updated.RefundedAmount, "paymentStatus": updated.PaymentStatus, "amount": amount},
+		Reason:     input.Reason,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Refund issued", gin.H{"order": updated}))
+}
+
+// CancelOrder cancels an order that is stuck, typically because the vendor
+// stopped responding. Any captured payment left on it is refunded, and items
+// that never shipped go back into stock.
+func (h *AdminHandler) CancelOrder(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID format"))
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A reason for the cancellation is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	order, err := h.OrderRepo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	if order.Status == models.StatusCancelled || order.Status == models.StatusRefunded {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Order is already "+string(order.Status)))
+		return
+	}
+
+	// 1. Cancel, restocking anything that never left the vendor
+	restock := order.Status != models.StatusShipped && order.Status != models.StatusOutForDelivery && order.Status != models.StatusDelivered
+	actor := h.orderActor(c, input.Reason)
+	if err := h.OrderRepo.CancelOrder(ctx, order, restock, actor, orderStatusEvent(order, models.StatusCancelled, "admin")); err != nil {
+		if err == repository.ErrOrderStatusChanged {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Order status changed; please reload and try again"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to cancel order"))
+		return
+	}
+
+	// 2. Refund whatever was captured
+	var refunded models.Money
+	if isRefundable(order) {
+		amount := order.RefundableAmount()
+		if _, err := h.refundOrder(ctx, c, order, amount, input.Reason); err != nil {
+			logrus.WithError(err).WithField("orderId", orderID.Hex()).Error("Order cancelled but refund failed")
+			h.recordAudit(c, models.AuditLog{
+				Action:     models.AuditOrderCancelled,
+				TargetType: models.AuditTargetOrder,
+				TargetID:   orderID,
+				Before:     gin.H{"status": order.Status},
+				After:      gin.H{"status": models.StatusCancelled, "restocked": restock, "refundFailed": true},
+				Reason:     input.Reason,
+			})
+			c.JSON(http.StatusBadGateway, utils.ErrorResponse("Order cancelled but the refund failed; retry it from the refund endpoint"))
+			return
+		}
+		refunded = amount
+	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditOrderCancelled,
+		TargetType: models.AuditTargetOrder,
+		TargetID:   orderID,
+		Before:     gin.H{"status": order.Status},
+		After:      gin.H{"status": models.StatusCancelled, "restocked": restock, "refunded": refunded},
+		Reason:     input.Reason,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Order cancelled", gin.H{
+		"restocked": restock,
+		"refunded":  refunded,
+	}))
+}
+
+// adminCorrectableStatuses are the fulfilment statuses an admin may set by
+// hand. Payment, cancellation and refund states have their own flows.
+var adminCorrectableStatuses = map[models.OrderStatus]bool{
+	models.StatusConfirmed:      true,
+	models.StatusShipped:        true,
+	models.StatusOutForDelivery: true,
+	models.StatusDelivered:      true,
+}
+
+// CorrectOrderStatus lets an admin fix an order's fulfilment status when the
+// vendor can't or won't.
+func (h *AdminHandler) CorrectOrderStatus(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID format"))
+		return
+	}
+
+	var input struct {
+		Status         models.OrderStatus `json:"status" binding:"required"`
+		TrackingNumber string             `json:"trackingNumber"`
+		Reason         string             `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Status and reason are required"))
+		return
+	}
+	if !adminCorrectableStatuses[input.Status] {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Status must be one of confirmed, shipped or delivered"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.OrderRepo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	if order.PaymentStatus != "paid" && order.PaymentStatus != "partially_refunded" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Only paid orders can have their fulfilment status corrected"))
+		return
+	}
+
+	before := gin.H{"status": order.Status, "trackingNumber": order.TrackingNumber}
+	if input.TrackingNumber != "" {
+		order.TrackingNumber = input.TrackingNumber
+	}
+	actor := h.orderActor(c, input.Reason)
+	if input.TrackingNumber != "" {
+		actor.Metadata = map[string]string{"trackingNumber": input.TrackingNumber}
+	}
+	if err := h.OrderRepo.UpdateOrderStatus(ctx, orderID, input.Status, input.TrackingNumber, actor, orderStatusEvent(order, input.Status, "admin")); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update status"))
+		return
+	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditOrderStatusCorrected,
+		TargetType: models.AuditTargetOrder,
+		TargetID:   orderID,
+		Before:     before,
+		After:      gin.H{"status": input.Status, "trackingNumber": order.TrackingNumber},
+		Reason:     input.Reason,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Order status updated", gin.H{"status": input.Status}))
+}
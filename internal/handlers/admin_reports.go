@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListReports returns the report triage queue, active reports by default,
+// oldest first.
+func (h *AdminHandler) ListReports(c *gin.Context) {
+	page, limit := pageParams(c, 20, 100)
+
+	filter := bson.M{}
+	switch status := c.DefaultQuery("status", "active"); status {
+	case "active":
+		filter["active"] = true
+	case "all":
+	default:
+		filter["status"] = status
+	}
+	if targetType := c.Query("targetType"); targetType != "" {
+		filter["targetType"] = targetType
+	}
+	if category := c.Query("category"); category != "" {
+		filter["category"] = category
+	}
+	switch c.Query("source") {
+	case "automated":
+		filter["automated"] = true
+	case "user":
+		filter["automated"] = bson.M{"$ne": true}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	reports, total, err := h.ReportRepo.List(ctx, filter, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch reports"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Reports fetched", gin.H{
+		"reports": reports,
+		"meta":    models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetReport returns a report together with the reported content and how many
+// other active reports it has.
+func (h *AdminHandler) GetReport(c *gin.Context) {
+	reportID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid report ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	report, err := h.ReportRepo.Get(ctx, reportID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch report"))
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Report not found"))
+		return
+	}
+
+	var target bson.M
+	_ = h.DB.Collection(models.ReportTargetCollections[report.TargetType]).FindOne(ctx, bson.M{"_id": report.TargetID}).Decode(&target)
+	activeReports, _ := h.ReportRepo.CountActiveForTarget(ctx, report.TargetType, report.TargetID)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Report fetched", gin.H{
+		"report":        report,
+		"target":        target,
+		"activeReports": activeReports,
+	}))
+}
+
+// TriageReport moves a report to investigating, resolved or dismissed. The
+// reporter is told the outcome once the report is closed.
+func (h *AdminHandler) TriageReport(c *gin.Context) {
+	reportID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid report ID"))
+		return
+	}
+
+	var input struct {
+		Status     string `json:"status" binding:"required,oneof=investigating resolved dismissed"`
+		Resolution string `json:"resolution"`
+		Note       string `json:"note" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Status must be investigating, resolved or dismissed"))
+		return
+	}
+	if input.Status == models.ReportStatusResolved {
+		valid := false
+		for _, r := range models.ReportResolutions {
+			if input.Resolution == r {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Resolution must be one of: "+strings.Join(models.ReportResolutions, ", ")))
+			return
+		}
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	fields := bson.M{"status": input.Status}
+	if note := strings.TrimSpace(input.Note); note != "" {
+		fields["resolutionNote"] = note
+	}
+	closing := input.Status != models.ReportStatusInvestigating
+	if closing {
+		fields["resolution"] = input.Resolution
+		fields["resolvedBy"] = adminID
+		fields["resolvedAt"] = time.Now()
+	}
+
+	updated, err := h.ReportRepo.UpdateStatus(ctx, reportID, fields)
+	if err == repository.ErrReportNotFound {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Report not found or already closed"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update report"))
+		return
+	}
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditReportTriaged,
+		TargetType: models.AuditTargetReport,
+		TargetID:   reportID,
+		After:      gin.H{"status": updated.Status, "resolution": updated.Resolution},
+		Reason:     input.Note,
+	})
+
+	if closing {
+		go notifyReporter(h.DB, *updated)
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Report updated", gin.H{"report": updated}))
+}
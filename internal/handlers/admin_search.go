@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTopSearches lists the most frequent storefront searches over the window
+// (same ?window= / ?from= / ?to= as the dashboard), with result counts and
+// click-through rates.
+func (h *AdminHandler) GetTopSearches(c *gin.Context) {
+	h.searchReport(c, h.SearchRepo.TopSearches, "Top searches fetched")
+}
+
+// GetZeroResultSearches lists the most frequent searches that found nothing,
+// so merchandising can fill catalog gaps.
+func (h *AdminHandler) GetZeroResultSearches(c *gin.Context) {
+	h.searchReport(c, h.SearchRepo.ZeroResultSearches, "Zero-result searches fetched")
+}
+
+func (h *AdminHandler) searchReport(c *gin.Context, report func(ctx context.Context, from, to time.Time, limit int64) ([]models.SearchQueryStat, error), message string) {
+	window, from, to, err := ParseDashboardWindow(c.Query("window"), c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	stats, err := report(ctx, from, to, int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build search report"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{
+		"queries": stats,
+		"window":  window,
+		"from":    from,
+		"to":      to,
+	}))
+}
+
+// ListSearchSynonyms lists the synonym sets, filtered by ?language= when
+// given.
+func (h *AdminHandler) ListSearchSynonyms(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	synonyms, err := h.SearchRepo.ListSynonyms(ctx, c.Query("language"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch synonyms"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Synonyms fetched", gin.H{"synonyms": synonyms}))
+}
+
+// CreateSearchSynonym adds a set of interchangeable search terms.
+func (h *AdminHandler) CreateSearchSynonym(c *gin.Context) {
+	synonym, ok := bindSynonym(c)
+	if !ok {
+		return
+	}
+	synonym.CreatedAt = synonym.UpdatedAt
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.SearchRepo.CreateSynonym(ctx, &synonym); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create synonym"))
+		return
+	}
+	synonymCache.Delete(synonym.Language)
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditSynonymCreated,
+		TargetType: models.AuditTargetSynonym,
+		TargetID:   synonym.ID,
+		After:      gin.H{"language": synonym.Language, "terms": synonym.Terms},
+	})
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Synonym created", gin.H{"synonym": synonym}))
+}
+
+// UpdateSearchSynonym replaces a synonym set's language and terms.
+func (h *AdminHandler) UpdateSearchSynonym(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid synonym ID"))
+		return
+	}
+	synonym, ok := bindSynonym(c)
+	if !ok {
+		return
+	}
+	synonym.ID = id
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	existing, err := h.SearchRepo.GetSynonym(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch synonym"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Synonym not found"))
+		return
+	}
+	if err := h.SearchRepo.UpdateSynonym(ctx, synonym); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update synonym"))
+		return
+	}
+	synonymCache.Delete(existing.Language)
+	synonymCache.Delete(synonym.Language)
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditSynonymUpdated,
+		TargetType: models.AuditTargetSynonym,
+		TargetID:   id,
+		Before:     gin.H{"language": existing.Language, "terms": existing.Terms},
+		After:      gin.H{"language": synonym.Language, "terms": synonym.Terms},
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Synonym updated", nil))
+}
+
+// DeleteSearchSynonym removes a synonym set.
+func (h *AdminHandler) DeleteSearchSynonym(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid synonym ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	existing, err := h.SearchRepo.GetSynonym(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch synonym"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Synonym not found"))
+		return
+	}
+	if _, err := h.SearchRepo.DeleteSynonym(ctx, id); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete synonym"))
+		return
+	}
+	synonymCache.Delete(existing.Language)
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditSynonymDeleted,
+		TargetType: models.AuditTargetSynonym,
+		TargetID:   id,
+		Before:     gin.H{"language": existing.Language, "terms": existing.Terms},
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Synonym deleted", nil))
+}
+
+// bindSynonym reads and normalizes a synonym set from the request body,
+// writing the error response itself when the body is invalid.
+func bindSynonym(c *gin.Context) (models.SearchSynonym, bool) {
+	var input models.SearchSynonymInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
+		return models.SearchSynonym{}, false
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return models.SearchSynonym{}, false
+	}
+	terms := models.NormalizeSynonymTerms(input.Terms)
+	if len(terms) < 2 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A synonym set needs at least two distinct terms of up to three words"))
+		return models.SearchSynonym{}, false
+	}
+	language := strings.ToLower(input.Language)
+	if language == "" {
+		language = models.DefaultSearchLanguage
+	}
+	return models.SearchSynonym{Language: language, Terms: terms, UpdatedAt: time.Now()}, true
+}
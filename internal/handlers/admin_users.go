@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListUsers searches all users by email or name, role and account status.
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, limit := pageParams(c, 20, 100)
+
+	filter := bson.M{}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		pattern := regexp.QuoteMeta(search)
+		filter["$or"] = []bson.M{
+			{"email": bson.M{"$regex": pattern, "$options": "i"}},
+			{"name": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+	}
+	if role := c.Query("role"); role != "" && role != "all" {
+		filter["role"] = role
+	}
+	switch status := c.Query("status"); status {
+	case "", "all":
+	case models.AccountStatusActive:
+		filter["accountStatus"] = bson.M{"$nin": []string{models.AccountStatusSuspended, models.AccountStatusBanned}}
+	default:
+		filter["accountStatus"] = status
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := h.DB.Collection("users").Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch users"))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to decode users"))
+		return
+	}
+	total, _ := h.DB.Collection("users").CountDocuments(ctx, filter)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Users fetched", gin.H{
+		"users": users,
+		"meta":  models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetUserDetail returns a user's profile, account state and order history.
+func (h *AdminHandler) GetUserDetail(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid user ID format"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+
+	// 1. Recent orders
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(50)
+	cursor, err := h.DB.Collection("orders").Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch orders"))
+		return
+	}
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to decode orders"))
+		return
+	}
+
+	// 2. Lifetime order totals
+	pipeline := []bson.M{
+		{"$match": bson.M{"userId": userID}},
+		{"$group": bson.M{
+			"_id":   nil,
+			"count": bson.M{"$sum": 1},
+			"spent": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$in": bson.A{"$status", models.PaidOrderStatuses}}, "$total", 0,
+			}}},
+		}},
+	}
+	var totals []struct {
+		Count int64        `bson:"count"`
+		Spent models.Money `bson:"spent"`
+	}
+	if cursor, err := h.DB.Collection("orders").Aggregate(ctx, pipeline); err == nil {
+		_ = cursor.All(ctx, &totals)
+	}
+	orderStats := gin.H{"totalOrders": 0, "totalSpent": models.Money(0)}
+	if len(totals) > 0 {
+		orderStats = gin.H{"totalOrders": totals[0].Count, "totalSpent": totals[0].Spent}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("User fetched", gin.H{
+		"user":          user,
+		"accountStatus": models.EffectiveAccountStatus(user.AccountStatus, user.SuspendedUntil, time.Now()),
+		"orders":        orders,
+		"orderStats":    orderStats,
+	}))
+}
+
+// UpdateUserStatus suspends, bans or reactivates a user account.
+func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid user ID format"))
+		return
+	}
+
+	var input struct {
+		Status         string     `json:"status" binding:"required,oneof=active suspended banned"`
+		Reason         string     `json:"reason" binding:"max=500"`
+		SuspendedUntil *time.Time `json:"suspendedUntil"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.Status != models.AccountStatusActive && strings.TrimSpace(input.Reason) == "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A reason is required to suspend or ban a user"))
+		return
+	}
+	if input.SuspendedUntil != nil && !input.SuspendedUntil.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("suspendedUntil must be in the future"))
+		return
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	if adminIdStr.(string) == userID.Hex() {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You cannot change your own account status"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+
+	if err := h.UserRepo.UpdateAccountStatus(ctx, userID, input.Status, strings.TrimSpace(input.Reason), input.SuspendedUntil); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update user status"))
+		return
+	}
+	middleware.InvalidateAccountState(userID.Hex())
+
+	before := gin.H{"accountStatus": models.EffectiveAccountStatus(user.AccountStatus, user.SuspendedUntil, time.Now())}
+	if user.SuspendedUntil != nil {
+		before["suspendedUntil"] = user.SuspendedUntil
+	}
+	after := gin.H{"accountStatus": input.Status}
+	if input.SuspendedUntil != nil {
+		after["suspendedUntil"] = input.SuspendedUntil
+	}
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditUserStatusChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		Before:     before,
+		After:      after,
+		Reason:     input.Reason,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("User status updated", gin.H{"status": input.Status}))
+}
+
+// UpdateUserRole changes a user's role. It applies to their very next
+// request; existing tokens are re-checked by AuthMiddleware.
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid user ID format"))
+		return
+	}
+
+	var input struct {
+		Role   string `json:"role" binding:"required"`
+		Reason string `json:"reason" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	validRole := false
+	for _, r := range models.UserRoles {
+		if input.Role == r {
+			validRole = true
+			break
+		}
+	}
+	if !validRole {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Role must be one of: "+strings.Join(models.UserRoles, ", ")))
+		return
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	if adminIdStr.(string) == userID.Hex() {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You cannot change your own role"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+	if user.Role == input.Role {
+		c.JSON(http.StatusOK, utils.SuccessResponse("User already has this role", gin.H{"role": user.Role}))
+		return
+	}
+
+	if err := h.UserRepo.UpdateRole(ctx, userID, input.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update user role"))
+		return
+	}
+	middleware.InvalidateAccountState(userID.Hex())
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditUserRoleChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		Before:     gin.H{"role": user.Role},
+		After:      gin.H{"role": input.Role},
+		Reason:     input.Reason,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("User role updated", gin.H{"role": input.Role}))
+}
+
+// impersonationTTL is how long an impersonation token stays valid. It can't
+// be refreshed; support staff request a new one if needed.
+const impersonationTTL = 15 * time.Minute
+
+// ImpersonateUser issues a short-lived token that lets an admin see the
+// platform as the given user. The token is read-only and every request made
+// with it is written to the audit log.
+func (h *AdminHandler) ImpersonateUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid user ID format"))
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason" binding:"required,min=5,max=500"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A reason for impersonating this user is required"))
+		return
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	if adminIdStr.(string) == userID.Hex() {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You cannot impersonate yourself"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+	if user.Role == "admin" {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Admin accounts cannot be impersonated"))
+		return
+	}
+
+	token, err := utils.GenerateImpersonationToken(userID.Hex(), user.Role, adminIdStr.(string), impersonationTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to issue impersonation token"))
+		return
+	}
+	expiresAt := time.Now().Add(impersonationTTL)
+
+	h.recordAudit(c, models.AuditLog{
+		Action:     models.AuditImpersonationStarted,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		After:      gin.H{"expiresAt": expiresAt},
+		Reason:     input.Reason,
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Impersonation token issued", gin.H{
+		"accessToken":   token,
+		"expiresAt":     expiresAt,
+		"impersonation": true,
+		"readOnly":      true,
+		"user": gin.H{
+			"id":    user.ID,
+			"name":  user.Name,
+			"email": user.Email,
+			"role":  user.Role,
+		},
+	}))
+}
@@ -6,10 +6,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -25,7 +25,7 @@ func NewAuthHandler(db *mongo.Database) *AuthHandler {
 	return &AuthHandler{DB: db}
 }
 
-var validate = validator.New()
+var validate = utils.NewValidator()
 
 func (h *AuthHandler) CreateUser(c *gin.Context) {
 	var user models.RegisterInput
@@ -36,7 +36,7 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 	}
 
 	if err := validate.Struct(user); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 	collection := h.DB.Collection("users")
@@ -72,20 +72,8 @@ func (h *AuthHandler) CreateUser(c *gin.Context) {
 		return
 	}
 	verificationLink := fmt.Sprintf("https://vendora-f.vercel.app/verify?token=%s", newUser.ID.Hex())
-	emailBody := fmt.Sprintf(`
-    <html>
-    <body style="font-family: Arial, sans-serif;">
-        <h2>Welcome to Vendora, %s!</h2>
-        <p>Thank you for registering. Please verify your email by clicking the button below:</p>
-        <a href="%s" style="background-color: #4CAF50; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">Verify Email</a>
-        <p>If you didn't create this account, please ignore this email.</p>
-        <p>Best regards,<br>The Vendora Team</p>
-    </body>
-    </html>
-`, newUser.Name, verificationLink)
-
 	go func() {
-		if err := utils.SendEmail(user.Email, "Verify Your Vendora Account", emailBody); err != nil {
+		if err := utils.SendTemplateEmail(user.Email, utils.EmailTemplateVerification, utils.VerificationEmailData{Name: newUser.Name, Link: verificationLink}); err != nil {
 			logrus.WithError(err).WithField("email", user.Email).Error("Failed to send verification email")
 		} else {
 			logrus.WithField("email", user.Email).Info("Verification email sent successfully")
@@ -194,20 +182,8 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 
 	// Generate new verification link (using the same user ID as token)
 	verificationLink := fmt.Sprintf("https://vendora-f.vercel.app/verify?token=%s", user.ID.Hex())
-	emailBody := fmt.Sprintf(`
-    <html>
-    <body style="font-family: Arial, sans-serif;">
-        <h2>Welcome to Vendora, %s!</h2>
-        <p>Here is your verification link again. Please verify your email by clicking the button below:</p>
-        <a href="%s" style="background-color: #4CAF50; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">Verify Email</a>
-        <p>If you didn't create this account, please ignore this email.</p>
-        <p>Best regards,<br>The Vendora Team</p>
-    </body>
-    </html>
-`, user.Name, verificationLink)
-
 	go func() {
-		if err := utils.SendEmail(user.Email, "Verify Your Vendora Account", emailBody); err != nil {
+		if err := utils.SendTemplateEmail(user.Email, utils.EmailTemplateVerification, utils.VerificationEmailData{Name: user.Name, Link: verificationLink, Resend: true}); err != nil {
 			logrus.WithError(err).WithField("email", user.Email).Error("Failed to send verification email")
 		} else {
 			logrus.WithField("email", user.Email).Info("Verification email sent successfully")
@@ -228,14 +204,14 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 		return
 	}
 	if err := validate.Struct(cred); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 	var user models.User
 	collection := h.DB.Collection("users")
-	filter := bson.M{"email": cred.Email}
+	filter := repository.NotDeleted(bson.M{"email": cred.Email})
 	if err := collection.FindOne(ctx, filter).Decode(&user); err != nil {
 		c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid email or password"))
 		return
@@ -248,6 +224,10 @@ func (h *AuthHandler) LoginUser(c *gin.Context) {
 		c.JSON(http.StatusForbidden, utils.ErrorResponse("Please verify your account"))
 		return
 	}
+	if status := models.EffectiveAccountStatus(user.AccountStatus, user.SuspendedUntil, time.Now()); status != models.AccountStatusActive {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Your account is "+status))
+		return
+	}
 	token, err := utils.GenerateToken(user.ID.Hex(), user.Role, 24*time.Hour)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate token"))
@@ -304,7 +284,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 	if err := validate.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -313,7 +293,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 
 	var user models.User
 	collection := h.DB.Collection("users")
-	filter := bson.M{"email": input.Email}
+	filter := repository.NotDeleted(bson.M{"email": input.Email})
 	if err := collection.FindOne(ctx, filter).Decode(&user); err != nil {
 		c.JSON(http.StatusOK, utils.SuccessResponse("If the email exists, a reset link has been sent", nil))
 		return
@@ -336,22 +316,8 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 	resetLink := fmt.Sprintf("https://vendora-f.vercel.app/reset-password?token=%s", resetToken)
-	emailBody := fmt.Sprintf(`
-        <html>
-        <body style="font-family: Arial, sans-serif;">
-            <h2>Password Reset Request</h2>
-            <p>Hi %s,</p>
-            <p>You requested to reset your password. Click the button below to reset it:</p>
-            <a href="%s" style="background-color: #4CAF50; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">Reset Password</a>
-            <p>This link will expire in 1 hour.</p>
-            <p>If you didn't request this, please ignore this email.</p>
-            <p>Best regards,<br>The Vendora Team</p>
-        </body>
-        </html>
-    `, user.Name, resetLink)
-
 	go func() {
-		if err := utils.SendEmail(user.Email, "Reset Your Vendora Password", emailBody); err != nil {
+		if err := utils.SendTemplateEmail(user.Email, utils.EmailTemplatePasswordReset, utils.PasswordResetEmailData{Name: user.Name, Link: resetLink}); err != nil {
 			logrus.WithError(err).WithField("email", user.Email).Error("Failed to send reset email")
 		} else {
 			logrus.WithField("email", user.Email).Info("Reset email sent successfully")
@@ -374,7 +340,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	}
 
 	if err := validate.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -386,7 +352,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	}
 
 	collection := h.DB.Collection("users")
-	filter := bson.M{"resetToken": input.Token}
+	filter := repository.NotDeleted(bson.M{"resetToken": input.Token})
 	var user models.User
 	if err := collection.FindOne(ctx, filter).Decode(&user); err != nil {
 		c.JSON(http.StatusForbidden, utils.ErrorResponse("Invalid Reset Token"))
@@ -446,7 +412,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	if err := validate.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -454,7 +420,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	defer cancel()
 
 	collection := h.DB.Collection("users")
-	filter := bson.M{"refreshToken": input.RefreshToken}
+	filter := repository.NotDeleted(bson.M{"refreshToken": input.RefreshToken})
 
 	var user models.User
 	if err := collection.FindOne(ctx, filter).Decode(&user); err != nil {
@@ -466,6 +432,10 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Refresh token expired"))
 		return
 	}
+	if status := models.EffectiveAccountStatus(user.AccountStatus, user.SuspendedUntil, time.Now()); status != models.AccountStatusActive {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Your account is "+status))
+		return
+	}
 
 	accessToken, err := utils.GenerateToken(user.ID.Hex(), user.Role, 24*time.Hour)
 	if err != nil {
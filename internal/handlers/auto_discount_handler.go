@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Every cart view looks for automatic discounts, and there are only ever a
+// handful, so the live ones are held briefly.
+var liveAutoDiscountCache = utils.NewTTLCache[[]models.AutoDiscount](time.Minute)
+
+type AutoDiscountHandler struct {
+	Repo      repository.AutoDiscountRepository
+	AuditRepo repository.AuditRepository
+}
+
+func NewAutoDiscountHandler(db *mongo.Database) *AutoDiscountHandler {
+	return &AutoDiscountHandler{
+		Repo:      repository.NewAutoDiscountRepository(db),
+		AuditRepo: repository.NewAuditRepository(db),
+	}
+}
+
+// ListAutoDiscounts lists every automatic discount, for admins.
+func (h *AutoDiscountHandler) ListAutoDiscounts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	discounts, err := h.Repo.ListAutoDiscounts(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch automatic discounts"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Automatic discounts fetched", gin.H{"autoDiscounts": discounts}))
+}
+
+// CreateAutoDiscount sets up a discount for a buyer segment.
+func (h *AutoDiscountHandler) CreateAutoDiscount(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	fields, ok := bindAutoDiscount(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	discount := models.AutoDiscount{
+		Name:        fields["name"].(string),
+		Segment:     fields["segment"].(string),
+		WinBackDays: fields["winBackDays"].(int),
+		Type:        fields["type"].(string),
+		Value:       fields["value"].(float64),
+		MaxDiscount: fields["maxDiscount"].(float64),
+		MinSpend:    fields["minSpend"].(float64),
+		StartsAt:    fields["startsAt"].(*time.Time),
+		ExpiresAt:   fields["expiresAt"].(*time.Time),
+		Active:      fields["active"].(bool),
+		CreatedBy:   userID,
+	}
+	created, err := h.Repo.CreateAutoDiscount(ctx, discount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create automatic discount"))
+		return
+	}
+	liveAutoDiscountCache.Delete("")
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditAutoDiscountCreated,
+		TargetType: models.AuditTargetAutoDiscount,
+		TargetID:   created.ID,
+		After:      autoDiscountSnapshot(created),
+	})
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Automatic discount created", gin.H{"autoDiscount": created}))
+}
+
+// UpdateAutoDiscount replaces an automatic discount's terms. Its running
+// totals are kept.
+func (h *AutoDiscountHandler) UpdateAutoDiscount(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid discount ID"))
+		return
+	}
+	fields, ok := bindAutoDiscount(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	existing, err := h.Repo.GetAutoDiscount(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch automatic discount"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Automatic discount not found"))
+		return
+	}
+	updated, err := h.Repo.UpdateAutoDiscount(ctx, id, fields)
+	if err == repository.ErrAutoDiscountNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Automatic discount not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update automatic discount"))
+		return
+	}
+	liveAutoDiscountCache.Delete("")
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditAutoDiscountUpdated,
+		TargetType: models.AuditTargetAutoDiscount,
+		TargetID:   id,
+		Before:     autoDiscountSnapshot(*existing),
+		After:      autoDiscountSnapshot(*updated),
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Automatic discount updated", gin.H{"autoDiscount": updated}))
+}
+
+// DeleteAutoDiscount removes an automatic discount. Its redemptions are kept
+// for reporting; deactivating it keeps the totals on the discount too.
+func (h *AutoDiscountHandler) DeleteAutoDiscount(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid discount ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	existing, err := h.Repo.GetAutoDiscount(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch automatic discount"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Automatic discount not found"))
+		return
+	}
+	if err := h.Repo.DeleteAutoDiscount(ctx, id); err == repository.ErrAutoDiscountNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Automatic discount not found"))
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete automatic discount"))
+		return
+	}
+	liveAutoDiscountCache.Delete("")
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditAutoDiscountDeleted,
+		TargetType: models.AuditTargetAutoDiscount,
+		TargetID:   id,
+		Before:     autoDiscountSnapshot(*existing),
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Automatic discount deleted", nil))
+}
+
+// GetAutoDiscountReport shows how an automatic discount has done: how often
+// it was taken, by how many buyers, what it cost and what the orders came
+// to, with the latest redemptions.
+func (h *AutoDiscountHandler) GetAutoDiscountReport(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid discount ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	discount, err := h.Repo.GetAutoDiscount(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch automatic discount"))
+		return
+	}
+	if discount == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Automatic discount not found"))
+		return
+	}
+	report, err := h.Repo.GetReport(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build report"))
+		return
+	}
+	redemptions, err := h.Repo.ListRedemptions(ctx, id, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch redemptions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Report fetched", gin.H{
+		"autoDiscount": discount,
+		"report":       report,
+		"redemptions":  redemptions,
+	}))
+}
+
+// bindAutoDiscount reads and checks an automatic discount body, returning
+// the fields to store.
+func bindAutoDiscount(c *gin.Context) (bson.M, bool) {
+	var input models.AutoDiscountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return nil, false
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return nil, false
+	}
+	if input.Type == models.CouponPercentage && input.Value > 100 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A percentage discount can't be over 100"))
+		return nil, false
+	}
+	if input.StartsAt != nil && input.ExpiresAt != nil && !input.ExpiresAt.After(*input.StartsAt) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A discount must expire after it starts"))
+		return nil, false
+	}
+	winBackDays := 0
+	if input.Segment == models.SegmentWinBack {
+		winBackDays = input.WinBackDays
+	}
+
+	active := true
+	if input.Active != nil {
+		active = *input.Active
+	}
+	return bson.M{
+		"name":        input.Name,
+		"segment":     input.Segment,
+		"winBackDays": winBackDays,
+		"type":        input.Type,
+		"value":       input.Value,
+		"maxDiscount": input.MaxDiscount,
+		"minSpend":    input.MinSpend,
+		"startsAt":    input.StartsAt,
+		"expiresAt":   input.ExpiresAt,
+		"active":      active,
+	}, true
+}
+
+// autoDiscountSnapshot is the part of an automatic discount an audit entry
+// keeps.
+func autoDiscountSnapshot(d models.AutoDiscount) gin.H {
+	return gin.H{
+		"name":        d.Name,
+		"segment":     d.Segment,
+		"winBackDays": d.WinBackDays,
+		"type":        d.Type,
+		"value":       d.Value,
+		"maxDiscount": d.MaxDiscount,
+		"minSpend":    d.MinSpend,
+		"startsAt":    d.StartsAt,
+		"expiresAt":   d.ExpiresAt,
+		"active":      d.Active,
+	}
+}
+
+// priceAutoDiscount finds the automatic discount that takes most off items
+// for userID, or nil when the buyer isn't in any live discount's segment or
+// none applies to their basket.
+func priceAutoDiscount(ctx context.Context, repo repository.AutoDiscountRepository, productRepo repository.ProductRepository, userID primitive.ObjectID, items []models.CartItem) (*models.AutoDiscount, models.CouponDiscount, error) {
+	now := time.Now()
+	discounts, ok := liveAutoDiscountCache.Get("")
+	if !ok {
+		var err error
+		discounts, err = repo.ListLiveAutoDiscounts(ctx, now)
+		if err != nil {
+			return nil, models.CouponDiscount{}, err
+		}
+		liveAutoDiscountCache.Set("", discounts)
+	}
+	if len(discounts) == 0 || len(items) == 0 {
+		return nil, models.CouponDiscount{}, nil
+	}
+
+	history, err := repo.GetBuyerHistory(ctx, userID)
+	if err != nil {
+		return nil, models.CouponDiscount{}, err
+	}
+	lines, err := discountItems(ctx, productRepo, items)
+	if err != nil {
+		return nil, models.CouponDiscount{}, err
+	}
+	best, discount := models.BestAutoDiscount(discounts, history, lines, now)
+	return best, discount, nil
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxBatchRequests  = 20
+	maxBatchBodyBytes = 16 << 10
+
+	// Sub-requests one batch runs at once
+	batchParallelism = 6
+)
+
+// batchKey is what a sub-request may be named: letters, digits, _ and -.
+var batchKey = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// batchHeaders are the headers sub-requests inherit from the batch, so they
+// run as the same user, in the same language, from the same client.
+var batchHeaders = []string{"Authorization", "Accept-Language", "X-Forwarded-For", "X-Real-IP", middleware.RequestIDHeader}
+
+// BatchHandler runs several API reads in one round trip, for clients on slow
+// networks that need, say, a few products, the categories and the cart
+// before they can draw their first screen.
+type BatchHandler struct {
+	API http.Handler // The router, with /api/v2 routed; sub-requests go through it
+}
+
+func NewBatchHandler(api http.Handler) *BatchHandler {
+	return &BatchHandler{API: api}
+}
+
+// BatchRequest is one read in a batch.
+type BatchRequest struct {
+	Method string `json:"method"` // GET, the default, is the only one allowed
+	Path   string `json:"path"`   // e.g. /api/v1/public/products/<id>, with any query
+}
+
+// BatchResult is a batched read's response.
+type BatchResult struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Batch runs the GET sub-requests in the body's requests, keyed by a name
+// the client picks, and answers with each one's status and body under the
+// same key. Sub-requests run concurrently as the caller: they carry the
+// batch's Authorization header and are rate limited one by one.
+func (h *BatchHandler) Batch(c *gin.Context) {
+	var body struct {
+		Requests map[string]BatchRequest `json:"requests" binding:"required"`
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodyBytes)
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if len(body.Requests) == 0 || len(body.Requests) > maxBatchRequests {
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeInvalidRequest, "A batch takes 1 to 20 requests"))
+		return
+	}
+	for key, req := range body.Requests {
+		if !batchKey.MatchString(key) {
+			c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeInvalidRequest, "Request keys may only use letters, digits, _ and -"))
+			return
+		}
+		if err := checkBatchRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeInvalidRequest, key+": "+err.Error()))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	results := make(map[string]BatchResult, len(body.Requests))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, batchParallelism)
+	for key, req := range body.Requests {
+		wg.Add(1)
+		go func(key string, req BatchRequest) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			result := h.run(ctx, c.Request, req)
+			mu.Lock()
+			results[key] = result
+			mu.Unlock()
+		}(key, req)
+	}
+	wg.Wait()
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Batch completed", gin.H{"results": results}))
+}
+
+// checkBatchRequest allows reads of the API's own routes, but not of
+// another batch.
+func checkBatchRequest(req BatchRequest) error {
+	if req.Method != "" && !strings.EqualFold(req.Method, http.MethodGet) {
+		return errors.New("only GET requests can be batched")
+	}
+	u, err := url.Parse(req.Path)
+	if err != nil || u.IsAbs() || u.Host != "" {
+		return errors.New("path must be an API path such as /api/v1/public/categories")
+	}
+	if !strings.HasPrefix(u.Path, "/api/v1/") && !strings.HasPrefix(u.Path, "/api/v2/") || strings.Contains(u.Path, "..") {
+		return errors.New("path must be an API path such as /api/v1/public/categories")
+	}
+	if strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/batch") {
+		return errors.New("batches can't be nested")
+	}
+	return nil
+}
+
+// run makes one sub-request of parent and records its response.
+func (h *BatchHandler) run(ctx context.Context, parent *http.Request, req BatchRequest) BatchResult {
+	sub, err := http.NewRequestWithContext(ctx, http.MethodGet, req.Path, nil)
+	if err != nil {
+		return BatchResult{Status: http.StatusBadRequest}
+	}
+	for _, name := range batchHeaders {
+		if value := parent.Header.Get(name); value != "" {
+			sub.Header.Set(name, value)
+		}
+	}
+	sub.Header.Set("Accept", "application/json")
+	sub.RemoteAddr = parent.RemoteAddr
+
+	w := &batchRecorder{header: http.Header{}, status: http.StatusOK}
+	h.API.ServeHTTP(w, sub)
+
+	result := BatchResult{Status: w.status}
+	if w.body.Len() == 0 {
+		return result
+	}
+	if json.Valid(w.body.Bytes()) {
+		result.Body = w.body.Bytes()
+	} else {
+		// Responses that aren't JSON, such as CSV exports, as a string
+		result.Body, _ = json.Marshal(w.body.String())
+	}
+	return result
+}
+
+// batchRecorder holds a sub-request's response.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *batchRecorder) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *batchRecorder) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// Flush is a no-op: the whole response is sent with the batch.
+func (w *batchRecorder) Flush() {}
+
+// Hijack refuses upgrades; sockets can't be batched.
+func (w *batchRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("batched requests can't be upgraded")
+}
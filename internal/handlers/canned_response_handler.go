@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetCannedResponses lists the authenticated vendor's saved replies, most
+// used first.
+func (h *MessageHandler) GetCannedResponses(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	responses, err := h.Templates.List(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch canned responses"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Canned responses fetched", gin.H{"cannedResponses": responses}))
+}
+
+// CreateCannedResponse saves a reply the vendor can send again with
+// templateId, up to models.MaxCannedResponses of them.
+func (h *MessageHandler) CreateCannedResponse(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	input, ok := bindCannedResponse(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	count, err := h.Templates.Count(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save canned response"))
+		return
+	}
+	if count >= models.MaxCannedResponses {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("You can keep up to %d canned responses; delete one first", models.MaxCannedResponses)))
+		return
+	}
+
+	response := models.CannedResponse{VendorID: vendorID, Title: input.Title, Body: input.Body}
+	if err := h.Templates.Create(ctx, &response); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save canned response"))
+		return
+	}
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Canned response saved", gin.H{"cannedResponse": response}))
+}
+
+// UpdateCannedResponse replaces one of the vendor's saved replies.
+func (h *MessageHandler) UpdateCannedResponse(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid canned response ID"))
+		return
+	}
+	input, ok := bindCannedResponse(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	response, err := h.Templates.Update(ctx, vendorID, id, input)
+	if errors.Is(err, repository.ErrCannedResponseNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Canned response not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update canned response"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Canned response updated", gin.H{"cannedResponse": response}))
+}
+
+// DeleteCannedResponse removes one of the vendor's saved replies.
+func (h *MessageHandler) DeleteCannedResponse(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid canned response ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err = h.Templates.Delete(ctx, vendorID, id)
+	if errors.Is(err, repository.ErrCannedResponseNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Canned response not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete canned response"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Canned response deleted", nil))
+}
+
+func bindCannedResponse(c *gin.Context) (models.CannedResponseInput, bool) {
+	var input models.CannedResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return input, false
+	}
+	input.Title = strings.TrimSpace(input.Title)
+	input.Body = strings.TrimSpace(input.Body)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return input, false
+	}
+	return input, true
+}
+
+// GetAutoReply returns the vendor's away message and whether it is going
+// out right now.
+func (h *MessageHandler) GetAutoReply(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	reply, err := h.Templates.GetAutoReply(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch away message"))
+		return
+	}
+	if reply == nil {
+		reply = &models.AutoReply{VendorID: vendorID}
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Away message fetched", gin.H{
+		"autoReply": reply,
+		"active":    reply.ActiveAt(time.Now()),
+	}))
+}
+
+// SetAutoReply turns the vendor's away message on or off. Buyers who write
+// while it's on get it back once a day per conversation; it can be limited
+// to a window with startsAt and endsAt.
+func (h *MessageHandler) SetAutoReply(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.AutoReplyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Message = strings.TrimSpace(input.Message)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("endsAt must be after startsAt"))
+		return
+	}
+	// Sent as a message, so it is filtered like one
+	filtered, err := h.Filter.Apply(services.FilterMessage, input.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(filterRejection(filtered)))
+		return
+	}
+	input.Message = filtered.Text
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	reply := models.AutoReply{
+		VendorID: vendorID,
+		Enabled:  input.Enabled,
+		Message:  input.Message,
+		StartsAt: input.StartsAt,
+		EndsAt:   input.EndsAt,
+	}
+	if err := h.Templates.SaveAutoReply(ctx, reply); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save away message"))
+		return
+	}
+	reply.UpdatedAt = time.Now()
+	c.JSON(http.StatusOK, utils.SuccessResponse("Away message updated", gin.H{
+		"autoReply": reply,
+		"active":    reply.ActiveAt(time.Now()),
+	}))
+}
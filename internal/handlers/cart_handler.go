@@ -15,14 +15,29 @@ import (
 )
 
 type CartHandler struct {
-	Repo        repository.CartRepository
-	ProductRepo repository.ProductRepository
+	Repo             repository.CartRepository
+	ProductRepo      repository.ProductRepository
+	StoreRepo        repository.StoreRepository
+	ShippingRepo     repository.ShippingRepository
+	CouponRepo       repository.CouponRepository
+	FlashSaleRepo    repository.FlashSaleRepository
+	AutoDiscountRepo repository.AutoDiscountRepository
+	CheckoutRepo     repository.CheckoutEventRepository
 }
 
 func NewCartHandler(db *mongo.Database) *CartHandler {
 	repo := repository.NewCartRepository(db)
 	productRepo := repository.NewProductRepository(db)
-	return &CartHandler{Repo: repo, ProductRepo: productRepo}
+	return &CartHandler{
+		Repo:             repo,
+		ProductRepo:      productRepo,
+		StoreRepo:        repository.NewStoreRepository(db),
+		ShippingRepo:     repository.NewShippingRepository(db),
+		CouponRepo:       repository.NewCouponRepository(db),
+		FlashSaleRepo:    repository.NewFlashSaleRepository(db),
+		AutoDiscountRepo: repository.NewAutoDiscountRepository(db),
+		CheckoutRepo:     repository.NewCheckoutEventRepository(db),
+	}
 }
 
 func (h *CartHandler) AddToCart(c *gin.Context) {
@@ -30,10 +45,12 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
 
 	var req struct {
-		ProductID string  `json:"productId" binding:"required"`
-		Quantity  int     `json:"quantity" binding:"required,min=1"`
-		Price     float64 `json:"price" binding:"required"`
-		Name      string  `json:"name" binding:"required"`
+		ProductID string       `json:"productId" binding:"required"`
+		Quantity  int          `json:"quantity" binding:"required,min=1"`
+		Price     models.Money `json:"price" binding:"required"`
+		Name      string       `json:"name" binding:"required"`
+		Region    string       `json:"region"` // Where the buyer is shipping to, e.g. "NG-LA"; needed for domestic-only products
+		FlashSale string       `json:"flashSaleId"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -58,7 +75,26 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	}
 
 	if req.Quantity > product.Stock {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Requested quantity exceeds available stock"))
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeCartItemOutOfStock, "Requested quantity exceeds available stock"))
+		return
+	}
+
+	// 2. Check it can be sent to the buyer. Domestic-only products need
+	// to know where they're going; checkout checks the rest again
+	home := map[primitive.ObjectID]string{}
+	if product.ShippingRestrictions.DomesticOnly {
+		store, err := h.StoreRepo.GetByVendorID(ctx, product.VendorID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check shipping restrictions"))
+			return
+		}
+		home = homeCountries(map[primitive.ObjectID]*models.Store{product.VendorID: store})
+	}
+	if reason := product.ShippingRestrictions.Reason(req.Region, home[product.VendorID]); reason != "" {
+		respondRestricted(c, &models.ShippingRestrictionError{
+			Region: models.NormalizeRegion(req.Region),
+			Items:  []models.RestrictedItem{{ProductID: product.ID, Name: product.Name, Reason: reason}},
+		})
 		return
 	}
 
@@ -82,7 +118,7 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		for i, existingItem := range cart.Items {
 			if existingItem.ProductID == productID {
 				if existingItem.Quantity+req.Quantity > product.Stock {
-					c.JSON(http.StatusBadRequest, utils.ErrorResponse("Total quantity in cart exceeds available stock"))
+					c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeCartItemOutOfStock, "Total quantity in cart exceeds available stock"))
 					return
 				}
 
@@ -113,6 +149,38 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		}
 	}
 
+	// A flash sale price holds for as many units as the buyer may have in
+	// all, so units already in the cart count. Adding more of a line that's
+	// on a flash sale keeps it on the sale.
+	inCart := 0
+	saleID := req.FlashSale
+	for _, existingItem := range cart.Items {
+		if existingItem.ProductID == productID {
+			inCart = existingItem.Quantity
+			if saleID == "" && existingItem.FlashSaleID != nil {
+				saleID = existingItem.FlashSaleID.Hex()
+			}
+		}
+	}
+	if saleID != "" {
+		id, err := primitive.ObjectIDFromHex(saleID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid flash sale ID"))
+			return
+		}
+		sale, err := checkFlashSale(ctx, h.FlashSaleRepo, id, productID, userID, inCart+req.Quantity)
+		if isFlashSaleError(err) {
+			c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check flash sale"))
+			return
+		}
+		item.Price = sale.Price
+		item.FlashSaleID = &sale.ID
+	}
+
 	if err := h.Repo.AddToCart(ctx, userID, item); err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to add to cart"))
 		return
@@ -156,7 +224,145 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse("Cart fetched successfully", gin.H{"cart": cart}))
+	totals, err := h.cartTotals(ctx, userID, cart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to price cart"))
+		return
+	}
+	if len(cart.Items) > 0 {
+		recordCheckoutStep(h.CheckoutRepo, models.CheckoutEvent{UserID: userID, Step: models.CheckoutStepCartViewed})
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Cart fetched successfully", gin.H{"cart": cart, "totals": totals}))
+}
+
+// ApplyCoupon checks a promo code against the cart and saves it for
+// checkout.
+func (h *CartHandler) ApplyCoupon(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var req struct {
+		Code string `json:"code" binding:"required,max=32"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A coupon code is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cart, err := h.Repo.GetCart(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch cart"))
+		return
+	}
+	if len(cart.Items) == 0 {
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeCartEmpty, "Your cart is empty"))
+		return
+	}
+
+	coupon, _, err := priceCoupon(ctx, h.CouponRepo, h.ProductRepo, req.Code, userID, cart.Items)
+	if isCouponError(err) {
+		c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to apply coupon"))
+		return
+	}
+	if err := h.Repo.SetCoupon(ctx, userID, coupon.Code); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to apply coupon"))
+		return
+	}
+	cart.CouponCode = coupon.Code
+
+	totals, err := h.cartTotals(ctx, userID, cart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to price cart"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Coupon applied", gin.H{"cart": cart, "totals": totals}))
+}
+
+// RemoveCoupon takes the promo code off the cart.
+func (h *CartHandler) RemoveCoupon(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Repo.SetCoupon(ctx, userID, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to remove coupon"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Coupon removed", nil))
+}
+
+// cartTotals adds up the cart and what comes off it: its saved coupon or
+// the buyer's best automatic discount, whichever takes off more. A code that
+// no longer applies is reported rather than failing the cart.
+func (h *CartHandler) cartTotals(ctx context.Context, userID primitive.ObjectID, cart models.Cart) (models.CartTotals, error) {
+	var totals models.CartTotals
+	for _, item := range cart.Items {
+		totals.Subtotal += item.Price.Mul(item.Quantity)
+	}
+	totals.Total = totals.Subtotal
+
+	if len(cart.Items) == 0 {
+		return totals, nil
+	}
+	if cart.CouponCode != "" {
+		_, discount, err := priceCoupon(ctx, h.CouponRepo, h.ProductRepo, cart.CouponCode, userID, cart.Items)
+		if isCouponError(err) {
+			totals.CouponError = err.Error()
+		} else if err != nil {
+			return totals, err
+		} else {
+			totals.Coupon = &discount
+			totals.Discount = discount.Amount
+		}
+	}
+	auto, autoDiscount, err := priceAutoDiscount(ctx, h.AutoDiscountRepo, h.ProductRepo, userID, cart.Items)
+	if err != nil {
+		return totals, err
+	}
+	if auto != nil && autoDiscount.Amount > totals.Discount {
+		totals.AutoDiscount = &autoDiscount
+		totals.Discount = autoDiscount.Amount
+	}
+	totals.Total = max(0, totals.Subtotal-totals.Discount)
+
+	if totals.FreeShipping, err = h.freeShippingProgress(ctx, cart.Items); err != nil {
+		return totals, err
+	}
+	return totals, nil
+}
+
+// freeShippingProgress estimates how far each shipment the cart would make
+// up is from shipping free, before the buyer has said where it's going.
+func (h *CartHandler) freeShippingProgress(ctx context.Context, items []models.CartItem) ([]models.FreeShippingProgress, error) {
+	lines, err := discountItems(ctx, h.ProductRepo, items)
+	if err != nil {
+		return nil, err
+	}
+	var vendorIDs []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	shipping := make([]models.ShippingItem, 0, len(lines))
+	for _, line := range lines {
+		if !seen[line.VendorID] {
+			seen[line.VendorID] = true
+			vendorIDs = append(vendorIDs, line.VendorID)
+		}
+		shipping = append(shipping, models.ShippingItem{ProductID: line.ProductID, VendorID: line.VendorID, Subtotal: line.Subtotal.Float64()})
+	}
+	rates, _, err := loadShippingRates(ctx, h.ShippingRepo, h.StoreRepo, vendorIDs)
+	if err != nil {
+		return nil, err
+	}
+	return models.FreeShippingProgressFor(shipping, rates), nil
 }
 
 func (h *CartHandler) UpdateQuantity(c *gin.Context) {
@@ -189,10 +395,31 @@ func (h *CartHandler) UpdateQuantity(c *gin.Context) {
 	}
 
 	if req.Quantity > product.Stock {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Requested quantity exceeds available stock"))
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeCartItemOutOfStock, "Requested quantity exceeds available stock"))
 		return
 	}
 
+	// Flash sale lines stay within the sale's per-customer limit
+	cart, err := h.Repo.GetCart(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch cart"))
+		return
+	}
+	for _, item := range cart.Items {
+		if item.ProductID != productID || item.FlashSaleID == nil {
+			continue
+		}
+		_, err := checkFlashSale(ctx, h.FlashSaleRepo, *item.FlashSaleID, productID, userID, req.Quantity)
+		if isFlashSaleError(err) {
+			c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check flash sale"))
+			return
+		}
+	}
+
 	if err := h.Repo.UpdateQuantity(ctx, userID, productID, req.Quantity); err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update quantity"))
 		return
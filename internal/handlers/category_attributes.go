@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetCategoryAttributes replaces the attribute schema products in a category
+// (and the categories below it) are checked against.
+func (h *CategoryHandler) SetCategoryAttributes(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
+	var input models.CategoryAttributesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	attributes, err := models.NormalizeAttributeSchema(input.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	existing, err := h.Repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+
+	if _, err := h.Repo.SetAttributes(ctx, id, attributes); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update category attributes"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategorySchemaSet,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		Before:     gin.H{"attributes": existing.Attributes},
+		After:      gin.H{"attributes": attributes},
+	})
+	categoryHierarchyCache.Delete("")
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Category attributes updated successfully", gin.H{
+		"attributes": attributes,
+	}))
+}
+
+// applyCategoryAttributes checks product metadata against the attribute
+// schema of its category (see models.ApplyAttributeSchema). If the hierarchy
+// can't be loaded the metadata is accepted as it is rather than blocking the
+// vendor.
+func applyCategoryAttributes(ctx context.Context, repo repository.CategoryRepository, categoryID primitive.ObjectID, metadata map[string]string, variantOptions []models.VariantOption) (map[string]string, error) {
+	hierarchy, err := loadCategoryHierarchy(ctx, repo)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load category hierarchy")
+		return metadata, nil
+	}
+	return models.ApplyAttributeSchema(hierarchy.Attributes(categoryID), metadata, variantOptions)
+}
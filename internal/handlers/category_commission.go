@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// commissionBackdateGrace allows for clock skew between an admin's client
+// and the server when a rate is set to start "now".
+const commissionBackdateGrace = time.Minute
+
+// GetCategoryCommission returns a category's commission history and the
+// rate it charges now (nil when vendor rates apply).
+func (h *CategoryHandler) GetCategoryCommission(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	history, err := h.CommissionRepo.ListForCategory(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch commission history"))
+		return
+	}
+	current, _ := models.CommissionRateAt(history, time.Now())
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Commission history fetched", gin.H{
+		"current": current,
+		"history": history,
+	}))
+}
+
+// SetCategoryCommission schedules a new commission rate for a category, or
+// clears it. Rates can't be backdated, so orders already placed keep the
+// rate they were placed under.
+func (h *CategoryHandler) SetCategoryCommission(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
+	var input models.CategoryCommissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	effectiveFrom := now
+	if input.EffectiveFrom != nil {
+		if input.EffectiveFrom.Before(now.Add(-commissionBackdateGrace)) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("effectiveFrom can't be in the past"))
+			return
+		}
+		if input.EffectiveFrom.After(now) {
+			effectiveFrom = *input.EffectiveFrom
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	category, err := h.Repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if category == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+	commission, err := h.CommissionRepo.Create(ctx, models.CategoryCommission{
+		CategoryID:    id,
+		Rate:          input.Rate,
+		EffectiveFrom: effectiveFrom,
+		CreatedBy:     adminID,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to set commission"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryCommission,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		After:      gin.H{"rate": input.Rate, "effectiveFrom": effectiveFrom},
+	})
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Commission scheduled", gin.H{"commission": commission}))
+}
+
+// commissionRates returns the commission rate charged on sales made at t in
+// each of categoryIDs: the category's own, else that of the nearest category
+// above it with one. Categories left to vendor rates map to nil. If the
+// rates can't be loaded vendor rates apply throughout.
+func commissionRates(ctx context.Context, commissionRepo repository.CommissionRepository, categoryRepo repository.CategoryRepository, categoryIDs []primitive.ObjectID, t time.Time) map[primitive.ObjectID]*float64 {
+	rates := map[primitive.ObjectID]*float64{}
+	if len(categoryIDs) == 0 {
+		return rates
+	}
+
+	// 1. Each category and the ones above it, nearest first
+	hierarchy, err := loadCategoryHierarchy(ctx, categoryRepo)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load category hierarchy")
+	}
+	chains := map[primitive.ObjectID][]primitive.ObjectID{}
+	var all []primitive.ObjectID
+	for _, id := range categoryIDs {
+		if _, ok := chains[id]; ok {
+			continue
+		}
+		chain := []primitive.ObjectID{id}
+		if hierarchy != nil {
+			if crumbs := hierarchy.Breadcrumbs(id); crumbs != nil {
+				chain = chain[:0]
+				for i := len(crumbs) - 1; i >= 0; i-- {
+					chain = append(chain, crumbs[i].ID)
+				}
+			}
+		}
+		chains[id] = chain
+		all = append(all, chain...)
+	}
+
+	// 2. The first rate in force along each chain
+	histories, err := commissionRepo.ListForCategories(ctx, all)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load category commissions; charging vendor rates")
+		return rates
+	}
+	for id, chain := range chains {
+		for _, categoryID := range chain {
+			if rate, ok := models.CommissionRateAt(histories[categoryID], t); ok {
+				rates[id] = rate
+				break
+			}
+		}
+	}
+	return rates
+}
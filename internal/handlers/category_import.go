@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCategoryImportSize caps an uploaded taxonomy file.
+const maxCategoryImportSize = 5 << 20 // 5MB
+
+// ImportCategories creates or updates a whole category taxonomy in one call,
+// matching categories by slug so the same import can be run again safely.
+// It takes a JSON body ({"categories": [...]} with nested children), a CSV
+// body, or either as a multipart "file" upload.
+func (h *CategoryHandler) ImportCategories(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCategoryImportSize)
+
+	// 1. Read the taxonomy
+	var (
+		body   io.Reader = c.Request.Body
+		isJSON           = strings.HasPrefix(c.ContentType(), "application/json")
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("No file provided or file too large (Max 5MB)"))
+			return
+		}
+		defer file.Close()
+		body = file
+		isJSON = strings.EqualFold(filepath.Ext(header.Filename), ".json")
+	}
+
+	var rows []models.CategoryImportRow
+	if isJSON {
+		var input struct {
+			Categories []models.CategoryImportNode `json:"categories"`
+		}
+		if err := json.NewDecoder(body).Decode(&input); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
+			return
+		}
+		rows = models.FlattenCategoryImport(input.Categories, utils.GenerateSlug)
+	} else {
+		var err error
+		if rows, err = models.ParseCategoryCSV(body, utils.GenerateSlug); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+			return
+		}
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No categories to import"))
+		return
+	}
+
+	// 2. Check it and put parents first
+	rows, err := models.OrderCategoryImport(rows)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	// 3. Import
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	result, err := h.Repo.Import(ctx, rows)
+	categoryHierarchyCache.Delete("")
+	if errors.Is(err, repository.ErrImportParentMissing) || errors.Is(err, repository.ErrImportCycle) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Category import failed after %d created, %d updated: %v", result.Created, result.Updated, err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Category import failed part way; it is safe to run again"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryImported,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   primitive.NilObjectID,
+		After:      gin.H{"rows": len(rows), "result": result},
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Categories imported successfully", result))
+}
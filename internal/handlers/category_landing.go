@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// landingCache holds assembled category pages; their counts and rankings
+// change slowly and the pages are hit by every storefront visitor.
+var landingCache = utils.NewTTLCache[models.CategoryLanding](5 * time.Minute)
+
+// GetCategoryLanding returns a category with its sub-categories, top brands,
+// featured and best-selling products and facet counts, so a storefront
+// category page needs one request.
+func (h *CategoryHandler) GetCategoryLanding(c *gin.Context) {
+	slug := strings.ToLower(c.Param("slug"))
+	if landing, ok := landingCache.Get(slug); ok {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Category page fetched", landing))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. The category and its sub-categories
+	category, err := h.Repo.GetActiveBySlug(ctx, slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if category == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+	children, err := h.Repo.ListActiveChildren(ctx, category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+
+	// 2. Facet counts and featured products across the category and
+	// everything below it
+	childIDs := make([]primitive.ObjectID, len(children))
+	for i, child := range children {
+		childIDs[i] = child.ID
+	}
+	categoryIDs := append([]primitive.ObjectID{category.ID}, childIDs...)
+	var filterable []models.CategoryAttribute
+	if hierarchy, err := loadCategoryHierarchy(ctx, h.Repo); err == nil {
+		categoryIDs = hierarchy.WithDescendants(category.ID)
+		for _, a := range hierarchy.Attributes(category.ID) {
+			if a.Filterable {
+				filterable = append(filterable, a)
+			}
+		}
+	}
+
+	facets, featured, err := h.Repo.GetLandingFacets(ctx, categoryIDs, childIDs, filterable)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+
+	// 3. Best sellers from the hourly rankings
+	bestSellers, err := loadRankedProducts(ctx, h.ProductRepo, h.RankingRepo, models.RankingBestSellers, category.ID, models.LandingProductLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if bestSellers == nil {
+		bestSellers = []models.Product{}
+	}
+
+	landing := models.CategoryLanding{
+		Category:      *category,
+		SubCategories: children,
+		TopBrands:     facets.Brands[:min(len(facets.Brands), models.LandingBrandLimit)],
+		Featured:      featured,
+		BestSellers:   bestSellers,
+		Facets:        facets,
+	}
+	landingCache.Set(slug, landing)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Category page fetched", landing))
+}
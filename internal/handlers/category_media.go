@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// categoryMediaKind is one of the pictures a category can have: a large
+// image for category grids and banners, and a small icon for menus.
+type categoryMediaKind struct {
+	Field string // Category field and form file name
+	Media services.MediaContext
+}
+
+var (
+	categoryImage = categoryMediaKind{Field: "image", Media: services.MediaCategoryImage}
+	categoryIcon  = categoryMediaKind{Field: "icon", Media: services.MediaCategoryIcon}
+)
+
+// UploadCategoryImage replaces a category's image.
+func (h *CategoryHandler) UploadCategoryImage(c *gin.Context) {
+	h.uploadCategoryMedia(c, categoryImage)
+}
+
+// UploadCategoryIcon replaces a category's icon.
+func (h *CategoryHandler) UploadCategoryIcon(c *gin.Context) {
+	h.uploadCategoryMedia(c, categoryIcon)
+}
+
+// RemoveCategoryImage clears a category's image.
+func (h *CategoryHandler) RemoveCategoryImage(c *gin.Context) {
+	h.removeCategoryMedia(c, categoryImage)
+}
+
+// RemoveCategoryIcon clears a category's icon.
+func (h *CategoryHandler) RemoveCategoryIcon(c *gin.Context) {
+	h.removeCategoryMedia(c, categoryIcon)
+}
+
+func (h *CategoryHandler) uploadCategoryMedia(c *gin.Context, kind categoryMediaKind) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
+	// 1. Read the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, kind.Media.MaxSize+1<<20)
+	file, header, err := c.Request.FormFile(kind.Field)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No file provided or file too large ("+kind.Media.Describe()+")"))
+		return
+	}
+	defer file.Close()
+
+	// 2. Check the category exists before uploading anything
+	category, ok := h.loadCategory(c, id)
+	if !ok {
+		return
+	}
+
+	// 3. Upload. The media service checks the file and stores one per
+	// category, so a new upload replaces the old file rather than piling up
+	// next to it.
+	upload, err := h.Media.Upload(c.Request.Context(), kind.Media, category.ID, file, header)
+	if !uploadSucceeded(c, err, kind.Media, "Unsupported "+kind.Field+"; upload ") {
+		return
+	}
+
+	h.setCategoryMedia(c, kind, upload.URL)
+}
+
+// removeCategoryMedia clears a category image or icon and deletes the file.
+func (h *CategoryHandler) removeCategoryMedia(c *gin.Context, kind categoryMediaKind) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+	category, ok := h.loadCategory(c, id)
+	if !ok {
+		return
+	}
+	old := category.Image
+	if kind.Field == categoryIcon.Field {
+		old = category.Icon
+	}
+
+	h.setCategoryMedia(c, kind, "")
+	if old == "" || c.Writer.Status() != http.StatusOK {
+		return
+	}
+	// The category no longer points at the file, so a failed delete only
+	// leaves it orphaned in storage
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := h.Media.Delete(ctx, old); err != nil && !errors.Is(err, services.ErrMediaNotStored) {
+			logrus.WithError(err).WithField("categoryId", id.Hex()).Warn("Failed to delete category " + kind.Field)
+		}
+	}()
+}
+
+func (h *CategoryHandler) loadCategory(c *gin.Context, id primitive.ObjectID) (*models.Category, bool) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	category, err := h.Repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return nil, false
+	}
+	if category == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return nil, false
+	}
+	return category, true
+}
+
+// setCategoryMedia stores a category image or icon URL, or clears it when
+// url is empty.
+func (h *CategoryHandler) setCategoryMedia(c *gin.Context, kind categoryMediaKind, url string) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	found, err := h.Repo.SetMedia(ctx, id, kind.Field, url)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update category"))
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryUpdated,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		After:      gin.H{kind.Field: url},
+	})
+	categoryHierarchyCache.Delete("")
+
+	msg := "Category " + kind.Field + " updated successfully"
+	if url == "" {
+		msg = "Category " + kind.Field + " removed successfully"
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse(msg, gin.H{kind.Field: url}))
+}
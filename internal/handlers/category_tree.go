@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// categoryHierarchyCache holds the active categories indexed as a tree. It
+// backs the tree endpoint, product breadcrumbs and category filters, and is
+// cleared whenever an admin changes a category.
+var categoryHierarchyCache = utils.NewTTLCache[*models.CategoryHierarchy](5 * time.Minute)
+
+// loadCategoryHierarchy returns the active category hierarchy, from the
+// cache when it can.
+func loadCategoryHierarchy(ctx context.Context, repo repository.CategoryRepository) (*models.CategoryHierarchy, error) {
+	if hierarchy, ok := categoryHierarchyCache.Get(""); ok {
+		return hierarchy, nil
+	}
+	categories, err := repo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hierarchy := models.NewCategoryHierarchy(categories)
+	categoryHierarchyCache.Set("", hierarchy)
+	return hierarchy, nil
+}
+
+// categoryCondition matches products filed under a category or any category
+// below it, either as their main category or as a sub-category. If the
+// hierarchy can't be loaded only the category itself is matched.
+func categoryCondition(ctx context.Context, repo repository.CategoryRepository, categoryID primitive.ObjectID) bson.M {
+	ids := []primitive.ObjectID{categoryID}
+	if hierarchy, err := loadCategoryHierarchy(ctx, repo); err != nil {
+		logrus.WithError(err).Warn("Failed to load category hierarchy")
+	} else {
+		ids = hierarchy.WithDescendants(categoryID)
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"categoryId": bson.M{"$in": ids}},
+		bson.M{"subCategoryIds": bson.M{"$in": ids}},
+	}}
+}
+
+// GetCategoryTree returns the active categories nested under their parents.
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	hierarchy, err := loadCategoryHierarchy(ctx, h.Repo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch categories"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Category tree fetched successfully", gin.H{
+		"categories": hierarchy.Tree(),
+	}))
+}
+
+// GetCategoryBySlug returns an active category by its URL slug, with the
+// breadcrumb path down to it and its direct sub-categories.
+func (h *CategoryHandler) GetCategoryBySlug(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	category, err := h.Repo.GetActiveBySlug(ctx, strings.ToLower(c.Param("slug")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if category == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+	children, err := h.Repo.ListActiveChildren(ctx, category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+
+	breadcrumbs := []models.CategoryCrumb{{ID: category.ID, Name: category.Name, Slug: category.Slug}}
+	if hierarchy, err := loadCategoryHierarchy(ctx, h.Repo); err != nil {
+		logrus.WithError(err).Warn("Failed to load category hierarchy")
+	} else if path := hierarchy.Breadcrumbs(category.ID); path != nil {
+		breadcrumbs = path
+	}
+	category.Localize(i18n.FromContext(ctx))
+	localizeCategories(c, children)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Category fetched successfully", gin.H{
+		"category":      category,
+		"breadcrumbs":   breadcrumbs,
+		"subCategories": children,
+	}))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// recordCheckoutStep counts a buyer reaching a checkout step for the funnel
+// report, without holding up the response.
+func recordCheckoutStep(repo repository.CheckoutEventRepository, event models.CheckoutEvent) {
+	if event.UserID.IsZero() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := repo.Record(ctx, event); err != nil {
+			logrus.WithError(err).WithField("step", event.Step).Warn("Failed to record checkout step")
+		}
+	}()
+}
+
+// recordPaymentSucceeded counts the buyer of a newly paid order as having
+// finished checkout. The webhook and the verify call both see the payment;
+// recording is idempotent within the day, so it doesn't matter which does.
+func (h *PaymentHandler) recordPaymentSucceeded(order models.Order) {
+	recordCheckoutStep(h.CheckoutRepo, models.CheckoutEvent{
+		UserID:   order.UserID,
+		Step:     models.CheckoutStepPaymentSucceeded,
+		Provider: models.PaymentProviderStripe,
+		OrderID:  order.ID,
+	})
+}
+
+// GetCheckoutFunnel reports how many buyers got to each checkout step over
+// a range (?window= or ?from=/?to=, as for the dashboard), the share who
+// dropped off before the next, and the payment steps per provider, naming
+// the step most buyers bail before.
+func (h *AdminHandler) GetCheckoutFunnel(c *gin.Context) {
+	_, from, to, err := ParseDashboardWindow(c.Query("window"), c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	overall, byProvider, err := h.AnalyticsRepo.ListCheckoutReach(ctx, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build checkout funnel"))
+		return
+	}
+	funnel := models.BuildCheckoutFunnel(overall, byProvider, from, to)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Checkout funnel fetched", gin.H{"funnel": funnel}))
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CouponHandler struct {
+	Repo        repository.CouponRepository
+	ProductRepo repository.ProductRepository
+	AuditRepo   repository.AuditRepository
+}
+
+func NewCouponHandler(db *mongo.Database) *CouponHandler {
+	return &CouponHandler{
+		Repo:        repository.NewCouponRepository(db),
+		ProductRepo: repository.NewProductRepository(db),
+		AuditRepo:   repository.NewAuditRepository(db),
+	}
+}
+
+// ListVendorCoupons lists the authenticated vendor's coupons.
+func (h *CouponHandler) ListVendorCoupons(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	h.listCoupons(c, bson.M{"vendorId": vendorID})
+}
+
+// CreateVendorCoupon creates a coupon for the vendor's own products.
+func (h *CouponHandler) CreateVendorCoupon(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	h.createCoupon(c, vendorID)
+}
+
+// UpdateVendorCoupon replaces one of the vendor's coupons.
+func (h *CouponHandler) UpdateVendorCoupon(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	h.updateCoupon(c, vendorID, false)
+}
+
+// DeleteVendorCoupon deletes one of the vendor's coupons.
+func (h *CouponHandler) DeleteVendorCoupon(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	h.deleteCoupon(c, vendorID, false)
+}
+
+// GetVendorCouponRedemptions lists the latest orders one of the vendor's
+// coupons was used on.
+func (h *CouponHandler) GetVendorCouponRedemptions(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	couponID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid coupon ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	coupon, err := h.Repo.GetCoupon(ctx, bson.M{"_id": couponID, "vendorId": vendorID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch coupon"))
+		return
+	}
+	if coupon == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Coupon not found"))
+		return
+	}
+	redemptions, err := h.Repo.ListRedemptions(ctx, couponID, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch redemptions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Redemptions fetched", gin.H{"coupon": coupon, "redemptions": redemptions}))
+}
+
+// ListCoupons lists every coupon for admins, optionally one vendor's
+// (?vendorId=) or the platform's own (?vendorId=platform).
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	filter := bson.M{}
+	switch vendor := c.Query("vendorId"); vendor {
+	case "":
+	case "platform":
+		filter["vendorId"] = primitive.NilObjectID
+	default:
+		vendorID, err := primitive.ObjectIDFromHex(vendor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid vendor ID"))
+			return
+		}
+		filter["vendorId"] = vendorID
+	}
+	h.listCoupons(c, filter)
+}
+
+// CreatePlatformCoupon creates a coupon the platform funds, usable on any
+// vendor's products.
+func (h *CouponHandler) CreatePlatformCoupon(c *gin.Context) {
+	coupon, ok := h.createCoupon(c, primitive.NilObjectID)
+	if !ok {
+		return
+	}
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCouponCreated,
+		TargetType: models.AuditTargetCoupon,
+		TargetID:   coupon.ID,
+		After:      couponSnapshot(coupon),
+	})
+}
+
+// UpdateCoupon replaces any coupon, for admins.
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	h.updateCoupon(c, primitive.NilObjectID, true)
+}
+
+// DeleteCoupon deletes any coupon, for admins.
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	h.deleteCoupon(c, primitive.NilObjectID, true)
+}
+
+func (h *CouponHandler) listCoupons(c *gin.Context, filter bson.M) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	coupons, err := h.Repo.ListCoupons(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch coupons"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Coupons fetched", gin.H{"coupons": coupons}))
+}
+
+// createCoupon creates a coupon owned by vendorID (nil for the platform)
+// from the request body, responding either way.
+func (h *CouponHandler) createCoupon(c *gin.Context, vendorID primitive.ObjectID) (models.Coupon, bool) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	fields, ok := h.bindCoupon(ctx, c, vendorID)
+	if !ok {
+		return models.Coupon{}, false
+	}
+	coupon := models.Coupon{VendorID: vendorID, CreatedBy: userID}
+	applyCouponFields(&coupon, fields)
+
+	created, err := h.Repo.CreateCoupon(ctx, coupon)
+	if err == repository.ErrCouponCodeExists {
+		c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+		return models.Coupon{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create coupon"))
+		return models.Coupon{}, false
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Coupon created", gin.H{"coupon": created}))
+	return created, true
+}
+
+// updateCoupon replaces a coupon from the request body: one of vendorID's,
+// or any for an admin.
+func (h *CouponHandler) updateCoupon(c *gin.Context, vendorID primitive.ObjectID, admin bool) {
+	couponID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid coupon ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": couponID}
+	if !admin {
+		filter["vendorId"] = vendorID
+	}
+	existing, err := h.Repo.GetCoupon(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch coupon"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Coupon not found"))
+		return
+	}
+
+	fields, ok := h.bindCoupon(ctx, c, existing.VendorID)
+	if !ok {
+		return
+	}
+	updated, err := h.Repo.UpdateCoupon(ctx, filter, fields)
+	if err == repository.ErrCouponCodeExists {
+		c.JSON(http.StatusConflict, utils.ErrorResponse(err.Error()))
+		return
+	}
+	if err == repository.ErrCouponNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Coupon not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update coupon"))
+		return
+	}
+
+	if admin {
+		recordAudit(h.AuditRepo, c, models.AuditLog{
+			Action:     models.AuditCouponUpdated,
+			TargetType: models.AuditTargetCoupon,
+			TargetID:   couponID,
+			Before:     couponSnapshot(*existing),
+			After:      couponSnapshot(*updated),
+		})
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Coupon updated", gin.H{"coupon": updated}))
+}
+
+// couponSnapshot is the part of a coupon an audit entry keeps.
+func couponSnapshot(coupon models.Coupon) gin.H {
+	return gin.H{
+		"code":        coupon.Code,
+		"type":        coupon.Type,
+		"value":       coupon.Value,
+		"maxDiscount": coupon.MaxDiscount,
+		"minSpend":    coupon.MinSpend,
+		"usageLimit":  coupon.UsageLimit,
+		"startsAt":    coupon.StartsAt,
+		"expiresAt":   coupon.ExpiresAt,
+		"active":      coupon.Active,
+	}
+}
+
+// deleteCoupon deletes a coupon: one of vendorID's, or any for an admin.
+// Orders it was used on keep their discount.
+func (h *CouponHandler) deleteCoupon(c *gin.Context, vendorID primitive.ObjectID, admin bool) {
+	couponID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid coupon ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": couponID}
+	if !admin {
+		filter["vendorId"] = vendorID
+	}
+	if err := h.Repo.DeleteCoupon(ctx, filter); err == repository.ErrCouponNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Coupon not found"))
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete coupon"))
+		return
+	}
+
+	if admin {
+		recordAudit(h.AuditRepo, c, models.AuditLog{
+			Action:     models.AuditCouponDeleted,
+			TargetType: models.AuditTargetCoupon,
+			TargetID:   couponID,
+		})
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Coupon deleted", nil))
+}
+
+// bindCoupon reads and checks a coupon body for a coupon owned by vendorID,
+// returning the fields to store. A vendor's coupon can only name their own
+// products.
+func (h *CouponHandler) bindCoupon(ctx context.Context, c *gin.Context, vendorID primitive.ObjectID) (bson.M, bool) {
+	var input models.CouponInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return nil, false
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return nil, false
+	}
+	if input.Type == models.CouponPercentage && input.Value > 100 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A percentage discount can't be over 100"))
+		return nil, false
+	}
+	if input.StartsAt != nil && input.ExpiresAt != nil && !input.ExpiresAt.After(*input.StartsAt) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A coupon must expire after it starts"))
+		return nil, false
+	}
+
+	productIDs := dedupeProductIDs(input.ProductIDs)
+	if !vendorID.IsZero() && len(productIDs) > 0 {
+		_, total, err := h.ProductRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": productIDs}, "vendorId": vendorID}, 1, 0, nil, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify products"))
+			return nil, false
+		}
+		if int(total) != len(productIDs) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Coupons can only apply to your own products"))
+			return nil, false
+		}
+	}
+
+	active := true
+	if input.Active != nil {
+		active = *input.Active
+	}
+	return bson.M{
+		"code":         models.NormalizeCouponCode(input.Code),
+		"description":  input.Description,
+		"type":         input.Type,
+		"value":        input.Value,
+		"maxDiscount":  input.MaxDiscount,
+		"minSpend":     input.MinSpend,
+		"productIds":   productIDs,
+		"categoryIds":  dedupeProductIDs(input.CategoryIDs),
+		"usageLimit":   input.UsageLimit,
+		"perUserLimit": input.PerUserLimit,
+		"startsAt":     input.StartsAt,
+		"expiresAt":    input.ExpiresAt,
+		"active":       active,
+	}, true
+}
+
+func applyCouponFields(coupon *models.Coupon, fields bson.M) {
+	coupon.Code = fields["code"].(string)
+	coupon.Description = fields["description"].(string)
+	coupon.Type = fields["type"].(string)
+	coupon.Value = fields["value"].(float64)
+	coupon.MaxDiscount = fields["maxDiscount"].(float64)
+	coupon.MinSpend = fields["minSpend"].(float64)
+	coupon.ProductIDs = fields["productIds"].([]primitive.ObjectID)
+	coupon.CategoryIDs = fields["categoryIds"].([]primitive.ObjectID)
+	coupon.UsageLimit = fields["usageLimit"].(int)
+	coupon.PerUserLimit = fields["perUserLimit"].(int)
+	coupon.StartsAt = fields["startsAt"].(*time.Time)
+	coupon.ExpiresAt = fields["expiresAt"].(*time.Time)
+	coupon.Active = fields["active"].(bool)
+}
+
+// priceCoupon works out what the coupon with code takes off items for
+// userID at today's prices. It returns one of the models.ErrCoupon* errors
+// when the code can't be used.
+func priceCoupon(ctx context.Context, couponRepo repository.CouponRepository, productRepo repository.ProductRepository, code string, userID primitive.ObjectID, items []models.CartItem) (*models.Coupon, models.CouponDiscount, error) {
+	// 1. The coupon, and whether this buyer can still use it
+	coupon, err := couponRepo.GetCouponByCode(ctx, code)
+	if err != nil {
+		return nil, models.CouponDiscount{}, err
+	}
+	if coupon == nil {
+		return nil, models.CouponDiscount{}, models.ErrCouponNotFound
+	}
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return nil, models.CouponDiscount{}, models.ErrCouponUsedUp
+	}
+	if coupon.PerUserLimit > 0 {
+		used, err := couponRepo.CountUserRedemptions(ctx, coupon.ID, userID)
+		if err != nil {
+			return nil, models.CouponDiscount{}, err
+		}
+		if used >= int64(coupon.PerUserLimit) {
+			return nil, models.CouponDiscount{}, models.ErrCouponUserLimit
+		}
+	}
+
+	// 2. What it takes off
+	lines, err := discountItems(ctx, productRepo, items)
+	if err != nil {
+		return nil, models.CouponDiscount{}, err
+	}
+	discount, err := coupon.Apply(lines, time.Now())
+	return coupon, discount, err
+}
+
+// discountItems prices the cart's lines for working out a discount, at
+// today's prices.
+func discountItems(ctx context.Context, productRepo repository.ProductRepository, items []models.CartItem) ([]models.DiscountItem, error) {
+	ids := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	products, _, err := productRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": ids}}, int64(len(ids)), 0, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	lines := make([]models.DiscountItem, 0, len(items))
+	for _, item := range items {
+		if p, ok := byID[item.ProductID]; ok {
+			price := p.EffectivePrice()
+			if item.FlashSaleID != nil {
+				price = item.Price
+			}
+			lines = append(lines, models.DiscountItem{
+				ProductID:  p.ID,
+				VendorID:   p.VendorID,
+				CategoryID: p.CategoryID,
+				Subtotal:   price.Mul(item.Quantity),
+			})
+		}
+	}
+	return lines, nil
+}
+
+// isCouponError reports whether err is a reason the buyer's code can't be
+// used, rather than a failure.
+func isCouponError(err error) bool {
+	for _, target := range []error{
+		models.ErrCouponNotFound,
+		models.ErrCouponInactive,
+		models.ErrCouponUsedUp,
+		models.ErrCouponUserLimit,
+		models.ErrCouponMinSpend,
+		models.ErrCouponNotApplicable,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	digestTopProducts     = 3
+	digestLowStockLimit   = 10
+	digestRecommendations = 4
+)
+
+// DigestJob sends the weekly vendor and buyer digest emails.
+type DigestJob struct {
+	Repo       repository.DigestRepository
+	Dispatcher *NotificationDispatcher
+}
+
+func NewDigestJob(db *mongo.Database) *DigestJob {
+	return &DigestJob{
+		Repo:       repository.NewDigestRepository(db),
+		Dispatcher: NewNotificationDispatcher(db),
+	}
+}
+
+// Start sends digests every week at models.NextDigestRun until ctx is done.
+func (j *DigestJob) Start(ctx context.Context) {
+	go func() {
+		for {
+			next := models.NextDigestRun(time.Now())
+			logrus.WithField("at", next).Info("Next weekly digest scheduled")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+				j.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run sends one round of digests. A user who already got a digest this
+// period is skipped, so overlapping instances or a restart mid-run don't
+// send twice.
+func (j *DigestJob) Run(ctx context.Context) {
+	since := time.Now().Add(-models.DigestPeriod)
+	// A little slack so a run that starts a few minutes early still claims
+	// last week's recipients
+	cutoff := since.Add(time.Hour)
+
+	// 1. Vendors
+	vendorIDs, err := j.Repo.ListDigestVendorIDs(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list digest vendors")
+	}
+	vendorsSent := 0
+	for _, vendorID := range vendorIDs {
+		if j.sendVendorDigest(ctx, vendorID, since, cutoff) {
+			vendorsSent++
+		}
+	}
+
+	// 2. Buyers with wishlists
+	buyersSent := 0
+	err = j.Repo.ForEachDigestWishlist(ctx, func(w models.DigestWishlist) error {
+		if j.sendBuyerDigest(ctx, w, cutoff) {
+			buyersSent++
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to go through wishlists for digests")
+	}
+
+	logrus.WithFields(logrus.Fields{"vendors": vendorsSent, "buyers": buyersSent}).Info("Weekly digests sent")
+}
+
+func (j *DigestJob) sendVendorDigest(ctx context.Context, vendorID primitive.ObjectID, since, cutoff time.Time) bool {
+	log := logrus.WithField("vendorId", vendorID.Hex())
+
+	sales, err := j.Repo.GetVendorSalesSummary(ctx, vendorID, since, digestTopProducts)
+	if err != nil {
+		log.WithError(err).Error("Failed to summarise vendor sales for digest")
+		return false
+	}
+	lowStock, err := j.Repo.GetLowStockProducts(ctx, vendorID, digestLowStockLimit)
+	if err != nil {
+		log.WithError(err).Error("Failed to load low stock products for digest")
+		return false
+	}
+	// Nothing to report
+	if sales.OrderCount == 0 && len(lowStock) == 0 {
+		return false
+	}
+
+	claimed, err := j.Repo.ClaimDigest(ctx, vendorID, models.DigestAudienceVendor, cutoff)
+	if err != nil || !claimed {
+		if err != nil {
+			log.WithError(err).Error("Failed to claim vendor digest")
+		}
+		return false
+	}
+
+	data := utils.VendorDigestEmailData{
+		Revenue:    sales.Revenue.Float64(),
+		UnitsSold:  sales.UnitsSold,
+		OrderCount: sales.OrderCount,
+		Link:       frontendURL() + "/vendor/dashboard",
+	}
+	for _, p := range sales.TopProducts {
+		data.TopProducts = append(data.TopProducts, utils.DigestEmailProduct{
+			Name:     p.Name,
+			Price:    p.Revenue.Float64(),
+			Quantity: p.UnitsSold,
+			Link:     frontendURL() + "/products/" + p.ProductID.Hex(),
+		})
+	}
+	for _, p := range lowStock {
+		data.LowStock = append(data.LowStock, utils.DigestEmailProduct{
+			Name:     p.Name,
+			Quantity: p.Stock,
+			Link:     frontendURL() + "/vendor/products/" + p.ID.Hex(),
+		})
+	}
+
+	j.Dispatcher.Dispatch(ctx, vendorID, OutgoingNotification{
+		Type: models.NotificationDigest,
+		Email: func(user models.User) EmailContent {
+			data.Name = user.Name
+			data.UnsubscribeLink = digestUnsubscribeLink(user.ID)
+			return EmailContent{Template: utils.EmailTemplateVendorDigest, Data: data}
+		},
+	})
+	return true
+}
+
+func (j *DigestJob) sendBuyerDigest(ctx context.Context, w models.DigestWishlist, cutoff time.Time) bool {
+	log := logrus.WithField("userId", w.UserID.Hex())
+
+	drops := models.WishlistPriceDrops(w.Products, w.DigestPrices)
+
+	// Recommend from the categories the buyer is saving things in
+	seen := map[primitive.ObjectID]bool{}
+	var categoryIDs, exclude []primitive.ObjectID
+	for _, p := range w.Products {
+		exclude = append(exclude, p.ID)
+		if !p.CategoryID.IsZero() && !seen[p.CategoryID] {
+			seen[p.CategoryID] = true
+			categoryIDs = append(categoryIDs, p.CategoryID)
+		}
+	}
+	recommended, err := j.Repo.GetRecommendations(ctx, categoryIDs, exclude, digestRecommendations)
+	if err != nil {
+		log.WithError(err).Error("Failed to load digest recommendations")
+		recommended = nil
+	}
+	if len(drops) == 0 && len(recommended) == 0 {
+		// Still record prices, so a wishlist's first drop is caught next week
+		j.savePriceSnapshot(ctx, w)
+		return false
+	}
+
+	claimed, err := j.Repo.ClaimDigest(ctx, w.UserID, models.DigestAudienceBuyer, cutoff)
+	if err != nil || !claimed {
+		if err != nil {
+			log.WithError(err).Error("Failed to claim buyer digest")
+		}
+		return false
+	}
+
+	data := utils.BuyerDigestEmailData{Link: frontendURL() + "/wishlist"}
+	for _, d := range drops {
+		data.PriceDrops = append(data.PriceDrops, utils.DigestEmailProduct{
+			Name:          d.Name,
+			Price:         d.Price.Float64(),
+			PreviousPrice: d.PreviousPrice.Float64(),
+			Link:          frontendURL() + "/products/" + d.ProductID.Hex(),
+		})
+	}
+	for _, p := range recommended {
+		data.Recommendations = append(data.Recommendations, utils.DigestEmailProduct{
+			Name:  p.Name,
+			Price: p.EffectivePrice().Float64(),
+			Link:  frontendURL() + "/products/" + p.ID.Hex(),
+		})
+	}
+
+	j.Dispatcher.Dispatch(ctx, w.UserID, OutgoingNotification{
+		Type: models.NotificationDigest,
+		Email: func(user models.User) EmailContent {
+			data.Name = user.Name
+			data.UnsubscribeLink = digestUnsubscribeLink(user.ID)
+			return EmailContent{Template: utils.EmailTemplateBuyerDigest, Data: data}
+		},
+	})
+
+	j.savePriceSnapshot(ctx, w)
+	return true
+}
+
+// savePriceSnapshot records today's prices for next week's drops to be
+// measured from.
+func (j *DigestJob) savePriceSnapshot(ctx context.Context, w models.DigestWishlist) {
+	if err := j.Repo.SaveWishlistPriceSnapshot(ctx, w.UserID, models.WishlistPriceSnapshot(w.Products)); err != nil {
+		logrus.WithError(err).WithField("userId", w.UserID.Hex()).Error("Failed to save wishlist price snapshot")
+	}
+}
+
+// digestUnsubscribeLink points at the frontend page that confirms the
+// unsubscribe through the API.
+func digestUnsubscribeLink(userID primitive.ObjectID) string {
+	token, err := utils.GenerateUnsubscribeToken(userID.Hex(), models.NotificationDigest)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create unsubscribe link")
+		return ""
+	}
+	return frontendURL() + "/unsubscribe?token=" + token
+}
@@ -0,0 +1,783 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DisputeHandler struct {
+	Repo      repository.DisputeRepository
+	OrderRepo repository.OrderRepository
+	TxRepo    repository.TransactionRepository
+	AuditRepo repository.AuditRepository
+	Media     *services.MediaService
+}
+
+func NewDisputeHandler(db *mongo.Database, media *services.MediaService) *DisputeHandler {
+	return &DisputeHandler{
+		Repo:      repository.NewDisputeRepository(db),
+		OrderRepo: repository.NewOrderRepository(db),
+		TxRepo:    repository.NewTransactionRepository(db),
+		AuditRepo: repository.NewAuditRepository(db),
+		Media:     media,
+	}
+}
+
+// UploadEvidence uploads a photo or PDF to attach to a dispute as evidence.
+func (h *DisputeHandler) UploadEvidence(c *gin.Context) {
+	uploadAttachment(c, h.Media, services.MediaDisputeEvidence)
+}
+
+// OpenDispute lets a buyer dispute one vendor's part of a paid order. The
+// vendor's sale funds for the order stop maturing until it is settled.
+func (h *DisputeHandler) OpenDispute(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.OpenDisputeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Description = strings.TrimSpace(input.Description)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if err := services.MediaDisputeEvidence.CheckAttachments(userID, input.Evidence); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid evidence: "+err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.OrderRepo.GetOrderById(ctx, input.OrderID)
+	if err != nil || order.UserID != userID {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	now := time.Now()
+	if err := models.CheckDisputable(order, now); err != nil {
+		c.JSON(http.StatusBadRequest, domainErrorResponse(err))
+		return
+	}
+
+	vendors := orderVendorIDs(order)
+	var vendorID primitive.ObjectID
+	switch {
+	case input.VendorID != nil:
+		if !containsVendor(vendors, *input.VendorID) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("That vendor isn't on this order"))
+			return
+		}
+		vendorID = *input.VendorID
+	case len(vendors) == 1:
+		vendorID = vendors[0]
+	default:
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("This order has several vendors; say which with vendorId"))
+		return
+	}
+
+	limit := models.DisputeClaimLimit(order, vendorID)
+	if limit <= 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Everything paid for this vendor's items has already been refunded"))
+		return
+	}
+	amount := input.Amount
+	if amount == 0 {
+		amount = limit
+	}
+	if amount > limit {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("You can claim at most $%s for this vendor's items", limit)))
+		return
+	}
+
+	dispute, err := h.Repo.CreateDispute(ctx, models.Dispute{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		BuyerID:     userID,
+		VendorID:    vendorID,
+		Reason:      input.Reason,
+		Description: input.Description,
+		Evidence:    input.Evidence,
+		Amount:      amount,
+		Status:      models.DisputeStatusOpen,
+		Messages:    []models.DisputeMessage{},
+		RespondBy:   now.Add(models.DisputeResponseWindow),
+	})
+	if err == repository.ErrDisputeExists {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("You've already disputed this order with this vendor"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to open dispute"))
+		return
+	}
+
+	// Pause the vendor's payout for the order. Funds already released can
+	// still be clawed back if the buyer is refunded.
+	held, err := h.TxRepo.HoldSaleForDispute(ctx, vendorID, order.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("disputeId", dispute.ID.Hex()).Error("Failed to hold vendor funds for dispute")
+	}
+	if held {
+		if updated, err := h.Repo.Transition(ctx, dispute.ID, []string{models.DisputeStatusOpen}, models.DisputeStatusOpen, bson.M{"escrowHeld": true}, nil); err == nil {
+			dispute = *updated
+		}
+	}
+
+	events.Publish(events.DisputeOpened, disputeEventData(dispute))
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Dispute opened", gin.H{"dispute": dispute}))
+}
+
+// GetMyDisputes lists the disputes the buyer has opened, paged with ?page=
+// and ?limit= and filtered by ?status=.
+func (h *DisputeHandler) GetMyDisputes(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	filter := bson.M{"buyerId": userID}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	h.listDisputes(c, filter)
+}
+
+// GetVendorDisputes lists the disputes opened against the vendor, filtered
+// by ?status=.
+func (h *DisputeHandler) GetVendorDisputes(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	filter := bson.M{"vendorId": userID}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+	h.listDisputes(c, filter)
+}
+
+// ListDisputes is the admin queue. It shows escalated disputes unless
+// ?status= asks for another state, or "all", and narrows by ?reason=,
+// ?vendorId=, ?buyerId=, ?orderNumber=, ?awaitingInfo=true and the dates
+// opened, ?from= and ?to= (YYYY-MM-DD).
+func (h *DisputeHandler) ListDisputes(c *gin.Context) {
+	status := c.DefaultQuery("status", models.DisputeStatusEscalated)
+	filter := bson.M{}
+	if status != "all" {
+		filter["status"] = status
+	}
+	if reason := c.Query("reason"); reason != "" {
+		filter["reason"] = reason
+	}
+	for _, key := range []string{"vendorId", "buyerId"} {
+		if v := c.Query(key); v != "" {
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid "+key))
+				return
+			}
+			filter[key] = id
+		}
+	}
+	if orderNumber := strings.TrimSpace(c.Query("orderNumber")); orderNumber != "" {
+		filter["orderNumber"] = orderNumber
+	}
+	if c.Query("awaitingInfo") == "true" {
+		filter["infoRequested.0"] = bson.M{"$exists": true}
+	}
+
+	created := bson.M{}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid from date; use YYYY-MM-DD"))
+			return
+		}
+		created["$gte"] = t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid to date; use YYYY-MM-DD"))
+			return
+		}
+		created["$lt"] = t.AddDate(0, 0, 1)
+	}
+	if len(created) > 0 {
+		filter["createdAt"] = created
+	}
+	h.listDisputes(c, filter)
+}
+
+func (h *DisputeHandler) listDisputes(c *gin.Context, filter bson.M) {
+	page, limit := pageParams(c, 20, 100)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	disputes, total, err := h.Repo.ListDisputes(ctx, filter, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch disputes"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Disputes fetched", gin.H{
+		"disputes": disputes,
+		"meta":     models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetDispute returns a dispute with its thread, to the buyer or vendor on it.
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	dispute, _, ok := h.loadDispute(ctx, c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Dispute fetched", gin.H{"dispute": dispute}))
+}
+
+// GetDisputeCase is the admin's view of a dispute: the full thread with the
+// order it is about, what can still be refunded for the vendor's items and
+// the vendor's money for the order.
+func (h *DisputeHandler) GetDisputeCase(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid dispute ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	dispute, err := h.Repo.GetDispute(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch dispute"))
+		return
+	}
+	if dispute == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Dispute not found"))
+		return
+	}
+	order, err := h.OrderRepo.GetOrderById(ctx, dispute.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch the disputed order"))
+		return
+	}
+	transactions, err := h.TxRepo.GetOrderTransactions(ctx, dispute.VendorID, dispute.OrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch the vendor's transactions"))
+		return
+	}
+
+	vendorItems := []models.OrderItem{}
+	for _, item := range order.Items {
+		if item.VendorID == dispute.VendorID {
+			vendorItems = append(vendorItems, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Dispute fetched", gin.H{
+		"dispute":      dispute,
+		"order":        order,
+		"vendorItems":  vendorItems,
+		"claimLimit":   models.DisputeClaimLimit(order, dispute.VendorID),
+		"transactions": transactions,
+	}))
+}
+
+// RequestDisputeInfo lets an admin ask the buyer, the vendor or both for
+// more before ruling. The request goes in the thread and stays open until
+// each of them replies.
+func (h *DisputeHandler) RequestDisputeInfo(c *gin.Context) {
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid dispute ID"))
+		return
+	}
+	var input models.RequestDisputeInfoInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Body = strings.TrimSpace(input.Body)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	parties := models.DisputeInfoParties(input.From)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.Repo.RequestInfo(ctx, id, parties, models.DisputeMessage{
+		AuthorID: adminID,
+		Role:     models.DisputeRoleAdmin,
+		Body:     input.Body,
+	})
+	if err == repository.ErrDisputeStateChanged {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute isn't active"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to request information"))
+		return
+	}
+
+	recipients := []string{}
+	for _, party := range parties {
+		if party == models.DisputeRoleBuyer {
+			recipients = append(recipients, updated.BuyerID.Hex())
+		} else {
+			recipients = append(recipients, updated.VendorID.Hex())
+		}
+	}
+	h.publishTo(*updated, recipients, models.DisputeRoleAdmin, "info_requested", input.Body)
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditDisputeInfoRequested,
+		TargetType: models.AuditTargetDispute,
+		TargetID:   id,
+		After:      gin.H{"infoRequested": updated.InfoRequested},
+		Reason:     input.Body,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Information requested", gin.H{"dispute": updated}))
+}
+
+// AddDisputeMessage adds to the thread of an active dispute, with more
+// evidence if there is any.
+func (h *DisputeHandler) AddDisputeMessage(c *gin.Context) {
+	input, ok := bindDisputeMessage(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	dispute, role, ok := h.loadDispute(ctx, c)
+	if !ok {
+		return
+	}
+	author := dispute.BuyerID
+	if role == models.DisputeRoleVendor {
+		author = dispute.VendorID
+	}
+
+	updated, err := h.Repo.AddMessage(ctx, dispute.ID, models.DisputeMessage{
+		AuthorID: author,
+		Role:     role,
+		Body:     input.Body,
+		Evidence: input.Evidence,
+	})
+	if err == repository.ErrDisputeStateChanged {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute has been settled"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to add message"))
+		return
+	}
+
+	h.publishUpdate(*updated, role, "message", input.Body)
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Message added", gin.H{"dispute": updated}))
+}
+
+// RespondToDispute is the vendor's answer to an open dispute: accept it, which
+// refunds the buyer what they asked for, or contest it with their side.
+func (h *DisputeHandler) RespondToDispute(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.DisputeResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Body = strings.TrimSpace(input.Body)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if err := services.MediaDisputeEvidence.CheckAttachments(userID, input.Evidence); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid evidence: "+err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	dispute, role, ok := h.loadDispute(ctx, c)
+	if !ok {
+		return
+	}
+	if role != models.DisputeRoleVendor {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Only the vendor can respond to this dispute"))
+		return
+	}
+	if dispute.Status != models.DisputeStatusOpen {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute has already been answered"))
+		return
+	}
+	message := &models.DisputeMessage{AuthorID: dispute.VendorID, Role: role, Body: input.Body, Evidence: input.Evidence}
+
+	if input.Accept {
+		resolution := models.DisputeResolution{
+			Outcome:      models.DisputeOutcomeRefund,
+			RefundAmount: dispute.Amount,
+			Note:         input.Body,
+			DecidedBy:    dispute.VendorID,
+			DecidedRole:  role,
+		}
+		settled, ok := h.settle(ctx, c, *dispute, resolution, message)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, utils.SuccessResponse("Dispute accepted and buyer refunded", gin.H{"dispute": settled}))
+		return
+	}
+
+	updated, err := h.Repo.Transition(ctx, dispute.ID, []string{models.DisputeStatusOpen}, models.DisputeStatusVendorResponded, nil, message)
+	if err == repository.ErrDisputeStateChanged {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute has already been answered"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to respond to dispute"))
+		return
+	}
+
+	h.publishUpdate(*updated, role, "responded", input.Body)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Response sent", gin.H{"dispute": updated}))
+}
+
+// EscalateDispute hands a dispute to an admin to arbitrate. The vendor can
+// escalate any time; the buyer once the vendor has contested it or the
+// response window has passed.
+func (h *DisputeHandler) EscalateDispute(c *gin.Context) {
+	input, ok := bindDisputeMessage(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	dispute, role, ok := h.loadDispute(ctx, c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if !dispute.CanEscalate(role, now) {
+		if dispute.Status == models.DisputeStatusOpen && role == models.DisputeRoleBuyer {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("The vendor has until "+dispute.RespondBy.Format("Jan 2, 15:04 MST")+" to respond"))
+			return
+		}
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute can't be escalated"))
+		return
+	}
+	author := dispute.BuyerID
+	if role == models.DisputeRoleVendor {
+		author = dispute.VendorID
+	}
+
+	updated, err := h.Repo.Transition(ctx, dispute.ID, []string{dispute.Status}, models.DisputeStatusEscalated,
+		bson.M{"escalatedAt": now},
+		&models.DisputeMessage{AuthorID: author, Role: role, Body: input.Body, Evidence: input.Evidence},
+	)
+	if err == repository.ErrDisputeStateChanged {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Dispute status changed; please reload and try again"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to escalate dispute"))
+		return
+	}
+
+	h.publishUpdate(*updated, role, "escalated", input.Body)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Dispute escalated", gin.H{"dispute": updated}))
+}
+
+// WithdrawDispute lets the buyer drop a dispute. The vendor keeps the sale
+// and their held funds are released.
+func (h *DisputeHandler) WithdrawDispute(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	dispute, role, ok := h.loadDispute(ctx, c)
+	if !ok {
+		return
+	}
+	if role != models.DisputeRoleBuyer {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Only the buyer can withdraw this dispute"))
+		return
+	}
+	if !dispute.IsActive() {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute has been settled"))
+		return
+	}
+
+	settled, ok := h.settle(ctx, c, *dispute, models.DisputeResolution{
+		Outcome:     models.DisputeOutcomeRelease,
+		Note:        "Withdrawn by the buyer",
+		DecidedBy:   dispute.BuyerID,
+		DecidedRole: role,
+	}, nil)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Dispute withdrawn", gin.H{"dispute": settled}))
+}
+
+// ResolveDispute records an admin's binding ruling on a dispute and carries
+// it out: refunding the buyer in full or in part (a split), or releasing the
+// vendor's held funds. Whatever isn't refunded stays with the vendor.
+func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid dispute ID"))
+		return
+	}
+	var input models.ResolveDisputeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	dispute, err := h.Repo.GetDispute(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch dispute"))
+		return
+	}
+	if dispute == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Dispute not found"))
+		return
+	}
+	if !dispute.IsActive() {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute has already been settled"))
+		return
+	}
+
+	resolution := models.DisputeResolution{
+		Outcome:     input.Outcome,
+		Note:        input.Note,
+		DecidedBy:   adminID,
+		DecidedRole: models.DisputeRoleAdmin,
+	}
+	switch input.Outcome {
+	case models.DisputeOutcomeRefund:
+		resolution.RefundAmount = input.Amount
+		if resolution.RefundAmount == 0 {
+			resolution.RefundAmount = dispute.Amount
+		}
+	case models.DisputeOutcomeSplit:
+		resolution.RefundAmount = input.Amount
+		if resolution.RefundAmount <= 0 || resolution.RefundAmount >= dispute.Amount {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("A split refunds the buyer more than $0 and less than the $%s they claimed", dispute.Amount)))
+			return
+		}
+	}
+
+	settled, ok := h.settle(ctx, c, *dispute, resolution, &models.DisputeMessage{
+		AuthorID: adminID,
+		Role:     models.DisputeRoleAdmin,
+		Body:     input.Note,
+	})
+	if !ok {
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditDisputeResolved,
+		TargetType: models.AuditTargetDispute,
+		TargetID:   id,
+		Before:     gin.H{"status": dispute.Status, "amount": dispute.Amount},
+		After:      gin.H{"status": settled.Status, "outcome": resolution.Outcome, "refundAmount": resolution.RefundAmount},
+		Reason:     input.Note,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Dispute resolved", gin.H{"dispute": settled}))
+}
+
+// settle closes an active dispute with resolution and carries it out. The
+// dispute is claimed first so it can only be settled once; if the refund
+// then fails it goes back to where it was. Either way the vendor's hold is
+// lifted once it is settled. It writes the error response itself.
+func (h *DisputeHandler) settle(ctx context.Context, c *gin.Context, dispute models.Dispute, resolution models.DisputeResolution, message *models.DisputeMessage) (*models.Dispute, bool) {
+	var order models.Order
+	if resolution.RefundsBuyer() {
+		var err error
+		order, err = h.OrderRepo.GetOrderById(ctx, dispute.OrderID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+			return nil, false
+		}
+		if !isRefundable(order) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("This order has no captured payment left to refund"))
+			return nil, false
+		}
+		if limit := models.DisputeClaimLimit(order, dispute.VendorID); resolution.RefundAmount > limit {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("Refund cannot exceed the $%s paid for this vendor's items", limit)))
+			return nil, false
+		}
+	}
+
+	// 1. Claim the dispute
+	resolution.DecidedAt = time.Now()
+	settled, err := h.Repo.Transition(ctx, dispute.ID, models.DisputeActiveStatuses, models.DisputeStatusResolved,
+		bson.M{"resolution": resolution, "infoRequested": []string{}}, message)
+	if err == repository.ErrDisputeStateChanged {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This dispute has already been settled"))
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to settle dispute"))
+		return nil, false
+	}
+
+	// 2. Refund the buyer, taking it back from this vendor alone
+	if resolution.RefundsBuyer() {
+		shares := map[primitive.ObjectID]models.Money{
+			dispute.VendorID: models.DisputeClawback(order, dispute.VendorID, resolution.RefundAmount),
+		}
+		if _, err := refundPayment(ctx, h.OrderRepo, h.TxRepo, order, resolution.RefundAmount, "Dispute: "+strings.ReplaceAll(dispute.Reason, "_", " "), models.ActingUser(resolution.DecidedRole, resolution.DecidedBy), shares); err != nil {
+			logrus.WithError(err).WithField("disputeId", dispute.ID.Hex()).Error("Dispute refund failed")
+			reopen := bson.M{"resolution": nil, "infoRequested": append([]string{}, dispute.InfoRequested...)}
+			if _, revErr := h.Repo.Transition(context.Background(), dispute.ID, []string{models.DisputeStatusResolved}, dispute.Status, reopen, nil); revErr != nil {
+				logrus.WithError(revErr).WithField("disputeId", dispute.ID.Hex()).Error("Failed to reopen dispute after refund failure")
+			}
+			if err == repository.ErrRefundExceedsTotal {
+				c.JSON(http.StatusConflict, utils.ErrorResponse("Another refund was issued on this order; please reload and try again"))
+				return nil, false
+			}
+			c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to issue refund"))
+			return nil, false
+		}
+	}
+
+	// 3. Let the vendor's remaining funds mature
+	if err := h.TxRepo.ReleaseDisputeHold(ctx, dispute.VendorID, dispute.OrderID); err != nil {
+		logrus.WithError(err).WithField("disputeId", dispute.ID.Hex()).Error("Failed to release dispute hold")
+	} else if err := h.TxRepo.MaturateFunds(ctx, dispute.VendorID); err != nil {
+		logrus.WithError(err).WithField("vendorId", dispute.VendorID.Hex()).Warn("Failed to mature funds after dispute")
+	}
+
+	data := disputeEventData(*settled)
+	data["outcome"] = resolution.Outcome
+	data["refundAmount"] = resolution.RefundAmount.Float64()
+	data["decidedRole"] = resolution.DecidedRole
+	events.Publish(events.DisputeResolved, data)
+	return settled, true
+}
+
+// loadDispute fetches the dispute in the path and checks the user is its
+// buyer or vendor, returning which. It writes the error response itself.
+func (h *DisputeHandler) loadDispute(ctx context.Context, c *gin.Context) (*models.Dispute, string, bool) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid dispute ID"))
+		return nil, "", false
+	}
+	dispute, err := h.Repo.GetDispute(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch dispute"))
+		return nil, "", false
+	}
+	role := ""
+	if dispute != nil {
+		role = dispute.RoleOf(userID)
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Dispute not found"))
+		return nil, "", false
+	}
+	return dispute, role, true
+}
+
+// publishUpdate tells the other side of the dispute that role did something
+// to it.
+func (h *DisputeHandler) publishUpdate(dispute models.Dispute, role, action, body string) {
+	recipient := dispute.VendorID
+	if role == models.DisputeRoleVendor {
+		recipient = dispute.BuyerID
+	}
+	h.publishTo(dispute, []string{recipient.Hex()}, role, action, body)
+}
+
+func (h *DisputeHandler) publishTo(dispute models.Dispute, recipients []string, role, action, body string) {
+	data := disputeEventData(dispute)
+	data["action"] = action
+	data["actorRole"] = role
+	data["recipientId"] = recipients
+	data["preview"] = models.MessagePreviewText(body)
+	events.Publish(events.DisputeUpdated, data)
+}
+
+// bindDisputeMessage reads a message for a dispute's thread, checking any
+// evidence was uploaded by the sender.
+func bindDisputeMessage(c *gin.Context) (models.DisputeMessageInput, bool) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.DisputeMessageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return input, false
+	}
+	input.Body = strings.TrimSpace(input.Body)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return input, false
+	}
+	if err := services.MediaDisputeEvidence.CheckAttachments(userID, input.Evidence); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid evidence: "+err.Error()))
+		return input, false
+	}
+	return input, true
+}
+
+func disputeEventData(d models.Dispute) map[string]interface{} {
+	return map[string]interface{}{
+		"disputeId":   d.ID.Hex(),
+		"orderId":     d.OrderID.Hex(),
+		"orderNumber": d.OrderNumber,
+		"buyerId":     d.BuyerID.Hex(),
+		"vendorId":    d.VendorID.Hex(),
+		"reason":      d.Reason,
+		"status":      d.Status,
+		"amount":      d.Amount.Float64(),
+		"respondBy":   d.RespondBy,
+	}
+}
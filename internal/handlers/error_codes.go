@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+)
+
+// domainErrorCodes are the codes clients get for the domain errors handlers
+// report as they are.
+var domainErrorCodes = []struct {
+	err  error
+	code utils.ErrorCode
+}{
+	{models.ErrOutOfStock, utils.CodeCartItemOutOfStock},
+	{models.ErrProductUnavailable, utils.CodeCartItemUnavailable},
+	{models.ErrUndeliverableAddress, utils.CodeAddressUndeliverable},
+	{models.ErrNotShippable, utils.CodeShippingUnavailable},
+	{models.ErrUnknownShippingMethod, utils.CodeShippingMethodUnknown},
+	{models.ErrUnknownPickupLocation, utils.CodePickupLocationUnknown},
+	{models.ErrCouponNotFound, utils.CodeCouponNotFound},
+	{models.ErrCouponInactive, utils.CodeCouponInactive},
+	{models.ErrCouponUsedUp, utils.CodeCouponUsedUp},
+	{models.ErrCouponUserLimit, utils.CodeCouponUserLimit},
+	{models.ErrCouponMinSpend, utils.CodeCouponMinSpend},
+	{models.ErrCouponNotApplicable, utils.CodeCouponNotApplicable},
+	{models.ErrFlashSaleNotFound, utils.CodeFlashSaleNotFound},
+	{models.ErrFlashSaleNotLive, utils.CodeFlashSaleNotLive},
+	{models.ErrFlashSaleSoldOut, utils.CodeFlashSaleSoldOut},
+	{models.ErrFlashSaleUserLimit, utils.CodeFlashSaleUserLimit},
+	{models.ErrDisputeNotPaid, utils.CodeDisputeNotPaid},
+	{models.ErrDisputeWindowClosed, utils.CodeDisputeWindowClosed},
+}
+
+// domainErrorResponse reports err, whose message is meant for the buyer,
+// with its code from the catalog.
+func domainErrorResponse(err error) utils.Response {
+	for _, known := range domainErrorCodes {
+		if errors.Is(err, known.err) {
+			return utils.CodedErrorResponse(known.code, err.Error())
+		}
+	}
+	return utils.ErrorResponse(err.Error())
+}
@@ -0,0 +1,628 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// exportLease is how long a worker has to build an export before
+	// another may take it over.
+	exportLease = 15 * time.Minute
+	// exportPollInterval is how often the worker looks for queued exports.
+	exportPollInterval = 5 * time.Second
+	// exportSweepInterval is how often expired export files are deleted.
+	exportSweepInterval = 10 * time.Minute
+)
+
+// ExportSource produces the rows of one exportable endpoint. Check, if set,
+// rejects bad parameters when the export is requested rather than when it
+// runs; Build runs in the worker with the parameters the endpoint was called
+// with.
+type ExportSource struct {
+	Check func(params map[string]string) error
+	Build func(ctx context.Context, job models.ExportJob) (models.ExportTable, error)
+}
+
+// ExportHandler turns requests to exportable endpoints into background
+// jobs that write their results to a CSV or XLSX file, so big reports don't
+// have to be built and sent in one response.
+type ExportHandler struct {
+	DB            *mongo.Database
+	Repo          repository.ExportRepository
+	AnalyticsRepo repository.AnalyticsRepository
+	OrderRepo     repository.OrderRepository
+	ProductRepo   repository.ProductRepository
+	Media         *services.MediaService
+	Sources       map[string]ExportSource
+}
+
+func NewExportHandler(db *mongo.Database, media *services.MediaService) *ExportHandler {
+	h := &ExportHandler{
+		DB:            db,
+		Repo:          repository.NewExportRepository(db),
+		AnalyticsRepo: repository.NewAnalyticsRepository(db),
+		OrderRepo:     repository.NewOrderRepository(db),
+		ProductRepo:   repository.NewProductRepository(db),
+		Media:         media,
+	}
+	h.Sources = map[string]ExportSource{
+		"admin.orders":                    {Check: checkOrderDates, Build: h.exportAdminOrders},
+		"admin.analytics.overview":        {Check: checkStatsRange, Build: h.exportPlatformAnalytics},
+		"admin.analytics.categories":      {Check: checkStatsRange, Build: h.exportCategoryAnalytics},
+		"admin.analytics.cohorts":         {Check: checkCohortRange, Build: h.exportCohorts},
+		"admin.analytics.checkout_funnel": {Check: checkDashboardWindow, Build: h.exportCheckoutFunnel},
+		"vendor.stats":                    {Check: checkStatsRange, Build: h.exportVendorStats},
+		"vendor.inventory":                {Check: checkDashboardWindow, Build: h.exportInventory},
+	}
+	return h
+}
+
+// Exportable lets the endpoint it wraps be exported as source: called with
+// ?export=csv or ?export=xlsx, the request is queued as an export of the
+// same query instead of being answered, and 202 is returned with the job to
+// poll at GET /exports/:id. Without ?export= the endpoint runs as usual.
+func (h *ExportHandler) Exportable(source string) gin.HandlerFunc {
+	src, ok := h.Sources[source]
+	if !ok {
+		panic("unknown export source " + source)
+	}
+	return func(c *gin.Context) {
+		format := c.Query("export")
+		if format == "" {
+			c.Next()
+			return
+		}
+		if _, ok := models.ExportContentTypes[format]; !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, utils.ErrorResponse("export must be csv or xlsx"))
+			return
+		}
+
+		params := map[string]string{}
+		for key, values := range c.Request.URL.Query() {
+			if key != "export" && key != "refresh" && len(values) > 0 {
+				params[key] = values[0]
+			}
+		}
+		if src.Check != nil {
+			if err := src.Check(params); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+				return
+			}
+		}
+
+		userIdStr, _ := c.Get("userId")
+		userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+		role, _ := c.Get("role")
+		roleStr, _ := role.(string)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		job := &models.ExportJob{
+			Source:      source,
+			Format:      format,
+			Params:      params,
+			RequestedBy: userID,
+			Role:        roleStr,
+		}
+		if err := h.Repo.Create(ctx, job); err != nil {
+			if errors.Is(err, repository.ErrTooManyExports) {
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, utils.ErrorResponse(fmt.Sprintf("You already have %d exports in progress; wait for one to finish", models.ExportMaxPending)))
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to queue export"))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusAccepted, utils.SuccessResponse("Export queued", gin.H{"export": job}))
+	}
+}
+
+// ListExports returns the caller's recent exports, newest first.
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	jobs, err := h.Repo.ListForUser(ctx, userID, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch exports"))
+		return
+	}
+	for i := range jobs {
+		withDownloadURL(&jobs[i])
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Exports fetched", gin.H{"exports": jobs}))
+}
+
+// GetExport returns one of the caller's exports and, once it is ready, a
+// signed link to download it until it expires.
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid export ID"))
+		return
+	}
+	userIdStr, _ := c.Get("userId")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.Repo.Get(ctx, id)
+	if err != nil || job.RequestedBy.Hex() != userIdStr.(string) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Export not found"))
+		return
+	}
+	withDownloadURL(job)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Export fetched", gin.H{"export": job}))
+}
+
+// withDownloadURL signs a download link for job if it is ready.
+func withDownloadURL(job *models.ExportJob) {
+	if job.Status != models.ExportStatusReady || job.ExpiresAt == nil {
+		return
+	}
+	token, err := utils.GenerateExportToken(job.ID.Hex(), *job.ExpiresAt)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to sign export download link")
+		return
+	}
+	job.DownloadURL = "/api/v1/exports/download/" + token
+}
+
+// exportDownloadClient fetches export files from storage.
+var exportDownloadClient = &http.Client{Timeout: 2 * time.Minute}
+
+// DownloadExport streams the file of a ready export. The signed token in
+// the link is the only credential, so it can be opened outside the app.
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	exportID, err := utils.VerifyExportToken(c.Param("token"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse(utils.ErrInvalidExportToken.Error()))
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(exportID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse(utils.ErrInvalidExportToken.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	job, err := h.Repo.Get(ctx, id)
+	if err != nil || job.Status != models.ExportStatusReady || job.FileURL == "" {
+		c.JSON(http.StatusGone, utils.ErrorResponse("This export is no longer available"))
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, job.FileURL, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch export"))
+		return
+	}
+	resp, err := exportDownloadClient.Do(req)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to fetch export"))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logrus.WithField("exportId", exportID).WithField("status", resp.StatusCode).Error("Export file missing from storage")
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to fetch export"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.FileName))
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, resp.ContentLength, models.ExportContentTypes[job.Format], resp.Body, nil)
+}
+
+// StartWorker builds queued exports one at a time until ctx is done, and
+// deletes the files of exports past their expiry. Claims are leased, so
+// several instances can run workers side by side.
+func (h *ExportHandler) StartWorker(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(exportPollInterval)
+		defer ticker.Stop()
+		var lastSweep time.Time
+		for {
+			for h.runNextExport(ctx) {
+			}
+			if time.Since(lastSweep) >= exportSweepInterval {
+				h.expireExports(ctx)
+				lastSweep = time.Now()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runNextExport builds the next queued export, if any, and reports whether
+// there was one.
+func (h *ExportHandler) runNextExport(ctx context.Context) bool {
+	job, err := h.Repo.Claim(ctx, exportLease)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to claim export")
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	log := logrus.WithField("exportId", job.ID.Hex()).WithField("source", job.Source)
+	if err := h.runExport(ctx, job); err != nil {
+		final := job.Attempts >= models.ExportMaxAttempts
+		log.WithError(err).WithField("final", final).Error("Failed to build export")
+		if err := h.Repo.MarkFailed(ctx, job.ID, err, final); err != nil {
+			log.WithError(err).Error("Failed to record export failure")
+		}
+	}
+	return true
+}
+
+func (h *ExportHandler) runExport(ctx context.Context, job *models.ExportJob) error {
+	ctx, cancel := context.WithTimeout(ctx, exportLease)
+	defer cancel()
+
+	src, ok := h.Sources[job.Source]
+	if !ok {
+		return fmt.Errorf("unknown export source %q", job.Source)
+	}
+	table, err := src.Build(ctx, *job)
+	if err != nil {
+		return err
+	}
+	if len(table.Rows) > models.ExportMaxRows {
+		return fmt.Errorf("export has more than %d rows; narrow the filters", models.ExportMaxRows)
+	}
+
+	var file bytes.Buffer
+	if err := table.Write(&file, job.Format); err != nil {
+		return err
+	}
+	size := int64(file.Len())
+	link, err := h.Media.Store(ctx, services.MediaExport, job.RequestedBy, &file, models.ExportContentTypes[job.Format], size)
+	if err != nil {
+		return fmt.Errorf("store export: %w", err)
+	}
+
+	now := time.Now()
+	expires := now.Add(models.ExportTTL)
+	job.FileURL = link
+	job.FileName = job.ExportFileName()
+	job.Size = size
+	job.Rows = len(table.Rows)
+	job.ExpiresAt = &expires
+	job.CompletedAt = &now
+	return h.Repo.MarkReady(ctx, *job)
+}
+
+// expireExports deletes the files of exports whose download window has
+// closed.
+func (h *ExportHandler) expireExports(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	jobs, err := h.Repo.ListExpired(ctx, time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list expired exports")
+		return
+	}
+	for _, job := range jobs {
+		log := logrus.WithField("exportId", job.ID.Hex())
+		if err := h.Media.Delete(ctx, job.FileURL); err != nil && !errors.Is(err, services.ErrMediaNotStored) {
+			log.WithError(err).Error("Failed to delete expired export")
+			continue
+		}
+		if err := h.Repo.MarkExpired(ctx, job.ID); err != nil {
+			log.WithError(err).Error("Failed to mark export expired")
+		}
+	}
+}
+
+func checkStatsRange(params map[string]string) error {
+	_, _, _, err := ParseStatsRange(params["window"], params["from"], params["to"], params["granularity"], time.Now())
+	return err
+}
+
+func checkDashboardWindow(params map[string]string) error {
+	_, _, _, err := ParseDashboardWindow(params["window"], params["from"], params["to"], time.Now())
+	return err
+}
+
+func checkCohortRange(params map[string]string) error {
+	if _, _, err := ParseCohortRange(params["from"], params["to"], time.Now()); err != nil {
+		return err
+	}
+	if segment := params["segment"]; segment != "" && segment != models.CohortSegmentInterests && segment != models.CohortSegmentOnboarding {
+		return fmt.Errorf("segment must be interests or onboarding")
+	}
+	return nil
+}
+
+func checkOrderDates(params map[string]string) error {
+	_, err := orderDatesFilter(params)
+	return err
+}
+
+// orderDatesFilter is the createdAt condition for ?from=/?to= (YYYY-MM-DD,
+// to exclusive); empty if neither is given.
+func orderDatesFilter(params map[string]string) (bson.M, error) {
+	created := bson.M{}
+	for param, op := range map[string]string{"from": "$gte", "to": "$lt"} {
+		if value := params[param]; value != "" {
+			t, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid '%s' date; use YYYY-MM-DD", param)
+			}
+			created[op] = t
+		}
+	}
+	return created, nil
+}
+
+// exportOrderRow is one line of the admin orders export.
+type exportOrderRow struct {
+	OrderNumber     string             `json:"orderNumber"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	BuyerID         primitive.ObjectID `json:"buyerId"`
+	BuyerName       string             `json:"buyerName"`
+	Status          models.OrderStatus `json:"status"`
+	PaymentStatus   string             `json:"paymentStatus"`
+	PaymentMethod   string             `json:"paymentMethod"`
+	Items           int                `json:"items"`
+	Subtotal        models.Money       `json:"subTotal"`
+	Tax             models.Money       `json:"tax"`
+	ShippingFee     models.Money       `json:"shippingFee"`
+	Total           models.Money       `json:"total"`
+	TrackingNumber  string             `json:"trackingNumber"`
+	ShippingAddress string             `json:"shippingAddress"`
+}
+
+// exportAdminOrders exports the orders GET /admin/orders lists, with the
+// same ?status= filter and optionally ?from=/?to= (YYYY-MM-DD, to
+// exclusive) on when they were placed.
+func (h *ExportHandler) exportAdminOrders(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	filter := bson.M{}
+	if status := job.Params["status"]; status != "" && status != "all" {
+		filter["status"] = status
+	}
+	created, err := orderDatesFilter(job.Params)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	if len(created) > 0 {
+		filter["createdAt"] = created
+	}
+
+	// One more than the cap, so an export that would be cut short fails
+	// instead
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(models.ExportMaxRows + 1)
+	cursor, err := h.DB.Collection("orders").Find(ctx, filter, opts)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	var orders []models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return models.ExportTable{}, err
+	}
+
+	buyerIDs := make([]primitive.ObjectID, 0, len(orders))
+	for _, o := range orders {
+		buyerIDs = append(buyerIDs, o.UserID)
+	}
+	names := map[primitive.ObjectID]string{}
+	cursor, err = h.DB.Collection("users").Find(ctx, bson.M{"_id": bson.M{"$in": buyerIDs}}, options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return models.ExportTable{}, err
+	}
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+
+	rows := make([]exportOrderRow, 0, len(orders))
+	for _, o := range orders {
+		items := 0
+		for _, item := range o.Items {
+			items += item.Quantity
+		}
+		rows = append(rows, exportOrderRow{
+			OrderNumber:     o.OrderNumber,
+			CreatedAt:       o.CreatedAt,
+			BuyerID:         o.UserID,
+			BuyerName:       names[o.UserID],
+			Status:          o.Status,
+			PaymentStatus:   o.PaymentStatus,
+			PaymentMethod:   o.PaymentMethod,
+			Items:           items,
+			Subtotal:        o.Subtotal,
+			Tax:             o.Tax,
+			ShippingFee:     o.ShippingFee,
+			Total:           o.Total,
+			TrackingNumber:  o.TrackingNumber,
+			ShippingAddress: o.ShippingAddress,
+		})
+	}
+	return models.TableOf(rows)
+}
+
+// exportPlatformAnalytics exports the series of GET
+// /admin/analytics/overview, one row per bucket.
+func (h *ExportHandler) exportPlatformAnalytics(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	from, to, granularity, err := ParseStatsRange(job.Params["window"], job.Params["from"], job.Params["to"], job.Params["granularity"], job.CreatedAt)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	days, err := h.AnalyticsRepo.ListPlatformDays(ctx, from, to)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	return models.TableOf(models.RollUpPlatformDays(days, from, to, granularity).Series)
+}
+
+// exportCategoryAnalytics exports every category's sales per bucket of GET
+// /admin/analytics/categories, one row per category and bucket.
+func (h *ExportHandler) exportCategoryAnalytics(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	from, to, granularity, err := ParseStatsRange(job.Params["window"], job.Params["from"], job.Params["to"], job.Params["granularity"], job.CreatedAt)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	limit, _ := strconv.Atoi(job.Params["limit"])
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	days, err := h.AnalyticsRepo.ListPlatformDays(ctx, from, to)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+
+	type row struct {
+		Start time.Time `json:"start"`
+		models.CategorySales
+	}
+	var rows []row
+	for _, period := range models.RollUpCategoryDays(days, from, to, granularity, limit).Series {
+		for _, category := range period.Categories {
+			rows = append(rows, row{Start: period.Start, CategorySales: category})
+		}
+	}
+	return models.TableOf(rows)
+}
+
+// exportCohorts exports GET /admin/analytics/cohorts, one row per cohort
+// with its retention rate a column per month since signup.
+func (h *ExportHandler) exportCohorts(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	from, to, err := ParseCohortRange(job.Params["from"], job.Params["to"], job.CreatedAt)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	counts, activity, err := h.AnalyticsRepo.ListCohorts(ctx, from, to, job.Params["segment"])
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	return cohortTable(models.BuildCohorts(counts, activity, job.CreatedAt)), nil
+}
+
+func cohortTable(cohorts []models.Cohort) models.ExportTable {
+	months := 0
+	for _, cohort := range cohorts {
+		if len(cohort.Retention) > months {
+			months = len(cohort.Retention)
+		}
+	}
+	table := models.ExportTable{Columns: []string{"month", "segment", "users", "buyers", "repeatBuyers", "conversionRate", "repeatRate"}}
+	for m := 0; m < months; m++ {
+		table.Columns = append(table.Columns, fmt.Sprintf("month%dRate", m))
+	}
+	// Numbers are json.Numbers, as TableOf leaves them
+	number := func(v interface{}) interface{} { return json.Number(fmt.Sprint(v)) }
+	for _, cohort := range cohorts {
+		row := []interface{}{cohort.Month, cohort.Segment, number(cohort.Users), number(cohort.Buyers), number(cohort.RepeatBuyers), number(cohort.ConversionRate), number(cohort.RepeatRate)}
+		for m := 0; m < months; m++ {
+			if m < len(cohort.Retention) {
+				row = append(row, number(cohort.Retention[m].Rate))
+			} else {
+				row = append(row, nil)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// exportCheckoutFunnel exports GET /admin/analytics/checkout-funnel, the
+// overall steps first and then each provider's.
+func (h *ExportHandler) exportCheckoutFunnel(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	_, from, to, err := ParseDashboardWindow(job.Params["window"], job.Params["from"], job.Params["to"], job.CreatedAt)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	overall, byProvider, err := h.AnalyticsRepo.ListCheckoutReach(ctx, from, to)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	funnel := models.BuildCheckoutFunnel(overall, byProvider, from, to)
+
+	type row struct {
+		Provider string `json:"provider"`
+		models.FunnelStep
+	}
+	var rows []row
+	for _, step := range funnel.Steps {
+		rows = append(rows, row{Provider: "all", FunnelStep: step})
+	}
+	for _, provider := range funnel.Providers {
+		for _, step := range provider.Steps {
+			rows = append(rows, row{Provider: provider.Provider, FunnelStep: step})
+		}
+	}
+	return models.TableOf(rows)
+}
+
+// exportVendorStats exports the sales series of GET /vendor/orders/stats
+// for the vendor who asked, one row per bucket.
+func (h *ExportHandler) exportVendorStats(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	query, err := ParseVendorStatsQuery(job.Params["window"], job.Params["from"], job.Params["to"], job.Params["granularity"], job.CreatedAt)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	stats, err := h.OrderRepo.GetVendorStats(ctx, job.RequestedBy, query)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+
+	type row struct {
+		Start         time.Time    `json:"start"`
+		Revenue       models.Money `json:"revenue"`
+		Orders        int          `json:"orders"`
+		UnitsSold     int          `json:"unitsSold"`
+		AvgOrderValue models.Money `json:"avgOrderValue"`
+	}
+	rows := make([]row, 0, len(stats.Sales))
+	for _, bucket := range stats.Sales {
+		rows = append(rows, row{bucket.Start, bucket.Revenue, bucket.Orders, bucket.UnitsSold, bucket.AvgOrderValue})
+	}
+	return models.TableOf(rows)
+}
+
+// exportInventory exports every product of GET /vendor/reports/inventory
+// for the vendor who asked.
+func (h *ExportHandler) exportInventory(ctx context.Context, job models.ExportJob) (models.ExportTable, error) {
+	_, from, to, err := ParseDashboardWindow(job.Params["window"], job.Params["from"], job.Params["to"], job.CreatedAt)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	report, err := buildInventoryReport(ctx, h.ProductRepo, h.OrderRepo, job.RequestedBy, from, to)
+	if err != nil {
+		return models.ExportTable{}, err
+	}
+	return models.TableOf(report.Products)
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// flashSaleHorizon is how far ahead the public list shows upcoming sales.
+const flashSaleHorizon = 7 * 24 * time.Hour
+
+// The public list is hit hardest just as a sale opens, so it is held for a
+// few seconds; remaining stock in it can be that stale, but claims at
+// checkout always check the live counters.
+var flashSaleListCache = utils.NewTTLCache[[]models.FlashSale](5 * time.Second)
+
+type FlashSaleHandler struct {
+	Repo        repository.FlashSaleRepository
+	ProductRepo repository.ProductRepository
+}
+
+func NewFlashSaleHandler(db *mongo.Database) *FlashSaleHandler {
+	return &FlashSaleHandler{
+		Repo:        repository.NewFlashSaleRepository(db),
+		ProductRepo: repository.NewProductRepository(db),
+	}
+}
+
+// GetPublicFlashSales lists flash sales that are live or start within the
+// next week, each with a countdown. Filter with ?state=live or
+// ?state=upcoming; ?limit= defaults to 20.
+func (h *FlashSaleHandler) GetPublicFlashSales(c *gin.Context) {
+	state := c.Query("state")
+	if state != "" && state != models.FlashSaleLive && state != models.FlashSaleUpcoming {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("state must be live or upcoming"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	sales, ok := flashSaleListCache.Get("")
+	if !ok {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		var err error
+		sales, err = h.Repo.ListPublicFlashSales(ctx, now, now.Add(flashSaleHorizon), 100)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch flash sales"))
+			return
+		}
+		flashSaleListCache.Set("", sales)
+	}
+
+	now := time.Now()
+	views := []models.FlashSaleView{}
+	for _, sale := range sales {
+		view := sale.View(now)
+		if view.State == models.FlashSaleEnded || (state != "" && view.State != state) {
+			continue
+		}
+		views = append(views, view)
+		if len(views) == limit {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Flash sales fetched", gin.H{"flashSales": views, "serverTime": now}))
+}
+
+// GetVendorFlashSales lists the vendor's flash sales, latest first.
+func (h *FlashSaleHandler) GetVendorFlashSales(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sales, err := h.Repo.GetVendorFlashSales(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch flash sales"))
+		return
+	}
+	now := time.Now()
+	views := make([]models.FlashSaleView, 0, len(sales))
+	for _, sale := range sales {
+		views = append(views, sale.View(now))
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Flash sales fetched", gin.H{"flashSales": views}))
+}
+
+// CreateFlashSale sets up a flash sale on one of the vendor's products. A
+// product can only have one flash sale at a time.
+func (h *FlashSaleHandler) CreateFlashSale(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.FlashSaleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if !input.EndsAt.After(input.StartsAt) || !input.EndsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A flash sale must end after it starts, and in the future"))
+		return
+	}
+	if input.EndsAt.Sub(input.StartsAt) > models.MaxFlashSaleDuration {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A flash sale can run for at most 48 hours"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	product, err := h.ProductRepo.GetProduct(ctx, bson.M{"_id": input.ProductID, "vendorId": vendorID})
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Product not found"))
+		return
+	}
+	if input.Price >= product.Price {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("The flash price must be below the product's price"))
+		return
+	}
+	if input.Quantity > product.Stock {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You don't have that many in stock"))
+		return
+	}
+	overlapping, err := h.Repo.HasOverlappingFlashSale(ctx, product.ID, input.StartsAt, input.EndsAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check for other flash sales"))
+		return
+	}
+	if overlapping {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This product already has a flash sale at that time"))
+		return
+	}
+
+	sale := models.FlashSale{
+		VendorID:      vendorID,
+		ProductID:     product.ID,
+		ProductName:   product.Name,
+		Price:         input.Price,
+		OriginalPrice: product.Price,
+		Quantity:      input.Quantity,
+		PerUserLimit:  input.PerUserLimit,
+		StartsAt:      input.StartsAt,
+		EndsAt:        input.EndsAt,
+	}
+	if len(product.Images) > 0 {
+		sale.Image = product.Images[0]
+	}
+	created, err := h.Repo.CreateFlashSale(ctx, sale)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create flash sale"))
+		return
+	}
+	flashSaleListCache.Delete("")
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Flash sale scheduled", gin.H{"flashSale": created.View(time.Now())}))
+}
+
+// CancelFlashSale calls off one of the vendor's flash sales.
+func (h *FlashSaleHandler) CancelFlashSale(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid flash sale ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Repo.CancelFlashSale(ctx, vendorID, id); errors.Is(err, models.ErrFlashSaleNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("No running or upcoming flash sale with that ID"))
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to cancel flash sale"))
+		return
+	}
+	flashSaleListCache.Delete("")
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Flash sale cancelled", nil))
+}
+
+// checkFlashSale makes sure the buyer can have quantity units of a product
+// at the flash sale's price in all, counting what they have already bought
+// from it. It returns one of the models.ErrFlashSale* errors when not.
+func checkFlashSale(ctx context.Context, repo repository.FlashSaleRepository, saleID, productID, userID primitive.ObjectID, quantity int) (*models.FlashSale, error) {
+	sale, err := repo.GetFlashSale(ctx, saleID)
+	if err != nil {
+		return nil, err
+	}
+	if sale == nil || sale.ProductID != productID {
+		return nil, models.ErrFlashSaleNotFound
+	}
+	switch sale.State(time.Now()) {
+	case models.FlashSaleSoldOut:
+		return nil, models.ErrFlashSaleSoldOut
+	case models.FlashSaleLive:
+	default:
+		return nil, models.ErrFlashSaleNotLive
+	}
+	if quantity > sale.Remaining() {
+		return nil, models.ErrFlashSaleSoldOut
+	}
+	if sale.PerUserLimit > 0 {
+		bought, err := repo.GetUserPurchased(ctx, sale.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := sale.CheckLimit(bought, quantity); err != nil {
+			return nil, err
+		}
+	}
+	return sale, nil
+}
+
+func isFlashSaleError(err error) bool {
+	for _, target := range []error{
+		models.ErrFlashSaleNotFound,
+		models.ErrFlashSaleNotLive,
+		models.ErrFlashSaleSoldOut,
+		models.ErrFlashSaleUserLimit,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// flashSaleClaim is units claimed from a flash sale for an order being
+// placed.
+type flashSaleClaim struct {
+	Sale     models.FlashSale
+	Quantity int
+}
+
+// claimFlashSales claims the units of every flash sale line in the cart,
+// setting each line to its sale's price. If any can't be had, the ones
+// already claimed are given back and the error names the product.
+func claimFlashSales(ctx context.Context, repo repository.FlashSaleRepository, userID primitive.ObjectID, items []models.CartItem) ([]flashSaleClaim, error) {
+	var claims []flashSaleClaim
+	for i, item := range items {
+		if item.FlashSaleID == nil {
+			continue
+		}
+		sale, err := repo.GetFlashSale(ctx, *item.FlashSaleID)
+		if err == nil && (sale == nil || sale.ProductID != item.ProductID) {
+			err = models.ErrFlashSaleNotFound
+		}
+		if err == nil {
+			err = repo.ClaimFlashSale(ctx, *sale, userID, item.Quantity)
+		}
+		if err != nil {
+			releaseFlashSales(repo, userID, claims)
+			if isFlashSaleError(err) {
+				return nil, fmt.Errorf("%s: %w", item.Name, err)
+			}
+			return nil, err
+		}
+		items[i].Price = sale.Price
+		claims = append(claims, flashSaleClaim{Sale: *sale, Quantity: item.Quantity})
+	}
+	return claims, nil
+}
+
+// releaseFlashSales gives back units claimed for an order that wasn't
+// placed.
+func releaseFlashSales(repo repository.FlashSaleRepository, userID primitive.ObjectID, claims []flashSaleClaim) {
+	for _, claim := range claims {
+		if err := repo.ReleaseFlashSale(context.Background(), claim.Sale, userID, claim.Quantity); err != nil {
+			logrus.WithError(err).WithField("flashSaleId", claim.Sale.ID.Hex()).Error("Failed to release flash sale units")
+		}
+	}
+}
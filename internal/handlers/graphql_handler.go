@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:embed graphql_schema.graphql
+var graphQLSchema string
+
+const (
+	maxGraphQLBodyBytes = 64 << 10
+	maxGraphQLDepth     = 8
+
+	// Resolvers one request may run at once
+	maxGraphQLParallelism = 20
+
+	// How long a loader waits for sibling fields to ask for more keys
+	graphQLBatchWait = 2 * time.Millisecond
+	graphQLMaxBatch  = 100
+)
+
+// GraphQLHandler serves the storefront's GraphQL API, so a page such as a
+// product's, with its store, category path and reviews, is one request.
+type GraphQLHandler struct {
+	Schema   *graphql.Schema
+	resolver *graphQLResolver
+}
+
+func NewGraphQLHandler(db *mongo.Database) *GraphQLHandler {
+	productRepo := repository.NewProductRepository(db)
+	root := &graphQLResolver{
+		ProductRepo:    productRepo,
+		CategoryRepo:   repository.NewCategoryRepository(db),
+		StoreRepo:      repository.NewStoreRepository(db),
+		ReviewRepo:     repository.NewReviewRepository(db),
+		OrderRepo:      repository.NewOrderRepository(db),
+		CartHandler:    NewCartHandler(db),
+		ProductHandler: NewProductHandler(db, productRepo),
+	}
+	return &GraphQLHandler{
+		Schema: graphql.MustParseSchema(graphQLSchema, root,
+			graphql.MaxDepth(maxGraphQLDepth),
+			graphql.MaxParallelism(maxGraphQLParallelism),
+		),
+		resolver: root,
+	}
+}
+
+// Query runs a GraphQL query. Errors are reported in the response's errors
+// list, per the GraphQL spec, with whatever data could still be resolved.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var body struct {
+		Query         string                 `json:"query" binding:"required"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLBodyBytes)
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid GraphQL request"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	req := &graphQLRequest{language: searchLanguage(c)}
+	// Signed-in requests can read the buyer's cart and orders
+	if userID, err := primitive.ObjectIDFromHex(c.GetString("userId")); err == nil {
+		req.userID = &userID
+	}
+	req.loaders = h.resolver.newLoaders(ctx)
+	ctx = context.WithValue(ctx, graphQLRequestKey{}, req)
+
+	c.JSON(http.StatusOK, h.Schema.Exec(ctx, body.Query, body.OperationName, body.Variables))
+}
+
+type graphQLRequestKey struct{}
+
+// graphQLRequest is what resolvers need to know about the request they
+// resolve for.
+type graphQLRequest struct {
+	userID   *primitive.ObjectID // Nil when signed out
+	language string              // For search synonyms
+	loaders  *graphQLLoaders
+}
+
+func graphQLRequestFrom(ctx context.Context) *graphQLRequest {
+	return ctx.Value(graphQLRequestKey{}).(*graphQLRequest)
+}
+
+// graphQLLoaders batch the lookups fields make for the items of a list,
+// such as each cart line's product or each order line's store. They live
+// for one request.
+type graphQLLoaders struct {
+	products *utils.BatchLoader[primitive.ObjectID, models.Product]
+	stores   *utils.BatchLoader[primitive.ObjectID, models.Store] // By vendor ID
+}
+
+func (r *graphQLResolver) newLoaders(ctx context.Context) *graphQLLoaders {
+	return &graphQLLoaders{
+		products: utils.NewBatchLoader(ctx, func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Product, error) {
+			filter := bson.M{"_id": bson.M{"$in": ids}, "status": models.ProductStatusActive}
+			products, err := r.ProductRepo.FetchProductsPublicPage(ctx, filter, models.PageCursor{}, len(ids), nil)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[primitive.ObjectID]models.Product, len(products))
+			for _, p := range products {
+				byID[p.ID] = p
+			}
+			return byID, nil
+		}, graphQLBatchWait, graphQLMaxBatch),
+		stores: utils.NewBatchLoader(ctx, func(ctx context.Context, vendorIDs []primitive.ObjectID) (map[primitive.ObjectID]models.Store, error) {
+			stores, err := r.StoreRepo.GetByVendorIDs(ctx, vendorIDs)
+			if err != nil {
+				return nil, err
+			}
+			byVendor := make(map[primitive.ObjectID]models.Store, len(stores))
+			for _, s := range stores {
+				byVendor[s.VendorID] = s
+			}
+			return byVendor, nil
+		}, graphQLBatchWait, graphQLMaxBatch),
+	}
+}
@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/graph-gophers/graphql-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	errGraphQLSignIn   = errors.New("sign in to see your cart and orders")
+	errGraphQLID       = errors.New("invalid ID")
+	errGraphQLCursor   = errors.New("invalid cursor")
+	errGraphQLSelector = errors.New("pass either id or slug")
+)
+
+const maxGraphQLPageSize = 100
+
+// graphQLResolver resolves the fields of Query. Each type in the schema has
+// a resolver wrapping its model; fields that reach another collection go
+// through the request's loaders.
+type graphQLResolver struct {
+	ProductRepo    repository.ProductRepository
+	CategoryRepo   repository.CategoryRepository
+	StoreRepo      repository.StoreRepository
+	ReviewRepo     repository.ReviewRepository
+	OrderRepo      repository.OrderRepository
+	CartHandler    *CartHandler    // Prices carts as GET /carts does
+	ProductHandler *ProductHandler // Builds catalog filters as GET /public/products does
+}
+
+type pageArgs struct {
+	First *int32
+	After *string
+}
+
+// page reads a connection's arguments into a limit and the cursor to
+// continue after.
+func (a pageArgs) page(defaultLimit int) (models.PageCursor, int, error) {
+	limit := defaultLimit
+	if a.First != nil && *a.First >= 1 && *a.First <= maxGraphQLPageSize {
+		limit = int(*a.First)
+	}
+	var token string
+	if a.After != nil {
+		token = *a.After
+	}
+	after, err := models.DecodeCursor(token)
+	if err != nil {
+		return models.PageCursor{}, 0, errGraphQLCursor
+	}
+	return after, limit, nil
+}
+
+func parseGraphQLID(id graphql.ID) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		return primitive.NilObjectID, errGraphQLID
+	}
+	return objectID, nil
+}
+
+func (r *graphQLResolver) Product(ctx context.Context, args struct{ ID graphql.ID }) (*productResolver, error) {
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return r.loadProduct(ctx, id)
+}
+
+func (r *graphQLResolver) Products(ctx context.Context, args struct {
+	Query    *string
+	Category *graphql.ID
+	First    *int32
+	After    *string
+}) (*productConnection, error) {
+	var query, category string
+	if args.Query != nil {
+		query = *args.Query
+	}
+	if args.Category != nil {
+		category = string(*args.Category)
+	}
+	terms := expandSearch(ctx, r.ProductHandler.SearchRepo, query, graphQLRequestFrom(ctx).language)
+	filter := r.ProductHandler.buildProductFilter(ctx, terms, category, models.ProductFilters{})
+	return r.productPage(ctx, filter, pageArgs{First: args.First, After: args.After})
+}
+
+func (r *graphQLResolver) Category(ctx context.Context, args struct {
+	ID   *graphql.ID
+	Slug *string
+}) (*categoryResolver, error) {
+	var id primitive.ObjectID
+	switch {
+	case args.ID != nil && args.Slug == nil:
+		var err error
+		if id, err = parseGraphQLID(*args.ID); err != nil {
+			return nil, err
+		}
+	case args.Slug != nil && args.ID == nil:
+		category, err := r.CategoryRepo.GetActiveBySlug(ctx, *args.Slug)
+		if err != nil || category == nil {
+			return nil, err
+		}
+		id = category.ID
+	default:
+		return nil, errGraphQLSelector
+	}
+
+	// From the hierarchy, so categories whose parent is inactive stay hidden
+	// as they do on the tree
+	hierarchy, err := loadCategoryHierarchy(ctx, r.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := hierarchy.Get(id)
+	if !ok {
+		return nil, nil
+	}
+	return &categoryResolver{root: r, c: category}, nil
+}
+
+func (r *graphQLResolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	hierarchy, err := loadCategoryHierarchy(ctx, r.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+	return r.categories(hierarchy.Roots()), nil
+}
+
+func (r *graphQLResolver) Store(ctx context.Context, args struct{ Slug string }) (*storeResolver, error) {
+	store, err := r.StoreRepo.GetBySlug(ctx, args.Slug)
+	if err == nil && store == nil {
+		store, err = r.StoreRepo.GetByPreviousSlug(ctx, args.Slug)
+	}
+	if err != nil || store == nil {
+		return nil, err
+	}
+	return &storeResolver{root: r, s: *store}, nil
+}
+
+func (r *graphQLResolver) Cart(ctx context.Context) (*cartResolver, error) {
+	userID := graphQLRequestFrom(ctx).userID
+	if userID == nil {
+		return nil, errGraphQLSignIn
+	}
+	cart, err := r.CartHandler.Repo.GetCart(ctx, *userID)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := r.CartHandler.cartTotals(ctx, *userID, cart)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) > 0 {
+		recordCheckoutStep(r.CartHandler.CheckoutRepo, models.CheckoutEvent{UserID: *userID, Step: models.CheckoutStepCartViewed})
+	}
+	return &cartResolver{root: r, cart: cart, totals: totals}, nil
+}
+
+func (r *graphQLResolver) Order(ctx context.Context, args struct{ ID graphql.ID }) (*orderResolver, error) {
+	userID := graphQLRequestFrom(ctx).userID
+	if userID == nil {
+		return nil, errGraphQLSignIn
+	}
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := r.OrderRepo.GetOrderById(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Someone else's order is reported as missing rather than forbidden
+	if !canViewOrder(order, *userID) {
+		return nil, nil
+	}
+	return &orderResolver{root: r, o: order}, nil
+}
+
+func (r *graphQLResolver) Orders(ctx context.Context, args pageArgs) (*orderConnection, error) {
+	userID := graphQLRequestFrom(ctx).userID
+	if userID == nil {
+		return nil, errGraphQLSignIn
+	}
+	after, limit, err := args.page(20)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := r.OrderRepo.ListOrdersPage(ctx, bson.M{"userId": *userID}, after, int64(limit+1), nil)
+	if err != nil {
+		return nil, err
+	}
+	orders, meta := models.CursorPage(orders, limit, func(o models.Order) (time.Time, primitive.ObjectID) {
+		return o.CreatedAt, o.ID
+	})
+	conn := &orderConnection{nodes: make([]*orderResolver, 0, len(orders)), meta: meta}
+	for _, o := range orders {
+		conn.nodes = append(conn.nodes, &orderResolver{root: r, o: o})
+	}
+	return conn, nil
+}
+
+// loadProduct returns an active product through the request's loader, or
+// nil if there is none.
+func (r *graphQLResolver) loadProduct(ctx context.Context, id primitive.ObjectID) (*productResolver, error) {
+	product, found, err := graphQLRequestFrom(ctx).loaders.products.Load(id)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &productResolver{root: r, p: product}, nil
+}
+
+// loadStore returns a vendor's store through the request's loader, or nil
+// if they have none.
+func (r *graphQLResolver) loadStore(ctx context.Context, vendorID primitive.ObjectID) (*storeResolver, error) {
+	store, found, err := graphQLRequestFrom(ctx).loaders.stores.Load(vendorID)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &storeResolver{root: r, s: store}, nil
+}
+
+// productPage is a page of the active products matching filter, newest
+// first.
+func (r *graphQLResolver) productPage(ctx context.Context, filter bson.M, args pageArgs) (*productConnection, error) {
+	after, limit, err := args.page(12)
+	if err != nil {
+		return nil, err
+	}
+	products, err := r.ProductRepo.FetchProductsPublicPage(ctx, filter, after, limit+1, nil)
+	if err != nil {
+		return nil, err
+	}
+	products, meta := models.CursorPage(products, limit, func(p models.Product) (time.Time, primitive.ObjectID) {
+		return p.CreatedAt, p.ID
+	})
+	conn := &productConnection{nodes: make([]*productResolver, 0, len(products)), meta: meta}
+	for _, p := range products {
+		conn.nodes = append(conn.nodes, &productResolver{root: r, p: p})
+	}
+	return conn, nil
+}
+
+func (r *graphQLResolver) categories(list []models.Category) []*categoryResolver {
+	resolvers := make([]*categoryResolver, 0, len(list))
+	for _, c := range list {
+		resolvers = append(resolvers, &categoryResolver{root: r, c: c})
+	}
+	return resolvers
+}
+
+func graphQLTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+func graphQLCursor(meta models.CursorMeta) *string {
+	if meta.NextCursor == "" {
+		return nil
+	}
+	return &meta.NextCursor
+}
+
+type productConnection struct {
+	nodes []*productResolver
+	meta  models.CursorMeta
+}
+
+func (c *productConnection) Nodes() []*productResolver { return c.nodes }
+func (c *productConnection) NextCursor() *string       { return graphQLCursor(c.meta) }
+func (c *productConnection) HasMore() bool             { return c.meta.HasMore }
+
+type orderConnection struct {
+	nodes []*orderResolver
+	meta  models.CursorMeta
+}
+
+func (c *orderConnection) Nodes() []*orderResolver { return c.nodes }
+func (c *orderConnection) NextCursor() *string     { return graphQLCursor(c.meta) }
+func (c *orderConnection) HasMore() bool           { return c.meta.HasMore }
+
+type productResolver struct {
+	root *graphQLResolver
+	p    models.Product
+}
+
+func (r *productResolver) ID() graphql.ID          { return graphql.ID(r.p.ID.Hex()) }
+func (r *productResolver) Name() string            { return r.p.Name }
+func (r *productResolver) Description() string     { return r.p.Description }
+func (r *productResolver) Brand() string           { return r.p.Brand }
+func (r *productResolver) Images() []string        { return r.p.Images }
+func (r *productResolver) VideoURL() string        { return r.p.VideoURL }
+func (r *productResolver) Price() float64          { return r.p.Price.Float64() }
+func (r *productResolver) SalePrice() float64      { return r.p.SalePrice.Float64() }
+func (r *productResolver) Stock() int32            { return int32(r.p.Stock) }
+func (r *productResolver) AllowBackorder() bool    { return r.p.AllowBackorder }
+func (r *productResolver) IsDigital() bool         { return r.p.IsDigital }
+func (r *productResolver) Tags() []string          { return r.p.Tags }
+func (r *productResolver) Rating() float64         { return r.p.Rating }
+func (r *productResolver) ReviewCount() int32      { return int32(r.p.ReviewCount) }
+func (r *productResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.p.CreatedAt} }
+
+func (r *productResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	hierarchy, err := loadCategoryHierarchy(ctx, r.root.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := hierarchy.Get(r.p.CategoryID)
+	if !ok {
+		return nil, nil
+	}
+	return &categoryResolver{root: r.root, c: category}, nil
+}
+
+func (r *productResolver) Breadcrumbs(ctx context.Context) ([]*categoryResolver, error) {
+	hierarchy, err := loadCategoryHierarchy(ctx, r.root.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+	var path []models.Category
+	for _, crumb := range hierarchy.Breadcrumbs(r.p.CategoryID) {
+		if category, ok := hierarchy.Get(crumb.ID); ok {
+			path = append(path, category)
+		}
+	}
+	return r.root.categories(path), nil
+}
+
+func (r *productResolver) Store(ctx context.Context) (*storeResolver, error) {
+	return r.root.loadStore(ctx, r.p.VendorID)
+}
+
+func (r *productResolver) Reviews(ctx context.Context, args struct{ First *int32 }) ([]*reviewResolver, error) {
+	limit := 10
+	if args.First != nil && *args.First >= 1 && *args.First <= maxGraphQLPageSize {
+		limit = int(*args.First)
+	}
+	reviews, err := r.root.ReviewRepo.ListReviewsPage(ctx, bson.M{"productId": r.p.ID}, models.PageCursor{}, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*reviewResolver, 0, len(reviews))
+	for _, review := range reviews {
+		resolvers = append(resolvers, &reviewResolver{r: review})
+	}
+	return resolvers, nil
+}
+
+type categoryResolver struct {
+	root *graphQLResolver
+	c    models.Category
+}
+
+func (r *categoryResolver) ID() graphql.ID      { return graphql.ID(r.c.ID.Hex()) }
+func (r *categoryResolver) Name() string        { return r.c.Name }
+func (r *categoryResolver) Slug() string        { return r.c.Slug }
+func (r *categoryResolver) Description() string { return r.c.Description }
+func (r *categoryResolver) Icon() string        { return r.c.Icon }
+func (r *categoryResolver) Image() string       { return r.c.Image }
+func (r *categoryResolver) IsFeatured() bool    { return r.c.IsFeatured }
+
+func (r *categoryResolver) Parent(ctx context.Context) (*categoryResolver, error) {
+	if r.c.ParentID == nil {
+		return nil, nil
+	}
+	hierarchy, err := loadCategoryHierarchy(ctx, r.root.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+	parent, ok := hierarchy.Get(*r.c.ParentID)
+	if !ok {
+		return nil, nil
+	}
+	return &categoryResolver{root: r.root, c: parent}, nil
+}
+
+func (r *categoryResolver) Children(ctx context.Context) ([]*categoryResolver, error) {
+	hierarchy, err := loadCategoryHierarchy(ctx, r.root.CategoryRepo)
+	if err != nil {
+		return nil, err
+	}
+	return r.root.categories(hierarchy.Children(r.c.ID)), nil
+}
+
+func (r *categoryResolver) Products(ctx context.Context, args pageArgs) (*productConnection, error) {
+	filter := categoryCondition(ctx, r.root.CategoryRepo, r.c.ID)
+	filter["status"] = models.ProductStatusActive
+	return r.root.productPage(ctx, filter, args)
+}
+
+type storeResolver struct {
+	root *graphQLResolver
+	s    models.Store
+}
+
+func (r *storeResolver) ID() graphql.ID       { return graphql.ID(r.s.ID.Hex()) }
+func (r *storeResolver) Slug() string         { return r.s.Slug }
+func (r *storeResolver) Name() string         { return r.s.Name }
+func (r *storeResolver) Description() string  { return r.s.Description }
+func (r *storeResolver) Logo() string         { return r.s.Logo }
+func (r *storeResolver) Banner() string       { return r.s.Banner }
+func (r *storeResolver) PrimaryColor() string { return r.s.PrimaryColor }
+func (r *storeResolver) AccentColor() string  { return r.s.AccentColor }
+func (r *storeResolver) About() string        { return r.s.About }
+func (r *storeResolver) Location() string     { return r.s.Location }
+
+func (r *storeResolver) Products(ctx context.Context, args pageArgs) (*productConnection, error) {
+	filter := bson.M{"vendorId": r.s.VendorID, "status": models.ProductStatusActive}
+	return r.root.productPage(ctx, filter, args)
+}
+
+type reviewResolver struct {
+	r models.Review
+}
+
+func (r *reviewResolver) ID() graphql.ID          { return graphql.ID(r.r.ID.Hex()) }
+func (r *reviewResolver) Rating() int32           { return int32(r.r.Rating) }
+func (r *reviewResolver) Comment() string         { return r.r.Comment }
+func (r *reviewResolver) Images() []string        { return r.r.Images }
+func (r *reviewResolver) UserName() string        { return r.r.UserName }
+func (r *reviewResolver) UserImage() string       { return r.r.UserImage }
+func (r *reviewResolver) Response() string        { return r.r.Response }
+func (r *reviewResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.r.CreatedAt} }
+
+type cartResolver struct {
+	root   *graphQLResolver
+	cart   models.Cart
+	totals models.CartTotals
+}
+
+func (r *cartResolver) CouponCode() string  { return r.cart.CouponCode }
+func (r *cartResolver) Subtotal() float64   { return r.totals.Subtotal.Float64() }
+func (r *cartResolver) Discount() float64   { return r.totals.Discount.Float64() }
+func (r *cartResolver) Total() float64      { return r.totals.Total.Float64() }
+func (r *cartResolver) CouponError() string { return r.totals.CouponError }
+
+func (r *cartResolver) Items() []*cartItemResolver {
+	items := make([]*cartItemResolver, 0, len(r.cart.Items))
+	for _, item := range r.cart.Items {
+		items = append(items, &cartItemResolver{root: r.root, item: item})
+	}
+	return items
+}
+
+type cartItemResolver struct {
+	root *graphQLResolver
+	item models.CartItem
+}
+
+func (r *cartItemResolver) ProductID() graphql.ID { return graphql.ID(r.item.ProductID.Hex()) }
+func (r *cartItemResolver) Name() string          { return r.item.Name }
+func (r *cartItemResolver) Image() string         { return r.item.Image }
+func (r *cartItemResolver) Price() float64        { return r.item.Price.Float64() }
+func (r *cartItemResolver) Quantity() int32       { return int32(r.item.Quantity) }
+
+func (r *cartItemResolver) Product(ctx context.Context) (*productResolver, error) {
+	return r.root.loadProduct(ctx, r.item.ProductID)
+}
+
+type orderResolver struct {
+	root *graphQLResolver
+	o    models.Order
+}
+
+func (r *orderResolver) ID() graphql.ID          { return graphql.ID(r.o.ID.Hex()) }
+func (r *orderResolver) OrderNumber() string     { return r.o.OrderNumber }
+func (r *orderResolver) Status() string          { return string(r.o.Status) }
+func (r *orderResolver) PaymentStatus() string   { return r.o.PaymentStatus }
+func (r *orderResolver) Subtotal() float64       { return r.o.Subtotal.Float64() }
+func (r *orderResolver) Discount() float64       { return r.o.Discount.Float64() }
+func (r *orderResolver) ShippingFee() float64    { return r.o.ShippingFee.Float64() }
+func (r *orderResolver) Tax() float64            { return r.o.Tax.Float64() }
+func (r *orderResolver) Total() float64          { return r.o.Total.Float64() }
+func (r *orderResolver) ShippingAddress() string { return r.o.ShippingAddress }
+func (r *orderResolver) Carrier() string         { return r.o.Carrier }
+func (r *orderResolver) TrackingNumber() string  { return r.o.TrackingNumber }
+func (r *orderResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.o.CreatedAt} }
+func (r *orderResolver) ShippedAt() *graphql.Time {
+	return graphQLTime(r.o.ShippedAt)
+}
+func (r *orderResolver) DeliveredAt() *graphql.Time {
+	return graphQLTime(r.o.DeliveredAt)
+}
+
+func (r *orderResolver) Items() []*orderItemResolver {
+	items := make([]*orderItemResolver, 0, len(r.o.Items))
+	for _, item := range r.o.Items {
+		items = append(items, &orderItemResolver{root: r.root, item: item})
+	}
+	return items
+}
+
+type orderItemResolver struct {
+	root *graphQLResolver
+	item models.OrderItem
+}
+
+func (r *orderItemResolver) ProductID() graphql.ID { return graphql.ID(r.item.ProductID.Hex()) }
+func (r *orderItemResolver) Name() string          { return r.item.Name }
+func (r *orderItemResolver) Image() string         { return r.item.Image }
+func (r *orderItemResolver) Price() float64        { return r.item.Price.Float64() }
+func (r *orderItemResolver) Quantity() int32       { return int32(r.item.Quantity) }
+func (r *orderItemResolver) Subtotal() float64     { return r.item.Subtotal.Float64() }
+
+func (r *orderItemResolver) Product(ctx context.Context) (*productResolver, error) {
+	return r.root.loadProduct(ctx, r.item.ProductID)
+}
+
+func (r *orderItemResolver) Store(ctx context.Context) (*storeResolver, error) {
+	return r.root.loadStore(ctx, r.item.VendorID)
+}
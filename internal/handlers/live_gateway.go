@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Live update event names, as sent in the SSE "event:" field.
+const (
+	LiveNotification = "notification"
+	LiveOrder        = "order"
+	LiveMessage      = "message"
+	LiveTyping       = "typing"
+	LiveReceipt      = "receipt" // Messages delivered to or read by the other side of a conversation
+)
+
+const (
+	// liveHeartbeat keeps idle streams from being closed by proxies.
+	liveHeartbeat = 25 * time.Second
+	// liveMaxConnections caps open streams per user, e.g. browser tabs.
+	// Clients over the cap are told to poll instead.
+	liveMaxConnections = 5
+	// livePollInterval is how often clients should poll when they can't
+	// hold a stream open.
+	livePollInterval = 30 * time.Second
+)
+
+type liveUpdate struct {
+	Event string
+	Data  interface{}
+}
+
+// liveHub fans updates out to each user's open streams. It is in-process,
+// so each server instance only reaches the clients connected to it;
+// anything missed is still in the inbox, which polling reads.
+type liveHub struct {
+	mu   sync.RWMutex
+	subs map[primitive.ObjectID]map[chan liveUpdate]struct{}
+}
+
+var liveUpdates = &liveHub{subs: make(map[primitive.ObjectID]map[chan liveUpdate]struct{})}
+
+// subscribe opens a stream for the user. It reports false when the user
+// already has liveMaxConnections streams open.
+func (h *liveHub) subscribe(userID primitive.ObjectID) (<-chan liveUpdate, func(), bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subs[userID]) >= liveMaxConnections {
+		return nil, nil, false
+	}
+
+	ch := make(chan liveUpdate, 16)
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan liveUpdate]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[userID], ch)
+		if len(h.subs[userID]) == 0 {
+			delete(h.subs, userID)
+		}
+	}, true
+}
+
+// publish never blocks; a stream that has fallen behind misses the live
+// copy.
+func (h *liveHub) publish(userID primitive.ObjectID, event string, data interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- liveUpdate{Event: event, Data: data}:
+		default:
+		}
+	}
+}
+
+type LiveHandler struct {
+	NotificationRepo repository.NotificationRepository
+	MessageRepo      repository.MessageRepository
+}
+
+func NewLiveHandler(db *mongo.Database) *LiveHandler {
+	return &LiveHandler{
+		NotificationRepo: repository.NewNotificationRepository(db),
+		MessageRepo:      repository.NewMessageRepository(db),
+	}
+}
+
+// HandleEvent is a bus handler that pushes order and message events to the
+// users they concern. Notifications are pushed by the dispatcher as they are
+// created.
+func (h *LiveHandler) HandleEvent(ctx context.Context, e events.Event) {
+	d := e.Data
+	switch e.Type {
+	case events.OrderPlaced, events.OrderPaid, events.OrderStatusChanged, events.OrderRefunded:
+		update := gin.H{
+			"type":        e.Type,
+			"orderId":     eventString(d, "orderId"),
+			"orderNumber": eventString(d, "orderNumber"),
+			"occurredAt":  e.OccurredAt,
+		}
+		if status := eventString(d, "status"); status != "" {
+			update["status"] = status
+		}
+		if tracking := eventString(d, "trackingNumber"); tracking != "" {
+			update["trackingNumber"] = tracking
+		}
+		for _, id := range append(eventIDs(d, "buyerId"), eventIDs(d, "vendorIds")...) {
+			liveUpdates.publish(id, LiveOrder, update)
+		}
+
+	case events.MessageReceived:
+		for _, id := range eventIDs(d, "recipientId") {
+			liveUpdates.publish(id, LiveMessage, gin.H{
+				"conversationId": eventString(d, "conversationId"),
+				"senderId":       eventString(d, "senderId"),
+				"preview":        eventString(d, "preview"),
+				"message":        d["message"], // So chat windows can show it without fetching
+				"occurredAt":     e.OccurredAt,
+			})
+		}
+	}
+}
+
+// Stream pushes the user's notifications, order updates and messages as
+// server-sent events for as long as the connection stays open.
+func (h *LiveHandler) Stream(c *gin.Context) {
+	streamLive(c, nil)
+}
+
+// StreamNotifications is the notifications-only stream.
+func (h *NotificationHandler) StreamNotifications(c *gin.Context) {
+	streamLive(c, map[string]bool{LiveNotification: true})
+}
+
+// Poll is the fallback for clients that can't hold a stream open: it returns
+// notifications created after ?since= (RFC 3339), which cover order updates
+// and messages too.
+func (h *LiveHandler) Poll(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	since := time.Now().Add(-livePollInterval)
+	if raw := c.Query("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("since must be an RFC 3339 timestamp"))
+			return
+		}
+		since = t
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// Read the clock first so nothing created during the query is skipped
+	// by the client's next poll
+	now := time.Now()
+	notifications, err := h.NotificationRepo.ListSince(ctx, userID, since, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch updates"))
+		return
+	}
+	unread, _ := h.NotificationRepo.CountUnread(ctx, userID)
+
+	next := now
+	if len(notifications) == 100 {
+		// More to fetch; continue from the last one returned
+		next = notifications[len(notifications)-1].CreatedAt
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Updates fetched", gin.H{
+		"notifications": notifications,
+		"unreadCount":   unread,
+		"next":          next,
+		"pollInterval":  int(livePollInterval.Seconds()),
+	}))
+}
+
+// tooManyLiveConnections turns away a stream or socket over
+// liveMaxConnections, pointing the client at polling.
+func tooManyLiveConnections(c *gin.Context) {
+	c.Header("Retry-After", fmt.Sprint(int(livePollInterval.Seconds())))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"success":      false,
+		"message":      "Too many live connections; poll for updates instead",
+		"pollUrl":      "/api/v1/live/poll",
+		"pollInterval": int(livePollInterval.Seconds()),
+	})
+}
+
+// streamLive streams the user's live updates, limited to the given events
+// when only is set.
+func streamLive(c *gin.Context, only map[string]bool) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	updates, unsubscribe, ok := liveUpdates.subscribe(userID)
+	if !ok {
+		tooManyLiveConnections(c)
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// Tell EventSource how long to wait before reconnecting, and the client
+	// when to start polling from if it gives up on the stream
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	c.SSEvent("ready", gin.H{"at": time.Now(), "pollUrl": "/api/v1/live/poll", "pollInterval": int(livePollInterval.Seconds())})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case u := <-updates:
+			if only == nil || only[u.Event] {
+				c.SSEvent(u.Event, u.Data)
+			}
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"at": time.Now()})
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// liveSocketMaxFrame caps what a client can send; signals are tiny.
+	liveSocketMaxFrame = 4 << 10
+	// liveSocketConversations caps the conversations a socket remembers the
+	// user is on before it looks them up again.
+	liveSocketConversations = 50
+)
+
+// liveFrame is what the socket sends: the same events as the stream, plus
+// "pong" and "error" in reply to the client.
+type liveFrame struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// chatSignal is what a client sends over the socket.
+type chatSignal struct {
+	Type           string `json:"type"` // "typing", "delivered", "read" or "ping"
+	ConversationID string `json:"conversationId"`
+}
+
+// Socket is the live gateway over WebSocket. It pushes the same updates as
+// Stream, including new messages with their full content, typing indicators
+// and receipts, and takes the user's own typing, delivered and read signals.
+// Messages are still sent with POST /messages. Clients that can't open a
+// socket use Stream and the REST typing and receipt endpoints, or poll.
+func (h *LiveHandler) Socket(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	updates, unsubscribe, ok := liveUpdates.subscribe(userID)
+	if !ok {
+		tooManyLiveConnections(c)
+		return
+	}
+	defer unsubscribe()
+
+	// No origin check: the access token authenticates the socket, as it
+	// does the stream
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		ws.MaxPayloadBytes = liveSocketMaxFrame
+		session := &chatSession{
+			repo:          h.MessageRepo,
+			userID:        userID,
+			conversations: make(map[primitive.ObjectID]models.Conversation),
+		}
+		session.serve(ws, updates)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// chatSession is one user's open socket.
+type chatSession struct {
+	repo          repository.MessageRepository
+	userID        primitive.ObjectID
+	conversations map[primitive.ObjectID]models.Conversation // Ones the user is on, as looked up; only the reader uses it
+}
+
+// serve writes live updates to the socket while another goroutine reads
+// the client's signals, until either side goes away.
+func (s *chatSession) serve(ws *websocket.Conn, updates <-chan liveUpdate) {
+	replies := make(chan liveFrame, 8)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.read(ws, replies)
+	}()
+
+	send := func(f liveFrame) bool {
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return websocket.JSON.Send(ws, f) == nil
+	}
+	if !send(liveFrame{Event: "ready", Data: gin.H{
+		"at":           time.Now(),
+		"pollUrl":      "/api/v1/live/poll",
+		"pollInterval": int(livePollInterval.Seconds()),
+	}}) {
+		return
+	}
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var frame liveFrame
+		select {
+		case u := <-updates:
+			frame = liveFrame{Event: u.Event, Data: u.Data}
+		case frame = <-replies:
+		case <-heartbeat.C:
+			frame = liveFrame{Event: "ping", Data: gin.H{"at": time.Now()}}
+		case <-done:
+			return
+		}
+		if !send(frame) {
+			return
+		}
+	}
+}
+
+// read handles the client's signals until the socket closes. A malformed or
+// oversized signal gets an error back rather than closing the socket.
+func (s *chatSession) read(ws *websocket.Conn, replies chan<- liveFrame) {
+	reply := func(f liveFrame) {
+		// Dropped if the writer is behind; the client can signal again
+		select {
+		case replies <- f:
+		default:
+		}
+	}
+	for {
+		var signal chatSignal
+		err := websocket.JSON.Receive(ws, &signal)
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case err == nil:
+			if f := s.handle(signal); f != nil {
+				reply(*f)
+			}
+		case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.Is(err, websocket.ErrFrameTooLarge):
+			reply(liveErrorFrame("Signals are JSON objects with a type and a conversationId"))
+		default:
+			return
+		}
+	}
+}
+
+// handle acts on one signal, returning a frame to send back if there is one.
+func (s *chatSession) handle(signal chatSignal) *liveFrame {
+	switch signal.Type {
+	case "ping":
+		return &liveFrame{Event: "pong", Data: gin.H{"at": time.Now()}}
+	case LiveTyping, models.ReceiptDelivered, models.ReceiptRead:
+	default:
+		f := liveErrorFrame("Unknown signal type " + signal.Type)
+		return &f
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conversation, ok := s.conversation(ctx, signal.ConversationID)
+	if !ok {
+		f := liveErrorFrame("Conversation not found")
+		return &f
+	}
+	if signal.Type == LiveTyping {
+		publishTyping(conversation, s.userID)
+		return nil
+	}
+	if err := markConversation(ctx, s.repo, conversation, s.userID, signal.Type); err != nil {
+		logrus.WithError(err).WithField("conversationId", conversation.ID.Hex()).Warn("Failed to mark messages from live socket")
+		f := liveErrorFrame("Failed to mark messages " + signal.Type)
+		return &f
+	}
+	return nil
+}
+
+// conversation looks up a conversation the user is on, remembering it for
+// the rest of the session since its participants never change.
+func (s *chatSession) conversation(ctx context.Context, rawID string) (models.Conversation, bool) {
+	id, err := primitive.ObjectIDFromHex(rawID)
+	if err != nil {
+		return models.Conversation{}, false
+	}
+	if conversation, ok := s.conversations[id]; ok {
+		return conversation, true
+	}
+
+	conversation, err := s.repo.GetConversation(ctx, id)
+	if err != nil {
+		logrus.WithError(err).WithField("conversationId", rawID).Warn("Failed to fetch conversation for live socket")
+		return models.Conversation{}, false
+	}
+	if conversation == nil || !conversation.HasParticipant(s.userID) {
+		return models.Conversation{}, false
+	}
+	if len(s.conversations) >= liveSocketConversations {
+		s.conversations = make(map[primitive.ObjectID]models.Conversation)
+	}
+	s.conversations[id] = *conversation
+	return *conversation, true
+}
+
+func liveErrorFrame(message string) liveFrame {
+	return liveFrame{Event: "error", Data: gin.H{"message": message}}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Public pages show products and categories in the locale the request is
+// answered in (see middleware.Localize), falling back to the default
+// locale for whatever hasn't been translated. Vendors and admins get the
+// translations themselves, to edit.
+
+func localizeProducts(c *gin.Context, products []models.Product) {
+	locale := i18n.FromContext(c.Request.Context())
+	for i := range products {
+		products[i].Localize(locale)
+	}
+}
+
+func localizeCategories(c *gin.Context, categories []models.Category) {
+	locale := i18n.FromContext(c.Request.Context())
+	for i := range categories {
+		categories[i].Localize(locale)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// merchantFeed is the Merchant Center feed rendered in both formats it is
+// served in.
+type merchantFeed struct {
+	XML []byte
+	TSV []byte
+}
+
+// merchantFeedCache holds the rendered feed, kept like sitemapCache.
+var merchantFeedCache = utils.NewTTLCache[merchantFeed](2 * feedRefreshInterval)
+
+type merchantFeedRSS struct {
+	XMLName xml.Name            `xml:"rss"`
+	Version string              `xml:"version,attr"`
+	Xmlns   string              `xml:"xmlns:g,attr"`
+	Channel merchantFeedChannel `xml:"channel"`
+}
+
+type merchantFeedChannel struct {
+	Title       string                    `xml:"title"`
+	Link        string                    `xml:"link"`
+	Description string                    `xml:"description"`
+	Items       []models.MerchantFeedItem `xml:"item"`
+}
+
+// MerchantFeedHandler serves the catalog as a Google Merchant Center product
+// feed, so listings can appear in Shopping ads and free listings.
+type MerchantFeedHandler struct {
+	ProductRepo  repository.ProductRepository
+	CategoryRepo repository.CategoryRepository
+	Currency     string // ISO 4217 code prices are listed in, USD when unset
+}
+
+func NewMerchantFeedHandler(db *mongo.Database, currency string) *MerchantFeedHandler {
+	return &MerchantFeedHandler{
+		ProductRepo:  repository.NewProductRepository(db),
+		CategoryRepo: repository.NewCategoryRepository(db),
+		Currency:     currency,
+	}
+}
+
+// GetMerchantFeedXML serves the Merchant Center feed of active products as
+// RSS 2.0.
+func (h *MerchantFeedHandler) GetMerchantFeedXML(c *gin.Context) {
+	if feed, ok := h.feed(c); ok {
+		c.Data(http.StatusOK, "application/xml; charset=utf-8", feed.XML)
+	}
+}
+
+// GetMerchantFeedTSV serves the Merchant Center feed of active products as
+// tab-separated values, one product to a row.
+func (h *MerchantFeedHandler) GetMerchantFeedTSV(c *gin.Context) {
+	if feed, ok := h.feed(c); ok {
+		c.Data(http.StatusOK, "text/tab-separated-values; charset=utf-8", feed.TSV)
+	}
+}
+
+// feed returns the cached feed, building it if it has expired. It reports
+// false, having written the response, if it can't.
+func (h *MerchantFeedHandler) feed(c *gin.Context) (merchantFeed, bool) {
+	if feed, ok := merchantFeedCache.Get("feed"); ok {
+		return feed, true
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	feed, err := h.Regenerate(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to generate merchant feed")
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate product feed"))
+		return merchantFeed{}, false
+	}
+	return feed, true
+}
+
+// Regenerate builds the feed from the current catalog and caches it.
+// Products without an image are left out, as Merchant Center rejects them.
+func (h *MerchantFeedHandler) Regenerate(ctx context.Context) (merchantFeed, error) {
+	products, err := h.ProductRepo.ListFeedProducts(ctx)
+	if err != nil {
+		return merchantFeed{}, fmt.Errorf("load products: %w", err)
+	}
+	hierarchy, err := loadCategoryHierarchy(ctx, h.CategoryRepo)
+	if err != nil {
+		return merchantFeed{}, fmt.Errorf("load categories: %w", err)
+	}
+
+	base := frontendURL()
+	currency := h.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	items := make([]models.MerchantFeedItem, 0, len(products))
+	for _, p := range products {
+		if len(p.Images) == 0 {
+			continue
+		}
+		link := base + "/products/" + p.ID.Hex()
+		items = append(items, models.NewMerchantFeedItem(p, link, currency, hierarchy.Breadcrumbs(p.CategoryID)))
+	}
+
+	feed, err := renderMerchantFeed(base, items)
+	if err != nil {
+		return merchantFeed{}, err
+	}
+	merchantFeedCache.Set("feed", feed)
+	return feed, nil
+}
+
+func renderMerchantFeed(base string, items []models.MerchantFeedItem) (merchantFeed, error) {
+	rss := merchantFeedRSS{
+		Version: "2.0",
+		Xmlns:   "http://base.google.com/ns/1.0",
+		Channel: merchantFeedChannel{
+			Title:       "Vendora",
+			Link:        base + "/",
+			Description: "Products on the Vendora marketplace",
+			Items:       items,
+		},
+	}
+	out, err := xml.MarshalIndent(rss, "", "  ")
+	if err != nil {
+		return merchantFeed{}, err
+	}
+
+	var tsv bytes.Buffer
+	tsv.WriteString(strings.Join(models.MerchantFeedColumns, "\t") + "\n")
+	for _, item := range items {
+		tsv.WriteString(strings.Join(item.TSVRow(), "\t") + "\n")
+	}
+
+	return merchantFeed{XML: append([]byte(xml.Header), out...), TSV: tsv.Bytes()}, nil
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MessageHandler struct {
+	Repo        repository.MessageRepository
+	ProductRepo repository.ProductRepository
+	OrderRepo   repository.OrderRepository
+	Templates   repository.CannedResponseRepository
+	ReportRepo  repository.ReportRepository
+	Media       *services.MediaService
+	Filter      *services.ContentFilter
+}
+
+func NewMessageHandler(db *mongo.Database, media *services.MediaService) *MessageHandler {
+	return &MessageHandler{
+		Repo:        repository.NewMessageRepository(db),
+		ProductRepo: repository.NewProductRepository(db),
+		OrderRepo:   repository.NewOrderRepository(db),
+		Templates:   repository.NewCannedResponseRepository(db),
+		ReportRepo:  repository.NewReportRepository(db),
+		Media:       media,
+		Filter:      services.NewContentFilter(),
+	}
+}
+
+// SendMessage sends a message in a conversation, starting the conversation
+// about a product or order if there isn't one yet. The recipient is notified
+// through the usual channels. Attachments are uploaded first with
+// UploadAttachment, and vendors can send a saved reply with templateId.
+// When a buyer writes to a vendor who is away, the vendor's away message is
+// sent back.
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.SendMessageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	input.Body = strings.TrimSpace(input.Body)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// A template can be sent as it is or edited first
+	if input.TemplateID != nil {
+		template, err := h.Templates.Get(ctx, userID, *input.TemplateID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch canned response"))
+			return
+		}
+		if template == nil {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Canned response not found"))
+			return
+		}
+		if input.Body == "" {
+			input.Body = template.Body
+		}
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.Body == "" && len(input.Attachments) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A message needs some text or an attachment"))
+		return
+	}
+	filtered, err := h.Filter.Apply(services.FilterMessage, input.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(filterRejection(filtered)))
+		return
+	}
+	input.Body = filtered.Text
+	if err := services.MediaMessageAttachment.CheckAttachments(userID, input.Attachments); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid attachment: "+err.Error()))
+		return
+	}
+
+	conversation, status, msg := h.resolveConversation(ctx, userID, input)
+	if conversation == nil {
+		c.JSON(status, utils.ErrorResponse(msg))
+		return
+	}
+
+	message, err := h.Repo.AddMessage(ctx, *conversation, models.Message{SenderID: userID, Body: input.Body, Attachments: input.Attachments})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to send message"))
+		return
+	}
+	go flagFilteredContent(h.ReportRepo, "message", message.ID, filtered)
+
+	events.Publish(events.MessageReceived, map[string]interface{}{
+		"conversationId": conversation.ID.Hex(),
+		"messageId":      message.ID.Hex(),
+		"senderId":       userID.Hex(),
+		"recipientId":    conversation.Recipient(userID).Hex(),
+		"preview":        message.Preview(),
+		"message":        message,
+	})
+
+	if input.TemplateID != nil {
+		if err := h.Templates.IncrementUse(ctx, *input.TemplateID); err != nil {
+			logrus.WithError(err).WithField("templateId", input.TemplateID.Hex()).Warn("Failed to count canned response use")
+		}
+	}
+	if userID == conversation.BuyerID {
+		h.sendAutoReply(ctx, *conversation)
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Message sent", gin.H{
+		"conversationId": conversation.ID,
+		"message":        message,
+	}))
+}
+
+// UploadAttachment uploads an image or PDF to attach to a message.
+func (h *MessageHandler) UploadAttachment(c *gin.Context) {
+	uploadAttachment(c, h.Media, services.MediaMessageAttachment)
+}
+
+// sendAutoReply answers the buyer with the vendor's away message when it is
+// on and hasn't already gone out in this conversation recently. Failing to
+// send it doesn't fail the buyer's message.
+func (h *MessageHandler) sendAutoReply(ctx context.Context, conversation models.Conversation) {
+	log := logrus.WithField("conversationId", conversation.ID.Hex())
+	now := time.Now()
+
+	away, err := h.Templates.GetAutoReply(ctx, conversation.VendorID)
+	if err != nil {
+		log.WithError(err).Warn("Failed to fetch away message")
+		return
+	}
+	if !away.ActiveAt(now) {
+		return
+	}
+	claimed, err := h.Repo.ClaimAutoReply(ctx, conversation.ID, now)
+	if err != nil {
+		log.WithError(err).Warn("Failed to claim away message")
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	message, err := h.Repo.AddMessage(ctx, conversation, models.Message{
+		SenderID:  conversation.VendorID,
+		Body:      away.Message,
+		AutoReply: true,
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to send away message")
+		return
+	}
+	events.Publish(events.MessageReceived, map[string]interface{}{
+		"conversationId": conversation.ID.Hex(),
+		"messageId":      message.ID.Hex(),
+		"senderId":       conversation.VendorID.Hex(),
+		"recipientId":    conversation.BuyerID.Hex(),
+		"preview":        message.Preview(),
+		"message":        message,
+	})
+}
+
+// resolveConversation finds the conversation a message goes in, starting
+// it when the message is about a product or order. It returns nil with the
+// status and message to respond with when the sender can't send it.
+func (h *MessageHandler) resolveConversation(ctx context.Context, userID primitive.ObjectID, input models.SendMessageInput) (*models.Conversation, int, string) {
+	switch {
+	case input.ConversationID != nil:
+		conversation, err := h.Repo.GetConversation(ctx, *input.ConversationID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, "Failed to fetch conversation"
+		}
+		if conversation == nil || !conversation.HasParticipant(userID) {
+			return nil, http.StatusNotFound, "Conversation not found"
+		}
+		return conversation, 0, ""
+
+	case input.ProductID != nil:
+		product, err := h.ProductRepo.GetProduct(ctx, bson.M{"_id": *input.ProductID})
+		if err != nil {
+			return nil, http.StatusNotFound, "Product not found"
+		}
+		if product.VendorID == userID {
+			return nil, http.StatusBadRequest, "You can't message yourself about your own product"
+		}
+		return h.startConversation(ctx, models.Conversation{
+			BuyerID:   userID,
+			VendorID:  product.VendorID,
+			Subject:   models.ConversationProduct,
+			ProductID: &product.ID,
+			Title:     product.Name,
+		})
+
+	case input.OrderID != nil:
+		order, err := h.OrderRepo.GetOrderById(ctx, *input.OrderID)
+		if err != nil {
+			return nil, http.StatusNotFound, "Order not found"
+		}
+		conversation := models.Conversation{
+			BuyerID: order.UserID,
+			Subject: models.ConversationOrder,
+			OrderID: &order.ID,
+			Title:   order.OrderNumber,
+		}
+		vendors := orderVendorIDs(order)
+		switch {
+		case order.UserID == userID && input.VendorID != nil:
+			if !containsVendor(vendors, *input.VendorID) {
+				return nil, http.StatusBadRequest, "That vendor isn't on this order"
+			}
+			conversation.VendorID = *input.VendorID
+		case order.UserID == userID && len(vendors) == 1:
+			conversation.VendorID = vendors[0]
+		case order.UserID == userID:
+			return nil, http.StatusBadRequest, "This order has several vendors; say which with vendorId"
+		case containsVendor(vendors, userID):
+			conversation.VendorID = userID
+		default:
+			return nil, http.StatusNotFound, "Order not found"
+		}
+		return h.startConversation(ctx, conversation)
+	}
+	return nil, http.StatusBadRequest, "A conversationId, productId or orderId is required"
+}
+
+func (h *MessageHandler) startConversation(ctx context.Context, conversation models.Conversation) (*models.Conversation, int, string) {
+	saved, err := h.Repo.GetOrCreateConversation(ctx, conversation)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to start conversation"
+	}
+	return &saved, 0, ""
+}
+
+// GetConversations lists the user's conversations, most recently active
+// first, with how many messages they haven't read. Pages are numbered with
+// ?page= and ?limit=.
+func (h *MessageHandler) GetConversations(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	page, limit := pageParams(c, 20, 100)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	conversations, total, err := h.Repo.ListConversations(ctx, userID, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch conversations"))
+		return
+	}
+	unread, err := h.Repo.CountUnread(ctx, userID)
+	if err != nil {
+		logrus.WithError(err).WithField("userId", userID.Hex()).Warn("Failed to count unread messages")
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Conversations fetched", gin.H{
+		"conversations": conversations,
+		"unreadCount":   unread,
+		"meta":          models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetMessages returns a conversation's messages, newest first, paged with
+// ?cursor= and ?limit=. Clients without a live connection poll for new
+// messages with ?since= (RFC 3339) instead, which returns those sent after
+// it, oldest first. Reading them marks what the user received as read and
+// tells the sender.
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var since *time.Time
+	if raw := c.Query("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("since must be an RFC 3339 timestamp"))
+			return
+		}
+		since = &t
+	}
+	after, limit, ok := cursorParams(c, 30, 100)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	conversation, ok := h.loadConversation(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	var messages []models.Message
+	var meta interface{}
+	var err error
+	if since != nil {
+		// Read the clock first so nothing sent during the query is skipped
+		// by the client's next poll
+		now := time.Now()
+		messages, err = h.Repo.ListMessagesSince(ctx, conversation.ID, *since, int64(limit))
+		next := now
+		if len(messages) == limit {
+			next = messages[len(messages)-1].CreatedAt
+		}
+		meta = gin.H{"next": next, "pollInterval": int(livePollInterval.Seconds())}
+	} else {
+		messages, err = h.Repo.ListMessages(ctx, conversation.ID, after, int64(limit)+1)
+		if err == nil {
+			messages, meta = models.CursorPage(messages, limit, func(m models.Message) (time.Time, primitive.ObjectID) {
+				return m.CreatedAt, m.ID
+			})
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch messages"))
+		return
+	}
+
+	if conversation.UnreadFor(userID) > 0 {
+		if err := markConversation(ctx, h.Repo, *conversation, userID, models.ReceiptRead); err != nil {
+			logrus.WithError(err).WithField("conversationId", conversation.ID.Hex()).Warn("Failed to mark messages read")
+		}
+	}
+	conversation.Unread = 0
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Messages fetched", gin.H{
+		"conversation": conversation,
+		"messages":     messages,
+		"meta":         meta,
+	}))
+}
+
+// loadConversation fetches the conversation in the path and checks the user
+// is on it. It writes the error response itself.
+func (h *MessageHandler) loadConversation(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (*models.Conversation, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid conversation ID"))
+		return nil, false
+	}
+	conversation, err := h.Repo.GetConversation(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch conversation"))
+		return nil, false
+	}
+	if conversation == nil || !conversation.HasParticipant(userID) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Conversation not found"))
+		return nil, false
+	}
+	return conversation, true
+}
+
+// orderVendorIDs lists the vendors on an order, in the order they appear.
+func orderVendorIDs(order models.Order) []primitive.ObjectID {
+	var vendors []primitive.ObjectID
+	for _, item := range order.Items {
+		if !containsVendor(vendors, item.VendorID) {
+			vendors = append(vendors, item.VendorID)
+		}
+	}
+	return vendors
+}
+
+func containsVendor(vendors []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range vendors {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// typingTTL is how long clients show a typing indicator; they keep
+	// sending typing while the user is still typing.
+	typingTTL = 5 * time.Second
+	// typingThrottle drops typing signals from a user in a conversation that
+	// come closer together than this.
+	typingThrottle = 2 * time.Second
+)
+
+var typingSeen = utils.NewTTLCache[struct{}](typingThrottle)
+
+// publishTyping shows the other side of the conversation that userID is
+// typing. Typing isn't stored; a client that misses it just doesn't show it.
+func publishTyping(conversation models.Conversation, userID primitive.ObjectID) {
+	key := conversation.ID.Hex() + ":" + userID.Hex()
+	if _, ok := typingSeen.Get(key); ok {
+		return
+	}
+	typingSeen.Set(key, struct{}{})
+
+	liveUpdates.publish(conversation.Recipient(userID), LiveTyping, gin.H{
+		"conversationId": conversation.ID.Hex(),
+		"userId":         userID.Hex(),
+		"expiresIn":      int(typingTTL.Seconds()),
+	})
+}
+
+// markConversation records that userID received (models.ReceiptDelivered)
+// or read (models.ReceiptRead) the messages sent to them in the conversation
+// and sends the receipt to the other side.
+func markConversation(ctx context.Context, repo repository.MessageRepository, conversation models.Conversation, userID primitive.ObjectID, receipt string) error {
+	now := time.Now()
+	switch receipt {
+	case models.ReceiptDelivered:
+		marked, err := repo.MarkDelivered(ctx, conversation.ID, userID)
+		if err != nil || marked == 0 {
+			return err
+		}
+	case models.ReceiptRead:
+		if err := repo.MarkRead(ctx, conversation, userID); err != nil {
+			return err
+		}
+	}
+
+	liveUpdates.publish(conversation.Recipient(userID), LiveReceipt, gin.H{
+		"conversationId": conversation.ID.Hex(),
+		"receipt":        receipt,
+		"userId":         userID.Hex(),
+		"at":             now,
+	})
+	return nil
+}
+
+// SendTyping is the REST fallback for the live socket's typing signal.
+func (h *MessageHandler) SendTyping(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	conversation, ok := h.loadConversation(ctx, c, userID)
+	if !ok {
+		return
+	}
+	publishTyping(*conversation, userID)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Typing sent", gin.H{"expiresIn": int(typingTTL.Seconds())}))
+}
+
+// MarkConversationDelivered is the REST fallback for the live socket's
+// delivery receipt: the user's device has the conversation's messages.
+func (h *MessageHandler) MarkConversationDelivered(c *gin.Context) {
+	h.markConversation(c, models.ReceiptDelivered)
+}
+
+// MarkConversationRead is the REST fallback for the live socket's read
+// receipt.
+func (h *MessageHandler) MarkConversationRead(c *gin.Context) {
+	h.markConversation(c, models.ReceiptRead)
+}
+
+func (h *MessageHandler) markConversation(c *gin.Context, receipt string) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	conversation, ok := h.loadConversation(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if err := markConversation(ctx, h.Repo, *conversation, userID, receipt); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to mark messages "+receipt))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Messages marked "+receipt, nil))
+}
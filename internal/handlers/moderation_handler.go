@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ModerationHandler struct {
+	DB          *mongo.Database
+	Repo        repository.ModerationRepository
+	ProductRepo repository.ProductRepository
+}
+
+func NewModerationHandler(db *mongo.Database) *ModerationHandler {
+	return &ModerationHandler{
+		DB:          db,
+		Repo:        repository.NewModerationRepository(db),
+		ProductRepo: repository.NewProductRepository(db),
+	}
+}
+
+// moderationKeywords is the default keyword list plus any extra terms from
+// the comma-separated MODERATION_KEYWORDS variable.
+func moderationKeywords() []string {
+	keywords := append([]string{}, models.DefaultModerationKeywords...)
+	for _, kw := range strings.Split(os.Getenv("MODERATION_KEYWORDS"), ",") {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+	return keywords
+}
+
+// screenProductListing runs the automated keyword check on a listing and
+// queues it for review on a match. It is meant to run in the background after
+// a product is created or edited.
+func screenProductListing(repo repository.ModerationRepository, product models.Product) {
+	texts := append([]string{product.Name, product.Description, product.Brand}, product.Tags...)
+	hits := models.MatchModerationKeywords(moderationKeywords(), texts...)
+	if len(hits) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := repo.AddFlag(ctx, product, models.ModerationFlag{
+		Source:   models.FlagSourceAutomated,
+		Reason:   "keyword_match",
+		Keywords: hits,
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("productId", product.ID.Hex()).Error("Failed to flag product from keyword screening")
+	}
+}
+
+// notifyVendorModeration emails the vendor about a moderation decision on one
+// of their listings.
+func notifyVendorModeration(db *mongo.Database, vendorID primitive.ObjectID, subject, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"_id": vendorID}).Decode(&user); err != nil || user.Email == "" {
+		return
+	}
+	body := fmt.Sprintf("<p>Hello %s,</p><p>%s</p><p>You can review this decision and appeal it from your vendor dashboard.</p><p>Best regards,<br>The Vendora Team</p>",
+		html.EscapeString(user.Name), message)
+	if err := utils.SendEmail(user.Email, subject, body); err != nil {
+		logrus.WithError(err).WithField("vendorId", vendorID.Hex()).Error("Failed to send moderation email")
+	}
+}
+
+// ReportProduct lets a signed-in user report a listing for review.
+func (h *ModerationHandler) ReportProduct(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	productID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid product ID"))
+		return
+	}
+
+	var input struct {
+		Reason  string `json:"reason" binding:"required"`
+		Details string `json:"details" binding:"max=1000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	validReason := false
+	for _, r := range models.ProductReportReasons {
+		if input.Reason == r {
+			validReason = true
+			break
+		}
+	}
+	if !validReason {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Reason must be one of: "+strings.Join(models.ProductReportReasons, ", ")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	product, err := h.ProductRepo.GetProduct(ctx, bson.M{"_id": productID, "status": models.ProductStatusActive})
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Product not found"))
+		return
+	}
+	if product.VendorID == userID {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You cannot report your own product"))
+		return
+	}
+
+	reported, err := h.Repo.HasOpenReport(ctx, productID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to submit report"))
+		return
+	}
+	if reported {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("You have already reported this product"))
+		return
+	}
+
+	if _, err := h.Repo.AddFlag(ctx, product, models.ModerationFlag{
+		Source:     models.FlagSourceUserReport,
+		ReporterID: &userID,
+		Reason:     input.Reason,
+		Details:    strings.TrimSpace(input.Details),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to submit report"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Thanks, our team will review this listing", nil))
+}
+
+// GetVendorModerationCases lists moderation cases on the vendor's products.
+func (h *ModerationHandler) GetVendorModerationCases(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// Vendors only see decided cases; open ones are still under review
+	filter := bson.M{"vendorID": vendorID, "status": models.CaseStatusActioned}
+	cases, total, err := h.Repo.ListCases(ctx, filter, 100, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch moderation cases"))
+		return
+	}
+
+	// Reporter identities stay private
+	for i := range cases {
+		for j := range cases[i].Flags {
+			cases[i].Flags[j].ReporterID = nil
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Moderation cases fetched", gin.H{"cases": cases, "total": total}))
+}
+
+// AppealModerationCase lets a vendor appeal a moderation decision once.
+func (h *ModerationHandler) AppealModerationCase(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	caseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid case ID"))
+		return
+	}
+
+	var input struct {
+		Message string `json:"message" binding:"required,min=10,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Please explain your appeal (10-2000 characters)"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.Repo.SubmitAppeal(ctx, caseID, vendorID, strings.TrimSpace(input.Message))
+	if err == repository.ErrAppealNotAllowed {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This decision cannot be appealed or has already been appealed"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to submit appeal"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Appeal submitted", gin.H{"appeal": updated.Appeal}))
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EmailContent is either a built-in template with its data, or a free-form
+// subject and HTML body.
+type EmailContent struct {
+	Template string
+	Data     interface{}
+	Subject  string
+	HTML     string
+}
+
+// OutgoingNotification is one notification in the form each channel needs.
+// Channels without content are skipped.
+type OutgoingNotification struct {
+	Type string
+
+	// In-app (and push, once a push backend exists)
+	Title string
+	Body  string
+	Link  string
+	Data  map[string]interface{}
+
+	// Email is built from the recipient so templates can address them
+	Email func(user models.User) EmailContent
+
+	SMS string
+}
+
+// NotificationDispatcher sends a notification to a user over every channel
+// their preferences allow for its type.
+type NotificationDispatcher struct {
+	Repo     repository.NotificationRepository
+	UserRepo repository.UserRepository
+}
+
+func NewNotificationDispatcher(db *mongo.Database) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		Repo:     repository.NewNotificationRepository(db),
+		UserRepo: repository.NewUserRepository(db),
+	}
+}
+
+// Dispatch delivers n to userID. Delivery failures are logged per channel so
+// one failing channel doesn't stop the others.
+func (d *NotificationDispatcher) Dispatch(ctx context.Context, userID primitive.ObjectID, n OutgoingNotification) {
+	user, err := d.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			logrus.WithError(err).WithField("userId", userID.Hex()).Error("Failed to load notification recipient")
+		}
+		return
+	}
+	prefs := user.NotificationPreferences
+	log := logrus.WithFields(logrus.Fields{"userId": userID.Hex(), "type": n.Type})
+
+	// 1. In-app
+	if n.Title != "" && prefs.Allows(n.Type, models.ChannelInApp) {
+		notification := models.Notification{
+			UserID: userID,
+			Type:   n.Type,
+			Title:  n.Title,
+			Body:   n.Body,
+			Link:   n.Link,
+			Data:   n.Data,
+		}
+		if err := d.Repo.Create(ctx, &notification); err != nil {
+			log.WithError(err).Error("Failed to create notification")
+		} else {
+			liveUpdates.publish(userID, LiveNotification, notification)
+		}
+	}
+
+	// 2. Email
+	if n.Email != nil && user.Email != "" && prefs.Allows(n.Type, models.ChannelEmail) {
+		content := n.Email(user)
+		if content.Template != "" {
+			err = utils.SendTemplateEmail(user.Email, content.Template, content.Data)
+		} else {
+			err = utils.SendEmail(user.Email, content.Subject, content.HTML)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to send notification email")
+		}
+	}
+
+	// 3. SMS, to verified numbers only
+	if n.SMS != "" && user.PhoneVerified && user.Phone != "" && prefs.Allows(n.Type, models.ChannelSMS) {
+		if err := utils.SendSMS(user.Phone, n.SMS); err != nil && err != utils.ErrSMSNotConfigured {
+			log.WithError(err).Error("Failed to send notification SMS")
+		}
+	}
+
+	// Push preferences are stored and returned to clients, but there is no
+	// push backend to deliver through yet.
+}
@@ -0,0 +1,643 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type NotificationHandler struct {
+	Repo       repository.NotificationRepository
+	UserRepo   repository.UserRepository
+	Dispatcher *NotificationDispatcher
+}
+
+func NewNotificationHandler(db *mongo.Database) *NotificationHandler {
+	return &NotificationHandler{
+		Repo:       repository.NewNotificationRepository(db),
+		UserRepo:   repository.NewUserRepository(db),
+		Dispatcher: NewNotificationDispatcher(db),
+	}
+}
+
+// GetNotifications lists the current user's notifications, newest first.
+// Pass ?unread=true for unread ones only. Pages are numbered with ?page=,
+// or for scrolling back through history, pass ?cursor= (empty for the first
+// page, then meta.nextCursor from the previous response).
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	unreadOnly := c.Query("unread") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// Cursor pagination
+	if wantsCursor(c) {
+		after, limit, ok := cursorParams(c, 20, 100)
+		if !ok {
+			return
+		}
+		notifications, err := h.Repo.ListPage(ctx, userID, unreadOnly, after, int64(limit)+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch notifications"))
+			return
+		}
+		notifications, meta := models.CursorPage(notifications, limit, func(n models.Notification) (time.Time, primitive.ObjectID) {
+			return n.CreatedAt, n.ID
+		})
+		unread, _ := h.Repo.CountUnread(ctx, userID)
+
+		c.JSON(http.StatusOK, utils.SuccessResponse("Notifications fetched", gin.H{
+			"notifications": notifications,
+			"unreadCount":   unread,
+			"meta":          meta,
+		}))
+		return
+	}
+
+	page, limit := pageParams(c, 20, 100)
+
+	notifications, total, err := h.Repo.ListForUser(ctx, userID, unreadOnly, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch notifications"))
+		return
+	}
+	unread, _ := h.Repo.CountUnread(ctx, userID)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Notifications fetched", gin.H{
+		"notifications": notifications,
+		"unreadCount":   unread,
+		"meta":          models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// GetUnreadCount returns how many unread notifications the user has, for the
+// header badge. It reads a maintained counter, so it is cheap enough to poll.
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	unread, err := h.Repo.CountUnread(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to count notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Unread count fetched", gin.H{"unreadCount": unread}))
+}
+
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid notification ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	found, err := h.Repo.MarkRead(ctx, id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update notification"))
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Notification not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Notification marked as read", nil))
+}
+
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.Repo.MarkAllRead(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("All notifications marked as read", gin.H{"updated": updated}))
+}
+
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid notification ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	found, err := h.Repo.Delete(ctx, id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete notification"))
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Notification not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Notification deleted", nil))
+}
+
+// GetNotificationPreferences returns the user's effective channel ×
+// notification-type matrix along with which channels each type supports.
+func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Notification preferences fetched", notificationPreferencesResponse(user.NotificationPreferences, user.PhoneVerified)))
+}
+
+// UpdateNotificationPreferences switches channels on or off per notification
+// type. Only the cells sent are changed; the rest keep their setting.
+func (h *NotificationHandler) UpdateNotificationPreferences(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Channels  map[string]map[string]bool `json:"channels"`
+		SMSOptOut *bool                      `json:"smsOptOut"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	for kind, channels := range input.Channels {
+		if _, ok := models.NotificationEventChannels[kind]; !ok {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Unknown notification type: "+kind))
+			return
+		}
+		for channel := range channels {
+			if !models.SupportsChannel(kind, channel) {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse(fmt.Sprintf("%s notifications can't be sent by %s", kind, channel)))
+				return
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+
+	// Merge into what is stored
+	prefs := models.NotificationPreferences{}
+	if user.NotificationPreferences != nil {
+		prefs = *user.NotificationPreferences
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = make(map[string]map[string]bool)
+	}
+	for kind, channels := range input.Channels {
+		if prefs.Channels[kind] == nil {
+			prefs.Channels[kind] = make(map[string]bool)
+		}
+		for channel, enabled := range channels {
+			prefs.Channels[kind][channel] = enabled
+		}
+	}
+	if input.SMSOptOut != nil && *input.SMSOptOut != prefs.SMSOptOut {
+		prefs.SMSOptOut = *input.SMSOptOut
+		prefs.SMSOptedOutAt = nil
+		if prefs.SMSOptOut {
+			now := time.Now()
+			prefs.SMSOptedOutAt = &now
+		}
+	}
+
+	if err := h.UserRepo.UpdateNotificationPreferences(ctx, userID, prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Notification preferences updated", notificationPreferencesResponse(&prefs, user.PhoneVerified)))
+}
+
+// Unsubscribe turns off email for one notification type using the signed
+// token from an email footer. It needs no login, so the link works from any
+// mail client.
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	userIdStr, kind, err := utils.VerifyUnsubscribeToken(c.Param("token"))
+	if err != nil || !models.SupportsChannel(kind, models.ChannelEmail) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid unsubscribe link"))
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(userIdStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid unsubscribe link"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+
+	prefs := models.NotificationPreferences{}
+	if user.NotificationPreferences != nil {
+		prefs = *user.NotificationPreferences
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = make(map[string]map[string]bool)
+	}
+	if prefs.Channels[kind] == nil {
+		prefs.Channels[kind] = make(map[string]bool)
+	}
+	prefs.Channels[kind][models.ChannelEmail] = false
+
+	if err := h.UserRepo.UpdateNotificationPreferences(ctx, userID, prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to unsubscribe"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("You have been unsubscribed", gin.H{
+		"type":    kind,
+		"channel": models.ChannelEmail,
+	}))
+}
+
+func notificationPreferencesResponse(prefs *models.NotificationPreferences, phoneVerified bool) gin.H {
+	smsOptOut := prefs != nil && prefs.SMSOptOut
+	return gin.H{
+		"channels":      models.NotificationChannels,
+		"supported":     models.NotificationEventChannels,
+		"preferences":   prefs.Matrix(),
+		"smsOptOut":     smsOptOut,
+		"phoneVerified": phoneVerified, // SMS only reaches verified numbers
+	}
+}
+
+// HandleEvent is a bus handler that turns domain events into notifications
+// for the users they concern.
+func (h *NotificationHandler) HandleEvent(ctx context.Context, e events.Event) {
+	for _, n := range notificationsForEvent(e) {
+		h.Dispatcher.Dispatch(ctx, n.UserID, n.OutgoingNotification)
+	}
+}
+
+// eventString reads a string field from event data.
+func eventString(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
+
+// eventInt reads a whole number from event data. Events relayed from the
+// outbox have been through JSON, which makes every number a float64.
+func eventInt(data map[string]interface{}, key string) int {
+	switch v := data[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// eventIDs reads one or more hex IDs from event data, skipping bad ones.
+func eventIDs(data map[string]interface{}, key string) []primitive.ObjectID {
+	var raw []string
+	switch v := data[key].(type) {
+	case string:
+		raw = []string{v}
+	case []string:
+		raw = v
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(raw))
+	for _, s := range raw {
+		if id, err := primitive.ObjectIDFromHex(s); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// eventOrderItems reads the item lines from an order event, keeping only
+// one vendor's lines when vendorID is set, and totals them.
+func eventOrderItems(data map[string]interface{}, vendorID string) ([]utils.OrderEmailItem, float64) {
+	var items []utils.OrderEmailItem
+	var total float64
+	var lines []map[string]interface{}
+	switch v := data["items"].(type) {
+	case []map[string]interface{}:
+		lines = v
+	case []interface{}:
+		for _, item := range v {
+			if line, ok := item.(map[string]interface{}); ok {
+				lines = append(lines, line)
+			}
+		}
+	}
+	for _, line := range lines {
+		if vendorID != "" && eventString(line, "vendorId") != vendorID {
+			continue
+		}
+		subtotal, _ := line["subtotal"].(float64)
+		items = append(items, utils.OrderEmailItem{Name: eventString(line, "name"), Quantity: eventInt(line, "quantity"), Subtotal: subtotal})
+		total += subtotal
+	}
+	return items, total
+}
+
+// itemSummary describes order lines in a few words, e.g. "Lamp x2 and 1
+// more item".
+func itemSummary(items []utils.OrderEmailItem) string {
+	switch len(items) {
+	case 0:
+		return "An order"
+	case 1:
+		return fmt.Sprintf("%s x%d", items[0].Name, items[0].Quantity)
+	case 2:
+		return fmt.Sprintf("%s x%d and 1 more item", items[0].Name, items[0].Quantity)
+	}
+	return fmt.Sprintf("%s x%d and %d more items", items[0].Name, items[0].Quantity, len(items)-1)
+}
+
+// addressedNotification is a notification for one recipient.
+type addressedNotification struct {
+	UserID primitive.ObjectID
+	OutgoingNotification
+}
+
+// notificationsForEvent decides who hears about an event and what they see
+// on each channel.
+func notificationsForEvent(e events.Event) []addressedNotification {
+	var out []addressedNotification
+	notify := func(userIDs []primitive.ObjectID, n OutgoingNotification) {
+		n.Data = e.Data
+		for _, id := range userIDs {
+			out = append(out, addressedNotification{UserID: id, OutgoingNotification: n})
+		}
+	}
+
+	d := e.Data
+	orderNumber := eventString(d, "orderNumber")
+	orderLink := "/orders/" + eventString(d, "orderId")
+
+	switch e.Type {
+	case events.OrderPlaced:
+		total, _ := d["total"].(float64)
+		items, _ := eventOrderItems(d, "")
+		notify(eventIDs(d, "buyerId"), OutgoingNotification{
+			Type: models.NotificationOrderUpdate,
+			Email: func(user models.User) EmailContent {
+				return EmailContent{Template: utils.EmailTemplateOrderPlaced, Data: utils.OrderConfirmationEmailData{
+					Name:        user.Name,
+					OrderNumber: orderNumber,
+					Items:       items,
+					Total:       total,
+				}}
+			},
+		})
+
+	case events.OrderPaid:
+		// Each vendor hears about their own lines only
+		for _, vendorID := range eventIDs(d, "vendorIds") {
+			items, subtotal := eventOrderItems(d, vendorID.Hex())
+			link := "/vendor/orders/" + eventString(d, "orderId")
+			notify([]primitive.ObjectID{vendorID}, OutgoingNotification{
+				Type:  models.NotificationNewOrder,
+				Title: "New order " + orderNumber,
+				Body:  fmt.Sprintf("%s ($%.2f) paid and ready to fulfil.", itemSummary(items), subtotal),
+				Link:  link,
+				Email: func(user models.User) EmailContent {
+					return EmailContent{Template: utils.EmailTemplateVendorNewOrder, Data: utils.VendorNewOrderEmailData{
+						Name:        user.Name,
+						OrderNumber: orderNumber,
+						Items:       items,
+						Subtotal:    subtotal,
+						Link:        "https://vendora-f.vercel.app" + link,
+					}}
+				},
+			})
+		}
+
+	case events.OrderStatusChanged:
+		status := strings.ReplaceAll(eventString(d, "status"), "_", " ")
+		if eventString(d, "actor") == "buyer" {
+			notify(eventIDs(d, "vendorIds"), OutgoingNotification{
+				Type:  models.NotificationOrderUpdate,
+				Title: "Order " + orderNumber + " " + status,
+				Body:  "The buyer updated order " + orderNumber + " to " + status + ".",
+				Link:  "/vendor/orders",
+			})
+			break
+		}
+
+		n := OutgoingNotification{
+			Type:  models.NotificationOrderUpdate,
+			Title: "Order " + orderNumber + " " + status,
+			Body:  "Your order " + orderNumber + " is now " + status + ".",
+			Link:  orderLink,
+		}
+		switch models.OrderStatus(eventString(d, "status")) {
+		case models.StatusOutForDelivery:
+			n.Type = models.NotificationOutForDelivery
+			n.SMS = "Vendora: your order " + orderNumber + " is out for delivery and should arrive today."
+			fallthrough
+		case models.StatusShipped, models.StatusDelivered:
+			tracking := eventString(d, "trackingNumber")
+			n.Email = func(user models.User) EmailContent {
+				return EmailContent{Template: utils.EmailTemplateShippingUpdate, Data: utils.ShippingUpdateEmailData{
+					Name:           user.Name,
+					OrderNumber:    orderNumber,
+					Status:         status,
+					TrackingNumber: tracking,
+					TrackingURL:    utils.TrackingURL(tracking),
+					Link:           "https://vendora-f.vercel.app" + orderLink,
+				}}
+			}
+		}
+		notify(eventIDs(d, "buyerId"), n)
+
+	case events.OrderRefunded:
+		amount, _ := d["amount"].(float64)
+		notify(eventIDs(d, "buyerId"), OutgoingNotification{
+			Type:  models.NotificationRefund,
+			Title: "Refund issued",
+			Body:  fmt.Sprintf("We've refunded $%.2f on order %s.", amount, orderNumber),
+			Link:  orderLink,
+			Email: func(user models.User) EmailContent {
+				return EmailContent{
+					Subject: "Refund issued for order " + orderNumber,
+					HTML: fmt.Sprintf("<p>Hello %s,</p><p>We've issued a refund of <strong>$%.2f</strong> for order <strong>%s</strong>. It should reach your original payment method within 5-10 business days.</p><p>Best regards,<br>The Vendora Team</p>",
+						html.EscapeString(user.Name), amount, html.EscapeString(orderNumber)),
+				}
+			},
+		})
+
+	case events.PayoutSent:
+		amount, _ := d["amount"].(float64)
+		reference := eventString(d, "reference")
+		notify(eventIDs(d, "vendorId"), OutgoingNotification{
+			Type:  models.NotificationPayoutSent,
+			Title: "Payout sent",
+			Body:  fmt.Sprintf("Your payout of $%.2f (ref %s) has been sent.", amount, reference),
+			Link:  "/vendor/wallet",
+			SMS:   fmt.Sprintf("Vendora: your payout of $%.2f (ref %s) has been sent.", amount, reference),
+		})
+
+	case events.ApplicationApproved:
+		notify(eventIDs(d, "userId"), OutgoingNotification{
+			Type:  models.NotificationApplication,
+			Title: "Your seller application was approved",
+			Body:  "Welcome to Vendora! Your store is ready to set up.",
+			Link:  "/vendor/dashboard",
+			Email: func(user models.User) EmailContent {
+				return EmailContent{Template: utils.EmailTemplateVendorApproval, Data: utils.VendorApprovalEmailData{
+					Name: user.Name,
+					Link: "https://vendora-f.vercel.app/vendor/dashboard",
+				}}
+			},
+		})
+
+	case events.ApplicationRejected:
+		notify(eventIDs(d, "userId"), OutgoingNotification{
+			Type:  models.NotificationApplication,
+			Title: "Your seller application was not approved",
+			Body:  "Unfortunately we couldn't approve your application this time.",
+			Link:  "/seller/application",
+		})
+
+	case events.TierRequestApproved:
+		notify(eventIDs(d, "vendorId"), OutgoingNotification{
+			Type:  models.NotificationTierRequest,
+			Title: "Tier upgrade approved",
+			Body:  "Your account has been upgraded to the " + eventString(d, "tier") + " tier.",
+			Link:  "/vendor/settings",
+		})
+
+	case events.TierRequestRejected:
+		notify(eventIDs(d, "vendorId"), OutgoingNotification{
+			Type:  models.NotificationTierRequest,
+			Title: "Tier upgrade not approved",
+			Body:  "Your request for the " + eventString(d, "tier") + " tier was not approved.",
+			Link:  "/vendor/settings",
+		})
+
+	case events.ReviewCreated:
+		rating := eventInt(d, "rating")
+		notify(eventIDs(d, "vendorId"), OutgoingNotification{
+			Type:  models.NotificationNewReview,
+			Title: "New review",
+			Body:  fmt.Sprintf("A buyer left a %d-star review on one of your products.", rating),
+			Link:  "/vendor/reviews",
+		})
+
+	case events.ReviewResponded:
+		notify(eventIDs(d, "reviewerId"), OutgoingNotification{
+			Type:  models.NotificationReviewResponse,
+			Title: "The seller replied to your review",
+			Body:  "The seller responded to your review.",
+			Link:  "/products/" + eventString(d, "productId"),
+		})
+
+	case events.MessageReceived:
+		notify(eventIDs(d, "recipientId"), OutgoingNotification{
+			Type:  models.NotificationMessage,
+			Title: "New message",
+			Body:  eventString(d, "preview"),
+			Link:  "/messages/" + eventString(d, "conversationId"),
+		})
+
+	case events.DisputeOpened:
+		amount, _ := d["amount"].(float64)
+		respondBy, _ := d["respondBy"].(time.Time)
+		notify(eventIDs(d, "vendorId"), OutgoingNotification{
+			Type:  models.NotificationDispute,
+			Title: "Order " + orderNumber + " disputed",
+			Body: fmt.Sprintf("The buyer says the order was %s and is asking for $%.2f. Please respond by %s.",
+				strings.ReplaceAll(eventString(d, "reason"), "_", " "), amount, respondBy.Format("Jan 2")),
+			Link: "/vendor/disputes/" + eventString(d, "disputeId"),
+		})
+
+	case events.DisputeUpdated:
+		n := OutgoingNotification{
+			Type:  models.NotificationDispute,
+			Title: "New reply on the dispute for order " + orderNumber,
+			Body:  eventString(d, "preview"),
+			Link:  "/disputes/" + eventString(d, "disputeId"),
+		}
+		switch eventString(d, "action") {
+		case "responded":
+			n.Title = "The seller responded to your dispute"
+		case "escalated":
+			n.Title = "The dispute for order " + orderNumber + " was escalated"
+			n.Body = "Vendora will review the dispute and make a decision. " + n.Body
+		case "info_requested":
+			n.Title = "Vendora needs more information about the dispute for order " + orderNumber
+		}
+		notify(eventIDs(d, "recipientId"), n)
+
+	case events.DisputeResolved:
+		refund, _ := d["refundAmount"].(float64)
+		body := "The dispute for order " + orderNumber + " was closed and the seller keeps the payment."
+		switch eventString(d, "outcome") {
+		case models.DisputeOutcomeRefund:
+			body = fmt.Sprintf("The dispute for order %s was resolved with a $%.2f refund to the buyer.", orderNumber, refund)
+		case models.DisputeOutcomeSplit:
+			body = fmt.Sprintf("The dispute for order %s was settled with a $%.2f partial refund to the buyer; the seller keeps the rest.", orderNumber, refund)
+		}
+		notify(append(eventIDs(d, "buyerId"), eventIDs(d, "vendorId")...), OutgoingNotification{
+			Type:  models.NotificationDispute,
+			Title: "Dispute resolved",
+			Body:  body,
+			Link:  "/disputes/" + eventString(d, "disputeId"),
+		})
+	}
+	return out
+}
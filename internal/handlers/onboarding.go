@@ -2,20 +2,21 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/internal/services"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -24,17 +25,21 @@ import (
 
 type OnboardingHandler struct {
 	DB        *mongo.Database
+	StoreRepo repository.StoreRepository
 	AIService *services.VerificationService
+	Media     *services.MediaService
 }
 
-func NewOnboardingHandler(db *mongo.Database) *OnboardingHandler {
+func NewOnboardingHandler(db *mongo.Database, media *services.MediaService) *OnboardingHandler {
 	return &OnboardingHandler{
 		DB:        db,
+		StoreRepo: repository.NewStoreRepository(db),
 		AIService: nil,
+		Media:     media,
 	}
 }
 
-var onboardingValidator = validator.New()
+var onboardingValidator = utils.NewValidator()
 
 func (h *OnboardingHandler) ClientUpdateInterest(c *gin.Context) {
 	// Get userId from context (set by AuthMiddleware)
@@ -61,7 +66,7 @@ func (h *OnboardingHandler) ClientUpdateInterest(c *gin.Context) {
 		return
 	}
 	if err := onboardingValidator.Struct(userInterest); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Validation failed: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -103,11 +108,11 @@ func (h *OnboardingHandler) ClientUpdatePreference(c *gin.Context) {
 	var userPref models.UserPreferences
 
 	if err := c.ShouldBindJSON(&userPref); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Validation failed: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 	if err := onboardingValidator.Struct(&userPref); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Validation failed: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -166,25 +171,6 @@ func (h *OnboardingHandler) CompleteOnboardingFlow(c *gin.Context) {
 		return
 	}
 
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-	}
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Only JPEG/PNG images are allowed"))
-		return
-	}
-
-	cld, err := cloudinary.NewFromParams(
-		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		os.Getenv("CLOUDINARY_API_KEY"),
-		os.Getenv("CLOUDINARY_API_SECRET"),
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to initialize Cloudinary"))
-		return
-	}
-
 	src, err := file.Open()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to process image"))
@@ -192,17 +178,15 @@ func (h *OnboardingHandler) CompleteOnboardingFlow(c *gin.Context) {
 	}
 	defer src.Close()
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
-	defer cancel()
-
-	uploadResult, err := cld.Upload.Upload(ctx, src, uploader.UploadParams{
-		Folder: "users/profiles",
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to upload image"))
+	// One profile picture per user; a new one replaces the old file
+	picture, err := h.Media.Upload(c.Request.Context(), services.MediaProfilePicture, objectId, src, file)
+	if !uploadSucceeded(c, err, services.MediaProfilePicture, "Unsupported profile picture; upload ") {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
 	collection := h.DB.Collection("users")
 	filter := bson.M{"_id": objectId}
 
@@ -212,7 +196,7 @@ func (h *OnboardingHandler) CompleteOnboardingFlow(c *gin.Context) {
 			"profile": bson.M{
 				"location":     location,
 				"bio":          bio,
-				"profileImage": uploadResult.SecureURL,
+				"profileImage": picture.URL,
 			},
 			"onboardingCompleted": true,
 			"updatedAt":           time.Now(),
@@ -235,7 +219,7 @@ func (h *OnboardingHandler) CompleteOnboardingFlow(c *gin.Context) {
 		"profile": gin.H{
 			"location":     location,
 			"bio":          bio,
-			"profileImage": uploadResult.SecureURL,
+			"profileImage": picture.URL,
 		},
 	}))
 }
@@ -266,10 +250,22 @@ func (h *OnboardingHandler) UserOnboardingDraft(c *gin.Context) {
 		return
 	}
 	if err := onboardingValidator.Struct(&input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Validation failed: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
+	// Vendor drafts are checked against the typed step schema and stored in
+	// that shape; other roles keep their free-form step data.
+	var stepData interface{} = input.StepData
+	if input.Role == "vendor" {
+		vendorData, err := validateVendorDraft(&input)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+			return
+		}
+		stepData = vendorData
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
@@ -280,7 +276,7 @@ func (h *OnboardingHandler) UserOnboardingDraft(c *gin.Context) {
 		"$set": bson.M{
 			"step":          input.Step,
 			"stepCompleted": input.StepCompleted,
-			"stepData":      input.StepData,
+			"stepData":      stepData,
 			"role":          input.Role,
 			"updatedAt":     time.Now(),
 		},
@@ -304,6 +300,7 @@ func (h *OnboardingHandler) UserOnboardingDraft(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save draft"))
 		return
 	}
+	publishDraftSaved(saved)
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Draft saved successfully", gin.H{
 		"success": true,
@@ -320,6 +317,68 @@ func (h *OnboardingHandler) UserOnboardingDraft(c *gin.Context) {
 	}))
 }
 
+// publishDraftSaved announces a draft write on the event bus.
+func publishDraftSaved(draft models.UserOnboardingDraft) {
+	events.Publish(events.DraftSaved, map[string]interface{}{
+		"draftId": draft.ID.Hex(),
+		"userId":  draft.UserID.Hex(),
+		"role":    draft.Role,
+		"step":    draft.Step,
+		"version": draft.Version,
+	})
+}
+
+// validateVendorDraft decodes a vendor draft's stepData into the typed schema.
+// Only the current step is validated, once it is marked completed; data the
+// client already holds for other steps is kept as it is.
+func validateVendorDraft(input *models.UserOnboardingDraft) (*models.VendorDraftStepData, error) {
+	if input.Step < 1 || input.Step > len(models.VendorDraftSteps)+1 {
+		return nil, fmt.Errorf("step must be between 1 and %d", len(models.VendorDraftSteps)+1)
+	}
+
+	raw, err := json.Marshal(input.StepData)
+	if err != nil {
+		return nil, err
+	}
+	var data models.VendorDraftStepData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("stepData does not match the vendor onboarding schema: %v", err)
+	}
+
+	if !input.StepCompleted || input.Step > len(models.VendorDraftSteps) {
+		return &data, nil
+	}
+
+	key := models.VendorDraftSteps[input.Step-1]
+	switch key {
+	case "businessInfo":
+		if data.BusinessInfo == nil {
+			return nil, fmt.Errorf("stepData.%s is required to complete step %d", key, input.Step)
+		}
+		// The tier is picked later and defaults to individual on submit
+		err = onboardingValidator.StructExcept(data.BusinessInfo, "RequestedTier")
+		if err == nil {
+			err = onboardingValidator.Var(data.BusinessInfo.RequestedTier, "omitempty,oneof=individual verified business")
+		}
+	case "categories":
+		err = onboardingValidator.Var(data.Categories, "required,min=1,max=5,dive,required")
+	case "businessDetails":
+		if data.BusinessDetails == nil {
+			return nil, fmt.Errorf("stepData.%s is required to complete step %d", key, input.Step)
+		}
+		err = onboardingValidator.Struct(data.BusinessDetails)
+	case "storeDetails":
+		if data.StoreDetails == nil || data.StoreDetails.StoreName == "" {
+			return nil, fmt.Errorf("stepData.%s.storeName is required to complete step %d", key, input.Step)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stepData.%s: %v", key, err)
+	}
+
+	return &data, nil
+}
+
 func (h *OnboardingHandler) GetOnboardingDraft(c *gin.Context) {
 	authHeader := c.Request.Header.Get("Authorization")
 	if !strings.HasPrefix(authHeader, "Bearer ") {
@@ -393,7 +452,7 @@ func (h *OnboardingHandler) SellerBusinessType(c *gin.Context) {
 		return
 	}
 	if err := onboardingValidator.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -418,6 +477,7 @@ func (h *OnboardingHandler) SellerBusinessType(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save: "+err.Error()))
 		return
 	}
+	publishDraftSaved(saved)
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Business details updated", gin.H{
 		"success": true,
@@ -446,7 +506,7 @@ func (h *OnboardingHandler) SellerBusinessCategory(c *gin.Context) {
 		return
 	}
 	if err := onboardingValidator.Struct(input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -470,6 +530,7 @@ func (h *OnboardingHandler) SellerBusinessCategory(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save: "+err.Error()))
 		return
 	}
+	publishDraftSaved(saved)
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Business categories updated", gin.H{
 		"success": true,
@@ -490,13 +551,13 @@ func (h *OnboardingHandler) SellerBusinessInfo(c *gin.Context) {
 	}
 	var input models.BusinessDetails
 	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.Log(c).WithError(err).Debug("Invalid business details payload")
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
-		fmt.Println("Error", err)
 		return
 	}
 	if err := onboardingValidator.Struct(&input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
-		fmt.Println("Error", err)
+		middleware.Log(c).WithError(err).Debug("Invalid business details")
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 	userID, _ := primitive.ObjectIDFromHex(claims.UserID)
@@ -529,6 +590,7 @@ func (h *OnboardingHandler) SellerBusinessInfo(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save: "+err.Error()))
 		return
 	}
+	publishDraftSaved(saved)
 	c.JSON(http.StatusOK, utils.SuccessResponse("Business details updated", gin.H{
 		"success": true,
 		"data": gin.H{
@@ -560,59 +622,43 @@ func (h *OnboardingHandler) StoreDetails(c *gin.Context) {
 	storeDescription := c.PostForm("storeDescription")
 	primaryColor := c.PostForm("primaryColor")
 	accentColor := c.PostForm("accentColor")
-	file, err := c.FormFile("storeLogo")
+	details := models.StoreDetails{
+		StoreName:        storeName,
+		StoreDescription: storeDescription,
+		PrimaryColor:     primaryColor,
+		AccentColor:      accentColor,
+	}
+	if err := onboardingValidator.Struct(&details); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	logoFile, err := c.FormFile("storeLogo")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid file"))
 		return
 	}
 
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-	}
-	if !allowedTypes[file.Header.Get("Content-Type")] {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Only JPEG/PNG images are allowed"))
-		return
-	}
-	cld, err := cloudinary.NewFromParams(
-		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		os.Getenv("CLOUDINARY_API_KEY"),
-		os.Getenv("CLOUDINARY_API_SECRET"),
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to initialize Cloudinary"))
+	// 1. Upload Logo
+	logoURL, ok := h.uploadStoreImage(c, services.MediaStoreLogo, userID, logoFile)
+	if !ok {
 		return
 	}
 
-	// 1. Upload Logo if present
-	logoFile, _ := c.FormFile("storeLogo")
-	logoURL := ""
-	if logoFile != nil {
-		src, err := logoFile.Open()
-		if err == nil {
-			uploadResult, err := cld.Upload.Upload(ctx, src, uploader.UploadParams{
-				Folder: "stores/logo",
-			})
-			if err == nil {
-				logoURL = uploadResult.SecureURL
-			}
-			src.Close()
+	// 2. Upload Banner if present
+	bannerURL := ""
+	if bannerFile, _ := c.FormFile("storeBanner"); bannerFile != nil {
+		if bannerURL, ok = h.uploadStoreImage(c, services.MediaStoreBanner, userID, bannerFile); !ok {
+			return
 		}
 	}
 
-	// 2. Upload Banner if present
-	bannerFile, _ := c.FormFile("storeBanner")
-	bannerURL := ""
-	if bannerFile != nil {
-		src, err := bannerFile.Open()
-		if err == nil {
-			uploadResult, err := cld.Upload.Upload(ctx, src, uploader.UploadParams{
-				Folder: "stores/banners",
-			})
-			if err == nil {
-				bannerURL = uploadResult.SecureURL
-			}
-			src.Close()
+	// 3. Reserve a unique store slug from the store name
+	storeSlug := ""
+	if storeName != "" {
+		storeSlug, err = h.StoreRepo.GenerateUniqueSlug(ctx, storeName, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate store slug"))
+			return
 		}
 	}
 
@@ -655,6 +701,9 @@ func (h *OnboardingHandler) StoreDetails(c *gin.Context) {
 	if bannerURL != "" {
 		update["$set"].(bson.M)["stepData.storeDetails.storeBanner"] = bannerURL
 	}
+	if storeSlug != "" {
+		update["$set"].(bson.M)["stepData.storeDetails.storeSlug"] = storeSlug
+	}
 
 	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
 	var saved models.UserOnboardingDraft
@@ -662,18 +711,59 @@ func (h *OnboardingHandler) StoreDetails(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store details"))
 		return
 	}
+	publishDraftSaved(saved)
 	c.JSON(http.StatusOK, utils.SuccessResponse("Store details updated", gin.H{
 		"success": true,
 		"data": gin.H{
 			"storeLogo":        logoURL,
 			"storeBanner":      bannerURL,
 			"storeName":        storeName,
+			"storeSlug":        storeSlug,
 			"storeDescription": storeDescription,
 			"primaryColor":     primaryColor,
 			"accentColor":      accentColor,
 		},
 	}))
 }
+
+// CheckStoreSlug reports whether the slug derived from a store name is free,
+// suggesting the nearest available alternative when it is not.
+func (h *OnboardingHandler) CheckStoreSlug(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	name := strings.TrimSpace(c.Query("name"))
+	slug := utils.GenerateSlug(name)
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A store name with letters or numbers is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	available, err := h.StoreRepo.IsSlugAvailable(ctx, slug, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check store slug"))
+		return
+	}
+
+	suggestion := slug
+	if !available {
+		suggestion, err = h.StoreRepo.GenerateUniqueSlug(ctx, name, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check store slug"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store slug checked", gin.H{
+		"name":       name,
+		"slug":       slug,
+		"available":  available,
+		"suggestion": suggestion,
+	}))
+}
 func (h *OnboardingHandler) CalculateTier1RiskScore(user *models.User, application *models.SellerApplication) models.RiskScore {
 	score := 0
 	var flags []string
@@ -762,28 +852,29 @@ func (h *OnboardingHandler) MakeApprovalDecision(score models.RiskScore) models.
 		Score:  score.Total,
 	}
 }
-func (h *OnboardingHandler) processDocumentUpload(form *multipart.Form, fieldName string) *models.VerificationDocument {
-	headers, ok := form.File[fieldName]
-	if !ok || len(headers) == 0 {
-		return nil
-	}
-	header := headers[0]
-
-	// Validate file size (5MB max)
-	if header.Size > 5*1024*1024 {
-		return nil
+// uploadStoreImage uploads a store's logo or banner, replacing the one the
+// vendor uploaded before, and writes the error response if it fails.
+func (h *OnboardingHandler) uploadStoreImage(c *gin.Context, m services.MediaContext, vendorID primitive.ObjectID, header *multipart.FileHeader) (string, bool) {
+	src, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to process image"))
+		return "", false
 	}
+	defer src.Close()
 
-	// Validate file type
-	allowedTypes := map[string]bool{
-		"image/jpeg":      true,
-		"image/png":       true,
-		"application/pdf": true,
+	image, err := h.Media.Upload(c.Request.Context(), m, vendorID, src, header)
+	if !uploadSucceeded(c, err, m, "Unsupported image; upload ") {
+		return "", false
 	}
+	return image.URL, true
+}
 
-	if !allowedTypes[header.Header.Get("Content-Type")] {
+func (h *OnboardingHandler) processDocumentUpload(ctx context.Context, form *multipart.Form, fieldName string, userID primitive.ObjectID) *models.VerificationDocument {
+	headers, ok := form.File[fieldName]
+	if !ok || len(headers) == 0 {
 		return nil
 	}
+	header := headers[0]
 
 	file, err := header.Open()
 	if err != nil {
@@ -791,52 +882,21 @@ func (h *OnboardingHandler) processDocumentUpload(form *multipart.Form, fieldNam
 	}
 	defer file.Close()
 
-	// Upload to Cloudinary
-	uploadResult, err := h.uploadToCloudinary(file, header)
+	// The media service checks the size (5MB max) and type from the content
+	document, err := h.Media.Upload(ctx, services.MediaVerificationDocument, userID, file, header)
 	if err != nil {
-		fmt.Printf("Error uploading %s to Cloudinary: %v\n", fieldName, err)
-		return nil
-	}
-
-	if uploadResult == nil || uploadResult.SecureURL == "" {
-		fmt.Printf("Cloudinary upload succeeded but SecureURL is empty for %s\n", fieldName)
+		logrus.WithError(err).WithField("field", fieldName).Warn("Failed to upload verification document")
 		return nil
 	}
 
 	return &models.VerificationDocument{
-		FileName:    header.Filename,
-		FileURL:     uploadResult.SecureURL,
-		FileSize:    header.Size,
-		ContentType: header.Header.Get("Content-Type"),
+		FileName:    document.Name,
+		FileURL:     document.URL,
+		FileSize:    document.Size,
+		ContentType: document.ContentType,
 		UploadedAt:  time.Now(),
 	}
 }
-
-func (h *OnboardingHandler) uploadToCloudinary(file multipart.File, header *multipart.FileHeader) (*uploader.UploadResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
-	apiKey := os.Getenv("CLOUDINARY_API_KEY")
-	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
-
-	if cloudName == "" || apiKey == "" || apiSecret == "" {
-		return nil, fmt.Errorf("Cloudinary environment variables missing")
-	}
-
-	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Cloudinary: %w", err)
-	}
-
-	uploadResult, err := cld.Upload.Upload(ctx, file, uploader.UploadParams{
-		Folder:       "seller-verification",
-		ResourceType: "auto",
-		Format:       "auto",
-	})
-
-	return uploadResult, err
-}
 func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 	auth := c.GetHeader("Authorization")
 	if !strings.HasPrefix(auth, "Bearer ") {
@@ -879,7 +939,7 @@ func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 	}
 
 	// 3. Get draft data to populate application
-	var draft models.UserOnboardingDraft
+	var draft models.VendorOnboardingDraft
 	err = h.DB.Collection("drafts").FindOne(ctx, bson.M{"userID": userID, "role": "vendor"}).Decode(&draft)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Onboarding draft not found. Please complete previous steps."))
@@ -887,12 +947,12 @@ func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 	}
 
 	// 4. Process uploaded documents
-	idDocument := h.processDocumentUpload(form, "idDocument")
+	idDocument := h.processDocumentUpload(ctx, form, "idDocument", userID)
 	if idDocument == nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("ID document required"))
 		return
 	}
-	selfieDoc := h.processDocumentUpload(form, "selfieVerification")
+	selfieDoc := h.processDocumentUpload(ctx, form, "selfieVerification", userID)
 
 	// 5. Build SellerApplication from draft and uploads
 	application := &models.SellerApplication{
@@ -907,89 +967,31 @@ func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 		UpdatedAt:          time.Now(),
 	}
 
-	// Extract data from draft.StepData with safety
-	if busInfo, ok := draft.StepData["businessInfo"].(map[string]interface{}); ok {
-		application.BusinessTypeInfo = &models.SellerBusinessInfo{}
-		if val, ok := busInfo["requestedTier"].(string); ok {
-			application.RequestedTier = val
-			application.BusinessTypeInfo.RequestedTier = val
+	// Copy the typed draft step data onto the application
+	if info := draft.StepData.BusinessInfo; info != nil {
+		application.BusinessTypeInfo = info
+		if info.RequestedTier != "" {
+			application.RequestedTier = info.RequestedTier
 		}
-		if val, ok := busInfo["type"].(string); ok {
-			application.BusinessTypeInfo.BusinessType = val
-		}
-		if val, ok := busInfo["size"].(string); ok {
-			application.BusinessTypeInfo.BusinessSize = val
-		}
-		if val, ok := busInfo["experience"].(string); ok {
-			application.BusinessTypeInfo.BusinessExperience = val
-		}
-
-		if application.BusinessTypeInfo.BusinessType != "" && application.BusinessTypeInfo.BusinessType != "unregistered" {
+		if info.BusinessType != "" && info.BusinessType != "unregistered" {
 			application.IsRegistered = true
 		}
 	}
 
-	if categories, ok := draft.StepData["categories"].([]interface{}); ok {
-		for _, cat := range categories {
-			if s, ok := cat.(string); ok {
-				application.Categories = append(application.Categories, s)
-			}
-		}
-	}
-
-	if busDetails, ok := draft.StepData["businessDetails"].(map[string]interface{}); ok {
-		application.BusinessDetails = &models.BusinessDetails{}
-		if val, ok := busDetails["businessName"].(string); ok {
-			application.BusinessDetails.BusinessName = val
-		}
-		if val, ok := busDetails["description"].(string); ok {
-			application.BusinessDetails.Description = val
-		}
-		if val, ok := busDetails["location"].(string); ok {
-			application.BusinessDetails.Location = val
-		}
-		if val, ok := busDetails["shipsFrom"].(string); ok {
-			application.BusinessDetails.ShipsFrom = val
-		}
-		if val, ok := busDetails["url"].(string); ok {
-			application.BusinessDetails.Url = val
-		}
-		if val, ok := busDetails["shippingPolicy"].(string); ok {
-			application.BusinessDetails.ShippingPolicy = val
-		}
-		if val, ok := busDetails["returnPolicy"].(string); ok {
-			application.BusinessDetails.ReturnPolicy = val
-		}
+	application.Categories = draft.StepData.Categories
 
-		application.StoreName = application.BusinessDetails.BusinessName
+	if details := draft.StepData.BusinessDetails; details != nil {
+		application.BusinessDetails = details
+		application.StoreName = details.BusinessName
 	}
 
-	if storeDetails, ok := draft.StepData["storeDetails"].(map[string]interface{}); ok {
-		application.StoreDetails = &models.StoreDetails{}
-		if val, ok := storeDetails["storeLogo"].(string); ok {
-			application.StoreDetails.StoreLogo = val
-		}
-		if val, ok := storeDetails["storeBanner"].(string); ok {
-			application.StoreDetails.StoreBanner = val
-		}
-		if val, ok := storeDetails["storeName"].(string); ok {
-			application.StoreDetails.StoreName = val
+	if store := draft.StepData.StoreDetails; store != nil {
+		application.StoreDetails = store
+		if store.StoreName != "" {
+			application.StoreName = store.StoreName
 		}
-		if val, ok := storeDetails["storeDescription"].(string); ok {
-			application.StoreDetails.StoreDescription = val
-		}
-		if val, ok := storeDetails["primaryColor"].(string); ok {
-			application.StoreDetails.PrimaryColor = val
-		}
-		if val, ok := storeDetails["accentColor"].(string); ok {
-			application.StoreDetails.AccentColor = val
-		}
-
-		if application.StoreDetails.StoreName != "" {
-			application.StoreName = application.StoreDetails.StoreName
-		}
-		if application.StoreDetails.StoreDescription != "" {
-			application.StoreDescription = application.StoreDetails.StoreDescription
+		if store.StoreDescription != "" {
+			application.StoreDescription = store.StoreDescription
 		}
 	}
 
@@ -1023,7 +1025,7 @@ func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 				riskScore.Flags = append(riskScore.Flags, fmt.Sprintf("AI Verification Failed: %s (Confidence: %d)", aiResult.RejectionReason, aiResult.Confidence))
 			}
 		} else {
-			fmt.Printf("AI Verification failed to run: %v\n", err)
+			middleware.Log(c).WithError(err).Error("AI verification failed to run")
 			// If AI failed to run, we must increase risk to prevent auto-approve
 			riskScore.Total += 50
 			riskScore.Flags = append(riskScore.Flags, "AI Verification System Error - Manual Review Required")
@@ -1055,8 +1057,25 @@ func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 		application.Status = "pending" // Requires manual review (Tier 2/3 or Medium/High risk)
 	}
 
-	// 9. Save application
-	_, err = h.DB.Collection("sellerApplications").InsertOne(ctx, application)
+	// 9. Save application, with the events announcing it
+	appEvent := map[string]interface{}{
+		"applicationId": application.ID.Hex(),
+		"userId":        userID.Hex(),
+		"requestedTier": application.RequestedTier,
+		"status":        application.Status,
+		"riskScore":     application.RiskScore,
+	}
+	appEvents := []events.Event{events.New(events.ApplicationSubmitted, appEvent)}
+	switch application.Status {
+	case "approved":
+		appEvents = append(appEvents, events.New(events.ApplicationApproved, appEvent))
+	case "rejected":
+		appEvents = append(appEvents, events.New(events.ApplicationRejected, appEvent))
+	}
+	err = repository.WithEvents(ctx, h.DB, func(sessCtx mongo.SessionContext) error {
+		_, err := h.DB.Collection("sellerApplications").InsertOne(sessCtx, application)
+		return err
+	}, appEvents...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save application"))
 		return
@@ -1079,16 +1098,37 @@ func (h *OnboardingHandler) SellerVerification(c *gin.Context) {
 			UpdatedAt:       time.Now(),
 		}
 
-		_, err = h.DB.Collection("vendorAccounts").InsertOne(ctx, vendorAccount)
-		if err == nil {
-			// Update user role and vendor status
-			h.DB.Collection("users").UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		// The account, the user's new role and the events announcing them
+		// are written together
+		activated := events.New(events.VendorActivated, map[string]interface{}{
+			"vendorAccountId": vendorAccount.ID.Hex(),
+			"userId":          userID.Hex(),
+			"applicationId":   application.ID.Hex(),
+			"tier":            vendorAccount.Tier,
+		})
+		approved := events.New(events.VendorApproved, map[string]interface{}{
+			"vendorAccountId": vendorAccount.ID.Hex(),
+			"userId":          userID.Hex(),
+			"tier":            vendorAccount.Tier,
+		})
+		err = repository.WithEvents(ctx, h.DB, func(sessCtx mongo.SessionContext) error {
+			if _, err := h.DB.Collection("vendorAccounts").InsertOne(sessCtx, vendorAccount); err != nil {
+				return err
+			}
+			_, err := h.DB.Collection("users").UpdateOne(sessCtx, bson.M{"_id": userID}, bson.M{
 				"$set": bson.M{
 					"role":         "vendor",
 					"vendorStatus": "approved",
 					"updatedAt":    time.Now(),
 				},
 			})
+			return err
+		}, activated, approved)
+		if err == nil {
+			// Open the vendor's public storefront
+			if _, err := h.StoreRepo.CreateStore(ctx, models.NewStoreFromApplication(userID, application)); err != nil {
+				logrus.WithError(err).WithField("vendorId", userID.Hex()).Error("Failed to create store for vendor")
+			}
 		}
 	} else {
 		// Update user vendor status to pending (or rejected)
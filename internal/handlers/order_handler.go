@@ -2,26 +2,50 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
 	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type OrderHandler struct {
-	Repo     repository.OrderRepository
-	CartRepo repository.CartRepository
+	Repo             repository.OrderRepository
+	CartRepo         repository.CartRepository
+	ProductRepo      repository.ProductRepository
+	ShippingRepo     repository.ShippingRepository
+	StoreRepo        repository.StoreRepository
+	CouponRepo       repository.CouponRepository
+	FlashSaleRepo    repository.FlashSaleRepository
+	AutoDiscountRepo repository.AutoDiscountRepository
+	CheckoutRepo     repository.CheckoutEventRepository
 }
 
 func NewOrderHandler(db *mongo.Database) *OrderHandler {
 	repo := repository.NewOrderRepository(db)
 	cartRepo := repository.NewCartRepository(db)
-	return &OrderHandler{Repo: repo, CartRepo: cartRepo}
+	return &OrderHandler{
+		Repo:             repo,
+		CartRepo:         cartRepo,
+		ProductRepo:      repository.NewProductRepository(db),
+		ShippingRepo:     repository.NewShippingRepository(db),
+		StoreRepo:        repository.NewStoreRepository(db),
+		CouponRepo:       repository.NewCouponRepository(db),
+		FlashSaleRepo:    repository.NewFlashSaleRepository(db),
+		AutoDiscountRepo: repository.NewAutoDiscountRepository(db),
+		CheckoutRepo:     repository.NewCheckoutEventRepository(db),
+	}
 }
 
 func (h *OrderHandler) PlaceOrder(c *gin.Context) {
@@ -34,6 +58,26 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		return
 	}
 
+	// Every order needs a structured address that checks out, so restrictions
+	// and carriers know where it's going
+	if err := validate.Struct(input.ShipTo); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	err := checkAddress(input.ShipTo)
+	if errors.Is(err, models.ErrUndeliverableAddress) {
+		c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid shipping address: "+err.Error()))
+		return
+	}
+	if input.ShippingAddress == "" {
+		input.ShippingAddress = input.ShipTo.String()
+	}
+	recordCheckoutStep(h.CheckoutRepo, models.CheckoutEvent{UserID: userID, Step: models.CheckoutStepAddressEntered})
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
@@ -44,15 +88,144 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	}
 
 	if len(cart.Items) == 0 {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Your cart is empty"))
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeCartEmpty, "Your cart is empty"))
+		return
+	}
+
+	shipping, err := quoteShipping(ctx, h.ShippingRepo, h.ProductRepo, h.StoreRepo, shippingRequest{
+		Items:   cart.Items,
+		Region:  input.ShippingRegion,
+		To:      input.ShipTo,
+		Methods: input.ShippingMethods,
+		Pickups: input.PickupLocations,
+	})
+	var restricted *models.ShippingRestrictionError
+	if errors.As(err, &restricted) {
+		respondRestricted(c, restricted)
+		return
+	}
+	if errors.Is(err, models.ErrNotShippable) || errors.Is(err, models.ErrUnknownShippingMethod) || errors.Is(err, models.ErrUnknownPickupLocation) {
+		c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to quote shipping"))
+		return
+	}
+
+	// Collections need a location, and a code for the buyer to show
+	for i := range shipping.Shipments {
+		s := &shipping.Shipments[i]
+		if s.Method != models.ShippingMethodPickup {
+			continue
+		}
+		if s.Pickup == nil {
+			c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(models.ErrUnknownPickupLocation))
+			return
+		}
+		if s.PickupCode, err = utils.GenerateNumericCode(6); err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to place order"))
+			return
+		}
+	}
+
+	// Flash sale units are claimed from their sales' counters up front, so
+	// the coupon is priced on what they really cost
+	claims, err := claimFlashSales(ctx, h.FlashSaleRepo, userID, cart.Items)
+	if isFlashSaleError(err) {
+		c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to claim flash sale items"))
 		return
 	}
 
-	order, err := h.Repo.PlaceOrder(ctx, userID, input, cart)
+	// The coupon, if any, is priced again, and so is the buyer's best
+	// automatic discount. Only the bigger comes off; a coupon that wins has
+	// one of its uses held for this order.
+	code := input.CouponCode
+	if code == "" {
+		code = cart.CouponCode
+	}
+	var coupon *models.Coupon
+	var discount models.CouponDiscount
+	if code != "" {
+		coupon, discount, err = priceCoupon(ctx, h.CouponRepo, h.ProductRepo, code, userID, cart.Items)
+		if err != nil {
+			releaseFlashSales(h.FlashSaleRepo, userID, claims)
+			if isCouponError(err) {
+				c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to apply coupon"))
+			}
+			return
+		}
+	}
+	auto, autoDiscount, err := priceAutoDiscount(ctx, h.AutoDiscountRepo, h.ProductRepo, userID, cart.Items)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
+		releaseFlashSales(h.FlashSaleRepo, userID, claims)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to apply discounts"))
 		return
 	}
+	if auto != nil && autoDiscount.Amount > discount.Amount {
+		coupon, discount = nil, autoDiscount
+	} else {
+		auto = nil
+	}
+	if coupon != nil {
+		if err := h.CouponRepo.ReserveCoupon(ctx, coupon.ID); err != nil {
+			releaseFlashSales(h.FlashSaleRepo, userID, claims)
+			if isCouponError(err) {
+				c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to apply coupon"))
+			}
+			return
+		}
+	}
+
+	order, err := h.Repo.PlaceOrder(ctx, userID, input, cart, shipping, discount)
+	if err != nil {
+		middleware.Log(c).WithError(err).Error("Failed to place order")
+		releaseFlashSales(h.FlashSaleRepo, userID, claims)
+		if coupon != nil {
+			if relErr := h.CouponRepo.ReleaseCoupon(context.Background(), coupon.ID); relErr != nil {
+				logrus.WithError(relErr).WithField("couponId", coupon.ID.Hex()).Error("Failed to release coupon use")
+			}
+		}
+		if errors.Is(err, models.ErrOutOfStock) || errors.Is(err, models.ErrProductUnavailable) {
+			c.JSON(http.StatusConflict, domainErrorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to place order"))
+		return
+	}
+	telemetry.OrderPlaced(order.Total.Float64())
+	if coupon != nil {
+		err := h.CouponRepo.RecordRedemption(ctx, models.CouponRedemption{
+			CouponID: coupon.ID,
+			Code:     coupon.Code,
+			UserID:   userID,
+			OrderID:  order.ID,
+			Discount: discount.Amount,
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("orderId", order.ID.Hex()).Error("Failed to record coupon redemption")
+		}
+	}
+	if auto != nil {
+		err := h.AutoDiscountRepo.RecordRedemption(ctx, models.AutoDiscountRedemption{
+			AutoDiscountID: auto.ID,
+			UserID:         userID,
+			OrderID:        order.ID,
+			Discount:       discount.Amount,
+			OrderTotal:     order.Total,
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("orderId", order.ID.Hex()).Error("Failed to record automatic discount redemption")
+		}
+	}
 
 	c.JSON(http.StatusCreated, utils.SuccessResponse("Order placed successfully", gin.H{"order": order}))
 }
@@ -64,13 +237,11 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	orders, err := h.Repo.GetOrdersByUserID(ctx, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch orders"))
+	fields, ok := fieldsParam(c, models.Order{}, "createdAt")
+	if !ok {
 		return
 	}
-
-	c.JSON(http.StatusOK, utils.SuccessResponse("Orders fetched successfully", gin.H{"orders": orders}))
+	h.listOrders(ctx, c, bson.M{"userId": userID}, fields, "Orders fetched successfully")
 }
 
 func (h *OrderHandler) GetOrderById(c *gin.Context) {
@@ -93,21 +264,108 @@ func (h *OrderHandler) GetOrderById(c *gin.Context) {
 		return
 	}
 
-	isBuyer := order.UserID == userID
-	isVendor := false
-	for _, item := range order.Items {
-		if item.VendorID == userID {
-			isVendor = true
-			break
-		}
+	if !canViewOrder(order, userID) {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to view this order"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Order fetched successfully", gin.H{"order": order}))
+}
+
+// GetOrderTracking fetches the carrier's latest tracking for an order and
+// records it as a webhook update would.
+func (h *OrderHandler) GetOrderTracking(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID"))
+		return
 	}
 
-	if !isBuyer && !isVendor {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.Repo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	if !canViewOrder(order, userID) {
 		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to view this order"))
 		return
 	}
+	if order.TrackingNumber == "" || order.Carrier == "" {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("This order has no carrier tracking yet"))
+		return
+	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse("Order fetched successfully", gin.H{"order": order}))
+	provider := utils.CurrentShippingProvider()
+	if provider == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("Carrier tracking is not available"))
+		return
+	}
+	tracking, err := provider.Track(order.Carrier, order.TrackingNumber)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to fetch tracking from the carrier"))
+		return
+	}
+
+	// Polling counts too, for carriers that don't push updates
+	if _, err := applyTracking(ctx, h.Repo, order, tracking); err != nil {
+		logrus.WithError(err).WithField("orderId", orderID.Hex()).Warn("Failed to record tracking")
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Tracking fetched", gin.H{"tracking": tracking}))
+}
+
+// GetOrderTimeline returns every change of an order's status, who made it
+// and when, with how long each step of fulfilment took.
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID"))
+		return
+	}
+
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.Repo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	if !canViewOrder(order, userID) {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to view this order"))
+		return
+	}
+
+	history, err := h.Repo.GetStatusHistory(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch order timeline"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Order timeline fetched", gin.H{"timeline": models.NewOrderStatusTimeline(history)}))
+}
+
+// canViewOrder reports whether the user bought the order or sold something
+// in it.
+func canViewOrder(order models.Order, userID primitive.ObjectID) bool {
+	if order.UserID == userID {
+		return true
+	}
+	for _, item := range order.Items {
+		if item.VendorID == userID {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *OrderHandler) GetVendorOrders(c *gin.Context) {
@@ -117,13 +375,65 @@ func (h *OrderHandler) GetVendorOrders(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	orders, err := h.Repo.GetOrdersByVendorID(ctx, vendorID)
+	fields, ok := fieldsParam(c, models.Order{}, "createdAt")
+	if !ok {
+		return
+	}
+	h.listOrders(ctx, c, bson.M{"items.vendorId": vendorID}, fields, "Vendor orders fetched successfully")
+}
+
+// orderSorts are the fields order lists can be sorted by.
+var orderSorts = []string{"createdAt", "updatedAt", "total", "status"}
+
+// listOrders responds with the orders matching filter, trimmed to fields:
+// a cursor page when ?cursor= is given, otherwise a numbered page in ?sort=
+// order, newest first by default.
+func (h *OrderHandler) listOrders(ctx context.Context, c *gin.Context, filter bson.M, fields models.FieldSet, message string) {
+	if wantsCursor(c) {
+		h.listOrdersPage(ctx, c, filter, fields, message)
+		return
+	}
+
+	sort, ok := listSort(c, models.Order{}, orderSorts, nil)
+	if !ok {
+		return
+	}
+
+	page, limit := pageParams(c, 20, 100)
+	orders, total, err := h.Repo.ListOrders(ctx, filter, int64(limit), int64((page-1)*limit), sort, fields.Projection())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch orders"))
+		return
+	}
+	selected, ok := selectFields(c, fields, orders)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{"orders": selected, "meta": models.NewPageMeta(total, page, limit)}))
+}
+
+// listOrdersPage responds with a cursor page of the orders matching filter,
+// newest first, trimmed to fields.
+func (h *OrderHandler) listOrdersPage(ctx context.Context, c *gin.Context, filter bson.M, fields models.FieldSet, message string) {
+	after, limit, ok := cursorParams(c, 20, 100)
+	if !ok {
+		return
+	}
+	orders, err := h.Repo.ListOrdersPage(ctx, filter, after, int64(limit)+1, fields.Projection())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor orders"))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch orders"))
+		return
+	}
+	orders, meta := models.CursorPage(orders, limit, func(o models.Order) (time.Time, primitive.ObjectID) {
+		return o.CreatedAt, o.ID
+	})
+	selected, ok := selectFields(c, fields, orders)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor orders fetched successfully", gin.H{"orders": orders}))
+	c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{"orders": selected, "meta": meta}))
 }
 
 func (h *OrderHandler) UpdateVendorOrderStatus(c *gin.Context) {
@@ -137,6 +447,7 @@ func (h *OrderHandler) UpdateVendorOrderStatus(c *gin.Context) {
 	var input struct {
 		Status         models.OrderStatus `json:"status" binding:"required"`
 		TrackingNumber string             `json:"trackingNumber"`
+		Carrier        string             `json:"carrier"` // Carrier code, e.g. "usps"; enables tracking lookups
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid status provided"))
@@ -169,23 +480,92 @@ func (h *OrderHandler) UpdateVendorOrderStatus(c *gin.Context) {
 		return
 	}
 
+	if order.VendorPickup(vendorID) >= 0 && input.TrackingNumber != "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("The buyer is collecting this order; it's checked off with their pickup code, not tracking"))
+		return
+	}
+
+	// A bought label already put its tracking number on the order
+	if label, ok := order.VendorLabel(vendorID); ok && input.TrackingNumber == "" {
+		input.TrackingNumber, input.Carrier = label.TrackingNumber, label.Carrier
+	}
+
 	// 2. Update status
-	if err := h.Repo.UpdateOrderStatus(ctx, orderID, input.Status, input.TrackingNumber); err != nil {
+	if input.TrackingNumber != "" {
+		order.TrackingNumber = input.TrackingNumber
+	}
+	actor := models.ActingUser(models.OrderActorVendor, vendorID)
+	if input.TrackingNumber != "" {
+		actor.Metadata = map[string]string{"trackingNumber": input.TrackingNumber}
+	}
+	if err := h.Repo.UpdateOrderStatus(ctx, orderID, input.Status, input.TrackingNumber, actor, orderStatusEvent(order, input.Status, "vendor")); err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update status"))
 		return
 	}
+	if input.TrackingNumber != "" && input.Carrier != "" {
+		if err := h.Repo.SetTracking(ctx, orderID, input.Carrier, input.TrackingNumber); err != nil {
+			logrus.WithError(err).Warn("Failed to save carrier for order")
+		}
+	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Order status updated", nil))
 }
 
+// maxSalesBuckets caps how many buckets one stats request can ask for, e.g.
+// a year of days.
+const maxSalesBuckets = 366
+
+// vendorStatsTopProducts is how many best sellers vendor stats list.
+const vendorStatsTopProducts = 5
+
+// ParseStatsRange resolves the range of a stats request broken down over
+// time. The range is taken as for the admin dashboard (a preset window, or
+// from/to); granularity is day, week or month and defaults to day.
+func ParseStatsRange(window, fromStr, toStr, granularity string, now time.Time) (time.Time, time.Time, string, error) {
+	_, from, to, err := ParseDashboardWindow(window, fromStr, toStr, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+	switch granularity {
+	case "":
+		granularity = models.GranularityDay
+	case models.GranularityDay, models.GranularityWeek, models.GranularityMonth:
+	default:
+		return time.Time{}, time.Time{}, "", errors.New("granularity must be day, week or month")
+	}
+	if n := len(models.SalesBuckets(from, to, granularity)); n > maxSalesBuckets {
+		return time.Time{}, time.Time{}, "", fmt.Errorf("range spans %d %ss; use a coarser granularity or a shorter range", n, granularity)
+	}
+	return from, to, granularity, nil
+}
+
+// ParseVendorStatsQuery resolves the range of a vendor stats request; see
+// ParseStatsRange.
+func ParseVendorStatsQuery(window, fromStr, toStr, granularity string, now time.Time) (models.VendorStatsQuery, error) {
+	from, to, granularity, err := ParseStatsRange(window, fromStr, toStr, granularity, now)
+	if err != nil {
+		return models.VendorStatsQuery{}, err
+	}
+	return models.VendorStatsQuery{From: from, To: to, Granularity: granularity, TopProducts: vendorStatsTopProducts}, nil
+}
+
+// GetVendorStats returns the vendor's revenue, orders, units sold, average
+// order value and best sellers over a time range, overall and per day, week
+// or month.
 func (h *OrderHandler) GetVendorStats(c *gin.Context) {
 	userIdStr, _ := c.Get("userId")
 	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
 
+	query, err := ParseVendorStatsQuery(c.Query("window"), c.Query("from"), c.Query("to"), c.Query("granularity"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	stats, err := h.Repo.GetVendorStats(ctx, vendorID)
+	stats, err := h.Repo.GetVendorStats(ctx, vendorID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor stats"))
 		return
@@ -220,13 +600,14 @@ func (h *OrderHandler) ConfirmReceipt(c *gin.Context) {
 		return
 	}
 
-	if order.Status != models.StatusShipped {
+	if order.Status != models.StatusShipped && order.Status != models.StatusOutForDelivery {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Only shipped orders can be confirmed"))
 		return
 	}
 
 	// 2. Update status to Delivered
-	if err := h.Repo.UpdateOrderStatus(ctx, orderID, models.StatusDelivered, ""); err != nil {
+	actor := models.ActingUser(models.OrderActorBuyer, userID)
+	if err := h.Repo.UpdateOrderStatus(ctx, orderID, models.StatusDelivered, "", actor, orderStatusEvent(order, models.StatusDelivered, "buyer")); err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to confirm receipt"))
 		return
 	}
@@ -249,3 +630,24 @@ func (h *OrderHandler) GetBuyerOverview(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Buyer overview fetched successfully", gin.H{"stats": stats}))
 }
+
+// orderStatusEvent announces that actor ("vendor", "buyer", "carrier" or
+// "admin") moved the order to status. It is written to the outbox with the
+// status change.
+func orderStatusEvent(order models.Order, status models.OrderStatus, actor string) events.Event {
+	data := order.EventData()
+	data["previousStatus"] = string(order.Status)
+	data["status"] = string(status)
+	data["actor"] = actor
+	data["trackingNumber"] = order.TrackingNumber
+	return events.New(events.OrderStatusChanged, data)
+}
+
+// orderEmailItems turns an order's lines into email template rows.
+func orderEmailItems(order models.Order) []utils.OrderEmailItem {
+	items := make([]utils.OrderEmailItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, utils.OrderEmailItem{Name: item.Name, Quantity: item.Quantity, Subtotal: item.Subtotal.Float64()})
+	}
+	return items
+}
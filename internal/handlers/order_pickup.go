@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetPickupCodes lists where and with which code the buyer collects each
+// part of their order sent by the pickup method.
+func (h *OrderHandler) GetPickupCodes(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID"))
+		return
+	}
+
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	order, err := h.Repo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	// Only the buyer; the code is what proves they're collecting
+	if order.UserID != userID {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to view this order"))
+		return
+	}
+
+	pickups := []gin.H{}
+	for _, s := range order.Shipments {
+		if s.Method != models.ShippingMethodPickup {
+			continue
+		}
+		pickups = append(pickups, gin.H{
+			"vendorIds":   s.VendorIDs,
+			"location":    s.Pickup,
+			"code":        s.PickupCode,
+			"readyAt":     s.ReadyAt,
+			"collectedAt": s.CollectedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Pickups fetched", gin.H{"pickups": pickups}))
+}
+
+// MarkPickupReady tells the buyer the vendor's part of the order is ready to
+// collect. An order that is all collections counts as shipped once every
+// part is ready.
+func (h *OrderHandler) MarkPickupReady(c *gin.Context) {
+	h.updatePickup(c, func(c *gin.Context, order *models.Order, shipment *models.Shipment) (models.OrderStatus, bool) {
+		if shipment.ReadyAt != nil {
+			c.JSON(http.StatusOK, utils.SuccessResponse("Already marked ready", gin.H{"shipment": shipment}))
+			return "", false
+		}
+		if order.Status != models.StatusPaid && order.Status != models.StatusConfirmed {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Only paid orders can be readied for pickup"))
+			return "", false
+		}
+
+		now := time.Now()
+		shipment.ReadyAt = &now
+		order.Timeline = append(order.Timeline, models.OrderTimelineEvent{
+			Status:   models.TrackingReadyForPickup,
+			Location: shipment.Pickup.Name,
+			At:       now,
+		})
+		if order.PickupOnly() && order.PickupsReady() {
+			return models.StatusShipped, true
+		}
+		return "", false
+	})
+}
+
+// CollectPickup checks the code the buyer shows at collection and records
+// the vendor's part of the order as collected. An order that is all
+// collections is delivered once every part has been collected.
+func (h *OrderHandler) CollectPickup(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A pickup code is required"))
+		return
+	}
+
+	h.updatePickup(c, func(c *gin.Context, order *models.Order, shipment *models.Shipment) (models.OrderStatus, bool) {
+		if shipment.CollectedAt != nil {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("This order has already been collected"))
+			return "", false
+		}
+		if order.Status != models.StatusPaid && order.Status != models.StatusConfirmed && order.Status != models.StatusShipped {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Only paid orders can be collected"))
+			return "", false
+		}
+		code := strings.TrimSpace(input.Code)
+		if subtle.ConstantTimeCompare([]byte(code), []byte(shipment.PickupCode)) != 1 {
+			c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("That pickup code doesn't match this order"))
+			return "", false
+		}
+
+		now := time.Now()
+		if shipment.ReadyAt == nil {
+			shipment.ReadyAt = &now
+		}
+		shipment.CollectedAt = &now
+		order.Timeline = append(order.Timeline, models.OrderTimelineEvent{
+			Status:   models.TrackingPickedUp,
+			Location: shipment.Pickup.Name,
+			At:       now,
+		})
+		if order.PickupOnly() && order.PickupsCollected() {
+			return models.StatusDelivered, true
+		}
+		// Parcels may have arrived while this was waiting to be collected
+		return order.TrackedStatus()
+	})
+}
+
+// updatePickup loads the order and the requesting vendor's pickup shipment
+// in it, lets step change them, and saves the result. step responds itself
+// to refuse, or returns the status the order moves to and true if it moves.
+func (h *OrderHandler) updatePickup(c *gin.Context, step func(*gin.Context, *models.Order, *models.Shipment) (models.OrderStatus, bool)) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID"))
+		return
+	}
+
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. The vendor's collection
+	order, err := h.Repo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	i := order.VendorPickup(vendorID)
+	if i < 0 {
+		if canViewOrder(order, vendorID) {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("The buyer isn't collecting this order"))
+			return
+		}
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to update this order"))
+		return
+	}
+	if order.Shipments[i].Pickup == nil {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("This order has no pickup location"))
+		return
+	}
+
+	// 2. Move it on
+	previous := order.Status
+	status, advance := step(c, &order, &order.Shipments[i])
+	if c.Writer.Written() {
+		return
+	}
+	if err := h.Repo.SavePickup(ctx, order); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update pickup"))
+		return
+	}
+	if advance && status != previous {
+		actor := models.ActingUser(models.OrderActorVendor, vendorID)
+		if err := h.Repo.UpdateOrderStatus(ctx, orderID, status, "", actor, orderStatusEvent(order, status, "vendor")); err != nil {
+			logrus.WithError(err).WithField("orderId", orderID.Hex()).Error("Failed to update status after pickup")
+		} else {
+			order.Status = status
+		}
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Pickup updated", gin.H{
+		"status":   order.Status,
+		"shipment": order.Shipments[i],
+	}))
+}
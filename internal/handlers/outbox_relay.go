@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// outboxPollInterval is how often the relay looks for new events.
+	outboxPollInterval = time.Second
+	// outboxLease is how long a claimed event is left to the relay that
+	// claimed it before another may publish it. Subscribers get a minute each
+	// and run at the same time, so this is comfortably longer.
+	outboxLease = 2 * time.Minute
+)
+
+// StartOutboxRelay publishes the events written to the outbox to the bus's
+// subscribers (notifications, live updates and webhooks), oldest first, until
+// ctx is done. An event is marked published once every subscriber has
+// finished with it; one whose relay stops halfway is published again with
+// the same ID when its lease runs out.
+func StartOutboxRelay(ctx context.Context, repo repository.OutboxRepository, bus *events.Bus) {
+	go func() {
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+		for {
+			relayOutbox(ctx, repo, bus)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// relayOutbox publishes events until the outbox is empty.
+func relayOutbox(ctx context.Context, repo repository.OutboxRepository, bus *events.Bus) {
+	for ctx.Err() == nil {
+		claimed, err := repo.Claim(ctx, outboxLease)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to claim outbox event")
+			return
+		}
+		if claimed == nil {
+			return
+		}
+		log := logrus.WithFields(logrus.Fields{"event": claimed.Type, "eventId": claimed.EventID})
+
+		var e events.Event
+		if err := json.Unmarshal([]byte(claimed.Payload), &e); err != nil {
+			log.WithError(err).Error("Failed to decode outbox event")
+			if err := repo.MarkFailed(ctx, claimed.ID, fmt.Errorf("decode: %w", err)); err != nil {
+				log.WithError(err).Error("Failed to record outbox failure")
+			}
+			continue
+		}
+		bus.Dispatch(ctx, e)
+		if err := repo.MarkPublished(ctx, claimed.ID); err != nil {
+			log.WithError(err).Error("Failed to mark outbox event published")
+		}
+	}
+}
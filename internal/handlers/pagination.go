@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// List pagination. Every list takes ?limit= and either ?page=, counting
+// from 1, or, where it supports it, ?cursor=, and answers with the page and
+// a meta block: models.PageMeta or models.CursorMeta. Lists that can be
+// sorted take ?sort=, e.g. ?sort=-price,name for the dearest first.
+
+// pageParams reads ?page= and ?limit= for a page-numbered list. Values out
+// of range fall back to the first page and defaultLimit.
+func pageParams(c *gin.Context, defaultLimit, maxLimit int) (page, limit int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	return page, limit
+}
+
+// listSort reads ?sort= for a list of model, which can be sorted by the
+// allowed fields and otherwise comes in def order. It reports false, having
+// written the response, when the sort is invalid.
+func listSort(c *gin.Context, model interface{}, allowed []string, def bson.D) (bson.D, bool) {
+	sort, err := models.ParseSort(c.Query("sort"), model, allowed, def)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeInvalidSort, "Invalid sort: "+err.Error()))
+		return nil, false
+	}
+	return sort, true
+}
+
+// cursorParams reads ?cursor= and ?limit= for a cursor-paginated list. An
+// empty cursor is the first page. Cursor pages only come newest first. It
+// reports false, having written the response, when the cursor is invalid or
+// a sort is asked for.
+func cursorParams(c *gin.Context, defaultLimit, maxLimit int) (models.PageCursor, int, bool) {
+	if c.Query("sort") != "" {
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeInvalidSort, "Cursor pages can't be sorted; use ?page= to sort"))
+		return models.PageCursor{}, 0, false
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	after, err := models.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeInvalidCursor, "Invalid cursor"))
+		return models.PageCursor{}, 0, false
+	}
+	return after, limit, true
+}
+
+// fieldsParam reads ?fields= for a list of model, fetching required too.
+// It reports false, having written the response, when a field is unknown.
+func fieldsParam(c *gin.Context, model interface{}, required ...string) (models.FieldSet, bool) {
+	fields, err := models.ParseFieldSet(c.Query("fields"), model, required...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.CodedErrorResponse(utils.CodeUnknownField, "Invalid fields: "+err.Error()))
+		return models.FieldSet{}, false
+	}
+	return fields, true
+}
+
+// selectFields trims list to the fields asked for. It reports false, having
+// written the response, if it can't.
+func selectFields(c *gin.Context, fields models.FieldSet, list interface{}) (interface{}, bool) {
+	selected, err := fields.Select(list)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to select fields"))
+		return nil, false
+	}
+	return selected, true
+}
+
+// wantsCursor reports whether the client asked for cursor pagination by
+// passing ?cursor=, empty for the first page.
+func wantsCursor(c *gin.Context) bool {
+	_, ok := c.GetQuery("cursor")
+	return ok
+}
@@ -3,20 +3,24 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
 	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
 	"github.com/stripe/stripe-go/v81"
 	"github.com/stripe/stripe-go/v81/paymentintent"
+	"github.com/stripe/stripe-go/v81/refund"
 	"github.com/stripe/stripe-go/v81/webhook"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -24,19 +28,33 @@ import (
 )
 
 type PaymentHandler struct {
-	DB              *mongo.Database
-	OrderRepo       repository.OrderRepository
-	TransactionRepo repository.TransactionRepository
+	DB               *mongo.Database
+	OrderRepo        repository.OrderRepository
+	TransactionRepo  repository.TransactionRepository
+	CommissionRepo   repository.CommissionRepository
+	CategoryRepo     repository.CategoryRepository
+	StoreRepo        repository.StoreRepository
+	AnnouncementRepo repository.AnnouncementRepository
+	CheckoutRepo     repository.CheckoutEventRepository
+	Notifier         *NotificationDispatcher
+	WebhookSecret    string
 }
 
-func NewPaymentHandler(db *mongo.Database) *PaymentHandler {
-	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
+func NewPaymentHandler(db *mongo.Database, cfg config.StripeConfig) *PaymentHandler {
+	stripe.Key = cfg.SecretKey
 	orderRepo := repository.NewOrderRepository(db)
 	txRepo := repository.NewTransactionRepository(db)
 	return &PaymentHandler{
-		DB:              db,
-		OrderRepo:       orderRepo,
-		TransactionRepo: txRepo,
+		DB:               db,
+		OrderRepo:        orderRepo,
+		TransactionRepo:  txRepo,
+		CommissionRepo:   repository.NewCommissionRepository(db),
+		CategoryRepo:     repository.NewCategoryRepository(db),
+		StoreRepo:        repository.NewStoreRepository(db),
+		AnnouncementRepo: repository.NewAnnouncementRepository(db),
+		CheckoutRepo:     repository.NewCheckoutEventRepository(db),
+		Notifier:         NewNotificationDispatcher(db),
+		WebhookSecret:    cfg.WebhookSecret,
 	}
 }
 
@@ -62,7 +80,7 @@ func (h *PaymentHandler) CreatePaymentIntent(c *gin.Context) {
 		return
 	}
 
-	amount := int64(order.Total * 100)
+	amount := order.Total.Cents()
 
 	params := &stripe.PaymentIntentParams{
 		Amount:   stripe.Int64(amount),
@@ -74,8 +92,14 @@ func (h *PaymentHandler) CreatePaymentIntent(c *gin.Context) {
 			"orderId": req.OrderID,
 		},
 	}
+	// Stripe dedupes too, in case our own record of the key was lost
+	if key := middleware.IdempotencyKey(c); key != "" {
+		params.SetIdempotencyKey("intent-" + key)
+	}
 
+	_, call := telemetry.External(c.Request.Context(), "stripe", "payment_intent.create")
 	pi, err := paymentintent.New(params)
+	call.End(err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(fmt.Sprintf("Stripe error: %v", err)))
 		return
@@ -87,6 +111,12 @@ func (h *PaymentHandler) CreatePaymentIntent(c *gin.Context) {
 		bson.M{"_id": orderID},
 		bson.M{"$set": bson.M{"paymentId": pi.ID}},
 	)
+	recordCheckoutStep(h.CheckoutRepo, models.CheckoutEvent{
+		UserID:   order.UserID,
+		Step:     models.CheckoutStepPaymentIntentCreated,
+		Provider: models.PaymentProviderStripe,
+		OrderID:  order.ID,
+	})
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Payment intent created", gin.H{
 		"clientSecret": pi.ClientSecret,
@@ -120,7 +150,9 @@ func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
 	}
 
 	// Query Stripe directly
+	_, call := telemetry.External(c.Request.Context(), "stripe", "payment_intent.retrieve")
 	pi, err := paymentintent.Get(order.PaymentID, nil)
+	call.End(err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Stripe retrieval error"))
 		return
@@ -128,17 +160,18 @@ func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
 
 	if pi.Status == stripe.PaymentIntentStatusSucceeded {
 		// Update DB
-		collection := h.DB.Collection("orders")
-		_, _ = collection.UpdateOne(c.Request.Context(),
-			bson.M{"_id": orderID},
-			bson.M{"$set": bson.M{
-				"status":        models.StatusPaid,
-				"paymentStatus": "paid",
-				"updatedAt":     time.Now(),
-			}})
+		actor := models.OrderStatusActor{Role: models.OrderActorSystem, Metadata: map[string]string{"paymentId": pi.ID, "source": "verify"}}
+		if err := h.OrderRepo.MarkPaid(c.Request.Context(), orderID, "", actor); err != nil {
+			logrus.WithError(err).WithField("orderId", orderID.Hex()).Error("Failed to mark order paid")
+		}
+		telemetry.PaymentSucceeded("verify")
+		h.recordPaymentSucceeded(order)
 
 		// Credit vendors
 		h.creditVendors(c.Request.Context(), order)
+		if h.claimPaidOrder(c.Request.Context(), order) {
+			go h.sendOrderConfirmation(order)
+		}
 
 		c.JSON(http.StatusOK, utils.SuccessResponse("Payment verified successfully", nil))
 		return
@@ -148,16 +181,140 @@ func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
 }
 
 func (h *PaymentHandler) creditVendors(ctx context.Context, order models.Order) {
-	vendorSales := make(map[primitive.ObjectID]float64)
+	// Commission rates in force when the order was placed
+	categoryIDs := make([]primitive.ObjectID, 0, len(order.Items))
+	for _, item := range order.Items {
+		if !item.CategoryID.IsZero() {
+			categoryIDs = append(categoryIDs, item.CategoryID)
+		}
+	}
+	rates := commissionRates(ctx, h.CommissionRepo, h.CategoryRepo, categoryIDs, order.CreatedAt)
+
+	vendorSales := make(map[primitive.ObjectID][]models.SaleItem)
 	for _, item := range order.Items {
-		vendorSales[item.VendorID] += item.Subtotal
+		vendorSales[item.VendorID] = append(vendorSales[item.VendorID], models.SaleItem{
+			Amount:    item.VendorAmount(),
+			Rate:      rates[item.CategoryID],
+			PromoCost: item.PromoCost(),
+		})
 	}
 
-	for vID, amount := range vendorSales {
-		_ = h.TransactionRepo.CreditVendorForSale(ctx, vID, amount, order.ID, order.OrderNumber)
+	for vID, items := range vendorSales {
+		_ = h.TransactionRepo.CreditVendorForSale(ctx, vID, items, order.ID, order.OrderNumber)
 	}
 }
 
+// errPaymentClaimed is returned inside claimPaidOrder's transaction when the
+// payment has already been announced.
+var errPaymentClaimed = errors.New("payment already announced")
+
+// claimPaidOrder marks a paid order's confirmation as sent and writes the
+// OrderPaid event in the same transaction, reporting whether this call did
+// it. Both Stripe's webhook and the buyer's verify call see the payment;
+// only the first to claim it announces it and sends the confirmation.
+func (h *PaymentHandler) claimPaidOrder(ctx context.Context, order models.Order) bool {
+	paid := order.EventData()
+	paid["total"] = order.Total.Float64()
+	paid["items"] = order.EventItems()
+
+	err := repository.WithEvents(ctx, h.DB, func(sessCtx mongo.SessionContext) error {
+		res, err := h.DB.Collection("orders").UpdateOne(sessCtx,
+			bson.M{"_id": order.ID, "confirmationSentAt": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"confirmationSentAt": time.Now()}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.ModifiedCount == 0 {
+			return errPaymentClaimed
+		}
+		return nil
+	}, events.New(events.OrderPaid, paid))
+	if err != nil && !errors.Is(err, errPaymentClaimed) {
+		logrus.WithError(err).WithField("orderId", order.ID.Hex()).Error("Failed to announce payment")
+	}
+	return err == nil
+}
+
+// sendOrderConfirmation emails the buyer a summary of their paid order,
+// including any live announcements from the vendors whose items they bought.
+// Callers claim the order first with claimPaidOrder so it is sent at most
+// once.
+func (h *PaymentHandler) sendOrderConfirmation(order models.Order) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	// 1. Item lines
+	data := utils.OrderConfirmationEmailData{
+		OrderNumber: order.OrderNumber,
+		Items:       orderEmailItems(order),
+		Total:       order.Total.Float64(),
+	}
+	vendorIDs := []primitive.ObjectID{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, item := range order.Items {
+		if !seen[item.VendorID] {
+			seen[item.VendorID] = true
+			vendorIDs = append(vendorIDs, item.VendorID)
+		}
+	}
+
+	// 2. Vendor announcements, labelled by store
+	announcements, err := h.AnnouncementRepo.GetLiveAnnouncements(ctx, vendorIDs, now)
+	if err != nil {
+		logrus.WithError(err).WithField("orderId", order.ID.Hex()).Warn("Failed to load store announcements for confirmation email")
+	}
+	storeNames := make(map[primitive.ObjectID]string)
+	for _, a := range announcements {
+		name, ok := storeNames[a.VendorID]
+		if !ok {
+			if store, _ := h.StoreRepo.GetByVendorID(ctx, a.VendorID); store != nil {
+				name = store.Name
+			}
+			storeNames[a.VendorID] = name
+		}
+		data.Notes = append(data.Notes, utils.OrderEmailNote{StoreName: name, Message: a.Message})
+	}
+
+	// 3. Hand it to the dispatcher, which honours the buyer's preferences
+	h.Notifier.Dispatch(ctx, order.UserID, OutgoingNotification{
+		Type:  models.NotificationOrderUpdate,
+		Title: "Payment confirmed",
+		Body:  fmt.Sprintf("We've received your payment of $%s for order %s.", order.Total, order.OrderNumber),
+		Link:  "/orders/" + order.ID.Hex(),
+		Data:  order.EventData(),
+		Email: func(user models.User) EmailContent {
+			data.Name = user.Name
+			return EmailContent{Template: utils.EmailTemplateOrderConfirmation, Data: data}
+		},
+	})
+}
+
+// issueStripeRefund refunds a payment intent, in full when full is set and
+// otherwise by amount. The refund's own ID is the idempotency key, so a retry
+// can never pay out twice.
+func issueStripeRefund(ctx context.Context, paymentIntentID string, amount models.Money, full bool, refundID primitive.ObjectID) (string, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+	}
+	// Leaving the amount out refunds whatever is left on the charge, which
+	// avoids rounding past what was captured
+	if !full {
+		params.Amount = stripe.Int64(amount.Cents())
+	}
+	params.SetIdempotencyKey("refund-" + refundID.Hex())
+
+	_, call := telemetry.External(ctx, "stripe", "refund.create")
+	rf, err := refund.New(params)
+	call.End(err)
+	if err != nil {
+		return "", err
+	}
+	return rf.ID, nil
+}
+
 // HandleWebhook processes asynchronous events from Stripe
 func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
 
@@ -169,14 +326,14 @@ func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
 		return
 	}
 
-	endpointSecret := strings.TrimSpace(os.Getenv("STRIPE_WEBHOOK_SECRET"))
-	if endpointSecret == "" {
-		_ = godotenv.Load()
-		endpointSecret = strings.TrimSpace(os.Getenv("STRIPE_WEBHOOK_SECRET"))
+	if h.WebhookSecret == "" {
+		logrus.Error("Stripe webhook received but STRIPE_WEBHOOK_SECRET is not set")
+		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("Webhook not configured"))
+		return
 	}
 
 	signature := c.GetHeader("Stripe-Signature")
-	event, err := webhook.ConstructEventWithOptions(payload, signature, endpointSecret, webhook.ConstructEventOptions{
+	event, err := webhook.ConstructEventWithOptions(payload, signature, h.WebhookSecret, webhook.ConstructEventOptions{
 		IgnoreAPIVersionMismatch: true,
 	})
 	if err != nil {
@@ -207,20 +364,13 @@ func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
 		}
 
 		// 3. Mark order as paid in DB
-		collection := h.DB.Collection("orders")
-		_, err = collection.UpdateOne(c.Request.Context(),
-			bson.M{"_id": orderID},
-			bson.M{"$set": bson.M{
-				"status":        models.StatusPaid,
-				"paymentStatus": "paid",
-				"paymentId":     pi.ID,
-				"updatedAt":     time.Now(),
-			}})
-
+		actor := models.OrderStatusActor{Role: models.OrderActorSystem, Metadata: map[string]string{"paymentId": pi.ID, "source": "webhook"}}
+		err = h.OrderRepo.MarkPaid(c.Request.Context(), orderID, pi.ID, actor)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Database update failed"))
 			return
 		}
+		telemetry.PaymentSucceeded("webhook")
 
 		// 4. Credit Vendors
 		order, err := h.OrderRepo.GetOrderById(c.Request.Context(), orderID)
@@ -228,7 +378,11 @@ func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Could not retrieve order details from DB"))
 			return
 		} else {
+			h.recordPaymentSucceeded(order)
 			h.creditVendors(c.Request.Context(), order)
+			if h.claimPaidOrder(c.Request.Context(), order) {
+				go h.sendOrderConfirmation(order)
+			}
 		}
 
 		c.JSON(http.StatusOK, gin.H{"success": true})
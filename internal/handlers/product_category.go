@@ -5,22 +5,37 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
 	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CategoryHandler struct {
-	DB *mongo.Database
+	DB             *mongo.Database
+	Repo           repository.CategoryRepository
+	AuditRepo      repository.AuditRepository
+	ProductRepo    repository.ProductRepository
+	RankingRepo    repository.RankingRepository
+	CommissionRepo repository.CommissionRepository
+	Media          *services.MediaService
 }
 
-func NewCategoryHandler(db *mongo.Database) *CategoryHandler {
-	return &CategoryHandler{DB: db}
+func NewCategoryHandler(db *mongo.Database, media *services.MediaService) *CategoryHandler {
+	return &CategoryHandler{
+		DB:             db,
+		Repo:           repository.NewCategoryRepository(db),
+		AuditRepo:      repository.NewAuditRepository(db),
+		ProductRepo:    repository.NewProductRepository(db),
+		RankingRepo:    repository.NewRankingRepository(db),
+		CommissionRepo: repository.NewCommissionRepository(db),
+		Media:          media,
+	}
 }
 
 func (h *CategoryHandler) CreateProductCategory(c *gin.Context) {
@@ -31,53 +46,106 @@ func (h *CategoryHandler) CreateProductCategory(c *gin.Context) {
 	}
 
 	if err := validate.Struct(category); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Validation failed"))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
-	if category.Slug == "" {
-		category.Slug = utils.GenerateSlug(category.Name)
+	attributes, err := models.NormalizeAttributeSchema(category.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
 	}
+	category.Attributes = attributes
 
 	category.CreatedAt = time.Now()
 	category.UpdatedAt = time.Now()
 	category.IsActive = true
 
-	collection := h.DB.Collection("categories")
 	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second*10)
 	defer cancel()
 
+	if category.ParentID != nil {
+		parent, err := h.Repo.GetByID(ctx, *category.ParentID)
+		if err != nil || parent == nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Parent category not found"))
+			return
+		}
+	}
+
+	// A slug the admin chose must be free; one derived from the name gets a
+	// numbered suffix until it is
+	if category.Slug != "" {
+		category.Slug = utils.GenerateSlug(category.Slug)
+		if category.Slug == "" {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid slug"))
+			return
+		}
+		available, err := h.Repo.IsSlugAvailable(ctx, category.Slug, primitive.NilObjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create category"))
+			return
+		}
+		if !available {
+			logrus.Warnf("Category creation conflict: slug '%s' already exists", category.Slug)
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Category with this slug already exists"))
+			return
+		}
+	} else {
+		category.Slug, err = h.Repo.GenerateUniqueSlug(ctx, category.Name, primitive.NilObjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create category"))
+			return
+		}
+	}
+
 	logrus.Infof("Attempting to create category: %s (Slug: %s, Parent: %v)", category.Name, category.Slug, category.ParentID)
 
-	var existingCategory models.Category
-	if err := collection.FindOne(ctx, bson.M{"slug": category.Slug}).Decode(&existingCategory); err == nil {
-		logrus.Warnf("Category creation conflict: slug '%s' already exists (ID: %s)", category.Slug, existingCategory.ID.Hex())
+	category, err = h.Repo.Create(ctx, category)
+	if err == repository.ErrCategorySlugTaken {
 		c.JSON(http.StatusConflict, utils.ErrorResponse("Category with this slug already exists"))
 		return
 	}
-
-	ctg, err := collection.InsertOne(ctx, category)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create category"))
 		return
 	}
 
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryCreated,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   category.ID,
+		After:      gin.H{"name": category.Name, "slug": category.Slug},
+	})
+
+	categoryHierarchyCache.Delete("")
+
 	res := gin.H{
-		"id":        ctg.InsertedID,
+		"id":        category.ID,
 		"category":  category,
 		"createdAt": category.CreatedAt,
 	}
 	c.JSON(http.StatusCreated, utils.SuccessResponse("Category created successfully", res))
 }
 
+// categorySorts are the fields category lists can be sorted by.
+var categorySorts = []string{"sortOrder", "name", "createdAt"}
+
+// GetAllProductCategories lists categories in display order unless ?sort=
+// says otherwise, 100 to a page. Filter by parentId, topLevel or featured.
 func (h *CategoryHandler) GetAllProductCategories(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	// Handle query params for filtering
+	// Handle query params for filtering. Only admins can see deactivated
+	// categories.
 	filter := bson.M{}
-	if isActiveStr := c.Query("isActive"); isActiveStr != "" {
-		filter["isActive"] = isActiveStr == "true"
+	role, _ := c.Get("role")
+	if role == "admin" {
+		if isActiveStr := c.Query("isActive"); isActiveStr != "" {
+			filter["isActive"] = isActiveStr == "true"
+		}
+	} else {
+		filter["isActive"] = true
 	}
 	if parentIDStr := c.Query("parentId"); parentIDStr != "" {
 		if pID, err := primitive.ObjectIDFromHex(parentIDStr); err == nil {
@@ -87,24 +155,28 @@ func (h *CategoryHandler) GetAllProductCategories(c *gin.Context) {
 		filter["parentId"] = nil
 	}
 
-	collection := h.DB.Collection("categories")
-	opts := options.Find().SetSort(bson.M{"name": 1})
+	if c.Query("featured") == "true" {
+		filter["isFeatured"] = true
+	}
 
-	cursor, err := collection.Find(ctx, filter, opts)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Failed to fetch categories"))
+	page, limit := pageParams(c, 100, 100)
+	sort, ok := listSort(c, models.Category{}, categorySorts, nil)
+	if !ok {
 		return
 	}
-	defer cursor.Close(ctx)
 
-	categories := []models.Category{}
-	if err := cursor.All(ctx, &categories); err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to decode categories"))
+	categories, total, err := h.Repo.List(ctx, filter, int64(limit), int64((page-1)*limit), sort)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch categories"))
 		return
 	}
+	if role != "admin" {
+		localizeCategories(c, categories)
+	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Categories fetched successfully", gin.H{
 		"categories": categories,
+		"meta":       models.NewPageMeta(total, page, limit),
 	}))
 }
 
@@ -141,17 +213,70 @@ func (h *CategoryHandler) UpdateProductCategory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid update body"))
 		return
 	}
+	if err := validate.Struct(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
 
 	logrus.Infof("Updating category: %s with input %+v", idStr, input)
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
+	if input.Slug != nil {
+		slug := utils.GenerateSlug(*input.Slug)
+		if slug == "" {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid slug"))
+			return
+		}
+		available, err := h.Repo.IsSlugAvailable(ctx, slug, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to validate slug"))
+			return
+		}
+		if !available {
+			c.JSON(http.StatusConflict, utils.ErrorResponse("Category with this slug already exists"))
+			return
+		}
+		input.Slug = &slug
+	}
+	if input.ParentID != nil {
+		// A category can't sit under itself or anything in its own subtree
+		cyclic, err := h.Repo.IsDescendant(ctx, *input.ParentID, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to validate parent category"))
+			return
+		}
+		if cyclic {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("A category cannot be moved under itself or one of its subcategories"))
+			return
+		}
+		parent, err := h.Repo.GetByID(ctx, *input.ParentID)
+		if err != nil || parent == nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Parent category not found"))
+			return
+		}
+	}
+
+	existing, err := h.Repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+
 	input.UpdatedAt = time.Now()
 	update := bson.M{"$set": input}
 
 	logrus.Infof("Database update filter: _id=%v, update=%+v", id, update)
 	res, err := h.DB.Collection("categories").UpdateOne(ctx, bson.M{"_id": id}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Category with this slug already exists"))
+		return
+	}
 	if err != nil {
 		logrus.Errorf("Database error during category update: %v", err)
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update category"))
@@ -165,9 +290,23 @@ func (h *CategoryHandler) UpdateProductCategory(c *gin.Context) {
 		return
 	}
 
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryUpdated,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		Before:     gin.H{"name": existing.Name, "slug": existing.Slug, "parentId": existing.ParentID},
+		After:      gin.H{"changes": input},
+	})
+
+	categoryHierarchyCache.Delete("")
+
 	c.JSON(http.StatusOK, utils.SuccessResponse("Category updated successfully", nil))
 }
 
+// DeleteProductCategory deletes a category. Products filed under it block
+// the delete unless ?reassignTo= names a category to move them to;
+// sub-categories block it unless ?subcategories=reparent moves them up a
+// level or ?subcategories=delete removes them too.
 func (h *CategoryHandler) DeleteProductCategory(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := primitive.ObjectIDFromHex(idStr)
@@ -176,33 +315,309 @@ func (h *CategoryHandler) DeleteProductCategory(c *gin.Context) {
 		return
 	}
 
+	opts := models.CategoryDeleteOptions{Subcategories: c.DefaultQuery("subcategories", models.SubcategoriesBlock)}
+	switch opts.Subcategories {
+	case models.SubcategoriesBlock, models.SubcategoriesReparent, models.SubcategoriesDelete:
+	default:
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("subcategories must be block, reparent or delete"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if reassignStr := c.Query("reassignTo"); reassignStr != "" {
+		targetID, ok := h.resolveTargetCategory(ctx, c, id, reassignStr)
+		if !ok {
+			return
+		}
+		opts.ReassignTo = &targetID
+	}
+
+	existing, err := h.Repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+
+	result, err := h.Repo.Delete(ctx, id, opts)
+	switch err {
+	case nil:
+	case repository.ErrCategoryNotFound:
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	case repository.ErrCategoryHasChildren:
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Cannot delete category with subcategories. Pass subcategories=reparent or subcategories=delete."))
+		return
+	case repository.ErrCategoryHasProducts:
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Cannot delete category that has products. Pass reassignTo to move them to another category."))
+		return
+	case repository.ErrTargetInSubtree:
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Target category is one of the categories being deleted"))
+		return
+	default:
+		logrus.Errorf("Failed to delete category %s: %v", idStr, err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete category"))
+		return
+	}
+
+	after := gin.H{"subcategories": opts.Subcategories, "result": result}
+	if opts.ReassignTo != nil {
+		after["reassignedTo"] = *opts.ReassignTo
+	}
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryDeleted,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		Before: gin.H{
+			"name":        existing.Name,
+			"slug":        existing.Slug,
+			"description": existing.Description,
+			"parentId":    existing.ParentID,
+			"attributes":  existing.Attributes,
+			"isActive":    existing.IsActive,
+			"isFeatured":  existing.IsFeatured,
+			"sortOrder":   existing.SortOrder,
+		},
+		After: after,
+	})
+
+	categoryHierarchyCache.Delete("")
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Category deleted successfully", result))
+}
+
+// resolveTargetCategory parses and checks a category that products are being
+// moved into. When ok is false a response has been written.
+func (h *CategoryHandler) resolveTargetCategory(ctx context.Context, c *gin.Context, sourceID primitive.ObjectID, targetStr string) (primitive.ObjectID, bool) {
+	targetID, err := primitive.ObjectIDFromHex(targetStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid target category ID"))
+		return primitive.NilObjectID, false
+	}
+	if targetID == sourceID {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Target category must be different"))
+		return primitive.NilObjectID, false
+	}
+	target, err := h.Repo.GetByID(ctx, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch target category"))
+		return primitive.NilObjectID, false
+	}
+	if target == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Target category not found"))
+		return primitive.NilObjectID, false
+	}
+	if !target.IsActive {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Target category is inactive"))
+		return primitive.NilObjectID, false
+	}
+	return targetID, true
+}
+
+func (h *CategoryHandler) setCategoryActive(c *gin.Context, active bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	// Check if category has subcategories
-	count, _ := h.DB.Collection("categories").CountDocuments(ctx, bson.M{"parentId": id})
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Cannot delete category with subcategories. Remove them first."))
+	changed, err := h.Repo.SetActive(ctx, id, active)
+	if err == repository.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update category"))
+		return
+	}
+
+	msg, action := "Category activated successfully", models.AuditCategoryActivated
+	if !active {
+		msg, action = "Category deactivated successfully", models.AuditCategoryDeactivated
+	}
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     action,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		After:      gin.H{"isActive": active, "categoriesChanged": changed},
+	})
+
+	categoryHierarchyCache.Delete("")
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(msg, gin.H{"updated": changed}))
+}
+
+// ActivateCategory makes a category visible again.
+func (h *CategoryHandler) ActivateCategory(c *gin.Context) {
+	h.setCategoryActive(c, true)
+}
 
-	// Check if category has products
-	prodCount, _ := h.DB.Collection("products").CountDocuments(ctx, bson.M{"categoryId": id})
-	if prodCount > 0 {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Cannot delete category that has products. Reassign them first."))
+// DeactivateCategory hides a category and its subcategories from buyers and
+// vendors without touching the products in it.
+func (h *CategoryHandler) DeactivateCategory(c *gin.Context) {
+	h.setCategoryActive(c, false)
+}
+
+func (h *CategoryHandler) setCategoryFeatured(c *gin.Context, featured bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
 		return
 	}
 
-	res, err := h.DB.Collection("categories").DeleteOne(ctx, bson.M{"_id": id})
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	found, err := h.Repo.SetFeatured(ctx, id, featured)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete category"))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update category"))
 		return
 	}
+	if !found {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryUpdated,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   id,
+		After:      gin.H{"isFeatured": featured},
+	})
+
+	categoryHierarchyCache.Delete("")
+
+	msg := "Category featured successfully"
+	if !featured {
+		msg = "Category unfeatured successfully"
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse(msg, gin.H{"isFeatured": featured}))
+}
 
-	if res.DeletedCount == 0 {
+// FeatureCategory highlights a category on the storefront (?featured=true).
+func (h *CategoryHandler) FeatureCategory(c *gin.Context) {
+	h.setCategoryFeatured(c, true)
+}
+
+// UnfeatureCategory removes a category from the featured list.
+func (h *CategoryHandler) UnfeatureCategory(c *gin.Context) {
+	h.setCategoryFeatured(c, false)
+}
+
+// ReorderCategories sets the display order of sibling categories.
+func (h *CategoryHandler) ReorderCategories(c *gin.Context) {
+	var input models.CategoryOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err := h.Repo.Reorder(ctx, input.ParentID, input.IDs)
+	if err == repository.ErrCategoryNotFound {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Categories must exist, be listed once and share the same parent"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to reorder categories"))
+		return
+	}
+
+	for i, id := range input.IDs {
+		recordAudit(h.AuditRepo, c, models.AuditLog{
+			Action:     models.AuditCategoryUpdated,
+			TargetType: models.AuditTargetCategory,
+			TargetID:   id,
+			After:      gin.H{"sortOrder": i + 1},
+		})
+	}
+
+	categoryHierarchyCache.Delete("")
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Categories reordered successfully", gin.H{"reordered": len(input.IDs)}))
+}
+
+// MergeCategory folds a category into another: its products and
+// subcategories move to the target and it is deleted.
+func (h *CategoryHandler) MergeCategory(c *gin.Context) {
+	sourceID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID format"))
+		return
+	}
+
+	var input struct {
+		TargetID string `json:"targetId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("targetId is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	source, err := h.Repo.GetByID(ctx, sourceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch category"))
+		return
+	}
+	if source == nil {
 		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
 		return
 	}
+	targetID, ok := h.resolveTargetCategory(ctx, c, sourceID, input.TargetID)
+	if !ok {
+		return
+	}
+	// Merging into a descendant would re-parent children under themselves
+	cyclic, err := h.Repo.IsDescendant(ctx, targetID, sourceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to validate target category"))
+		return
+	}
+	if cyclic {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Cannot merge a category into one of its own subcategories"))
+		return
+	}
 
-	c.JSON(http.StatusOK, utils.SuccessResponse("Category deleted successfully", nil))
+	moved, err := h.Repo.Merge(ctx, sourceID, targetID)
+	if err == repository.ErrCategoryNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Category not found"))
+		return
+	}
+	if err != nil {
+		logrus.Errorf("Failed to merge category %s into %s: %v", sourceID.Hex(), targetID.Hex(), err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to merge categories"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditCategoryMerged,
+		TargetType: models.AuditTargetCategory,
+		TargetID:   sourceID,
+		Before:     gin.H{"name": source.Name, "slug": source.Slug},
+		After:      gin.H{"mergedInto": targetID, "movedProducts": moved},
+	})
+
+	categoryHierarchyCache.Delete("")
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Categories merged successfully", gin.H{
+		"targetId":      targetID,
+		"movedProducts": moved,
+	}))
 }
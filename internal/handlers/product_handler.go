@@ -2,29 +2,45 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductHandler struct {
-	Repo repository.ProductRepository
-	DB   *mongo.Database // Kept for legacy methods until full refactor
+	Repo           repository.ProductRepository
+	ModerationRepo repository.ModerationRepository
+	RankingRepo    repository.RankingRepository
+	SearchRepo     repository.SearchRepository
+	CategoryRepo   repository.CategoryRepository
+	ShippingRepo   repository.ShippingRepository
+	StoreRepo      repository.StoreRepository
+	DB             *mongo.Database // Kept for legacy methods until full refactor
 }
 
 func NewProductHandler(db *mongo.Database, repo repository.ProductRepository) *ProductHandler {
 	return &ProductHandler{
-		Repo: repo,
-		DB:   db,
+		Repo:           repo,
+		ModerationRepo: repository.NewModerationRepository(db),
+		RankingRepo:    repository.NewRankingRepository(db),
+		SearchRepo:     repository.NewSearchRepository(db),
+		CategoryRepo:   repository.NewCategoryRepository(db),
+		ShippingRepo:   repository.NewShippingRepository(db),
+		StoreRepo:      repository.NewStoreRepository(db),
+		DB:             db,
 	}
 }
 
@@ -47,8 +63,15 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 	limitCheck, err := utils.CheckVendorLimits(ctx, userId, h.DB)
 	if err != nil {
+		// The tier is only filled in when the vendor is at their limit
+		code := utils.CodeVendorInactive
+		if limitCheck.Tier != "" {
+			code = utils.CodeVendorLimitReached
+		}
 		c.JSON(http.StatusForbidden, gin.H{
+			"success":    false,
 			"error":      err.Error(),
+			"code":       code,
 			"current":    limitCheck.CurrentCount,
 			"max":        limitCheck.MaxAllowed,
 			"tier":       limitCheck.Tier,
@@ -63,11 +86,26 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 	if err := validate.Struct(product); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	metadata, err := applyCategoryAttributes(ctx, h.CategoryRepo, product.CategoryID, product.Metadata, product.VariantOptions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+	product.Metadata = metadata
+
+	if err := h.checkShippingRestrictions(ctx, userId, &product.ShippingRestrictions); err != nil {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
 		return
 	}
 
 	product.VendorID = userId
+	if product.Status == "" {
+		product.Status = models.ProductStatusDraft
+	}
 	product.CreatedAt = time.Now()
 	product.UpdatedAt = time.Now()
 
@@ -76,6 +114,14 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create product"))
 		return
 	}
+	go screenProductListing(h.ModerationRepo, createdProduct)
+	events.Publish(events.ProductCreated, map[string]interface{}{
+		"productId": createdProduct.ID.Hex(),
+		"vendorId":  userId.Hex(),
+		"name":      createdProduct.Name,
+		"price":     createdProduct.Price.Float64(),
+		"status":    createdProduct.Status,
+	})
 
 	c.JSON(http.StatusCreated, utils.SuccessResponse("Product created successfully", gin.H{
 		"success": true,
@@ -83,6 +129,11 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}))
 }
 
+// vendorProductSorts are the fields a vendor's product list can be sorted by.
+var vendorProductSorts = []string{"createdAt", "updatedAt", "name", "price", "stock", "totalSales", "rating"}
+
+// GetVendorProducts lists the vendor's own products, newest first unless
+// ?sort= says otherwise, optionally matching ?query= by name.
 func (h *ProductHandler) GetVendorProducts(c *gin.Context) {
 	// Get user info from context (set by AuthMiddleware)
 	userIdStr, _ := c.Get("userId")
@@ -90,19 +141,8 @@ func (h *ProductHandler) GetVendorProducts(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	limit := c.Request.URL.Query().Get("limit")
-	page := c.Request.URL.Query().Get("page")
-	searchTerm := c.Request.URL.Query().Get("query")
-
-	if limit == "" {
-		limit = "10"
-	}
-	if page == "" {
-		page = "1"
-	}
-	pageConv, _ := strconv.Atoi(page)
-	convLimit, _ := strconv.ParseInt(limit, 10, 64)
-	skip := (pageConv - 1) * int(convLimit)
+	page, limit := pageParams(c, 10, 100)
+	searchTerm := c.Query("query")
 
 	vendorID, err := primitive.ObjectIDFromHex(userIdStr.(string))
 	if err != nil {
@@ -114,16 +154,29 @@ func (h *ProductHandler) GetVendorProducts(c *gin.Context) {
 	if searchTerm != "" {
 		filter["name"] = bson.M{"$regex": searchTerm, "$options": "i"}
 	}
+	fields, ok := fieldsParam(c, models.Product{})
+	if !ok {
+		return
+	}
+	sort, ok := listSort(c, models.Product{}, vendorProductSorts, nil)
+	if !ok {
+		return
+	}
 
-	products, total, err := h.Repo.GetVendorProducts(ctx, filter, convLimit, int64(skip))
+	products, total, err := h.Repo.GetVendorProducts(ctx, filter, int64(limit), int64((page-1)*limit), sort, fields.Projection())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("failed to fetch products"))
 		return
 	}
+	selected, ok := selectFields(c, fields, products)
+	if !ok {
+		return
+	}
 
 	res := gin.H{
-		"products": products,
+		"products": selected,
 		"total":    total,
+		"meta":     models.NewPageMeta(total, page, limit),
 	}
 	c.JSON(http.StatusOK, utils.SuccessResponse("products fetched successfully", res))
 }
@@ -143,7 +196,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 	if err := validate.Struct(&input); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("invalid json format"))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -164,13 +217,51 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to update this product"))
 		return
 	}
+	if existingProduct.Status == models.ProductStatusRemoved {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("This listing was removed by moderation. Submit an appeal to have it reinstated"))
+		return
+	}
+	if input.Status != nil && *input.Status == models.ProductStatusRemoved {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("invalid product status"))
+		return
+	}
+
+	// Check attributes against the schema of the category the product ends
+	// up in, when either changes
+	if input.CategoryId != nil || input.Metadata != nil || input.VariantOptions != nil {
+		categoryID, metadata, variantOptions := existingProduct.CategoryID, existingProduct.Metadata, existingProduct.VariantOptions
+		if input.CategoryId != nil {
+			categoryID = *input.CategoryId
+		}
+		if input.Metadata != nil {
+			metadata = *input.Metadata
+		}
+		if input.VariantOptions != nil {
+			variantOptions = *input.VariantOptions
+		}
+		metadata, err = applyCategoryAttributes(ctx, h.CategoryRepo, categoryID, metadata, variantOptions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+			return
+		}
+		if metadata != nil {
+			input.Metadata = &metadata
+		}
+	}
+
+	if input.ShippingRestrictions != nil {
+		if err := h.checkShippingRestrictions(ctx, vendorId, input.ShippingRestrictions); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+			return
+		}
+	}
 
 	filter := bson.M{"vendorId": vendorId, "_id": productId}
 	input.UpdatedAt = time.Now()
 
 	updated, err := h.Repo.UpdateProduct(ctx, filter, input)
 	if err != nil {
-		fmt.Println(err)
+		middleware.Log(c).WithError(err).Error("Failed to update product")
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("failed to update product"))
 		return
 	}
@@ -178,9 +269,45 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		c.JSON(http.StatusNotFound, utils.ErrorResponse("product not found or unauthorized"))
 		return
 	}
+
+	// Re-screen the edited listing; if a moderator asked for edits, send it
+	// back to the queue for another look
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		product, err := h.Repo.GetProduct(bgCtx, bson.M{"_id": productId})
+		if err != nil {
+			return
+		}
+		if existingProduct.ModerationStatus == models.ModerationEditsRequested {
+			_, _ = h.ModerationRepo.AddFlag(bgCtx, product, models.ModerationFlag{
+				Source: models.FlagSourceResubmit,
+				Reason: "edits_submitted",
+			})
+		}
+		screenProductListing(h.ModerationRepo, product)
+	}()
+
 	c.JSON(http.StatusOK, utils.SuccessResponse("product updated successfully", gin.H{"success": true}))
 }
 
+// checkShippingRestrictions tidies a product's shipping restrictions and
+// makes sure a domestic-only product's vendor has said where they ship from.
+func (h *ProductHandler) checkShippingRestrictions(ctx context.Context, vendorID primitive.ObjectID, r *models.ShippingRestrictions) error {
+	r.Normalize()
+	if !r.DomesticOnly {
+		return nil
+	}
+	store, err := h.StoreRepo.GetByVendorID(ctx, vendorID)
+	if err != nil {
+		return err
+	}
+	if store == nil || store.Policies == nil || store.Policies.Shipping.Origin == nil {
+		return errors.New("set your ship-from address in your store's shipping policy before limiting a product to domestic shipping")
+	}
+	return nil
+}
+
 func (h *ProductHandler) GetProductById(c *gin.Context) {
 	id, _ := c.Params.Get("id")
 	productId, err := primitive.ObjectIDFromHex(id)
@@ -262,25 +389,113 @@ func (h *ProductHandler) FetchProductsPublic(c *gin.Context) {
 		limit = 12
 	}
 
-	filter := h.buildProductFilter(searchTerm, category)
+	filters, err := models.ParseProductFilters(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+	// Mobile clients ask for just what their cards show; cursor pages need
+	// createdAt for the next cursor, and names are localized from
+	// translations
+	fields, ok := fieldsParam(c, models.Product{}, "createdAt", "translations")
+	if !ok {
+		return
+	}
+
+	filter := h.buildProductFilter(ctx, expandSearch(ctx, h.SearchRepo, searchTerm, searchLanguage(c)), category, filters)
+
+	// Cursor pagination, for infinite scroll. Cursors follow creation order,
+	// so only the newest-first sort supports them.
+	if wantsCursor(c) {
+		if sortParam != "newest" {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Cursor pagination is only available with sort=newest"))
+			return
+		}
+		after, limit, ok := cursorParams(c, 12, 100)
+		if !ok {
+			return
+		}
+		products, err := h.Repo.FetchProductsPublicPage(ctx, filter, after, limit+1, fields.Projection())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("failed to fetch products"))
+			return
+		}
+		products, meta := models.CursorPage(products, limit, func(p models.Product) (time.Time, primitive.ObjectID) {
+			return p.CreatedAt, p.ID
+		})
+		localizeProducts(c, products)
+		selected, ok := selectFields(c, fields, products)
+		if !ok {
+			return
+		}
+		data := gin.H{"products": selected, "meta": meta}
+		if after.IsZero() && searchTerm != "" {
+			if total, err := h.Repo.CountProductsPublic(ctx, filter); err == nil {
+				if searchID := h.logSearch(searchTerm, category, total); searchID != "" {
+					data["searchId"] = searchID
+				}
+			}
+		}
+		c.JSON(http.StatusOK, utils.SuccessResponse("Collection retrieved", data))
+		return
+	}
+
 	sort := h.buildProductSort(sortParam)
 	pageSkip := (page - 1) * limit
 
-	products, total, err := h.Repo.FetchProductsPublic(ctx, filter, sort, limit, pageSkip)
+	products, total, err := h.Repo.FetchProductsPublic(ctx, filter, sort, limit, pageSkip, fields.Projection())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("failed to fetch products"))
 		return
 	}
+	localizeProducts(c, products)
+	selected, ok := selectFields(c, fields, products)
+	if !ok {
+		return
+	}
+
+	meta := gin.H{
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}
+	if page == 1 {
+		if searchID := h.logSearch(searchTerm, category, total); searchID != "" {
+			meta["searchId"] = searchID
+		}
+	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Collection retrieved", gin.H{
-		"products": products,
-		"meta": gin.H{
-			"total": total,
-			"page":  page,
-			"limit": limit,
-		},
+		"products": selected,
+		"meta":     meta,
 	}))
 }
+
+// logSearch records the first page of a search for the search reports,
+// returning the event's ID so the client can report which result was
+// clicked. It returns "" for browsing without a query.
+func (h *ProductHandler) logSearch(searchTerm, category string, total int64) string {
+	query := models.NormalizeSearchQuery(searchTerm)
+	if query == "" {
+		return ""
+	}
+	event := models.SearchEvent{
+		ID:          primitive.NewObjectID(),
+		Query:       query,
+		Category:    category,
+		ResultCount: total,
+		CreatedAt:   time.Now(),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.SearchRepo.RecordSearch(ctx, event); err != nil {
+			logrus.WithError(err).Warn("Failed to record search")
+		}
+	}()
+	return event.ID.Hex()
+}
+
 func (h *ProductHandler) FetchProductsPublicById(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -301,10 +516,58 @@ func (h *ProductHandler) FetchProductsPublicById(c *gin.Context) {
 		return
 	}
 
+	// Breadcrumbs from the top-level category down to the product's
+	if hierarchy, err := loadCategoryHierarchy(ctx, h.CategoryRepo); err != nil {
+		logrus.WithError(err).Warn("Failed to load category hierarchy")
+	} else {
+		product.Breadcrumbs = hierarchy.Breadcrumbs(product.CategoryID)
+	}
+
+	// When it would arrive, for the buyer's region if they've said
+	if !product.IsDigital {
+		product.EstimatedDelivery = h.deliveryEstimate(ctx, product.ID, c.Query("region"))
+	}
+	product.Localize(i18n.FromContext(ctx))
+
+	// Count the view for trending without holding up the response
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.RankingRepo.RecordView(ctx, productId); err != nil {
+			logrus.WithError(err).Warn("Failed to record product view")
+		}
+	}()
+
 	c.JSON(http.StatusOK, product)
 
 }
 
+// deliveryEstimateCache holds product page delivery estimates by product
+// and region, so a popular page doesn't quote shipping on every view.
+var deliveryEstimateCache = utils.NewTTLCache[*models.DeliveryEstimate](15 * time.Minute)
+
+// deliveryEstimate is when one of the product would arrive in region, or nil
+// when it can't be quoted.
+func (h *ProductHandler) deliveryEstimate(ctx context.Context, productID primitive.ObjectID, region string) *models.DeliveryEstimate {
+	key := productID.Hex() + ":" + region
+	if estimate, ok := deliveryEstimateCache.Get(key); ok {
+		return estimate
+	}
+
+	var estimate *models.DeliveryEstimate
+	quote, err := quoteShipping(ctx, h.ShippingRepo, h.Repo, h.StoreRepo, shippingRequest{
+		Items:  []models.CartItem{{ProductID: productID, Quantity: 1}},
+		Region: region,
+	})
+	if err == nil {
+		estimate = quote.EstimatedDelivery
+	} else if ctx.Err() != nil {
+		return nil
+	}
+	deliveryEstimateCache.Set(key, estimate)
+	return estimate
+}
+
 func (h *ProductHandler) FetchSimilarProducts(c *gin.Context) {
 	id := c.Param("id")
 	productId, err := primitive.ObjectIDFromHex(id)
@@ -332,7 +595,7 @@ func (h *ProductHandler) FetchSimilarProducts(c *gin.Context) {
 	sort := bson.M{"createdAt": -1}
 	limit := 4
 
-	similar, _, err := h.Repo.FetchProductsPublic(ctx, filter, sort, limit, 0)
+	similar, _, err := h.Repo.FetchProductsPublic(ctx, filter, sort, limit, 0, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("failed to fetch similar products"))
 		return
@@ -343,22 +606,27 @@ func (h *ProductHandler) FetchSimilarProducts(c *gin.Context) {
 	}))
 }
 
-func (h *ProductHandler) buildProductFilter(query, category string) bson.M {
+func (h *ProductHandler) buildProductFilter(ctx context.Context, terms [][]string, category string, filters models.ProductFilters) bson.M {
 	filter := bson.M{
 		"status": "active",
 	}
 
-	if query != "" {
-		filter["name"] = bson.M{"$regex": query, "$options": "i"}
+	and := searchTermsFilter(terms)
+	for _, cond := range filters.Conditions() {
+		and = append(and, cond)
 	}
 
+	// A category takes in everything filed below it
 	if category != "" {
-
 		if catID, err := primitive.ObjectIDFromHex(category); err == nil {
-			filter["categoryId"] = catID
+			and = append(and, categoryCondition(ctx, h.CategoryRepo, catID))
 		}
 	}
 
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
 	return filter
 }
 
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rankingRefreshInterval is how often trending and best-seller rankings are
+// recomputed.
+const rankingRefreshInterval = time.Hour
+
+// rankingCache holds ranked product lists per kind, category and limit
+// between refreshes.
+var rankingCache = utils.NewTTLCache[[]models.Product](5 * time.Minute)
+
+// StartRankingRefresh rebuilds the product rankings now and then every
+// rankingRefreshInterval until ctx is done. Rebuilds replace rankings
+// wholesale, so overlapping runs from several instances are harmless.
+func StartRankingRefresh(ctx context.Context, repo repository.RankingRepository) {
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		now := time.Now()
+		if err := repo.RebuildTrending(ctx, now); err != nil {
+			logrus.WithError(err).Error("Failed to rebuild trending products")
+		}
+		if err := repo.RebuildBestSellers(ctx, now); err != nil {
+			logrus.WithError(err).Error("Failed to rebuild best-selling products")
+		}
+	}
+
+	go func() {
+		refresh()
+		ticker := time.NewTicker(rankingRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// FetchTrendingProducts lists products selling and being viewed most over
+// the last week. Filter with ?category=<id>; ?limit= defaults to 12.
+func (h *ProductHandler) FetchTrendingProducts(c *gin.Context) {
+	h.fetchRankedProducts(c, models.RankingTrending, "Trending products retrieved")
+}
+
+// FetchBestSellers lists the products that sold the most units over the
+// last 30 days. Filter with ?category=<id>; ?limit= defaults to 12.
+func (h *ProductHandler) FetchBestSellers(c *gin.Context) {
+	h.fetchRankedProducts(c, models.RankingBestSellers, "Best-selling products retrieved")
+}
+
+func (h *ProductHandler) fetchRankedProducts(c *gin.Context, kind, message string) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	if limit < 1 || limit > 50 {
+		limit = 12
+	}
+	var categoryID primitive.ObjectID
+	if raw := c.Query("category"); raw != "" {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid category ID"))
+			return
+		}
+		categoryID = id
+	}
+
+	key := kind + "|" + categoryID.Hex() + "|" + strconv.Itoa(limit)
+	if products, ok := rankingCache.Get(key); ok {
+		c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{"products": products}))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	products, err := loadRankedProducts(ctx, h.Repo, h.RankingRepo, kind, categoryID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch products"))
+		return
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+	rankingCache.Set(key, products)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{"products": products}))
+}
+
+// loadRankedProducts returns the top limit products of a ranking, optionally
+// in one category, in rank order. Before the first rebuild, or in a category
+// with no recent sales, it falls back to all-time sales.
+func loadRankedProducts(ctx context.Context, products repository.ProductRepository, rankings repository.RankingRepository, kind string, categoryID primitive.ObjectID, limit int) ([]models.Product, error) {
+	// 1. Ranked IDs from the precomputed rankings
+	ids, err := rankings.ListRankedProductIDs(ctx, kind, categoryID, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"status": models.ProductStatusActive}
+	if !categoryID.IsZero() {
+		filter["$or"] = bson.A{bson.M{"categoryId": categoryID}, bson.M{"subCategoryIds": categoryID}}
+	}
+	if len(ids) == 0 {
+		found, _, err := products.FetchProductsPublic(ctx, filter, bson.M{"totalSales": -1}, limit, 0, nil)
+		return found, err
+	}
+
+	// 2. Load them, keeping rank order
+	filter["_id"] = bson.M{"$in": ids}
+	found, _, err := products.FetchProductsPublic(ctx, filter, bson.M{"_id": 1}, len(ids), 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	ranked := make([]models.Product, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			ranked = append(ranked, p)
+		}
+	}
+	return ranked, nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recommendationCache holds each user's recommendations briefly; signals
+// only change when they order or redo onboarding.
+var recommendationCache = utils.NewTTLCache[[]models.Recommendation](10 * time.Minute)
+
+type RecommendationHandler struct {
+	Repo        repository.RecommendationRepository
+	UserRepo    repository.UserRepository
+	ProductRepo repository.ProductRepository
+	RankingRepo repository.RankingRepository
+}
+
+func NewRecommendationHandler(db *mongo.Database) *RecommendationHandler {
+	return &RecommendationHandler{
+		Repo:        repository.NewRecommendationRepository(db),
+		UserRepo:    repository.NewUserRepository(db),
+		ProductRepo: repository.NewProductRepository(db),
+		RankingRepo: repository.NewRankingRepository(db),
+	}
+}
+
+// GetRecommendations returns products picked for the user from the
+// categories they chose at onboarding, the categories they buy from and what
+// people with similar purchases bought. Users with nothing to go on get
+// trending products. ?limit= defaults to 12, max 50.
+func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, err := primitive.ObjectIDFromHex(userIdStr.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid user ID"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	if limit < 1 || limit > 50 {
+		limit = 12
+	}
+
+	key := userID.Hex() + "|" + strconv.Itoa(limit)
+	if recs, ok := recommendationCache.Get(key); ok {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Recommendations fetched", gin.H{"recommendations": recs}))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. Gather what we know about the user
+	signals, err := h.loadSignals(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch recommendations"))
+		return
+	}
+
+	// 2. Score candidates from the user's categories and co-purchases
+	recs := []models.Recommendation{}
+	if !signals.Empty() {
+		categoryIDs := make([]primitive.ObjectID, 0, len(signals.InterestCategories)+len(signals.PurchaseCategories))
+		for id := range signals.InterestCategories {
+			categoryIDs = append(categoryIDs, id)
+		}
+		for id := range signals.PurchaseCategories {
+			if !signals.InterestCategories[id] {
+				categoryIDs = append(categoryIDs, id)
+			}
+		}
+		coIDs := make([]primitive.ObjectID, 0, len(signals.CoPurchases))
+		for id := range signals.CoPurchases {
+			coIDs = append(coIDs, id)
+		}
+
+		candidates, err := h.Repo.GetCandidates(ctx, categoryIDs, coIDs, int64(limit*8))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch recommendations"))
+			return
+		}
+		recs = models.RankRecommendations(candidates, signals, limit)
+	}
+
+	// 3. Top up with trending products
+	if len(recs) < limit {
+		trending, err := h.trending(ctx, signals.Exclude, recs, limit-len(recs))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch recommendations"))
+			return
+		}
+		recs = append(recs, trending...)
+	}
+	recommendationCache.Set(key, recs)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Recommendations fetched", gin.H{"recommendations": recs}))
+}
+
+// loadSignals reads the user's onboarding interests and preferences and
+// their order history.
+func (h *RecommendationHandler) loadSignals(ctx context.Context, userID primitive.ObjectID) (models.RecommendationSignals, error) {
+	signals := models.RecommendationSignals{
+		InterestCategories: map[primitive.ObjectID]bool{},
+		PurchaseCategories: map[primitive.ObjectID]int{},
+		CoPurchases:        map[primitive.ObjectID]int{},
+		Exclude:            map[primitive.ObjectID]bool{},
+	}
+
+	user, err := h.UserRepo.GetByID(ctx, userID)
+	if err != nil {
+		return signals, err
+	}
+	var refs []string
+	if user.Interests != nil {
+		refs = append(refs, user.Interests.Categories...)
+	}
+	if user.Preferences != nil {
+		refs = append(refs, user.Preferences.Categories...)
+		signals.BudgetMin, signals.BudgetMax, _ = models.ParseBudgetRange(user.Preferences.BudgetRange)
+	}
+	categoryIDs, err := h.Repo.ResolveCategories(ctx, refs)
+	if err != nil {
+		return signals, err
+	}
+	for _, id := range categoryIDs {
+		signals.InterestCategories[id] = true
+	}
+
+	purchased, err := h.Repo.GetPurchasedProducts(ctx, userID)
+	if err != nil {
+		return signals, err
+	}
+	productIDs := make([]primitive.ObjectID, 0, len(purchased))
+	for _, p := range purchased {
+		signals.PurchaseCategories[p.CategoryID] += p.Lines
+		signals.Exclude[p.ProductID] = true
+		productIDs = append(productIDs, p.ProductID)
+	}
+	signals.CoPurchases, err = h.Repo.GetCoPurchases(ctx, userID, productIDs, 50)
+	return signals, err
+}
+
+// trending returns up to n trending products not already bought or picked,
+// falling back to all-time sales before the rankings are first built.
+func (h *RecommendationHandler) trending(ctx context.Context, exclude map[primitive.ObjectID]bool, picked []models.Recommendation, n int) ([]models.Recommendation, error) {
+	skip := make(map[primitive.ObjectID]bool, len(exclude)+len(picked))
+	for id := range exclude {
+		skip[id] = true
+	}
+	for _, r := range picked {
+		skip[r.ID] = true
+	}
+	products, err := loadRankedProducts(ctx, h.ProductRepo, h.RankingRepo, models.RankingTrending, primitive.NilObjectID, n+len(skip))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.Recommendation, 0, n)
+	for _, p := range products {
+		if len(out) == n {
+			break
+		}
+		if skip[p.ID] {
+			continue
+		}
+		out = append(out, models.Recommendation{Product: p, Reason: models.ReasonTrending})
+	}
+	return out, nil
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReportHandler struct {
+	DB             *mongo.Database
+	Repo           repository.ReportRepository
+	ModerationRepo repository.ModerationRepository
+	ProductRepo    repository.ProductRepository
+}
+
+func NewReportHandler(db *mongo.Database) *ReportHandler {
+	return &ReportHandler{
+		DB:             db,
+		Repo:           repository.NewReportRepository(db),
+		ModerationRepo: repository.NewModerationRepository(db),
+		ProductRepo:    repository.NewProductRepository(db),
+	}
+}
+
+// reportTargetOwner returns the user responsible for a piece of reported
+// content, or a zero ID if it doesn't exist.
+func reportTargetOwner(ctx context.Context, db *mongo.Database, targetType string, targetID primitive.ObjectID) primitive.ObjectID {
+	// Stores spell the owner field vendorID, products vendorId
+	var doc struct {
+		StoreVendorID   primitive.ObjectID `bson:"vendorID"`
+		ProductVendorID primitive.ObjectID `bson:"vendorId"`
+		UserID          primitive.ObjectID `bson:"userId"`
+		SenderID        primitive.ObjectID `bson:"senderId"`
+	}
+	if err := db.Collection(models.ReportTargetCollections[targetType]).FindOne(ctx, bson.M{"_id": targetID}).Decode(&doc); err != nil {
+		return primitive.NilObjectID
+	}
+	switch targetType {
+	case "review":
+		return doc.UserID
+	case "message":
+		return doc.SenderID
+	case "product":
+		return doc.ProductVendorID
+	default:
+		return doc.StoreVendorID
+	}
+}
+
+// filterReportCategories is the report category each content filter check
+// files under.
+var filterReportCategories = map[string]string{
+	services.FilterProfanity:   "offensive",
+	services.FilterPII:         "privacy",
+	services.FilterContactInfo: "spam",
+}
+
+// flagFilteredContent queues content the filter masked for a moderator to
+// look at, as an automated report. The matched text itself isn't kept, as it
+// may be someone's personal details. It is meant to run in the background
+// after the content is saved.
+func flagFilteredContent(repo repository.ReportRepository, targetType string, targetID primitive.ObjectID, result services.FilterResult) {
+	if !result.Flagged() {
+		return
+	}
+	counts := map[string]int{}
+	for _, m := range result.Matches {
+		counts[m.Check]++
+	}
+	checks := result.Checks()
+	var found []string
+	for _, check := range checks {
+		found = append(found, fmt.Sprintf("%s (%d)", strings.ReplaceAll(check, "_", " "), counts[check]))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := repo.Create(ctx, &models.ContentReport{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Category:   filterReportCategories[checks[0]],
+		Details:    "Masked by the content filter: " + strings.Join(found, ", "),
+		Automated:  true,
+	})
+	if err != nil && err != repository.ErrDuplicateReport {
+		logrus.WithError(err).WithField("targetId", targetID.Hex()).Error("Failed to flag filtered content")
+	}
+}
+
+// filterRejection is the error message for text a rejecting surface refused.
+func filterRejection(result services.FilterResult) string {
+	var found []string
+	for _, check := range result.Checks() {
+		switch check {
+		case services.FilterProfanity:
+			found = append(found, "profanity")
+		case services.FilterPII:
+			found = append(found, "personal details such as emails or phone numbers")
+		case services.FilterContactInfo:
+			found = append(found, "links or contact details")
+		}
+	}
+	return "Please remove " + strings.Join(found, " and ") + " and try again"
+}
+
+// CreateReport lets a signed-in user report a product, review, store or
+// message. Product reports also feed the product moderation queue.
+func (h *ReportHandler) CreateReport(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		TargetType string `json:"targetType" binding:"required"`
+		TargetID   string `json:"targetId" binding:"required"`
+		Category   string `json:"category" binding:"required"`
+		Details    string `json:"details" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("targetType, targetId and category are required"))
+		return
+	}
+	if _, ok := models.ReportTargetCollections[input.TargetType]; !ok {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("targetType must be one of product, review, store or message"))
+		return
+	}
+	validCategory := false
+	for _, cat := range models.ReportCategories {
+		if input.Category == cat {
+			validCategory = true
+			break
+		}
+	}
+	if !validCategory {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("category must be one of: "+strings.Join(models.ReportCategories, ", ")))
+		return
+	}
+	targetID, err := primitive.ObjectIDFromHex(input.TargetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid target ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	report, status, msg := h.fileReport(ctx, userID, input.TargetType, targetID, input.Category, input.Details)
+	if report == nil {
+		c.JSON(status, utils.ErrorResponse(msg))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Thanks, our team will review your report", gin.H{"report": report}))
+}
+
+// ReportProduct lets a signed-in user report a listing as counterfeit,
+// prohibited or a scam. It goes to the report queue and the product
+// moderation queue.
+func (h *ReportHandler) ReportProduct(c *gin.Context) {
+	h.reportListing(c, "product")
+}
+
+// ReportStore lets a signed-in user report a seller's store as counterfeit,
+// prohibited or a scam.
+func (h *ReportHandler) ReportStore(c *gin.Context) {
+	h.reportListing(c, "store")
+}
+
+func (h *ReportHandler) reportListing(c *gin.Context, targetType string) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	targetID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid "+targetType+" ID"))
+		return
+	}
+	var input struct {
+		Reason  string `json:"reason" binding:"required"`
+		Details string `json:"details" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A reason is required"))
+		return
+	}
+	validReason := false
+	for _, r := range models.ListingReportReasons {
+		if input.Reason == r {
+			validReason = true
+			break
+		}
+	}
+	if !validReason {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Reason must be one of: "+strings.Join(models.ListingReportReasons, ", ")))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	report, status, msg := h.fileReport(ctx, userID, targetType, targetID, input.Reason, input.Details)
+	if report == nil {
+		c.JSON(status, utils.ErrorResponse(msg))
+		return
+	}
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Thanks, our team will review your report", gin.H{"report": report}))
+}
+
+// fileReport files a report once the reporter is under their rate limit and
+// the content exists and isn't their own. Product reports also feed the
+// product moderation queue. It returns nil with the status and message to
+// respond with when the report can't be filed.
+func (h *ReportHandler) fileReport(ctx context.Context, userID primitive.ObjectID, targetType string, targetID primitive.ObjectID, category, details string) (*models.ContentReport, int, string) {
+	// 1. Limit how fast one user can file reports
+	recent, err := h.Repo.CountByReporterSince(ctx, userID, time.Now().Add(-models.ReportRateWindow))
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to submit report"
+	}
+	if recent >= models.ReportRateLimit {
+		return nil, http.StatusTooManyRequests, "You've filed a lot of reports recently; please try again later"
+	}
+
+	// 2. The content must exist and not be the reporter's own
+	owner := reportTargetOwner(ctx, h.DB, targetType, targetID)
+	if owner.IsZero() {
+		return nil, http.StatusNotFound, "Reported content not found"
+	}
+	if owner == userID {
+		return nil, http.StatusBadRequest, "You cannot report your own content"
+	}
+
+	// 3. File the report
+	report := models.ContentReport{
+		ReporterID: userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Category:   category,
+		Details:    strings.TrimSpace(details),
+	}
+	if err := h.Repo.Create(ctx, &report); err != nil {
+		if err == repository.ErrDuplicateReport {
+			return nil, http.StatusConflict, "You have already reported this content"
+		}
+		return nil, http.StatusInternalServerError, "Failed to submit report"
+	}
+
+	// 4. Products go through the listing moderation queue as well
+	if targetType == "product" {
+		if product, err := h.ProductRepo.GetProduct(ctx, bson.M{"_id": targetID}); err == nil {
+			if _, err := h.ModerationRepo.AddFlag(ctx, product, models.ModerationFlag{
+				Source:     models.FlagSourceUserReport,
+				ReporterID: &userID,
+				Reason:     category,
+				Details:    report.Details,
+			}); err != nil {
+				logrus.WithError(err).WithField("productId", targetID.Hex()).Error("Failed to flag reported product")
+			}
+		}
+	}
+	return &report, 0, ""
+}
+
+// GetMyReports lists the reports the current user has filed and where they
+// stand.
+func (h *ReportHandler) GetMyReports(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	reports, total, err := h.Repo.List(ctx, bson.M{"reporterID": userID}, 100, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch reports"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Reports fetched", gin.H{"reports": reports, "total": total}))
+}
+
+// notifyReporter tells the reporter how their report was handled.
+func notifyReporter(db *mongo.Database, report models.ContentReport) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := db.Collection("users").FindOne(ctx, bson.M{"_id": report.ReporterID}).Decode(&user); err != nil || user.Email == "" {
+		return
+	}
+
+	outcome := "We've reviewed it and taken action. Thank you for helping keep Vendora safe."
+	if report.Status == models.ReportStatusDismissed || report.Resolution == "no_violation" {
+		outcome = "We've reviewed it and found it doesn't break our guidelines, so no action was taken."
+	}
+	body := fmt.Sprintf("<p>Hello %s,</p><p>Thanks for your report about a %s. %s</p><p>Best regards,<br>The Vendora Team</p>",
+		html.EscapeString(user.Name), report.TargetType, outcome)
+	if err := utils.SendEmail(user.Email, "Update on your report", body); err != nil {
+		logrus.WithError(err).WithField("reportId", report.ID.Hex()).Error("Failed to send report update email")
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
 	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,12 +17,18 @@ import (
 )
 
 type ReviewHandler struct {
-	Repo repository.ReviewRepository
+	Repo       repository.ReviewRepository
+	ReportRepo repository.ReportRepository
+	Filter     *services.ContentFilter
 }
 
 func NewReviewHandler(db *mongo.Database) *ReviewHandler {
 	repo := repository.NewReviewRepository(db)
-	return &ReviewHandler{Repo: repo}
+	return &ReviewHandler{
+		Repo:       repo,
+		ReportRepo: repository.NewReportRepository(db),
+		Filter:     services.NewContentFilter(),
+	}
 }
 
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
@@ -46,6 +54,12 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
 		return
 	}
+	filtered, err := h.Filter.Apply(services.FilterReview, input.Comment)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(filterRejection(filtered)))
+		return
+	}
+	input.Comment = filtered.Text
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -55,6 +69,15 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
 		return
 	}
+	go flagFilteredContent(h.ReportRepo, "review", review.ID, filtered)
+
+	events.Publish(events.ReviewCreated, map[string]interface{}{
+		"reviewId":   review.ID.Hex(),
+		"productId":  review.ProductID.Hex(),
+		"vendorId":   review.VendorID.Hex(),
+		"reviewerId": userID.Hex(),
+		"rating":     review.Rating,
+	})
 
 	c.JSON(http.StatusCreated, utils.SuccessResponse("Review submitted successfully", gin.H{"review": review}))
 }
@@ -70,13 +93,7 @@ func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	reviews, err := h.Repo.GetProductReviews(ctx, productID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch reviews"))
-		return
-	}
-
-	c.JSON(http.StatusOK, utils.SuccessResponse("Reviews fetched successfully", gin.H{"reviews": reviews}))
+	h.listReviews(ctx, c, bson.M{"productId": productID}, "Reviews fetched successfully")
 }
 
 func (h *ReviewHandler) GetVendorReviews(c *gin.Context) {
@@ -86,13 +103,53 @@ func (h *ReviewHandler) GetVendorReviews(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	reviews, err := h.Repo.GetVendorReviews(ctx, vendorID)
+	h.listReviews(ctx, c, bson.M{"vendorId": vendorID}, "Vendor reviews fetched successfully")
+}
+
+// reviewSorts are the fields review lists can be sorted by.
+var reviewSorts = []string{"createdAt", "rating"}
+
+// listReviews responds with the reviews matching filter: a cursor page when
+// ?cursor= is given, otherwise a numbered page in ?sort= order, newest first
+// by default.
+func (h *ReviewHandler) listReviews(ctx context.Context, c *gin.Context, filter bson.M, message string) {
+	if wantsCursor(c) {
+		h.listReviewsPage(ctx, c, filter, message)
+		return
+	}
+
+	sort, ok := listSort(c, models.Review{}, reviewSorts, nil)
+	if !ok {
+		return
+	}
+
+	page, limit := pageParams(c, 20, 100)
+	reviews, total, err := h.Repo.ListReviews(ctx, filter, int64(limit), int64((page-1)*limit), sort)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch reviews"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{"reviews": reviews, "meta": models.NewPageMeta(total, page, limit)}))
+}
+
+// listReviewsPage responds with a cursor page of the reviews matching
+// filter, newest first.
+func (h *ReviewHandler) listReviewsPage(ctx context.Context, c *gin.Context, filter bson.M, message string) {
+	after, limit, ok := cursorParams(c, 20, 100)
+	if !ok {
+		return
+	}
+	reviews, err := h.Repo.ListReviewsPage(ctx, filter, after, int64(limit)+1)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor reviews"))
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch reviews"))
 		return
 	}
+	reviews, meta := models.CursorPage(reviews, limit, func(r models.Review) (time.Time, primitive.ObjectID) {
+		return r.CreatedAt, r.ID
+	})
 
-	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor reviews fetched successfully", gin.H{"reviews": reviews}))
+	c.JSON(http.StatusOK, utils.SuccessResponse(message, gin.H{"reviews": reviews, "meta": meta}))
 }
 
 func (h *ReviewHandler) RespondToReview(c *gin.Context) {
@@ -111,6 +168,12 @@ func (h *ReviewHandler) RespondToReview(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
 		return
 	}
+	filtered, err := h.Filter.Apply(services.FilterReviewResponse, input.Response)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(filterRejection(filtered)))
+		return
+	}
+	input.Response = filtered.Text
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -119,6 +182,16 @@ func (h *ReviewHandler) RespondToReview(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse(err.Error()))
 		return
 	}
+	go flagFilteredContent(h.ReportRepo, "review", reviewID, filtered)
+
+	if review, err := h.Repo.GetReviewByID(ctx, reviewID); err == nil {
+		events.Publish(events.ReviewResponded, map[string]interface{}{
+			"reviewId":   review.ID.Hex(),
+			"productId":  review.ProductID.Hex(),
+			"vendorId":   vendorID.Hex(),
+			"reviewerId": review.UserID.Hex(),
+		})
+	}
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Response added successfully", nil))
 }
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	reviewInsightsInterval = 10 * time.Minute
+	reviewInsightsBatch    = 500
+)
+
+// StartReviewInsights analyzes reviews not analyzed yet, or by an older
+// analyzer, now and then every reviewInsightsInterval until ctx is done.
+// Analysis is deterministic, so overlapping runs from several instances are
+// harmless.
+func StartReviewInsights(ctx context.Context, repo repository.ReviewRepository) {
+	analyze := func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		n, err := analyzeReviews(ctx, repo)
+		if err != nil {
+			logrus.WithError(err).WithField("analyzed", n).Error("Failed to analyze reviews")
+			return
+		}
+		if n > 0 {
+			logrus.WithField("analyzed", n).Info("Analyzed reviews")
+		}
+	}
+
+	go func() {
+		analyze()
+		ticker := time.NewTicker(reviewInsightsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				analyze()
+			}
+		}
+	}()
+}
+
+// analyzeReviews works through the reviews waiting for analysis a batch at
+// a time and returns how many it did.
+func analyzeReviews(ctx context.Context, repo repository.ReviewRepository) (int, error) {
+	done := 0
+	for {
+		reviews, err := repo.ListReviewsToAnalyze(ctx, reviewInsightsBatch)
+		if err != nil {
+			return done, err
+		}
+		for _, review := range reviews {
+			insights := services.AnalyzeReview(review.Comment, review.Rating, time.Now())
+			if err := repo.SaveReviewInsights(ctx, review.ID, insights); err != nil {
+				return done, err
+			}
+			done++
+		}
+		if len(reviews) < reviewInsightsBatch {
+			return done, nil
+		}
+	}
+}
+
+// reviewInsights responds with the insights summary of the reviews matching
+// filter over the range in ?window= or ?from=/?to=, as for the dashboard.
+func (h *ReviewHandler) reviewInsights(c *gin.Context, filter bson.M) {
+	_, from, to, err := ParseDashboardWindow(c.Query("window"), c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+	if id := c.Query("productId"); id != "" {
+		productID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid product ID"))
+			return
+		}
+		filter["productId"] = productID
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sentiments, topics, err := h.Repo.GetReviewInsights(ctx, filter, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch review insights"))
+		return
+	}
+	summary := models.BuildReviewInsightsSummary(sentiments, topics, from, to)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Review insights fetched", gin.H{"insights": summary}))
+}
+
+// GetVendorReviewInsights sums up the sentiment of the vendor's reviews and
+// what they talk about, like sizing or late delivery, against the range
+// before, so topics trending negative stand out. Narrow it to one product
+// with ?productId=.
+func (h *ReviewHandler) GetVendorReviewInsights(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	h.reviewInsights(c, bson.M{"vendorId": vendorID})
+}
+
+// GetReviewInsights is GetVendorReviewInsights for admins, across the
+// platform or for one ?vendorId= or ?productId=.
+func (h *ReviewHandler) GetReviewInsights(c *gin.Context) {
+	filter := bson.M{}
+	if id := c.Query("vendorId"); id != "" {
+		vendorID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid vendor ID"))
+			return
+		}
+		filter["vendorId"] = vendorID
+	}
+	h.reviewInsights(c, filter)
+}
@@ -1,20 +1,37 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/docs"
+	"github.com/developia-II/ecommerce-backend/internal/events"
 	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
+	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetupRoutes(router *gin.Engine, db *mongo.Database) {
+func SetupRoutes(router *gin.Engine, db *mongo.Database, cfg *config.Config) {
 	logrus.Info("Setting up routes...")
 
+	// /api/v2 answers in one envelope; /api/v1 announces its retirement.
+	// First, so responses from the middleware below are versioned too
+	router.Use(middleware.APIVersion(cfg.API))
+	// Messages and content in the client's Accept-Language
+	router.Use(middleware.Localize())
+
 	router.GET("/", func(c *gin.Context) {
-		logrus.Info("Handling request to /")
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Server is running!",
 			"status":  "ok",
@@ -22,27 +39,135 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 	})
 
 	router.GET("/health", func(c *gin.Context) {
-		logrus.Info("Handling request to /health")
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "healthy",
 			"service": "vendora-backend",
 		})
 	})
 
+	// Prometheus scrapes request latencies, error rates and business counters
+	router.GET("/metrics", middleware.MetricsAuth(cfg.Telemetry.MetricsToken), gin.WrapH(telemetry.Handler()))
+
+	// Swagger UI and the OpenAPI document, regenerated by go generate ./internal/docs
+	docs.Register(router)
+
 	if db != nil {
 		logrus.Info("Database connected - setting up database routes")
+		// One media client for every handler that takes uploads
+		mediaStore, err := services.NewCloudinaryStore(cfg.Cloudinary)
+		if err != nil {
+			logrus.WithError(err).Warn("Media storage unavailable - uploads will fail")
+		}
+		media := services.NewMediaService(mediaStore)
+
 		userRepo := repository.NewUserRepository(db)
 		authHandler := NewAuthHandler(db)
-		onboardingHandler := NewOnboardingHandler(db)
+		onboardingHandler := NewOnboardingHandler(db, media)
 		productRepo := repository.NewProductRepository(db)
 		productHandler := NewProductHandler(db, productRepo)
-		categoryHandler := NewCategoryHandler(db)
-		uploadHandler := NewUploadHandler(db)
+		categoryHandler := NewCategoryHandler(db, media)
+		uploadHandler := NewUploadHandler(db, media)
 		vendorHandler := NewVendorHandler(db, userRepo)
 
+		// Let AuthMiddleware see suspensions, bans and role changes made after
+		// a token was issued
+		middleware.SetAccountStateLookup(func(ctx context.Context, userID string) (middleware.AccountState, error) {
+			id, err := primitive.ObjectIDFromHex(userID)
+			if err != nil {
+				return middleware.AccountState{}, middleware.ErrAccountNotFound
+			}
+			status, role, err := userRepo.GetAccountState(ctx, id)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return middleware.AccountState{}, middleware.ErrAccountNotFound
+			}
+			return middleware.AccountState{Status: status, Role: role}, err
+		})
+
+		// Turn domain events into in-app notifications
+		notificationHandler := NewNotificationHandler(db)
+		events.Subscribe(events.AllEvents, notificationHandler.HandleEvent)
+
+		// Push order and message events to open live streams
+		liveHandler := NewLiveHandler(db)
+		events.Subscribe(events.AllEvents, liveHandler.HandleEvent)
+
+		// Send events to the endpoints integrators have subscribed
+		webhookHandler := NewWebhookHandler(db)
+		events.Subscribe(events.AllEvents, webhookHandler.HandleEvent)
+
+		// Publish the order, payment and application events written to the
+		// outbox with the changes they announce
+		StartOutboxRelay(context.Background(), repository.NewOutboxRepository(db), events.Default)
+
+		// Keep trending and best-seller rankings fresh
+		StartRankingRefresh(context.Background(), productHandler.RankingRepo)
+
+		// Put scheduled sales on and take them off again
+		saleCampaignHandler := NewSaleCampaignHandler(db)
+		StartSaleCampaigns(context.Background(), saleCampaignHandler.Repo)
+
+		// Precompute the admin analytics days every night
+		StartPlatformAnalytics(context.Background(), repository.NewAnalyticsRepository(db))
+
+		// Build queued report exports and delete them once they expire
+		exportHandler := NewExportHandler(db, media)
+		exportHandler.StartWorker(context.Background())
+
+		// Analyze new reviews for sentiment and topics
+		StartReviewInsights(context.Background(), repository.NewReviewRepository(db))
+
+		// Purge soft-deleted documents once they are past retention
+		StartTrashPurge(context.Background(), repository.NewTrashRepository(db), cfg.TrashRetention)
+
+		// Weekly digest emails. Opt-in per deployment so staging doesn't mail
+		// real users.
+		if os.Getenv("DIGEST_ENABLED") == "true" {
+			NewDigestJob(db).Start(context.Background())
+		}
+
+		// Stamp every request made with an admin impersonation token into the
+		// audit log
+		auditRepo := repository.NewAuditRepository(db)
+		middleware.SetImpersonationRecorder(func(req middleware.ImpersonatedRequest) {
+			adminID, _ := primitive.ObjectIDFromHex(req.ImpersonatorID)
+			userID, _ := primitive.ObjectIDFromHex(req.UserID)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := auditRepo.Record(ctx, models.AuditLog{
+				ActorID:    adminID,
+				ActorRole:  "admin",
+				Action:     models.AuditImpersonatedRequest,
+				TargetType: models.AuditTargetUser,
+				TargetID:   userID,
+				After:      map[string]interface{}{"method": req.Method, "path": req.Path, "status": req.Status},
+				IP:         req.IP,
+				CreatedAt:  req.At,
+			})
+			if err != nil {
+				logrus.WithError(err).Error("Failed to record impersonated request")
+			}
+		})
+
+		// Keep a log of every email send attempt for debugging delivery
+		emailLogRepo := repository.NewEmailLogRepository(db)
+		utils.SetEmailSendLogger(func(entry models.EmailLog) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := emailLogRepo.Record(ctx, entry); err != nil {
+				logrus.WithError(err).Error("Failed to record email log")
+			}
+		})
+
+		// Rate limit every client, with tighter limits on the routes worth
+		// abusing, and shut out the clients admins block
+		limiter := middleware.NewRateLimiter(cfg.RateLimits)
+		abuseHandler := NewAbuseHandler(db, limiter)
+		abuseHandler.StartBlockRefresh(context.Background())
+		router.Use(limiter.Limit(middleware.RateLimitGlobal))
+
 		// Public Routes
 		v1Group := router.Group("/api/v1")
-		authGroup := v1Group.Group("/auth")
+		authGroup := v1Group.Group("/auth", limiter.Limit(middleware.RateLimitAuth))
 		{
 			authGroup.POST("/register", authHandler.CreateUser)
 			authGroup.POST("/verify/:token", authHandler.VerifyEmail)
@@ -53,30 +178,92 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 			authGroup.POST("/resend/:token", authHandler.ResendVerification)
 		}
 
-		// Public Product Routes
-		publicProductGroup := v1Group.Group("/public/products")
+		// One-click unsubscribe from email footers
+		v1Group.POST("/notifications/unsubscribe/:token", notificationHandler.Unsubscribe)
+
+		// Signed, expiring links to finished exports
+		v1Group.GET("/exports/download/:token", exportHandler.DownloadExport)
+
+		// Public Product Routes. The public catalog carries ETag and
+		// Last-Modified so storefronts and CDNs can revalidate instead of
+		// downloading unchanged responses again
+		publicProductGroup := v1Group.Group("/public/products", middleware.HTTPCache(time.Minute))
 		{
-			publicProductGroup.GET("", productHandler.FetchProductsPublic)
+			publicProductGroup.GET("", limiter.Limit(middleware.RateLimitSearch), productHandler.FetchProductsPublic)
+			publicProductGroup.GET("/trending", productHandler.FetchTrendingProducts)
+			publicProductGroup.GET("/best-sellers", productHandler.FetchBestSellers)
 			publicProductGroup.GET("/:id", productHandler.FetchProductsPublicById)
 			publicProductGroup.GET("/:id/similar", productHandler.FetchSimilarProducts)
 		}
 
+		// Public Search Routes
+		searchHandler := NewSearchHandler(db)
+		searchLimit := limiter.Limit(middleware.RateLimitSearch)
+		v1Group.GET("/public/search/suggest", searchLimit, searchHandler.Suggest)
+		v1Group.POST("/public/search/click", searchLimit, searchHandler.RecordClick)
+
 		// Public Category Routes
-		publicCategoryGroup := v1Group.Group("/public/categories")
+		publicCategoryGroup := v1Group.Group("/public/categories", middleware.HTTPCache(5*time.Minute))
 		{
 			publicCategoryGroup.GET("", categoryHandler.GetAllProductCategories)
+			publicCategoryGroup.GET("/tree", categoryHandler.GetCategoryTree)
+			publicCategoryGroup.GET("/:slug", categoryHandler.GetCategoryBySlug)
+			publicCategoryGroup.GET("/:slug/landing", categoryHandler.GetCategoryLanding)
 		}
 
 		// Public Vendor Routes
-		publicVendorGroup := v1Group.Group("/public/vendors")
+		publicVendorGroup := v1Group.Group("/public/vendors", middleware.HTTPCache(time.Minute))
 		{
 			publicVendorGroup.GET("", vendorHandler.ListPublicVendors)
 			publicVendorGroup.GET("/:id", vendorHandler.GetPublicVendorById)
+			publicVendorGroup.GET("/:id/profile", vendorHandler.GetPublicVendorProfile)
+		}
+
+		// Public Storefront Routes
+		storeHandler := NewStoreHandler(db, media)
+		publicStoreGroup := v1Group.Group("/public/stores", middleware.HTTPCache(time.Minute))
+		{
+			publicStoreGroup.GET("/:slug", storeHandler.GetPublicStore)
+			publicStoreGroup.GET("/:slug/collections", storeHandler.GetPublicStoreCollections)
+			publicStoreGroup.GET("/:slug/collections/:collectionSlug", storeHandler.GetPublicStoreCollection)
+		}
+
+		// GraphQL for the storefront. Catalog queries are public; the cart and
+		// orders need a signed-in buyer
+		graphQLHandler := NewGraphQLHandler(db)
+		router.POST("/graphql", middleware.OptionalAuth(), graphQLHandler.Query)
+
+		// Several reads in one round trip, for mobile apps' first screens
+		batchHandler := NewBatchHandler(middleware.VersionedAPI(router))
+		v1Group.POST("/batch", batchHandler.Batch)
+
+		// Public Flash Sales
+		flashSaleHandler := NewFlashSaleHandler(db)
+		v1Group.GET("/public/flash-sales", flashSaleHandler.GetPublicFlashSales)
+
+		// Sitemap and the Google Merchant Center product feed, rebuilt hourly
+		sitemapHandler := NewSitemapHandler(db)
+		router.GET("/sitemap.xml", sitemapHandler.GetSitemap)
+		merchantFeedHandler := NewMerchantFeedHandler(db, cfg.MerchantFeedCurrency)
+		router.GET("/feeds/google-merchant.xml", merchantFeedHandler.GetMerchantFeedXML)
+		router.GET("/feeds/google-merchant.tsv", merchantFeedHandler.GetMerchantFeedTSV)
+		StartFeedRefresh(context.Background(), sitemapHandler, merchantFeedHandler)
+
+		// Live updates. EventSource and WebSocket can't set headers, so the
+		// stream and socket also take the access token as ?access_token=
+		live := v1Group.Group("/live", middleware.TokenFromQuery("access_token"), middleware.AuthMiddleware())
+		{
+			live.GET("", liveHandler.Stream)
+			live.GET("/ws", liveHandler.Socket)
+			live.GET("/poll", liveHandler.Poll)
 		}
 
 		// Protected Routes
 		protected := router.Group("/api/v1")
 		protected.Use(middleware.AuthMiddleware())
+		// Lets clients on flaky networks retry creates without duplicating
+		// orders, charges or products
+		idempotent := middleware.Idempotency(repository.NewIdempotencyRepository(db))
 		{
 			// Profile / User Routes
 			userHandler := NewUserHandler(db)
@@ -85,6 +272,8 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 				profileGroup.GET("", userHandler.GetProfile)
 				profileGroup.PUT("", userHandler.UpdateProfile)
 				profileGroup.PUT("/password", userHandler.ChangePassword)
+				profileGroup.POST("/phone/send-code", userHandler.SendPhoneCode)
+				profileGroup.POST("/phone/verify", userHandler.VerifyPhone)
 			}
 
 			// Onboarding Routes
@@ -102,6 +291,7 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 					seller.POST("/business-category", onboardingHandler.SellerBusinessCategory)
 					seller.POST("/business-details", onboardingHandler.SellerBusinessInfo)
 					seller.POST("/store-details", onboardingHandler.StoreDetails)
+					seller.GET("/store-slug/check", onboardingHandler.CheckStoreSlug)
 					seller.POST("/verification", onboardingHandler.SellerVerification)
 				}
 			}
@@ -109,13 +299,77 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 			// Product Routes
 			products := protected.Group("/products")
 			{
-				products.POST("", middleware.RoleMiddleware("vendor", "seller"), productHandler.CreateProduct)
+				products.POST("", middleware.RoleMiddleware("vendor", "seller"), idempotent, productHandler.CreateProduct)
 				products.GET("", productHandler.GetVendorProducts)
 				products.PUT("/:id", productHandler.UpdateProduct)
 				products.GET("/:id", productHandler.GetProductById)
 				products.DELETE("/:id", productHandler.DeleteProduct)
 			}
 
+			// Product Moderation Routes
+			moderationHandler := NewModerationHandler(db)
+			protected.POST("/products/:id/report", moderationHandler.ReportProduct)
+
+			vendorModeration := protected.Group("/vendor/moderation")
+			vendorModeration.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorModeration.GET("", moderationHandler.GetVendorModerationCases)
+				vendorModeration.POST("/:id/appeal", moderationHandler.AppealModerationCase)
+			}
+
+			// Notification Routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.GetNotifications)
+				notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+				notifications.GET("/stream", notificationHandler.StreamNotifications)
+				notifications.GET("/preferences", notificationHandler.GetNotificationPreferences)
+				notifications.PUT("/preferences", notificationHandler.UpdateNotificationPreferences)
+				notifications.PUT("/read-all", notificationHandler.MarkAllNotificationsRead)
+				notifications.PUT("/:id/read", notificationHandler.MarkNotificationRead)
+				notifications.DELETE("/:id", notificationHandler.DeleteNotification)
+			}
+
+			// Report exports. Exportable endpoints queue one when called
+			// with ?export=csv|xlsx
+			exports := protected.Group("/exports")
+			{
+				exports.GET("", exportHandler.ListExports)
+				exports.GET("/:id", exportHandler.GetExport)
+			}
+
+			// Buyer-vendor messaging, about a product or an order
+			messageHandler := NewMessageHandler(db, media)
+			protected.POST("/messages", messageHandler.SendMessage)
+			protected.POST("/messages/attachments", messageHandler.UploadAttachment)
+			protected.GET("/conversations", messageHandler.GetConversations)
+			protected.GET("/conversations/:id/messages", messageHandler.GetMessages)
+			protected.POST("/conversations/:id/typing", messageHandler.SendTyping)
+			protected.POST("/conversations/:id/delivered", messageHandler.MarkConversationDelivered)
+			protected.POST("/conversations/:id/read", messageHandler.MarkConversationRead)
+
+			vendorMessages := protected.Group("/vendor/messages")
+			vendorMessages.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorMessages.GET("/templates", messageHandler.GetCannedResponses)
+				vendorMessages.POST("/templates", messageHandler.CreateCannedResponse)
+				vendorMessages.PUT("/templates/:id", messageHandler.UpdateCannedResponse)
+				vendorMessages.DELETE("/templates/:id", messageHandler.DeleteCannedResponse)
+				vendorMessages.GET("/away", messageHandler.GetAutoReply)
+				vendorMessages.PUT("/away", messageHandler.SetAutoReply)
+			}
+
+			// Content Report Routes
+			reportHandler := NewReportHandler(db)
+			protected.POST("/reports", reportHandler.CreateReport)
+			protected.GET("/reports", reportHandler.GetMyReports)
+			protected.POST("/public/products/:id/report", reportHandler.ReportProduct) // Needs a signed-in reporter, though the page is public
+			protected.POST("/stores/:id/report", reportHandler.ReportStore)
+
+			// Personalized Recommendations
+			recommendationHandler := NewRecommendationHandler(db)
+			protected.GET("/recommendations", recommendationHandler.GetRecommendations)
+
 			// Category Routes
 			categories := protected.Group("/categories")
 			{
@@ -124,6 +378,19 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 				categories.POST("", middleware.RoleMiddleware("admin"), categoryHandler.CreateProductCategory)
 				categories.PUT("/:id", middleware.RoleMiddleware("admin"), categoryHandler.UpdateProductCategory)
 				categories.DELETE("/:id", middleware.RoleMiddleware("admin"), categoryHandler.DeleteProductCategory)
+				categories.PUT("/:id/activate", middleware.RoleMiddleware("admin"), categoryHandler.ActivateCategory)
+				categories.PUT("/:id/deactivate", middleware.RoleMiddleware("admin"), categoryHandler.DeactivateCategory)
+				categories.PUT("/:id/feature", middleware.RoleMiddleware("admin"), categoryHandler.FeatureCategory)
+				categories.PUT("/:id/unfeature", middleware.RoleMiddleware("admin"), categoryHandler.UnfeatureCategory)
+				categories.PUT("/reorder", middleware.RoleMiddleware("admin"), categoryHandler.ReorderCategories)
+				categories.POST("/:id/merge", middleware.RoleMiddleware("admin"), categoryHandler.MergeCategory)
+				categories.PUT("/:id/attributes", middleware.RoleMiddleware("admin"), categoryHandler.SetCategoryAttributes)
+				categories.GET("/:id/commission", middleware.RoleMiddleware("admin"), categoryHandler.GetCategoryCommission)
+				categories.POST("/:id/commission", middleware.RoleMiddleware("admin"), categoryHandler.SetCategoryCommission)
+				categories.POST("/:id/image", middleware.RoleMiddleware("admin"), categoryHandler.UploadCategoryImage)
+				categories.DELETE("/:id/image", middleware.RoleMiddleware("admin"), categoryHandler.RemoveCategoryImage)
+				categories.POST("/:id/icon", middleware.RoleMiddleware("admin"), categoryHandler.UploadCategoryIcon)
+				categories.DELETE("/:id/icon", middleware.RoleMiddleware("admin"), categoryHandler.RemoveCategoryIcon)
 			}
 
 			// Media Routes
@@ -133,20 +400,100 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 			orderHandler := NewOrderHandler(db)
 			orders := protected.Group("/orders")
 			{
-				orders.POST("", orderHandler.PlaceOrder)
+				orders.POST("", idempotent, orderHandler.PlaceOrder)
 				orders.GET("", orderHandler.GetUserOrders)
 				orders.GET("/overview", orderHandler.GetBuyerOverview)
 				orders.GET("/:id", orderHandler.GetOrderById)
+				orders.GET("/:id/tracking", orderHandler.GetOrderTracking)
+				orders.GET("/:id/timeline", orderHandler.GetOrderTimeline)
+				orders.GET("/:id/pickup", orderHandler.GetPickupCodes)
 				orders.PUT("/:id/confirm-receipt", orderHandler.ConfirmReceipt)
 			}
 
+			// Dispute Routes
+			disputeHandler := NewDisputeHandler(db, media)
+			disputes := protected.Group("/disputes")
+			{
+				disputes.POST("", disputeHandler.OpenDispute)
+				disputes.POST("/attachments", disputeHandler.UploadEvidence)
+				disputes.GET("", disputeHandler.GetMyDisputes)
+				disputes.GET("/:id", disputeHandler.GetDispute)
+				disputes.POST("/:id/messages", disputeHandler.AddDisputeMessage)
+				disputes.POST("/:id/escalate", disputeHandler.EscalateDispute)
+				disputes.POST("/:id/withdraw", disputeHandler.WithdrawDispute)
+			}
+
+			vendorDisputes := protected.Group("/vendor/disputes")
+			vendorDisputes.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorDisputes.GET("", disputeHandler.GetVendorDisputes)
+				vendorDisputes.POST("/:id/respond", disputeHandler.RespondToDispute)
+			}
+
+			// Shipping Routes
+			shippingHandler := NewShippingHandler(db, cfg.Shipping)
+			protected.POST("/shipping/quote", shippingHandler.QuoteShipping)
+
+			vendorShipping := protected.Group("/vendor/shipping")
+			vendorShipping.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorShipping.GET("/rates", shippingHandler.GetVendorRates)
+				vendorShipping.PUT("/rates", shippingHandler.SetVendorRates)
+				vendorShipping.DELETE("/rates", shippingHandler.DeleteVendorRates)
+				vendorShipping.GET("/free-shipping", shippingHandler.GetVendorFreeShipping)
+				vendorShipping.PUT("/free-shipping", shippingHandler.SetVendorFreeShipping)
+				vendorShipping.DELETE("/free-shipping", shippingHandler.DeleteVendorFreeShipping)
+			}
+
+			// Coupon Routes
+			couponHandler := NewCouponHandler(db)
+			vendorCoupons := protected.Group("/vendor/coupons")
+			vendorCoupons.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorCoupons.GET("", couponHandler.ListVendorCoupons)
+				vendorCoupons.POST("", couponHandler.CreateVendorCoupon)
+				vendorCoupons.PUT("/:id", couponHandler.UpdateVendorCoupon)
+				vendorCoupons.DELETE("/:id", couponHandler.DeleteVendorCoupon)
+				vendorCoupons.GET("/:id/redemptions", couponHandler.GetVendorCouponRedemptions)
+			}
+
+			// Sale Campaign Routes
+			vendorSales := protected.Group("/vendor/sales")
+			vendorSales.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorSales.GET("", saleCampaignHandler.GetSaleCampaigns)
+				vendorSales.POST("", saleCampaignHandler.CreateSaleCampaign)
+				vendorSales.GET("/:id", saleCampaignHandler.GetSaleCampaign)
+				vendorSales.PUT("/:id", saleCampaignHandler.UpdateSaleCampaign)
+				vendorSales.DELETE("/:id", saleCampaignHandler.CancelSaleCampaign)
+			}
+
+			// Flash Sale Routes
+			vendorFlashSales := protected.Group("/vendor/flash-sales")
+			vendorFlashSales.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorFlashSales.GET("", flashSaleHandler.GetVendorFlashSales)
+				vendorFlashSales.POST("", flashSaleHandler.CreateFlashSale)
+				vendorFlashSales.DELETE("/:id", flashSaleHandler.CancelFlashSale)
+			}
+
+			// Vendor Reports
+			vendorReports := protected.Group("/vendor/reports")
+			vendorReports.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorReports.GET("/inventory", exportHandler.Exportable("vendor.inventory"), orderHandler.GetInventoryReport)
+			}
+
 			// Vendor Order Routes
 			vendorOrders := protected.Group("/vendor/orders")
 			vendorOrders.Use(middleware.RoleMiddleware("vendor", "seller"))
 			{
 				vendorOrders.GET("", orderHandler.GetVendorOrders)
-				vendorOrders.GET("/stats", orderHandler.GetVendorStats)
+				vendorOrders.GET("/stats", exportHandler.Exportable("vendor.stats"), orderHandler.GetVendorStats)
 				vendorOrders.PUT("/:id/status", orderHandler.UpdateVendorOrderStatus)
+				vendorOrders.POST("/:id/label", orderHandler.CreateShippingLabel)
+				vendorOrders.POST("/:id/pickup/ready", orderHandler.MarkPickupReady)
+				vendorOrders.POST("/:id/pickup/collect", orderHandler.CollectPickup)
 				// For now using the same detail handler, but in future might need specific vendor view
 				vendorOrders.GET("/:id", orderHandler.GetOrderById)
 			}
@@ -162,12 +509,13 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 
 			// Review Routes
 			reviewHandler := NewReviewHandler(db)
-			protected.POST("/reviews", reviewHandler.CreateReview)
+			protected.POST("/reviews", limiter.Limit(middleware.RateLimitReview), reviewHandler.CreateReview)
 
 			vendorReviews := protected.Group("/vendor/reviews")
 			vendorReviews.Use(middleware.RoleMiddleware("vendor", "seller"))
 			{
 				vendorReviews.GET("", reviewHandler.GetVendorReviews)
+				vendorReviews.GET("/insights", reviewHandler.GetVendorReviewInsights)
 				vendorReviews.POST("/:id/respond", reviewHandler.RespondToReview)
 			}
 
@@ -191,42 +539,138 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 				tier.GET("/eligibility", tierHandler.GetEligibility)
 				tier.POST("/appeal", tierHandler.SubmitAppeal)
 			}
+			protected.POST("/vendor/upgrade", middleware.RoleMiddleware("vendor", "seller"), tierHandler.RequestUpgrade)
+
+			// Vendor Store Routes
+			vendorStore := protected.Group("/vendor/store")
+			vendorStore.Use(middleware.RoleMiddleware("vendor", "seller"))
+			{
+				vendorStore.GET("", storeHandler.GetMyStore)
+				vendorStore.PUT("", storeHandler.UpdateStoreProfile)
+				vendorStore.POST("/banner", storeHandler.UploadStoreBanner)
+				vendorStore.PUT("/sections", storeHandler.UpdateStoreSections)
+				vendorStore.PUT("/policies", storeHandler.UpdateStorePolicies)
+				vendorStore.PUT("/shipping-methods", storeHandler.UpdateStoreShippingMethods)
+				vendorStore.PUT("/pickup-locations", storeHandler.UpdateStorePickupLocations)
+				vendorStore.PUT("/seo", storeHandler.UpdateStoreSEO)
+				vendorStore.PUT("/slug", storeHandler.UpdateStoreSlug)
+				vendorStore.GET("/announcements", storeHandler.GetStoreAnnouncements)
+				vendorStore.POST("/announcements", storeHandler.CreateStoreAnnouncement)
+				vendorStore.PUT("/announcements/:id", storeHandler.UpdateStoreAnnouncement)
+				vendorStore.DELETE("/announcements/:id", storeHandler.DeleteStoreAnnouncement)
+				vendorStore.GET("/collections", storeHandler.GetMyStoreCollections)
+				vendorStore.POST("/collections", storeHandler.CreateStoreCollection)
+				vendorStore.PUT("/collections/:id", storeHandler.UpdateStoreCollection)
+				vendorStore.DELETE("/collections/:id", storeHandler.DeleteStoreCollection)
+			}
 
 			// Public Vendor Application
 			protected.POST("/vendor/apply", vendorHandler.ApplyForVendor)
 
 			// Admin Routes
 			adminHandler := NewAdminHandler(db)
+			autoDiscountHandler := NewAutoDiscountHandler(db)
+			trashHandler := NewTrashHandler(db, cfg.TrashRetention)
 			admin := protected.Group("/admin")
 			admin.Use(middleware.RoleMiddleware("admin"))
 			{
 				admin.GET("/stats", adminHandler.GetPlatformStats)
+				admin.GET("/dashboard", adminHandler.GetDashboard)
+				admin.GET("/analytics/overview", exportHandler.Exportable("admin.analytics.overview"), adminHandler.GetPlatformAnalytics)
+				admin.GET("/analytics/categories", exportHandler.Exportable("admin.analytics.categories"), adminHandler.GetCategoryAnalytics)
+				admin.GET("/analytics/cohorts", exportHandler.Exportable("admin.analytics.cohorts"), adminHandler.GetCohorts)
+				admin.GET("/analytics/checkout-funnel", exportHandler.Exportable("admin.analytics.checkout_funnel"), adminHandler.GetCheckoutFunnel)
+				admin.POST("/analytics/rebuild", adminHandler.RebuildPlatformAnalytics)
 				admin.GET("/vendors", adminHandler.ListVendors)
 				admin.GET("/vendors/:id", adminHandler.GetVendor)
+				admin.PATCH("/vendors/:id", adminHandler.UpdateVendor)
 				admin.GET("/products", adminHandler.ListProducts)
 				admin.GET("/products/:id", adminHandler.GetProduct)
 				admin.PUT("/products/:id/flag", adminHandler.FlagProduct)
 				admin.PUT("/products/:id/approve", adminHandler.ApproveProduct)
 				admin.GET("/customers", adminHandler.ListCustomers)
-				admin.GET("/orders", adminHandler.ListOrders)
+				admin.GET("/users", adminHandler.ListUsers)
+				admin.GET("/users/:id", adminHandler.GetUserDetail)
+				admin.PUT("/users/:id/status", adminHandler.UpdateUserStatus)
+				admin.PUT("/users/:id/role", adminHandler.UpdateUserRole)
+				admin.POST("/impersonate/:userId", adminHandler.ImpersonateUser)
+				admin.GET("/moderation", adminHandler.ListModerationQueue)
+				admin.GET("/moderation/:id", adminHandler.GetModerationCase)
+				admin.PUT("/moderation/:id/action", adminHandler.ActOnModerationCase)
+				admin.PUT("/moderation/:id/appeal", adminHandler.ResolveModerationAppeal)
+				admin.GET("/reports", adminHandler.ListReports)
+				admin.GET("/reports/:id", adminHandler.GetReport)
+				admin.PUT("/reports/:id", adminHandler.TriageReport)
+				admin.GET("/orders", exportHandler.Exportable("admin.orders"), adminHandler.ListOrders)
 				admin.GET("/orders/:id", adminHandler.GetOrder)
+				admin.POST("/orders/:id/refund", adminHandler.RefundOrder)
+				admin.POST("/orders/:id/cancel", adminHandler.CancelOrder)
+				admin.PUT("/orders/:id/status", adminHandler.CorrectOrderStatus)
 				admin.GET("/tier-requests", adminHandler.ListTierRequests)
 				admin.PUT("/tier-requests/:id/approve", adminHandler.ApproveTierRequest)
 				admin.PUT("/tier-requests/:id/reject", adminHandler.RejectTierRequest)
 				admin.PUT("/vendors/:id/unsuspend", adminHandler.UnsuspendVendor)
 				admin.PUT("/vendors/:id/ban", adminHandler.BanVendor)
+				admin.GET("/audit-logs", adminHandler.ListAuditLogs)
+				admin.GET("/audit-logs/:id", adminHandler.GetAuditLog)
+				admin.GET("/email-logs", adminHandler.ListEmailLogs)
+				admin.POST("/categories/import", categoryHandler.ImportCategories)
+				admin.DELETE("/categories/:id", categoryHandler.DeleteProductCategory)
+				admin.GET("/search/top", adminHandler.GetTopSearches)
+				admin.GET("/search/zero-results", adminHandler.GetZeroResultSearches)
+				admin.GET("/search/synonyms", adminHandler.ListSearchSynonyms)
+				admin.POST("/search/synonyms", adminHandler.CreateSearchSynonym)
+				admin.PUT("/search/synonyms/:id", adminHandler.UpdateSearchSynonym)
+				admin.DELETE("/search/synonyms/:id", adminHandler.DeleteSearchSynonym)
+				admin.GET("/shipping/rates", shippingHandler.GetPlatformRates)
+				admin.PUT("/shipping/rates", shippingHandler.SetPlatformRates)
+				admin.GET("/shipping/free-shipping", shippingHandler.GetPlatformFreeShipping)
+				admin.PUT("/shipping/free-shipping", shippingHandler.SetPlatformFreeShipping)
+				admin.GET("/coupons", couponHandler.ListCoupons)
+				admin.POST("/coupons", couponHandler.CreatePlatformCoupon)
+				admin.PUT("/coupons/:id", couponHandler.UpdateCoupon)
+				admin.DELETE("/coupons/:id", couponHandler.DeleteCoupon)
+				admin.GET("/auto-discounts", autoDiscountHandler.ListAutoDiscounts)
+				admin.POST("/auto-discounts", autoDiscountHandler.CreateAutoDiscount)
+				admin.PUT("/auto-discounts/:id", autoDiscountHandler.UpdateAutoDiscount)
+				admin.DELETE("/auto-discounts/:id", autoDiscountHandler.DeleteAutoDiscount)
+				admin.GET("/auto-discounts/:id/report", autoDiscountHandler.GetAutoDiscountReport)
+				admin.GET("/disputes", disputeHandler.ListDisputes)
+				admin.GET("/disputes/:id", disputeHandler.GetDisputeCase)
+				admin.POST("/disputes/:id/request-info", disputeHandler.RequestDisputeInfo)
+				admin.POST("/disputes/:id/resolve", disputeHandler.ResolveDispute)
+				admin.GET("/abuse", abuseHandler.GetAbuse)
+				admin.POST("/abuse/blocks", abuseHandler.BlockClient)
+				admin.DELETE("/abuse/blocks/:key", abuseHandler.UnblockClient)
+				admin.GET("/webhooks", webhookHandler.ListWebhooks)
+				admin.POST("/webhooks", webhookHandler.CreateWebhook)
+				admin.GET("/webhooks/events", webhookHandler.ListWebhookEvents)
+				admin.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+				admin.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+				admin.POST("/webhooks/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+				admin.GET("/webhooks/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+				admin.POST("/webhooks/:id/deliveries/:deliveryId/replay", webhookHandler.ReplayWebhookDelivery)
+				admin.DELETE("/users/:id", trashHandler.DeleteUser)
+				admin.DELETE("/products/:id", trashHandler.DeleteProduct)
+				admin.GET("/reviews/insights", reviewHandler.GetReviewInsights)
+				admin.DELETE("/reviews/:id", trashHandler.DeleteReview)
+				admin.DELETE("/stores/:id", trashHandler.DeleteStore)
+				admin.GET("/trash/:kind", trashHandler.ListTrash)
+				admin.POST("/trash/:kind/:id/restore", trashHandler.RestoreFromTrash)
 			}
 
 			// Payment Routes
-			paymentHandler := NewPaymentHandler(db)
+			paymentHandler := NewPaymentHandler(db, cfg.Stripe)
 			payments := protected.Group("/payments")
 			{
-				payments.POST("/create-intent", paymentHandler.CreatePaymentIntent)
+				payments.POST("/create-intent", idempotent, paymentHandler.CreatePaymentIntent)
 				payments.POST("/verify/:id", paymentHandler.VerifyPayment)
 			}
 
-			// Public Webhook (Payment handler already initialized above)
+			// Public Webhooks (Payment handler already initialized above)
 			router.POST("/api/v1/payments/webhook", paymentHandler.HandleWebhook)
+			router.POST("/api/v1/sms/twilio/inbound", NewSMSHandler(db, cfg.SMS.Twilio).TwilioInbound)
+			router.POST("/api/v1/shipping/webhooks/:provider", shippingHandler.HandleTrackingWebhook)
 
 			// Public Review Routes
 			v1Group.GET("/products/:id/reviews", reviewHandler.GetProductReviews)
@@ -235,12 +679,15 @@ func SetupRoutes(router *gin.Engine, db *mongo.Database) {
 			// Cart Routes
 			cartHandler := NewCartHandler(db)
 			carts := protected.Group("/cart")
+			cartLimit := limiter.Limit(middleware.RateLimitCart)
 			{
-				carts.POST("", cartHandler.AddToCart)
-				carts.DELETE("/:id", cartHandler.RemoveFromCart)
+				carts.POST("", cartLimit, cartHandler.AddToCart)
+				carts.DELETE("/:id", cartLimit, cartHandler.RemoveFromCart)
 				carts.GET("", cartHandler.GetCart)
-				carts.PUT("/:id", cartHandler.UpdateQuantity)
-				carts.DELETE("", cartHandler.ClearCart)
+				carts.PUT("/:id", cartLimit, cartHandler.UpdateQuantity)
+				carts.DELETE("", cartLimit, cartHandler.ClearCart)
+				carts.POST("/coupon", cartLimit, cartHandler.ApplyCoupon)
+				carts.DELETE("/coupon", cartLimit, cartHandler.RemoveCoupon)
 			}
 		}
 
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// saleCampaignInterval is how often campaigns are started and ended, and so
+// how late past its start or end time a sale can go on or off.
+const saleCampaignInterval = time.Minute
+
+type SaleCampaignHandler struct {
+	Repo           repository.SaleCampaignRepository
+	CollectionRepo repository.CollectionRepository
+	ProductRepo    repository.ProductRepository
+}
+
+func NewSaleCampaignHandler(db *mongo.Database) *SaleCampaignHandler {
+	return &SaleCampaignHandler{
+		Repo:           repository.NewSaleCampaignRepository(db),
+		CollectionRepo: repository.NewCollectionRepository(db),
+		ProductRepo:    repository.NewProductRepository(db),
+	}
+}
+
+// StartSaleCampaigns puts scheduled sales on when they start and takes them
+// off when they end, until ctx is done.
+func StartSaleCampaigns(ctx context.Context, repo repository.SaleCampaignRepository) {
+	run := func() {
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		now := time.Now()
+		// End first, so a campaign that follows straight on from another can
+		// take over its products
+		if n, err := repo.EndDueCampaigns(ctx, now); err != nil {
+			logrus.WithError(err).Error("Failed to end sale campaigns")
+		} else if n > 0 {
+			logrus.WithField("count", n).Info("Ended sale campaigns")
+		}
+		if n, err := repo.StartDueCampaigns(ctx, now); err != nil {
+			logrus.WithError(err).Error("Failed to start sale campaigns")
+		} else if n > 0 {
+			logrus.WithField("count", n).Info("Started sale campaigns")
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(saleCampaignInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+// GetSaleCampaigns lists the vendor's sale campaigns, latest first.
+func (h *SaleCampaignHandler) GetSaleCampaigns(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	campaigns, err := h.Repo.GetVendorCampaigns(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch sale campaigns"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Sale campaigns fetched", gin.H{"campaigns": campaigns}))
+}
+
+// GetSaleCampaign returns one of the vendor's campaigns with the products it
+// covers as things stand.
+func (h *SaleCampaignHandler) GetSaleCampaign(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid campaign ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	campaign, err := h.Repo.GetCampaign(ctx, vendorID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch sale campaign"))
+		return
+	}
+	if campaign == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Sale campaign not found"))
+		return
+	}
+	productIDs, err := h.Repo.CampaignProductIDs(ctx, *campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch campaign products"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Sale campaign fetched", gin.H{"campaign": campaign, "productIds": productIDs}))
+}
+
+// CreateSaleCampaign schedules a sale on some of the vendor's products.
+func (h *SaleCampaignHandler) CreateSaleCampaign(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	campaign, ok := h.bindCampaign(ctx, c, vendorID, primitive.NilObjectID)
+	if !ok {
+		return
+	}
+	created, err := h.Repo.CreateCampaign(ctx, campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create sale campaign"))
+		return
+	}
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Sale campaign scheduled", gin.H{"campaign": created}))
+}
+
+// UpdateSaleCampaign replaces a campaign that hasn't started yet. A running
+// one can only be cancelled.
+func (h *SaleCampaignHandler) UpdateSaleCampaign(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid campaign ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	campaign, ok := h.bindCampaign(ctx, c, vendorID, id)
+	if !ok {
+		return
+	}
+	updated, err := h.Repo.UpdateScheduledCampaign(ctx, vendorID, id, bson.M{
+		"name":          campaign.Name,
+		"type":          campaign.Type,
+		"value":         campaign.Value,
+		"productIds":    campaign.ProductIDs,
+		"collectionIds": campaign.CollectionIDs,
+		"startsAt":      campaign.StartsAt,
+		"endsAt":        campaign.EndsAt,
+	})
+	if err == repository.ErrSaleCampaignNotFound {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Only a campaign that hasn't started can be changed"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update sale campaign"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Sale campaign updated", gin.H{"campaign": updated}))
+}
+
+// CancelSaleCampaign calls off a scheduled campaign, or ends a running one
+// early and puts its products' prices back.
+func (h *SaleCampaignHandler) CancelSaleCampaign(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid campaign ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	campaign, err := h.Repo.CancelCampaign(ctx, vendorID, id)
+	if err == repository.ErrSaleCampaignNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("No scheduled or running campaign with that ID"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to cancel sale campaign"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Sale campaign cancelled", gin.H{"campaign": campaign}))
+}
+
+// bindCampaign reads and checks a campaign body for the vendor. A campaign
+// can only name the vendor's own products and collections, and can't share
+// a product with another of their campaigns whose window overlaps it;
+// existingID is the campaign being replaced, which doesn't count.
+func (h *SaleCampaignHandler) bindCampaign(ctx context.Context, c *gin.Context, vendorID, existingID primitive.ObjectID) (models.SaleCampaign, bool) {
+	var input models.SaleCampaignInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return models.SaleCampaign{}, false
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return models.SaleCampaign{}, false
+	}
+	if input.Type == models.CouponPercentage && input.Value >= 100 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A percentage discount must be under 100"))
+		return models.SaleCampaign{}, false
+	}
+	if !input.EndsAt.After(input.StartsAt) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A campaign must end after it starts"))
+		return models.SaleCampaign{}, false
+	}
+	if !input.EndsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A campaign must end in the future"))
+		return models.SaleCampaign{}, false
+	}
+	if input.EndsAt.Sub(input.StartsAt) > models.MaxSaleDuration {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A campaign can run for at most 90 days"))
+		return models.SaleCampaign{}, false
+	}
+
+	campaign := models.SaleCampaign{
+		VendorID:      vendorID,
+		Name:          input.Name,
+		Type:          input.Type,
+		Value:         input.Value,
+		ProductIDs:    dedupeProductIDs(input.ProductIDs),
+		CollectionIDs: dedupeProductIDs(input.CollectionIDs),
+		StartsAt:      input.StartsAt,
+		EndsAt:        input.EndsAt,
+	}
+	if len(campaign.ProductIDs) == 0 && len(campaign.CollectionIDs) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Pick at least one product or collection"))
+		return models.SaleCampaign{}, false
+	}
+
+	// 1. Everything named is the vendor's
+	if len(campaign.ProductIDs) > 0 {
+		_, total, err := h.ProductRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": campaign.ProductIDs}, "vendorId": vendorID}, 1, 0, nil, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify products"))
+			return models.SaleCampaign{}, false
+		}
+		if int(total) != len(campaign.ProductIDs) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Sales can only include your own products"))
+			return models.SaleCampaign{}, false
+		}
+	}
+	for _, collectionID := range campaign.CollectionIDs {
+		collection, err := h.CollectionRepo.GetCollection(ctx, vendorID, collectionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify collections"))
+			return models.SaleCampaign{}, false
+		}
+		if collection == nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Sales can only include your own collections"))
+			return models.SaleCampaign{}, false
+		}
+	}
+
+	// 2. No product on two overlapping sales
+	open, err := h.Repo.GetOpenCampaigns(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check for conflicting campaigns"))
+		return models.SaleCampaign{}, false
+	}
+	var productIDs map[primitive.ObjectID]bool
+	for _, other := range open {
+		if other.ID == existingID || !campaign.Overlaps(other) {
+			continue
+		}
+		if productIDs == nil {
+			ids, err := h.Repo.CampaignProductIDs(ctx, campaign)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check for conflicting campaigns"))
+				return models.SaleCampaign{}, false
+			}
+			productIDs = make(map[primitive.ObjectID]bool, len(ids))
+			for _, id := range ids {
+				productIDs[id] = true
+			}
+		}
+		otherIDs, err := h.Repo.CampaignProductIDs(ctx, other)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to check for conflicting campaigns"))
+			return models.SaleCampaign{}, false
+		}
+		for _, id := range otherIDs {
+			if productIDs[id] {
+				c.JSON(http.StatusConflict, utils.ErrorResponse("Some of these products are already on the overlapping sale \""+other.Name+"\""))
+				return models.SaleCampaign{}, false
+			}
+		}
+	}
+
+	return campaign, true
+}
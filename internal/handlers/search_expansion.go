@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// searchVocabularySize caps the catalog words kept for typo correction.
+const searchVocabularySize = 20000
+
+var (
+	// synonymCache holds each language's synonym index; admin edits clear it.
+	synonymCache = utils.NewTTLCache[models.SynonymIndex](5 * time.Minute)
+	// vocabularyCache holds the catalog's words, which drift slowly as
+	// products are added.
+	vocabularyCache = utils.NewTTLCache[map[string]bool](10 * time.Minute)
+)
+
+// searchLanguage picks the language a search's synonyms come from: ?lang=,
+// else the first Accept-Language tag, else models.DefaultSearchLanguage.
+func searchLanguage(c *gin.Context) string {
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = strings.SplitN(c.GetHeader("Accept-Language"), ",", 2)[0]
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if len(lang) >= 2 {
+		lang = lang[:2]
+	}
+	if len(lang) != 2 || lang[0] < 'a' || lang[0] > 'z' || lang[1] < 'a' || lang[1] > 'z' {
+		return models.DefaultSearchLanguage
+	}
+	return lang
+}
+
+// expandSearch turns a query into term groups with corrections and synonyms
+// (see models.ExpandSearchQuery). If synonyms or the vocabulary can't be
+// loaded the search goes ahead without them.
+func expandSearch(ctx context.Context, repo repository.SearchRepository, query, language string) [][]string {
+	synonyms, ok := synonymCache.Get(language)
+	if !ok {
+		sets, err := repo.ListSynonyms(ctx, language)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to load search synonyms")
+		} else {
+			synonyms = models.NewSynonymIndex(sets)
+			synonymCache.Set(language, synonyms)
+		}
+	}
+
+	vocabulary, ok := vocabularyCache.Get("")
+	if !ok {
+		words, err := repo.Vocabulary(ctx, searchVocabularySize)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to load search vocabulary")
+		} else {
+			vocabulary = make(map[string]bool, len(words))
+			for _, w := range words {
+				vocabulary[w] = true
+			}
+			vocabularyCache.Set("", vocabulary)
+		}
+	}
+
+	return models.ExpandSearchQuery(query, synonyms, vocabulary)
+}
+
+// searchTermsFilter requires a product's name or brand to contain one
+// spelling from every term group.
+func searchTermsFilter(groups [][]string) bson.A {
+	and := bson.A{}
+	for _, group := range groups {
+		alternatives := make([]string, len(group))
+		for i, term := range group {
+			words := strings.Fields(term)
+			for j, w := range words {
+				words[j] = regexp.QuoteMeta(w)
+			}
+			alternatives[i] = strings.Join(words, `\s+`)
+		}
+		match := bson.M{"$regex": strings.Join(alternatives, "|"), "$options": "i"}
+		and = append(and, bson.M{"$or": bson.A{bson.M{"name": match}, bson.M{"brand": match}}})
+	}
+	return and
+}
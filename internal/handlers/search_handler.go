@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// suggestCache absorbs the burst of identical prefixes typed by many users;
+// popularity barely moves within a minute.
+var suggestCache = utils.NewTTLCache[models.SearchSuggestions](time.Minute)
+
+type SearchHandler struct {
+	Repo repository.SearchRepository
+}
+
+func NewSearchHandler(db *mongo.Database) *SearchHandler {
+	return &SearchHandler{Repo: repository.NewSearchRepository(db)}
+}
+
+// Suggest returns autocomplete suggestions for a partly typed query: products,
+// brands, categories and stores with a word starting with ?q=, most popular
+// first. ?limit= caps each group (default 5, max 10).
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	query := strings.Join(strings.Fields(c.Query("q")), " ")
+	if utf8.RuneCountInString(query) < 2 {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Suggestions fetched", models.SearchSuggestions{
+			Products:   []models.SearchSuggestion{},
+			Brands:     []models.SearchSuggestion{},
+			Categories: []models.SearchSuggestion{},
+			Stores:     []models.SearchSuggestion{},
+		}))
+		return
+	}
+	if utf8.RuneCountInString(query) > 50 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Query is too long"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > 10 {
+		limit = 5
+	}
+
+	key := strings.ToLower(query) + "|" + strconv.Itoa(limit)
+	if cached, ok := suggestCache.Get(key); ok {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Suggestions fetched", cached))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	suggestions, err := h.Repo.Suggest(ctx, query, int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch suggestions"))
+		return
+	}
+	suggestCache.Set(key, suggestions)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Suggestions fetched", suggestions))
+}
+
+// RecordClick logs that a shopper opened a result of a logged search, using
+// the searchId returned with the results.
+func (h *SearchHandler) RecordClick(c *gin.Context) {
+	var input models.SearchClickInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid json payload"))
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	searchID, err := primitive.ObjectIDFromHex(input.SearchID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid search ID"))
+		return
+	}
+	productID, err := primitive.ObjectIDFromHex(input.ProductID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid product ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// Unknown searches and repeat clicks are ignored rather than rejected;
+	// the client has nothing to do about either
+	if _, err := h.Repo.RecordClick(ctx, searchID, models.SearchClick{
+		ProductID: productID,
+		Position:  input.Position,
+		At:        time.Now(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to record click"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Click recorded", gin.H{}))
+}
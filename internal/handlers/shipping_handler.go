@@ -0,0 +1,607 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ShippingHandler struct {
+	Repo         repository.ShippingRepository
+	ProductRepo  repository.ProductRepository
+	StoreRepo    repository.StoreRepository
+	CartRepo     repository.CartRepository
+	OrderRepo    repository.OrderRepository
+	AuditRepo    repository.AuditRepository
+	CheckoutRepo repository.CheckoutEventRepository
+	WebhookToken string // Tracking webhooks must carry it; they are refused when unset
+}
+
+func NewShippingHandler(db *mongo.Database, cfg config.ShippingConfig) *ShippingHandler {
+	return &ShippingHandler{
+		Repo:         repository.NewShippingRepository(db),
+		ProductRepo:  repository.NewProductRepository(db),
+		StoreRepo:    repository.NewStoreRepository(db),
+		CartRepo:     repository.NewCartRepository(db),
+		OrderRepo:    repository.NewOrderRepository(db),
+		AuditRepo:    repository.NewAuditRepository(db),
+		CheckoutRepo: repository.NewCheckoutEventRepository(db),
+		WebhookToken: cfg.WebhookToken,
+	}
+}
+
+// QuoteShipping prices shipping to a region for the given items, or for the
+// buyer's cart when none are given.
+func (h *ShippingHandler) QuoteShipping(c *gin.Context) {
+	var input models.ShippingQuoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.Region == "" && input.Address == nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A region or address is required"))
+		return
+	}
+	// Just the format; the address is looked up when the order is placed
+	if input.Address != nil {
+		if err := input.Address.Normalize(); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid address: "+err.Error()))
+			return
+		}
+		userIdStr, _ := c.Get("userId")
+		userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+		recordCheckoutStep(h.CheckoutRepo, models.CheckoutEvent{UserID: userID, Step: models.CheckoutStepAddressEntered})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	items := make([]models.CartItem, 0, len(input.Items))
+	for _, item := range input.Items {
+		items = append(items, models.CartItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	if len(items) == 0 {
+		userIdStr, _ := c.Get("userId")
+		userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+		cart, err := h.CartRepo.GetCart(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch cart"))
+			return
+		}
+		items = cart.Items
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Nothing to quote"))
+		return
+	}
+
+	quote, err := quoteShipping(ctx, h.Repo, h.ProductRepo, h.StoreRepo, shippingRequest{
+		Items:   items,
+		Region:  input.Region,
+		To:      input.Address,
+		Methods: input.Methods,
+		Pickups: input.Pickups,
+	})
+	var restricted *models.ShippingRestrictionError
+	if errors.As(err, &restricted) {
+		respondRestricted(c, restricted)
+		return
+	}
+	if errors.Is(err, models.ErrNotShippable) || errors.Is(err, models.ErrUnknownShippingMethod) || errors.Is(err, models.ErrUnknownPickupLocation) {
+		c.JSON(http.StatusUnprocessableEntity, domainErrorResponse(err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to quote shipping"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Shipping quoted", gin.H{"quote": quote}))
+}
+
+// GetPlatformRates returns the platform's shipping rates.
+func (h *ShippingHandler) GetPlatformRates(c *gin.Context) {
+	h.getRates(c, primitive.NilObjectID)
+}
+
+// SetPlatformRates replaces the platform's shipping rates.
+func (h *ShippingHandler) SetPlatformRates(c *gin.Context) {
+	table, ok := h.saveRates(c, primitive.NilObjectID)
+	if !ok {
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditShippingRatesSet,
+		TargetType: models.AuditTargetShipping,
+		TargetID:   table.ID,
+		After:      gin.H{"zones": table.Zones},
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Shipping rates updated", gin.H{"rates": table}))
+}
+
+// GetVendorRates returns the authenticated vendor's shipping rates.
+func (h *ShippingHandler) GetVendorRates(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	h.getRates(c, vendorID)
+}
+
+// SetVendorRates replaces the authenticated vendor's shipping rates. Their
+// items then ship separately at these rates rather than the platform's.
+func (h *ShippingHandler) SetVendorRates(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	table, ok := h.saveRates(c, vendorID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Shipping rates updated", gin.H{"rates": table}))
+}
+
+// DeleteVendorRates puts the authenticated vendor back on platform rates.
+func (h *ShippingHandler) DeleteVendorRates(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Repo.DeleteRateTable(ctx, vendorID); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to remove shipping rates"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Shipping rates removed; platform rates apply", nil))
+}
+
+func (h *ShippingHandler) getRates(c *gin.Context, ownerID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	table, err := h.Repo.GetRateTable(ctx, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch shipping rates"))
+		return
+	}
+	isDefault := table == nil
+	if isDefault && ownerID.IsZero() {
+		table = &models.DefaultShippingRates
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Shipping rates fetched", gin.H{"rates": table, "default": isDefault}))
+}
+
+func (h *ShippingHandler) saveRates(c *gin.Context, ownerID primitive.ObjectID) (models.ShippingRateTable, bool) {
+	var input models.ShippingRateTable
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return input, false
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return input, false
+	}
+	for i, zone := range input.Zones {
+		for j, r := range zone.Regions {
+			input.Zones[i].Regions[j] = models.NormalizeRegion(r)
+		}
+	}
+	input.VendorID = ownerID
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	table, err := h.Repo.SaveRateTable(ctx, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save shipping rates"))
+		return input, false
+	}
+	return table, true
+}
+
+// GetPlatformFreeShipping returns the platform's free-shipping rule.
+func (h *ShippingHandler) GetPlatformFreeShipping(c *gin.Context) {
+	h.getFreeShipping(c, primitive.NilObjectID)
+}
+
+// SetPlatformFreeShipping replaces the platform's free-shipping rule. A
+// threshold of 0 turns free shipping off.
+func (h *ShippingHandler) SetPlatformFreeShipping(c *gin.Context) {
+	rule, ok := h.saveFreeShipping(c, primitive.NilObjectID)
+	if !ok {
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditFreeShippingSet,
+		TargetType: models.AuditTargetShipping,
+		TargetID:   rule.ID,
+		After:      gin.H{"threshold": rule.Threshold, "regions": rule.Regions},
+	})
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Free shipping updated", gin.H{"freeShipping": rule}))
+}
+
+// GetVendorFreeShipping returns the authenticated vendor's free-shipping
+// rule, or the platform's when they haven't set one.
+func (h *ShippingHandler) GetVendorFreeShipping(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	h.getFreeShipping(c, vendorID)
+}
+
+// SetVendorFreeShipping replaces the authenticated vendor's free-shipping
+// rule. Their items then ship separately so it can apply; a threshold of 0
+// turns free shipping off for them.
+func (h *ShippingHandler) SetVendorFreeShipping(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	rule, ok := h.saveFreeShipping(c, vendorID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Free shipping updated", gin.H{"freeShipping": rule}))
+}
+
+// DeleteVendorFreeShipping puts the authenticated vendor back on the
+// platform's free-shipping rule.
+func (h *ShippingHandler) DeleteVendorFreeShipping(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Repo.DeleteFreeShippingRule(ctx, vendorID); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to remove free shipping"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Free shipping removed; the platform's applies", nil))
+}
+
+// getFreeShipping responds with the owner's rule, falling back to the
+// platform's and then the default, and says which it is.
+func (h *ShippingHandler) getFreeShipping(c *gin.Context, ownerID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rule, err := h.Repo.GetFreeShippingRule(ctx, ownerID)
+	if err == nil && rule == nil && !ownerID.IsZero() {
+		rule, err = h.Repo.GetFreeShippingRule(ctx, primitive.NilObjectID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch free shipping"))
+		return
+	}
+	if rule == nil {
+		rule = &models.DefaultFreeShipping
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Free shipping fetched", gin.H{
+		"freeShipping": rule,
+		"inherited":    rule.VendorID != ownerID || rule.ID.IsZero(),
+	}))
+}
+
+func (h *ShippingHandler) saveFreeShipping(c *gin.Context, ownerID primitive.ObjectID) (models.FreeShippingRule, bool) {
+	var input models.FreeShippingRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return input, false
+	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return input, false
+	}
+	for i, r := range input.Regions {
+		input.Regions[i] = models.NormalizeRegion(r)
+	}
+	input.VendorID = ownerID
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rule, err := h.Repo.SaveFreeShippingRule(ctx, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save free shipping"))
+		return input, false
+	}
+	return rule, true
+}
+
+// shippingRequest is what a buyer wants shipped, where, and how.
+type shippingRequest struct {
+	Items   []models.CartItem
+	Region  string
+	To      *models.PostalAddress
+	Methods map[string]string // Shipping method code by vendor ID
+	Pickups map[string]string // Pickup location ID by vendor ID, for vendors collected from
+}
+
+// quoteShipping prices shipping cart items to region from the products'
+// current vendors and dimensions. Given the buyer's address, zones set to
+// live rates charge what the carrier quotes where it can.
+func quoteShipping(ctx context.Context, shippingRepo repository.ShippingRepository, productRepo repository.ProductRepository, storeRepo repository.StoreRepository, req shippingRequest) (models.ShippingQuote, error) {
+	region := req.Region
+	if region == "" && req.To != nil {
+		region = req.To.Region()
+	}
+
+	// 1. The products being shipped
+	ids := make([]primitive.ObjectID, 0, len(req.Items))
+	for _, item := range req.Items {
+		ids = append(ids, item.ProductID)
+	}
+	products, _, err := productRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": ids}}, int64(len(ids)), 0, nil, nil)
+	if err != nil {
+		return models.ShippingQuote{}, err
+	}
+	byID := make(map[primitive.ObjectID]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	var shipping []models.ShippingItem
+	var vendorIDs []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	for _, item := range req.Items {
+		p, ok := byID[item.ProductID]
+		if !ok {
+			continue
+		}
+		shipping = append(shipping, models.ShippingItem{
+			ProductID:    p.ID,
+			Name:         p.Name,
+			VendorID:     p.VendorID,
+			Quantity:     item.Quantity,
+			Subtotal:     p.Price.Mul(item.Quantity).Float64(),
+			Dimensions:   p.Dimensions,
+			Restrictions: p.ShippingRestrictions,
+		})
+		if !seen[p.VendorID] {
+			seen[p.VendorID] = true
+			vendorIDs = append(vendorIDs, p.VendorID)
+		}
+	}
+
+	// 2. The rates and methods they ship at
+	rates, stores, err := loadShippingRates(ctx, shippingRepo, storeRepo, vendorIDs)
+	if err != nil {
+		return models.ShippingQuote{}, err
+	}
+
+	selected := make(map[primitive.ObjectID]string, len(req.Methods))
+	for vendorHex, code := range req.Methods {
+		if vendorID, err := primitive.ObjectIDFromHex(vendorHex); err == nil {
+			selected[vendorID] = code
+		}
+	}
+
+	// 3. Whether they can go there at all
+	if restricted := models.RestrictedItems(shipping, region, homeCountries(stores)); len(restricted) > 0 {
+		return models.ShippingQuote{}, &models.ShippingRestrictionError{Region: models.NormalizeRegion(region), Items: restricted}
+	}
+
+	quote, err := models.QuoteShipping(shipping, rates, region, selected)
+	if err != nil {
+		return quote, err
+	}
+	for i := range quote.Shipments {
+		if err := setPickupLocation(&quote.Shipments[i], stores, req.Pickups); err != nil {
+			return quote, err
+		}
+	}
+
+	// 4. Carrier rates, for zones that want them
+	if req.To != nil {
+		applyCarrierRates(&quote, stores, shipping, *req.To)
+	}
+
+	// 5. When it should arrive
+	handling := make(map[primitive.ObjectID]models.ProcessingTime, len(stores))
+	for vendorID, store := range stores {
+		if store != nil && store.Policies != nil {
+			handling[vendorID] = store.Policies.ProcessingTime
+		}
+	}
+	quote.EstimateDelivery(time.Now(), handling)
+	return quote, nil
+}
+
+// loadShippingRates gathers what shipping for the given vendors is priced
+// by, with their stores, which are nil for vendors without one.
+func loadShippingRates(ctx context.Context, shippingRepo repository.ShippingRepository, storeRepo repository.StoreRepository, vendorIDs []primitive.ObjectID) (models.ShippingRates, map[primitive.ObjectID]*models.Store, error) {
+	rates := models.ShippingRates{Methods: map[primitive.ObjectID][]models.ShippingMethod{}}
+	stores := make(map[primitive.ObjectID]*models.Store, len(vendorIDs))
+
+	var err error
+	if rates.Vendors, err = shippingRepo.GetRateTables(ctx, vendorIDs); err != nil {
+		return rates, nil, err
+	}
+	platform, err := shippingRepo.GetRateTable(ctx, primitive.NilObjectID)
+	if err != nil {
+		return rates, nil, err
+	}
+	rates.Platform = models.DefaultShippingRates
+	if platform != nil {
+		rates.Platform = *platform
+	}
+
+	if rates.VendorFreeShipping, err = shippingRepo.GetFreeShippingRules(ctx, vendorIDs); err != nil {
+		return rates, nil, err
+	}
+	free, err := shippingRepo.GetFreeShippingRule(ctx, primitive.NilObjectID)
+	if err != nil {
+		return rates, nil, err
+	}
+	rates.FreeShipping = models.DefaultFreeShipping
+	if free != nil {
+		rates.FreeShipping = *free
+	}
+
+	for _, vendorID := range vendorIDs {
+		store, err := storeRepo.GetByVendorID(ctx, vendorID)
+		if err != nil {
+			return rates, nil, err
+		}
+		stores[vendorID] = store
+		if store != nil && len(store.ShippingMethods) > 0 {
+			rates.Methods[vendorID] = store.ShippingMethods
+		}
+	}
+	return rates, stores, nil
+}
+
+// homeCountries is the country each vendor ships from, where they've said.
+func homeCountries(stores map[primitive.ObjectID]*models.Store) map[primitive.ObjectID]string {
+	home := make(map[primitive.ObjectID]string, len(stores))
+	for vendorID, store := range stores {
+		if store != nil && store.Policies != nil && store.Policies.Shipping.Origin != nil {
+			home[vendorID] = store.Policies.Shipping.Origin.Country
+		}
+	}
+	return home
+}
+
+// respondRestricted answers a request with items that can't be shipped to
+// the buyer, listing which and why.
+func respondRestricted(c *gin.Context, err *models.ShippingRestrictionError) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    utils.CodeShippingRestricted,
+		"items":   err.Items,
+	})
+}
+
+// setPickupLocation records where a shipment by the pickup method will be
+// collected from: the location the buyer chose, or the vendor's only one.
+// It is left unset, to be chosen before checkout, if the vendor has several
+// and the buyer hasn't said.
+func setPickupLocation(shipment *models.Shipment, stores map[primitive.ObjectID]*models.Store, chosen map[string]string) error {
+	if shipment.Method != models.ShippingMethodPickup {
+		return nil
+	}
+	store := stores[shipment.VendorIDs[0]]
+	if store == nil {
+		return models.ErrUnknownPickupLocation
+	}
+	hex, ok := chosen[shipment.VendorIDs[0].Hex()]
+	locationID, err := primitive.ObjectIDFromHex(hex)
+	if ok && err != nil {
+		return models.ErrUnknownPickupLocation
+	}
+	location, found := store.PickupLocation(locationID)
+	if !found && ok {
+		return models.ErrUnknownPickupLocation
+	}
+	if found {
+		shipment.Pickup = &location
+	}
+	return nil
+}
+
+// applyCarrierRates replaces the table price of live-rated shipments with
+// the carriers' cheapest rates from each vendor's origin. A shipment keeps
+// its table price if any vendor in it has no origin set or the carrier
+// can't quote.
+func applyCarrierRates(quote *models.ShippingQuote, stores map[primitive.ObjectID]*models.Store, items []models.ShippingItem, to models.PostalAddress) {
+	provider := utils.CurrentShippingProvider()
+	if provider == nil {
+		return
+	}
+
+	quote.Fee = 0
+	for i := range quote.Shipments {
+		shipment := &quote.Shipments[i]
+		if shipment.LiveRates {
+			if rate, ok := carrierRate(provider, stores, shipment.VendorIDs, items, to); ok {
+				shipment.Fee = math.Round(rate.Amount*100) / 100
+				shipment.Carrier, shipment.Service = rate.Carrier, rate.Service
+				if rate.EstimatedDays > 0 {
+					shipment.MinTransitDays, shipment.MaxTransitDays = rate.EstimatedDays, rate.EstimatedDays
+				}
+			}
+		}
+		quote.Fee += shipment.Fee
+	}
+	quote.Fee = math.Round(quote.Fee*100) / 100
+}
+
+// carrierRate returns the cheapest carrier rate for sending each vendor's
+// items to the buyer, summed. Carrier and Service are only set for a single
+// vendor.
+func carrierRate(provider utils.ShippingProvider, stores map[primitive.ObjectID]*models.Store, vendorIDs []primitive.ObjectID, items []models.ShippingItem, to models.PostalAddress) (utils.CarrierRate, bool) {
+	var total utils.CarrierRate
+	for _, vendorID := range vendorIDs {
+		store := stores[vendorID]
+		if store == nil || store.Policies == nil || store.Policies.Shipping.Origin == nil {
+			return total, false
+		}
+
+		var vendorItems []models.ShippingItem
+		for _, item := range items {
+			if item.VendorID == vendorID {
+				vendorItems = append(vendorItems, item)
+			}
+		}
+		rates, err := provider.Rates(*store.Policies.Shipping.Origin, to, models.ConsolidatedParcel(vendorItems))
+		if err != nil {
+			logrus.WithError(err).WithField("vendorId", vendorID.Hex()).Warn("Carrier rates unavailable; using table rates")
+			return total, false
+		}
+		cheapest, ok := utils.CheapestRate(rates)
+		if !ok {
+			return total, false
+		}
+		total.Amount += cheapest.Amount
+		total.EstimatedDays = max(total.EstimatedDays, cheapest.EstimatedDays)
+		total.Carrier, total.Service = cheapest.Carrier, cheapest.Service
+	}
+	if len(vendorIDs) > 1 {
+		total.Carrier, total.Service = "", ""
+	}
+	return total, true
+}
+
+// checkAddress normalizes an address and, when a geocoder is configured,
+// makes sure it can be found, recording where. It returns
+// models.ErrUndeliverableAddress for one that can't; a geocoder that's down
+// lets the address through rather than stopping the sale.
+func checkAddress(addr *models.PostalAddress) error {
+	if err := addr.Normalize(); err != nil {
+		return err
+	}
+
+	geocoder := utils.CurrentGeocoder()
+	if geocoder == nil {
+		return nil
+	}
+	point, err := geocoder.Geocode(*addr)
+	if err != nil {
+		logrus.WithError(err).Warn("Geocoding failed; accepting address unchecked")
+		return nil
+	}
+	if point == nil {
+		return models.ErrUndeliverableAddress
+	}
+	addr.Geo = point
+	return nil
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateShippingLabel buys a carrier label for the vendor's items in an
+// order and puts its tracking number on the order. Without a carrier or
+// service in the body it uses the one the buyer was quoted, else the
+// cheapest. Asking again returns the label already bought.
+func (h *OrderHandler) CreateShippingLabel(c *gin.Context) {
+	orderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid order ID"))
+		return
+	}
+
+	var input struct {
+		Carrier string `json:"carrier"`
+		Service string `json:"service"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+			return
+		}
+	}
+
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 45*time.Second)
+	defer cancel()
+
+	// 1. The order, and whether it's ready to ship
+	order, err := h.Repo.GetOrderById(ctx, orderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Order not found"))
+		return
+	}
+	var items []models.OrderItem
+	for _, item := range order.Items {
+		if item.VendorID == vendorID {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to update this order"))
+		return
+	}
+	if label, ok := order.VendorLabel(vendorID); ok {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Label already created", gin.H{"label": label}))
+		return
+	}
+	if order.Status != models.StatusPaid && order.Status != models.StatusConfirmed {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("Labels can only be created for paid orders that haven't shipped"))
+		return
+	}
+	if order.VendorPickup(vendorID) >= 0 {
+		c.JSON(http.StatusConflict, utils.ErrorResponse("The buyer is collecting this order; no label is needed"))
+		return
+	}
+	if order.ShipTo == nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("This order has no structured shipping address; enter tracking manually"))
+		return
+	}
+
+	provider := utils.CurrentShippingProvider()
+	if provider == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("Label printing is not available"))
+		return
+	}
+	store, err := h.StoreRepo.GetByVendorID(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+		return
+	}
+	if store == nil || store.Policies == nil || store.Policies.Shipping.Origin == nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Set your ship-from address in your store's shipping policy first"))
+		return
+	}
+
+	// 2. The parcel
+	parcel, err := h.vendorParcel(ctx, items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch products"))
+		return
+	}
+
+	// 3. Pick a rate and buy it
+	rates, err := provider.Rates(*store.Policies.Shipping.Origin, *order.ShipTo, parcel)
+	if err != nil {
+		logrus.WithError(err).WithField("orderId", orderID.Hex()).Warn("Carrier rates unavailable for label")
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to get rates from the carrier"))
+		return
+	}
+	chosen := input.Carrier != "" || input.Service != ""
+	if !chosen {
+		for _, s := range order.Shipments {
+			if len(s.VendorIDs) == 1 && s.VendorIDs[0] == vendorID {
+				input.Carrier, input.Service = s.Carrier, s.Service
+			}
+		}
+	}
+	rate, ok := pickCarrierRate(rates, input.Carrier, input.Service)
+	if !ok && !chosen {
+		rate, ok = utils.CheapestRate(rates)
+	}
+	if !ok {
+		c.JSON(http.StatusUnprocessableEntity, utils.ErrorResponse("No matching carrier rate for this parcel"))
+		return
+	}
+
+	shipment, err := provider.Purchase(rate)
+	if err != nil {
+		logrus.WithError(err).WithField("orderId", orderID.Hex()).Error("Failed to buy shipping label")
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("The carrier couldn't create the label"))
+		return
+	}
+
+	label := models.ShippingLabel{
+		VendorID:       vendorID,
+		Carrier:        shipment.Carrier,
+		Service:        rate.Service,
+		TrackingNumber: shipment.TrackingNumber,
+		TrackingURL:    shipment.TrackingURL,
+		LabelURL:       shipment.LabelURL,
+		Cost:           rate.Amount,
+		Currency:       rate.Currency,
+		CreatedAt:      time.Now(),
+	}
+	if err := h.Repo.AddShippingLabel(ctx, orderID, label); err != nil {
+		// The label is paid for; don't lose it
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"orderId":  orderID.Hex(),
+			"tracking": label.TrackingNumber,
+			"labelUrl": label.LabelURL,
+		}).Error("Bought a shipping label but failed to save it")
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Label created but not saved; contact support with tracking "+label.TrackingNumber))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Label created", gin.H{"label": label}))
+}
+
+// vendorParcel packs a vendor's order items into one parcel, sized from
+// their products' dimensions.
+func (h *OrderHandler) vendorParcel(ctx context.Context, items []models.OrderItem) (models.Dimensions, error) {
+	ids := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	products, _, err := h.ProductRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": ids}}, int64(len(ids)), 0, nil, nil)
+	if err != nil {
+		return models.Dimensions{}, err
+	}
+	dims := make(map[primitive.ObjectID]models.Dimensions, len(products))
+	for _, p := range products {
+		dims[p.ID] = p.Dimensions
+	}
+
+	shipping := make([]models.ShippingItem, 0, len(items))
+	for _, item := range items {
+		shipping = append(shipping, models.ShippingItem{
+			ProductID:  item.ProductID,
+			VendorID:   item.VendorID,
+			Quantity:   item.Quantity,
+			Subtotal:   item.Subtotal.Float64(),
+			Dimensions: dims[item.ProductID],
+		})
+	}
+	return models.ConsolidatedParcel(shipping), nil
+}
+
+// pickCarrierRate returns the cheapest rate from the given carrier and
+// service, either of which may be empty to allow any.
+func pickCarrierRate(rates []utils.CarrierRate, carrier, service string) (utils.CarrierRate, bool) {
+	var matching []utils.CarrierRate
+	for _, r := range rates {
+		if (carrier == "" || strings.EqualFold(r.Carrier, carrier)) && (service == "" || strings.EqualFold(r.Service, service)) {
+			matching = append(matching, r)
+		}
+	}
+	return utils.CheapestRate(matching)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// HandleTrackingWebhook takes tracking updates pushed by the shipping
+// provider, records them on the order's timeline and moves the order along
+// as its parcels ship and arrive. The provider is configured to post to
+// /shipping/webhooks/<provider>?token=<SHIPPING_WEBHOOK_TOKEN>.
+func (h *ShippingHandler) HandleTrackingWebhook(c *gin.Context) {
+	// 1. Check it came from the provider
+	secret := h.WebhookToken
+	if secret == "" {
+		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("Tracking webhooks are not configured"))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid token"))
+		return
+	}
+
+	const maxBodyBytes = int64(1 << 20)
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Error reading request body"))
+		return
+	}
+	info, ok, err := utils.ParseTrackingWebhook(c.Param("provider"), payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid webhook payload"))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 2. Find the order and record the update
+	order, err := h.OrderRepo.GetOrderByTracking(ctx, info.TrackingNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch order"))
+		return
+	}
+	if order == nil {
+		// Not one of ours, or bought outside the platform
+		c.JSON(http.StatusOK, gin.H{"success": true})
+		return
+	}
+	if _, err := applyTracking(ctx, h.OrderRepo, *order, info); err != nil {
+		logrus.WithError(err).WithField("orderId", order.ID.Hex()).Error("Failed to record tracking update")
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to record tracking update"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// applyTracking records a parcel's latest tracking on its order and, once
+// every parcel has got that far, moves the order to shipped, out for
+// delivery or delivered. It returns the updated order.
+func applyTracking(ctx context.Context, orderRepo repository.OrderRepository, order models.Order, info utils.TrackingInfo) (models.Order, error) {
+	// 1. The parcel's status and history
+	if order.TrackingNumber == info.TrackingNumber {
+		order.TrackingStatus = info.Status
+	}
+	for i := range order.Labels {
+		if order.Labels[i].TrackingNumber == info.TrackingNumber {
+			order.Labels[i].TrackingStatus = info.Status
+		}
+	}
+	history := make([]models.OrderTimelineEvent, 0, len(info.Events))
+	for _, e := range info.Events {
+		history = append(history, models.OrderTimelineEvent{
+			TrackingNumber: info.TrackingNumber,
+			Status:         e.Status,
+			Details:        e.Details,
+			Location:       e.Location,
+			At:             e.At,
+		})
+	}
+	order.Timeline = models.MergeTimeline(order.Timeline, info.TrackingNumber, history)
+	if err := orderRepo.SaveTracking(ctx, order); err != nil {
+		return order, err
+	}
+
+	// 2. The order's status
+	status, ok := order.TrackedStatus()
+	if !ok {
+		return order, nil
+	}
+	actor := models.OrderStatusActor{Role: models.OrderActorCarrier, Metadata: map[string]string{"trackingNumber": info.TrackingNumber}}
+	if err := orderRepo.UpdateOrderStatus(ctx, order.ID, status, "", actor, orderStatusEvent(order, status, "carrier")); err != nil {
+		return order, err
+	}
+	order.Status = status
+	return order, nil
+}
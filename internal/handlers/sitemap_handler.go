@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultFrontendURL = "https://vendora-f.vercel.app"
+
+// feedRefreshInterval is how often the sitemap and shopping feeds are
+// rebuilt ahead of crawlers asking for them.
+const feedRefreshInterval = time.Hour
+
+// sitemapCache holds the rendered sitemap; regenerating it walks every active
+// product and store. Entries outlive a refresh so crawlers are never the
+// ones to wait for a rebuild.
+var sitemapCache = utils.NewTTLCache[[]byte](2 * feedRefreshInterval)
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type SitemapHandler struct {
+	DB *mongo.Database
+}
+
+func NewSitemapHandler(db *mongo.Database) *SitemapHandler {
+	return &SitemapHandler{DB: db}
+}
+
+func frontendURL() string {
+	if base := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"); base != "" {
+		return base
+	}
+	return defaultFrontendURL
+}
+
+// StartFeedRefresh rebuilds the sitemap and the Merchant Center feed now and
+// then every feedRefreshInterval until ctx is done.
+func StartFeedRefresh(ctx context.Context, sitemap *SitemapHandler, feed *MerchantFeedHandler) {
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		if _, err := sitemap.Regenerate(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to regenerate sitemap")
+		}
+		if _, err := feed.Regenerate(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to regenerate merchant feed")
+		}
+	}
+
+	go func() {
+		refresh()
+		ticker := time.NewTicker(feedRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// GetSitemap serves sitemap.xml covering active product pages and storefronts.
+func (h *SitemapHandler) GetSitemap(c *gin.Context) {
+	body, ok := sitemapCache.Get("sitemap")
+	if !ok {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+		var err error
+		if body, err = h.Regenerate(ctx); err != nil {
+			logrus.WithError(err).Error("Failed to generate sitemap")
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate sitemap"))
+			return
+		}
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// Regenerate builds the sitemap from the current catalog and caches it.
+func (h *SitemapHandler) Regenerate(ctx context.Context) ([]byte, error) {
+	base := frontendURL()
+	set := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  []sitemapURL{{Loc: base + "/", ChangeFreq: "daily", Priority: "1.0"}},
+	}
+
+	// 1. Product pages
+	var products []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		UpdatedAt time.Time          `bson:"updatedAt"`
+	}
+	cursor, err := h.DB.Collection("products").Find(ctx,
+		repository.NotDeleted(bson.M{"status": models.ProductStatusActive}),
+		options.Find().SetProjection(bson.M{"_id": 1, "updatedAt": 1}),
+	)
+	if err == nil {
+		err = cursor.All(ctx, &products)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load products: %w", err)
+	}
+	for _, p := range products {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:        base + "/products/" + p.ID.Hex(),
+			LastMod:    sitemapDate(p.UpdatedAt),
+			ChangeFreq: "weekly",
+			Priority:   "0.8",
+		})
+	}
+
+	// 2. Storefronts
+	var stores []struct {
+		Slug      string    `bson:"slug"`
+		UpdatedAt time.Time `bson:"updatedAt"`
+	}
+	cursor, err = h.DB.Collection("stores").Find(ctx,
+		repository.NotDeleted(bson.M{"slug": bson.M{"$ne": ""}}),
+		options.Find().SetProjection(bson.M{"slug": 1, "updatedAt": 1}),
+	)
+	if err == nil {
+		err = cursor.All(ctx, &stores)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load stores: %w", err)
+	}
+	for _, s := range stores {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:        base + "/stores/" + url.PathEscape(s.Slug),
+			LastMod:    sitemapDate(s.UpdatedAt),
+			ChangeFreq: "weekly",
+			Priority:   "0.7",
+		})
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	body := append([]byte(xml.Header), out...)
+	sitemapCache.Set("sitemap", body)
+	return body, nil
+}
+
+func sitemapDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02")
+}
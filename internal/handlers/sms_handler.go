@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SMSHandler struct {
+	UserRepo repository.UserRepository
+	Twilio   config.TwilioConfig
+}
+
+func NewSMSHandler(db *mongo.Database, twilio config.TwilioConfig) *SMSHandler {
+	return &SMSHandler{UserRepo: repository.NewUserRepository(db), Twilio: twilio}
+}
+
+// smsStopWords and smsStartWords are the carrier-standard opt-out and opt-in
+// keywords.
+var (
+	smsStopWords  = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true}
+	smsStartWords = map[string]bool{"START": true, "UNSTOP": true, "YES": true}
+)
+
+// TwilioInbound receives replies to our SMS and applies STOP/START to the
+// sender's notification preferences. Twilio also enforces STOP itself; this
+// keeps our records in line so we don't keep trying.
+func (h *SMSHandler) TwilioInbound(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	token := h.Twilio.AuthToken
+	fullURL := h.Twilio.WebhookBaseURL + c.Request.URL.RequestURI()
+	if token == "" || !utils.ValidTwilioSignature(token, fullURL, c.Request.PostForm, c.GetHeader("X-Twilio-Signature")) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	phone, err := utils.NormalizePhone(c.Request.PostForm.Get("From"))
+	if err != nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	keyword := strings.ToUpper(strings.TrimSpace(c.Request.PostForm.Get("Body")))
+
+	var optOut bool
+	switch {
+	case smsStopWords[keyword]:
+		optOut = true
+	case smsStartWords[keyword]:
+		optOut = false
+	default:
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	updated, err := h.UserRepo.SetSMSOptOutByPhone(ctx, phone, optOut)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to apply SMS opt-out")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	logrus.WithFields(logrus.Fields{"optOut": optOut, "accounts": updated}).Info("Applied SMS keyword reply")
+
+	// An empty TwiML response; Twilio sends its own confirmation
+	c.Data(http.StatusOK, "text/xml", []byte("<Response></Response>"))
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func storeCollectionSummary(col models.StoreCollection) gin.H {
+	return gin.H{
+		"id":           col.ID,
+		"name":         col.Name,
+		"slug":         col.Slug,
+		"description":  col.Description,
+		"productCount": len(col.ProductIDs),
+	}
+}
+
+// dedupeProductIDs drops repeated IDs while keeping the first occurrence's
+// position.
+func dedupeProductIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	out := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if id.IsZero() || seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
+
+// validateCollectionProducts checks that every product belongs to the vendor.
+func (h *StoreHandler) validateCollectionProducts(ctx context.Context, vendorID primitive.ObjectID, ids []primitive.ObjectID) (bool, error) {
+	if len(ids) == 0 {
+		return true, nil
+	}
+	_, total, err := h.ProductRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": ids}, "vendorId": vendorID}, 1, 0, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	return int(total) == len(ids), nil
+}
+
+// GetPublicStoreCollections lists a store's visible collections.
+func (h *StoreHandler) GetPublicStoreCollections(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, ok := h.resolvePublicStore(ctx, c, c.Param("slug"))
+	if !ok {
+		return
+	}
+
+	collections, err := h.CollectionRepo.GetVendorCollections(ctx, store.VendorID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch collections"))
+		return
+	}
+	views := make([]gin.H, 0, len(collections))
+	for _, col := range collections {
+		views = append(views, storeCollectionSummary(col))
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Collections fetched successfully", gin.H{"collections": views}))
+}
+
+// GetPublicStoreCollection returns one visible collection with its active
+// products in the vendor's order.
+func (h *StoreHandler) GetPublicStoreCollection(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, ok := h.resolvePublicStore(ctx, c, c.Param("slug"))
+	if !ok {
+		return
+	}
+
+	col, err := h.CollectionRepo.GetCollectionBySlug(ctx, store.VendorID, c.Param("collectionSlug"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch collection"))
+		return
+	}
+	if col == nil || !col.Visible {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Collection not found"))
+		return
+	}
+
+	products, err := h.orderedStoreProducts(ctx, store.VendorID, col.ProductIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch collection products"))
+		return
+	}
+
+	view := storeCollectionSummary(*col)
+	view["productCount"] = len(products)
+	c.JSON(http.StatusOK, utils.SuccessResponse("Collection fetched successfully", gin.H{
+		"store": gin.H{
+			"id":   store.ID,
+			"slug": store.Slug,
+			"name": store.Name,
+		},
+		"collection": view,
+		"products":   products,
+	}))
+}
+
+// GetMyStoreCollections lists all of the vendor's collections, hidden ones
+// included.
+func (h *StoreHandler) GetMyStoreCollections(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	collections, err := h.CollectionRepo.GetVendorCollections(ctx, vendorID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch collections"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Collections fetched successfully", gin.H{"collections": collections}))
+}
+
+// CreateStoreCollection creates a named collection of the vendor's products.
+func (h *StoreHandler) CreateStoreCollection(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.StoreCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.Name == nil || strings.TrimSpace(*input.Name) == "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Name is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	col := models.StoreCollection{
+		VendorID: vendorID,
+		Name:     strings.TrimSpace(*input.Name),
+		Visible:  true,
+	}
+	if input.Description != nil {
+		col.Description = *input.Description
+	}
+	if input.Position != nil {
+		col.Position = *input.Position
+	}
+	if input.Visible != nil {
+		col.Visible = *input.Visible
+	}
+	if input.ProductIDs != nil {
+		col.ProductIDs = dedupeProductIDs(*input.ProductIDs)
+		ok, err := h.validateCollectionProducts(ctx, vendorID, col.ProductIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify products"))
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Collections can only contain your own products"))
+			return
+		}
+	}
+
+	slug, err := h.CollectionRepo.GenerateUniqueSlug(ctx, vendorID, col.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate collection slug"))
+		return
+	}
+	col.Slug = slug
+
+	created, err := h.CollectionRepo.CreateCollection(ctx, col)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create collection"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Collection created", gin.H{"collection": created}))
+}
+
+// UpdateStoreCollection edits a collection. The slug is fixed at creation so
+// shared links keep working when the collection is renamed.
+func (h *StoreHandler) UpdateStoreCollection(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid collection ID"))
+		return
+	}
+
+	var input models.StoreCollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	fields := bson.M{}
+	if input.Name != nil {
+		fields["name"] = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		fields["description"] = *input.Description
+	}
+	if input.Position != nil {
+		fields["position"] = *input.Position
+	}
+	if input.Visible != nil {
+		fields["visible"] = *input.Visible
+	}
+	if input.ProductIDs != nil {
+		ids := dedupeProductIDs(*input.ProductIDs)
+		ok, err := h.validateCollectionProducts(ctx, vendorID, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify products"))
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Collections can only contain your own products"))
+			return
+		}
+		fields["productIDs"] = ids
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No fields to update"))
+		return
+	}
+
+	updated, err := h.CollectionRepo.UpdateCollection(ctx, vendorID, id, fields)
+	if err == repository.ErrCollectionNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Collection not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update collection"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Collection updated", gin.H{"collection": updated}))
+}
+
+// DeleteStoreCollection removes a collection. Its products are unaffected.
+func (h *StoreHandler) DeleteStoreCollection(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid collection ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err = h.CollectionRepo.DeleteCollection(ctx, vendorID, id)
+	if err == repository.ErrCollectionNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Collection not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete collection"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Collection deleted", nil))
+}
@@ -0,0 +1,967 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var storeValidator = utils.NewValidator()
+
+type StoreHandler struct {
+	Repo             repository.StoreRepository
+	ProductRepo      repository.ProductRepository
+	ReviewRepo       repository.ReviewRepository
+	AnnouncementRepo repository.AnnouncementRepository
+	CollectionRepo   repository.CollectionRepository
+	ReportRepo       repository.ReportRepository
+	Filter           *services.ContentFilter
+	Media            *services.MediaService
+}
+
+func NewStoreHandler(db *mongo.Database, media *services.MediaService) *StoreHandler {
+	return &StoreHandler{
+		Repo:             repository.NewStoreRepository(db),
+		ProductRepo:      repository.NewProductRepository(db),
+		ReviewRepo:       repository.NewReviewRepository(db),
+		AnnouncementRepo: repository.NewAnnouncementRepository(db),
+		CollectionRepo:   repository.NewCollectionRepository(db),
+		ReportRepo:       repository.NewReportRepository(db),
+		Filter:           services.NewContentFilter(),
+		Media:            media,
+	}
+}
+
+// GetPublicStore returns a vendor's storefront: branding, policies, rating
+// and a page of their active products.
+func (h *StoreHandler) GetPublicStore(c *gin.Context) {
+	slug := c.Param("slug")
+
+	page, limit := pageParams(c, 12, 100)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. Resolve the store, redirecting slugs the vendor has moved away from
+	store, ok := h.resolvePublicStore(ctx, c, slug)
+	if !ok {
+		return
+	}
+
+	// 2. Vendor rating across all their reviews
+	rating, reviewCount, err := h.ReviewRepo.GetVendorAverageRating(ctx, store.VendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store rating"))
+		return
+	}
+
+	// 3. Active products, newest first
+	filter := bson.M{"vendorId": store.VendorID, "status": models.ProductStatusActive}
+	products, total, err := h.ProductRepo.GetVendorProducts(ctx, filter, int64(limit), int64((page-1)*limit), nil, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store products"))
+		return
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+
+	// 4. Homepage layout
+	layout, err := h.buildStoreLayout(ctx, store)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build store layout"))
+		return
+	}
+
+	// 5. Announcements currently in their scheduled window
+	announcements, err := h.AnnouncementRepo.GetLiveAnnouncements(ctx, []primitive.ObjectID{store.VendorID}, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store announcements"))
+		return
+	}
+
+	// 6. Curated collections, without their products
+	collections, err := h.CollectionRepo.GetVendorCollections(ctx, store.VendorID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store collections"))
+		return
+	}
+	collectionViews := make([]gin.H, 0, len(collections))
+	for _, col := range collections {
+		collectionViews = append(collectionViews, storeCollectionSummary(col))
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store fetched successfully", gin.H{
+		"store": gin.H{
+			"id":           store.ID,
+			"vendorId":     store.VendorID,
+			"slug":         store.Slug,
+			"name":         store.Name,
+			"description":  store.Description,
+			"logo":         store.Logo,
+			"banner":       store.Banner,
+			"primaryColor": store.PrimaryColor,
+			"accentColor":  store.AccentColor,
+			"location":     store.Location,
+			"about":        store.About,
+			"socialLinks":  store.SocialLinks,
+			"createdAt":    store.CreatedAt,
+		},
+		"layout":        layout,
+		"announcements": announcements,
+		"collections":   collectionViews,
+		"rating": gin.H{
+			"average": rating,
+			"count":   reviewCount,
+		},
+		"policies": storePoliciesView(store),
+		"seo":      storeSEOView(store),
+		"products": products,
+		"meta":     models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// resolvePublicStore looks a store up by slug for public routes. Slugs the
+// vendor has moved away from are answered with a permanent redirect to the
+// same path under the new slug. When ok is false a response has been written.
+func (h *StoreHandler) resolvePublicStore(ctx context.Context, c *gin.Context, slug string) (*models.Store, bool) {
+	store, err := h.Repo.GetBySlug(ctx, slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+		return nil, false
+	}
+	if store != nil {
+		return store, true
+	}
+
+	moved, err := h.Repo.GetByPreviousSlug(ctx, slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+		return nil, false
+	}
+	if moved == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return nil, false
+	}
+	target := strings.Replace(c.Request.URL.Path, "/stores/"+slug, "/stores/"+moved.Slug, 1)
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, target)
+	return nil, false
+}
+
+// UpdateStoreSlug changes the vendor's store slug. This is allowed once; the
+// old slug keeps redirecting to the store.
+func (h *StoreHandler) UpdateStoreSlug(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Slug string `json:"slug" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Slug is required"))
+		return
+	}
+
+	slug := utils.GenerateSlug(input.Slug)
+	if slug != input.Slug || len(slug) < 3 || len(slug) > 60 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Slug must be 3-60 lowercase letters, numbers or hyphens"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	current, err := h.Repo.EnsureStore(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+		return
+	}
+	if current == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if current.Slug == slug {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("This is already your store slug"))
+		return
+	}
+
+	store, err := h.Repo.ChangeSlug(ctx, vendorID, slug)
+	switch err {
+	case nil:
+	case repository.ErrSlugTaken:
+		c.JSON(http.StatusConflict, utils.ErrorResponse("That slug is already taken"))
+		return
+	case repository.ErrSlugChangeUsed:
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Your store slug can only be changed once"))
+		return
+	case repository.ErrStoreNotFound:
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store slug"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store slug updated", gin.H{
+		"slug":          store.Slug,
+		"previousSlugs": store.PreviousSlugs,
+	}))
+}
+
+// storePoliciesView merges the structured policies with the free-text ones
+// captured at onboarding, which older stores may still rely on.
+func storePoliciesView(store *models.Store) gin.H {
+	view := gin.H{
+		"shipsFrom":      store.ShipsFrom,
+		"shippingPolicy": store.ShippingPolicy,
+		"returnPolicy":   store.ReturnPolicy,
+	}
+	if store.Policies != nil {
+		view["return"] = store.Policies.Return
+		view["shipping"] = store.Policies.Shipping
+		view["processingTime"] = store.Policies.ProcessingTime
+		view["updatedAt"] = store.Policies.UpdatedAt
+	}
+	return view
+}
+
+// storeSEOView fills in any SEO fields the vendor left blank from the store's
+// branding so every storefront has usable metadata.
+func storeSEOView(store *models.Store) models.SEO {
+	seo := models.SEO{Slug: store.Slug}
+	if store.SEO != nil {
+		seo.Title = store.SEO.Title
+		seo.Description = store.SEO.Description
+		seo.Keywords = store.SEO.Keywords
+	}
+	if seo.Title == "" {
+		seo.Title = store.Name
+	}
+	if seo.Description == "" {
+		seo.Description = store.Description
+	}
+	if seo.Keywords == nil {
+		seo.Keywords = []string{}
+	}
+	return seo
+}
+
+// buildStoreLayout resolves the store's homepage sections, in order, into
+// renderable blocks with their products filled in.
+func (h *StoreHandler) buildStoreLayout(ctx context.Context, store *models.Store) ([]gin.H, error) {
+	sections := store.Sections
+	if len(sections) == 0 {
+		sections = models.DefaultStoreSections
+	}
+	sections = append([]models.StoreSection{}, sections...)
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Position < sections[j].Position })
+
+	layout := []gin.H{}
+	for _, section := range sections {
+		if !section.Enabled {
+			continue
+		}
+
+		block := gin.H{"type": section.Type, "title": section.Title}
+		switch section.Type {
+		case models.SectionAbout:
+			if store.About == "" {
+				continue
+			}
+			block["content"] = store.About
+
+		case models.SectionNewArrivals:
+			limit := section.Limit
+			if limit <= 0 {
+				limit = 8
+			}
+			filter := bson.M{"vendorId": store.VendorID, "status": models.ProductStatusActive}
+			products, _, err := h.ProductRepo.GetVendorProducts(ctx, filter, int64(limit), 0, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if len(products) == 0 {
+				continue
+			}
+			block["products"] = products
+
+		case models.SectionFeaturedProducts:
+			if len(section.ProductIDs) == 0 {
+				continue
+			}
+			products, err := h.orderedStoreProducts(ctx, store.VendorID, section.ProductIDs)
+			if err != nil {
+				return nil, err
+			}
+			if len(products) == 0 {
+				continue
+			}
+			block["products"] = products
+
+		default:
+			continue
+		}
+		layout = append(layout, block)
+	}
+	return layout, nil
+}
+
+// orderedStoreProducts loads the vendor's active products with the given IDs,
+// keeping the vendor's chosen order and dropping any that are unavailable.
+func (h *StoreHandler) orderedStoreProducts(ctx context.Context, vendorID primitive.ObjectID, ids []primitive.ObjectID) ([]models.Product, error) {
+	products := []models.Product{}
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	filter := bson.M{
+		"_id":      bson.M{"$in": ids},
+		"vendorId": vendorID,
+		"status":   models.ProductStatusActive,
+	}
+	found, _, err := h.ProductRepo.GetVendorProducts(ctx, filter, int64(len(ids)), 0, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]models.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// GetMyStore returns the authenticated vendor's store settings.
+func (h *StoreHandler) GetMyStore(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, err := h.Repo.EnsureStore(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+		return
+	}
+	if store == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+
+	if len(store.Sections) == 0 {
+		store.Sections = models.DefaultStoreSections
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store fetched successfully", gin.H{"store": store}))
+}
+
+// UpdateStoreProfile updates the storefront's branding, about section and
+// social links. Omitted fields are left unchanged.
+func (h *StoreHandler) UpdateStoreProfile(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Name         *string                   `json:"name" validate:"omitempty,min=2,max=50"`
+		Description  *string                   `json:"description" validate:"omitempty,max=500"`
+		About        *string                   `json:"about" validate:"omitempty,max=5000"`
+		PrimaryColor *string                   `json:"primaryColor" validate:"omitempty,color"`
+		AccentColor  *string                   `json:"accentColor" validate:"omitempty,color"`
+		SocialLinks  *[]models.SocialMediaLink `json:"socialLinks" validate:"omitempty,max=10,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	filtered, err := h.Filter.FilterText(services.FilterStoreText, input.Name, input.Description, input.About)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(filterRejection(filtered)))
+		return
+	}
+
+	fields := bson.M{}
+	if input.Name != nil {
+		fields["name"] = *input.Name
+	}
+	if input.Description != nil {
+		fields["description"] = *input.Description
+	}
+	if input.About != nil {
+		fields["about"] = *input.About
+	}
+	if input.PrimaryColor != nil {
+		fields["primaryColor"] = *input.PrimaryColor
+	}
+	if input.AccentColor != nil {
+		fields["accentColor"] = *input.AccentColor
+	}
+	if input.SocialLinks != nil {
+		for _, link := range *input.SocialLinks {
+			if link.Platform == "" || (link.URL == "" && link.Handle == "") {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse("Each social link needs a platform and a URL or handle"))
+				return
+			}
+		}
+		fields["socialLinks"] = *input.SocialLinks
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No fields to update"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, err := h.Repo.UpdateStore(ctx, vendorID, fields)
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store"))
+		return
+	}
+	go flagFilteredContent(h.ReportRepo, "store", store.ID, filtered)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store updated", gin.H{"store": store}))
+}
+
+// UploadStoreBanner replaces the storefront banner image.
+func (h *StoreHandler) UploadStoreBanner(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	m := services.MediaStoreBanner
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, m.MaxSize+1<<20)
+
+	file, header, err := c.Request.FormFile("banner")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No file provided or file too large ("+m.Describe()+")"))
+		return
+	}
+	defer file.Close()
+
+	// Each store has one banner file, which a new upload replaces
+	banner, err := h.Media.Upload(c.Request.Context(), m, vendorID, file, header)
+	if !uploadSucceeded(c, err, m, "Unsupported banner; upload ") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, err := h.Repo.UpdateStore(ctx, vendorID, bson.M{"banner": banner.URL})
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store banner updated", gin.H{"banner": store.Banner}))
+}
+
+// UpdateStoreSections replaces the storefront homepage layout. Sections are
+// stored in the order given; featured products must be the vendor's own.
+func (h *StoreHandler) UpdateStoreSections(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Sections []models.StoreSection `json:"sections" validate:"max=10,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. Check featured products belong to this vendor
+	for i := range input.Sections {
+		section := &input.Sections[i]
+		section.Position = i
+		if section.Type != models.SectionFeaturedProducts {
+			section.ProductIDs = nil
+			continue
+		}
+		if len(section.ProductIDs) == 0 {
+			continue
+		}
+
+		unique := map[primitive.ObjectID]bool{}
+		for _, id := range section.ProductIDs {
+			unique[id] = true
+		}
+		ids := make([]primitive.ObjectID, 0, len(unique))
+		for id := range unique {
+			ids = append(ids, id)
+		}
+
+		_, owned, err := h.ProductRepo.GetVendorProducts(ctx, bson.M{"_id": bson.M{"$in": ids}, "vendorId": vendorID}, 1, 0, nil, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify products"))
+			return
+		}
+		if int(owned) != len(ids) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Featured products must be your own products"))
+			return
+		}
+	}
+
+	// 2. Save the layout
+	store, err := h.Repo.UpdateStore(ctx, vendorID, bson.M{"sections": input.Sections})
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store layout"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store layout updated", gin.H{"sections": store.Sections}))
+}
+
+// UpdateStorePolicies replaces the store's structured return, shipping and
+// processing-time policies.
+func (h *StoreHandler) UpdateStorePolicies(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.StorePolicies
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.Return.AcceptsReturns && input.Return.WindowDays == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A return window is required when returns are accepted"))
+		return
+	}
+	if !input.Return.AcceptsReturns {
+		input.Return.WindowDays = 0
+	}
+	if input.Shipping.Origin != nil {
+		if err := checkAddress(input.Shipping.Origin); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ship-from address: "+err.Error()))
+			return
+		}
+	}
+	input.UpdatedAt = time.Now()
+
+	fields := bson.M{"policies": input}
+	// Keep the legacy ships-from field in step for older clients
+	if input.Shipping.ShipsFrom != "" {
+		fields["shipsFrom"] = input.Shipping.ShipsFrom
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, err := h.Repo.UpdateStore(ctx, vendorID, fields)
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store policies"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store policies updated", gin.H{"policies": store.Policies}))
+}
+
+// UpdateStoreShippingMethods replaces the shipping methods buyers choose
+// between at checkout. An empty list goes back to rate-table pricing.
+func (h *StoreHandler) UpdateStoreShippingMethods(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Methods []models.ShippingMethod `json:"methods" validate:"max=3,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	seen := map[string]bool{}
+	for i := range input.Methods {
+		m := &input.Methods[i]
+		if seen[m.Code] {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Each shipping method can only be offered once"))
+			return
+		}
+		seen[m.Code] = true
+		for j, r := range m.Regions {
+			m.Regions[j] = models.NormalizeRegion(r)
+		}
+		if m.Code == models.ShippingMethodPickup {
+			m.Fee, m.FreeOver, m.MinTransitDays, m.MaxTransitDays = 0, 0, 0, 0
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if seen[models.ShippingMethodPickup] {
+		current, err := h.Repo.GetByVendorID(ctx, vendorID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+			return
+		}
+		if current == nil {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+			return
+		}
+		if len(current.PickupLocations) == 0 {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Add a pickup location before offering pickup"))
+			return
+		}
+	}
+
+	store, err := h.Repo.UpdateStore(ctx, vendorID, bson.M{"shippingMethods": input.Methods})
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update shipping methods"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Shipping methods updated", gin.H{"methods": store.ShippingMethods}))
+}
+
+// UpdateStorePickupLocations replaces the places buyers can collect orders
+// from. Locations keep their IDs when sent back with them; new ones get one.
+func (h *StoreHandler) UpdateStorePickupLocations(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Locations []models.PickupLocation `json:"locations" validate:"max=20,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for i := range input.Locations {
+		l := &input.Locations[i]
+		if l.ID.IsZero() || seen[l.ID] {
+			l.ID = primitive.NewObjectID()
+		}
+		seen[l.ID] = true
+		if err := checkAddress(&l.Address); err != nil {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid address for "+l.Name+": "+err.Error()))
+			return
+		}
+		for _, hours := range l.Hours {
+			if hours.Closes <= hours.Opens {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse("Pickup hours must close after they open"))
+				return
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	current, err := h.Repo.GetByVendorID(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch store"))
+		return
+	}
+	if current == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if len(input.Locations) == 0 {
+		for _, m := range current.ShippingMethods {
+			if m.Code == models.ShippingMethodPickup {
+				c.JSON(http.StatusBadRequest, utils.ErrorResponse("Stop offering pickup before removing every pickup location"))
+				return
+			}
+		}
+	}
+
+	store, err := h.Repo.UpdateStore(ctx, vendorID, bson.M{"pickupLocations": input.Locations})
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update pickup locations"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Pickup locations updated", gin.H{"locations": store.PickupLocations}))
+}
+
+// UpdateStoreSEO sets the storefront's search title, description and
+// keywords. Empty values fall back to the store's name and description.
+func (h *StoreHandler) UpdateStoreSEO(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Title       string   `json:"title" validate:"max=70"`
+		Description string   `json:"description" validate:"max=160"`
+		Keywords    []string `json:"keywords" validate:"max=20,dive,min=1,max=50"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	seo := models.SEO{
+		Title:       strings.TrimSpace(input.Title),
+		Description: strings.TrimSpace(input.Description),
+		Keywords:    []string{},
+	}
+	seen := make(map[string]bool)
+	for _, kw := range input.Keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" || seen[kw] {
+			continue
+		}
+		seen[kw] = true
+		seo.Keywords = append(seo.Keywords, kw)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	store, err := h.Repo.UpdateStore(ctx, vendorID, bson.M{"seo": seo})
+	if err == repository.ErrStoreNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Store not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update store SEO"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Store SEO updated", gin.H{"seo": storeSEOView(store)}))
+}
+
+// GetStoreAnnouncements lists all of the vendor's announcements, including
+// scheduled and expired ones.
+func (h *StoreHandler) GetStoreAnnouncements(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	announcements, err := h.AnnouncementRepo.GetVendorAnnouncements(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch announcements"))
+		return
+	}
+
+	now := time.Now()
+	views := make([]gin.H, 0, len(announcements))
+	for _, a := range announcements {
+		views = append(views, gin.H{"announcement": a, "live": a.IsLive(now)})
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Announcements fetched successfully", gin.H{"announcements": views}))
+}
+
+// CreateStoreAnnouncement schedules a new storefront announcement. It starts
+// immediately and stays active unless told otherwise.
+func (h *StoreHandler) CreateStoreAnnouncement(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input models.StoreAnnouncementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+	if input.Message == nil || strings.TrimSpace(*input.Message) == "" {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Message is required"))
+		return
+	}
+
+	announcement := models.StoreAnnouncement{
+		VendorID: vendorID,
+		Message:  strings.TrimSpace(*input.Message),
+		Active:   true,
+		StartsAt: time.Now(),
+		EndsAt:   input.EndsAt,
+	}
+	if input.Active != nil {
+		announcement.Active = *input.Active
+	}
+	if input.StartsAt != nil {
+		announcement.StartsAt = *input.StartsAt
+	}
+	if announcement.EndsAt != nil && !announcement.EndsAt.After(announcement.StartsAt) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("endsAt must be after startsAt"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	created, err := h.AnnouncementRepo.CreateAnnouncement(ctx, announcement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create announcement"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Announcement created", gin.H{"announcement": created}))
+}
+
+// UpdateStoreAnnouncement edits an announcement's message or schedule, or
+// toggles it on and off.
+func (h *StoreHandler) UpdateStoreAnnouncement(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid announcement ID"))
+		return
+	}
+
+	var input models.StoreAnnouncementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return
+	}
+	if err := storeValidator.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	existing, err := h.AnnouncementRepo.GetAnnouncement(ctx, vendorID, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch announcement"))
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Announcement not found"))
+		return
+	}
+
+	fields := bson.M{}
+	if input.Message != nil {
+		fields["message"] = strings.TrimSpace(*input.Message)
+	}
+	if input.Active != nil {
+		fields["active"] = *input.Active
+	}
+	startsAt, endsAt := existing.StartsAt, existing.EndsAt
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+		fields["startsAt"] = startsAt
+	}
+	if input.EndsAt != nil {
+		endsAt = input.EndsAt
+		fields["endsAt"] = *input.EndsAt
+	}
+	if endsAt != nil && !endsAt.After(startsAt) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("endsAt must be after startsAt"))
+		return
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No fields to update"))
+		return
+	}
+
+	updated, err := h.AnnouncementRepo.UpdateAnnouncement(ctx, vendorID, id, fields)
+	if err == repository.ErrAnnouncementNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Announcement not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update announcement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Announcement updated", gin.H{"announcement": updated}))
+}
+
+// DeleteStoreAnnouncement removes an announcement.
+func (h *StoreHandler) DeleteStoreAnnouncement(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid announcement ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err = h.AnnouncementRepo.DeleteAnnouncement(ctx, vendorID, id)
+	if err == repository.ErrAnnouncementNotFound {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Announcement not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete announcement"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Announcement deleted", nil))
+}
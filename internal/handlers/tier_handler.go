@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
@@ -11,6 +12,7 @@ import (
 	"github.com/developia-II/ecommerce-backend/internal/services"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -59,16 +61,30 @@ func (h *TierHandler) RequestUpgrade(c *gin.Context) {
 		return
 	}
 
-	// 2. Get current tier
+	// 2. Get current tier from the vendor account
 	user, err := h.UserRepo.GetByID(ctx, vendorID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor account"))
 		return
 	}
 
-	currentTier := "individual"
-	if user.VendorAccount != nil {
-		currentTier = user.VendorAccount.Tier
+	acc, err := h.Repo.GetVendorAccount(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor account"))
+		return
+	}
+	if acc == nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Vendor account not found"))
+		return
+	}
+	if acc.Status != "active" {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Only active vendors can apply for a tier upgrade"))
+		return
+	}
+
+	currentTier := acc.Tier
+	if currentTier == "" {
+		currentTier = "individual"
 	}
 
 	// Enforce sequential tier upgrades
@@ -85,12 +101,35 @@ func (h *TierHandler) RequestUpgrade(c *gin.Context) {
 		return
 	}
 
-	// 3. Handle AI Verification for Tier 2 (Verified)
-	status := models.UpgradeStatusPending
-	riskScore := 0
-	reviewNotes := ""
-	adminNotes := ""
+	// 3. Check the documents required for the requested tier
+	if missing := missingTierDocuments(input.RequestedTier, input.Documents); len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Missing required documents: "+strings.Join(missing, ", ")))
+		return
+	}
+	if input.RequestedTier == "business" && input.BusinessInfo == nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Business information is required for the Business tier"))
+		return
+	}
 
+	req := models.TierUpgradeRequest{
+		ID:            primitive.NewObjectID(),
+		VendorID:      vendorID,
+		CurrentTier:   currentTier,
+		RequestedTier: input.RequestedTier,
+		Documents:     input.Documents,
+		BusinessInfo:  input.BusinessInfo,
+		Status:        models.UpgradeStatusPending,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	// 4. Score the upgrade risk
+	risk := CalculateUpgradeRiskScore(acc, &req)
+	req.RiskScore = risk.Total
+	req.RiskFlags = risk.Flags
+
+	// 5. Handle AI Verification for Tier 2 (Verified)
+	autoApprove := false
 	if input.RequestedTier == "verified" && h.AIService != nil {
 		var idURL, selfieURL string
 		for _, doc := range input.Documents {
@@ -104,70 +143,140 @@ func (h *TierHandler) RequestUpgrade(c *gin.Context) {
 		if idURL != "" {
 			aiResult, err := h.AIService.AnalyzeIdentity(ctx, idURL, selfieURL, user.Name)
 			if err == nil {
-				riskScore = 100 - aiResult.Confidence
-				reviewNotes = fmt.Sprintf("AI Extraction: %s. Match: %v. Confidence: %d%%. Reason: %s",
+				req.ReviewNotes = fmt.Sprintf("AI Extraction: %s. Match: %v. Confidence: %d%%. Reason: %s",
 					aiResult.ExtractedName, aiResult.IsMatch, aiResult.Confidence, aiResult.RejectionReason)
 
-				if aiResult.IsMatch && aiResult.Confidence > 80 {
-					// Auto-approve
-					status = models.UpgradeStatusApproved
-					h.UserRepo.UpdateTier(ctx, vendorID, "verified")
+				if aiResult.IsMatch && aiResult.Confidence > 80 && risk.Total < risk.Threshold {
+					// Auto-approve once the request is on record
+					autoApprove = true
 				} else if !aiResult.IsMatch || aiResult.Confidence < 40 {
 					// Hard AI rejection — save as rejected so it appears in history + counts retries
-					status = models.UpgradeStatusRejected
-					adminNotes = "AI hard rejection: " + aiResult.RejectionReason
+					req.Status = models.UpgradeStatusRejected
+					req.AdminNotes = "AI hard rejection: " + aiResult.RejectionReason
 
 					// Increment retries and possibly suspend
-					if user.VendorAccount != nil {
-						newRetries := user.VendorAccount.VerificationRetries + 1
-						if newRetries >= 3 {
-							suspendUntil := time.Now().Add(7 * 24 * time.Hour)
-							h.UserRepo.UpdateVendorSuspension(ctx, vendorID, newRetries, &suspendUntil)
-						} else {
-							h.UserRepo.UpdateVendorSuspension(ctx, vendorID, newRetries, nil)
-						}
+					newRetries := acc.VerificationRetries + 1
+					if newRetries >= 3 {
+						suspendUntil := time.Now().Add(7 * 24 * time.Hour)
+						h.UserRepo.UpdateVendorSuspension(ctx, vendorID, newRetries, &suspendUntil)
+					} else {
+						h.UserRepo.UpdateVendorSuspension(ctx, vendorID, newRetries, nil)
 					}
 				}
-				// Otherwise remains pending (confidence 40-80) for manual review
+				// Otherwise remains pending (confidence 40-80 or risk flags) for manual review
 			}
 		}
 	}
 
-	// 4. Create request record (ALWAYS saved, including AI rejections)
-	req := models.TierUpgradeRequest{
-		ID:            primitive.NewObjectID(),
-		VendorID:      vendorID,
-		CurrentTier:   currentTier,
-		RequestedTier: input.RequestedTier,
-		Documents:     input.Documents,
-		BusinessInfo:  input.BusinessInfo,
-		Status:        status,
-		RiskScore:     riskScore,
-		ReviewNotes:   reviewNotes,
-		AdminNotes:    adminNotes,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
-
+	// 6. Create request record (ALWAYS saved, including AI rejections)
 	if err := h.Repo.CreateUpgradeRequest(ctx, req); err != nil {
 		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to submit upgrade request"))
 		return
 	}
 
+	if autoApprove {
+		if err := h.Repo.ApproveUpgradeRequest(ctx, req, getTierConfig(req.RequestedTier), primitive.NilObjectID); err != nil {
+			logrus.WithError(err).WithField("requestId", req.ID.Hex()).Error("Failed to auto-approve tier upgrade")
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Your documents were verified but the upgrade could not be applied; it has been kept for manual review."))
+			return
+		}
+		req.Status = models.UpgradeStatusApproved
+	}
+
 	message := "Upgrade request submitted for review."
-	if status == models.UpgradeStatusApproved {
+	if req.Status == models.UpgradeStatusApproved {
 		message = "AI Verification successful! Your account has been upgraded to Verified Tier."
-	} else if status == models.UpgradeStatusRejected {
+	} else if req.Status == models.UpgradeStatusRejected {
 		message = "AI Verification failed. Please review the notes and try again."
 	}
 
 	c.JSON(http.StatusCreated, utils.SuccessResponse(message, gin.H{
 		"requestId": req.ID.Hex(),
 		"status":    req.Status,
-		"notes":     adminNotes,
+		"notes":     req.AdminNotes,
 	}))
 }
 
+// missingTierDocuments returns the document types required for the tier that
+// are not present in the submitted documents.
+func missingTierDocuments(tier string, docs []models.VerificationDocument) []string {
+	provided := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		if doc.FileURL != "" {
+			provided[doc.DocumentType] = true
+		}
+	}
+
+	var missing []string
+	for _, docType := range models.TierDocumentRequirements[tier] {
+		if !provided[docType] {
+			missing = append(missing, docType)
+		}
+	}
+	return missing
+}
+
+// CalculateUpgradeRiskScore scores a tier upgrade application against the
+// vendor's track record. Scores under the threshold are eligible for automatic
+// approval; everything else waits for an admin.
+func CalculateUpgradeRiskScore(acc *models.VendorAccount, req *models.TierUpgradeRequest) models.RiskScore {
+	score := 0
+	var flags []string
+
+	// 1. Account tenure (30 points max)
+	daysActive := int(time.Since(acc.ActivatedAt).Hours() / 24)
+	minDays := 30
+	if req.RequestedTier == "business" {
+		minDays = 90
+	}
+	if daysActive < minDays {
+		score += 30
+		flags = append(flags, fmt.Sprintf("Account active for %d days (< %d)", daysActive, minDays))
+	}
+
+	// 2. Order history (25 points max)
+	minOrders := 20
+	if req.RequestedTier == "business" {
+		minOrders = 100
+	}
+	if acc.TotalOrders < minOrders {
+		score += 25
+		flags = append(flags, fmt.Sprintf("Only %d completed orders (< %d)", acc.TotalOrders, minOrders))
+	}
+
+	// 3. Disputes (25 points max)
+	if acc.DisputeCount > 0 {
+		score += 10
+		flags = append(flags, "Vendor has open or past disputes")
+		if acc.TotalOrders > 0 && float64(acc.DisputeCount)/float64(acc.TotalOrders) > 0.02 {
+			score += 15
+			flags = append(flags, "Dispute rate above 2%")
+		}
+	}
+
+	// 4. Previous failed verifications (20 points max)
+	if acc.VerificationRetries > 0 {
+		score += min(10*acc.VerificationRetries, 20)
+		flags = append(flags, fmt.Sprintf("%d previous failed verification attempt(s)", acc.VerificationRetries))
+	}
+
+	// 5. Document quality (15 points max)
+	for _, doc := range req.Documents {
+		ct := strings.ToLower(doc.ContentType)
+		if ct != "" && ct != "image/jpeg" && ct != "image/png" && ct != "application/pdf" {
+			score += 15
+			flags = append(flags, "Non-standard document format")
+			break
+		}
+	}
+
+	return models.RiskScore{
+		Total:     score,
+		Threshold: 35,
+		Flags:     flags,
+	}
+}
+
 func (h *TierHandler) GetUpgradeStatus(c *gin.Context) {
 	userIdStr, _ := c.Get("userId")
 	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// trashPurgeInterval is how often documents past their retention are purged.
+const trashPurgeInterval = 24 * time.Hour
+
+// trashAuditTargets is the audit target type of each kind of document.
+var trashAuditTargets = map[string]string{
+	models.TrashUsers:    models.AuditTargetUser,
+	models.TrashProducts: models.AuditTargetProduct,
+	models.TrashReviews:  models.AuditTargetReview,
+	models.TrashStores:   models.AuditTargetStore,
+}
+
+// TrashHandler lets admins soft-delete users, products, reviews and stores,
+// see what is in the trash and restore it before it is purged.
+type TrashHandler struct {
+	Repo       repository.TrashRepository
+	ReviewRepo repository.ReviewRepository
+	AuditRepo  repository.AuditRepository
+	Retention  time.Duration // models.DefaultTrashRetention when unset
+}
+
+func NewTrashHandler(db *mongo.Database, retention time.Duration) *TrashHandler {
+	return &TrashHandler{
+		Repo:       repository.NewTrashRepository(db),
+		ReviewRepo: repository.NewReviewRepository(db),
+		AuditRepo:  repository.NewAuditRepository(db),
+		Retention:  retention,
+	}
+}
+
+// retention is how long soft-deleted documents can be restored.
+func (h *TrashHandler) retention() time.Duration {
+	if h.Retention > 0 {
+		return h.Retention
+	}
+	return models.DefaultTrashRetention
+}
+
+// StartTrashPurge purges documents that have been in the trash longer than
+// retention, now and then daily until ctx is done.
+func StartTrashPurge(ctx context.Context, repo repository.TrashRepository, retention time.Duration) {
+	purge := func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		before := time.Now().Add(-retention)
+		for _, kind := range models.TrashKinds {
+			n, err := repo.Purge(ctx, kind, before)
+			if err != nil {
+				logrus.WithError(err).WithField("kind", kind).Error("Failed to purge trash")
+				continue
+			}
+			if n > 0 {
+				logrus.WithFields(logrus.Fields{"kind": kind, "purged": n}).Info("Purged trash")
+			}
+		}
+	}
+
+	go func() {
+		purge()
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purge()
+			}
+		}
+	}()
+}
+
+// DeleteUser soft-deletes an account. It is signed out straight away and
+// can't sign in again unless restored.
+func (h *TrashHandler) DeleteUser(c *gin.Context) {
+	h.softDelete(c, models.TrashUsers)
+}
+
+// DeleteProduct soft-deletes a product, taking it off the storefront.
+func (h *TrashHandler) DeleteProduct(c *gin.Context) {
+	h.softDelete(c, models.TrashProducts)
+}
+
+// DeleteReview soft-deletes a review and recalculates its product's rating.
+func (h *TrashHandler) DeleteReview(c *gin.Context) {
+	h.softDelete(c, models.TrashReviews)
+}
+
+// DeleteStore soft-deletes a vendor's storefront.
+func (h *TrashHandler) DeleteStore(c *gin.Context) {
+	h.softDelete(c, models.TrashStores)
+}
+
+func (h *TrashHandler) softDelete(c *gin.Context, kind string) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID"))
+		return
+	}
+	userIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+	if kind == models.TrashUsers && id == adminID {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("You can't delete your own account"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	var review models.Review
+	if kind == models.TrashReviews {
+		review, err = h.ReviewRepo.GetReviewByID(ctx, id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Not found"))
+			return
+		}
+	}
+
+	err = h.Repo.SoftDelete(ctx, kind, id, adminID)
+	if errors.Is(err, repository.ErrTrashNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete"))
+		return
+	}
+
+	switch kind {
+	case models.TrashUsers:
+		middleware.InvalidateAccountState(id.Hex())
+	case models.TrashReviews:
+		if err := h.ReviewRepo.RefreshProductRating(ctx, review.ProductID); err != nil {
+			logrus.WithError(err).WithField("productId", review.ProductID.Hex()).Error("Failed to refresh product rating")
+		}
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditRecordDeleted,
+		TargetType: trashAuditTargets[kind],
+		TargetID:   id,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Moved to trash", gin.H{
+		"purgeAt": time.Now().Add(h.retention()),
+	}))
+}
+
+// ListTrash lists the soft-deleted documents of a kind (users, products,
+// reviews or stores), most recently deleted first, with when each will be
+// purged.
+func (h *TrashHandler) ListTrash(c *gin.Context) {
+	kind := c.Param("kind")
+	if !models.IsTrashKind(kind) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Unknown kind"))
+		return
+	}
+	page, limit := pageParams(c, 20, 100)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	items, total, err := h.Repo.ListDeleted(ctx, kind, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch trash"))
+		return
+	}
+	retention := h.retention()
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(retention)
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Trash retrieved", gin.H{
+		"items": items,
+		"meta":  models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// RestoreFromTrash brings a soft-deleted document back.
+func (h *TrashHandler) RestoreFromTrash(c *gin.Context) {
+	kind := c.Param("kind")
+	if !models.IsTrashKind(kind) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Unknown kind"))
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err = h.Repo.Restore(ctx, kind, id)
+	if errors.Is(err, repository.ErrTrashNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Not in the trash"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to restore"))
+		return
+	}
+
+	switch kind {
+	case models.TrashUsers:
+		middleware.InvalidateAccountState(id.Hex())
+	case models.TrashReviews:
+		if review, err := h.ReviewRepo.GetReviewByID(ctx, id); err == nil {
+			if err := h.ReviewRepo.RefreshProductRating(ctx, review.ProductID); err != nil {
+				logrus.WithError(err).WithField("productId", review.ProductID.Hex()).Error("Failed to refresh product rating")
+			}
+		}
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditRecordRestored,
+		TargetType: trashAuditTargets[kind],
+		TargetID:   id,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Restored", nil))
+}
@@ -1,23 +1,25 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"path/filepath"
 	"strings"
 
+	"github.com/developia-II/ecommerce-backend/internal/services"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UploadHandler struct {
-	DB *mongo.Database
+	DB    *mongo.Database
+	Media *services.MediaService
 }
 
-func NewUploadHandler(db *mongo.Database) *UploadHandler {
-	return &UploadHandler{DB: db}
+func NewUploadHandler(db *mongo.Database, media *services.MediaService) *UploadHandler {
+	return &UploadHandler{DB: db, Media: media}
 }
 
 // UploadImage handles the POST /api/v1/upload request.
@@ -30,81 +32,84 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 		return
 	}
 	authStr := strings.TrimPrefix(authHeader, "Bearer ")
-	_, err := utils.VerifyToken(authStr)
+	claims, err := utils.VerifyToken(authStr)
 	if err != nil {
 		c.JSON(http.StatusForbidden, utils.ErrorResponse(err.Error()))
 		return
 	}
+	userID, _ := primitive.ObjectIDFromHex(claims.UserID)
 
-	// 2. Check the Weight (Size Limit: 10MB)
+	// 2. Check the Weight
 	// http.MaxBytesReader prevents the server from reading more than the limit
-	const MaxUploadSize = 10 << 20 // 10MB
-	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxUploadSize)
+	m := services.MediaProductImage
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, m.MaxSize+1<<20)
 
 	// 3. Grab the Package (Extracting the file)
 	file, header, err := c.Request.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No file provided or file too large (Max 10MB)"))
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No file provided or file too large ("+m.Describe()+")"))
 		return
 	}
 	defer file.Close()
 
-	// 4. Scan the Contents (Magic Number Validation)
-	// We read the first 512 bytes to detect the actual file type
-	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to read file for validation"))
+	// 4. Ship to Storage. The media service checks the real type from the
+	// file's content and stores it under a random name.
+	image, err := h.Media.Upload(c.Request.Context(), m, userID, file, header)
+	if !uploadSucceeded(c, err, m, "Unsupported file type. Please upload ") {
 		return
 	}
-	// Reset the file pointer to the beginning so Cloudinary can read the whole file
-	file.Seek(0, 0)
 
-	contentType := http.DetectContentType(buffer)
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/png":  true,
-		"image/webp": true,
-		"image/gif":  true,
-	}
+	// 5. Send the Receipt (Success Response)
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"message":      "Image uploaded successfully",
+		"url":          image.URL,
+		"thumbnailUrl": h.Media.Transform(image.URL, services.MediaThumbnail),
+		"size":         image.Size,
+		"type":         image.ContentType,
+	})
+}
 
-	if !allowedTypes[contentType] {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Unsupported file type. Please upload JPG, PNG, WEBP, or GIF"))
-		return
-	}
+// uploadAttachment uploads the "file" form field through the media service
+// to the signed-in user's folder in the given context, and returns the
+// attachment to send along with a message.
+func uploadAttachment(c *gin.Context, media *services.MediaService, m services.MediaContext) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
 
-	// 5. Rename the Original (Security/Sanitization)
-	// We use UUID to prevent path traversal and name collisions
-	uniqueID := uuid.New().String()
-	ext := filepath.Ext(header.Filename)
-	if ext == "" {
-		// Fallback extension based on content type if original filename has none
-		switch contentType {
-		case "image/jpeg":
-			ext = ".jpg"
-		case "image/png":
-			ext = ".png"
-		case "image/webp":
-			ext = ".webp"
-		case "image/gif":
-			ext = ".gif"
-		}
+	// Leave room for the multipart envelope; the file itself is checked below
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, m.MaxSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("No file provided or file too large ("+m.Describe()+")"))
+		return
 	}
-	safeFilename := fmt.Sprintf("%s%s", uniqueID, ext)
+	defer file.Close()
 
-	// 6. Ship to Storage (Streaming to Cloudinary)
-	imageUrl, err := utils.UploadToCloudinary(file, safeFilename)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Cloudinary upload failed: "+err.Error()))
+	attachment, err := media.Upload(c.Request.Context(), m, userID, file, header)
+	if !uploadSucceeded(c, err, m, "Unsupported file; attach ") {
 		return
 	}
 
-	// 7. Send the Receipt (Success Response)
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Image uploaded successfully",
-		"url":     imageUrl,
-		"size":    header.Size,
-		"type":    contentType,
-	})
+	c.JSON(http.StatusCreated, utils.SuccessResponse("File uploaded", gin.H{"attachment": attachment}))
+}
+
+// uploadSucceeded writes the response for a failed upload: a 400 starting
+// with rejected for a file the context doesn't take, otherwise a 500. It
+// reports whether err was nil.
+func uploadSucceeded(c *gin.Context, err error, m services.MediaContext, rejected string) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, services.ErrMediaTooLarge) || errors.Is(err, services.ErrMediaType) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(rejected+m.Describe()))
+		return false
+	}
+	if errors.Is(err, services.ErrMediaNotConfigured) {
+		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("File uploads are unavailable right now"))
+		return false
+	}
+	logrus.WithError(err).WithField("folder", m.Folder).Error("Media upload failed")
+	c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to upload file"))
+	return false
 }
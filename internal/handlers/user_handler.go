@@ -9,6 +9,7 @@ import (
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
@@ -51,6 +52,10 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
 		return
 	}
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
@@ -96,3 +101,119 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Password updated successfully", nil))
 }
+
+const (
+	phoneCodeTTL         = 10 * time.Minute
+	phoneCodeResendAfter = time.Minute
+	phoneCodeMaxAttempts = 5
+)
+
+// SendPhoneCode texts a one-time code to the number the user wants to
+// verify.
+func (h *UserHandler) SendPhoneCode(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Phone number is required"))
+		return
+	}
+	phone, err := utils.NormalizePhone(input.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	user, err := h.Repo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+	if user.PhoneVerified && user.Phone == phone {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("This number is already verified"))
+		return
+	}
+	if time.Since(user.PhoneOTPSentAt) < phoneCodeResendAfter {
+		c.JSON(http.StatusTooManyRequests, utils.ErrorResponse("Please wait a minute before requesting another code"))
+		return
+	}
+
+	// 1. Store the hashed code
+	code, err := utils.GenerateNumericCode(6)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate code"))
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to generate code"))
+		return
+	}
+	if err := h.Repo.SetPhoneOTP(ctx, userID, phone, string(hash), time.Now().Add(phoneCodeTTL)); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to save code"))
+		return
+	}
+
+	// 2. Text it
+	if err := utils.SendSMS(phone, "Your Vendora verification code is "+code+". It expires in 10 minutes."); err != nil {
+		if err == utils.ErrSMSNotConfigured {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("We can't send SMS to this country yet"))
+			return
+		}
+		logrus.WithError(err).WithField("userId", userID.Hex()).Error("Failed to send phone verification code")
+		c.JSON(http.StatusBadGateway, utils.ErrorResponse("Failed to send code"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Verification code sent", gin.H{"phone": phone}))
+}
+
+// VerifyPhone checks the code and marks the number as verified.
+func (h *UserHandler) VerifyPhone(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	var input struct {
+		Code string `json:"code" binding:"required,len=6,numeric"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("A 6-digit code is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.Repo.GetByID(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("User not found"))
+		return
+	}
+	if user.PhoneOTPHash == "" || time.Now().After(user.PhoneOTPExpiry) {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Code has expired; please request a new one"))
+		return
+	}
+	if user.PhoneOTPAttempts >= phoneCodeMaxAttempts {
+		c.JSON(http.StatusTooManyRequests, utils.ErrorResponse("Too many attempts; please request a new code"))
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PhoneOTPHash), []byte(input.Code)); err != nil {
+		_ = h.Repo.IncrementPhoneOTPAttempts(ctx, userID)
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Incorrect code"))
+		return
+	}
+
+	if err := h.Repo.ConfirmPhone(ctx, userID, user.PhoneOTPPhone); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to verify phone"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Phone number verified", gin.H{"phone": user.PhoneOTPPhone}))
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"context"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -11,22 +10,34 @@ import (
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type VendorHandler struct {
-	DB   *mongo.Database
-	Repo repository.UserRepository
+	DB          *mongo.Database
+	Repo        repository.UserRepository
+	OrderRepo   repository.OrderRepository
+	ReviewRepo  repository.ReviewRepository
+	MessageRepo repository.MessageRepository
 }
 
 func NewVendorHandler(db *mongo.Database, repo repository.UserRepository) *VendorHandler {
-	return &VendorHandler{DB: db, Repo: repo}
+	return &VendorHandler{
+		DB:          db,
+		Repo:        repo,
+		OrderRepo:   repository.NewOrderRepository(db),
+		ReviewRepo:  repository.NewReviewRepository(db),
+		MessageRepo: repository.NewMessageRepository(db),
+	}
 }
 
-var vendorValidator = validator.New()
+var vendorValidator = utils.NewValidator()
+
+// vendorProfileCache holds computed trust signals; they are aggregation-heavy
+// and only need to be roughly current.
+var vendorProfileCache = utils.NewTTLCache[gin.H](10 * time.Minute)
 
 // VendorApplication represents the vendor application data
 type VendorApplication struct {
@@ -34,10 +45,10 @@ type VendorApplication struct {
 	BusinessType        string   `json:"businessType" validate:"required"`
 	BusinessDescription string   `json:"businessDescription" validate:"required,min=10,max=500"`
 	ContactEmail        string   `json:"contactEmail" validate:"required,email"`
-	ContactPhone        string   `json:"contactPhone" validate:"required,min=10,max=15"`
+	ContactPhone        string   `json:"contactPhone" validate:"required,phone"`
 	BusinessAddress     string   `json:"businessAddress" validate:"required,min=10,max=200"`
 	TaxID               string   `json:"taxId,omitempty"`
-	Website             string   `json:"website,omitempty"`
+	Website             string   `json:"website,omitempty" validate:"omitempty,weburl"`
 	SocialMedia         []string `json:"socialMedia,omitempty"`
 	Products            []string `json:"products" validate:"required,min=1"`
 	Experience          string   `json:"experience" validate:"required,min=10,max=300"`
@@ -93,13 +104,13 @@ func (h *VendorHandler) ApplyForVendor(c *gin.Context) {
 	// Parse vendor application data
 	var application VendorApplication
 	if err := c.ShouldBindJSON(&application); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid JSON format: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
 	// Validate application data
 	if err := vendorValidator.Struct(&application); err != nil {
-		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Validation failed: "+err.Error()))
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
 		return
 	}
 
@@ -154,13 +165,9 @@ func (h *VendorHandler) ApplyForVendor(c *gin.Context) {
 }
 
 func (h *VendorHandler) ListPublicVendors(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	page, limit := pageParams(c, 12, 100)
 	search := c.Query("search")
 	category := c.Query("category")
-    
-	if page < 1 { page = 1 }
-	if limit < 1 { limit = 12 }
 	skip := (page - 1) * limit
 
 	filter := bson.M{"vendorStatus": "approved"}
@@ -185,11 +192,7 @@ func (h *VendorHandler) ListPublicVendors(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Vendors fetched successfully", gin.H{
 		"vendors": vendors,
-		"meta": gin.H{
-			"total": total,
-			"page":  page,
-			"limit": limit,
-		},
+		"meta":    models.NewPageMeta(total, page, limit),
 	}))
 }
 
@@ -217,3 +220,89 @@ func (h *VendorHandler) GetPublicVendorById(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor details fetched successfully", vendor))
 }
+
+// GetPublicVendorProfile returns the trust signals buyers use to assess a
+// seller: tenure, catalogue size, fulfillment record and review stats.
+func (h *VendorHandler) GetPublicVendorProfile(c *gin.Context) {
+	vendorID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid vendor ID"))
+		return
+	}
+
+	if profile, ok := vendorProfileCache.Get(vendorID.Hex()); ok {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Vendor profile fetched successfully", profile))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// 1. Vendor account (approved vendors only)
+	var account models.VendorAccount
+	if err := h.DB.Collection("vendorAccounts").FindOne(ctx, bson.M{"userID": vendorID}).Decode(&account); err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Vendor not found"))
+		} else {
+			c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch vendor"))
+		}
+		return
+	}
+	if account.Status == "banned" {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Vendor not found"))
+		return
+	}
+
+	joinedAt := account.ActivatedAt
+	if joinedAt.IsZero() {
+		joinedAt = account.CreatedAt
+	}
+
+	// 2. Catalogue size
+	totalProducts, err := h.DB.Collection("products").CountDocuments(ctx, bson.M{
+		"vendorId": vendorID,
+		"status":   models.ProductStatusActive,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to count products"))
+		return
+	}
+
+	// 3. Fulfillment record
+	fulfillment, err := h.OrderRepo.GetVendorFulfillmentStats(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to compute fulfillment stats"))
+		return
+	}
+
+	// 4. Review stats
+	reviews, err := h.ReviewRepo.GetVendorReviewStats(ctx, vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to compute review stats"))
+		return
+	}
+
+	// 5. How quickly they answer buyers' messages, over the last 90 days
+	messaging, err := h.MessageRepo.GetVendorResponseStats(ctx, vendorID, time.Now().AddDate(0, 0, -90))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to compute message response stats"))
+		return
+	}
+
+	profile := gin.H{
+		"vendorId":        vendorID,
+		"tier":            account.Tier,
+		"joinedAt":        joinedAt,
+		"totalProducts":   totalProducts,
+		"totalOrders":     fulfillment.TotalOrders,
+		"fulfillmentRate": fulfillment.FulfillmentRate,
+		"avgShipHours":    fulfillment.AvgShipHours,
+		"reviews":         reviews,
+		"responseRate":    reviews.ResponseRate,
+		"messageResponse": messaging,
+		"computedAt":      time.Now(),
+	}
+	vendorProfileCache.Set(vendorID.Hex(), profile)
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Vendor profile fetched successfully", profile))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// inventoryReportMaxProducts caps the products in one inventory report.
+const inventoryReportMaxProducts = 5000
+
+// inventoryProductFields are the product fields an inventory report reads.
+var inventoryProductFields = bson.M{
+	"name": 1, "sku": 1, "status": 1, "stock": 1, "hasVariants": 1, "variants.stock": 1,
+	"price": 1, "costPrice": 1, "createdAt": 1,
+}
+
+// buildInventoryReport reports the vendor's stock against their sales over
+// [from, to).
+func buildInventoryReport(ctx context.Context, products repository.ProductRepository, orders repository.OrderRepository, vendorID primitive.ObjectID, from, to time.Time) (models.InventoryReport, error) {
+	filter := bson.M{"vendorId": vendorID, "status": bson.M{"$ne": models.ProductStatusRemoved}}
+	list, _, err := products.GetVendorProducts(ctx, filter, inventoryReportMaxProducts, 0, bson.D{{Key: "name", Value: 1}}, inventoryProductFields)
+	if err != nil {
+		return models.InventoryReport{}, err
+	}
+	sales, err := orders.GetVendorInventorySales(ctx, vendorID, from, to)
+	if err != nil {
+		return models.InventoryReport{}, err
+	}
+	return models.BuildInventoryReport(list, sales, from, to), nil
+}
+
+// GetInventoryReport shows the vendor's stock turnover over a range
+// (?window= or ?from=/?to=, as for the dashboard, default the last 30
+// days): each product's sell-through rate and days of cover, slowest
+// first, and the live products in stock that sold nothing, as candidates
+// to discount.
+func (h *OrderHandler) GetInventoryReport(c *gin.Context) {
+	userIdStr, _ := c.Get("userId")
+	vendorID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
+
+	_, from, to, err := ParseDashboardWindow(c.Query("window"), c.Query("from"), c.Query("to"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse(err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	report, err := buildInventoryReport(ctx, h.ProductRepo, h.Repo, vendorID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to build inventory report"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Inventory report fetched", gin.H{"report": report}))
+}
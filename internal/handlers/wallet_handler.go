@@ -7,9 +7,12 @@ import (
 	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -61,6 +64,7 @@ func (h *WalletHandler) GetWalletOverview(c *gin.Context) {
 			"available": account.AvailableBalance,
 			"pending":   account.PendingBalance,
 			"lifetime":  account.LifeTimeEarnings,
+			"promoCost": account.LifeTimePromoCost,
 			"tier":      account.Tier,
 			"holdDays":  account.PayoutHoldDays,
 		},
@@ -74,7 +78,7 @@ func (h *WalletHandler) RequestPayout(c *gin.Context) {
 	userID, _ := primitive.ObjectIDFromHex(userIdStr.(string))
 
 	var input struct {
-		Amount         float64           `json:"amount" binding:"required,gt=0"`
+		Amount         models.Money      `json:"amount" binding:"required,gt=0"`
 		Method         string            `json:"method" binding:"required"`
 		AccountDetails map[string]string `json:"accountDetails" binding:"required"`
 	}
@@ -116,7 +120,14 @@ func (h *WalletHandler) RequestPayout(c *gin.Context) {
 	}
 
 	// 3. Mock Email & Invoice Dispatch (Production ready for SendGrid/Resend)
-	fmt.Printf("[Email Service Mock] Sending withdrawal receipt & invoice to vendor %s for $%.2f\n", userID.Hex(), input.Amount)
+	middleware.Log(c).WithFields(logrus.Fields{"payoutId": payout.ID.Hex(), "amount": input.Amount}).Info("Withdrawal receipt queued")
+	events.Publish(events.PayoutSent, map[string]interface{}{
+		"payoutId":  payout.ID.Hex(),
+		"vendorId":  userID.Hex(),
+		"amount":    payout.Amount.Float64(),
+		"reference": payout.Reference,
+		"method":    payout.Method,
+	})
 
 	c.JSON(http.StatusCreated, utils.SuccessResponse("Withdrawal successful", gin.H{
 		"payout": payout,
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// webhookSecretBytes is how much randomness goes into a signing secret.
+const webhookSecretBytes = 32
+
+// WebhookHandler lets admins subscribe integrators' endpoints to platform
+// events and sends them each event, signed, logging every delivery.
+// Deliveries are signed as the WEBHOOK_URLS ones are: X-Vendora-Signature is
+// "sha256=" and the hex HMAC-SHA256 of the body under the subscription's
+// secret.
+type WebhookHandler struct {
+	Repo       repository.WebhookRepository
+	AuditRepo  repository.AuditRepository
+	Client     *http.Client
+	MaxRetries int
+}
+
+func NewWebhookHandler(db *mongo.Database) *WebhookHandler {
+	return &WebhookHandler{
+		Repo:       repository.NewWebhookRepository(db),
+		AuditRepo:  repository.NewAuditRepository(db),
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		MaxRetries: 3,
+	}
+}
+
+// HandleEvent is a bus Handler that sends the event to every active
+// subscription to it, at the same time.
+func (h *WebhookHandler) HandleEvent(ctx context.Context, e events.Event) {
+	subs, err := h.Repo.SubscriptionsFor(ctx, e.Type)
+	if err != nil {
+		logrus.WithError(err).WithField("event", e.Type).Error("Failed to load webhook subscriptions")
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		logrus.WithError(err).WithField("event", e.Type).Error("Failed to encode webhook payload")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func(sub models.WebhookSubscription) {
+			defer wg.Done()
+			delivery := models.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventID:        e.ID,
+				EventType:      e.Type,
+				Payload:        body,
+			}
+			h.deliver(ctx, sub, &delivery, h.MaxRetries)
+		}(sub)
+	}
+	wg.Wait()
+}
+
+// deliver logs delivery, sends it to sub's endpoint and logs how that went.
+func (h *WebhookHandler) deliver(ctx context.Context, sub models.WebhookSubscription, delivery *models.WebhookDelivery, maxRetries int) {
+	log := logrus.WithFields(logrus.Fields{"event": delivery.EventType, "webhookId": sub.ID.Hex()})
+
+	delivery.Status = models.WebhookDeliveryPending
+	if err := h.Repo.RecordDelivery(ctx, delivery); err != nil {
+		log.WithError(err).Error("Failed to record webhook delivery")
+		return
+	}
+
+	result := events.Deliver(ctx, h.Client, sub.URL, sub.Secret, delivery.EventType, delivery.ID.Hex(), delivery.Payload, maxRetries)
+	delivery.Attempts = result.Attempts
+	delivery.ResponseStatus = result.StatusCode
+	if result.Err != nil {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = result.Err.Error()
+		log.WithError(result.Err).Warn("Webhook delivery failed")
+	} else {
+		now := time.Now()
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	}
+
+	// The delivery's own context may be what ran out
+	saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Repo.UpdateDelivery(saveCtx, delivery); err != nil {
+		log.WithError(err).Error("Failed to update webhook delivery")
+	}
+}
+
+// ListWebhookEvents lists the event types webhooks can subscribe to.
+func (h *WebhookHandler) ListWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, utils.SuccessResponse("Webhook events fetched", gin.H{"events": events.Types}))
+}
+
+// ListWebhooks returns every webhook subscription, newest first. Secrets are
+// never listed.
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	subs, err := h.Repo.ListSubscriptions(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch webhooks"))
+		return
+	}
+	c.JSON(http.StatusOK, utils.SuccessResponse("Webhooks fetched", gin.H{"webhooks": subs}))
+}
+
+// CreateWebhook subscribes an endpoint to events. The response carries the
+// signing secret, which is not shown again; rotate it if it is lost.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	adminIdStr, _ := c.Get("userId")
+	adminID, _ := primitive.ObjectIDFromHex(adminIdStr.(string))
+
+	input, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+	secret, err := utils.GenerateSecureToken(webhookSecretBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create webhook"))
+		return
+	}
+	sub := models.WebhookSubscription{
+		URL:         input.URL,
+		Events:      input.Events,
+		Secret:      secret,
+		Description: input.Description,
+		Active:      input.Active == nil || *input.Active,
+		CreatedBy:   adminID,
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Repo.CreateSubscription(ctx, &sub); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to create webhook"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditWebhookCreated,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+		After:      gin.H{"url": sub.URL, "events": sub.Events, "active": sub.Active},
+	})
+	c.JSON(http.StatusCreated, utils.SuccessResponse("Webhook created", gin.H{"webhook": sub, "secret": secret}))
+}
+
+// UpdateWebhook replaces a subscription's endpoint, events, description and
+// whether it is active. The secret is kept.
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid webhook ID"))
+		return
+	}
+	input, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	before, err := h.Repo.GetSubscription(ctx, id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Webhook not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update webhook"))
+		return
+	}
+	sub, err := h.Repo.UpdateSubscription(ctx, id, bson.M{
+		"url":         input.URL,
+		"events":      input.Events,
+		"description": input.Description,
+		"active":      input.Active == nil || *input.Active,
+	})
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Webhook not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to update webhook"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditWebhookUpdated,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+		Before:     gin.H{"url": before.URL, "events": before.Events, "active": before.Active},
+		After:      gin.H{"url": sub.URL, "events": sub.Events, "active": sub.Active},
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Webhook updated", gin.H{"webhook": sub}))
+}
+
+// DeleteWebhook unsubscribes an endpoint. Its deliveries stay in the log.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid webhook ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err = h.Repo.DeleteSubscription(ctx, id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Webhook not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to delete webhook"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditWebhookDeleted,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   id,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Webhook deleted", nil))
+}
+
+// RotateWebhookSecret gives a subscription a new signing secret, returned
+// once. Deliveries are signed with it straight away.
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid webhook ID"))
+		return
+	}
+	secret, err := utils.GenerateSecureToken(webhookSecretBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to rotate webhook secret"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.Repo.UpdateSubscription(ctx, id, bson.M{"secret": secret})
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Webhook not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to rotate webhook secret"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditWebhookSecretRotated,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Webhook secret rotated", gin.H{"webhook": sub, "secret": secret}))
+}
+
+// ListWebhookDeliveries returns a subscription's deliveries, newest first.
+// Filter by status or event type.
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid webhook ID"))
+		return
+	}
+	page, limit := pageParams(c, 50, 200)
+
+	filter := bson.M{"subscriptionId": id}
+	for param, field := range map[string]string{"status": "status", "event": "eventType"} {
+		if v := c.Query(param); v != "" {
+			filter[field] = v
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	deliveries, total, err := h.Repo.ListDeliveries(ctx, filter, int64(limit), int64((page-1)*limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to fetch webhook deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.SuccessResponse("Webhook deliveries fetched", gin.H{
+		"deliveries": deliveries,
+		"meta":       models.NewPageMeta(total, page, limit),
+	}))
+}
+
+// ReplayWebhookDelivery sends a delivery's payload to its subscription again,
+// once, signed with the current secret, and logs it as a new delivery. The
+// event ID in the payload is unchanged, so receivers can drop duplicates.
+func (h *WebhookHandler) ReplayWebhookDelivery(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid webhook ID"))
+		return
+	}
+	deliveryID, err := primitive.ObjectIDFromHex(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid delivery ID"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
+	defer cancel()
+
+	original, err := h.Repo.GetDelivery(ctx, deliveryID)
+	if errors.Is(err, repository.ErrWebhookDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Delivery not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to replay delivery"))
+		return
+	}
+	if original.SubscriptionID != id {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Delivery not found"))
+		return
+	}
+	sub, err := h.Repo.GetSubscription(ctx, id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Webhook not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to replay delivery"))
+		return
+	}
+
+	replay := models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		ReplayOf:       &original.ID,
+	}
+	h.deliver(ctx, *sub, &replay, 0)
+	if replay.ID.IsZero() {
+		c.JSON(http.StatusInternalServerError, utils.ErrorResponse("Failed to replay delivery"))
+		return
+	}
+
+	recordAudit(h.AuditRepo, c, models.AuditLog{
+		Action:     models.AuditWebhookReplayed,
+		TargetType: models.AuditTargetWebhook,
+		TargetID:   sub.ID,
+		After:      gin.H{"deliveryId": replay.ID, "replayOf": original.ID, "status": replay.Status},
+	})
+	c.JSON(http.StatusOK, utils.SuccessResponse("Delivery replayed", gin.H{"delivery": replay}))
+}
+
+// bindWebhook reads and checks a subscription, answering the request if it
+// is invalid.
+func bindWebhook(c *gin.Context) (models.WebhookSubscriptionInput, bool) {
+	var input models.WebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorResponse("Invalid request body"))
+		return input, false
+	}
+	input.URL = strings.TrimSpace(input.URL)
+	input.Description = strings.TrimSpace(input.Description)
+	if err := validate.Struct(input); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ValidationErrorResponse(err))
+		return input, false
+	}
+	for _, eventType := range input.Events {
+		if !knownWebhookEvent(eventType) {
+			c.JSON(http.StatusBadRequest, utils.ErrorResponse("Unknown event type: "+eventType))
+			return input, false
+		}
+	}
+	return input, true
+}
+
+func knownWebhookEvent(eventType string) bool {
+	if eventType == models.WebhookAllEvents {
+		return true
+	}
+	for _, t := range events.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
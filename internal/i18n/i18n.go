@@ -0,0 +1,188 @@
+// Package i18n translates API messages into the locale a client asked for.
+// Messages are written in English, the default locale; each other locale
+// has a catalog in locales/ of translated messages, messages for error
+// codes, and validation rule templates. Anything a catalog lacks is sent in
+// English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the locale messages are written in and localized content
+// falls back to.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalog is one locale's translations. Message keys are the English
+// messages, lower-cased; rule templates take {field} and {param}.
+type catalog struct {
+	Messages map[string]string `json:"messages"`
+	Codes    map[string]string `json:"codes"`
+	Rules    map[string]string `json:"rules"`
+}
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]catalog {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := map[string]catalog{}
+	for _, f := range files {
+		raw, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var cat catalog
+		if err := json.Unmarshal(raw, &cat); err != nil {
+			panic("i18n: " + f.Name() + ": " + err.Error())
+		}
+		messages := make(map[string]string, len(cat.Messages))
+		for english, translated := range cat.Messages {
+			messages[strings.ToLower(english)] = translated
+		}
+		cat.Messages = messages
+		catalogs[strings.TrimSuffix(f.Name(), ".json")] = cat
+	}
+	return catalogs
+}
+
+// Supported returns the locales messages can be sent in, the default first.
+func Supported() []string {
+	locales := []string{DefaultLocale}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// IsSupported reports whether messages can be sent in locale.
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok || locale == DefaultLocale
+}
+
+// Negotiate picks the supported locale an Accept-Language header prefers,
+// matching regional tags such as fr-CA by their language. It returns the
+// default locale when none is supported.
+func Negotiate(acceptLanguage string) string {
+	type tag struct {
+		locale string
+		q      float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if locale == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{strings.ToLower(locale), q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if locale := Match(t.locale); locale != "" {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Match returns the supported locale for a language tag, exactly or by its
+// language, or "" if there is none.
+func Match(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if IsSupported(tag) {
+		return tag
+	}
+	if language, _, ok := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-"); ok && IsSupported(language) {
+		return language
+	}
+	return ""
+}
+
+type localeKey struct{}
+
+// WithLocale returns ctx carrying the locale a request is answered in.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the locale a request is answered in, or the default.
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Translate returns message in locale, or message itself if the catalog
+// doesn't have it.
+func Translate(locale, message string) string {
+	if translated, ok := catalogs[locale].Messages[strings.ToLower(message)]; ok {
+		return translated
+	}
+	return message
+}
+
+// CodeMessage returns the message for an error code in locale, or "".
+func CodeMessage(locale, code string) string {
+	return catalogs[locale].Codes[code]
+}
+
+// ruleAliases are validation rules that share another rule's message.
+var ruleAliases = map[string]string{
+	"required_if":      "required",
+	"required_unless":  "required",
+	"required_with":    "required",
+	"required_without": "required",
+	"http_url":         "url",
+	"gte":              "min",
+	"lte":              "max",
+	"number":           "numeric",
+}
+
+// RuleMessage returns the message for a field that broke a validation rule
+// in locale. sized says the field is a string or collection, whose min and
+// max limit its length. Rules the catalog lacks get its "invalid" message.
+func RuleMessage(locale, field, rule, param string, sized bool) string {
+	cat, ok := catalogs[locale]
+	if !ok {
+		return ""
+	}
+	if alias, ok := ruleAliases[rule]; ok {
+		rule = alias
+	}
+	template, ok := cat.Rules[rule]
+	if sized {
+		if lengthTemplate, found := cat.Rules[rule+"_length"]; found {
+			template, ok = lengthTemplate, true
+		}
+	}
+	if !ok {
+		template = cat.Rules["invalid"]
+	}
+	if rule == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(template)
+}
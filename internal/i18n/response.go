@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TranslateBody translates the messages in a JSON response body into
+// locale: its "message" and "error", and the message of each field in
+// "fields" that failed validation. An error the catalog doesn't know is
+// replaced by the message for its "code", if there is one. Bodies that
+// aren't JSON objects are returned as they are.
+func TranslateBody(locale string, body []byte) []byte {
+	if _, ok := catalogs[locale]; !ok {
+		return body
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil || fields == nil {
+		return body
+	}
+
+	changed := false
+	set := func(key, value string) {
+		if raw, err := json.Marshal(value); err == nil {
+			fields[key] = raw
+			changed = true
+		}
+	}
+
+	if msg, ok := jsonString(fields["message"]); ok && msg != "" {
+		if translated := Translate(locale, msg); translated != msg {
+			set("message", translated)
+		}
+	}
+	if msg, ok := jsonString(fields["error"]); ok && msg != "" {
+		translated := Translate(locale, msg)
+		if translated == msg {
+			if code, _ := jsonString(fields["code"]); CodeMessage(locale, code) != "" {
+				translated = CodeMessage(locale, code)
+			}
+		}
+		if translated != msg {
+			set("error", translated)
+		}
+	}
+	if raw, ok := fields["fields"]; ok {
+		if translated, ok := translateFieldErrors(locale, raw); ok {
+			fields["fields"] = translated
+			changed = true
+		}
+	}
+
+	if !changed {
+		return body
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// fieldError is the part of utils.FieldError a translation needs; other
+// keys are kept as they are.
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+func translateFieldErrors(locale string, raw json.RawMessage) (json.RawMessage, bool) {
+	var list []map[string]json.RawMessage
+	if json.Unmarshal(raw, &list) != nil || len(list) == 0 {
+		return nil, false
+	}
+	for _, item := range list {
+		var fe fieldError
+		encoded, _ := json.Marshal(item)
+		if json.Unmarshal(encoded, &fe) != nil || fe.Field == "" || fe.Rule == "" {
+			continue
+		}
+		// The English message says whether min and max counted characters
+		// or items rather than a value
+		sized := strings.Contains(fe.Message, "characters or items")
+		if msg := RuleMessage(locale, fe.Field, fe.Rule, fe.Param, sized); msg != "" {
+			item["message"], _ = json.Marshal(msg)
+		}
+	}
+	out, err := json.Marshal(list)
+	return out, err == nil
+}
+
+func jsonString(raw json.RawMessage) (string, bool) {
+	var s string
+	if raw == nil || json.Unmarshal(raw, &s) != nil {
+		return "", false
+	}
+	return s, true
+}
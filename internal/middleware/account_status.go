@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/utils"
+)
+
+// AccountState is the live state of a user account, which may have changed
+// since their token was issued.
+type AccountState struct {
+	Status string
+	Role   string
+}
+
+// ErrAccountNotFound is what an AccountStateLookup returns for a user that
+// no longer exists, such as one purged from the trash.
+var ErrAccountNotFound = errors.New("account not found")
+
+// AccountStateLookup loads a user's current account state by ID.
+type AccountStateLookup func(ctx context.Context, userID string) (AccountState, error)
+
+var (
+	accountStateLookup AccountStateLookup
+	accountStateCache  = utils.NewTTLCache[AccountState](30 * time.Second)
+)
+
+// SetAccountStateLookup installs the lookup AuthMiddleware uses to reject
+// suspended or banned users and to pick up role changes. Without one, the
+// token's claims are trusted as-is.
+func SetAccountStateLookup(fn AccountStateLookup) {
+	accountStateLookup = fn
+}
+
+// InvalidateAccountState drops a cached account state so a status or role
+// change takes effect on the user's next request.
+func InvalidateAccountState(userID string) {
+	accountStateCache.Delete(userID)
+}
+
+func loadAccountState(ctx context.Context, userID string) (AccountState, bool, error) {
+	if accountStateLookup == nil {
+		return AccountState{}, false, nil
+	}
+	if state, ok := accountStateCache.Get(userID); ok {
+		return state, true, nil
+	}
+	state, err := accountStateLookup(ctx, userID)
+	if err != nil {
+		return AccountState{}, false, err
+	}
+	accountStateCache.Set(userID, state)
+	return state, true, nil
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// API versions. Both serve the same routes: /api/v2/... is answered by the
+// handler for /api/v1/..., with every JSON response put in the v2 envelope
+// (see utils.EnvelopeV2). v1 keeps the shapes each handler has always
+// returned until it is retired.
+const (
+	APIVersionHeader = "API-Version"
+
+	apiV1Prefix = "/api/v1"
+	apiV2Prefix = "/api/v2"
+)
+
+type apiVersionKey struct{}
+
+// VersionedAPI routes /api/v2 requests to the v1 routes, noting the version
+// asked for so APIVersion can answer in its envelope. It wraps the router
+// because the path has to change before gin picks a route.
+func VersionedAPI(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest, ok := strings.CutPrefix(r.URL.Path, apiV2Prefix); ok && (rest == "" || rest[0] == '/') {
+			r = r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, 2))
+			u := *r.URL
+			u.Path = apiV1Prefix + rest
+			if u.RawPath != "" {
+				u.RawPath = apiV1Prefix + strings.TrimPrefix(u.RawPath, apiV2Prefix)
+			}
+			r.URL = &u
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIVersionFrom returns the API version a request was made to: 2 for
+// /api/v2, otherwise 1.
+func APIVersionFrom(ctx context.Context) int {
+	if v, ok := ctx.Value(apiVersionKey{}).(int); ok {
+		return v
+	}
+	return 1
+}
+
+// APIVersion answers API requests for the version they were made to. v2
+// responses are rewritten into the v2 envelope. v1 responses point at their
+// v2 successor and, once v1's retirement is scheduled, carry Deprecation and
+// Sunset headers; after the sunset v1 answers 410 Gone. Register it before
+// any middleware that can reject a request, so rejections are enveloped too.
+func APIVersion(cfg config.APIConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path != apiV1Prefix && !strings.HasPrefix(path, apiV1Prefix+"/") {
+			c.Next()
+			return
+		}
+
+		if APIVersionFrom(c.Request.Context()) == 2 {
+			c.Header(APIVersionHeader, "2")
+			envelopeV2(c)
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set(APIVersionHeader, "1")
+		header.Add("Link", `<`+apiV2Prefix+strings.TrimPrefix(path, apiV1Prefix)+`>; rel="successor-version"`)
+		if !cfg.V1DeprecatedAt.IsZero() {
+			header.Set("Deprecation", "@"+strconv.FormatInt(cfg.V1DeprecatedAt.Unix(), 10))
+		}
+		if !cfg.V1Sunset.IsZero() {
+			header.Set("Sunset", cfg.V1Sunset.UTC().Format(http.TimeFormat))
+			if time.Now().After(cfg.V1Sunset) {
+				c.AbortWithStatusJSON(http.StatusGone, utils.CodedErrorResponse(utils.CodeAPIVersionRetired, "API v1 has been retired; use "+apiV2Prefix))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// envelopeV2 runs the rest of the chain and sends its JSON response in the
+// v2 envelope. Streams and upgraded connections are left alone, as are
+// responses that aren't JSON, such as exports and redirects.
+func envelopeV2(c *gin.Context) {
+	if c.Request.Header.Get("Upgrade") != "" || strings.Contains(c.Request.Header.Get("Accept"), "text/event-stream") {
+		c.Next()
+		return
+	}
+
+	original := c.Writer
+	w := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+	c.Writer = w
+	c.Next()
+	c.Writer = original
+
+	status, body := w.status, w.body.Bytes()
+	if len(body) > 0 && strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+		envelope := utils.ToEnvelopeV2(status, body)
+		if enveloped, err := json.Marshal(envelope); err == nil {
+			body = enveloped
+			original.Header().Del("Content-Length")
+			if envelope.Error != nil {
+				// Failures some v1 handlers report with a success status
+				status = envelope.Error.Status
+			}
+		}
+	}
+	original.WriteHeader(status)
+	if len(body) > 0 {
+		original.Write(body)
+	} else {
+		original.WriteHeaderNow()
+	}
+}
@@ -1,25 +1,28 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/developia-II/ecommerce-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Authorization header is required"))
+			c.JSON(http.StatusUnauthorized, utils.CodedErrorResponse(utils.CodeUnauthorized, "Authorization header is required"))
 			c.Abort()
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Authorization header must be Bearer token"))
+			c.JSON(http.StatusUnauthorized, utils.CodedErrorResponse(utils.CodeUnauthorized, "Authorization header must be Bearer token"))
 			c.Abort()
 			return
 		}
@@ -28,14 +31,88 @@ func AuthMiddleware() gin.HandlerFunc {
 		claims, err := utils.VerifyToken(tokenString)
 		if err != nil {
 			// Return 401 Unauthorized for token errors to trigger frontend refresh
-			c.JSON(http.StatusUnauthorized, utils.ErrorResponse(err.Error()))
+			c.JSON(http.StatusUnauthorized, utils.CodedErrorResponse(utils.CodeUnauthorized, err.Error()))
 			c.Abort()
 			return
 		}
 
+		// Reject suspended or banned accounts, and prefer the current role over
+		// the one baked into the token. If the account can't be checked, the
+		// token alone isn't enough.
+		role := claims.Role
+		state, ok, err := loadAccountState(c.Request.Context(), claims.UserID)
+		if errors.Is(err, ErrAccountNotFound) {
+			c.JSON(http.StatusUnauthorized, utils.CodedErrorResponse(utils.CodeUnauthorized, "This account no longer exists"))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("userId", claims.UserID).Error("Failed to load account state")
+			c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("Unable to verify your account right now; please try again"))
+			c.Abort()
+			return
+		}
+		if ok {
+			switch state.Status {
+			case models.AccountStatusBanned:
+				c.JSON(http.StatusForbidden, utils.CodedErrorResponse(utils.CodeAccountBanned, "Your account has been banned"))
+				c.Abort()
+				return
+			case models.AccountStatusSuspended:
+				c.JSON(http.StatusForbidden, utils.CodedErrorResponse(utils.CodeAccountSuspended, "Your account is suspended"))
+				c.Abort()
+				return
+			case models.AccountStatusDeleted:
+				c.JSON(http.StatusUnauthorized, utils.CodedErrorResponse(utils.CodeUnauthorized, "This account has been deleted"))
+				c.Abort()
+				return
+			}
+			if state.Role != "" {
+				role = state.Role
+			}
+		}
+
 		// Store claims in context
 		c.Set("userId", claims.UserID)
-		c.Set("role", claims.Role)
+		c.Set("role", role)
+
+		if claims.ImpersonatorID != "" {
+			if !handleImpersonation(c, claims, role) {
+				return
+			}
+			c.Next()
+			recordImpersonatedRequest(c, claims)
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuth authenticates requests that carry a token, as
+// AuthMiddleware does, and lets requests without one through anonymously,
+// for routes that serve both signed-in and signed-out users.
+func OptionalAuth() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// TokenFromQuery lets a route take the access token as a query parameter,
+// for clients such as EventSource that can't set headers. Use it only in
+// front of AuthMiddleware on streaming routes; tokens in URLs end up in
+// access logs.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query(param); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
 		c.Next()
 	}
 }
@@ -44,7 +121,7 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, utils.ErrorResponse("Role not found in context"))
+			c.JSON(http.StatusUnauthorized, utils.CodedErrorResponse(utils.CodeUnauthorized, "Role not found in context"))
 			c.Abort()
 			return
 		}
@@ -59,7 +136,7 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		}
 
 		if !isAllowed {
-			c.JSON(http.StatusForbidden, utils.ErrorResponse("You do not have permission to access this resource"))
+			c.JSON(http.StatusForbidden, utils.CodedErrorResponse(utils.CodeForbidden, "You do not have permission to access this resource"))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipMinSize is the smallest response worth compressing; below it the
+// gzip header and CPU cost more than they save.
+const gzipMinSize = 1 << 10
+
+// compressibleTypes are the content types gzip helps. Images, PDFs and
+// archives are already compressed.
+var compressibleTypes = []string{
+	"application/json", "application/xml", "application/javascript",
+	"text/", "image/svg+xml",
+}
+
+// Gzip compresses responses for clients that accept it. Streams and
+// upgraded connections (the live gateway) are left alone.
+func Gzip(level int) gin.HandlerFunc {
+	pool := sync.Pool{New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(nil, level)
+		return gz
+	}}
+
+	return func(c *gin.Context) {
+		req := c.Request
+		if req.Method == http.MethodHead ||
+			!strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") ||
+			req.Header.Get("Upgrade") != "" ||
+			strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		w := &gzipWriter{ResponseWriter: c.Writer, pool: &pool}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// gzipWriter holds back the start of the response until it knows whether
+// compressing it is worthwhile.
+type gzipWriter struct {
+	gin.ResponseWriter
+	pool    *sync.Pool
+	gz      *gzip.Writer
+	held    []byte
+	decided bool
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if len(w.held)+len(b) < gzipMinSize {
+			w.held = append(w.held, b...)
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide starts compressing if the response is a compressible type that
+// isn't already encoded, then writes out what was held back.
+func (w *gzipWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	if header.Get("Content-Encoding") == "" && status >= http.StatusOK &&
+		status != http.StatusNoContent && status != http.StatusNotModified &&
+		isCompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	held := w.held
+	w.held = nil
+	if len(held) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(held)
+	} else {
+		_, err = w.ResponseWriter.Write(held)
+	}
+	return err
+}
+
+// finish sends a response too small to compress as it is, or completes the
+// compressed one.
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		w.decided = true
+		if len(w.held) > 0 {
+			w.ResponseWriter.Write(w.held)
+		}
+		return
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		w.gz.Reset(nil)
+		w.pool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func isCompressible(contentType string) bool {
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
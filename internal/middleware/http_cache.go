@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// representation is a response body this server has handed out for a URL,
+// and when it first did.
+type representation struct {
+	ETag  string
+	Since time.Time
+}
+
+// firstServed remembers when each public URL last changed, which is its
+// Last-Modified. Responses are built from several collections, so there's
+// no single updatedAt to use instead.
+var firstServed = utils.NewTTLCache[representation](24 * time.Hour)
+
+// HTTPCache adds ETag and Last-Modified validators to successful GET
+// responses, answering conditional requests for unchanged content with 304
+// Not Modified, and lets browsers and CDNs reuse responses for maxAge.
+// Requests made with a token are cached by the browser only.
+func HTTPCache(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = original
+
+		if w.status != http.StatusOK {
+			original.WriteHeader(w.status)
+			original.Write(w.body.Bytes())
+			return
+		}
+
+		// Localize translates the body after this, so the locale is hashed
+		// too: each locale is a representation of its own
+		locale := i18n.FromContext(c.Request.Context())
+		hash := sha256.New()
+		hash.Write([]byte(locale + " "))
+		hash.Write(w.body.Bytes())
+		sum := hash.Sum(nil)
+		// Weak, as the body may be gzipped on the way out
+		etag := `W/"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+		key := locale + " " + c.Request.URL.RequestURI()
+		seen, ok := firstServed.Get(key)
+		if !ok || seen.ETag != etag {
+			seen = representation{ETag: etag, Since: time.Now().UTC().Truncate(time.Second)}
+			firstServed.Set(key, seen)
+		}
+
+		header := original.Header()
+		header.Set("ETag", etag)
+		header.Set("Last-Modified", seen.Since.Format(http.TimeFormat))
+		scope := "public"
+		if c.GetHeader("Authorization") != "" {
+			scope = "private"
+		}
+		header.Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(maxAge.Seconds())))
+
+		if notModified(c.Request, etag, seen.Since) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		original.WriteHeader(http.StatusOK)
+		original.Write(w.body.Bytes())
+	}
+}
+
+// notModified applies the request's conditions. If-None-Match wins over
+// If-Modified-Since when both are sent.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(req.Header.Get("If-Modified-Since")); err == nil {
+		return !lastModified.After(since)
+	}
+	return false
+}
+
+// bufferedWriter holds a response so it can be checked before it is sent.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// IdempotencyKeyHeader names the header clients send a retry-safe key in.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyTTL is how long a response is replayed for
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyLockTimeout is how long a request may hold its key before
+	// it is taken to have died with the server that was handling it
+	idempotencyLockTimeout = 2 * time.Minute
+	// maxIdempotentBody caps the request bodies read for hashing
+	maxIdempotentBody = 1 << 20
+)
+
+// IdempotencyStore keeps requests made with an Idempotency-Key and their
+// responses.
+type IdempotencyStore interface {
+	// Begin claims the key, or returns the record that already holds it
+	Begin(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error
+	Release(ctx context.Context, id primitive.ObjectID) error
+}
+
+// Idempotency makes a route safe to retry: a request repeated with the same
+// Idempotency-Key header gets the first response replayed instead of running
+// again. Keys belong to the signed-in user, so it must follow
+// AuthMiddleware. Requests without the header run as usual.
+//
+// Server errors release the key so the retry can run. Reusing a key for a
+// different request is refused with 422, and retrying one that is still
+// running with 409.
+func Idempotency(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, utils.ErrorResponse("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+		userID := c.GetString("userId")
+		if userID == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, utils.ErrorResponse("Failed to read request body"))
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, utils.ErrorResponse("Request body too large"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &models.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.Path, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyTTL),
+		}
+		existing, err := store.Begin(c.Request.Context(), record)
+		if err == nil && existing != nil && !existing.Completed && now.Sub(existing.CreatedAt) > idempotencyLockTimeout {
+			// The request holding the key never finished
+			if err = store.Release(c.Request.Context(), existing.ID); err == nil {
+				existing, err = store.Begin(c.Request.Context(), record)
+			}
+		}
+		if err != nil {
+			logrus.WithError(err).Error("Failed to claim idempotency key")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, utils.ErrorResponse("Unable to process the request right now; retry with the same Idempotency-Key"))
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, utils.ErrorResponse("Idempotency-Key has already been used for a different request"))
+			case !existing.Completed:
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusConflict, utils.ErrorResponse("A request with this Idempotency-Key is still being processed"))
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		original := c.Writer
+		w := &recordingWriter{ResponseWriter: original}
+		c.Writer = w
+		completed := false
+		defer func() {
+			c.Writer = original
+			// A background context, as the client may already have gone
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if completed {
+				return
+			}
+			if err := store.Release(ctx, record.ID); err != nil {
+				logrus.WithError(err).Error("Failed to release idempotency key")
+			}
+		}()
+
+		c.Next()
+
+		if status := w.Status(); status < http.StatusInternalServerError {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := store.Complete(ctx, record.ID, status, w.Header().Get("Content-Type"), w.body.Bytes()); err != nil {
+				logrus.WithError(err).Error("Failed to save idempotent response")
+				return
+			}
+			completed = true
+		}
+	}
+}
+
+// IdempotencyKey is the request's Idempotency-Key scoped to the user, for
+// passing on to payment providers that dedupe on their side too. It is
+// empty if the client didn't send one.
+func IdempotencyKey(c *gin.Context) string {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.GetString("userId") + "|" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter passes a response through while keeping a copy of the
+// body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonatedRequest describes one request made with an impersonation token.
+type ImpersonatedRequest struct {
+	ImpersonatorID string
+	UserID         string
+	Method         string
+	Path           string
+	Status         int
+	IP             string
+	At             time.Time
+}
+
+// ImpersonationRecorder persists impersonated requests, typically to the
+// audit log.
+type ImpersonationRecorder func(req ImpersonatedRequest)
+
+var impersonationRecorder ImpersonationRecorder
+
+// SetImpersonationRecorder installs the function AuthMiddleware calls after
+// every request made with an impersonation token.
+func SetImpersonationRecorder(fn ImpersonationRecorder) {
+	impersonationRecorder = fn
+}
+
+// handleImpersonation marks the request as impersonated and blocks anything
+// that isn't a read. The token stops working if the impersonator is no longer
+// an active admin, or can't be checked, and admin accounts can never be
+// impersonated. It returns false if the request was rejected.
+func handleImpersonation(c *gin.Context, claims *utils.JWTClaims, role string) bool {
+	c.Set("impersonatorId", claims.ImpersonatorID)
+	c.Header("X-Impersonating", "true")
+
+	admin, ok, err := loadAccountState(c.Request.Context(), claims.ImpersonatorID)
+	if role == "admin" || err != nil || (ok && (admin.Role != "admin" || admin.Status != models.AccountStatusActive)) {
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("Impersonation is no longer permitted"))
+		c.Abort()
+		recordImpersonatedRequest(c, claims)
+		return false
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		c.JSON(http.StatusForbidden, utils.ErrorResponse("This action is not allowed while impersonating a user"))
+		c.Abort()
+		recordImpersonatedRequest(c, claims)
+		return false
+	}
+	return true
+}
+
+func recordImpersonatedRequest(c *gin.Context, claims *utils.JWTClaims) {
+	if impersonationRecorder == nil {
+		return
+	}
+	req := ImpersonatedRequest{
+		ImpersonatorID: claims.ImpersonatorID,
+		UserID:         claims.UserID,
+		Method:         c.Request.Method,
+		Path:           c.Request.URL.Path,
+		Status:         c.Writer.Status(),
+		IP:             c.ClientIP(),
+		At:             time.Now(),
+	}
+	go impersonationRecorder(req)
+}
+
+// IsImpersonating reports whether the current request uses an impersonation
+// token.
+func IsImpersonating(c *gin.Context) bool {
+	_, ok := c.Get("impersonatorId")
+	return ok
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Localize answers each request in the locale its Accept-Language prefers,
+// out of those i18n supports. Handlers find the locale with
+// i18n.FromContext, for content such as product names; the messages in
+// JSON responses are translated here. Register it after APIVersion, so v2
+// responses are translated before they are enveloped.
+func Localize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+
+		header := c.Writer.Header()
+		header.Set("Content-Language", locale)
+		header.Add("Vary", "Accept-Language")
+
+		if locale == i18n.DefaultLocale || c.Request.Header.Get("Upgrade") != "" || strings.Contains(c.Request.Header.Get("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = original
+
+		body := w.body.Bytes()
+		if len(body) > 0 && strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			body = i18n.TranslateBody(locale, body)
+			original.Header().Del("Content-Length")
+		}
+		original.WriteHeader(w.status)
+		if len(body) > 0 {
+			original.Write(body)
+		} else {
+			original.WriteHeaderNow()
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy allows each client Limit requests per Window on the
+// routes it guards. Signed-in clients are counted by user, everyone else by
+// IP address.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	ByIP   bool // Count by address even when signed in
+}
+
+// Default policies. Each can be overridden with RATE_LIMIT_<NAME>, e.g.
+// RATE_LIMIT_SEARCH=120/1m.
+var (
+	RateLimitGlobal = RateLimitPolicy{Name: "global", Limit: 600, Window: time.Minute, ByIP: true}
+	RateLimitAuth   = RateLimitPolicy{Name: "auth", Limit: 10, Window: time.Minute, ByIP: true}
+	RateLimitSearch = RateLimitPolicy{Name: "search", Limit: 60, Window: time.Minute}
+	RateLimitCart   = RateLimitPolicy{Name: "cart", Limit: 60, Window: time.Minute}
+	RateLimitReview = RateLimitPolicy{Name: "review", Limit: 10, Window: time.Hour}
+
+	RateLimitPolicies = []RateLimitPolicy{RateLimitGlobal, RateLimitAuth, RateLimitSearch, RateLimitCart, RateLimitReview}
+)
+
+// maxOffenders caps how many rate-limited clients are remembered for admins.
+const maxOffenders = 1000
+
+// Offender is a client that has hit rate limits, for admins deciding whom to
+// block. Counts are for this server instance since it started or the client
+// was last forgotten.
+type Offender struct {
+	Key           string         `json:"key"`
+	Limited       int            `json:"limited"`
+	Policies      map[string]int `json:"policies"`
+	LastPath      string         `json:"lastPath"`
+	LastLimitedAt time.Time      `json:"lastLimitedAt"`
+}
+
+// rateWindow counts a client's requests under one policy. The estimate
+// weights the previous window by how much of it still overlaps the sliding
+// window ending now.
+type rateWindow struct {
+	start    time.Time
+	count    int
+	previous int
+}
+
+// RateLimiter enforces rate limit policies and admin blocks. Counts are kept
+// in memory per instance; blocks are loaded from the database.
+type RateLimiter struct {
+	mu        sync.Mutex
+	overrides map[string]config.RateLimit
+	windows   map[string]*rateWindow // <policy>|<client key>
+	offenders map[string]*Offender
+	blocks    map[string]models.ClientBlock
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewRateLimiter(overrides map[string]config.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		overrides: overrides,
+		windows:   make(map[string]*rateWindow),
+		offenders: make(map[string]*Offender),
+		blocks:    make(map[string]models.ClientBlock),
+		now:       time.Now,
+	}
+}
+
+// Policy is p with any configured override applied.
+func (l *RateLimiter) Policy(p RateLimitPolicy) RateLimitPolicy {
+	if o, ok := l.overrides[p.Name]; ok {
+		p.Limit, p.Window = o.Requests, o.Window
+	}
+	return p
+}
+
+// Limit guards routes with the policy, refusing blocked clients with 403
+// and clients over the limit with 429 and Retry-After.
+func (l *RateLimiter) Limit(policy RateLimitPolicy) gin.HandlerFunc {
+	p := l.Policy(policy)
+	return func(c *gin.Context) {
+		ipKey := models.ClientKeyIP + c.ClientIP()
+		key := ipKey
+		userKey := ""
+		if id, ok := c.Get("userId"); ok {
+			if s, ok := id.(string); ok && s != "" {
+				userKey = models.ClientKeyUser + s
+				if !p.ByIP {
+					key = userKey
+				}
+			}
+		}
+
+		if l.Blocked(ipKey) || (userKey != "" && l.Blocked(userKey)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.CodedErrorResponse(utils.CodeForbidden, "Access to this service has been blocked"))
+			return
+		}
+
+		allowed, remaining, retryAfter := l.take(p, key)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(p.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			l.recordOffence(key, p.Name, loggedPath(c))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, utils.CodedErrorResponse(utils.CodeRateLimited, fmt.Sprintf("Too many requests; try again in %d seconds", seconds)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// take counts a request from key, reporting whether it is allowed, how many
+// more are, and when to retry if it isn't.
+func (l *RateLimiter) take(p RateLimitPolicy, key string) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	id := p.Name + "|" + key
+	w, ok := l.windows[id]
+	if !ok {
+		w = &rateWindow{start: now}
+		l.windows[id] = w
+	}
+	if elapsed := now.Sub(w.start); elapsed >= p.Window {
+		// Roll forward; a window more than one behind left nothing to carry
+		w.previous = 0
+		if elapsed < 2*p.Window {
+			w.previous = w.count
+		}
+		w.start = w.start.Add(elapsed / p.Window * p.Window)
+		w.count = 0
+	}
+
+	overlap := 1 - float64(now.Sub(w.start))/float64(p.Window)
+	estimate := float64(w.previous)*overlap + float64(w.count)
+	if estimate+1 > float64(p.Limit) {
+		// Wait for enough of the previous window to slide out, or for this
+		// one to end
+		retry := w.start.Add(p.Window).Sub(now)
+		if w.previous > 0 {
+			needed := (estimate + 1 - float64(p.Limit)) / float64(w.previous)
+			if wait := time.Duration(needed * float64(p.Window)); wait < retry {
+				retry = wait
+			}
+		}
+		if retry < time.Second {
+			retry = time.Second
+		}
+		return false, 0, retry
+	}
+	w.count++
+	return true, p.Limit - int(math.Ceil(estimate+1)), 0
+}
+
+// sweep drops counts and offenders nobody has touched for a while.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for id, w := range l.windows {
+		// No policy window is longer than a day
+		if now.Sub(w.start) > 48*time.Hour {
+			delete(l.windows, id)
+		}
+	}
+	for key, o := range l.offenders {
+		if now.Sub(o.LastLimitedAt) > 24*time.Hour {
+			delete(l.offenders, key)
+		}
+	}
+	for key, b := range l.blocks {
+		if !b.ActiveAt(now) {
+			delete(l.blocks, key)
+		}
+	}
+}
+
+func (l *RateLimiter) recordOffence(key, policy, path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	o, ok := l.offenders[key]
+	if !ok {
+		if len(l.offenders) >= maxOffenders {
+			return
+		}
+		o = &Offender{Key: key, Policies: map[string]int{}}
+		l.offenders[key] = o
+	}
+	o.Limited++
+	o.Policies[policy]++
+	o.LastPath = path
+	o.LastLimitedAt = l.now()
+}
+
+// Offenders lists the clients refused most often, up to limit.
+func (l *RateLimiter) Offenders(limit int) []Offender {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	offenders := make([]Offender, 0, len(l.offenders))
+	for _, o := range l.offenders {
+		copied := *o
+		copied.Policies = make(map[string]int, len(o.Policies))
+		for k, v := range o.Policies {
+			copied.Policies[k] = v
+		}
+		offenders = append(offenders, copied)
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].Limited != offenders[j].Limited {
+			return offenders[i].Limited > offenders[j].Limited
+		}
+		return offenders[i].LastLimitedAt.After(offenders[j].LastLimitedAt)
+	})
+	if len(offenders) > limit {
+		offenders = offenders[:limit]
+	}
+	return offenders
+}
+
+// Blocked reports whether the client key is blocked now.
+func (l *RateLimiter) Blocked(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.blocks[key]
+	return ok && b.ActiveAt(l.now())
+}
+
+// SetBlocks replaces the blocks in force, as loaded from the database.
+func (l *RateLimiter) SetBlocks(blocks []models.ClientBlock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blocks = make(map[string]models.ClientBlock, len(blocks))
+	for _, b := range blocks {
+		l.blocks[b.Key] = b
+	}
+}
+
+// Block puts a block in force on this instance straight away; others pick
+// it up on their next refresh.
+func (l *RateLimiter) Block(block models.ClientBlock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blocks[block.Key] = block
+	delete(l.offenders, block.Key)
+}
+
+func (l *RateLimiter) Unblock(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.blocks, key)
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries a request's ID in and out, so a client or proxy
+// can quote it when reporting a problem.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID gives every request an ID, reusing one a proxy in front has
+// already assigned, and returns it in the X-Request-ID response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set("requestId", id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestIDFrom returns the ID of the request ctx belongs to, if any.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Log is a logger for the request, tagged with its ID and the signed-in
+// user so every line it writes can be traced back to it.
+func Log(c *gin.Context) *logrus.Entry {
+	fields := logrus.Fields{}
+	if id := c.GetString("requestId"); id != "" {
+		fields["requestId"] = id
+	}
+	if userID := c.GetString("userId"); userID != "" {
+		fields["userId"] = userID
+	}
+	if traceID := telemetry.TraceID(c.Request.Context()); traceID != "" {
+		fields["traceId"] = traceID
+	}
+	return logrus.WithFields(fields)
+}
+
+// LogFrom is Log for code that only has the request's context.
+func LogFrom(ctx context.Context) *logrus.Entry {
+	if id := RequestIDFrom(ctx); id != "" {
+		return logrus.WithField("requestId", id)
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// AccessLog writes one line per request once it has been handled: the
+// method, matched route, status, latency and who made it. Server errors are
+// logged as errors and client errors as warnings.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		entry := Log(c).WithFields(logrus.Fields{
+			"method":    c.Request.Method,
+			"route":     route,
+			"path":      loggedPath(c),
+			"status":    status,
+			"latencyMs": time.Since(start).Milliseconds(),
+			"bytes":     c.Writer.Size(),
+			"ip":        c.ClientIP(),
+		})
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request failed")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request rejected")
+		default:
+			entry.Info("Request handled")
+		}
+	}
+}
+
+// secretParams are route parameters that carry credentials, like the
+// signed tokens of email verification and export download links. They are
+// kept out of access logs, traces and the rate limiter's offender list, so
+// none of them can be used to replay a link.
+var secretParams = map[string]bool{"token": true}
+
+// loggedPath is the request's path with the values of secretParams
+// replaced.
+func loggedPath(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		return c.Request.URL.Path
+	}
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		if secretParams[name] {
+			segments[i] = "REDACTED"
+		} else {
+			segments[i] = strings.TrimPrefix(c.Param(name), "/")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		// Printable ASCII only, so IDs can't forge log lines
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Telemetry traces each request, continuing a trace the caller started, and
+// records its latency and status for /metrics. Handlers and repositories
+// that take the request's context add their spans beneath it.
+func Telemetry() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := telemetry.StartRequest(c.Request.Context(), c.Request.Header, c.Request.Method+" "+route,
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(loggedPath(c)),
+			semconv.ClientAddress(c.ClientIP()),
+			attribute.String("request.id", c.GetString("requestId")),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if userID := c.GetString("userId"); userID != "" {
+			span.SetAttributes(semconv.EnduserID(userID))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		telemetry.ObserveRequest(c.Request.Method, route, status, time.Since(start))
+	}
+}
+
+// MetricsAuth guards /metrics with a bearer token, if one is configured.
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.ErrorResponse("Invalid metrics token"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUndeliverableAddress is returned when an address can't be found, so a
+// parcel sent there would come back.
+var ErrUndeliverableAddress = errors.New("we couldn't find that address; check it and try again")
+
+// GeoPoint is where an address was found on the map.
+type GeoPoint struct {
+	Lat float64 `json:"lat" bson:"lat"`
+	Lng float64 `json:"lng" bson:"lng"`
+}
+
+// addressFormat is what a country's addresses need beyond the basics.
+type addressFormat struct {
+	Postal      *regexp.Regexp // Postal code, after normalising; nil for no check
+	NeedsPostal bool
+	NeedsState  bool
+	PostalSpace int // Put a space this many characters from the end of the postal code
+}
+
+var addressFormats = map[string]addressFormat{
+	"AU": {Postal: regexp.MustCompile(`^\d{4}$`), NeedsPostal: true, NeedsState: true},
+	"CA": {Postal: regexp.MustCompile(`^[A-Z]\d[A-Z] \d[A-Z]\d$`), NeedsPostal: true, NeedsState: true, PostalSpace: 3},
+	"DE": {Postal: regexp.MustCompile(`^\d{5}$`), NeedsPostal: true},
+	"FR": {Postal: regexp.MustCompile(`^\d{5}$`), NeedsPostal: true},
+	"GB": {Postal: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`), NeedsPostal: true, PostalSpace: 3},
+	"GH": {},
+	"IN": {Postal: regexp.MustCompile(`^\d{6}$`), NeedsPostal: true, NeedsState: true},
+	"KE": {Postal: regexp.MustCompile(`^\d{5}$`)},
+	"NG": {Postal: regexp.MustCompile(`^\d{6}$`), NeedsState: true},
+	"NL": {Postal: regexp.MustCompile(`^\d{4} [A-Z]{2}$`), NeedsPostal: true, PostalSpace: 2},
+	"US": {Postal: regexp.MustCompile(`^\d{5}(-\d{4})?$`), NeedsPostal: true, NeedsState: true},
+	"ZA": {Postal: regexp.MustCompile(`^\d{4}$`), NeedsPostal: true},
+}
+
+var addressSpaces = regexp.MustCompile(`\s+`)
+
+// Normalize tidies the address into the form carriers expect (trimmed,
+// upper-case country, state code and postal code, postal code spaced the
+// country's way) and checks it has what its country's addresses need.
+func (a *PostalAddress) Normalize() error {
+	for _, f := range []*string{&a.Name, &a.Street1, &a.Street2, &a.City, &a.State, &a.PostalCode, &a.Phone, &a.Email} {
+		*f = addressSpaces.ReplaceAllString(strings.TrimSpace(*f), " ")
+	}
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	if len(a.State) <= 3 {
+		a.State = strings.ToUpper(a.State)
+	}
+
+	if len(a.Country) != 2 || strings.Trim(a.Country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return errors.New("country must be a two-letter ISO code")
+	}
+	if a.Street1 == "" || a.City == "" {
+		return errors.New("street and city are required")
+	}
+
+	format := addressFormats[a.Country]
+	if format.NeedsState && a.State == "" {
+		return fmt.Errorf("a state or province is required for addresses in %s", a.Country)
+	}
+	if a.PostalCode == "" {
+		if format.NeedsPostal {
+			return fmt.Errorf("a postal code is required for addresses in %s", a.Country)
+		}
+		return nil
+	}
+
+	postal := strings.ToUpper(strings.ReplaceAll(a.PostalCode, " ", ""))
+	if format.PostalSpace > 0 && len(postal) > format.PostalSpace {
+		postal = postal[:len(postal)-format.PostalSpace] + " " + postal[len(postal)-format.PostalSpace:]
+	}
+	if format.Postal != nil && !format.Postal.MatchString(postal) {
+		return fmt.Errorf("%q isn't a valid postal code in %s", a.PostalCode, a.Country)
+	}
+	a.PostalCode = postal
+	return nil
+}
+
+// String is the address on one line, for display.
+func (a PostalAddress) String() string {
+	parts := []string{a.Name, a.Street1, a.Street2, a.City, strings.TrimSpace(a.State + " " + a.PostalCode), a.Country}
+	lines := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			lines = append(lines, part)
+		}
+	}
+	return strings.Join(lines, ", ")
+}
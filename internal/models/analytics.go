@@ -0,0 +1,330 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CapturedPaymentStatuses are the payment states of orders whose money was
+// taken, whether or not some of it has since been refunded.
+var CapturedPaymentStatuses = []string{"paid", "partially_refunded", "refunded"}
+
+// Platform analytics are rebuilt every night at AnalyticsHour UTC. Each run
+// recomputes the last AnalyticsRestateDays whole days, so refunds and fee
+// clawbacks on recent orders are reflected in the days they belong to.
+const (
+	AnalyticsHour        = 1
+	AnalyticsRestateDays = 30
+)
+
+// NextAnalyticsRun returns the first nightly analytics run strictly after
+// now.
+func NextAnalyticsRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), AnalyticsHour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// PlatformDay is one UTC day of platform activity. Days are precomputed
+// nightly into the platformDailyStats collection, so analytics over long
+// ranges read a row per day instead of scanning orders.
+type PlatformDay struct {
+	Day             time.Time     `bson:"_id" json:"day"`
+	GMV             Money         `bson:"gmv" json:"gmv"`                         // Totals of orders placed that day and paid for, refunded or not
+	Orders          int           `bson:"orders" json:"orders"`                   // How many of those orders there were
+	PlatformRevenue Money         `bson:"platformRevenue" json:"platformRevenue"` // Fees on that day's sales, less any returned on refunds since
+	RefundVolume    Money         `bson:"refundVolume" json:"refundVolume"`       // Refunds issued that day, whenever the order was placed
+	Refunds         int           `bson:"refunds" json:"refunds"`
+	NewVendors      int           `bson:"newVendors" json:"newVendors"` // Vendor accounts activated that day
+	Vendors         int           `bson:"vendors" json:"vendors"`       // Vendor accounts activated by the end of the day
+	Categories      []CategoryDay `bson:"categories" json:"categories"`
+	ComputedAt      time.Time     `bson:"computedAt" json:"computedAt"`
+}
+
+// CategoryDay is one category's share of a day's paid order lines.
+type CategoryDay struct {
+	CategoryID primitive.ObjectID `bson:"categoryId" json:"categoryId"` // Zero for lines ordered without a category
+	Name       string             `bson:"name" json:"name"`
+	Revenue    Money              `bson:"revenue" json:"revenue"`
+	UnitsSold  int                `bson:"unitsSold" json:"unitsSold"`
+	Orders     int                `bson:"orders" json:"orders"`
+}
+
+// PlatformPeriod is platform activity over one bucket of an analytics
+// range, or over the whole range. Rates are percentages of GMV.
+type PlatformPeriod struct {
+	Start           time.Time `json:"start"`
+	GMV             Money     `json:"gmv"`
+	Orders          int       `json:"orders"`
+	AvgOrderValue   Money     `json:"avgOrderValue"`
+	PlatformRevenue Money     `json:"platformRevenue"`
+	TakeRate        float64   `json:"takeRate"`
+	RefundVolume    Money     `json:"refundVolume"`
+	Refunds         int       `json:"refunds"`
+	RefundRate      float64   `json:"refundRate"`
+	NewVendors      int       `json:"newVendors"`
+	Vendors         int       `json:"vendors"` // At the end of the period
+}
+
+// PlatformAnalytics is platform activity over [From, To), in total and per
+// bucket of Granularity.
+type PlatformAnalytics struct {
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Granularity string           `json:"granularity"`
+	Totals      PlatformPeriod   `json:"totals"`
+	Series      []PlatformPeriod `json:"series"`
+	ComputedAt  *time.Time       `json:"computedAt"` // When the stalest day in the range was computed; nil if none has been
+}
+
+// CategoryPeriod is category performance over one bucket of an analytics
+// range.
+type CategoryPeriod struct {
+	Start      time.Time       `json:"start"`
+	Categories []CategorySales `json:"categories"`
+}
+
+// CategoryAnalytics is category performance over [From, To), best first, in
+// total and per bucket of Granularity.
+type CategoryAnalytics struct {
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Granularity string           `json:"granularity"`
+	Totals      []CategorySales  `json:"totals"`
+	Series      []CategoryPeriod `json:"series"`
+}
+
+// percentOf is part as a percentage of whole, to two places.
+func percentOf(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+func (p *PlatformPeriod) add(d PlatformDay) {
+	p.GMV += d.GMV
+	p.Orders += d.Orders
+	p.PlatformRevenue += d.PlatformRevenue
+	p.RefundVolume += d.RefundVolume
+	p.Refunds += d.Refunds
+	p.NewVendors += d.NewVendors
+	if d.Vendors > p.Vendors {
+		p.Vendors = d.Vendors
+	}
+}
+
+func (p *PlatformPeriod) finish() {
+	if p.Orders > 0 {
+		p.AvgOrderValue = p.GMV / Money(p.Orders)
+	}
+	p.TakeRate = percentOf(p.PlatformRevenue.Cents(), p.GMV.Cents())
+	p.RefundRate = percentOf(p.RefundVolume.Cents(), p.GMV.Cents())
+}
+
+// RollUpPlatformDays sums precomputed days into buckets of granularity
+// covering [from, to). Buckets with no days are zero, except that the
+// vendor count carries over from the bucket before.
+func RollUpPlatformDays(days []PlatformDay, from, to time.Time, granularity string) PlatformAnalytics {
+	a := PlatformAnalytics{From: from, To: to, Granularity: granularity, Series: []PlatformPeriod{}}
+	index := map[int64]int{}
+	for i, start := range SalesBuckets(from, to, granularity) {
+		a.Series = append(a.Series, PlatformPeriod{Start: start})
+		index[start.Unix()] = i
+	}
+	a.Totals.Start = BucketStart(from, granularity)
+
+	for _, d := range days {
+		i, ok := index[BucketStart(d.Day, granularity).Unix()]
+		if !ok {
+			continue
+		}
+		a.Series[i].add(d)
+		a.Totals.add(d)
+		if a.ComputedAt == nil || d.ComputedAt.Before(*a.ComputedAt) {
+			computedAt := d.ComputedAt
+			a.ComputedAt = &computedAt
+		}
+	}
+	for i := range a.Series {
+		if i > 0 && a.Series[i].Vendors < a.Series[i-1].Vendors {
+			a.Series[i].Vendors = a.Series[i-1].Vendors
+		}
+		a.Series[i].finish()
+	}
+	a.Totals.finish()
+	return a
+}
+
+// RollUpCategoryDays sums precomputed days' categories into buckets of
+// granularity covering [from, to), keeping the top limit categories by
+// revenue in each bucket and overall.
+func RollUpCategoryDays(days []PlatformDay, from, to time.Time, granularity string, limit int) CategoryAnalytics {
+	a := CategoryAnalytics{From: from, To: to, Granularity: granularity, Series: []CategoryPeriod{}}
+	index := map[int64]int{}
+	buckets := []map[primitive.ObjectID]*CategorySales{}
+	for i, start := range SalesBuckets(from, to, granularity) {
+		a.Series = append(a.Series, CategoryPeriod{Start: start})
+		buckets = append(buckets, map[primitive.ObjectID]*CategorySales{})
+		index[start.Unix()] = i
+	}
+	totals := map[primitive.ObjectID]*CategorySales{}
+
+	add := func(into map[primitive.ObjectID]*CategorySales, c CategoryDay) {
+		sales, ok := into[c.CategoryID]
+		if !ok {
+			sales = &CategorySales{Name: c.Name}
+			if !c.CategoryID.IsZero() {
+				sales.CategoryID = c.CategoryID.Hex()
+			}
+			into[c.CategoryID] = sales
+		}
+		sales.Revenue += c.Revenue
+		sales.UnitsSold += c.UnitsSold
+		sales.OrderCount += c.Orders
+	}
+	for _, d := range days {
+		i, ok := index[BucketStart(d.Day, granularity).Unix()]
+		if !ok {
+			continue
+		}
+		for _, c := range d.Categories {
+			add(buckets[i], c)
+			add(totals, c)
+		}
+	}
+
+	for i := range a.Series {
+		a.Series[i].Categories = topCategories(buckets[i], limit)
+	}
+	a.Totals = topCategories(totals, limit)
+	return a
+}
+
+func topCategories(sales map[primitive.ObjectID]*CategorySales, limit int) []CategorySales {
+	out := make([]CategorySales, 0, len(sales))
+	for _, s := range sales {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Revenue != out[j].Revenue {
+			return out[i].Revenue > out[j].Revenue
+		}
+		return out[i].CategoryID < out[j].CategoryID
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// Cohort segments: a cohort can be split by whether its users picked
+// interests, or finished onboarding, to compare how well each half is
+// retained.
+const (
+	CohortSegmentInterests  = "interests"
+	CohortSegmentOnboarding = "onboarding"
+)
+
+// CohortCounts is how many users of a signup cohort, and segment, have
+// bought at all and more than once.
+type CohortCounts struct {
+	Month        time.Time `bson:"month"` // First of the signup month
+	Segment      string    `bson:"segment"`
+	Users        int       `bson:"users"`
+	Buyers       int       `bson:"buyers"`
+	RepeatBuyers int       `bson:"repeatBuyers"`
+}
+
+// CohortActivity is how many users of a signup cohort, and segment, bought
+// in one month.
+type CohortActivity struct {
+	Month   time.Time `bson:"month"`
+	Segment string    `bson:"segment"`
+	Active  time.Time `bson:"active"` // First of the month they bought in
+	Buyers  int       `bson:"buyers"`
+}
+
+// CohortRetention is the share of a cohort buying in its nth month, the
+// signup month being 0.
+type CohortRetention struct {
+	MonthsSinceSignup int     `json:"monthsSinceSignup"`
+	Buyers            int     `json:"buyers"`
+	Rate              float64 `json:"rate"` // Percentage of the cohort's users
+}
+
+// Cohort is the users who signed up in one month, and how they went on to
+// buy. Rates are percentages.
+type Cohort struct {
+	Month          string            `json:"month"` // YYYY-MM
+	Segment        string            `json:"segment,omitempty"`
+	Users          int               `json:"users"`
+	Buyers         int               `json:"buyers"`
+	RepeatBuyers   int               `json:"repeatBuyers"`
+	ConversionRate float64           `json:"conversionRate"` // Of users, who bought at all
+	RepeatRate     float64           `json:"repeatRate"`     // Of buyers, who bought again
+	Retention      []CohortRetention `json:"retention"`      // Every month from signup to now's
+}
+
+// monthsBetween is how many calendar months from's month is before to's.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// BuildCohorts assembles cohorts from their counts and monthly activity,
+// oldest first. Each cohort's retention runs to now's month, with months
+// nobody bought in at zero.
+func BuildCohorts(counts []CohortCounts, activity []CohortActivity, now time.Time) []Cohort {
+	type key struct {
+		month   int64
+		segment string
+	}
+	cohorts := make([]Cohort, 0, len(counts))
+	index := map[key]int{}
+	for _, c := range counts {
+		month := c.Month.UTC()
+		cohort := Cohort{
+			Month:          month.Format("2006-01"),
+			Segment:        c.Segment,
+			Users:          c.Users,
+			Buyers:         c.Buyers,
+			RepeatBuyers:   c.RepeatBuyers,
+			ConversionRate: percentOf(int64(c.Buyers), int64(c.Users)),
+			RepeatRate:     percentOf(int64(c.RepeatBuyers), int64(c.Buyers)),
+			Retention:      []CohortRetention{},
+		}
+		for n := 0; n <= monthsBetween(month, now.UTC()); n++ {
+			cohort.Retention = append(cohort.Retention, CohortRetention{MonthsSinceSignup: n})
+		}
+		index[key{month.Unix(), c.Segment}] = len(cohorts)
+		cohorts = append(cohorts, cohort)
+	}
+
+	for _, a := range activity {
+		i, ok := index[key{a.Month.UTC().Unix(), a.Segment}]
+		if !ok {
+			continue
+		}
+		n := monthsBetween(a.Month.UTC(), a.Active.UTC())
+		if n < 0 || n >= len(cohorts[i].Retention) {
+			continue
+		}
+		r := &cohorts[i].Retention[n]
+		r.Buyers += a.Buyers
+		r.Rate = percentOf(int64(r.Buyers), int64(cohorts[i].Users))
+	}
+
+	sort.Slice(cohorts, func(i, j int) bool {
+		if cohorts[i].Month != cohorts[j].Month {
+			return cohorts[i].Month < cohorts[j].Month
+		}
+		return cohorts[i].Segment < cohorts[j].Segment
+	})
+	return cohorts
+}
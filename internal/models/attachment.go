@@ -0,0 +1,17 @@
+package models
+
+import "strings"
+
+// Attachment is a file uploaded through the media service and attached to a
+// message or a dispute.
+type Attachment struct {
+	URL         string `json:"url" bson:"url" validate:"required,url"`
+	Name        string `json:"name,omitempty" bson:"name,omitempty" validate:"max=255"` // Original filename, for display
+	ContentType string `json:"contentType" bson:"contentType" validate:"required"`
+	Size        int64  `json:"size,omitempty" bson:"size,omitempty"`
+}
+
+// IsImage reports whether the attachment can be shown inline.
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audit actions
+const (
+	AuditUserStatusChanged    = "user.status_changed"
+	AuditUserRoleChanged      = "user.role_changed"
+	AuditVendorUnsuspended    = "vendor.unsuspended"
+	AuditVendorAdjusted       = "vendor.adjusted"
+	AuditVendorBanned         = "vendor.banned"
+	AuditTierApproved         = "vendor.tier_approved"
+	AuditTierRejected         = "vendor.tier_rejected"
+	AuditProductFlagged       = "product.flagged"
+	AuditProductApproved      = "product.approved"
+	AuditCategoryCreated      = "category.created"
+	AuditCategoryUpdated      = "category.updated"
+	AuditCategoryDeleted      = "category.deleted"
+	AuditCategoryActivated    = "category.activated"
+	AuditCategoryDeactivated  = "category.deactivated"
+	AuditCategoryMerged       = "category.merged"
+	AuditCategorySchemaSet    = "category.schema_set"
+	AuditCategoryCommission   = "category.commission_set"
+	AuditCategoryImported     = "category.imported"
+	AuditOrderRefunded        = "order.refunded"
+	AuditOrderCancelled       = "order.cancelled"
+	AuditOrderStatusCorrected = "order.status_corrected"
+	AuditReportTriaged        = "report.triaged"
+	AuditSynonymCreated       = "search_synonym.created"
+	AuditSynonymUpdated       = "search_synonym.updated"
+	AuditSynonymDeleted       = "search_synonym.deleted"
+	AuditImpersonationStarted = "impersonation.started"
+	AuditImpersonatedRequest  = "impersonation.request"
+	AuditShippingRatesSet     = "shipping.rates_set"
+	AuditFreeShippingSet      = "shipping.free_shipping_set"
+	AuditCouponCreated        = "coupon.created"
+	AuditCouponUpdated        = "coupon.updated"
+	AuditCouponDeleted        = "coupon.deleted"
+	AuditAutoDiscountCreated  = "auto_discount.created"
+	AuditAutoDiscountUpdated  = "auto_discount.updated"
+	AuditAutoDiscountDeleted  = "auto_discount.deleted"
+	AuditDisputeResolved      = "dispute.resolved"
+	AuditDisputeInfoRequested = "dispute.info_requested"
+	AuditClientBlocked        = "client.blocked"
+	AuditClientUnblocked      = "client.unblocked"
+	AuditWebhookCreated       = "webhook.created"
+	AuditWebhookUpdated       = "webhook.updated"
+	AuditWebhookDeleted       = "webhook.deleted"
+	AuditWebhookSecretRotated = "webhook.secret_rotated"
+	AuditWebhookReplayed      = "webhook.replayed"
+	AuditRecordDeleted        = "record.deleted"
+	AuditRecordRestored       = "record.restored"
+)
+
+// Audit target types
+const (
+	AuditTargetUser         = "user"
+	AuditTargetVendor       = "vendor"
+	AuditTargetProduct      = "product"
+	AuditTargetTier         = "tier_request"
+	AuditTargetCategory     = "category"
+	AuditTargetOrder        = "order"
+	AuditTargetReport       = "report"
+	AuditTargetSynonym      = "search_synonym"
+	AuditTargetShipping     = "shipping_rates"
+	AuditTargetCoupon       = "coupon"
+	AuditTargetAutoDiscount = "auto_discount"
+	AuditTargetDispute      = "dispute"
+	AuditTargetClientBlock  = "client_block"
+	AuditTargetWebhook      = "webhook"
+	AuditTargetReview       = "review"
+	AuditTargetStore        = "store"
+)
+
+// AuditLog records a privileged mutation: who did it, to what, and the
+// relevant state before and after.
+type AuditLog struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ActorID    primitive.ObjectID     `bson:"actorID" json:"actorId"`
+	ActorRole  string                 `bson:"actorRole" json:"actorRole"`
+	Action     string                 `bson:"action" json:"action"`
+	TargetType string                 `bson:"targetType" json:"targetType"`
+	TargetID   primitive.ObjectID     `bson:"targetID" json:"targetId"`
+	Before     map[string]interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After      map[string]interface{} `bson:"after,omitempty" json:"after,omitempty"`
+	Reason     string                 `bson:"reason,omitempty" json:"reason,omitempty"`
+	IP         string                 `bson:"ip,omitempty" json:"ip,omitempty"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+}
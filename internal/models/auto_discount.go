@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Buyer segments an automatic discount can target
+const (
+	SegmentFirstOrder = "first_order" // Has never placed an order
+	SegmentWinBack    = "win_back"    // Has ordered before, but not for WinBackDays
+)
+
+// DefaultWinBackDays is how long a buyer must have gone without ordering to
+// be won back, when a discount doesn't say.
+const DefaultWinBackDays = 90
+
+// AutoDiscount is a platform discount that applies by itself, without a
+// code, to baskets of buyers in a segment. Only one discount comes off an
+// order: the bigger of the buyer's best automatic discount and their coupon.
+type AutoDiscount struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"` // Shown to the buyer, e.g. "Welcome: 10% off your first order"
+	Segment     string             `json:"segment" bson:"segment"`
+	WinBackDays int                `json:"winBackDays,omitempty" bson:"winBackDays,omitempty"`
+	Type        string             `json:"type" bson:"type"` // CouponPercentage or CouponFixed
+	Value       float64            `json:"value" bson:"value"`
+	MaxDiscount float64            `json:"maxDiscount,omitempty" bson:"maxDiscount,omitempty"`
+	MinSpend    float64            `json:"minSpend,omitempty" bson:"minSpend,omitempty"`
+	StartsAt    *time.Time         `json:"startsAt,omitempty" bson:"startsAt,omitempty"`
+	ExpiresAt   *time.Time         `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	Active      bool               `json:"active" bson:"active"`
+
+	// Running totals, kept as orders are placed
+	RedemptionCount int   `json:"redemptionCount" bson:"redemptionCount"`
+	DiscountTotal   Money `json:"discountTotal" bson:"discountTotal"`
+
+	CreatedBy primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// AutoDiscountInput is the body for creating or replacing an automatic
+// discount.
+type AutoDiscountInput struct {
+	Name        string     `json:"name" validate:"required,min=3,max=80"`
+	Segment     string     `json:"segment" validate:"required,oneof=first_order win_back"`
+	WinBackDays int        `json:"winBackDays" validate:"gte=0,lte=730"`
+	Type        string     `json:"type" validate:"required,oneof=percentage fixed"`
+	Value       float64    `json:"value" validate:"gt=0"`
+	MaxDiscount float64    `json:"maxDiscount" validate:"gte=0"`
+	MinSpend    float64    `json:"minSpend" validate:"gte=0"`
+	StartsAt    *time.Time `json:"startsAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	Active      *bool      `json:"active"` // Defaults to true
+}
+
+// AutoDiscountRedemption records an automatic discount taken off an order.
+type AutoDiscountRedemption struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	AutoDiscountID primitive.ObjectID `json:"autoDiscountId" bson:"autoDiscountId"`
+	UserID         primitive.ObjectID `json:"userId" bson:"userId"`
+	OrderID        primitive.ObjectID `json:"orderId" bson:"orderId"`
+	Discount       Money              `json:"discount" bson:"discount"`
+	OrderTotal     Money              `json:"orderTotal" bson:"orderTotal"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// AutoDiscountReport is how an automatic discount has done.
+type AutoDiscountReport struct {
+	Redemptions   int64 `json:"redemptions" bson:"redemptions"`
+	Buyers        int64 `json:"buyers" bson:"buyers"`
+	DiscountTotal Money `json:"discountTotal" bson:"discountTotal"`
+	OrderTotal    Money `json:"orderTotal" bson:"orderTotal"` // What the discounted orders came to
+}
+
+// BuyerHistory is what segments are decided on: how many orders the buyer
+// has placed, leaving out cancelled ones, and when the last was.
+type BuyerHistory struct {
+	Orders      int64
+	LastOrderAt *time.Time
+}
+
+// InSegment reports whether a buyer with history h is in the discount's
+// segment at now.
+func (d AutoDiscount) InSegment(h BuyerHistory, now time.Time) bool {
+	switch d.Segment {
+	case SegmentFirstOrder:
+		return h.Orders == 0
+	case SegmentWinBack:
+		days := d.WinBackDays
+		if days == 0 {
+			days = DefaultWinBackDays
+		}
+		return h.Orders > 0 && h.LastOrderAt != nil && now.Sub(*h.LastOrderAt) >= time.Duration(days)*24*time.Hour
+	}
+	return false
+}
+
+// Apply works out the discount on items at now, the way a platform coupon
+// with the same terms would.
+func (d AutoDiscount) Apply(items []DiscountItem, now time.Time) (CouponDiscount, error) {
+	discount, err := Coupon{
+		Type:        d.Type,
+		Value:       d.Value,
+		MaxDiscount: d.MaxDiscount,
+		MinSpend:    d.MinSpend,
+		StartsAt:    d.StartsAt,
+		ExpiresAt:   d.ExpiresAt,
+		Active:      d.Active,
+	}.Apply(items, now)
+	if err != nil {
+		return CouponDiscount{}, err
+	}
+	discount.AutoDiscountID = d.ID
+	discount.Name = d.Name
+	return discount, nil
+}
+
+// BestAutoDiscount picks the discount that takes most off items for a buyer
+// with history h, or nil if none applies.
+func BestAutoDiscount(discounts []AutoDiscount, h BuyerHistory, items []DiscountItem, now time.Time) (*AutoDiscount, CouponDiscount) {
+	var best *AutoDiscount
+	var bestDiscount CouponDiscount
+	for i := range discounts {
+		if !discounts[i].InSegment(h, now) {
+			continue
+		}
+		discount, err := discounts[i].Apply(items, now)
+		if err != nil || discount.Amount <= bestDiscount.Amount {
+			continue
+		}
+		best, bestDiscount = &discounts[i], discount
+	}
+	return best, bestDiscount
+}
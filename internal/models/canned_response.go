@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxCannedResponses is how many reply templates a vendor can keep.
+const MaxCannedResponses = 50
+
+// AutoReplyCooldown is how long after an away reply a conversation gets
+// another one, so a buyer sending several messages hears it once.
+const AutoReplyCooldown = 24 * time.Hour
+
+// CannedResponse is a reply a vendor saved to reuse in conversations.
+type CannedResponse struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Title     string             `json:"title" bson:"title"`
+	Body      string             `json:"body" bson:"body"`
+	UseCount  int                `json:"useCount" bson:"useCount"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CannedResponseInput creates or replaces a reply template.
+type CannedResponseInput struct {
+	Title string `json:"title" validate:"required,max=80"`
+	Body  string `json:"body" validate:"required,max=2000"`
+}
+
+// AutoReply is a vendor's away message, sent on their behalf when a buyer
+// writes while it is on. Leaving out StartsAt or EndsAt leaves that side of
+// the window open.
+type AutoReply struct {
+	VendorID  primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Enabled   bool               `json:"enabled" bson:"enabled"`
+	Message   string             `json:"message" bson:"message"`
+	StartsAt  *time.Time         `json:"startsAt,omitempty" bson:"startsAt,omitempty"`
+	EndsAt    *time.Time         `json:"endsAt,omitempty" bson:"endsAt,omitempty"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// AutoReplyInput turns the away message on or off.
+type AutoReplyInput struct {
+	Enabled  bool       `json:"enabled"`
+	Message  string     `json:"message" validate:"required_if=Enabled true,max=1000"`
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt"`
+}
+
+// ActiveAt reports whether the away message should go out at t.
+func (a *AutoReply) ActiveAt(t time.Time) bool {
+	if a == nil || !a.Enabled || a.Message == "" {
+		return false
+	}
+	if a.StartsAt != nil && t.Before(*a.StartsAt) {
+		return false
+	}
+	return a.EndsAt == nil || t.Before(*a.EndsAt)
+}
+
+// MessageResponseStats is how quickly a vendor answers buyers, from their
+// own replies; away messages don't count.
+type MessageResponseStats struct {
+	Replies          int64   `json:"replies" bson:"replies"`
+	AvgResponseHours float64 `json:"avgResponseHours" bson:"avgResponseHours"`
+}
@@ -1,23 +1,46 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrOutOfStock is returned when an item can't be ordered in the quantity
+// asked for.
+var ErrOutOfStock = errors.New("not enough stock")
+
+// ErrProductUnavailable is returned when an item in the cart has been taken
+// off the marketplace since it was added.
+var ErrProductUnavailable = errors.New("product is no longer available")
+
 type CartItem struct {
-	ProductID primitive.ObjectID `json:"productId" bson:"productId"`
-	Name      string             `json:"name" bson:"name"`
-	Price     float64            `json:"price" bson:"price"`
-	Quantity  int                `json:"quantity" bson:"quantity"`
-	Image     string             `json:"image" bson:"image"`
+	ProductID   primitive.ObjectID  `json:"productId" bson:"productId"`
+	Name        string              `json:"name" bson:"name"`
+	Price       Money               `json:"price" bson:"price"`
+	Quantity    int                 `json:"quantity" bson:"quantity"`
+	Image       string              `json:"image" bson:"image"`
+	FlashSaleID *primitive.ObjectID `json:"flashSaleId,omitempty" bson:"flashSaleId,omitempty"` // Price is the flash sale's; claimed at checkout
 }
 
 type Cart struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
-	Items     []CartItem         `json:"items" bson:"items"`
-	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `json:"userId" bson:"userId"`
+	Items      []CartItem         `json:"items" bson:"items"`
+	CouponCode string             `json:"couponCode,omitempty" bson:"couponCode,omitempty"` // Applied at checkout
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CartTotals is what the cart comes to before shipping and tax.
+type CartTotals struct {
+	Subtotal     Money           `json:"subtotal"`
+	Discount     Money           `json:"discount"`
+	Total        Money           `json:"total"`
+	Coupon       *CouponDiscount `json:"coupon,omitempty"`
+	AutoDiscount *CouponDiscount `json:"autoDiscount,omitempty"` // Applied instead of the coupon when it takes off more
+	CouponError  string          `json:"couponError,omitempty"`  // Why the saved code no longer applies
+
+	FreeShipping []FreeShippingProgress `json:"freeShipping,omitempty"` // How close each shipment is to shipping free
 }
@@ -10,13 +10,19 @@ type Category struct {
 	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
 	Name        string              `json:"name" bson:"name" validate:"required"`
 	Description string              `json:"description,omitempty" bson:"description"`
-	Slug        string              `json:"slug" bson:"slug" validate:"required"`
+	Slug        string              `json:"slug" bson:"slug"`                             // Generated from Name when not given
 	ParentID    *primitive.ObjectID `json:"parentId,omitempty" bson:"parentId,omitempty"` // For subcategories
 	Icon        string              `json:"icon,omitempty" bson:"icon,omitempty"`
 	Image       string              `json:"image,omitempty" bson:"image,omitempty"`
+	Attributes  []CategoryAttribute `json:"attributes,omitempty" bson:"attributes,omitempty" validate:"max=50,dive"` // Product data schema; see category_attribute.go
 	IsActive    bool                `json:"isActive" bson:"isActive" default:"true"`
+	IsFeatured  bool                `json:"isFeatured" bson:"isFeatured"`
+	SortOrder   int                 `json:"sortOrder" bson:"sortOrder"` // Lower first; ties by name
 	CreatedAt   time.Time           `json:"createdAt" bson:"createdAt"`
 	UpdatedAt   time.Time           `json:"updatedAt" bson:"updatedAt"`
+
+	// Name and description in other locales; see localized.go
+	Translations Translations `json:"translations,omitempty" bson:"translations,omitempty" validate:"omitempty,max=20,dive,keys,locale,endkeys"`
 }
 
 type UpdateCategoryInput struct {
@@ -28,4 +34,35 @@ type UpdateCategoryInput struct {
 	Image       *string             `json:"image,omitempty" bson:"image,omitempty"`
 	IsActive    *bool               `json:"isActive,omitempty" bson:"isActive,omitempty"`
 	UpdatedAt   time.Time           `json:"updatedAt" bson:"updatedAt"`
+
+	Translations *Translations `json:"translations,omitempty" bson:"translations,omitempty" validate:"omitempty,max=20,dive,keys,locale,endkeys"` // Replaces them all
+}
+
+// CategoryOrderInput sets the display order of sibling categories: IDs in
+// the order they should be shown, under ParentID or at the top level.
+type CategoryOrderInput struct {
+	ParentID *primitive.ObjectID  `json:"parentId,omitempty"`
+	IDs      []primitive.ObjectID `json:"ids" validate:"required,min=1,max=500"`
+}
+
+// How a category delete treats the category's sub-categories
+const (
+	SubcategoriesBlock    = "block"    // Refuse while it has any
+	SubcategoriesReparent = "reparent" // Move them up to its parent
+	SubcategoriesDelete   = "delete"   // Delete the whole subtree
+)
+
+// CategoryDeleteOptions says what happens to what is filed under a deleted
+// category. Products anywhere in what is deleted move to ReassignTo; without
+// one, products block the delete.
+type CategoryDeleteOptions struct {
+	ReassignTo    *primitive.ObjectID
+	Subcategories string
+}
+
+// CategoryDeleteResult counts what a category delete changed.
+type CategoryDeleteResult struct {
+	DeletedCategories    int   `json:"deletedCategories"`
+	ReparentedCategories int64 `json:"reparentedCategories"`
+	ReassignedProducts   int64 `json:"reassignedProducts"`
 }
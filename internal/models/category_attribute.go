@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// AttributeType is the kind of value a category attribute holds.
+type AttributeType string
+
+const (
+	AttributeText    AttributeType = "text"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+	AttributeSelect  AttributeType = "select"
+)
+
+// maxAttributeTextLength caps free-text attribute values.
+const maxAttributeTextLength = 200
+
+// CategoryAttribute is one field of a category's product data schema, such
+// as "Screen size" or "Material". Products store attribute values in their
+// metadata under Key, or as a variant option of that name.
+type CategoryAttribute struct {
+	Key        string        `json:"key" bson:"key" validate:"required,max=40"`
+	Name       string        `json:"name" bson:"name" validate:"required,max=60"`
+	Type       AttributeType `json:"type" bson:"type" validate:"required,oneof=text number boolean select"`
+	Options    []string      `json:"options,omitempty" bson:"options,omitempty" validate:"max=100,dive,required,max=50"`
+	Unit       string        `json:"unit,omitempty" bson:"unit,omitempty" validate:"max=20"`
+	Required   bool          `json:"required" bson:"required"`
+	Filterable bool          `json:"filterable" bson:"filterable"`
+}
+
+// CategoryAttributesInput replaces a category's attribute schema.
+type CategoryAttributesInput struct {
+	Attributes []CategoryAttribute `json:"attributes" validate:"max=50,dive"`
+}
+
+// AttributeFacet counts a category's products by the values of a
+// filterable attribute.
+type AttributeFacet struct {
+	Key    string       `json:"key"`
+	Name   string       `json:"name"`
+	Unit   string       `json:"unit,omitempty"`
+	Values []FacetCount `json:"values"`
+}
+
+// NormalizeAttributeSchema trims a schema and checks it is usable: keys must
+// be unique and usable as ?attributes[key]= filters, select attributes need
+// options and only they may have them.
+func NormalizeAttributeSchema(attrs []CategoryAttribute) ([]CategoryAttribute, error) {
+	out := make([]CategoryAttribute, 0, len(attrs))
+	seen := map[string]bool{}
+	for _, a := range attrs {
+		a.Key = strings.TrimSpace(a.Key)
+		a.Name = strings.TrimSpace(a.Name)
+		a.Unit = strings.TrimSpace(a.Unit)
+		if !filterAttributeKey.MatchString(a.Key) {
+			return nil, errors.New("invalid attribute key " + strconv.Quote(a.Key))
+		}
+		if seen[strings.ToLower(a.Key)] {
+			return nil, errors.New("duplicate attribute key " + strconv.Quote(a.Key))
+		}
+		seen[strings.ToLower(a.Key)] = true
+
+		var options []string
+		for _, o := range a.Options {
+			if o = strings.TrimSpace(o); o != "" && matchOption(options, o) == "" {
+				options = append(options, o)
+			}
+		}
+		a.Options = options
+		switch {
+		case a.Type == AttributeSelect && len(a.Options) == 0:
+			return nil, errors.New("attribute " + strconv.Quote(a.Key) + " needs options")
+		case a.Type != AttributeSelect && len(a.Options) > 0:
+			return nil, errors.New("only select attributes can have options")
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// ApplyAttributeSchema checks a product's metadata against its category's
+// schema and returns it with schema attributes stored under their canonical
+// keys and values: numbers and booleans in a standard form and select values
+// spelled as the option is. Metadata outside the schema is kept as it is.
+// A required attribute may instead be a variant option.
+func ApplyAttributeSchema(schema []CategoryAttribute, metadata map[string]string, variantOptions []VariantOption) (map[string]string, error) {
+	if len(schema) == 0 {
+		return metadata, nil
+	}
+	out := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		out[k] = v
+	}
+
+	for _, a := range schema {
+		// 1. Find the value, whatever case the vendor used for the key
+		raw, found := "", false
+		for k, v := range out {
+			if strings.EqualFold(k, a.Key) {
+				delete(out, k)
+				if v = strings.TrimSpace(v); v != "" {
+					raw, found = v, true
+				}
+			}
+		}
+		if !found {
+			if a.Required && !hasVariantOption(variantOptions, a.Key) {
+				return nil, errors.New(a.Name + " is required")
+			}
+			continue
+		}
+
+		// 2. Check it against the attribute's type
+		value, err := normalizeAttributeValue(a, raw)
+		if err != nil {
+			return nil, err
+		}
+		out[a.Key] = value
+	}
+	return out, nil
+}
+
+func normalizeAttributeValue(a CategoryAttribute, raw string) (string, error) {
+	switch a.Type {
+	case AttributeNumber:
+		v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(raw, a.Unit)), 64)
+		if err != nil {
+			return "", errors.New(a.Name + " must be a number")
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case AttributeBoolean:
+		v, err := strconv.ParseBool(strings.ToLower(raw))
+		if err != nil {
+			return "", errors.New(a.Name + " must be true or false")
+		}
+		return strconv.FormatBool(v), nil
+	case AttributeSelect:
+		if option := matchOption(a.Options, raw); option != "" {
+			return option, nil
+		}
+		return "", errors.New(a.Name + " must be one of: " + strings.Join(a.Options, ", "))
+	default:
+		if utf8.RuneCountInString(raw) > maxAttributeTextLength {
+			return "", errors.New(a.Name + " is too long")
+		}
+		return raw, nil
+	}
+}
+
+// matchOption returns the option equal to value ignoring case, or "".
+func matchOption(options []string, value string) string {
+	for _, o := range options {
+		if strings.EqualFold(o, value) {
+			return o
+		}
+	}
+	return ""
+}
+
+func hasVariantOption(options []VariantOption, key string) bool {
+	for _, o := range options {
+		if strings.EqualFold(strings.TrimSpace(o.Name), key) && len(o.Values) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxCategoryImportRows caps how many categories one import can hold.
+const MaxCategoryImportRows = 2000
+
+// CategoryImportNode is a category in a JSON taxonomy import, with its
+// sub-categories nested under it. A node may instead name its parent by
+// slug, to hang it under a category that already exists.
+type CategoryImportNode struct {
+	Name        string               `json:"name"`
+	Slug        string               `json:"slug,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Parent      string               `json:"parent,omitempty"`
+	SortOrder   int                  `json:"sortOrder,omitempty"`
+	Children    []CategoryImportNode `json:"children,omitempty"`
+}
+
+// CategoryImportRow is one category to import, its parent named by slug
+// ("" for top level).
+type CategoryImportRow struct {
+	Name        string
+	Slug        string
+	Description string
+	ParentSlug  string
+	SortOrder   int
+}
+
+// CategoryImportResult counts what an import did.
+type CategoryImportResult struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// FlattenCategoryImport turns a nested JSON taxonomy into rows. Children
+// get their parent's slug unless they name another.
+func FlattenCategoryImport(nodes []CategoryImportNode, slugify func(string) string) []CategoryImportRow {
+	var rows []CategoryImportRow
+	var walk func(nodes []CategoryImportNode, parentSlug string)
+	walk = func(nodes []CategoryImportNode, parentSlug string) {
+		for _, n := range nodes {
+			row := CategoryImportRow{
+				Name:        strings.TrimSpace(n.Name),
+				Slug:        n.Slug,
+				Description: strings.TrimSpace(n.Description),
+				ParentSlug:  parentSlug,
+				SortOrder:   n.SortOrder,
+			}
+			if row.Slug == "" {
+				row.Slug = row.Name
+			}
+			row.Slug = slugify(row.Slug)
+			if n.Parent != "" {
+				row.ParentSlug = slugify(n.Parent)
+			}
+			rows = append(rows, row)
+			walk(n.Children, row.Slug)
+		}
+	}
+	walk(nodes, "")
+	return rows
+}
+
+// ParseCategoryCSV reads a taxonomy from CSV with a header row. The name
+// column is required; slug, parent (a slug), description and sortOrder are
+// optional.
+func ParseCategoryCSV(r io.Reader, slugify func(string) string) ([]CategoryImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("CSV has no header row")
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, errors.New("CSV needs a name column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []CategoryImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		row := CategoryImportRow{
+			Name:        field(record, "name"),
+			Slug:        field(record, "slug"),
+			Description: field(record, "description"),
+			ParentSlug:  slugify(field(record, "parent")),
+		}
+		if row.Slug == "" {
+			row.Slug = row.Name
+		}
+		row.Slug = slugify(row.Slug)
+		if v := field(record, "sortorder"); v != "" {
+			if row.SortOrder, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("line %d: sortOrder must be a whole number", line)
+			}
+		}
+		rows = append(rows, row)
+		if len(rows) > MaxCategoryImportRows {
+			return nil, fmt.Errorf("imports are limited to %d categories", MaxCategoryImportRows)
+		}
+	}
+	return rows, nil
+}
+
+// OrderCategoryImport checks rows and orders them so every parent in the
+// import comes before its children. Parents not in the import must already
+// exist; that is checked when importing.
+func OrderCategoryImport(rows []CategoryImportRow) ([]CategoryImportRow, error) {
+	if len(rows) > MaxCategoryImportRows {
+		return nil, fmt.Errorf("imports are limited to %d categories", MaxCategoryImportRows)
+	}
+	bySlug := make(map[string]int, len(rows))
+	for i, row := range rows {
+		switch {
+		case row.Name == "":
+			return nil, fmt.Errorf("row %d: name is required", i+1)
+		case row.Slug == "":
+			return nil, fmt.Errorf("row %d: %q doesn't make a usable slug", i+1, row.Name)
+		case row.ParentSlug == row.Slug:
+			return nil, fmt.Errorf("%q can't be its own parent", row.Slug)
+		}
+		if _, dup := bySlug[row.Slug]; dup {
+			return nil, fmt.Errorf("slug %q appears more than once", row.Slug)
+		}
+		bySlug[row.Slug] = i
+	}
+
+	// Depth-first from each row up through its parents in the import
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(rows))
+	ordered := make([]CategoryImportRow, 0, len(rows))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("categories around %q form a cycle", rows[i].Slug)
+		}
+		state[i] = visiting
+		if parent, ok := bySlug[rows[i].ParentSlug]; ok {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		ordered = append(ordered, rows[i])
+		return nil
+	}
+	for i := range rows {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
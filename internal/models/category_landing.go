@@ -0,0 +1,60 @@
+package models
+
+import "strconv"
+
+// How many products and brands a category landing page shows.
+const (
+	LandingProductLimit = 8
+	LandingBrandLimit   = 10
+	LandingPriceBuckets = 5
+)
+
+// CategoryLanding is everything a storefront category page needs in one
+// response.
+type CategoryLanding struct {
+	Category      Category       `json:"category"`
+	SubCategories []Category     `json:"subCategories"`
+	TopBrands     []FacetCount   `json:"topBrands"`
+	Featured      []Product      `json:"featured"`
+	BestSellers   []Product      `json:"bestSellers"`
+	Facets        CategoryFacets `json:"facets"`
+}
+
+// FacetCount is a filter value and how many products match it.
+type FacetCount struct {
+	Value string `json:"value" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// PriceRange is a price facet bucket, from Min up to but excluding Max.
+type PriceRange struct {
+	Min   Money `json:"min" bson:"min"`
+	Max   Money `json:"max" bson:"max"`
+	Count int   `json:"count" bson:"count"`
+}
+
+// CategoryFacets counts the category's active products by the filters the
+// storefront offers.
+type CategoryFacets struct {
+	Total       int          `json:"total"`
+	InStock     int          `json:"inStock"`
+	Brands      []FacetCount `json:"brands"`
+	SubCategory []FacetCount `json:"subCategories"` // Keyed by sub-category ID
+	Price       []PriceRange `json:"price"`
+	// Rating counts products rated at least 4, 3, 2 and 1 stars
+	Rating []FacetCount `json:"rating"`
+	// Attributes counts values of the category's filterable attributes
+	Attributes []AttributeFacet `json:"attributes"`
+}
+
+// RatingAtLeast turns product counts per whole-star rating (0-5) into
+// "N stars & up" counts, highest first. Unrated products are left out.
+func RatingAtLeast(byStars map[int]int) []FacetCount {
+	out := []FacetCount{}
+	running := byStars[5]
+	for stars := 4; stars >= 1; stars-- {
+		running += byStars[stars]
+		out = append(out, FacetCount{Value: strconv.Itoa(stars), Count: running})
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCategoryTreeDepth bounds walks up and down the category hierarchy so a
+// corrupt parent cycle can't loop.
+const maxCategoryTreeDepth = 16
+
+// CategoryNode is a category with its sub-categories, for the category tree.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// CategoryCrumb is one step of a breadcrumb path, from the top-level
+// category down.
+type CategoryCrumb struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+	Slug string             `json:"slug"`
+}
+
+// CategoryHierarchy indexes a set of categories by ID and by parent, to walk
+// the tree in memory.
+type CategoryHierarchy struct {
+	byID     map[primitive.ObjectID]Category
+	children map[primitive.ObjectID][]Category
+	roots    []Category
+}
+
+// NewCategoryHierarchy indexes categories. A category whose parent isn't in
+// the set (deactivated, say) is left out of the tree along with everything
+// under it.
+func NewCategoryHierarchy(categories []Category) *CategoryHierarchy {
+	h := &CategoryHierarchy{
+		byID:     make(map[primitive.ObjectID]Category, len(categories)),
+		children: map[primitive.ObjectID][]Category{},
+	}
+	for _, c := range categories {
+		h.byID[c.ID] = c
+	}
+	for _, c := range categories {
+		switch {
+		case c.ParentID == nil:
+			h.roots = append(h.roots, c)
+		case *c.ParentID != c.ID:
+			h.children[*c.ParentID] = append(h.children[*c.ParentID], c)
+		}
+	}
+	for _, list := range h.children {
+		sortCategories(list)
+	}
+	sortCategories(h.roots)
+	return h
+}
+
+// sortCategories puts siblings in display order: by SortOrder, then name.
+func sortCategories(list []Category) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].SortOrder != list[j].SortOrder {
+			return list[i].SortOrder < list[j].SortOrder
+		}
+		return list[i].Name < list[j].Name
+	})
+}
+
+// Get returns the category with the ID, if it's in the set.
+func (h *CategoryHierarchy) Get(id primitive.ObjectID) (Category, bool) {
+	c, ok := h.byID[id]
+	return c, ok
+}
+
+// Roots returns the top-level categories in display order.
+func (h *CategoryHierarchy) Roots() []Category {
+	return h.roots
+}
+
+// Children returns the categories directly under id in display order.
+func (h *CategoryHierarchy) Children(id primitive.ObjectID) []Category {
+	return h.children[id]
+}
+
+// Tree returns the top-level categories with their sub-categories nested
+// under them, each level in display order.
+func (h *CategoryHierarchy) Tree() []*CategoryNode {
+	var build func(list []Category, depth int) []*CategoryNode
+	build = func(list []Category, depth int) []*CategoryNode {
+		nodes := make([]*CategoryNode, 0, len(list))
+		for _, c := range list {
+			node := &CategoryNode{Category: c, Children: []*CategoryNode{}}
+			if depth < maxCategoryTreeDepth {
+				node.Children = build(h.children[c.ID], depth+1)
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	return build(h.roots, 1)
+}
+
+// Breadcrumbs returns the path from the top-level category down to id. It
+// returns nil if id, or any category above it, isn't in the set.
+func (h *CategoryHierarchy) Breadcrumbs(id primitive.ObjectID) []CategoryCrumb {
+	var path []CategoryCrumb
+	current := id
+	for i := 0; i < maxCategoryTreeDepth; i++ {
+		c, ok := h.byID[current]
+		if !ok {
+			return nil
+		}
+		path = append(path, CategoryCrumb{ID: c.ID, Name: c.Name, Slug: c.Slug})
+		if c.ParentID == nil {
+			// Collected bottom-up; reverse to read top-down
+			for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+				path[l], path[r] = path[r], path[l]
+			}
+			return path
+		}
+		current = *c.ParentID
+	}
+	return nil
+}
+
+// WithDescendants returns id followed by the IDs of every category below it.
+func (h *CategoryHierarchy) WithDescendants(id primitive.ObjectID) []primitive.ObjectID {
+	ids := []primitive.ObjectID{id}
+	seen := map[primitive.ObjectID]bool{id: true}
+	level := []primitive.ObjectID{id}
+	for depth := 0; depth < maxCategoryTreeDepth && len(level) > 0; depth++ {
+		var next []primitive.ObjectID
+		for _, parent := range level {
+			for _, child := range h.children[parent] {
+				if !seen[child.ID] {
+					seen[child.ID] = true
+					ids = append(ids, child.ID)
+					next = append(next, child.ID)
+				}
+			}
+		}
+		level = next
+	}
+	return ids
+}
+
+// Attributes returns the attribute schema products in a category follow: its
+// own attributes and those of every category above it, a category's own
+// definition of a key replacing an inherited one.
+func (h *CategoryHierarchy) Attributes(id primitive.ObjectID) []CategoryAttribute {
+	var out []CategoryAttribute
+	seen := map[string]bool{}
+	current := id
+	for i := 0; i < maxCategoryTreeDepth; i++ {
+		c, ok := h.byID[current]
+		if !ok {
+			break
+		}
+		for _, a := range c.Attributes {
+			if key := strings.ToLower(a.Key); !seen[key] {
+				seen[key] = true
+				out = append(out, a)
+			}
+		}
+		if c.ParentID == nil {
+			break
+		}
+		current = *c.ParentID
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Checkout steps, in the order buyers go through them
+const (
+	CheckoutStepCartViewed           = "cart_viewed"
+	CheckoutStepAddressEntered       = "address_entered"
+	CheckoutStepPaymentIntentCreated = "payment_intent_created"
+	CheckoutStepPaymentSucceeded     = "payment_succeeded"
+)
+
+// CheckoutSteps is the checkout funnel, first step first.
+var CheckoutSteps = []string{
+	CheckoutStepCartViewed,
+	CheckoutStepAddressEntered,
+	CheckoutStepPaymentIntentCreated,
+	CheckoutStepPaymentSucceeded,
+}
+
+// PaymentProviderStripe is the provider of Stripe payment intents.
+const PaymentProviderStripe = "stripe"
+
+// CheckoutStage is step's position in CheckoutSteps, or -1 if it isn't one.
+func CheckoutStage(step string) int {
+	for i, s := range CheckoutSteps {
+		if s == step {
+			return i
+		}
+	}
+	return -1
+}
+
+// CheckoutEvent is a buyer reaching a checkout step. Events are kept one
+// per buyer, step and provider a UTC day, counting repeats, so reloading the
+// cart doesn't add a row each time.
+type CheckoutEvent struct {
+	UserID   primitive.ObjectID `bson:"userId" json:"userId"`
+	Step     string             `bson:"step" json:"step"`
+	Stage    int                `bson:"stage" json:"stage"`                         // CheckoutStage of Step, for ordering
+	Provider string             `bson:"provider" json:"provider,omitempty"`         // Payment steps only
+	OrderID  primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"` // The latest order it was for, once there is one
+	Day      time.Time          `bson:"day" json:"day"`
+	Count    int                `bson:"count" json:"count"`
+	FirstAt  time.Time          `bson:"firstAt" json:"firstAt"`
+	LastAt   time.Time          `bson:"lastAt" json:"lastAt"`
+}
+
+// CheckoutReach counts the buyers whose furthest checkout stage in a range
+// was Furthest, with Provider if counted per payment provider.
+type CheckoutReach struct {
+	Provider string `bson:"provider" json:"provider,omitempty"`
+	Furthest int    `bson:"furthest" json:"furthest"`
+	Users    int    `bson:"users" json:"users"`
+}
+
+// FunnelStep is how many buyers got to a checkout step. Rates are
+// percentages: ConversionRate and DropOffRate of the buyers who got to the
+// step before, OverallRate of those who got to the first step.
+type FunnelStep struct {
+	Step           string  `json:"step"`
+	Users          int     `json:"users"`
+	ConversionRate float64 `json:"conversionRate"`
+	DropOffRate    float64 `json:"dropOffRate"`
+	OverallRate    float64 `json:"overallRate"`
+}
+
+// ProviderFunnel is the payment steps of the funnel for one provider.
+type ProviderFunnel struct {
+	Provider string       `json:"provider"`
+	Steps    []FunnelStep `json:"steps"`
+}
+
+// CheckoutFunnel is how far buyers got through checkout over [From, To).
+// A buyer counts at every step up to the furthest they reached, since some
+// steps, like viewing the cart on another device, aren't always seen.
+type CheckoutFunnel struct {
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	Steps          []FunnelStep     `json:"steps"`
+	Providers      []ProviderFunnel `json:"providers"`
+	BiggestDropOff string           `json:"biggestDropOff,omitempty"` // The step the most buyers failed to reach from the one before
+}
+
+// BuildCheckoutFunnel assembles a funnel from how far buyers got overall,
+// and per payment provider from the first payment step on.
+func BuildCheckoutFunnel(overall, byProvider []CheckoutReach, from, to time.Time) CheckoutFunnel {
+	funnel := CheckoutFunnel{From: from, To: to, Providers: []ProviderFunnel{}}
+	funnel.Steps = funnelSteps(overall, 0)
+
+	biggest := 0.0
+	for _, step := range funnel.Steps[1:] {
+		if step.DropOffRate > biggest {
+			biggest = step.DropOffRate
+			funnel.BiggestDropOff = step.Step
+		}
+	}
+
+	reach := map[string][]CheckoutReach{}
+	for _, r := range byProvider {
+		reach[r.Provider] = append(reach[r.Provider], r)
+	}
+	for provider, rs := range reach {
+		funnel.Providers = append(funnel.Providers, ProviderFunnel{
+			Provider: provider,
+			Steps:    funnelSteps(rs, CheckoutStage(CheckoutStepPaymentIntentCreated)),
+		})
+	}
+	sort.Slice(funnel.Providers, func(i, j int) bool {
+		return funnel.Providers[i].Provider < funnel.Providers[j].Provider
+	})
+	return funnel
+}
+
+// funnelSteps counts the buyers at each checkout step from stage first on.
+func funnelSteps(reach []CheckoutReach, first int) []FunnelStep {
+	steps := make([]FunnelStep, 0, len(CheckoutSteps)-first)
+	for stage := first; stage < len(CheckoutSteps); stage++ {
+		step := FunnelStep{Step: CheckoutSteps[stage]}
+		for _, r := range reach {
+			if r.Furthest >= stage {
+				step.Users += r.Users
+			}
+		}
+		if len(steps) == 0 {
+			if step.Users > 0 {
+				step.ConversionRate, step.OverallRate = 100, 100
+			}
+		} else {
+			prev := steps[len(steps)-1].Users
+			step.ConversionRate = percentOf(int64(step.Users), int64(prev))
+			if prev > 0 {
+				step.DropOffRate = percentOf(int64(prev-step.Users), int64(prev))
+			}
+			step.OverallRate = percentOf(int64(step.Users), int64(steps[0].Users))
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
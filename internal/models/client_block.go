@@ -0,0 +1,48 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClientBlock shuts an abusive client out of the API. Clients are keyed as
+// the rate limiter keys them: "ip:<address>" or "user:<id>".
+type ClientBlock struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Key       string             `json:"key" bson:"key"`
+	Reason    string             `json:"reason" bson:"reason"`
+	BlockedBy primitive.ObjectID `json:"blockedBy" bson:"blockedBy"`
+	ExpiresAt *time.Time         `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"` // Nil blocks until lifted
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ActiveAt reports whether the block is in force at t.
+func (b ClientBlock) ActiveAt(t time.Time) bool {
+	return b.ExpiresAt == nil || t.Before(*b.ExpiresAt)
+}
+
+// BlockClientInput blocks an IP address or a user, for a number of hours or,
+// with no hours, until the block is lifted.
+type BlockClientInput struct {
+	IP     string `json:"ip" validate:"required_without=UserID,omitempty,ip"`
+	UserID string `json:"userId" validate:"required_without=IP,omitempty,mongodb"`
+	Reason string `json:"reason" validate:"required,max=500"`
+	Hours  int    `json:"hours" validate:"omitempty,min=1,max=8760"`
+}
+
+// ClientKey is the rate limiter key the input names, the user if both are
+// given.
+func (in BlockClientInput) ClientKey() string {
+	if in.UserID != "" {
+		return ClientKeyUser + in.UserID
+	}
+	return ClientKeyIP + strings.TrimSpace(in.IP)
+}
+
+// Rate limiter client key prefixes
+const (
+	ClientKeyIP   = "ip:"
+	ClientKeyUser = "user:"
+)
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CategoryCommission is one entry in a category's commission history: from
+// EffectiveFrom on, sales in the category (and the categories below it that
+// have no rate of their own) are charged Rate percent instead of the
+// vendor's TransactionFee. A nil Rate hands the category back to vendor
+// rates. Entries are never edited, so orders placed under an old rate keep
+// being charged it.
+type CategoryCommission struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CategoryID    primitive.ObjectID `json:"categoryId" bson:"categoryId"`
+	Rate          *float64           `json:"rate" bson:"rate"` // Percentage
+	EffectiveFrom time.Time          `json:"effectiveFrom" bson:"effectiveFrom"`
+	CreatedBy     primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// CategoryCommissionInput schedules a category's commission rate. Omitting
+// effectiveFrom applies it now; omitting rate clears it.
+type CategoryCommissionInput struct {
+	Rate          *float64   `json:"rate" validate:"omitempty,gte=0,lte=100"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+}
+
+// CommissionRateAt returns the rate a category's history sets for a sale
+// made at t. ok is false when no entry had taken effect by then, so the rate
+// of the category above applies; a nil rate with ok means vendor rates.
+func CommissionRateAt(history []CategoryCommission, t time.Time) (rate *float64, ok bool) {
+	var current *CategoryCommission
+	for i := range history {
+		h := &history[i]
+		if h.EffectiveFrom.After(t) {
+			continue
+		}
+		if current == nil || h.EffectiveFrom.After(current.EffectiveFrom) ||
+			(h.EffectiveFrom.Equal(current.EffectiveFrom) && h.CreatedAt.After(current.CreatedAt)) {
+			current = h
+		}
+	}
+	if current == nil {
+		return nil, false
+	}
+	return current.Rate, true
+}
+
+// SaleItem is part of a vendor's share of an order, with the commission
+// rate it is charged. A nil Rate means the vendor's own TransactionFee.
+// Amount is after the vendor's promotions; PromoCost is what they took off.
+type SaleItem struct {
+	Amount    Money
+	Rate      *float64
+	PromoCost Money
+}
+
+// SaleFee totals a vendor's sale and the platform fee on it, the fee rounded
+// to the minor unit once it has been added up.
+func SaleFee(items []SaleItem, vendorRate float64) (gross, fee Money) {
+	var exact float64
+	for _, item := range items {
+		rate := vendorRate
+		if item.Rate != nil {
+			rate = *item.Rate
+		}
+		gross += item.Amount
+		exact += float64(item.Amount) * rate / 100
+	}
+	return gross, Money(math.Round(exact))
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportTargetCollections maps each reportable content type to the
+// collection it lives in.
+var ReportTargetCollections = map[string]string{
+	"product": "products",
+	"review":  "reviews",
+	"store":   "stores",
+	"message": "messages",
+}
+
+// ReportCategories are the categories a user may file a report under.
+var ReportCategories = []string{
+	"spam", "harassment", "hate", "counterfeit", "prohibited",
+	"misleading", "offensive", "privacy", "scam", "other",
+}
+
+// ListingReportReasons are the reasons a buyer may give when reporting a
+// product or seller from its page.
+var ListingReportReasons = []string{"counterfeit", "prohibited", "scam"}
+
+// A reporter may file at most ReportRateLimit reports per ReportRateWindow.
+const (
+	ReportRateLimit  = 10
+	ReportRateWindow = time.Hour
+)
+
+// Content report states. Open and investigating reports are active; a user
+// can only have one active report per piece of content.
+const (
+	ReportStatusOpen          = "open"
+	ReportStatusInvestigating = "investigating"
+	ReportStatusResolved      = "resolved"
+	ReportStatusDismissed     = "dismissed"
+)
+
+// ReportResolutions describe what was done about a resolved report.
+var ReportResolutions = []string{"content_removed", "content_edited", "user_warned", "user_suspended", "no_violation", "other"}
+
+// ContentReport is a user's report about a product, review, store or message.
+type ContentReport struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReporterID primitive.ObjectID `bson:"reporterID" json:"reporterId"`
+	TargetType string             `bson:"targetType" json:"targetType"`
+	TargetID   primitive.ObjectID `bson:"targetID" json:"targetId"`
+	Category   string             `bson:"category" json:"category"`
+	Details    string             `bson:"details,omitempty" json:"details,omitempty"`
+	Status     string             `bson:"status" json:"status"`
+	Active     bool               `bson:"active" json:"-"`                                // Open or investigating; backs the dedup index
+	Automated  bool               `bson:"automated,omitempty" json:"automated,omitempty"` // Raised by the content filter, with no reporter
+
+	Resolution     string              `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	ResolutionNote string              `bson:"resolutionNote,omitempty" json:"resolutionNote,omitempty"`
+	ResolvedBy     *primitive.ObjectID `bson:"resolvedBy,omitempty" json:"resolvedBy,omitempty"`
+	ResolvedAt     *time.Time          `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
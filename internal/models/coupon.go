@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Coupon discount types
+const (
+	CouponPercentage = "percentage"
+	CouponFixed      = "fixed"
+)
+
+// Reasons a coupon can't be used
+var (
+	ErrCouponNotFound      = errors.New("that code isn't valid")
+	ErrCouponInactive      = errors.New("that code isn't active yet or has expired")
+	ErrCouponUsedUp        = errors.New("that code has been used up")
+	ErrCouponUserLimit     = errors.New("you've already used that code as many times as it allows")
+	ErrCouponMinSpend      = errors.New("your basket doesn't reach that code's minimum spend")
+	ErrCouponNotApplicable = errors.New("that code doesn't apply to anything in your basket")
+)
+
+// Coupon is a promo code buyers enter at checkout. A vendor's coupon only
+// discounts their own products; the platform's (VendorID nil) can discount
+// anyone's. Products and categories narrow it further when given.
+type Coupon struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Code        string               `json:"code" bson:"code"` // Upper case, unique
+	Description string               `json:"description,omitempty" bson:"description,omitempty"`
+	VendorID    primitive.ObjectID   `json:"vendorId" bson:"vendorId"`
+	Type        string               `json:"type" bson:"type"`
+	Value       float64              `json:"value" bson:"value"`                                 // Percent off, or amount off
+	MaxDiscount float64              `json:"maxDiscount,omitempty" bson:"maxDiscount,omitempty"` // Cap on a percentage discount; 0 for none
+	MinSpend    float64              `json:"minSpend,omitempty" bson:"minSpend,omitempty"`       // On the items it applies to
+	ProductIDs  []primitive.ObjectID `json:"productIds,omitempty" bson:"productIds,omitempty"`
+	CategoryIDs []primitive.ObjectID `json:"categoryIds,omitempty" bson:"categoryIds,omitempty"`
+
+	UsageLimit   int `json:"usageLimit,omitempty" bson:"usageLimit,omitempty"`     // Redemptions in all; 0 for no limit
+	PerUserLimit int `json:"perUserLimit,omitempty" bson:"perUserLimit,omitempty"` // Redemptions per buyer; 0 for no limit
+	UsedCount    int `json:"usedCount" bson:"usedCount"`
+
+	StartsAt  *time.Time `json:"startsAt,omitempty" bson:"startsAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	Active    bool       `json:"active" bson:"active"`
+
+	CreatedBy primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CouponInput is the body for creating or replacing a coupon.
+type CouponInput struct {
+	Code         string               `json:"code" validate:"required,min=3,max=32,alphanum"`
+	Description  string               `json:"description" validate:"max=200"`
+	Type         string               `json:"type" validate:"required,oneof=percentage fixed"`
+	Value        float64              `json:"value" validate:"gt=0"`
+	MaxDiscount  float64              `json:"maxDiscount" validate:"gte=0"`
+	MinSpend     float64              `json:"minSpend" validate:"gte=0"`
+	ProductIDs   []primitive.ObjectID `json:"productIds" validate:"max=200"`
+	CategoryIDs  []primitive.ObjectID `json:"categoryIds" validate:"max=50"`
+	UsageLimit   int                  `json:"usageLimit" validate:"gte=0"`
+	PerUserLimit int                  `json:"perUserLimit" validate:"gte=0"`
+	StartsAt     *time.Time           `json:"startsAt"`
+	ExpiresAt    *time.Time           `json:"expiresAt"`
+	Active       *bool                `json:"active"` // Defaults to true
+}
+
+// CouponRedemption records a coupon used on an order.
+type CouponRedemption struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CouponID  primitive.ObjectID `json:"couponId" bson:"couponId"`
+	Code      string             `json:"code" bson:"code"`
+	UserID    primitive.ObjectID `json:"userId" bson:"userId"`
+	OrderID   primitive.ObjectID `json:"orderId" bson:"orderId"`
+	Discount  Money              `json:"discount" bson:"discount"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// NormalizeCouponCode is how codes are stored and looked up.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// DiscountItem is a basket line a coupon might apply to.
+type DiscountItem struct {
+	ProductID  primitive.ObjectID
+	VendorID   primitive.ObjectID
+	CategoryID primitive.ObjectID
+	Subtotal   Money
+}
+
+// CouponDiscount is what a coupon, or an automatic discount, takes off a
+// basket, and how much of that comes off each product.
+type CouponDiscount struct {
+	Code           string                       `json:"code,omitempty"`
+	AutoDiscountID primitive.ObjectID           `json:"autoDiscountId,omitempty"` // Set instead of Code for an automatic discount
+	Name           string                       `json:"name,omitempty"`
+	Amount         Money                        `json:"amount"`
+	ByProduct      map[primitive.ObjectID]Money `json:"-"`
+	VendorFunded   bool                         `json:"-"` // A vendor's coupon, paid for out of their earnings
+}
+
+// Live reports whether the coupon can be used at now, leaving aside how
+// often it has been.
+func (c Coupon) Live(now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return false
+	}
+	return c.ExpiresAt == nil || now.Before(*c.ExpiresAt)
+}
+
+// Covers reports whether the coupon applies to the item.
+func (c Coupon) Covers(item DiscountItem) bool {
+	if !c.VendorID.IsZero() && item.VendorID != c.VendorID {
+		return false
+	}
+	if len(c.ProductIDs) > 0 && !containsObjectID(c.ProductIDs, item.ProductID) {
+		return false
+	}
+	if len(c.CategoryIDs) > 0 && !containsObjectID(c.CategoryIDs, item.CategoryID) {
+		return false
+	}
+	return true
+}
+
+// Apply works out the coupon's discount on items at now, spread across the
+// items it covers in proportion to their subtotals. It doesn't check usage
+// limits, which depend on who is using it.
+func (c Coupon) Apply(items []DiscountItem, now time.Time) (CouponDiscount, error) {
+	if !c.Live(now) {
+		return CouponDiscount{}, ErrCouponInactive
+	}
+
+	var covered []DiscountItem
+	var eligible Money
+	for _, item := range items {
+		if c.Covers(item) && item.Subtotal > 0 {
+			covered = append(covered, item)
+			eligible += item.Subtotal
+		}
+	}
+	if len(covered) == 0 {
+		return CouponDiscount{}, ErrCouponNotApplicable
+	}
+	if eligible < FromFloat(c.MinSpend) {
+		return CouponDiscount{}, ErrCouponMinSpend
+	}
+
+	amount := FromFloat(c.Value)
+	if c.Type == CouponPercentage {
+		amount = eligible.Percent(c.Value)
+		if c.MaxDiscount > 0 {
+			amount = min(amount, FromFloat(c.MaxDiscount))
+		}
+	}
+	amount = min(amount, eligible)
+
+	// Split it by subtotal, the last item taking what rounding leaves
+	discount := CouponDiscount{Code: c.Code, Amount: amount, ByProduct: map[primitive.ObjectID]Money{}, VendorFunded: !c.VendorID.IsZero()}
+	remaining := amount
+	for i, item := range covered {
+		share := remaining
+		if i < len(covered)-1 {
+			share = amount.Share(item.Subtotal, eligible)
+		}
+		discount.ByProduct[item.ProductID] += share
+		remaining -= share
+	}
+	return discount, nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// PaidOrderStatuses are the order states that count toward GMV: money has
+// been captured and the order has not been cancelled or refunded.
+var PaidOrderStatuses = []OrderStatus{StatusPaid, StatusConfirmed, StatusShipped, StatusOutForDelivery, StatusDelivered}
+
+// CategorySales is one row of the top-categories breakdown.
+type CategorySales struct {
+	CategoryID string `bson:"_id" json:"categoryId"`
+	Name       string `bson:"name" json:"name"`
+	Revenue    Money  `bson:"revenue" json:"revenue"`
+	UnitsSold  int    `bson:"unitsSold" json:"unitsSold"`
+	OrderCount int    `bson:"orderCount" json:"orderCount"`
+}
+
+// AdminDashboard aggregates platform activity over [From, To).
+type AdminDashboard struct {
+	Window string    `json:"window"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	GMV               Money            `json:"gmv"`
+	AverageOrderValue Money            `json:"averageOrderValue"`
+	OrderCount        int64            `json:"orderCount"`
+	OrdersByStatus    map[string]int64 `json:"ordersByStatus"`
+
+	NewUsers            int64 `json:"newUsers"`
+	NewVendors          int64 `json:"newVendors"`
+	PendingApplications int64 `json:"pendingApplications"` // Current backlog, not windowed
+
+	RefundCount  int64 `json:"refundCount"`  // Refunds issued in the window, partial ones included
+	RefundVolume Money `json:"refundVolume"` // What they returned
+
+	TopCategories []CategorySales `json:"topCategories"`
+	GeneratedAt   time.Time       `json:"generatedAt"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Weekly digests go out on Monday mornings and cover the previous seven days.
+const (
+	DigestWeekday = time.Monday
+	DigestHour    = 8 // UTC
+	DigestPeriod  = 7 * 24 * time.Hour
+)
+
+// Digest audiences. A vendor who also shops gets both.
+const (
+	DigestAudienceVendor = "vendor"
+	DigestAudienceBuyer  = "buyer"
+)
+
+// NextDigestRun returns the first digest send time strictly after now.
+func NextDigestRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), DigestHour, 0, 0, 0, time.UTC)
+	next = next.AddDate(0, 0, (int(DigestWeekday)-int(next.Weekday())+7)%7)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
+
+// VendorSalesSummary is a vendor's paid sales over a digest period.
+type VendorSalesSummary struct {
+	Revenue     Money                `json:"revenue" bson:"revenue"`
+	UnitsSold   int                  `json:"unitsSold" bson:"unitsSold"`
+	OrderCount  int                  `json:"orderCount" bson:"orderCount"`
+	TopProducts []VendorProductSales `json:"topProducts" bson:"topProducts"`
+}
+
+type VendorProductSales struct {
+	ProductID primitive.ObjectID `json:"productId" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	UnitsSold int                `json:"unitsSold" bson:"unitsSold"`
+	Revenue   Money              `json:"revenue" bson:"revenue"`
+}
+
+// DigestWishlist is a buyer's wishlist with its current products and the
+// prices seen at the last digest.
+type DigestWishlist struct {
+	UserID       primitive.ObjectID `bson:"userId"`
+	Products     []Product          `bson:"products"`
+	DigestPrices map[string]Money   `bson:"digestPrices"`
+}
+
+// WishlistPriceDrop is a wishlisted product that got cheaper since the
+// buyer's last digest.
+type WishlistPriceDrop struct {
+	ProductID     primitive.ObjectID `json:"productId"`
+	Name          string             `json:"name"`
+	Image         string             `json:"image"`
+	PreviousPrice Money              `json:"previousPrice"`
+	Price         Money              `json:"price"`
+}
+
+// EffectivePrice is what a buyer pays for the product: the sale price when
+// one is set below the list price.
+func (p Product) EffectivePrice() Money {
+	if p.SalePrice > 0 && p.SalePrice < p.Price {
+		return p.SalePrice
+	}
+	return p.Price
+}
+
+// WishlistPriceDrops compares current prices with the snapshot taken at the
+// last digest (product ID hex -> price), biggest drop first. Products not in
+// the snapshot were added since and have nothing to compare against.
+func WishlistPriceDrops(products []Product, previous map[string]Money) []WishlistPriceDrop {
+	drops := []WishlistPriceDrop{}
+	for _, p := range products {
+		before, ok := previous[p.ID.Hex()]
+		if !ok || p.Status != ProductStatusActive {
+			continue
+		}
+		if now := p.EffectivePrice(); now < before {
+			drop := WishlistPriceDrop{ProductID: p.ID, Name: p.Name, PreviousPrice: before, Price: now}
+			if len(p.Images) > 0 {
+				drop.Image = p.Images[0]
+			}
+			drops = append(drops, drop)
+		}
+	}
+	sort.SliceStable(drops, func(i, j int) bool {
+		return drops[i].PreviousPrice-drops[i].Price > drops[j].PreviousPrice-drops[j].Price
+	})
+	return drops
+}
+
+// WishlistPriceSnapshot records the current price of each product for the
+// next digest to compare against.
+func WishlistPriceSnapshot(products []Product) map[string]Money {
+	out := make(map[string]Money, len(products))
+	for _, p := range products {
+		out[p.ID.Hex()] = p.EffectivePrice()
+	}
+	return out
+}
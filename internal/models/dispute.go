@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Why a buyer opens a dispute
+const (
+	DisputeNotDelivered   = "not_delivered"
+	DisputeNotAsDescribed = "not_as_described"
+)
+
+// Dispute states. A dispute starts open, waiting on the vendor; the vendor
+// either refunds or contests it, and either side can escalate it to an admin
+// for arbitration.
+const (
+	DisputeStatusOpen            = "open"
+	DisputeStatusVendorResponded = "vendor_responded"
+	DisputeStatusEscalated       = "escalated"
+	DisputeStatusResolved        = "resolved"
+)
+
+// DisputeActiveStatuses are the states in which a dispute can still change.
+var DisputeActiveStatuses = []string{DisputeStatusOpen, DisputeStatusVendorResponded, DisputeStatusEscalated}
+
+// How a dispute was settled
+const (
+	DisputeOutcomeRefund  = "refund_buyer"      // Buyer refunded, clawed back from the vendor
+	DisputeOutcomeRelease = "release_to_vendor" // Vendor keeps the sale and the held funds are released
+	DisputeOutcomeSplit   = "split"             // Buyer refunded part of their claim, the vendor keeps the rest
+)
+
+// Who wrote a dispute message
+const (
+	DisputeRoleBuyer  = "buyer"
+	DisputeRoleVendor = "vendor"
+	DisputeRoleAdmin  = "admin"
+)
+
+const (
+	// DisputeWindow is how long after delivery, or after the order was placed
+	// if it never arrived, a buyer can open a dispute.
+	DisputeWindow = 30 * 24 * time.Hour
+	// DisputeResponseWindow is how long the vendor has to respond before the
+	// buyer can escalate.
+	DisputeResponseWindow = 3 * 24 * time.Hour
+)
+
+var (
+	ErrDisputeNotPaid      = errors.New("only paid orders can be disputed")
+	ErrDisputeWindowClosed = errors.New("the window for disputing this order has closed")
+)
+
+// Dispute is a buyer's claim against one vendor's part of an order. While it
+// is active the vendor's held sale funds for the order don't mature.
+type Dispute struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID     primitive.ObjectID `json:"orderId" bson:"orderId"`
+	OrderNumber string             `json:"orderNumber" bson:"orderNumber"`
+	BuyerID     primitive.ObjectID `json:"buyerId" bson:"buyerId"`
+	VendorID    primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Reason      string             `json:"reason" bson:"reason"` // DisputeNotDelivered or DisputeNotAsDescribed
+	Description string             `json:"description" bson:"description"`
+	Evidence    []Attachment       `json:"evidence,omitempty" bson:"evidence,omitempty"` // Photos and documents from the media service
+	Amount      Money              `json:"amount" bson:"amount"`                         // Refund the buyer is asking for
+
+	Status     string           `json:"status" bson:"status"`
+	Messages   []DisputeMessage `json:"messages" bson:"messages"`     // Oldest first
+	EscrowHeld bool             `json:"escrowHeld" bson:"escrowHeld"` // The vendor's sale was still on hold, and now waits on the dispute
+
+	// Parties an admin has asked for more information who haven't replied yet
+	InfoRequested []string `json:"infoRequested,omitempty" bson:"infoRequested,omitempty"`
+
+	RespondBy   time.Time          `json:"respondBy" bson:"respondBy"` // After this the buyer can escalate without a response
+	EscalatedAt *time.Time         `json:"escalatedAt,omitempty" bson:"escalatedAt,omitempty"`
+	Resolution  *DisputeResolution `json:"resolution,omitempty" bson:"resolution,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// DisputeMessage is one entry in a dispute's thread.
+type DisputeMessage struct {
+	AuthorID  primitive.ObjectID `json:"authorId" bson:"authorId"`
+	Role      string             `json:"role" bson:"role"` // DisputeRoleBuyer, DisputeRoleVendor or DisputeRoleAdmin
+	Body      string             `json:"body" bson:"body"`
+	Evidence  []Attachment       `json:"evidence,omitempty" bson:"evidence,omitempty"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// DisputeResolution is how a dispute was settled and by whom.
+type DisputeResolution struct {
+	Outcome      string             `json:"outcome" bson:"outcome"`
+	RefundAmount Money              `json:"refundAmount,omitempty" bson:"refundAmount,omitempty"`
+	Note         string             `json:"note,omitempty" bson:"note,omitempty"`
+	DecidedBy    primitive.ObjectID `json:"decidedBy" bson:"decidedBy"`
+	DecidedRole  string             `json:"decidedRole" bson:"decidedRole"` // Vendor accepting, buyer withdrawing or admin ruling
+	DecidedAt    time.Time          `json:"decidedAt" bson:"decidedAt"`
+}
+
+// OpenDisputeInput opens a dispute. VendorID picks the vendor on an order
+// with several; Amount defaults to everything the buyer paid that vendor.
+// Evidence is uploaded beforehand to the dispute attachments endpoint.
+type OpenDisputeInput struct {
+	OrderID     primitive.ObjectID  `json:"orderId" validate:"required"`
+	VendorID    *primitive.ObjectID `json:"vendorId"`
+	Reason      string              `json:"reason" validate:"required,oneof=not_delivered not_as_described"`
+	Description string              `json:"description" validate:"required,min=10,max=2000"`
+	Evidence    []Attachment        `json:"evidence" validate:"max=10,dive"`
+	Amount      Money               `json:"amount" validate:"min=0"`
+}
+
+// DisputeMessageInput adds to a dispute's thread.
+type DisputeMessageInput struct {
+	Body     string       `json:"body" validate:"required,max=2000"`
+	Evidence []Attachment `json:"evidence" validate:"max=10,dive"`
+}
+
+// DisputeResponseInput is the vendor's answer: accept and refund the buyer,
+// or contest with their side of the story.
+type DisputeResponseInput struct {
+	Accept   bool         `json:"accept"`
+	Body     string       `json:"body" validate:"required,max=2000"`
+	Evidence []Attachment `json:"evidence" validate:"max=10,dive"`
+}
+
+// ResolveDisputeInput is an admin's ruling. Amount is the buyer's refund:
+// for DisputeOutcomeRefund it defaults to what they asked for, and for
+// DisputeOutcomeSplit it is required and less than that.
+type ResolveDisputeInput struct {
+	Outcome string `json:"outcome" validate:"required,oneof=refund_buyer release_to_vendor split"`
+	Amount  Money  `json:"amount" validate:"min=0"`
+	Note    string `json:"note" validate:"required,max=1000"`
+}
+
+// RequestDisputeInfoInput is an admin asking the buyer, the vendor or both
+// for more before ruling.
+type RequestDisputeInfoInput struct {
+	From string `json:"from" validate:"required,oneof=buyer vendor both"`
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+// DisputeInfoParties is who a request for information with from goes to.
+func DisputeInfoParties(from string) []string {
+	switch from {
+	case DisputeRoleBuyer, DisputeRoleVendor:
+		return []string{from}
+	case "both":
+		return []string{DisputeRoleBuyer, DisputeRoleVendor}
+	}
+	return nil
+}
+
+// RefundsBuyer reports whether the resolution sends money back to the buyer.
+func (r DisputeResolution) RefundsBuyer() bool {
+	return r.Outcome == DisputeOutcomeRefund || r.Outcome == DisputeOutcomeSplit
+}
+
+// IsActive reports whether the dispute is still to be settled.
+func (d Dispute) IsActive() bool {
+	return d.Status != DisputeStatusResolved
+}
+
+// RoleOf is userID's side of the dispute, or "" if they aren't on it.
+func (d Dispute) RoleOf(userID primitive.ObjectID) string {
+	switch userID {
+	case d.BuyerID:
+		return DisputeRoleBuyer
+	case d.VendorID:
+		return DisputeRoleVendor
+	}
+	return ""
+}
+
+// CanEscalate reports whether role may escalate the dispute now. The vendor
+// can escalate any time; the buyer once the vendor has contested it or has
+// let the response window pass.
+func (d Dispute) CanEscalate(role string, now time.Time) bool {
+	switch d.Status {
+	case DisputeStatusVendorResponded:
+		return role == DisputeRoleBuyer || role == DisputeRoleVendor
+	case DisputeStatusOpen:
+		return role == DisputeRoleVendor || (role == DisputeRoleBuyer && now.After(d.RespondBy))
+	}
+	return false
+}
+
+// CheckDisputable reports why the order can't be disputed now, if it can't.
+func CheckDisputable(order Order, now time.Time) error {
+	if order.PaymentStatus != "paid" && order.PaymentStatus != "partially_refunded" {
+		return ErrDisputeNotPaid
+	}
+	if order.Status == StatusCancelled || order.Status == StatusRefunded {
+		return ErrDisputeNotPaid
+	}
+	from := order.CreatedAt
+	if order.DeliveredAt != nil {
+		from = *order.DeliveredAt
+	}
+	if now.Sub(from) > DisputeWindow {
+		return ErrDisputeWindowClosed
+	}
+	return nil
+}
+
+// DisputeClaimLimit is the most a dispute against vendorID can refund: what
+// the buyer paid for that vendor's lines after discounts, and no more than is
+// left to refund on the order.
+func DisputeClaimLimit(order Order, vendorID primitive.ObjectID) Money {
+	var paid Money
+	for _, item := range order.Items {
+		if item.VendorID == vendorID {
+			paid += item.Subtotal - item.Discount
+		}
+	}
+	return min(paid, order.RefundableAmount())
+}
+
+// DisputeClawback is how much of a dispute refund comes back from the vendor:
+// the whole refund, up to what they were credited for their lines.
+func DisputeClawback(order Order, vendorID primitive.ObjectID, amount Money) Money {
+	var credited Money
+	for _, item := range order.Items {
+		if item.VendorID == vendorID {
+			credited += item.VendorAmount()
+		}
+	}
+	return min(amount, credited)
+}
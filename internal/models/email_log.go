@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Email send outcomes
+const (
+	EmailStatusSent   = "sent"
+	EmailStatusFailed = "failed"
+)
+
+// EmailLog records one attempt to send an email, for debugging delivery.
+// Bodies are not stored.
+type EmailLog struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Provider   string             `bson:"provider" json:"provider"`
+	To         string             `bson:"to" json:"to"`
+	Subject    string             `bson:"subject" json:"subject"`
+	Template   string             `bson:"template,omitempty" json:"template,omitempty"` // Empty for free-form emails
+	Status     string             `bson:"status" json:"status"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64              `bson:"durationMs" json:"durationMs"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
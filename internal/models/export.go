@@ -0,0 +1,174 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Export file formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// Export job states. A job is queued, run by a worker, and ready to
+// download until ExportTTL after it finished, when its file is deleted.
+const (
+	ExportStatusQueued  = "queued"
+	ExportStatusRunning = "running"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+	ExportStatusExpired = "expired"
+)
+
+const (
+	// ExportTTL is how long a finished export can be downloaded.
+	ExportTTL = 24 * time.Hour
+	// ExportMaxRows caps the rows of one export.
+	ExportMaxRows = 100000
+	// ExportMaxAttempts is how many times a worker tries an export before
+	// marking it failed.
+	ExportMaxAttempts = 3
+	// ExportMaxPending caps how many exports one user can have queued or
+	// running at once.
+	ExportMaxPending = 5
+)
+
+// ExportJob is a request to produce an endpoint's results as a file,
+// built in the background instead of in one giant response.
+type ExportJob struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Source      string             `json:"source" bson:"source"` // The exportable endpoint, e.g. "admin.orders"
+	Format      string             `json:"format" bson:"format"` // ExportFormatCSV or ExportFormatXLSX
+	Params      map[string]string  `json:"params" bson:"params"` // The endpoint's query parameters
+	RequestedBy primitive.ObjectID `json:"requestedBy" bson:"requestedBy"`
+	Role        string             `json:"-" bson:"role"` // The requester's role when they asked
+	Status      string             `json:"status" bson:"status"`
+
+	Attempts    int        `json:"-" bson:"attempts"`
+	LockedUntil *time.Time `json:"-" bson:"lockedUntil,omitempty"` // While a worker is building it
+	Error       string     `json:"error,omitempty" bson:"error,omitempty"`
+
+	FileURL   string     `json:"-" bson:"fileUrl,omitempty"` // Where the file is stored; downloads go through a signed link
+	FileName  string     `json:"fileName,omitempty" bson:"fileName,omitempty"`
+	Size      int64      `json:"size,omitempty" bson:"size,omitempty"`
+	Rows      int        `json:"rows,omitempty" bson:"rows,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+	// A signed link to the file, valid until ExpiresAt; set when a ready
+	// job is returned, never stored
+	DownloadURL string `json:"downloadUrl,omitempty" bson:"-"`
+
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
+}
+
+// ExportContentTypes are the MIME types of each export format.
+var ExportContentTypes = map[string]string{
+	ExportFormatCSV:  "text/csv",
+	ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportFileName is the name a job's file is downloaded as, e.g.
+// "admin.orders-20260314-0930.csv".
+func (j ExportJob) ExportFileName() string {
+	return fmt.Sprintf("%s-%s.%s", j.Source, j.CreatedAt.UTC().Format("20060102-1504"), j.Format)
+}
+
+// ExportTable is the rows of an export. Cells are strings, json.Numbers,
+// bools or nil, so numbers stay numbers in a spreadsheet.
+type ExportTable struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// TableOf flattens rows, a slice of anything that marshals to JSON
+// objects, into a table. Nested objects become dotted columns
+// ("shippingAddress.city"), in the order they are first seen; arrays are
+// kept as JSON in one cell.
+func TableOf(rows interface{}) (ExportTable, error) {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return ExportTable{}, err
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return ExportTable{}, fmt.Errorf("export rows must be a list: %w", err)
+	}
+
+	table := ExportTable{Columns: []string{}, Rows: make([][]interface{}, 0, len(raws))}
+	columns := map[string]int{}
+	for _, raw := range raws {
+		cells := map[string]interface{}{}
+		if err := flattenJSON(raw, "", cells, func(column string) {
+			if _, ok := columns[column]; !ok {
+				columns[column] = len(table.Columns)
+				table.Columns = append(table.Columns, column)
+			}
+		}); err != nil {
+			return ExportTable{}, err
+		}
+		row := make([]interface{}, len(table.Columns))
+		for column, value := range cells {
+			row[columns[column]] = value
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	// Rows from before a column was first seen are short
+	for i := range table.Rows {
+		for len(table.Rows[i]) < len(table.Columns) {
+			table.Rows[i] = append(table.Rows[i], nil)
+		}
+	}
+	return table, nil
+}
+
+// flattenJSON puts raw's values in cells under prefix, calling seen for
+// each column in document order.
+func flattenJSON(raw json.RawMessage, prefix string, cells map[string]interface{}, seen func(string)) error {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) > 0 && raw[0] == '{':
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return err
+			}
+			if err := flattenJSON(value, prefix+key.(string)+".", cells, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	case prefix == "":
+		return fmt.Errorf("export rows must be objects")
+	case raw[0] == '[':
+		column := prefix[:len(prefix)-1]
+		seen(column)
+		if string(raw) != "[]" {
+			cells[column] = string(raw)
+		}
+		return nil
+	default:
+		column := prefix[:len(prefix)-1]
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		seen(column)
+		cells[column] = value
+		return nil
+	}
+}
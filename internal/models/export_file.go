@@ -0,0 +1,150 @@
+package models
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Write writes the table to w as a CSV or XLSX file.
+func (t ExportTable) Write(w io.Writer, format string) error {
+	switch format {
+	case ExportFormatCSV:
+		return t.writeCSV(w)
+	case ExportFormatXLSX:
+		return t.writeXLSX(w)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func (t ExportTable) writeCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(t.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			record[i] = csvCell(cell)
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// csvCell formats a cell for CSV. Text that a spreadsheet would run as a
+// formula is quoted with a leading apostrophe, so an export opened in Excel
+// can't be made to run one by whoever wrote a product name or address.
+func csvCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// The fixed parts of a single-sheet workbook
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// writeXLSX writes a workbook with the table as its one sheet, header
+// first. Strings are stored inline, so no shared string table is needed.
+func (t ExportTable) writeXLSX(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for _, part := range []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]interface{}, len(t.Columns))
+	for i, column := range t.Columns {
+		header[i] = column
+	}
+	writeXLSXRow(sheet, 1, header)
+	for i, row := range t.Rows {
+		writeXLSXRow(sheet, i+2, row)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if err := sheet.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeXLSXRow(w *bufio.Writer, n int, cells []interface{}) {
+	fmt.Fprintf(w, `<row r="%d">`, n)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(n)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case json.Number:
+			fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, v)
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(w, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		default:
+			fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(w, []byte(fmt.Sprint(v)))
+			w.WriteString(`</t></is></c>`)
+		}
+	}
+	w.WriteString(`</row>`)
+}
+
+// xlsxColumn is the letters of the ith column: A, B, ... Z, AA, AB, ...
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MaxFieldSetFields caps how many fields one ?fields= may name.
+const MaxFieldSetFields = 50
+
+// FieldSet is the fields a client asked a list endpoint for with
+// ?fields=name,price,images, by their JSON names. Lists fetch only those
+// fields from Mongo and send only those, plus the ID. The zero FieldSet is
+// every field.
+type FieldSet struct {
+	fields     []string // JSON names to send, ID first
+	projection bson.M
+}
+
+// ParseFieldSet reads a comma-separated list of the JSON names of model's
+// fields, which must be a struct. Fields the handler needs to build its
+// response, such as createdAt for a page's cursor, are passed as required:
+// they are fetched but only sent if asked for. An empty list is every field.
+func ParseFieldSet(raw string, model interface{}, required ...string) (FieldSet, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return FieldSet{}, nil
+	}
+	names := fieldNames(reflect.TypeOf(model))
+
+	set := FieldSet{fields: []string{"id"}, projection: bson.M{"_id": 1}}
+	seen := map[string]bool{"id": true}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		bsonName, ok := names[name]
+		if !ok {
+			return FieldSet{}, fmt.Errorf("unknown field %q", name)
+		}
+		seen[name] = true
+		set.fields = append(set.fields, name)
+		if bsonName != "" {
+			set.projection[bsonName] = 1
+		}
+	}
+	if len(set.fields) > MaxFieldSetFields+1 {
+		return FieldSet{}, fmt.Errorf("at most %d fields can be asked for", MaxFieldSetFields)
+	}
+	for _, name := range required {
+		if bsonName := names[name]; bsonName != "" {
+			set.projection[bsonName] = 1
+		}
+	}
+	return set, nil
+}
+
+// fieldNames maps the JSON name of each field of struct type t to its BSON
+// name, or to "" for fields that aren't stored, which are filled in after
+// fetching.
+func fieldNames(t reflect.Type) map[string]string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || jsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = f.Name
+		}
+		bsonName, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		switch bsonName {
+		case "-":
+			bsonName = ""
+		case "":
+			bsonName = strings.ToLower(f.Name)
+		}
+		names[jsonName] = bsonName
+	}
+	return names
+}
+
+// All reports whether the set is every field.
+func (s FieldSet) All() bool {
+	return s.fields == nil
+}
+
+// Projection is the Mongo projection fetching the set's fields, or nil for
+// every field.
+func (s FieldSet) Projection() bson.M {
+	return s.projection
+}
+
+// Select trims each item of list, a slice, to the set's fields. It returns
+// list as it is for every field.
+func (s FieldSet) Select(list interface{}) (interface{}, error) {
+	if s.All() {
+		return list, nil
+	}
+	body, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	trimmed := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		trimmed[i] = make(map[string]json.RawMessage, len(s.fields))
+		for _, name := range s.fields {
+			if value, ok := item[name]; ok {
+				trimmed[i][name] = value
+			}
+		}
+	}
+	return trimmed, nil
+}
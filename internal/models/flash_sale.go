@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxFlashSaleDuration is the longest a flash sale can run.
+const MaxFlashSaleDuration = 48 * time.Hour
+
+// Flash sale states, as shown to buyers
+const (
+	FlashSaleUpcoming = "upcoming"
+	FlashSaleLive     = "live"
+	FlashSaleSoldOut  = "sold_out"
+	FlashSaleEnded    = "ended"
+)
+
+// Reasons a flash sale price can't be had
+var (
+	ErrFlashSaleNotFound  = errors.New("that flash sale doesn't exist")
+	ErrFlashSaleNotLive   = errors.New("that flash sale isn't running")
+	ErrFlashSaleSoldOut   = errors.New("that flash sale has sold out")
+	ErrFlashSaleUserLimit = errors.New("you've reached that flash sale's limit per customer")
+)
+
+// FlashSale is a limited number of units of one product at a special price
+// for a short window. Sold is only ever changed by the single conditional
+// update that claims units at checkout, so a rush of orders can't sell
+// more than Quantity.
+type FlashSale struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID      primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	ProductID     primitive.ObjectID `json:"productId" bson:"productId"`
+	ProductName   string             `json:"productName" bson:"productName"`
+	Image         string             `json:"image,omitempty" bson:"image,omitempty"`
+	Price         Money              `json:"price" bson:"price"`
+	OriginalPrice Money              `json:"originalPrice" bson:"originalPrice"` // List price when the sale was set up
+	Quantity      int                `json:"quantity" bson:"quantity"`
+	Sold          int                `json:"sold" bson:"sold"`
+	PerUserLimit  int                `json:"perUserLimit,omitempty" bson:"perUserLimit,omitempty"` // 0 for no limit
+	StartsAt      time.Time          `json:"startsAt" bson:"startsAt"`
+	EndsAt        time.Time          `json:"endsAt" bson:"endsAt"`
+	Cancelled     bool               `json:"cancelled" bson:"cancelled"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// FlashSaleInput is the body for setting up a flash sale.
+type FlashSaleInput struct {
+	ProductID    primitive.ObjectID `json:"productId" validate:"required"`
+	Price        Money              `json:"price" validate:"gt=0"`
+	Quantity     int                `json:"quantity" validate:"gt=0,lte=10000"`
+	PerUserLimit int                `json:"perUserLimit" validate:"gte=0,lte=100"`
+	StartsAt     time.Time          `json:"startsAt" validate:"required"`
+	EndsAt       time.Time          `json:"endsAt" validate:"required"`
+}
+
+// FlashSalePurchase counts the units one buyer has claimed from a flash
+// sale, so its per-customer limit holds across orders.
+type FlashSalePurchase struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FlashSaleID primitive.ObjectID `json:"flashSaleId" bson:"flashSaleId"`
+	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
+	Quantity    int                `json:"quantity" bson:"quantity"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// FlashSaleView is a flash sale as listed to buyers, with a countdown to
+// whichever of its start or end comes next.
+type FlashSaleView struct {
+	FlashSale
+	State     string `json:"state"`
+	Remaining int    `json:"remaining"`
+	StartsIn  int64  `json:"startsIn"` // Seconds; 0 once started
+	EndsIn    int64  `json:"endsIn"`   // Seconds; 0 once ended
+}
+
+// Remaining is how many units are left at the flash price.
+func (f FlashSale) Remaining() int {
+	if f.Sold >= f.Quantity {
+		return 0
+	}
+	return f.Quantity - f.Sold
+}
+
+// State is where the sale stands at the given time.
+func (f FlashSale) State(at time.Time) string {
+	switch {
+	case f.Cancelled || !at.Before(f.EndsAt):
+		return FlashSaleEnded
+	case at.Before(f.StartsAt):
+		return FlashSaleUpcoming
+	case f.Remaining() == 0:
+		return FlashSaleSoldOut
+	}
+	return FlashSaleLive
+}
+
+// View is the sale as listed to buyers at the given time.
+func (f FlashSale) View(at time.Time) FlashSaleView {
+	view := FlashSaleView{FlashSale: f, State: f.State(at), Remaining: f.Remaining()}
+	if at.Before(f.StartsAt) {
+		view.StartsIn = int64(f.StartsAt.Sub(at).Seconds())
+	}
+	if at.Before(f.EndsAt) {
+		view.EndsIn = int64(f.EndsAt.Sub(at).Seconds())
+	}
+	return view
+}
+
+// CheckLimit returns ErrFlashSaleUserLimit when a buyer who has already
+// claimed bought units would go over the per-customer limit by wanting
+// quantity more.
+func (f FlashSale) CheckLimit(bought, quantity int) error {
+	if f.PerUserLimit > 0 && bought+quantity > f.PerUserLimit {
+		return ErrFlashSaleUserLimit
+	}
+	return nil
+}
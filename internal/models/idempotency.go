@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it
+// has finished, the response to replay when the client retries it.
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      string             `json:"userId" bson:"userId"`
+	Key         string             `json:"key" bson:"key"`
+	RequestHash string             `json:"requestHash" bson:"requestHash"` // Of the method, path and body
+	Completed   bool               `json:"completed" bson:"completed"`
+	Status      int                `json:"status,omitempty" bson:"status,omitempty"`
+	ContentType string             `json:"contentType,omitempty" bson:"contentType,omitempty"`
+	Body        []byte             `json:"-" bson:"body,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	ExpiresAt   time.Time          `json:"expiresAt" bson:"expiresAt"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockOnHand is the units of p in stock, across its variants if it has
+// them.
+func (p Product) StockOnHand() int {
+	if !p.HasVariants || len(p.Variants) == 0 {
+		return p.Stock
+	}
+	stock := 0
+	for _, v := range p.Variants {
+		stock += v.Stock
+	}
+	return stock
+}
+
+// InventorySales is how one product sold: units and revenue from paid
+// orders over a report's range, and when it last sold at all.
+type InventorySales struct {
+	ProductID  primitive.ObjectID `bson:"_id"`
+	UnitsSold  int                `bson:"unitsSold"`
+	Revenue    Money              `bson:"revenue"`
+	LastSoldAt *time.Time         `bson:"lastSoldAt"`
+}
+
+// InventoryItem is one product's stock against its sales. SellThroughRate
+// is the percentage of the units available over the range (sold plus still
+// in stock) that sold; DaysOfCover is how long the stock lasts at the
+// range's pace, nil if nothing sold.
+type InventoryItem struct {
+	ProductID       primitive.ObjectID `json:"productId"`
+	Name            string             `json:"name"`
+	SKU             string             `json:"sku,omitempty"`
+	Status          ProductStatus      `json:"status"`
+	Stock           int                `json:"stock"`
+	StockValue      Money              `json:"stockValue"` // At cost, or at price where no cost is set
+	UnitsSold       int                `json:"unitsSold"`
+	Revenue         Money              `json:"revenue"`
+	SellThroughRate float64            `json:"sellThroughRate"`
+	DailySales      float64            `json:"dailySales"`
+	DaysOfCover     *float64           `json:"daysOfCover"`
+	LastSoldAt      *time.Time         `json:"lastSoldAt"`
+	ListedAt        time.Time          `json:"listedAt"`
+	DeadStock       bool               `json:"deadStock"`
+}
+
+// InventoryTotals sums an inventory report.
+type InventoryTotals struct {
+	Products        int     `json:"products"`
+	Stock           int     `json:"stock"`
+	StockValue      Money   `json:"stockValue"`
+	UnitsSold       int     `json:"unitsSold"`
+	SellThroughRate float64 `json:"sellThroughRate"`
+	DeadStock       int     `json:"deadStock"`      // Products
+	DeadStockValue  Money   `json:"deadStockValue"` // Tied up in them
+}
+
+// InventoryReport is a vendor's stock turnover over [From, To), to show
+// what is selling, what will run out and what to discount. Products are
+// slowest-selling first; DeadStock is the live products in stock the whole
+// range that sold nothing, the most value tied up first.
+type InventoryReport struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Days      float64         `json:"days"`
+	Totals    InventoryTotals `json:"totals"`
+	Products  []InventoryItem `json:"products"`
+	DeadStock []InventoryItem `json:"deadStock"`
+}
+
+// BuildInventoryReport combines a vendor's products with their sales over
+// [from, to).
+func BuildInventoryReport(products []Product, sales []InventorySales, from, to time.Time) InventoryReport {
+	days := to.Sub(from).Hours() / 24
+	report := InventoryReport{
+		From:      from,
+		To:        to,
+		Days:      math.Round(days*10) / 10,
+		Products:  make([]InventoryItem, 0, len(products)),
+		DeadStock: []InventoryItem{},
+	}
+	sold := map[primitive.ObjectID]InventorySales{}
+	for _, s := range sales {
+		sold[s.ProductID] = s
+	}
+
+	for _, p := range products {
+		s := sold[p.ID]
+		unitCost := p.CostPrice
+		if unitCost == 0 {
+			unitCost = p.Price
+		}
+		item := InventoryItem{
+			ProductID:  p.ID,
+			Name:       p.Name,
+			SKU:        p.SKU,
+			Status:     p.Status,
+			Stock:      p.StockOnHand(),
+			UnitsSold:  s.UnitsSold,
+			Revenue:    s.Revenue,
+			LastSoldAt: s.LastSoldAt,
+			ListedAt:   p.CreatedAt,
+		}
+		if item.Stock < 0 { // Backordered
+			item.Stock = 0
+		}
+		item.StockValue = unitCost.Mul(item.Stock)
+		item.SellThroughRate = percentOf(int64(item.UnitsSold), int64(item.UnitsSold+item.Stock))
+		if days > 0 {
+			item.DailySales = math.Round(float64(item.UnitsSold)/days*100) / 100
+		}
+		if item.UnitsSold > 0 {
+			cover := math.Round(float64(item.Stock)/(float64(item.UnitsSold)/days)*10) / 10
+			item.DaysOfCover = &cover
+		}
+		// Only products that could have sold all along count as dead
+		item.DeadStock = p.Status == ProductStatusActive && item.Stock > 0 && item.UnitsSold == 0 && !p.CreatedAt.After(from)
+
+		report.Totals.Products++
+		report.Totals.Stock += item.Stock
+		report.Totals.StockValue += item.StockValue
+		report.Totals.UnitsSold += item.UnitsSold
+		if item.DeadStock {
+			report.Totals.DeadStock++
+			report.Totals.DeadStockValue += item.StockValue
+			report.DeadStock = append(report.DeadStock, item)
+		}
+		report.Products = append(report.Products, item)
+	}
+	report.Totals.SellThroughRate = percentOf(int64(report.Totals.UnitsSold), int64(report.Totals.UnitsSold+report.Totals.Stock))
+
+	sort.SliceStable(report.Products, func(i, j int) bool {
+		a, b := report.Products[i], report.Products[j]
+		if a.SellThroughRate != b.SellThroughRate {
+			return a.SellThroughRate < b.SellThroughRate
+		}
+		return a.StockValue > b.StockValue
+	})
+	sort.SliceStable(report.DeadStock, func(i, j int) bool {
+		return report.DeadStock[i].StockValue > report.DeadStock[j].StockValue
+	})
+	return report
+}
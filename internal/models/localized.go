@@ -0,0 +1,45 @@
+package models
+
+import "github.com/developia-II/ecommerce-backend/internal/i18n"
+
+// LocalizedText is a product's or category's name and description in one
+// locale. Either may be left out to fall back to the default locale's.
+type LocalizedText struct {
+	Name        string `json:"name,omitempty" bson:"name,omitempty" validate:"max=200"`
+	Description string `json:"description,omitempty" bson:"description,omitempty" validate:"max=5000"`
+}
+
+// Translations are content in locales other than the default, keyed by
+// locale, e.g. "fr". Name and Description themselves are always in the
+// default locale.
+type Translations map[string]LocalizedText
+
+// In returns name and description in locale, falling back to the ones
+// given for anything not translated.
+func (t Translations) In(locale, name, description string) (string, string) {
+	if locale == i18n.DefaultLocale {
+		return name, description
+	}
+	text := t[locale]
+	if text.Name != "" {
+		name = text.Name
+	}
+	if text.Description != "" {
+		description = text.Description
+	}
+	return name, description
+}
+
+// Localize puts the product's name and description in locale. Public
+// pages show one locale, so the translations themselves are dropped.
+func (p *Product) Localize(locale string) {
+	p.Name, p.Description = p.Translations.In(locale, p.Name, p.Description)
+	p.Translations = nil
+}
+
+// Localize puts the category's name and description in locale, dropping
+// the translations as Product.Localize does.
+func (c *Category) Localize(locale string) {
+	c.Name, c.Description = c.Translations.In(locale, c.Name, c.Description)
+	c.Translations = nil
+}
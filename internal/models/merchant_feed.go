@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Google Merchant Center availability values
+const (
+	FeedInStock    = "in_stock"
+	FeedOutOfStock = "out_of_stock"
+	FeedBackorder  = "backorder"
+)
+
+// Limits Merchant Center puts on text attributes, in characters
+const (
+	maxFeedTitle       = 150
+	maxFeedDescription = 5000
+	maxFeedExtraImages = 10
+)
+
+// MerchantFeedItem is a product as Google Merchant Center reads it, one
+// <item> of the XML feed or one row of the TSV feed. Attributes the product
+// doesn't have are left empty and omitted.
+type MerchantFeedItem struct {
+	ID                   string   `xml:"g:id"`
+	Title                string   `xml:"g:title"`
+	Description          string   `xml:"g:description"`
+	Link                 string   `xml:"g:link"`
+	ImageLink            string   `xml:"g:image_link,omitempty"`
+	AdditionalImageLinks []string `xml:"g:additional_image_link,omitempty"`
+	Availability         string   `xml:"g:availability"`
+	Price                string   `xml:"g:price"`
+	SalePrice            string   `xml:"g:sale_price,omitempty"`
+	Brand                string   `xml:"g:brand,omitempty"`
+	GTIN                 string   `xml:"g:gtin,omitempty"`
+	MPN                  string   `xml:"g:mpn,omitempty"`
+	IdentifierExists     string   `xml:"g:identifier_exists,omitempty"` // "no" when there's no brand, GTIN or MPN
+	Condition            string   `xml:"g:condition"`
+	ProductType          string   `xml:"g:product_type,omitempty"` // The category path, "Fashion > Shoes"
+	ShippingWeight       string   `xml:"g:shipping_weight,omitempty"`
+}
+
+// MerchantFeedColumns is the TSV feed's header row, in the order of
+// MerchantFeedItem.TSVRow.
+var MerchantFeedColumns = []string{
+	"id", "title", "description", "link", "image_link", "additional_image_link",
+	"availability", "price", "sale_price", "brand", "gtin", "mpn",
+	"identifier_exists", "condition", "product_type", "shipping_weight",
+}
+
+// NewMerchantFeedItem describes p for Merchant Center. link is the
+// product's page; prices are in currency, an ISO 4217 code; categories are
+// the path down to p's category.
+func NewMerchantFeedItem(p Product, link, currency string, categories []CategoryCrumb) MerchantFeedItem {
+	item := MerchantFeedItem{
+		ID:           p.ID.Hex(),
+		Title:        truncateRunes(p.Name, maxFeedTitle),
+		Description:  truncateRunes(p.Description, maxFeedDescription),
+		Link:         link,
+		Availability: feedAvailability(p),
+		Price:        feedPrice(p.Price, currency),
+		Brand:        p.Brand,
+		GTIN:         p.Barcode,
+		MPN:          p.SKU,
+		Condition:    "new",
+	}
+	if item.Description == "" {
+		item.Description = item.Title
+	}
+	if len(p.Images) > 0 {
+		item.ImageLink = p.Images[0]
+		extra := p.Images[1:]
+		if len(extra) > maxFeedExtraImages {
+			extra = extra[:maxFeedExtraImages]
+		}
+		item.AdditionalImageLinks = extra
+	}
+	if p.SalePrice > 0 && p.SalePrice < p.Price {
+		item.SalePrice = feedPrice(p.SalePrice, currency)
+	}
+	if item.GTIN == "" && (item.Brand == "" || item.MPN == "") {
+		item.IdentifierExists = "no"
+	}
+	if len(categories) > 0 {
+		names := make([]string, len(categories))
+		for i, crumb := range categories {
+			names[i] = crumb.Name
+		}
+		item.ProductType = strings.Join(names, " > ")
+	}
+	if p.Dimensions.Weight > 0 {
+		item.ShippingWeight = fmt.Sprintf("%.2f kg", p.Dimensions.Weight)
+	}
+	return item
+}
+
+// TSVRow is the item's values in MerchantFeedColumns order. Tabs and line
+// breaks would split the row, so runs of whitespace become single spaces.
+func (i MerchantFeedItem) TSVRow() []string {
+	row := []string{
+		i.ID, i.Title, i.Description, i.Link, i.ImageLink, strings.Join(i.AdditionalImageLinks, ","),
+		i.Availability, i.Price, i.SalePrice, i.Brand, i.GTIN, i.MPN,
+		i.IdentifierExists, i.Condition, i.ProductType, i.ShippingWeight,
+	}
+	for n, value := range row {
+		row[n] = strings.Join(strings.Fields(value), " ")
+	}
+	return row
+}
+
+// feedAvailability is in stock when the product or any of its variants is,
+// else on backorder if the vendor takes them.
+func feedAvailability(p Product) string {
+	stock := p.Stock
+	if p.HasVariants {
+		stock = 0
+		for _, v := range p.Variants {
+			stock += v.Stock
+		}
+	}
+	switch {
+	case stock > 0:
+		return FeedInStock
+	case p.AllowBackorder:
+		return FeedBackorder
+	}
+	return FeedOutOfStock
+}
+
+func feedPrice(amount Money, currency string) string {
+	return amount.String() + " " + currency
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// What a conversation is about
+const (
+	ConversationProduct = "product" // Pre-sale questions
+	ConversationOrder   = "order"   // Fulfilment issues
+)
+
+// messagePreviewLength is how much of a message conversation lists and
+// notifications show.
+const messagePreviewLength = 80
+
+// ErrNotParticipant is returned when a user acts on a conversation they
+// aren't part of.
+var ErrNotParticipant = errors.New("you aren't part of this conversation")
+
+// Conversation is a thread between a buyer and a vendor about one product or
+// one order. There is at most one per buyer, vendor and subject.
+type Conversation struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	BuyerID   primitive.ObjectID  `json:"buyerId" bson:"buyerId"`
+	VendorID  primitive.ObjectID  `json:"vendorId" bson:"vendorId"`
+	Subject   string              `json:"subject" bson:"subject"` // ConversationProduct or ConversationOrder
+	ProductID *primitive.ObjectID `json:"productId,omitempty" bson:"productId,omitempty"`
+	OrderID   *primitive.ObjectID `json:"orderId,omitempty" bson:"orderId,omitempty"`
+	Title     string              `json:"title" bson:"title"` // Product name or order number, when started
+
+	LastMessage *MessagePreview `json:"lastMessage,omitempty" bson:"lastMessage,omitempty"`
+
+	// Messages each side hasn't read yet
+	BuyerUnread  int `json:"-" bson:"buyerUnread"`
+	VendorUnread int `json:"-" bson:"vendorUnread"`
+	Unread       int `json:"unread" bson:"-"` // For whoever is looking
+
+	// For the vendor's response time: when the buyer's oldest unanswered
+	// message was sent, and when the away message last went out
+	AwaitingReplySince *time.Time `json:"-" bson:"awaitingReplySince,omitempty"`
+	AutoRepliedAt      *time.Time `json:"-" bson:"autoRepliedAt,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"` // Last message
+}
+
+// MessagePreview is the latest message, shown in conversation lists.
+type MessagePreview struct {
+	SenderID  primitive.ObjectID `json:"senderId" bson:"senderId"`
+	Body      string             `json:"body" bson:"body"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Message is one message in a conversation.
+type Message struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ConversationID primitive.ObjectID `json:"conversationId" bson:"conversationId"`
+	SenderID       primitive.ObjectID `json:"senderId" bson:"senderId"`
+	Body           string             `json:"body" bson:"body"`
+	Attachments    []Attachment       `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	AutoReply      bool               `json:"autoReply,omitempty" bson:"autoReply,omitempty"`     // The vendor's away message
+	ResponseTime   int64              `json:"-" bson:"responseSeconds,omitempty"`                 // Seconds since the buyer was first left waiting, on vendor replies
+	DeliveredAt    *time.Time         `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"` // Reached one of the recipient's devices
+	ReadAt         *time.Time         `json:"readAt,omitempty" bson:"readAt,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Receipts sent back to a message's sender
+const (
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+)
+
+// SendMessageInput sends a message: a reply in an existing conversation, or
+// a message about a product or order, which continues the conversation about
+// it if there is one. VendorID picks the vendor on an order with several.
+// A message needs a body, attachments uploaded beforehand, or both; vendors
+// can send one of their canned responses with TemplateID instead of a body.
+type SendMessageInput struct {
+	ConversationID *primitive.ObjectID `json:"conversationId"`
+	ProductID      *primitive.ObjectID `json:"productId"`
+	OrderID        *primitive.ObjectID `json:"orderId"`
+	VendorID       *primitive.ObjectID `json:"vendorId"`
+	Body           string              `json:"body" validate:"max=2000"`
+	Attachments    []Attachment        `json:"attachments" validate:"max=5,dive"`
+	TemplateID     *primitive.ObjectID `json:"templateId"`
+}
+
+// HasParticipant reports whether userID is the conversation's buyer or
+// vendor.
+func (c Conversation) HasParticipant(userID primitive.ObjectID) bool {
+	return userID == c.BuyerID || userID == c.VendorID
+}
+
+// Recipient is the other side of the conversation from sender.
+func (c Conversation) Recipient(sender primitive.ObjectID) primitive.ObjectID {
+	if sender == c.BuyerID {
+		return c.VendorID
+	}
+	return c.BuyerID
+}
+
+// UnreadFor is how many messages userID hasn't read.
+func (c Conversation) UnreadFor(userID primitive.ObjectID) int {
+	if userID == c.BuyerID {
+		return c.BuyerUnread
+	}
+	return c.VendorUnread
+}
+
+// MessagePreviewText shortens a message body for lists and notifications.
+func MessagePreviewText(body string) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(body) <= messagePreviewLength {
+		return body
+	}
+	runes := []rune(body)
+	return strings.TrimSpace(string(runes[:messagePreviewLength-1])) + "…"
+}
+
+// Preview is what lists and notifications show for the message: its body,
+// or what was attached when there is no text.
+func (m Message) Preview() string {
+	if strings.TrimSpace(m.Body) != "" || len(m.Attachments) == 0 {
+		return MessagePreviewText(m.Body)
+	}
+	if len(m.Attachments) > 1 {
+		return "Sent " + strconv.Itoa(len(m.Attachments)) + " attachments"
+	}
+	if m.Attachments[0].IsImage() {
+		return "Sent a photo"
+	}
+	return "Sent a file"
+}
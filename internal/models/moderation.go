@@ -0,0 +1,136 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Product moderation states, stored on Product.ModerationStatus. Unpublished
+// and deleted listings are moved to ProductStatusRemoved so every public
+// query, which only shows active products, drops them.
+const (
+	ModerationFlagged        = "flagged"
+	ModerationEditsRequested = "edits_requested"
+	ModerationUnpublished    = "unpublished"
+	ModerationDeleted        = "deleted"
+)
+
+// Moderation case states
+const (
+	CaseStatusOpen      = "open"
+	CaseStatusActioned  = "actioned"
+	CaseStatusDismissed = "dismissed"
+)
+
+// Moderator actions
+const (
+	ModerationActionUnpublish    = "unpublish"
+	ModerationActionRequestEdits = "request_edits"
+	ModerationActionDelete       = "delete"
+	ModerationActionDismiss      = "dismiss"
+)
+
+// Flag sources
+const (
+	FlagSourceAutomated  = "automated"
+	FlagSourceUserReport = "user_report"
+	FlagSourceAdmin      = "admin"
+	FlagSourceResubmit   = "resubmission" // Vendor edited a listing after edits were requested
+)
+
+// ProductReportReasons are the reasons a buyer may give when reporting a listing.
+var ProductReportReasons = []string{"counterfeit", "prohibited", "misleading", "offensive", "spam", "other"}
+
+// ModerationFlag is one signal that a listing needs review.
+type ModerationFlag struct {
+	Source     string              `bson:"source" json:"source"`
+	ReporterID *primitive.ObjectID `bson:"reporterID,omitempty" json:"reporterId,omitempty"`
+	Reason     string              `bson:"reason" json:"reason"`
+	Details    string              `bson:"details,omitempty" json:"details,omitempty"`
+	Keywords   []string            `bson:"keywords,omitempty" json:"keywords,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// ModerationAppeal is a vendor's request to reverse a moderation action.
+type ModerationAppeal struct {
+	Message     string              `bson:"message" json:"message"`
+	Status      string              `bson:"status" json:"status"` // "pending", "approved", "rejected"
+	SubmittedAt time.Time           `bson:"submittedAt" json:"submittedAt"`
+	ResolvedAt  *time.Time          `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+	ResolvedBy  *primitive.ObjectID `bson:"resolvedBy,omitempty" json:"resolvedBy,omitempty"`
+	Note        string              `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// ModerationCase groups the flags raised against a product into a single
+// queue item. A product has at most one open case at a time.
+type ModerationCase struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID   primitive.ObjectID `bson:"productID" json:"productId"`
+	VendorID    primitive.ObjectID `bson:"vendorID" json:"vendorId"`
+	ProductName string             `bson:"productName" json:"productName"`
+	Status      string             `bson:"status" json:"status"`
+	Flags       []ModerationFlag   `bson:"flags" json:"flags"`
+	ReportCount int                `bson:"reportCount" json:"reportCount"`
+
+	// Set when a moderator acts on the case
+	Action      string              `bson:"action,omitempty" json:"action,omitempty"`
+	ActionNote  string              `bson:"actionNote,omitempty" json:"actionNote,omitempty"`
+	ModeratorID *primitive.ObjectID `bson:"moderatorID,omitempty" json:"moderatorId,omitempty"`
+	ActionedAt  *time.Time          `bson:"actionedAt,omitempty" json:"actionedAt,omitempty"`
+
+	// The product's status before the action, restored if an appeal succeeds
+	PreviousProductStatus ProductStatus `bson:"previousProductStatus,omitempty" json:"previousProductStatus,omitempty"`
+
+	Appeal *ModerationAppeal `bson:"appeal,omitempty" json:"appeal,omitempty"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// DefaultModerationKeywords are always screened for, in addition to any
+// configured through MODERATION_KEYWORDS.
+var DefaultModerationKeywords = []string{
+	"counterfeit", "replica", "fake id", "knockoff", "stolen",
+	"cocaine", "heroin", "meth", "firearm", "ammunition", "explosive",
+}
+
+// MatchModerationKeywords returns the keywords that appear in any of the
+// given texts, case-insensitively and each at most once.
+func MatchModerationKeywords(keywords []string, texts ...string) []string {
+	haystack := strings.ToLower(strings.Join(texts, " \n "))
+	var hits []string
+	seen := make(map[string]bool)
+	for _, kw := range keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" || seen[kw] {
+			continue
+		}
+		seen[kw] = true
+		if containsWord(haystack, kw) {
+			hits = append(hits, kw)
+		}
+	}
+	return hits
+}
+
+// containsWord reports whether needle occurs in s on word boundaries, so
+// "meth" doesn't match "method".
+func containsWord(s, needle string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], needle)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(needle)
+		if (start == 0 || !isWordByte(s[start-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+}
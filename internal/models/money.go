@@ -0,0 +1,155 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Money is an amount in the currency's minor unit (cents, kobo), so sums and
+// splits are exact. It is stored as an int64 count of minor units and shown
+// in JSON as a decimal number of major units, e.g. 1999 is 19.99.
+//
+// Documents written before amounts were kept this way hold a double of major
+// units; those are read as such until database.MigrateMoney has converted
+// them.
+type Money int64
+
+// minorUnits is how many minor units make a major one.
+const minorUnits = 100
+
+// FromFloat is the nearest Money to f major units.
+func FromFloat(f float64) Money {
+	return Money(math.Round(f * minorUnits))
+}
+
+// Float64 is m in major units, for display and for rates worked out in
+// floating point.
+func (m Money) Float64() float64 {
+	return float64(m) / minorUnits
+}
+
+// Cents is m in minor units, as payment providers take amounts.
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Mul is m times n, as for a line of n items at m each.
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+// Percent is p percent of m, rounded to the nearest minor unit.
+func (m Money) Percent(p float64) Money {
+	return Money(math.Round(float64(m) * p / 100))
+}
+
+// Share is the part of m that part is of whole, rounded to the nearest
+// minor unit; it is zero when whole is.
+func (m Money) Share(part, whole Money) Money {
+	if whole == 0 {
+		return 0
+	}
+	r := new(big.Rat).SetFrac64(int64(m)*int64(part), int64(whole))
+	return ratMoney(r)
+}
+
+// String formats m in major units, e.g. "19.99" or "-0.50".
+func (m Money) String() string {
+	sign := ""
+	n := int64(m)
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, n/minorUnits, n%minorUnits)
+}
+
+// MarshalJSON writes m as a number of major units.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a number of major units exactly, rounding anything
+// finer than a minor unit to the nearest one.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	r, ok := new(big.Rat).SetString(string(data))
+	if !ok || data[0] == '"' {
+		return fmt.Errorf("money: %s is not an amount", data)
+	}
+	*m = ratMoney(r.Mul(r, big.NewRat(minorUnits, 1)))
+	return nil
+}
+
+// MarshalBSONValue stores m as an int64 of minor units.
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.Int64, bsoncore.AppendInt64(nil, int64(m)), nil
+}
+
+// UnmarshalBSONValue reads integers as minor units and, for documents from
+// before the migration, doubles and decimals as major units.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Int64:
+		n, _, ok := bsoncore.ReadInt64(data)
+		if !ok {
+			return fmt.Errorf("money: malformed int64")
+		}
+		*m = Money(n)
+	case bsontype.Int32:
+		n, _, ok := bsoncore.ReadInt32(data)
+		if !ok {
+			return fmt.Errorf("money: malformed int32")
+		}
+		*m = Money(n)
+	case bsontype.Double:
+		f, _, ok := bsoncore.ReadDouble(data)
+		if !ok {
+			return fmt.Errorf("money: malformed double")
+		}
+		*m = FromFloat(f)
+	case bsontype.Decimal128:
+		d, _, ok := bsoncore.ReadDecimal128(data)
+		if !ok {
+			return fmt.Errorf("money: malformed decimal")
+		}
+		return m.setDecimal(d)
+	case bsontype.Null, bsontype.Undefined:
+		*m = 0
+	default:
+		return fmt.Errorf("money: cannot read %s", t)
+	}
+	return nil
+}
+
+func (m *Money) setDecimal(d primitive.Decimal128) error {
+	r, ok := new(big.Rat).SetString(d.String())
+	if !ok {
+		return fmt.Errorf("money: %s is not an amount", d)
+	}
+	*m = ratMoney(r.Mul(r, big.NewRat(minorUnits, 1)))
+	return nil
+}
+
+// ratMoney rounds r minor units to the nearest whole one, halves away from
+// zero as math.Round does.
+func ratMoney(r *big.Rat) Money {
+	num, den := new(big.Int).Set(r.Num()), r.Denom()
+	neg := num.Sign() < 0
+	num.Abs(num)
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if neg {
+		q.Neg(q)
+	}
+	return Money(q.Int64())
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types
+const (
+	NotificationOrderUpdate    = "order_update"
+	NotificationNewOrder       = "new_order"
+	NotificationRefund         = "refund"
+	NotificationApplication    = "application"
+	NotificationTierRequest    = "tier_request"
+	NotificationNewReview      = "new_review"
+	NotificationReviewResponse = "review_response"
+	NotificationMessage        = "message"
+	NotificationOutForDelivery = "out_for_delivery"
+	NotificationPayoutSent     = "payout_sent"
+	NotificationDispute        = "dispute"
+	NotificationDigest         = "digest"   // Weekly summary emails
+	NotificationSecurity       = "security" // Codes and account security; never optional
+)
+
+// Notification is an in-app alert shown to a single user.
+type Notification struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID     `bson:"userId" json:"userId"`
+	Type      string                 `bson:"type" json:"type"`
+	Title     string                 `bson:"title" json:"title"`
+	Body      string                 `bson:"body" json:"body"`
+	Link      string                 `bson:"link,omitempty" json:"link,omitempty"` // Frontend path to open
+	Data      map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"`
+	ReadAt    *time.Time             `bson:"readAt,omitempty" json:"readAt,omitempty"`
+	CreatedAt time.Time              `bson:"createdAt" json:"createdAt"`
+}
+
+// Notification channels
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+)
+
+// NotificationChannels are every channel, in display order.
+var NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelSMS, ChannelPush}
+
+// NotificationEventChannels lists the channels each notification type can be
+// delivered over. SMS is reserved for time-critical events. Security
+// messages such as one-time codes are not in the matrix and always go out.
+var NotificationEventChannels = map[string][]string{
+	NotificationOrderUpdate:    {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationOutForDelivery: {ChannelInApp, ChannelEmail, ChannelSMS, ChannelPush},
+	NotificationNewOrder:       {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationRefund:         {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationPayoutSent:     {ChannelInApp, ChannelEmail, ChannelSMS, ChannelPush},
+	NotificationApplication:    {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationTierRequest:    {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationNewReview:      {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationReviewResponse: {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationMessage:        {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationDispute:        {ChannelInApp, ChannelEmail, ChannelPush},
+	NotificationDigest:         {ChannelEmail},
+}
+
+// NotificationPreferences is a user's channel × notification-type matrix.
+// Anything not set explicitly is on.
+type NotificationPreferences struct {
+	Channels      map[string]map[string]bool `bson:"channels,omitempty" json:"channels,omitempty"`           // type -> channel -> enabled
+	SMSOptOut     bool                       `bson:"smsOptOut" json:"smsOptOut"`                             // Set by the user or by replying STOP
+	SMSOptedOutAt *time.Time                 `bson:"smsOptedOutAt,omitempty" json:"smsOptedOutAt,omitempty"` // When SMSOptOut was last set
+}
+
+// SupportsChannel reports whether notifications of this type can be sent
+// over channel at all.
+func SupportsChannel(kind, channel string) bool {
+	for _, ch := range NotificationEventChannels[kind] {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether a notification of the given type may be sent over
+// channel. SMS also respects the global opt-out. Nil preferences allow
+// every supported channel.
+func (p *NotificationPreferences) Allows(kind, channel string) bool {
+	if kind == NotificationSecurity {
+		return true
+	}
+	if !SupportsChannel(kind, channel) {
+		return false
+	}
+	if p == nil {
+		return true
+	}
+	if channel == ChannelSMS && p.SMSOptOut {
+		return false
+	}
+	if enabled, ok := p.Channels[kind][channel]; ok {
+		return enabled
+	}
+	return true
+}
+
+// Matrix returns the effective setting for every type and supported channel.
+func (p *NotificationPreferences) Matrix() map[string]map[string]bool {
+	out := make(map[string]map[string]bool, len(NotificationEventChannels))
+	for kind, channels := range NotificationEventChannels {
+		out[kind] = make(map[string]bool, len(channels))
+		for _, ch := range channels {
+			out[kind][ch] = p.Allows(kind, ch)
+		}
+	}
+	return out
+}
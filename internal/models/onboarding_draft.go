@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VendorDraftSteps lists the stepData key written by each vendor onboarding
+// step. Step N fills VendorDraftSteps[N-1]; the final verification step has
+// no draft data of its own.
+var VendorDraftSteps = []string{"businessInfo", "categories", "businessDetails", "storeDetails"}
+
+// VendorDraftStepData is the typed schema of a vendor draft's stepData.
+type VendorDraftStepData struct {
+	BusinessInfo    *SellerBusinessInfo `json:"businessInfo,omitempty" bson:"businessInfo,omitempty"`
+	Categories      []string            `json:"categories,omitempty" bson:"categories,omitempty"`
+	BusinessDetails *BusinessDetails    `json:"businessDetails,omitempty" bson:"businessDetails,omitempty"`
+	StoreDetails    *StoreDetails       `json:"storeDetails,omitempty" bson:"storeDetails,omitempty"`
+}
+
+// VendorOnboardingDraft is a UserOnboardingDraft with its stepData decoded
+// into the vendor schema.
+type VendorOnboardingDraft struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID        primitive.ObjectID  `json:"userID" bson:"userID"`
+	Role          string              `json:"role" bson:"role"`
+	Step          int                 `json:"step" bson:"step"`
+	StepCompleted bool                `json:"stepCompleted" bson:"stepCompleted"`
+	StepData      VendorDraftStepData `json:"stepData" bson:"stepData"`
+	UpdatedAt     time.Time           `json:"updatedAt" bson:"updatedAt"`
+	Version       int                 `json:"version" bson:"version"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -9,72 +11,414 @@ import (
 type OrderStatus string
 
 const (
-	StatusPending   OrderStatus = "pending"
-	StatusPaid      OrderStatus = "paid"
-	StatusConfirmed OrderStatus = "confirmed"
-	StatusShipped   OrderStatus = "shipped"
-	StatusDelivered OrderStatus = "delivered"
-	StatusCancelled OrderStatus = "cancelled"
-	StatusRefunded  OrderStatus = "refunded"
+	StatusPending        OrderStatus = "pending"
+	StatusPaid           OrderStatus = "paid"
+	StatusConfirmed      OrderStatus = "confirmed"
+	StatusShipped        OrderStatus = "shipped"
+	StatusOutForDelivery OrderStatus = "out_for_delivery"
+	StatusDelivered      OrderStatus = "delivered"
+	StatusCancelled      OrderStatus = "cancelled"
+	StatusRefunded       OrderStatus = "refunded"
+)
+
+// orderNumberBase is added to the order sequence so that sequential numbers
+// start above any made by the old time-and-random scheme, which had at most
+// eight digits.
+const orderNumberBase = 100000000
+
+// FormatOrderNumber is the number shown for the seq'th order placed.
+func FormatOrderNumber(seq int64) string {
+	return fmt.Sprintf("VEN-%d", orderNumberBase+seq)
+}
+
+// Who pays for a line's discount
+const (
+	FundedByVendor   = "vendor"   // Comes out of the vendor's earnings
+	FundedByPlatform = "platform" // The vendor is paid as if it hadn't been taken
 )
 
 type OrderItem struct {
-	ProductID primitive.ObjectID `json:"productId" bson:"productId"`
-	VendorID  primitive.ObjectID `json:"vendorId" bson:"vendorId"`
-	Name      string             `json:"name" bson:"name"`
-	Image     string             `json:"image" bson:"image"`
-	Price     float64            `json:"price" bson:"price"`
-	Quantity  int                `json:"quantity" bson:"quantity"`
-	Subtotal  float64            `json:"subtotal" bson:"subtotal"`
+	ProductID  primitive.ObjectID `json:"productId" bson:"productId"`
+	VendorID   primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Name       string             `json:"name" bson:"name"`
+	Image      string             `json:"image" bson:"image"`
+	Price      Money              `json:"price" bson:"price"`
+	Quantity   int                `json:"quantity" bson:"quantity"`
+	Subtotal   Money              `json:"subtotal" bson:"subtotal"`
+	Discount   Money              `json:"discount,omitempty" bson:"discount,omitempty"`     // This line's share of the coupon
+	CategoryID primitive.ObjectID `json:"categoryId,omitempty" bson:"categoryId,omitempty"` // When ordered, for commissions
+
+	// Promotions behind the line's price, for vendor statements
+	ListPrice        Money               `json:"listPrice,omitempty" bson:"listPrice,omitempty"` // Regular price, when a sale priced the line below it
+	SaleCampaignID   *primitive.ObjectID `json:"saleCampaignId,omitempty" bson:"saleCampaignId,omitempty"`
+	FlashSaleID      *primitive.ObjectID `json:"flashSaleId,omitempty" bson:"flashSaleId,omitempty"`
+	DiscountFundedBy string              `json:"discountFundedBy,omitempty" bson:"discountFundedBy,omitempty"` // FundedByVendor or FundedByPlatform
+}
+
+// VendorAmount is what the vendor is credited for the line before the
+// platform fee: the subtotal, less the discount when the vendor funded it.
+// Commission is charged on this, so vendors aren't charged on money their
+// promotions gave away.
+func (i OrderItem) VendorAmount() Money {
+	if i.DiscountFundedBy == FundedByVendor {
+		return i.Subtotal - i.Discount
+	}
+	return i.Subtotal
+}
+
+// PromoCost is what the vendor's own promotions took off the line: the sale
+// markdown from the list price and any discount they funded.
+func (i OrderItem) PromoCost() Money {
+	var cost Money
+	if i.ListPrice > i.Price {
+		cost = (i.ListPrice - i.Price).Mul(i.Quantity)
+	}
+	if i.DiscountFundedBy == FundedByVendor {
+		cost += i.Discount
+	}
+	return cost
 }
 
 type Order struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	OrderNumber string             `json:"orderNumber" bson:"orderNumber"` // e.g., VEN-100234
+	OrderNumber string             `json:"orderNumber" bson:"orderNumber"` // e.g., VEN-100000234; see FormatOrderNumber
 	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
 	Items       []OrderItem        `json:"items" bson:"items"`
 
 	// Pricing Breakdown
-	Subtotal    float64 `json:"subtotal" bson:"subtotal"`
-	ShippingFee float64 `json:"shippingFee" bson:"shippingFee"`
-	Tax         float64 `json:"tax" bson:"tax"`
-	Total       float64 `json:"total" bson:"total"`
+	Subtotal       Money               `json:"subtotal" bson:"subtotal"`
+	Discount       Money               `json:"discount,omitempty" bson:"discount,omitempty"`
+	CouponCode     string              `json:"couponCode,omitempty" bson:"couponCode,omitempty"`
+	AutoDiscountID *primitive.ObjectID `json:"autoDiscountId,omitempty" bson:"autoDiscountId,omitempty"` // Set when an automatic discount was taken instead of a coupon
+	ShippingFee    Money               `json:"shippingFee" bson:"shippingFee"`
+	Tax            Money               `json:"tax" bson:"tax"`
+	Total          Money               `json:"total" bson:"total"`
 
 	Status        OrderStatus `json:"status" bson:"status"`
 	PaymentStatus string      `json:"paymentStatus" bson:"paymentStatus"`
 	PaymentID     string      `json:"paymentId" bson:"paymentId"`
 	PaymentMethod string      `json:"paymentMethod" bson:"paymentMethod"`
 
-	ShippingAddress string `json:"shippingAddress" bson:"shippingAddress"`
-	TrackingNumber  string `json:"trackingNumber" bson:"trackingNumber"`
+	ShippingAddress   string               `json:"shippingAddress" bson:"shippingAddress"`
+	ShipTo            *PostalAddress       `json:"shipTo,omitempty" bson:"shipTo,omitempty"` // Structured copy, for carriers
+	ShippingRegion    string               `json:"shippingRegion,omitempty" bson:"shippingRegion,omitempty"`
+	Shipments         []Shipment           `json:"shipments,omitempty" bson:"shipments,omitempty"`                 // How ShippingFee was made up
+	EstimatedDelivery *DeliveryEstimate    `json:"estimatedDelivery,omitempty" bson:"estimatedDelivery,omitempty"` // Promised at checkout; compare with DeliveredAt
+	Carrier           string               `json:"carrier,omitempty" bson:"carrier,omitempty"`
+	TrackingNumber    string               `json:"trackingNumber" bson:"trackingNumber"`
+	TrackingStatus    string               `json:"trackingStatus,omitempty" bson:"trackingStatus,omitempty"` // Carrier's latest for TrackingNumber
+	Labels            []ShippingLabel      `json:"labels,omitempty" bson:"labels,omitempty"`                 // One per vendor that bought a label
+	Timeline          []OrderTimelineEvent `json:"timeline,omitempty" bson:"timeline,omitempty"`             // Carrier scans, oldest first
+
+	CreatedAt   time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" bson:"updatedAt"`
+	ShippedAt   *time.Time `json:"shippedAt,omitempty" bson:"shippedAt,omitempty"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+
+	// Set once the buyer's confirmation email has gone out, so the webhook and
+	// manual verification paths don't both send it.
+	ConfirmationSentAt *time.Time `json:"confirmationSentAt,omitempty" bson:"confirmationSentAt,omitempty"`
+
+	// Refunds issued against the order; RefundedAmount is their running total
+	RefundedAmount Money         `json:"refundedAmount,omitempty" bson:"refundedAmount,omitempty"`
+	Refunds        []OrderRefund `json:"refunds,omitempty" bson:"refunds,omitempty"`
+	CancelledAt    *time.Time    `json:"cancelledAt,omitempty" bson:"cancelledAt,omitempty"`
+}
+
+// Refund states
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+)
+
+// OrderRefund is a single refund issued against an order.
+type OrderRefund struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	Amount         Money              `json:"amount" bson:"amount"`
+	Status         string             `json:"status" bson:"status"`
+	StripeRefundID string             `json:"stripeRefundId,omitempty" bson:"stripeRefundId,omitempty"`
+	Reason         string             `json:"reason" bson:"reason"`
+	IssuedBy       primitive.ObjectID `json:"issuedBy" bson:"issuedBy"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ShippingLabel is a carrier label a vendor bought for their items in an
+// order.
+type ShippingLabel struct {
+	VendorID       primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Carrier        string             `json:"carrier" bson:"carrier"`
+	Service        string             `json:"service,omitempty" bson:"service,omitempty"`
+	TrackingNumber string             `json:"trackingNumber" bson:"trackingNumber"`
+	TrackingURL    string             `json:"trackingUrl,omitempty" bson:"trackingUrl,omitempty"`
+	LabelURL       string             `json:"labelUrl" bson:"labelUrl"`
+	Cost           float64            `json:"cost" bson:"cost"`
+	Currency       string             `json:"currency,omitempty" bson:"currency,omitempty"`
+	TrackingStatus string             `json:"trackingStatus,omitempty" bson:"trackingStatus,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// VendorLabel returns the label a vendor bought for the order, if any.
+func (o Order) VendorLabel(vendorID primitive.ObjectID) (ShippingLabel, bool) {
+	for _, l := range o.Labels {
+		if l.VendorID == vendorID {
+			return l, true
+		}
+	}
+	return ShippingLabel{}, false
+}
+
+// OrderTimelineEvent is a carrier scan of one of the order's parcels.
+type OrderTimelineEvent struct {
+	TrackingNumber string    `json:"trackingNumber" bson:"trackingNumber"`
+	Status         string    `json:"status" bson:"status"` // A Tracking* status
+	Details        string    `json:"details,omitempty" bson:"details,omitempty"`
+	Location       string    `json:"location,omitempty" bson:"location,omitempty"`
+	At             time.Time `json:"at" bson:"at"`
+}
+
+// MergeTimeline replaces a parcel's events in the timeline with its latest
+// history, since carriers report the whole history each time.
+func MergeTimeline(timeline []OrderTimelineEvent, trackingNumber string, history []OrderTimelineEvent) []OrderTimelineEvent {
+	merged := make([]OrderTimelineEvent, 0, len(timeline)+len(history))
+	for _, e := range timeline {
+		if e.TrackingNumber != trackingNumber {
+			merged = append(merged, e)
+		}
+	}
+	merged = append(merged, history...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].At.Before(merged[j].At) })
+	return merged
+}
+
+// fulfilmentRank orders the statuses a paid order moves through.
+var fulfilmentRank = map[OrderStatus]int{
+	StatusPaid:           1,
+	StatusConfirmed:      2,
+	StatusShipped:        3,
+	StatusOutForDelivery: 4,
+	StatusDelivered:      5,
+}
+
+// trackingOrderStatus is the order status each carrier status implies.
+var trackingOrderStatus = map[string]OrderStatus{
+	TrackingInTransit:      StatusShipped,
+	TrackingOutForDelivery: StatusOutForDelivery,
+	TrackingDelivered:      StatusDelivered,
+}
+
+// TrackedStatus returns the status carrier tracking has moved the order on
+// to, or false if it hasn't. An order with several labels goes no further
+// than its slowest parcel, and tracking never moves an order backwards or
+// touches one that is unpaid, cancelled or refunded.
+func (o Order) TrackedStatus() (OrderStatus, bool) {
+	current, ok := fulfilmentRank[o.Status]
+	if !ok {
+		return "", false
+	}
+
+	statuses := []string{o.TrackingStatus}
+	if len(o.Labels) > 0 {
+		statuses = statuses[:0]
+		for _, l := range o.Labels {
+			statuses = append(statuses, l.TrackingStatus)
+		}
+	}
+	var slowest OrderStatus
+	for _, s := range statuses {
+		status, ok := trackingOrderStatus[s]
+		if !ok {
+			return "", false
+		}
+		if slowest == "" || fulfilmentRank[status] < fulfilmentRank[slowest] {
+			slowest = status
+		}
+	}
+	// Parcels arriving don't deliver what's still waiting to be collected
+	if !o.PickupsCollected() && fulfilmentRank[slowest] > fulfilmentRank[StatusShipped] {
+		slowest = StatusShipped
+	}
+	if fulfilmentRank[slowest] <= current {
+		return "", false
+	}
+	return slowest, true
+}
+
+// EventData is the common payload of order events.
+func (o Order) EventData() map[string]interface{} {
+	vendorIDs := []string{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, item := range o.Items {
+		if !seen[item.VendorID] {
+			seen[item.VendorID] = true
+			vendorIDs = append(vendorIDs, item.VendorID.Hex())
+		}
+	}
+	return map[string]interface{}{
+		"orderId":     o.ID.Hex(),
+		"orderNumber": o.OrderNumber,
+		"buyerId":     o.UserID.Hex(),
+		"vendorIds":   vendorIDs,
+	}
+}
+
+// EventItems lists the order's lines for event payloads.
+func (o Order) EventItems() []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, map[string]interface{}{
+			"productId": item.ProductID.Hex(),
+			"vendorId":  item.VendorID.Hex(),
+			"name":      item.Name,
+			"quantity":  item.Quantity,
+			"subtotal":  item.Subtotal.Float64(),
+		})
+	}
+	return items
+}
+
+// RefundableAmount is what is left to refund on the order.
+func (o Order) RefundableAmount() Money {
+	return max(o.Total-o.RefundedAmount, 0)
+}
+
+// VendorRefundShares splits a refund across the order's vendors in
+// proportion to their share of the order total, which is what each vendor was
+// credited for the sale (see OrderItem.VendorAmount). Shipping and tax stay with the platform. Shares are
+// rounded to the minor unit, the last vendor on the order taking what rounding
+// leaves so the clawbacks add up to the vendors' part of the refund.
+func VendorRefundShares(order Order, amount Money) map[primitive.ObjectID]Money {
+	shares := make(map[primitive.ObjectID]Money)
+	if order.Total <= 0 || amount <= 0 {
+		return shares
+	}
+
+	var vendors []primitive.ObjectID
+	var itemsTotal Money
+	for _, item := range order.Items {
+		if _, seen := shares[item.VendorID]; !seen {
+			vendors = append(vendors, item.VendorID)
+		}
+		shares[item.VendorID] += item.VendorAmount()
+		itemsTotal += item.VendorAmount()
+	}
 
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	remaining := amount.Share(itemsTotal, order.Total)
+	for i, vendorID := range vendors {
+		share := remaining
+		if i < len(vendors)-1 {
+			share = amount.Share(shares[vendorID], order.Total)
+		}
+		shares[vendorID] = share
+		remaining -= share
+	}
+	return shares
 }
 
 type PlaceOrderInput struct {
-	ShippingAddress string `json:"shippingAddress" binding:"required"`
-	PaymentMethod   string `json:"paymentMethod" binding:"required"`
+	ShippingAddress string            `json:"shippingAddress"` // Display copy; filled in from shipTo when left out
+	PaymentMethod   string            `json:"paymentMethod" binding:"required"`
+	CouponCode      string            `json:"couponCode"`                // Defaults to the code saved on the cart
+	ShippingRegion  string            `json:"shippingRegion"`            // e.g. "NG-LA"; picks the shipping zone, else taken from shipTo
+	ShipTo          *PostalAddress    `json:"shipTo" binding:"required"` // Checked and geocoded before the order is placed
+	ShippingMethods map[string]string `json:"shippingMethods"`           // Method code by vendor ID, for vendors that offer a choice
+	PickupLocations map[string]string `json:"pickupLocations"`           // Location ID by vendor ID, for vendors collected from
 }
 
-type DailySales struct {
-	Date    string  `json:"date"`
-	Revenue float64 `json:"revenue"`
-	Orders  int     `json:"orders"`
+// Granularities vendor sales can be broken down by.
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// VendorStatsQuery is the range of a vendor's sales to report on.
+type VendorStatsQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity string // GranularityDay, GranularityWeek or GranularityMonth
+	TopProducts int    // How many best sellers to list, overall and per bucket
+}
+
+// ProductSales is one product's share of a vendor's sales.
+type ProductSales struct {
+	ProductID primitive.ObjectID `json:"productId" bson:"productId"`
+	Name      string             `json:"name" bson:"name"`
+	Revenue   Money              `json:"revenue" bson:"revenue"`
+	UnitsSold int                `json:"unitsSold" bson:"unitsSold"`
 }
 
+// SalesBucket is a vendor's sales in the day, week or month starting at Start.
+type SalesBucket struct {
+	Start         time.Time      `json:"start" bson:"_id"`
+	Revenue       Money          `json:"revenue" bson:"revenue"`
+	Orders        int            `json:"orders" bson:"orders"`
+	UnitsSold     int            `json:"unitsSold" bson:"unitsSold"`
+	AvgOrderValue Money          `json:"avgOrderValue" bson:"-"`
+	TopProducts   []ProductSales `json:"topProducts" bson:"topProducts"`
+}
+
+// VendorStats are a vendor's sales over [From, To). Revenue, orders and
+// units count the vendor's lines of paid orders (PaidOrderStatuses); the
+// status breakdown counts every order in the range.
 type VendorStats struct {
-	TotalRevenue     float64        `json:"totalRevenue"`
-	TotalOrders      int            `json:"totalOrders"`
-	TotalProducts    int            `json:"totalProducts"`
-	AvgOrderValue    float64        `json:"avgOrderValue"`
-	SalesPerformance []DailySales   `json:"salesPerformance"`
-	StatusBreakdown  map[string]int `json:"statusBreakdown"`
+	From            time.Time      `json:"from"`
+	To              time.Time      `json:"to"`
+	Granularity     string         `json:"granularity"`
+	TotalRevenue    Money          `json:"totalRevenue"`
+	TotalOrders     int            `json:"totalOrders"`
+	UnitsSold       int            `json:"unitsSold"`
+	TotalProducts   int            `json:"totalProducts"`
+	AvgOrderValue   Money          `json:"avgOrderValue"`
+	TopProducts     []ProductSales `json:"topProducts"`
+	Sales           []SalesBucket  `json:"sales"` // One per bucket in the range, oldest first, including empty ones
+	StatusBreakdown map[string]int `json:"statusBreakdown"`
+}
+
+// BucketStart is the start of the day, week (from Monday) or month holding
+// t, in UTC, as MongoDB's $dateTrunc works it out.
+func BucketStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch granularity {
+	case GranularityWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// SalesBuckets are the starts of every bucket overlapping [from, to), oldest
+// first.
+func SalesBuckets(from, to time.Time, granularity string) []time.Time {
+	var starts []time.Time
+	for start := BucketStart(from, granularity); start.Before(to); {
+		starts = append(starts, start)
+		switch granularity {
+		case GranularityWeek:
+			start = start.AddDate(0, 0, 7)
+		case GranularityMonth:
+			start = start.AddDate(0, 1, 0)
+		default:
+			start = start.AddDate(0, 0, 1)
+		}
+	}
+	return starts
 }
 
 type BuyerOverviewStats struct {
-	TotalAcquisitions   int     `json:"totalAcquisitions"`
-	ActiveWishlistCount int     `json:"activeWishlistCount"`
-	TotalSpent          float64 `json:"totalSpent"`
-	LastOrderDate       string  `json:"lastOrderDate"`
+	TotalAcquisitions   int    `json:"totalAcquisitions"`
+	ActiveWishlistCount int    `json:"activeWishlistCount"`
+	TotalSpent          Money  `json:"totalSpent"`
+	LastOrderDate       string `json:"lastOrderDate"`
+}
+
+// VendorFulfillmentStats summarises how reliably a vendor ships their orders.
+type VendorFulfillmentStats struct {
+	TotalOrders     int     `json:"totalOrders"`
+	FulfilledOrders int     `json:"fulfilledOrders"`
+	CancelledOrders int     `json:"cancelledOrders"`
+	FulfillmentRate float64 `json:"fulfillmentRate"` // Percentage of non-pending orders shipped or delivered
+	AvgShipHours    float64 `json:"avgShipHours"`    // Order placed -> shipped
 }
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Who moves orders from one status to another
+const (
+	OrderActorBuyer   = "buyer"
+	OrderActorVendor  = "vendor"
+	OrderActorAdmin   = "admin"
+	OrderActorCarrier = "carrier" // Tracking updates
+	OrderActorSystem  = "system"  // Payments
+)
+
+// OrderStatusActor is who is moving an order on, and why.
+type OrderStatusActor struct {
+	Role     string
+	UserID   *primitive.ObjectID // Unset for carriers and the system
+	Reason   string
+	Metadata map[string]string // Tracking numbers, payment and refund IDs
+}
+
+// ActingUser is an actor who is a signed-in user.
+func ActingUser(role string, userID primitive.ObjectID) OrderStatusActor {
+	return OrderStatusActor{Role: role, UserID: &userID}
+}
+
+// OrderStatusEvent is one change of an order's status. Events are only ever
+// added, in the same transaction as the change, so an order's history is the
+// record of what happened to it; its status field is kept for queries and is
+// what replaying its history gives.
+type OrderStatusEvent struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	OrderID   primitive.ObjectID  `json:"orderId" bson:"orderId"`
+	Sequence  int                 `json:"sequence" bson:"sequence"` // Counts up from 1 for each order
+	From      OrderStatus         `json:"from,omitempty" bson:"from,omitempty"`
+	To        OrderStatus         `json:"to" bson:"to"`
+	ActorRole string              `json:"actorRole" bson:"actorRole"`
+	ActorID   *primitive.ObjectID `json:"actorId,omitempty" bson:"actorId,omitempty"`
+	Reason    string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	Metadata  map[string]string   `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	At        time.Time           `json:"at" bson:"at"`
+}
+
+// NewOrderStatusEvent records actor moving an order from one status to
+// another as its sequence'th change.
+func NewOrderStatusEvent(orderID primitive.ObjectID, sequence int, from, to OrderStatus, actor OrderStatusActor) OrderStatusEvent {
+	return OrderStatusEvent{
+		OrderID:   orderID,
+		Sequence:  sequence,
+		From:      from,
+		To:        to,
+		ActorRole: actor.Role,
+		ActorID:   actor.UserID,
+		Reason:    actor.Reason,
+		Metadata:  actor.Metadata,
+		At:        time.Now(),
+	}
+}
+
+// DeriveOrderStatus replays an order's history to the status it is in now.
+// An order with no history is pending.
+func DeriveOrderStatus(history []OrderStatusEvent) OrderStatus {
+	if len(history) == 0 {
+		return StatusPending
+	}
+	latest := history[0]
+	for _, e := range history[1:] {
+		if e.Sequence > latest.Sequence {
+			latest = e
+		}
+	}
+	return latest.To
+}
+
+// OrderTimings are how long an order took to get through fulfilment, in
+// hours, for the steps it has been through.
+type OrderTimings struct {
+	HoursToPay     *float64 `json:"hoursToPay,omitempty"`     // Placed to paid
+	HoursToShip    *float64 `json:"hoursToShip,omitempty"`    // Paid to shipped
+	HoursToDeliver *float64 `json:"hoursToDeliver,omitempty"` // Shipped to delivered
+}
+
+// OrderStatusTimeline is an order's history as the timeline shows it.
+type OrderStatusTimeline struct {
+	Status  OrderStatus        `json:"status"`
+	Events  []OrderStatusEvent `json:"events"`
+	Timings OrderTimings       `json:"timings"`
+}
+
+// NewOrderStatusTimeline puts an order's history in order and works out its
+// status and timings from it.
+func NewOrderStatusTimeline(history []OrderStatusEvent) OrderStatusTimeline {
+	events := append([]OrderStatusEvent{}, history...)
+	sort.Slice(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+
+	reached := make(map[OrderStatus]time.Time)
+	for _, e := range events {
+		if _, ok := reached[e.To]; !ok {
+			reached[e.To] = e.At
+		}
+	}
+	between := func(from, to OrderStatus) *float64 {
+		start, ok := reached[from]
+		end, ok2 := reached[to]
+		if !ok || !ok2 || end.Before(start) {
+			return nil
+		}
+		hours := end.Sub(start).Hours()
+		return &hours
+	}
+
+	return OrderStatusTimeline{
+		Status: DeriveOrderStatus(events),
+		Events: events,
+		Timings: OrderTimings{
+			HoursToPay:     between(StatusPending, StatusPaid),
+			HoursToShip:    between(StatusPaid, StatusShipped),
+			HoursToDeliver: between(StatusShipped, StatusDelivered),
+		},
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxMaxAttempts is how many times the relay tries an outbox event
+// before leaving it for someone to look at.
+const OutboxMaxAttempts = 5
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it announces, so the change can't happen without the event being
+// published or the other way round. The relay publishes it and marks it
+// published.
+type OutboxEvent struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EventID     string             `json:"eventId" bson:"eventId"`
+	Type        string             `json:"type" bson:"type"`
+	Payload     string             `json:"payload" bson:"payload"` // The event as JSON, so subscribers get exactly what was written
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	LastError   string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	LockedUntil *time.Time         `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"` // While a relay is publishing it
+	PublishedAt *time.Time         `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned for a cursor that wasn't issued by this API.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorSort is the order cursor-paginated lists are walked in: newest
+// first, with _id breaking ties between documents created in the same
+// millisecond.
+var CursorSort = bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}
+
+// PageCursor marks the last item of a page. The zero cursor is the start of
+// the list.
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+}
+
+// Encode returns the cursor as an opaque URL-safe token.
+func (c PageCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMilli(), 36) + "." + c.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// IsZero reports whether the cursor is the start of the list.
+func (c PageCursor) IsZero() bool {
+	return c.ID.IsZero()
+}
+
+// DecodeCursor parses a token from PageCursor.Encode. An empty token is the
+// start of the list.
+func DecodeCursor(token string) (PageCursor, error) {
+	if token == "" {
+		return PageCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	ms, hex, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	millis, err := strconv.ParseInt(ms, 36, 64)
+	if err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	return PageCursor{CreatedAt: time.UnixMilli(millis), ID: id}, nil
+}
+
+// After adds to filter the condition for items after the cursor in
+// CursorSort order. The zero cursor leaves filter as it is.
+func (c PageCursor) After(filter bson.M) bson.M {
+	if c.IsZero() {
+		return filter
+	}
+	after := bson.M{"$or": bson.A{
+		bson.M{"createdAt": bson.M{"$lt": c.CreatedAt}},
+		bson.M{"createdAt": c.CreatedAt, "_id": bson.M{"$lt": c.ID}},
+	}}
+	if len(filter) == 0 {
+		return after
+	}
+	return bson.M{"$and": bson.A{filter, after}}
+}
+
+// CursorMeta is the pagination part of a cursor-paginated response. Pass
+// NextCursor as ?cursor= to fetch the next page; it is empty on the last.
+// HasMore is HasNext's old name, kept for clients that read it.
+type CursorMeta struct {
+	NextCursor string `json:"nextCursor"`
+	HasNext    bool   `json:"hasNext"`
+	HasMore    bool   `json:"hasMore"`
+	Limit      int    `json:"limit"`
+}
+
+// PageMeta is the pagination part of a page-numbered response. Ask for the
+// next page with ?page=Page+1 while HasNext.
+type PageMeta struct {
+	Total   int64 `json:"total"`
+	Page    int   `json:"page"`
+	Limit   int   `json:"limit"`
+	HasNext bool  `json:"hasNext"`
+}
+
+func NewPageMeta(total int64, page, limit int) PageMeta {
+	return PageMeta{Total: total, Page: page, Limit: limit, HasNext: int64(page)*int64(limit) < total}
+}
+
+// MaxSortFields caps how many fields one ?sort= may name.
+const MaxSortFields = 3
+
+// ParseSort reads a ?sort= such as "-price,name": the JSON names of model's
+// fields, which must be among allowed, each descending when prefixed with
+// "-". _id breaks ties, in the last field's direction, so pages neither
+// overlap nor skip. An empty sort is def.
+func ParseSort(raw string, model interface{}, allowed []string, def bson.D) (bson.D, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return def, nil
+	}
+	names := fieldNames(reflect.TypeOf(model))
+
+	sort := bson.D{}
+	seen := map[string]bool{}
+	for _, key := range strings.Split(raw, ",") {
+		key = strings.TrimSpace(key)
+		direction := 1
+		if name, ok := strings.CutPrefix(key, "-"); ok {
+			key, direction = name, -1
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		if !slices.Contains(allowed, key) || names[key] == "" {
+			return nil, fmt.Errorf("can't sort by %q; sort by %s", key, strings.Join(allowed, ", "))
+		}
+		seen[key] = true
+		sort = append(sort, bson.E{Key: names[key], Value: direction})
+	}
+	if len(sort) == 0 {
+		return def, nil
+	}
+	if len(sort) > MaxSortFields {
+		return nil, fmt.Errorf("sort by at most %d fields", MaxSortFields)
+	}
+	if !seen["id"] {
+		sort = append(sort, bson.E{Key: "_id", Value: sort[len(sort)-1].Value})
+	}
+	return sort, nil
+}
+
+// CursorPage trims items fetched with one more than limit to the page and
+// builds its meta; the extra item only says whether there is another page.
+// key returns an item's createdAt and _id.
+func CursorPage[T any](items []T, limit int, key func(T) (time.Time, primitive.ObjectID)) ([]T, CursorMeta) {
+	meta := CursorMeta{Limit: limit}
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) > limit {
+		items = items[:limit]
+		createdAt, id := key(items[limit-1])
+		meta.HasNext = true
+		meta.HasMore = true
+		meta.NextCursor = PageCursor{CreatedAt: createdAt, ID: id}.Encode()
+	}
+	return items, meta
+}
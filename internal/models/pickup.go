@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUnknownPickupLocation is returned when a buyer collecting from a vendor
+// hasn't picked one of the vendor's pickup locations.
+var ErrUnknownPickupLocation = errors.New("choose one of the vendor's pickup locations")
+
+// PickupLocation is somewhere a vendor's buyers can collect their orders.
+type PickupLocation struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	Name         string             `json:"name" bson:"name" validate:"required,max=80"`
+	Address      PostalAddress      `json:"address" bson:"address"`
+	Hours        []PickupHours      `json:"hours,omitempty" bson:"hours,omitempty" validate:"max=14,dive"`
+	Instructions string             `json:"instructions,omitempty" bson:"instructions,omitempty" validate:"max=500"`
+}
+
+// PickupHours is when a pickup location is open on one day of the week, in
+// its local time.
+type PickupHours struct {
+	Day    string `json:"day" bson:"day" validate:"required,oneof=mon tue wed thu fri sat sun"`
+	Opens  string `json:"opens" bson:"opens" validate:"required,datetime=15:04"`
+	Closes string `json:"closes" bson:"closes" validate:"required,datetime=15:04"`
+}
+
+// PickupLocation returns the store's pickup location with the given ID or,
+// when id is nil and there is only one, that one.
+func (s Store) PickupLocation(id primitive.ObjectID) (PickupLocation, bool) {
+	if id.IsZero() && len(s.PickupLocations) == 1 {
+		return s.PickupLocations[0], true
+	}
+	for _, l := range s.PickupLocations {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return PickupLocation{}, false
+}
+
+// VendorPickup returns the index of the vendor's pickup shipment in the
+// order, or -1 if they aren't shipping it for collection.
+func (o Order) VendorPickup(vendorID primitive.ObjectID) int {
+	for i, s := range o.Shipments {
+		if s.Method == ShippingMethodPickup && containsObjectID(s.VendorIDs, vendorID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// PickupOnly reports whether every shipment in the order is being
+// collected, so collection is delivery.
+func (o Order) PickupOnly() bool {
+	for _, s := range o.Shipments {
+		if s.Method != ShippingMethodPickup {
+			return false
+		}
+	}
+	return len(o.Shipments) > 0
+}
+
+// PickupsReady reports whether every pickup in the order is ready to
+// collect.
+func (o Order) PickupsReady() bool {
+	for _, s := range o.Shipments {
+		if s.Method == ShippingMethodPickup && s.ReadyAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// PickupsCollected reports whether every pickup in the order has been
+// collected.
+func (o Order) PickupsCollected() bool {
+	for _, s := range o.Shipments {
+		if s.Method == ShippingMethodPickup && s.CollectedAt == nil {
+			return false
+		}
+	}
+	return true
+}
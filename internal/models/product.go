@@ -12,6 +12,7 @@ const (
 	ProductStatusDraft    ProductStatus = "draft"
 	ProductStatusActive   ProductStatus = "active"
 	ProductStatusArchived ProductStatus = "archived"
+	ProductStatusRemoved  ProductStatus = "removed" // Taken down by moderation
 )
 
 type Dimensions struct {
@@ -29,7 +30,7 @@ type VariantOption struct {
 type Variant struct {
 	ID         string            `json:"id" bson:"id"`
 	SKU        string            `json:"sku" bson:"sku"`
-	Price      float64           `json:"price" bson:"price"`
+	Price      Money             `json:"price" bson:"price"`
 	Stock      int               `json:"stock" bson:"stock"`
 	Options    map[string]string `json:"options" bson:"options"`       // e.g., {"Color": "Red", "Size": "M"}
 	ImageIndex int               `json:"imageIndex" bson:"imageIndex"` // Index of the specific image for this variant
@@ -39,7 +40,7 @@ type SEO struct {
 	Title       string   `json:"title" bson:"title"`
 	Description string   `json:"description" bson:"description"`
 	Keywords    []string `json:"keywords" bson:"keywords"`
-	Slug        string   `json:"slug" bson:"slug"`
+	Slug        string   `json:"slug" bson:"slug" validate:"omitempty,slug"`
 }
 
 type Product struct {
@@ -51,24 +52,31 @@ type Product struct {
 	Description string `json:"description" bson:"description"`
 	Brand       string `json:"brand" bson:"brand"`
 
+	// Name and description in other locales; see localized.go
+	Translations Translations `json:"translations,omitempty" bson:"translations,omitempty" validate:"omitempty,max=20,dive,keys,locale,endkeys"`
+
 	// Categorization
 	CategoryID     primitive.ObjectID   `json:"categoryId" bson:"categoryId"`
 	SubCategoryIDs []primitive.ObjectID `json:"subCategoryIds" bson:"subCategoryIds"`
 	Tags           []string             `json:"tags" bson:"tags"`
+	Breadcrumbs    []CategoryCrumb      `json:"breadcrumbs,omitempty" bson:"-"` // Added on the product page
 
 	// Media
-	Images   []string           `json:"images" bson:"images"` // First image is primary
-	VideoURL string             `json:"videoUrl" bson:"videoUrl"`
+	Images   []string           `json:"images" bson:"images" validate:"dive,weburl"` // First image is primary
+	VideoURL string             `json:"videoUrl" bson:"videoUrl" validate:"omitempty,weburl"`
 
 	// Enriched fields (not stored in product collection, but added by aggregation)
-	VendorName     string `json:"vendorName,omitempty" bson:"vendorName"`
-	VendorLocation string `json:"vendorLocation,omitempty" bson:"vendorLocation"`
+	VendorName     string         `json:"vendorName,omitempty" bson:"vendorName"`
+	VendorLocation string         `json:"vendorLocation,omitempty" bson:"vendorLocation"`
+	StoreSlug      string         `json:"storeSlug,omitempty" bson:"storeSlug,omitempty"`
+	StorePolicies  *StorePolicies `json:"storePolicies,omitempty" bson:"storePolicies,omitempty"`
 
 	// Pricing
-	Price     float64 `json:"price" bson:"price" validate:"required,gt=0"`
-	SalePrice float64 `json:"salePrice" bson:"salePrice"`
-	CostPrice float64 `json:"costPrice" bson:"costPrice"` // For analytics
-	TaxRate   float64 `json:"taxRate" bson:"taxRate"`     // Percentage
+	Price     Money        `json:"price" bson:"price" validate:"required,gt=0"`
+	SalePrice Money        `json:"salePrice" bson:"salePrice"`
+	CostPrice Money        `json:"costPrice" bson:"costPrice"`           // For analytics
+	TaxRate   float64      `json:"taxRate" bson:"taxRate"`               // Percentage
+	Sale      *ProductSale `json:"sale,omitempty" bson:"sale,omitempty"` // Set while a sale campaign runs on it; see sale_campaign.go
 
 	// Inventory
 	SKU               string `json:"sku" bson:"sku"`
@@ -78,9 +86,12 @@ type Product struct {
 	AllowBackorder    bool   `json:"allowBackorder" bson:"allowBackorder"`
 
 	// Shipping & Delivery
-	IsDigital     bool       `json:"isDigital" bson:"isDigital"`
-	Dimensions    Dimensions `json:"dimensions" bson:"dimensions"`
-	ShippingClass string     `json:"shippingClass" bson:"shippingClass"`
+	IsDigital         bool              `json:"isDigital" bson:"isDigital"`
+	Dimensions        Dimensions        `json:"dimensions" bson:"dimensions"`
+	ShippingClass     string            `json:"shippingClass" bson:"shippingClass"`
+	EstimatedDelivery *DeliveryEstimate `json:"estimatedDelivery,omitempty" bson:"-"` // Added on the product page for the buyer's region
+
+	ShippingRestrictions ShippingRestrictions `json:"shippingRestrictions" bson:"shippingRestrictions,omitempty"`
 
 	// Variants
 	HasVariants    bool            `json:"hasVariants" bson:"hasVariants"`
@@ -90,7 +101,10 @@ type Product struct {
 	// SEO & Metadata
 	SEO      SEO               `json:"seo" bson:"seo"`
 	Metadata map[string]string `json:"metadata" bson:"metadata"`
-	Status   ProductStatus     `json:"status" bson:"status" default:"draft"`
+	Status   ProductStatus     `json:"status" bson:"status" default:"draft" validate:"omitempty,oneof=draft active archived"`
+
+	// Moderation. Set by admins; see models/moderation.go.
+	ModerationStatus string `json:"moderationStatus,omitempty" bson:"moderationStatus,omitempty"`
 
 	// Analytics (Computed or Cached)
 	Rating      float64 `json:"rating" bson:"rating"`
@@ -99,27 +113,34 @@ type Product struct {
 
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	// Soft delete; see soft_delete.go
+	DeletedAt *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
 }
 type UpdateProductInput struct {
 	Name        *string             `json:"name,omitempty" bson:"name,omitempty"`
 	Description *string             `json:"description,omitempty" bson:"description,omitempty"`
-	Price       *float64            `json:"price,omitempty" bson:"price,omitempty"`
+	Price       *Money              `json:"price,omitempty" bson:"price,omitempty"`
 	Stock       *int                `json:"stock,omitempty" bson:"stock,omitempty"`
 	CategoryId  *primitive.ObjectID `json:"categoryId,omitempty" bson:"categoryId,omitempty"`
 	SEO         *SEO                `json:"seo,omitempty" bson:"seo,omitempty"`
 	SKU         *string             `json:"sku,omitempty" bson:"sku,omitempty"`
 	Metadata    *map[string]string  `json:"metadata,omitempty" bson:"metadata,omitempty"`
-	Status      *ProductStatus      `json:"status,omitempty" bson:"status,omitempty"`
+	Status      *ProductStatus      `json:"status,omitempty" bson:"status,omitempty" validate:"omitempty,oneof=draft active archived"`
 	UpdatedAt   time.Time           `json:"updatedAt" bson:"updatedAt"`
 
-	SalePrice         *float64         `json:"salePrice,omitempty" bson:"salePrice,omitempty"`
-	CostPrice         *float64         `json:"costPrice,omitempty" bson:"costPrice,omitempty"`
+	SalePrice         *Money           `json:"salePrice,omitempty" bson:"salePrice,omitempty"`
+	CostPrice         *Money           `json:"costPrice,omitempty" bson:"costPrice,omitempty"`
 	TaxRate           *float64         `json:"taxRate,omitempty" bson:"taxRate,omitempty"`
 	Tags              *[]string        `json:"tags,omitempty" bson:"tags,omitempty"`
 	Brand             *string          `json:"brand,omitempty" bson:"brand,omitempty"`
 	Dimensions        *Dimensions      `json:"dimensions,omitempty" bson:"dimensions,omitempty"`
 	ShippingClass     *string          `json:"shippingClass,omitempty" bson:"shippingClass,omitempty"`
 	IsDigital         *bool            `json:"isDigital,omitempty" bson:"isDigital,omitempty"`
+
+	ShippingRestrictions *ShippingRestrictions `json:"shippingRestrictions,omitempty" bson:"shippingRestrictions,omitempty"`
+	Translations         *Translations         `json:"translations,omitempty" bson:"translations,omitempty" validate:"omitempty,max=20,dive,keys,locale,endkeys"` // Replaces them all
 	LowStockThreshold *int             `json:"lowStockThreshold,omitempty" bson:"lowStockThreshold,omitempty"`
 	AllowBackorder    *bool            `json:"allowBackorder,omitempty" bson:"allowBackorder,omitempty"`
 	HasVariants       *bool            `json:"hasVariants,omitempty" bson:"hasVariants,omitempty"`
@@ -127,6 +148,6 @@ type UpdateProductInput struct {
 	Variants          *[]Variant       `json:"variants,omitempty" bson:"variants,omitempty"`
 
 	SubCategoryIds *[]primitive.ObjectID `json:"subCategoryIds,omitempty" bson:"subCategoryIds,omitempty"`
-	Images         *[]string             `json:"images,omitempty" bson:"images,omitempty"`
-	VideoURL       *string               `json:"videoUrl,omitempty" bson:"videoUrl,omitempty"`
+	Images         *[]string             `json:"images,omitempty" bson:"images,omitempty" validate:"omitempty,dive,weburl"`
+	VideoURL       *string               `json:"videoUrl,omitempty" bson:"videoUrl,omitempty" validate:"omitempty,weburl"`
 }
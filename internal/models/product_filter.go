@@ -0,0 +1,186 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Bounds on storefront filters, so a crafted URL can't build a huge query.
+const (
+	maxFilterAttributes = 10
+	maxFilterValues     = 10
+)
+
+var filterAttributeKey = regexp.MustCompile(`^[A-Za-z0-9 _-]{1,40}$`)
+
+// ProductFilters are the structured filters of a storefront product search:
+// ?minPrice=&maxPrice=&brand=a,b&attributes[color]=red,blue. Price bounds
+// apply to the price a shopper pays, the sale price when there is one.
+type ProductFilters struct {
+	MinPrice   *Money
+	MaxPrice   *Money
+	Brands     []string
+	Attributes map[string][]string
+}
+
+// ParseProductFilters reads and validates the filters in a query string.
+func ParseProductFilters(q url.Values) (ProductFilters, error) {
+	var f ProductFilters
+
+	price := func(name string) (*Money, error) {
+		raw := strings.TrimSpace(q.Get(name))
+		if raw == "" {
+			return nil, nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			return nil, errors.New(name + " must be a non-negative number")
+		}
+		m := FromFloat(v)
+		return &m, nil
+	}
+	var err error
+	if f.MinPrice, err = price("minPrice"); err != nil {
+		return f, err
+	}
+	if f.MaxPrice, err = price("maxPrice"); err != nil {
+		return f, err
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return f, errors.New("minPrice must not be more than maxPrice")
+	}
+
+	if f.Brands, err = filterValues("brand", q["brand"]); err != nil {
+		return f, err
+	}
+
+	for key, values := range q {
+		if !strings.HasPrefix(key, "attributes[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := strings.TrimSpace(key[len("attributes[") : len(key)-1])
+		if !filterAttributeKey.MatchString(name) {
+			return f, errors.New("invalid attribute name " + strconv.Quote(name))
+		}
+		vals, err := filterValues(name, values)
+		if err != nil {
+			return f, err
+		}
+		if len(vals) == 0 {
+			continue
+		}
+		if f.Attributes == nil {
+			f.Attributes = map[string][]string{}
+		}
+		if len(f.Attributes) == maxFilterAttributes {
+			return f, errors.New("too many attribute filters")
+		}
+		f.Attributes[name] = vals
+	}
+	return f, nil
+}
+
+// filterValues splits repeated and comma-separated values, dropping blanks.
+func filterValues(name string, raw []string) ([]string, error) {
+	var out []string
+	for _, r := range raw {
+		for _, v := range strings.Split(r, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	if len(out) > maxFilterValues {
+		return nil, errors.New("too many values for " + name)
+	}
+	return out, nil
+}
+
+// Conditions translates the filters into query conditions to be ANDed with
+// the rest of the product query. They compare whole values against indexed
+// fields rather than using regexes.
+func (f ProductFilters) Conditions() []bson.M {
+	var conds []bson.M
+
+	// 1. Price, against the sale price when one is set
+	if f.MinPrice != nil || f.MaxPrice != nil {
+		bounds := bson.M{}
+		if f.MinPrice != nil {
+			bounds["$gte"] = *f.MinPrice
+		}
+		if f.MaxPrice != nil {
+			bounds["$lte"] = *f.MaxPrice
+		}
+		onSale := bson.M{"$gt": 0}
+		for k, v := range bounds {
+			onSale[k] = v
+		}
+		conds = append(conds, bson.M{"$or": bson.A{
+			bson.M{"salePrice": onSale},
+			bson.M{"salePrice": bson.M{"$not": bson.M{"$gt": 0}}, "price": bounds},
+		}})
+	}
+
+	// 2. Brands
+	if len(f.Brands) > 0 {
+		conds = append(conds, bson.M{"brand": bson.M{"$in": f.Brands}})
+	}
+
+	// 3. Attributes. A variant product matches when a single variant has
+	// every requested attribute, so size M in red only matches if that
+	// combination exists; other products match on their metadata. Names and
+	// values are tried as given, lower case and capitalized, as vendors
+	// enter both.
+	if len(f.Attributes) > 0 {
+		names := make([]string, 0, len(f.Attributes))
+		for name := range f.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var onVariant, onMetadata bson.A
+		for _, name := range names {
+			vals := caseFormsAll(f.Attributes[name])
+			var variantKeys, metadataKeys bson.A
+			for _, k := range caseForms(name) {
+				variantKeys = append(variantKeys, bson.M{"options." + k: bson.M{"$in": vals}})
+				metadataKeys = append(metadataKeys, bson.M{"metadata." + k: bson.M{"$in": vals}})
+			}
+			onVariant = append(onVariant, bson.M{"$or": variantKeys})
+			onMetadata = append(onMetadata, bson.M{"$or": metadataKeys})
+		}
+		conds = append(conds, bson.M{"$or": bson.A{
+			bson.M{"hasVariants": true, "variants": bson.M{"$elemMatch": bson.M{"$and": onVariant}}},
+			bson.M{"hasVariants": bson.M{"$ne": true}, "$and": onMetadata},
+		}})
+	}
+	return conds
+}
+
+// caseForms returns s as given, lower-cased and capitalized, without
+// duplicates.
+func caseForms(s string) []string {
+	lower := strings.ToLower(s)
+	r := []rune(lower)
+	forms := []string{s}
+	for _, f := range []string{lower, strings.ToUpper(string(r[:1])) + string(r[1:])} {
+		if !containsString(forms, f) {
+			forms = append(forms, f)
+		}
+	}
+	return forms
+}
+
+func caseFormsAll(values []string) []string {
+	var out []string
+	for _, v := range values {
+		out = appendUnique(out, caseForms(v)...)
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Product ranking kinds
+const (
+	RankingTrending    = "trending"
+	RankingBestSellers = "best_sellers"
+)
+
+// Ranking windows. Trending reacts to the last week; best-sellers smooth
+// over a month.
+const (
+	TrendingWindow    = 7 * 24 * time.Hour
+	BestSellersWindow = 30 * 24 * time.Hour
+)
+
+// TrendingUnitWeight is how many product page views one unit sold is worth
+// in the trending score.
+const TrendingUnitWeight = 20
+
+// ProductRanking is one product's precomputed place in a ranking, rebuilt
+// periodically from orders and views.
+type ProductRanking struct {
+	ID          string               `bson:"_id" json:"-"` // kind:productId
+	Kind        string               `bson:"kind" json:"kind"`
+	ProductID   primitive.ObjectID   `bson:"productId" json:"productId"`
+	CategoryIDs []primitive.ObjectID `bson:"categoryIds" json:"categoryIds"` // Main category and subcategories
+	UnitsSold   int                  `bson:"unitsSold" json:"unitsSold"`
+	Views       int                  `bson:"views" json:"views"`
+	Score       float64              `bson:"score" json:"score"`
+	ComputedAt  time.Time            `bson:"computedAt" json:"computedAt"`
+}
+
+// ProductView counts a product's page views for one UTC day.
+type ProductView struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID primitive.ObjectID `bson:"productId" json:"productId"`
+	Day       time.Time          `bson:"day" json:"day"`
+	Count     int                `bson:"count" json:"count"`
+}
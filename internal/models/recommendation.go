@@ -0,0 +1,172 @@
+package models
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Why a product was recommended
+const (
+	ReasonInterest   = "interest"         // In a category picked at onboarding
+	ReasonPurchases  = "purchase_history" // In a category the user buys from
+	ReasonAlsoBought = "also_bought"      // Bought by people who bought what the user did
+	ReasonTrending   = "trending"         // Fallback for users without signals
+)
+
+// Recommendation is a product with the reason it was picked.
+type Recommendation struct {
+	Product
+	Reason string  `json:"reason"`
+	Score  float64 `json:"-"`
+}
+
+// RecommendationSignals is what's known about a user's tastes.
+type RecommendationSignals struct {
+	InterestCategories map[primitive.ObjectID]bool
+	// PurchaseCategories counts the user's order lines per category
+	PurchaseCategories map[primitive.ObjectID]int
+	// CoPurchases counts, per product, the other buyers who bought it along
+	// with something the user bought
+	CoPurchases map[primitive.ObjectID]int
+	// Exclude holds products the user already bought
+	Exclude map[primitive.ObjectID]bool
+	// Budget, from onboarding preferences; zero means unbounded
+	BudgetMin, BudgetMax float64
+}
+
+// Empty reports whether there is nothing to personalise on.
+func (s RecommendationSignals) Empty() bool {
+	return len(s.InterestCategories) == 0 && len(s.PurchaseCategories) == 0 && len(s.CoPurchases) == 0
+}
+
+// Score weights. A product bought alongside the user's purchases by a few
+// people outranks one that is merely in a liked category.
+const (
+	scoreInterest    = 3.0
+	scorePurchase    = 1.0 // Per order line in the category, capped
+	scorePurchaseCap = 5.0
+	scoreCoPurchase  = 4.0 // Per co-buyer, capped
+	scoreCoCap       = 12.0
+	scoreInBudget    = 1.0
+	scorePopularity  = 0.5 // Times log10 of units sold, so bestsellers break ties
+
+	// recommendedPerCategory is how many products one category gets before
+	// other categories get a turn.
+	recommendedPerCategory = 2
+)
+
+// RankRecommendations scores candidates against the signals and returns the
+// best limit, spreading picks across categories so one category doesn't fill
+// the row.
+func RankRecommendations(candidates []Product, s RecommendationSignals, limit int) []Recommendation {
+	scored := make([]Recommendation, 0, len(candidates))
+	seen := make(map[primitive.ObjectID]bool, len(candidates))
+	for _, p := range candidates {
+		if s.Exclude[p.ID] || seen[p.ID] {
+			continue
+		}
+		seen[p.ID] = true
+
+		var score float64
+		reason := ""
+		best := 0.0
+		consider := func(points float64, why string) {
+			score += points
+			if points > best {
+				best, reason = points, why
+			}
+		}
+
+		categories := append([]primitive.ObjectID{p.CategoryID}, p.SubCategoryIDs...)
+		for _, c := range categories {
+			if s.InterestCategories[c] {
+				consider(scoreInterest, ReasonInterest)
+				break
+			}
+		}
+		purchases := 0
+		for _, c := range categories {
+			purchases += s.PurchaseCategories[c]
+		}
+		if purchases > 0 {
+			consider(min(float64(purchases)*scorePurchase, scorePurchaseCap), ReasonPurchases)
+		}
+		if n := s.CoPurchases[p.ID]; n > 0 {
+			consider(min(float64(n)*scoreCoPurchase, scoreCoCap), ReasonAlsoBought)
+		}
+		if reason == "" {
+			continue
+		}
+
+		price := p.EffectivePrice().Float64()
+		if (s.BudgetMin > 0 || s.BudgetMax > 0) && price >= s.BudgetMin && (s.BudgetMax == 0 || price <= s.BudgetMax) {
+			score += scoreInBudget
+		}
+		score += scorePopularity * math.Log10(1+float64(max(p.TotalSales, 0)))
+
+		scored = append(scored, Recommendation{Product: p, Reason: reason, Score: score})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+
+	// Take in score order, deferring a category's extra picks until every
+	// category has had its share
+	out := make([]Recommendation, 0, limit)
+	perCategory := map[primitive.ObjectID]int{}
+	var deferred []Recommendation
+	for _, r := range scored {
+		if len(out) == limit {
+			break
+		}
+		if perCategory[r.CategoryID] >= recommendedPerCategory {
+			deferred = append(deferred, r)
+			continue
+		}
+		perCategory[r.CategoryID]++
+		out = append(out, r)
+	}
+	for _, r := range deferred {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+var budgetNumber = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+// ParseBudgetRange reads a budget such as "50-200", "$50 - $200", "under
+// 100" or "500+" into bounds; a zero max means no upper bound. It reports
+// false for budgets it can't read.
+func ParseBudgetRange(budget string) (minPrice, maxPrice float64, ok bool) {
+	b := strings.ToLower(strings.ReplaceAll(budget, ",", ""))
+	nums := budgetNumber.FindAllString(b, -1)
+	values := make([]float64, 0, len(nums))
+	for _, n := range nums {
+		v, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		values = append(values, v)
+	}
+
+	switch {
+	case len(values) >= 2:
+		lo, hi := values[0], values[1]
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		return lo, hi, true
+	case len(values) == 1 && (strings.Contains(b, "under") || strings.Contains(b, "below") || strings.Contains(b, "less")):
+		return 0, values[0], true
+	case len(values) == 1 && (strings.Contains(b, "+") || strings.Contains(b, "over") || strings.Contains(b, "above")):
+		return values[0], 0, true
+	}
+	return 0, 0, false
+}
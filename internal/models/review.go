@@ -24,8 +24,15 @@ type Review struct {
 	Response   string     `json:"response,omitempty" bson:"response,omitempty"`
 	ResponseAt *time.Time `json:"responseAt,omitempty" bson:"responseAt,omitempty"`
 
+	// Sentiment and topics, filled in by the background analyzer
+	Insights *ReviewInsights `json:"-" bson:"insights,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+
+	// Soft delete; see soft_delete.go
+	DeletedAt *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
 }
 
 type CreateReviewInput struct {
@@ -39,3 +46,11 @@ type CreateReviewInput struct {
 type VendorResponseInput struct {
 	Response string `json:"response" binding:"required"`
 }
+
+// VendorReviewStats aggregates the reviews left across a vendor's products.
+type VendorReviewStats struct {
+	AverageRating float64     `json:"averageRating"`
+	TotalReviews  int         `json:"totalReviews"`
+	Breakdown     map[int]int `json:"breakdown"`    // stars -> count
+	ResponseRate  float64     `json:"responseRate"` // Percentage of reviews the vendor replied to
+}
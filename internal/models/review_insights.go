@@ -0,0 +1,218 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ReviewAnalyzerVersion is bumped whenever the review analyzer's word lists
+// or scoring change, so reviews analyzed before are done again.
+const ReviewAnalyzerVersion = 1
+
+// Review sentiment labels
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+)
+
+// SentimentThreshold is how far from zero a score must be to count as
+// positive or negative rather than neutral.
+const SentimentThreshold = 0.2
+
+// Periods of a review insights summary
+const (
+	ReviewPeriodCurrent  = "current"
+	ReviewPeriodPrevious = "previous"
+)
+
+// reviewTopicMinMentions is how many negative mentions a topic needs in a
+// period to be called trending.
+const reviewTopicMinMentions = 3
+
+// SentimentLabel is the label of a sentiment score in [-1, 1].
+func SentimentLabel(score float64) string {
+	switch {
+	case score >= SentimentThreshold:
+		return SentimentPositive
+	case score <= -SentimentThreshold:
+		return SentimentNegative
+	default:
+		return SentimentNeutral
+	}
+}
+
+// ReviewTopic is something a review talks about, like "sizing", and how it
+// feels about it, from -1 to 1.
+type ReviewTopic struct {
+	Topic     string  `json:"topic" bson:"topic"`
+	Sentiment float64 `json:"sentiment" bson:"sentiment"`
+}
+
+// ReviewInsights is what the background analyzer made of a review's
+// comment and rating.
+type ReviewInsights struct {
+	Sentiment  float64       `json:"sentiment" bson:"sentiment"` // -1 (negative) to 1 (positive)
+	Label      string        `json:"label" bson:"label"`
+	Topics     []ReviewTopic `json:"topics" bson:"topics"`
+	Version    int           `json:"version" bson:"version"` // ReviewAnalyzerVersion when analyzed
+	AnalyzedAt time.Time     `json:"analyzedAt" bson:"analyzedAt"`
+}
+
+// ReviewSentimentCount counts the analyzed reviews with a label in one
+// period of a summary.
+type ReviewSentimentCount struct {
+	Period       string  `bson:"period"`
+	Label        string  `bson:"label"`
+	Reviews      int     `bson:"reviews"`
+	SentimentSum float64 `bson:"sentimentSum"`
+}
+
+// ReviewTopicCount counts the mentions of a topic in one period of a
+// summary.
+type ReviewTopicCount struct {
+	Period       string  `bson:"period"`
+	Topic        string  `bson:"topic"`
+	Mentions     int     `bson:"mentions"`
+	Positive     int     `bson:"positive"`
+	Negative     int     `bson:"negative"`
+	SentimentSum float64 `bson:"sentimentSum"`
+}
+
+// TopicInsight is how reviews talked about a topic over a summary's range,
+// against the range of the same length before it. Trend is "new",
+// "worsening", "improving" or "steady".
+type TopicInsight struct {
+	Topic             string   `json:"topic"`
+	Mentions          int      `json:"mentions"`
+	Positive          int      `json:"positive"`
+	Negative          int      `json:"negative"`
+	Sentiment         float64  `json:"sentiment"`
+	PreviousMentions  int      `json:"previousMentions"`
+	PreviousNegative  int      `json:"previousNegative"`
+	PreviousSentiment *float64 `json:"previousSentiment"` // nil if it didn't come up
+	Trend             string   `json:"trend"`
+}
+
+// ReviewInsightsSummary sums up what reviews said over [From, To): overall
+// sentiment and each topic, most mentioned first. TrendingNegative names
+// the topics drawing more negative mentions than the range before, worst
+// first.
+type ReviewInsightsSummary struct {
+	From              time.Time      `json:"from"`
+	To                time.Time      `json:"to"`
+	Reviews           int            `json:"reviews"`
+	Positive          int            `json:"positive"`
+	Neutral           int            `json:"neutral"`
+	Negative          int            `json:"negative"`
+	Sentiment         float64        `json:"sentiment"`
+	PreviousReviews   int            `json:"previousReviews"`
+	PreviousSentiment *float64       `json:"previousSentiment"`
+	Topics            []TopicInsight `json:"topics"`
+	TrendingNegative  []string       `json:"trendingNegative"`
+}
+
+// sentimentChange is how far a topic's average sentiment must move between
+// periods to be worsening or improving.
+const sentimentChange = 0.15
+
+func roundSentiment(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// BuildReviewInsightsSummary assembles a summary of [from, to) from the
+// counts of it and of the range of the same length before.
+func BuildReviewInsightsSummary(sentiments []ReviewSentimentCount, topics []ReviewTopicCount, from, to time.Time) ReviewInsightsSummary {
+	summary := ReviewInsightsSummary{From: from, To: to, Topics: []TopicInsight{}, TrendingNegative: []string{}}
+
+	var sum, previousSum float64
+	for _, s := range sentiments {
+		if s.Period == ReviewPeriodPrevious {
+			summary.PreviousReviews += s.Reviews
+			previousSum += s.SentimentSum
+			continue
+		}
+		summary.Reviews += s.Reviews
+		sum += s.SentimentSum
+		switch s.Label {
+		case SentimentPositive:
+			summary.Positive += s.Reviews
+		case SentimentNegative:
+			summary.Negative += s.Reviews
+		default:
+			summary.Neutral += s.Reviews
+		}
+	}
+	if summary.Reviews > 0 {
+		summary.Sentiment = roundSentiment(sum / float64(summary.Reviews))
+	}
+	if summary.PreviousReviews > 0 {
+		previous := roundSentiment(previousSum / float64(summary.PreviousReviews))
+		summary.PreviousSentiment = &previous
+	}
+
+	type periods struct{ current, previous ReviewTopicCount }
+	byTopic := map[string]*periods{}
+	for _, t := range topics {
+		p, ok := byTopic[t.Topic]
+		if !ok {
+			p = &periods{}
+			byTopic[t.Topic] = p
+		}
+		if t.Period == ReviewPeriodPrevious {
+			p.previous = t
+		} else {
+			p.current = t
+		}
+	}
+	for topic, p := range byTopic {
+		if p.current.Mentions == 0 {
+			continue
+		}
+		insight := TopicInsight{
+			Topic:            topic,
+			Mentions:         p.current.Mentions,
+			Positive:         p.current.Positive,
+			Negative:         p.current.Negative,
+			Sentiment:        roundSentiment(p.current.SentimentSum / float64(p.current.Mentions)),
+			PreviousMentions: p.previous.Mentions,
+			PreviousNegative: p.previous.Negative,
+			Trend:            "steady",
+		}
+		if p.previous.Mentions == 0 {
+			insight.Trend = "new"
+		} else {
+			previous := roundSentiment(p.previous.SentimentSum / float64(p.previous.Mentions))
+			insight.PreviousSentiment = &previous
+			switch {
+			case insight.Sentiment <= previous-sentimentChange:
+				insight.Trend = "worsening"
+			case insight.Sentiment >= previous+sentimentChange:
+				insight.Trend = "improving"
+			}
+		}
+		summary.Topics = append(summary.Topics, insight)
+	}
+	sort.Slice(summary.Topics, func(i, j int) bool {
+		a, b := summary.Topics[i], summary.Topics[j]
+		if a.Mentions != b.Mentions {
+			return a.Mentions > b.Mentions
+		}
+		return a.Topic < b.Topic
+	})
+
+	var trending []TopicInsight
+	for _, t := range summary.Topics {
+		if t.Sentiment < 0 && t.Negative >= reviewTopicMinMentions && t.Negative > t.PreviousNegative {
+			trending = append(trending, t)
+		}
+	}
+	sort.SliceStable(trending, func(i, j int) bool {
+		return trending[i].Negative-trending[i].PreviousNegative > trending[j].Negative-trending[j].PreviousNegative
+	})
+	for _, t := range trending {
+		summary.TrendingNegative = append(summary.TrendingNegative, t.Topic)
+	}
+	return summary
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sale campaign statuses. A scheduled campaign is put on its products when
+// it starts and taken off again when it ends or is cancelled.
+const (
+	SaleScheduled = "scheduled"
+	SaleRunning   = "running"
+	SaleEnded     = "ended"
+	SaleCancelled = "cancelled"
+)
+
+// MaxSaleDuration is the longest a single campaign can run.
+const MaxSaleDuration = 90 * 24 * time.Hour
+
+// SaleCampaign is a vendor's timed sale: a discount on a set of their
+// products, and the products in a set of their collections, between StartsAt
+// and EndsAt. While it runs the discounted price is written to each
+// product's SalePrice, so every place that shows or charges a price picks it
+// up, and the price it replaced is put back when it ends.
+type SaleCampaign struct {
+	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	VendorID      primitive.ObjectID   `json:"vendorId" bson:"vendorId"`
+	Name          string               `json:"name" bson:"name"`
+	Type          string               `json:"type" bson:"type"` // CouponPercentage or CouponFixed
+	Value         float64              `json:"value" bson:"value"`
+	ProductIDs    []primitive.ObjectID `json:"productIds" bson:"productIds"`
+	CollectionIDs []primitive.ObjectID `json:"collectionIds" bson:"collectionIds"`
+	StartsAt      time.Time            `json:"startsAt" bson:"startsAt"`
+	EndsAt        time.Time            `json:"endsAt" bson:"endsAt"`
+	Status        string               `json:"status" bson:"status"`
+	AppliedCount  int64                `json:"appliedCount" bson:"appliedCount"` // Products it was put on when it started
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// SaleCampaignInput is the body for creating or replacing a campaign.
+type SaleCampaignInput struct {
+	Name          string               `json:"name" validate:"required,min=2,max=80"`
+	Type          string               `json:"type" validate:"required,oneof=percentage fixed"`
+	Value         float64              `json:"value" validate:"gt=0"`
+	ProductIDs    []primitive.ObjectID `json:"productIds" validate:"max=500"`
+	CollectionIDs []primitive.ObjectID `json:"collectionIds" validate:"max=20"`
+	StartsAt      time.Time            `json:"startsAt" validate:"required"`
+	EndsAt        time.Time            `json:"endsAt" validate:"required"`
+}
+
+// ProductSale marks a product as being on a campaign's sale, keeping the
+// sale price the product had before so it can be put back.
+type ProductSale struct {
+	CampaignID        primitive.ObjectID `json:"campaignId" bson:"campaignId"`
+	PreviousSalePrice Money              `json:"-" bson:"previousSalePrice"`
+	EndsAt            time.Time          `json:"endsAt" bson:"endsAt"`
+}
+
+// SalePriceFor is what the product sells for during the campaign, rounded
+// to the minor unit. It is 0 when the campaign should leave the product alone:
+// the discount would take the whole price, or the product is already on a
+// lower sale price of its own.
+func (s SaleCampaign) SalePriceFor(p Product) Money {
+	sale := p.Price - FromFloat(s.Value)
+	if s.Type == CouponPercentage {
+		sale = p.Price - p.Price.Percent(s.Value)
+	}
+	if sale <= 0 || (p.SalePrice > 0 && p.SalePrice <= sale) {
+		return 0
+	}
+	return sale
+}
+
+// Overlaps reports whether the two campaigns' windows share any time.
+func (s SaleCampaign) Overlaps(other SaleCampaign) bool {
+	return s.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(s.EndsAt)
+}
+
+// Open reports whether the campaign is still to run or running.
+func (s SaleCampaign) Open() bool {
+	return s.Status == SaleScheduled || s.Status == SaleRunning
+}
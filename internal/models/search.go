@@ -0,0 +1,99 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Search suggestion kinds
+const (
+	SuggestionProduct  = "product"
+	SuggestionBrand    = "brand"
+	SuggestionCategory = "category"
+	SuggestionStore    = "store"
+)
+
+// SearchSuggestion is one autocomplete entry. ID and Slug are set when the
+// suggestion links somewhere; Popularity is what it was ranked by.
+type SearchSuggestion struct {
+	Type       string `json:"type" bson:"-"`
+	ID         string `json:"id,omitempty" bson:"id,omitempty"`
+	Text       string `json:"text" bson:"text"`
+	Slug       string `json:"slug,omitempty" bson:"slug,omitempty"`
+	Image      string `json:"image,omitempty" bson:"image,omitempty"`
+	Popularity int    `json:"popularity" bson:"popularity"`
+}
+
+// SearchSuggestions groups suggestions by kind, each most popular first.
+type SearchSuggestions struct {
+	Products   []SearchSuggestion `json:"products"`
+	Brands     []SearchSuggestion `json:"brands"`
+	Categories []SearchSuggestion `json:"categories"`
+	Stores     []SearchSuggestion `json:"stores"`
+}
+
+// WordPrefixPattern builds a case-insensitive-ready pattern matching text in
+// which some word starts with the query, so "lam" finds "Desk Lamp". The
+// query is escaped, and runs of whitespace in it match any whitespace.
+func WordPrefixPattern(query string) string {
+	words := strings.Fields(query)
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return `(^|[\s\-/(])` + strings.Join(words, `\s+`)
+}
+
+// maxLoggedQueryLength caps stored queries so pasted text can't bloat the
+// searchEvents collection.
+const maxLoggedQueryLength = 100
+
+// SearchEvent is one storefront search, logged for merchandising reports.
+// Clicks are added as the shopper opens results.
+type SearchEvent struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Query       string             `json:"query" bson:"query"` // Normalized; see NormalizeSearchQuery
+	Category    string             `json:"category,omitempty" bson:"category,omitempty"`
+	ResultCount int64              `json:"resultCount" bson:"resultCount"`
+	Clicks      []SearchClick      `json:"clicks,omitempty" bson:"clicks,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// SearchClick is a result the shopper opened; Position is 1-based across
+// pages.
+type SearchClick struct {
+	ProductID primitive.ObjectID `json:"productId" bson:"productId"`
+	Position  int                `json:"position" bson:"position"`
+	At        time.Time          `json:"at" bson:"at"`
+}
+
+// SearchClickInput is the payload reporting a click on a search result.
+type SearchClickInput struct {
+	SearchID  string `json:"searchId" validate:"required"`
+	ProductID string `json:"productId" validate:"required"`
+	Position  int    `json:"position" validate:"gte=1"`
+}
+
+// SearchQueryStat aggregates the searches for one query over a report's
+// window.
+type SearchQueryStat struct {
+	Query          string    `json:"query" bson:"_id"`
+	Searches       int64     `json:"searches" bson:"searches"`
+	AvgResults     float64   `json:"avgResults" bson:"avgResults"`
+	ClickedThrough int64     `json:"clickedThrough" bson:"clickedThrough"`
+	ClickRate      float64   `json:"clickRate" bson:"clickRate"` // Share of searches with a click
+	LastSearchedAt time.Time `json:"lastSearchedAt" bson:"lastSearchedAt"`
+}
+
+// NormalizeSearchQuery folds a query to the form it is logged and grouped
+// under: lower case, single-spaced and at most maxLoggedQueryLength runes.
+func NormalizeSearchQuery(query string) string {
+	q := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if utf8.RuneCountInString(q) > maxLoggedQueryLength {
+		q = strings.TrimSpace(string([]rune(q)[:maxLoggedQueryLength]))
+	}
+	return q
+}
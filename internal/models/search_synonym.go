@@ -0,0 +1,201 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultSearchLanguage is used when a search doesn't say which language
+// it's in.
+const DefaultSearchLanguage = "en"
+
+// maxSynonymWords is the longest phrase a synonym term may be, and so how
+// many query words are tried together when looking one up.
+const maxSynonymWords = 3
+
+// SearchSynonym is a set of interchangeable search terms in one language:
+// searching for any of them finds products named with any other.
+type SearchSynonym struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Language  string             `json:"language" bson:"language"`
+	Terms     []string           `json:"terms" bson:"terms"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// SearchSynonymInput creates or replaces a synonym set.
+type SearchSynonymInput struct {
+	Language string   `json:"language" validate:"omitempty,len=2,alpha"`
+	Terms    []string `json:"terms" validate:"required,min=2,max=20,dive,required,max=50"`
+}
+
+// NormalizeSynonymTerms folds terms the way queries are folded and drops
+// blanks, duplicates and phrases longer than maxSynonymWords words.
+func NormalizeSynonymTerms(terms []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, t := range terms {
+		t = NormalizeSearchQuery(t)
+		if t == "" || seen[t] || len(strings.Fields(t)) > maxSynonymWords {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// SynonymIndex maps each term to the other terms in its sets.
+type SynonymIndex map[string][]string
+
+// NewSynonymIndex indexes synonym sets by term. A term in several sets gets
+// the union of them.
+func NewSynonymIndex(sets []SearchSynonym) SynonymIndex {
+	idx := SynonymIndex{}
+	for _, set := range sets {
+		for _, term := range set.Terms {
+			for _, other := range set.Terms {
+				if other != term && !containsString(idx[term], other) {
+					idx[term] = append(idx[term], other)
+				}
+			}
+		}
+	}
+	return idx
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxTypoDistance is how many edits a query word may be from a catalog word
+// and still be taken as a misspelling of it. Short words get no slack, since
+// almost every short word is a few edits from another.
+func MaxTypoDistance(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// EditDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of adjacent letters each
+// count as one edit.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// CorrectWord returns the catalog words closest to a word that isn't in the
+// catalog, within MaxTypoDistance. Words that are in the catalog, or that
+// start a catalog word (the shopper may still be typing), aren't corrected.
+func CorrectWord(word string, vocabulary map[string]bool) []string {
+	maxDist := MaxTypoDistance(word)
+	if maxDist == 0 || vocabulary[word] {
+		return nil
+	}
+	best := maxDist + 1
+	var matches []string
+	n := utf8.RuneCountInString(word)
+	for candidate := range vocabulary {
+		if strings.HasPrefix(candidate, word) {
+			return nil
+		}
+		// Lengths alone rule most words out
+		diff := utf8.RuneCountInString(candidate) - n
+		if diff > maxDist || -diff > maxDist {
+			continue
+		}
+		d := EditDistance(word, candidate)
+		switch {
+		case d < best:
+			best, matches = d, []string{candidate}
+		case d == best:
+			matches = append(matches, candidate)
+		}
+	}
+	// When it's too ambiguous to guess between many, keep a stable few
+	sort.Strings(matches)
+	if len(matches) > 3 {
+		matches = matches[:3]
+	}
+	return matches
+}
+
+// ExpandSearchQuery splits a query into the term groups a product must all
+// match, each group listing interchangeable spellings: the words as typed,
+// their likely corrections against the catalog vocabulary, and synonyms of
+// either. Phrases of up to maxSynonymWords words are looked up as synonyms
+// before single words, so "cell phone" can stand for "mobile".
+func ExpandSearchQuery(query string, synonyms SynonymIndex, vocabulary map[string]bool) [][]string {
+	words := strings.Fields(NormalizeSearchQuery(query))
+	groups := [][]string{}
+	for i := 0; i < len(words); {
+		// 1. Longest phrase starting here that is a synonym term
+		matched := 0
+		for n := min(maxSynonymWords, len(words)-i); n > 1; n-- {
+			if _, ok := synonyms[strings.Join(words[i:i+n], " ")]; ok {
+				matched = n
+				break
+			}
+		}
+		if matched > 0 {
+			phrase := strings.Join(words[i:i+matched], " ")
+			groups = append(groups, appendUnique([]string{phrase}, synonyms[phrase]...))
+			i += matched
+			continue
+		}
+
+		// 2. A single word, its corrections, and synonyms of both
+		word := words[i]
+		group := appendUnique([]string{word}, CorrectWord(word, vocabulary)...)
+		for _, w := range group {
+			group = appendUnique(group, synonyms[w]...)
+		}
+		groups = append(groups, group)
+		i++
+	}
+	return groups
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if !containsString(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,553 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotShippable is returned when part of an order can't be shipped to the
+// buyer's region.
+var ErrNotShippable = errors.New("some items can't be shipped to that region")
+
+// ErrUnknownShippingMethod is returned when a buyer picks a shipping method
+// the vendor doesn't offer to their region.
+var ErrUnknownShippingMethod = errors.New("that shipping method isn't available from this vendor")
+
+// Tracking statuses, the same whichever carrier reported them
+const (
+	TrackingPreTransit     = "pre_transit"
+	TrackingInTransit      = "in_transit"
+	TrackingOutForDelivery = "out_for_delivery"
+	TrackingDelivered      = "delivered"
+	TrackingReturned       = "returned"
+	TrackingFailure        = "failure"
+	TrackingUnknown        = "unknown"
+
+	// Collections by the pickup method, recorded on the timeline
+	TrackingReadyForPickup = "ready_for_pickup"
+	TrackingPickedUp       = "picked_up"
+)
+
+// VolumetricDivisor converts a parcel's volume in cm³ to the weight in kg
+// couriers bill it at when it is bulkier than it is heavy.
+const VolumetricDivisor = 5000
+
+// ShippingRateTable is how a vendor charges for shipping. The platform's own
+// table has a nil VendorID and prices everything from vendors without one.
+type ShippingRateTable struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Zones     []ShippingZone     `json:"zones" bson:"zones" validate:"required,min=1,max=50,dive"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ShippingZone prices shipping to a set of regions. Regions are country or
+// country-state codes ("NG", "NG-LA"); a country covers all of its states and
+// the most specific match wins. A zone without regions covers everywhere the
+// other zones don't.
+type ShippingZone struct {
+	Name       string   `json:"name" bson:"name" validate:"required,max=60"`
+	Regions    []string `json:"regions,omitempty" bson:"regions,omitempty" validate:"max=300,dive,min=2,max=10"`
+	BaseFee    float64  `json:"baseFee" bson:"baseFee" validate:"gte=0"`
+	IncludedKg float64  `json:"includedKg,omitempty" bson:"includedKg,omitempty" validate:"gte=0"` // Billable weight covered by BaseFee
+	PerKg      float64  `json:"perKg,omitempty" bson:"perKg,omitempty" validate:"gte=0"`           // For each kg over IncludedKg
+	FreeOver   float64  `json:"freeOver,omitempty" bson:"freeOver,omitempty" validate:"gte=0"`     // Free above this subtotal; 0 for never
+	LiveRates  bool     `json:"liveRates,omitempty" bson:"liveRates,omitempty"`                    // Charge the carrier's cheapest rate when one can be had
+
+	// Business days in transit, for delivery estimates
+	MinTransitDays int `json:"minTransitDays" bson:"minTransitDays" validate:"gte=0,lte=90"`
+	MaxTransitDays int `json:"maxTransitDays" bson:"maxTransitDays" validate:"gte=0,lte=90,gtefield=MinTransitDays"`
+}
+
+// DefaultShippingRates applies until an admin sets the platform's table: a
+// flat 25 per order, arriving in 2-7 business days. Free shipping is set
+// apart from the table; see DefaultFreeShipping.
+var DefaultShippingRates = ShippingRateTable{
+	Zones: []ShippingZone{{Name: "Standard", BaseFee: 25, MinTransitDays: 2, MaxTransitDays: 7}},
+}
+
+// FreeShippingRule waives shipping on shipments over a threshold. The
+// platform's rule (nil VendorID) covers everyone; a vendor's own replaces it
+// for their items, which then ship separately so it can apply.
+type FreeShippingRule struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `json:"vendorId" bson:"vendorId"`
+	Threshold float64            `json:"threshold" bson:"threshold" validate:"gte=0"`                                     // Free above this subtotal; 0 for never
+	Regions   []string           `json:"regions,omitempty" bson:"regions,omitempty" validate:"max=300,dive,min=2,max=10"` // Where it applies; empty for everywhere
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// DefaultFreeShipping applies until an admin sets the platform's rule:
+// shipping is free over 500, everywhere.
+var DefaultFreeShipping = FreeShippingRule{Threshold: 500}
+
+// Covers reports whether the rule applies in region.
+func (r FreeShippingRule) Covers(region string) bool {
+	return r.Threshold > 0 && inRegions(r.Regions, region)
+}
+
+// Applies reports whether a shipment of the given subtotal to region ships
+// free under the rule.
+func (r FreeShippingRule) Applies(region string, subtotal float64) bool {
+	return r.Covers(region) && subtotal > r.Threshold
+}
+
+// FreeShippingProgress is how close a shipment in the cart is to shipping
+// free, for "spend X more" prompts before the buyer has given an address.
+type FreeShippingProgress struct {
+	VendorIDs []primitive.ObjectID `json:"vendorIds"`
+	Threshold float64              `json:"threshold"`
+	Regions   []string             `json:"regions,omitempty"` // Only here; empty for everywhere
+	Subtotal  float64              `json:"subtotal"`
+	Remaining float64              `json:"remaining"` // 0 once it qualifies
+}
+
+// ShippingItem is a line to be shipped.
+type ShippingItem struct {
+	ProductID    primitive.ObjectID
+	Name         string
+	VendorID     primitive.ObjectID
+	Quantity     int
+	Subtotal     float64
+	Dimensions   Dimensions
+	Restrictions ShippingRestrictions
+}
+
+// Shipping method codes
+const (
+	ShippingMethodStandard = "standard"
+	ShippingMethodExpress  = "express"
+	ShippingMethodPickup   = "pickup"
+)
+
+// ShippingMethod is a way a vendor offers to ship their items, at a flat
+// price. A vendor with methods ships separately and the buyer picks one.
+type ShippingMethod struct {
+	Code         string   `json:"code" bson:"code" validate:"required,oneof=standard express pickup"`
+	Name         string   `json:"name" bson:"name" validate:"required,max=60"`
+	Fee          float64  `json:"fee" bson:"fee" validate:"gte=0"`
+	FreeOver     float64  `json:"freeOver,omitempty" bson:"freeOver,omitempty" validate:"gte=0"`
+	HandlingDays int      `json:"handlingDays" bson:"handlingDays" validate:"gte=0,lte=60"`                        // Days to dispatch
+	Regions      []string `json:"regions,omitempty" bson:"regions,omitempty" validate:"max=300,dive,min=2,max=10"` // Where it's offered; empty for everywhere
+
+	// Business days in transit once dispatched; both 0 for pickup
+	MinTransitDays int `json:"minTransitDays" bson:"minTransitDays" validate:"gte=0,lte=90"`
+	MaxTransitDays int `json:"maxTransitDays" bson:"maxTransitDays" validate:"gte=0,lte=90,gtefield=MinTransitDays"`
+}
+
+// Serves reports whether the method is offered in region.
+func (m ShippingMethod) Serves(region string) bool {
+	return inRegions(m.Regions, region)
+}
+
+// inRegions reports whether region is one of regions or a state of one of
+// them. An empty list covers everywhere.
+func inRegions(regions []string, region string) bool {
+	if len(regions) == 0 {
+		return true
+	}
+	region = NormalizeRegion(region)
+	for _, r := range regions {
+		r = NormalizeRegion(r)
+		if region == r || strings.HasPrefix(region, r+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// Price returns the method's fee for a shipment of the given subtotal.
+// Pickup is always free.
+func (m ShippingMethod) Price(subtotal float64) float64 {
+	if m.Code == ShippingMethodPickup || (m.FreeOver > 0 && subtotal > m.FreeOver) {
+		return 0
+	}
+	return m.Fee
+}
+
+// ShippingMethodOption is a method a buyer can pick for a shipment.
+type ShippingMethodOption struct {
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Fee            float64 `json:"fee"`
+	HandlingDays   int     `json:"handlingDays"`
+	MinTransitDays int     `json:"minTransitDays"`
+	MaxTransitDays int     `json:"maxTransitDays"`
+}
+
+// Shipment is one priced parcel of an order, and the part of the order its
+// vendors fulfil: a vendor with rates or methods of their own ships
+// separately, everyone else's items go together at platform rates.
+type Shipment struct {
+	VendorIDs    []primitive.ObjectID   `json:"vendorIds" bson:"vendorIds"`
+	Zone         string                 `json:"zone" bson:"zone"`
+	WeightKg     float64                `json:"weightKg" bson:"weightKg"` // Billable
+	Subtotal     float64                `json:"subtotal" bson:"subtotal"`
+	Fee          float64                `json:"fee" bson:"fee"`
+	LiveRates    bool                   `json:"liveRates,omitempty" bson:"liveRates,omitempty"` // Zone wants carrier rates; Fee is the table price until they're applied
+	Carrier      string                 `json:"carrier,omitempty" bson:"carrier,omitempty"`     // Set when Fee is a carrier rate
+	Service      string                 `json:"service,omitempty" bson:"service,omitempty"`
+	Method       string                 `json:"method,omitempty" bson:"method,omitempty"` // The vendor's method the buyer chose
+	HandlingDays int                    `json:"handlingDays,omitempty" bson:"handlingDays,omitempty"`
+	ShipBy       *time.Time             `json:"shipBy,omitempty" bson:"shipBy,omitempty"`             // Dispatch deadline, set when the order is placed
+	Methods      []ShippingMethodOption `json:"methods,omitempty" bson:"-"`                           // What the buyer can choose from
+	FreeShipping bool                   `json:"freeShipping,omitempty" bson:"freeShipping,omitempty"` // Waived by a free-shipping rule
+
+	MinTransitDays    int               `json:"minTransitDays" bson:"minTransitDays"`
+	MaxTransitDays    int               `json:"maxTransitDays" bson:"maxTransitDays"`
+	EstimatedDelivery *DeliveryEstimate `json:"estimatedDelivery,omitempty" bson:"estimatedDelivery,omitempty"`
+
+	// Collection, for the pickup method. The code is given to the buyer and
+	// checked by the vendor in place of a tracking number.
+	Pickup      *PickupLocation `json:"pickup,omitempty" bson:"pickup,omitempty"`
+	PickupCode  string          `json:"-" bson:"pickupCode,omitempty"`
+	ReadyAt     *time.Time      `json:"readyAt,omitempty" bson:"readyAt,omitempty"`
+	CollectedAt *time.Time      `json:"collectedAt,omitempty" bson:"collectedAt,omitempty"`
+}
+
+// ShippingQuote is the shipping charged on an order.
+type ShippingQuote struct {
+	Region            string            `json:"region"`
+	Fee               float64           `json:"fee"`
+	Shipments         []Shipment        `json:"shipments"`
+	EstimatedDelivery *DeliveryEstimate `json:"estimatedDelivery,omitempty"` // When the last shipment should arrive
+}
+
+// DeliveryEstimate is the window something should arrive in.
+type DeliveryEstimate struct {
+	Earliest time.Time `json:"earliest" bson:"earliest"`
+	Latest   time.Time `json:"latest" bson:"latest"`
+}
+
+// AddBusinessDays returns t moved forward by days weekdays.
+func AddBusinessDays(t time.Time, days int) time.Time {
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return t
+}
+
+// EstimateDelivery sets when each shipment, and so the whole order, should
+// arrive if ordered at from: the vendors' handling time then the zone's or
+// carrier's transit time, in business days. A shipment sent by a vendor's
+// shipping method takes the method's handling time; the rest take the
+// slowest processing time among their vendors, from handling.
+func (q *ShippingQuote) EstimateDelivery(from time.Time, handling map[primitive.ObjectID]ProcessingTime) {
+	q.EstimatedDelivery = nil
+	for i := range q.Shipments {
+		s := &q.Shipments[i]
+		minHandling, maxHandling := s.HandlingDays, s.HandlingDays
+		if s.Method == "" {
+			for _, vendorID := range s.VendorIDs {
+				minHandling = max(minHandling, handling[vendorID].MinDays)
+				maxHandling = max(maxHandling, handling[vendorID].MaxDays)
+			}
+		}
+		s.EstimatedDelivery = &DeliveryEstimate{
+			Earliest: AddBusinessDays(from, minHandling+s.MinTransitDays),
+			Latest:   AddBusinessDays(from, maxHandling+s.MaxTransitDays),
+		}
+
+		if q.EstimatedDelivery == nil {
+			estimate := *s.EstimatedDelivery
+			q.EstimatedDelivery = &estimate
+			continue
+		}
+		if s.EstimatedDelivery.Earliest.After(q.EstimatedDelivery.Earliest) {
+			q.EstimatedDelivery.Earliest = s.EstimatedDelivery.Earliest
+		}
+		if s.EstimatedDelivery.Latest.After(q.EstimatedDelivery.Latest) {
+			q.EstimatedDelivery.Latest = s.EstimatedDelivery.Latest
+		}
+	}
+}
+
+// ShippingRates is everything shipping is priced from: the platform's table
+// and free-shipping rule, and vendors' own tables, shipping methods and
+// free-shipping rules, keyed by vendor.
+type ShippingRates struct {
+	Platform           ShippingRateTable
+	Vendors            map[primitive.ObjectID]ShippingRateTable
+	Methods            map[primitive.ObjectID][]ShippingMethod
+	FreeShipping       FreeShippingRule
+	VendorFreeShipping map[primitive.ObjectID]FreeShippingRule
+}
+
+// ShippingQuoteInput asks what shipping a basket would cost. Without items
+// the buyer's cart is quoted. A full address allows carrier rates and, with
+// no region given, sets it. Methods picks a shipping method by code for
+// vendors that offer them, keyed by vendor ID, and Pickups the location to
+// collect from for vendors whose pickup method was picked.
+type ShippingQuoteInput struct {
+	Region  string              `json:"region"`
+	Address *PostalAddress      `json:"address"`
+	Items   []ShippingQuoteItem `json:"items" validate:"max=100,dive"`
+	Methods map[string]string   `json:"methods"`
+	Pickups map[string]string   `json:"pickups"` // Pickup location ID by vendor ID
+}
+
+// ShippingQuoteItem is a product and quantity to quote for.
+type ShippingQuoteItem struct {
+	ProductID primitive.ObjectID `json:"productId" validate:"required"`
+	Quantity  int                `json:"quantity" validate:"required,min=1,max=1000"`
+}
+
+// PostalAddress is an address in the structured form carriers need.
+type PostalAddress struct {
+	Name       string `json:"name" bson:"name" validate:"required,max=120"`
+	Street1    string `json:"street1" bson:"street1" validate:"required,max=200"`
+	Street2    string `json:"street2,omitempty" bson:"street2,omitempty" validate:"max=200"`
+	City       string `json:"city" bson:"city" validate:"required,max=100"`
+	State      string `json:"state,omitempty" bson:"state,omitempty" validate:"max=100"`
+	PostalCode string `json:"postalCode,omitempty" bson:"postalCode,omitempty" validate:"max=20"`
+	Country    string `json:"country" bson:"country" validate:"required,country"` // ISO 3166-1 alpha-2
+	Phone      string `json:"phone,omitempty" bson:"phone,omitempty" validate:"omitempty,max=30,phone"`
+	Email      string `json:"email,omitempty" bson:"email,omitempty" validate:"omitempty,email"`
+
+	Geo *GeoPoint `json:"geo,omitempty" bson:"geo,omitempty"` // Set when the address was geocoded
+}
+
+// Region is the address's shipping region: the country, with the state when
+// it is given as a code ("NG-LA").
+func (a PostalAddress) Region() string {
+	state := strings.TrimSpace(a.State)
+	if state != "" && len(state) <= 3 {
+		return NormalizeRegion(a.Country + "-" + state)
+	}
+	return NormalizeRegion(a.Country)
+}
+
+// BillableWeight is the weight a courier charges for a unit of the item:
+// its actual weight or, for bulky items, its volumetric weight.
+func (d Dimensions) BillableWeight() float64 {
+	return math.Max(d.Weight, d.Length*d.Width*d.Height/VolumetricDivisor)
+}
+
+// ConsolidatedParcel is the box items are packed into for a carrier: their
+// weights added, stacked on the largest footprint.
+func ConsolidatedParcel(items []ShippingItem) Dimensions {
+	var parcel Dimensions
+	for _, item := range items {
+		qty := float64(item.Quantity)
+		parcel.Length = math.Max(parcel.Length, item.Dimensions.Length)
+		parcel.Width = math.Max(parcel.Width, item.Dimensions.Width)
+		parcel.Height += item.Dimensions.Height * qty
+		parcel.Weight += item.Dimensions.Weight * qty
+	}
+	return parcel
+}
+
+// NormalizeRegion puts a region code in the form zones are matched on.
+func NormalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+// Zone returns the zone shipping to region is priced by, or false when the
+// table doesn't ship there.
+func (t ShippingRateTable) Zone(region string) (ShippingZone, bool) {
+	region = NormalizeRegion(region)
+	best, bestLen := -1, -1
+	for i, zone := range t.Zones {
+		if len(zone.Regions) == 0 {
+			if bestLen < 0 {
+				best, bestLen = i, 0
+			}
+			continue
+		}
+		for _, r := range zone.Regions {
+			r = NormalizeRegion(r)
+			if (region == r || strings.HasPrefix(region, r+"-")) && len(r) > bestLen {
+				best, bestLen = i, len(r)
+			}
+		}
+	}
+	if best < 0 {
+		return ShippingZone{}, false
+	}
+	return t.Zones[best], true
+}
+
+// Free reports whether a shipment of the given subtotal ships free.
+func (z ShippingZone) Free(subtotal float64) bool {
+	return z.FreeOver > 0 && subtotal > z.FreeOver
+}
+
+// Price returns the fee for a shipment of the given billable weight and
+// subtotal.
+func (z ShippingZone) Price(weightKg, subtotal float64) float64 {
+	if z.Free(subtotal) {
+		return 0
+	}
+	fee := z.BaseFee + z.PerKg*math.Max(0, weightKg-z.IncludedKg)
+	return math.Round(fee*100) / 100
+}
+
+// shipmentGroup is items that ship together, and what they're priced by.
+type shipmentGroup struct {
+	table    ShippingRateTable
+	methods  []ShippingMethod
+	free     FreeShippingRule
+	shipment Shipment
+}
+
+// groupShipments splits items into shipments, keeping the order vendors
+// first appear in. Vendors with shipping methods, a rate table or a
+// free-shipping rule of their own ship separately; everyone else's items go
+// together at platform rates.
+func groupShipments(items []ShippingItem, rates ShippingRates) []*shipmentGroup {
+	var groups []*shipmentGroup
+	byOwner := map[primitive.ObjectID]*shipmentGroup{}
+	for _, item := range items {
+		owner := primitive.NilObjectID
+		g := &shipmentGroup{table: rates.Platform, free: rates.FreeShipping}
+		if methods := rates.Methods[item.VendorID]; len(methods) > 0 {
+			owner, g.methods = item.VendorID, methods
+		} else if t, ok := rates.Vendors[item.VendorID]; ok {
+			owner, g.table = item.VendorID, t
+		}
+		if rule, ok := rates.VendorFreeShipping[item.VendorID]; ok {
+			owner, g.free = item.VendorID, rule
+		}
+		if existing, ok := byOwner[owner]; ok {
+			g = existing
+		} else {
+			byOwner[owner] = g
+			groups = append(groups, g)
+		}
+		if !containsObjectID(g.shipment.VendorIDs, item.VendorID) {
+			g.shipment.VendorIDs = append(g.shipment.VendorIDs, item.VendorID)
+		}
+		g.shipment.WeightKg += item.Dimensions.BillableWeight() * float64(item.Quantity)
+		g.shipment.Subtotal += item.Subtotal
+	}
+	return groups
+}
+
+// QuoteShipping prices shipping items to region. Items from vendors with
+// shipping methods ship at the method chosen in selected (the cheapest by
+// default), items from vendors with a rate table at their rates, and the
+// rest together at platform rates. A shipment over its free-shipping
+// threshold ships free whatever it would have cost. It returns
+// ErrNotShippable if any can't be shipped to region, and
+// ErrUnknownShippingMethod if a chosen method isn't offered there.
+func QuoteShipping(items []ShippingItem, rates ShippingRates, region string, selected map[primitive.ObjectID]string) (ShippingQuote, error) {
+	quote := ShippingQuote{Region: NormalizeRegion(region), Shipments: []Shipment{}}
+
+	for _, g := range groupShipments(items, rates) {
+		g.shipment.WeightKg = math.Round(g.shipment.WeightKg*1000) / 1000
+		if g.methods != nil {
+			if err := priceByMethod(&g.shipment, g.methods, quote.Region, selected[g.shipment.VendorIDs[0]]); err != nil {
+				return ShippingQuote{}, err
+			}
+		} else {
+			zone, ok := g.table.Zone(quote.Region)
+			if !ok {
+				return ShippingQuote{}, ErrNotShippable
+			}
+			g.shipment.Zone = zone.Name
+			g.shipment.LiveRates = zone.LiveRates && !zone.Free(g.shipment.Subtotal)
+			g.shipment.Fee = zone.Price(g.shipment.WeightKg, g.shipment.Subtotal)
+			g.shipment.MinTransitDays, g.shipment.MaxTransitDays = zone.MinTransitDays, zone.MaxTransitDays
+		}
+		if g.free.Applies(quote.Region, g.shipment.Subtotal) {
+			g.shipment.FreeShipping = true
+			g.shipment.Fee, g.shipment.LiveRates = 0, false
+			for i := range g.shipment.Methods {
+				g.shipment.Methods[i].Fee = 0
+			}
+		}
+		quote.Fee += g.shipment.Fee
+		quote.Shipments = append(quote.Shipments, g.shipment)
+	}
+	quote.Fee = math.Round(quote.Fee*100) / 100
+	return quote, nil
+}
+
+// FreeShippingProgressFor shows, for each shipment the items would make up
+// that has a free-shipping rule, how far it is from shipping free. Region is
+// left out until the buyer gives one, so rules limited to some regions are
+// listed with them.
+func FreeShippingProgressFor(items []ShippingItem, rates ShippingRates) []FreeShippingProgress {
+	progress := []FreeShippingProgress{}
+	for _, g := range groupShipments(items, rates) {
+		if g.free.Threshold <= 0 {
+			continue
+		}
+		subtotal := math.Round(g.shipment.Subtotal*100) / 100
+		remaining := 0.0
+		if subtotal <= g.free.Threshold {
+			// Strictly over the threshold qualifies, so a cent more is needed
+			remaining = math.Round((g.free.Threshold-subtotal+0.01)*100) / 100
+		}
+		progress = append(progress, FreeShippingProgress{
+			VendorIDs: g.shipment.VendorIDs,
+			Threshold: g.free.Threshold,
+			Regions:   g.free.Regions,
+			Subtotal:  subtotal,
+			Remaining: remaining,
+		})
+	}
+	return progress
+}
+
+// priceByMethod prices a vendor's shipment at the method chosen, or the
+// cheapest offered in region when none was, listing the others as options.
+// Pickup is only chosen by default when nothing else is offered, since the
+// buyer has to go and collect it.
+func priceByMethod(shipment *Shipment, methods []ShippingMethod, region, code string) error {
+	var chosen, pickup *ShippingMethod
+	for i := range methods {
+		m := &methods[i]
+		if !m.Serves(region) {
+			continue
+		}
+		fee := m.Price(shipment.Subtotal)
+		shipment.Methods = append(shipment.Methods, ShippingMethodOption{
+			Code:           m.Code,
+			Name:           m.Name,
+			Fee:            fee,
+			HandlingDays:   m.HandlingDays,
+			MinTransitDays: m.MinTransitDays,
+			MaxTransitDays: m.MaxTransitDays,
+		})
+		if code != "" && m.Code == code {
+			chosen = m
+		}
+		if code == "" && m.Code == ShippingMethodPickup {
+			pickup = m
+		} else if code == "" && (chosen == nil || fee < chosen.Price(shipment.Subtotal)) {
+			chosen = m
+		}
+	}
+	if len(shipment.Methods) == 0 {
+		return ErrNotShippable
+	}
+	if chosen == nil {
+		chosen = pickup
+	}
+	if chosen == nil {
+		return ErrUnknownShippingMethod
+	}
+	shipment.Zone = chosen.Name
+	shipment.Method = chosen.Code
+	shipment.HandlingDays = chosen.HandlingDays
+	shipment.MinTransitDays, shipment.MaxTransitDays = chosen.MinTransitDays, chosen.MaxTransitDays
+	shipment.Fee = math.Round(chosen.Price(shipment.Subtotal)*100) / 100
+	return nil
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
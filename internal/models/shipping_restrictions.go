@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShippingRestrictions limits where a product can be sent.
+type ShippingRestrictions struct {
+	DomesticOnly    bool     `json:"domesticOnly,omitempty" bson:"domesticOnly,omitempty"`                                            // Only within the country the vendor ships from
+	ExcludedRegions []string `json:"excludedRegions,omitempty" bson:"excludedRegions,omitempty" validate:"max=300,dive,min=2,max=10"` // Countries ("US") or regions ("NG-BO") it can't go to
+}
+
+// Normalize tidies the excluded regions and drops repeats.
+func (r *ShippingRestrictions) Normalize() {
+	seen := map[string]bool{}
+	regions := r.ExcludedRegions[:0]
+	for _, region := range r.ExcludedRegions {
+		region = NormalizeRegion(region)
+		if region != "" && !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+	r.ExcludedRegions = regions
+}
+
+// Reason returns why the product can't be sent to region from a vendor
+// shipping from home, a country code, or "" if it can. A domestic-only
+// product is held back when either end is unknown, as there's no telling
+// whether it would cross a border.
+func (r ShippingRestrictions) Reason(region, home string) string {
+	region = NormalizeRegion(region)
+	country, _, _ := strings.Cut(region, "-")
+	home = strings.ToUpper(strings.TrimSpace(home))
+	if r.DomesticOnly {
+		switch {
+		case home == "":
+			return "Only ships within the seller's country, which they haven't set"
+		case region == "":
+			return "Only ships within " + home + "; a delivery region is needed to check"
+		case country != home:
+			return "Only ships within " + home
+		}
+	}
+	for _, excluded := range r.ExcludedRegions {
+		excluded = NormalizeRegion(excluded)
+		if region == excluded || (region != "" && strings.HasPrefix(region, excluded+"-")) {
+			return "Can't be shipped to " + excluded
+		}
+	}
+	return ""
+}
+
+// RestrictedItem is a line that can't be sent where the buyer asked.
+type RestrictedItem struct {
+	ProductID primitive.ObjectID `json:"productId"`
+	Name      string             `json:"name"`
+	Reason    string             `json:"reason"`
+}
+
+// ShippingRestrictionError is returned when some items can't be sent to the
+// buyer's region; Items says which and why.
+type ShippingRestrictionError struct {
+	Region string
+	Items  []RestrictedItem
+}
+
+func (e *ShippingRestrictionError) Error() string {
+	if e.Region == "" {
+		return "some items can't be shipped without a delivery region"
+	}
+	return "some items can't be shipped to " + e.Region
+}
+
+// RestrictedItems lists the items that can't be sent to region, given the
+// country each vendor ships from.
+func RestrictedItems(items []ShippingItem, region string, home map[primitive.ObjectID]string) []RestrictedItem {
+	var restricted []RestrictedItem
+	for _, item := range items {
+		if reason := item.Restrictions.Reason(region, home[item.VendorID]); reason != "" {
+			restricted = append(restricted, RestrictedItem{ProductID: item.ProductID, Name: item.Name, Reason: reason})
+		}
+	}
+	return restricted
+}
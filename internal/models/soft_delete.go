@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Users, products, reviews and stores are soft-deleted: deleting one sets
+// its deletedAt and deletedBy, and reads leave it out from then on. It can
+// be restored from the trash until the retention period has passed, when
+// it is purged for good. Each kind is also the name of its collection.
+const (
+	TrashUsers    = "users"
+	TrashProducts = "products"
+	TrashReviews  = "reviews"
+	TrashStores   = "stores"
+)
+
+// TrashKinds are the kinds of document that are soft-deleted.
+var TrashKinds = []string{TrashUsers, TrashProducts, TrashReviews, TrashStores}
+
+// DefaultTrashRetention is how long soft-deleted documents are kept.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// IsTrashKind reports whether documents of kind are soft-deleted.
+func IsTrashKind(kind string) bool {
+	for _, k := range TrashKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// TrashedItem is a soft-deleted document as the trash lists it. Label is
+// its name, or a review's comment.
+type TrashedItem struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Kind      string             `json:"kind" bson:"-"`
+	Label     string             `json:"label" bson:"-"`
+	DeletedAt time.Time          `json:"deletedAt" bson:"deletedAt"`
+	DeletedBy primitive.ObjectID `json:"deletedBy" bson:"deletedBy"`
+	PurgeAt   time.Time          `json:"purgeAt" bson:"-"` // When it can no longer be restored
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Store is a vendor's public storefront. It is created from the seller
+// application when the vendor account is activated and is looked up by Slug.
+type Store struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	VendorID primitive.ObjectID `bson:"vendorID" json:"vendorId"`
+	Slug     string             `bson:"slug" json:"slug"`
+
+	// A vendor may change their slug once; old slugs keep redirecting here
+	PreviousSlugs []string   `bson:"previousSlugs,omitempty" json:"previousSlugs,omitempty"`
+	SlugChangedAt *time.Time `bson:"slugChangedAt,omitempty" json:"slugChangedAt,omitempty"`
+
+	// Branding
+	Name         string `bson:"name" json:"name"`
+	Description  string `bson:"description" json:"description"`
+	Logo         string `bson:"logo,omitempty" json:"logo,omitempty"`
+	Banner       string `bson:"banner,omitempty" json:"banner,omitempty"`
+	PrimaryColor string `bson:"primaryColor,omitempty" json:"primaryColor,omitempty"`
+	AccentColor  string `bson:"accentColor,omitempty" json:"accentColor,omitempty"`
+
+	// Customization
+	About       string            `bson:"about,omitempty" json:"about,omitempty"`
+	SocialLinks []SocialMediaLink `bson:"socialLinks,omitempty" json:"socialLinks,omitempty"`
+	Sections    []StoreSection    `bson:"sections,omitempty" json:"sections,omitempty"`
+
+	// SEO & Metadata. Slug is unused here; the store's own Slug is canonical.
+	SEO *SEO `bson:"seo,omitempty" json:"seo,omitempty"`
+
+	// Policies. ShippingPolicy/ReturnPolicy hold the free text captured at
+	// onboarding; Policies is the structured version vendors manage later.
+	Policies       *StorePolicies `bson:"policies,omitempty" json:"policies,omitempty"`
+	Location       string         `bson:"location,omitempty" json:"location,omitempty"`
+	ShipsFrom      string         `bson:"shipsFrom,omitempty" json:"shipsFrom,omitempty"`
+	ShippingPolicy string         `bson:"shippingPolicy,omitempty" json:"shippingPolicy,omitempty"`
+	ReturnPolicy   string         `bson:"returnPolicy,omitempty" json:"returnPolicy,omitempty"`
+
+	// Shipping methods buyers choose between at checkout. Without any, the
+	// vendor's items ship at rate-table prices.
+	ShippingMethods []ShippingMethod `bson:"shippingMethods,omitempty" json:"shippingMethods,omitempty"`
+	// Where buyers can collect orders shipped by the pickup method
+	PickupLocations []PickupLocation `bson:"pickupLocations,omitempty" json:"pickupLocations,omitempty"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+
+	// Soft delete; see soft_delete.go
+	DeletedAt *time.Time          `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
+}
+
+// ReturnPolicy describes whether and for how long a store accepts returns.
+type ReturnPolicy struct {
+	AcceptsReturns          bool    `bson:"acceptsReturns" json:"acceptsReturns"`
+	WindowDays              int     `bson:"windowDays" json:"windowDays" validate:"gte=0,lte=365"` // Counted from delivery
+	BuyerPaysReturnShipping bool    `bson:"buyerPaysReturnShipping" json:"buyerPaysReturnShipping"`
+	RestockingFeePercent    float64 `bson:"restockingFeePercent" json:"restockingFeePercent" validate:"gte=0,lte=50"`
+	Conditions              string  `bson:"conditions,omitempty" json:"conditions,omitempty" validate:"max=2000"`
+}
+
+// ReturnDeadline is the last moment a return may be opened for an order
+// delivered at deliveredAt.
+func (p ReturnPolicy) ReturnDeadline(deliveredAt time.Time) time.Time {
+	return deliveredAt.AddDate(0, 0, p.WindowDays)
+}
+
+// AllowsReturn reports whether a return opened at the given time falls within
+// the policy's window. Returns subsystems should validate against this rather
+// than a platform-wide constant.
+func (p ReturnPolicy) AllowsReturn(deliveredAt, at time.Time) bool {
+	if !p.AcceptsReturns || deliveredAt.IsZero() {
+		return false
+	}
+	return !at.After(p.ReturnDeadline(deliveredAt))
+}
+
+// ShippingPolicy describes where and how a store ships.
+type ShippingPolicy struct {
+	ShipsFrom             string         `bson:"shipsFrom,omitempty" json:"shipsFrom,omitempty" validate:"max=120"`
+	ShipsTo               []string       `bson:"shipsTo,omitempty" json:"shipsTo,omitempty" validate:"max=50,dive,max=60"`
+	FreeShippingThreshold float64        `bson:"freeShippingThreshold,omitempty" json:"freeShippingThreshold,omitempty" validate:"gte=0"`
+	Details               string         `bson:"details,omitempty" json:"details,omitempty" validate:"max=2000"`
+	Origin                *PostalAddress `bson:"origin,omitempty" json:"origin,omitempty"` // Where parcels are collected, for carrier rates and labels
+}
+
+// ProcessingTime is how many business days a store takes to ship an order.
+type ProcessingTime struct {
+	MinDays int `bson:"minDays" json:"minDays" validate:"gte=0,lte=60"`
+	MaxDays int `bson:"maxDays" json:"maxDays" validate:"gte=0,lte=60,gtefield=MinDays"`
+}
+
+// StorePolicies groups the structured policies shown on the storefront and
+// on product pages.
+type StorePolicies struct {
+	Return         ReturnPolicy   `bson:"return" json:"return"`
+	Shipping       ShippingPolicy `bson:"shipping" json:"shipping"`
+	ProcessingTime ProcessingTime `bson:"processingTime" json:"processingTime"`
+	UpdatedAt      time.Time      `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Homepage section types a vendor can arrange on their storefront.
+const (
+	SectionFeaturedProducts = "featured_products"
+	SectionNewArrivals      = "new_arrivals"
+	SectionAbout            = "about"
+)
+
+// StoreSection is one block of a storefront homepage. Sections render in
+// Position order; featured_products lists ProductIDs in the order given.
+type StoreSection struct {
+	Type       string               `bson:"type" json:"type" validate:"required,oneof=featured_products new_arrivals about"`
+	Title      string               `bson:"title,omitempty" json:"title,omitempty" validate:"max=80"`
+	ProductIDs []primitive.ObjectID `bson:"productIds,omitempty" json:"productIds,omitempty" validate:"max=24"`
+	Limit      int                  `bson:"limit,omitempty" json:"limit,omitempty" validate:"gte=0,lte=24"`
+	Position   int                  `bson:"position" json:"position"`
+	Enabled    bool                 `bson:"enabled" json:"enabled"`
+}
+
+// DefaultStoreSections is the layout used until a vendor arranges their own.
+var DefaultStoreSections = []StoreSection{
+	{Type: SectionAbout, Position: 0, Enabled: true},
+	{Type: SectionNewArrivals, Title: "New Arrivals", Limit: 8, Position: 1, Enabled: true},
+}
+
+// NewStoreFromApplication builds a Store from the branding and policies a
+// vendor supplied during onboarding.
+func NewStoreFromApplication(vendorID primitive.ObjectID, app *SellerApplication) Store {
+	store := Store{
+		VendorID:    vendorID,
+		Name:        app.StoreName,
+		Description: app.StoreDescription,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if app.StoreDetails != nil {
+		store.Slug = app.StoreDetails.StoreSlug
+		store.Logo = app.StoreDetails.StoreLogo
+		store.Banner = app.StoreDetails.StoreBanner
+		store.PrimaryColor = app.StoreDetails.PrimaryColor
+		store.AccentColor = app.StoreDetails.AccentColor
+		store.About = app.StoreDetails.About
+		store.SocialLinks = app.StoreDetails.SocialLinks
+		store.Sections = app.StoreDetails.Sections
+		if store.Description == "" {
+			store.Description = app.StoreDetails.StoreDescription
+		}
+	}
+
+	if app.BusinessDetails != nil {
+		store.Location = app.BusinessDetails.Location
+		store.ShipsFrom = app.BusinessDetails.ShipsFrom
+		store.ShippingPolicy = app.BusinessDetails.ShippingPolicy
+		store.ReturnPolicy = app.BusinessDetails.ReturnPolicy
+		if store.Description == "" {
+			store.Description = app.BusinessDetails.Description
+		}
+	}
+
+	return store
+}
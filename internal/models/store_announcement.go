@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StoreAnnouncement is a short, time-boxed notice a vendor shows on their
+// storefront (e.g. "Orders ship after Jan 5"). Live announcements are also
+// included in order confirmation emails for that vendor's items.
+type StoreAnnouncement struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	VendorID primitive.ObjectID `bson:"vendorID" json:"vendorId"`
+	Message  string             `bson:"message" json:"message"`
+
+	// Scheduling. A nil EndsAt keeps the announcement up until it is
+	// deactivated or deleted.
+	Active   bool       `bson:"active" json:"active"`
+	StartsAt time.Time  `bson:"startsAt" json:"startsAt"`
+	EndsAt   *time.Time `bson:"endsAt,omitempty" json:"endsAt,omitempty"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// IsLive reports whether the announcement should be shown at the given time.
+func (a StoreAnnouncement) IsLive(at time.Time) bool {
+	if !a.Active || at.Before(a.StartsAt) {
+		return false
+	}
+	return a.EndsAt == nil || at.Before(*a.EndsAt)
+}
+
+// StoreAnnouncementInput is the body for creating or updating an
+// announcement. Omitted fields are left unchanged on update.
+type StoreAnnouncementInput struct {
+	Message  *string    `json:"message" validate:"omitempty,min=3,max=280"`
+	Active   *bool      `json:"active"`
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt"`
+}
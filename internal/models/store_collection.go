@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StoreCollection is a named, vendor-curated list of products such as
+// "Summer Sale" or "Best Sellers". ProductIDs are kept in display order and
+// Slug is unique per vendor.
+type StoreCollection struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	VendorID    primitive.ObjectID   `bson:"vendorID" json:"vendorId"`
+	Name        string               `bson:"name" json:"name"`
+	Slug        string               `bson:"slug" json:"slug"`
+	Description string               `bson:"description,omitempty" json:"description,omitempty"`
+	ProductIDs  []primitive.ObjectID `bson:"productIDs" json:"productIds"`
+	Position    int                  `bson:"position" json:"position"`
+	Visible     bool                 `bson:"visible" json:"visible"`
+
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// StoreCollectionInput is the body for creating or updating a collection.
+// Omitted fields are left unchanged on update.
+type StoreCollectionInput struct {
+	Name        *string               `json:"name" validate:"omitempty,min=2,max=60"`
+	Description *string               `json:"description" validate:"omitempty,max=500"`
+	ProductIDs  *[]primitive.ObjectID `json:"productIds" validate:"omitempty,max=100"`
+	Position    *int                  `json:"position" validate:"omitempty,gte=0,lte=100"`
+	Visible     *bool                 `json:"visible"`
+}
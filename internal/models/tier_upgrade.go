@@ -29,10 +29,42 @@ type TierUpgradeRequest struct {
 
 	Status      TierUpgradeStatus `bson:"status" json:"status"`
 	RiskScore   int               `bson:"riskScore,omitempty" json:"riskScore,omitempty"`
+	RiskFlags   []string          `bson:"riskFlags,omitempty" json:"riskFlags,omitempty"`
 	ReviewNotes string            `bson:"reviewNotes,omitempty" json:"reviewNotes,omitempty"`
 	AdminNotes  string            `bson:"adminNotes,omitempty" json:"adminNotes,omitempty"`
 
-	CreatedAt  time.Time  `bson:"createdAt" json:"createdAt"`
-	UpdatedAt  time.Time  `bson:"updatedAt" json:"updatedAt"`
-	ReviewedAt *time.Time `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time           `bson:"updatedAt" json:"updatedAt"`
+	ReviewedAt *time.Time          `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	ReviewedBy *primitive.ObjectID `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+}
+
+// TierDocumentRequirements lists the document types a vendor must attach when
+// applying for a given tier. Business applications only come from vendors
+// already verified, so they ask for the business papers and a current ID
+// rather than repeating the selfie and proof of address.
+var TierDocumentRequirements = map[string][]string{
+	"verified": {"government_id", "selfie", "address_proof"},
+	"business": {"government_id", "business_registration", "tax_document"},
+}
+
+// TierLimits are the account limits applied to a VendorAccount for a tier.
+type TierLimits struct {
+	MaxProducts     int     `json:"maxProducts"`
+	MaxMonthlySales float64 `json:"maxMonthlySales"`
+	TransactionFee  float64 `json:"transactionFee"`
+	PayoutHoldDays  int     `json:"payoutHoldDays"`
+}
+
+// VendorAdjustmentReasons are the reason codes an admin must give when
+// changing a vendor's tier, limits or status by hand.
+var VendorAdjustmentReasons = []string{
+	"tier_review",
+	"limit_increase",
+	"limit_decrease",
+	"fee_agreement",
+	"policy_violation",
+	"fraud_risk",
+	"reinstatement",
+	"other",
 }
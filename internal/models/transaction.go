@@ -31,13 +31,15 @@ type Transaction struct {
 	OrderID   *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"` // Link to order if applicable
 	Type      TransactionType     `bson:"type" json:"type"`
 	Status    TransactionStatus   `bson:"status" json:"status"`
-	Amount    float64             `bson:"amount" json:"amount"` // Net amount (could be negative for payouts)
-	Fee       float64             `bson:"fee" json:"fee"`       // Platform fee taken
+	Amount    Money               `bson:"amount" json:"amount"`                           // Net amount (could be negative for payouts)
+	Fee       Money               `bson:"fee" json:"fee"`                                 // Platform fee taken
+	PromoCost Money               `bson:"promoCost,omitempty" json:"promoCost,omitempty"` // Vendor-funded discounts on a sale, already out of Amount
 	Currency  string              `bson:"currency" json:"currency"`
 	Reference string              `bson:"reference" json:"reference"` // External reference or description
 
 	// Hold Logic
-	HoldUntil *time.Time `bson:"holdUntil,omitempty" json:"holdUntil,omitempty"` // When pending becomes available
+	HoldUntil   *time.Time `bson:"holdUntil,omitempty" json:"holdUntil,omitempty"`     // When pending becomes available
+	DisputeHold bool       `bson:"disputeHold,omitempty" json:"disputeHold,omitempty"` // Kept pending past HoldUntil while the order is disputed
 
 	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
@@ -46,7 +48,7 @@ type Transaction struct {
 type PayoutRequest struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	VendorID       primitive.ObjectID `bson:"vendorId" json:"vendorId"`
-	Amount         float64            `bson:"amount" json:"amount"`
+	Amount         Money              `bson:"amount" json:"amount"`
 	Status         string             `bson:"status" json:"status"` // "pending", "approved", "rejected", "processed"
 	Method         string             `bson:"method" json:"method"` // "bank_transfer", etc.
 	AccountDetails map[string]string  `bson:"accountDetails" json:"accountDetails"`
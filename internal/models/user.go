@@ -10,17 +10,17 @@ type RegisterInput struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 	Name     string `json:"name" binding:"required"`
-	Phone    string `json:"phone"`
+	Phone    string `json:"phone" validate:"omitempty,phone"`
 	Address  string `json:"address"`
 }
 
 type UpdateProfileInput struct {
 	Name           string `json:"name"`
-	Phone          string `json:"phone"`
+	Phone          string `json:"phone" validate:"omitempty,phone"`
 	Address        string `json:"address"`
 	Location       string `json:"location"`
 	Bio            string `json:"bio"`
-	ProfilePicture string `json:"profilePicture"`
+	ProfilePicture string `json:"profilePicture" validate:"omitempty,weburl"`
 }
 
 type ChangePasswordInput struct {
@@ -34,6 +34,8 @@ type User struct {
 	Name             string             `json:"name" bson:"name"`
 	Address          string             `json:"address" bson:"address"`
 	Phone            string             `json:"phone" bson:"phone"`
+	PhoneVerified    bool               `json:"phoneVerified" bson:"phoneVerified"`
+	PhoneVerifiedAt  *time.Time         `json:"phoneVerifiedAt,omitempty" bson:"phoneVerifiedAt,omitempty"`
 	Password         string             `json:"-" bson:"password" validate:"required,min=6"`
 	Role             string             `json:"role" bson:"role"`
 	CreatedAt        time.Time          `json:"createdAt" bson:"createdAt"`
@@ -43,18 +45,64 @@ type User struct {
 	ResetTokenExpiry time.Time          `json:"-" bson:"resetTokenExpiry,omitempty"`
 	PasswordResetAt  time.Time          `json:"-" bson:"passwordResetAt,omitempty"`
 
+	// Pending phone verification; the code itself is stored hashed
+	PhoneOTPPhone    string    `json:"-" bson:"phoneOtpPhone,omitempty"`
+	PhoneOTPHash     string    `json:"-" bson:"phoneOtpHash,omitempty"`
+	PhoneOTPExpiry   time.Time `json:"-" bson:"phoneOtpExpiry,omitempty"`
+	PhoneOTPSentAt   time.Time `json:"-" bson:"phoneOtpSentAt,omitempty"`
+	PhoneOTPAttempts int       `json:"-" bson:"phoneOtpAttempts,omitempty"`
+
 	RefreshToken        string    `json:"-" bson:"refreshToken,omitempty"`
 	RefreshTokenExpiry  time.Time `json:"-" bson:"refreshTokenExpiry,omitempty"`
 	OnboardingCompleted bool      `json:"onboardingCompleted" bson:"onboardingCompleted"`
 
-	Preferences *UserPreferences `json:"preferences" bson:"preferences"`
-	Interests   *UserInterests   `json:"interests" bson:"interests"`
-	Profile     *UserProfile     `json:"profile" bson:"profile"`
+	// Account moderation. An empty status is treated as active.
+	AccountStatus  string     `json:"accountStatus,omitempty" bson:"accountStatus,omitempty"` // "active", "suspended", "banned"
+	SuspendedUntil *time.Time `json:"suspendedUntil,omitempty" bson:"suspendedUntil,omitempty"`
+	StatusReason   string     `json:"statusReason,omitempty" bson:"statusReason,omitempty"`
+
+	Preferences             *UserPreferences         `json:"preferences" bson:"preferences"`
+	Interests               *UserInterests           `json:"interests" bson:"interests"`
+	Profile                 *UserProfile             `json:"profile" bson:"profile"`
+	NotificationPreferences *NotificationPreferences `json:"notificationPreferences,omitempty" bson:"notificationPreferences,omitempty"`
+	DigestSentAt            map[string]time.Time     `json:"-" bson:"digestSentAt,omitempty"` // Digest audience (vendor, buyer) -> last sent
 
 	VendorStatus      string             `json:"vendorStatus" bson:"vendorStatus"` // "", "pending", "approved", "rejected"
 	SellerApplication *SellerApplication `json:"sellerApplication" bson:"sellerApplication"`
 	VendorAccount     *VendorAccount     `json:"vendorAccount" bson:"vendorAccount"`
 	FeaturedProducts  []Product          `json:"featuredProducts,omitempty" bson:"featuredProducts,omitempty"`
+
+	// Soft delete; see soft_delete.go
+	DeletedAt *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+}
+
+const (
+	AccountStatusActive    = "active"
+	AccountStatusSuspended = "suspended"
+	AccountStatusBanned    = "banned"
+
+	// Not stored: reported for soft-deleted accounts
+	AccountStatusDeleted = "deleted"
+)
+
+// UserRoles are the roles an admin may assign.
+var UserRoles = []string{"buyer", "vendor", "admin"}
+
+// EffectiveAccountStatus resolves a stored account status at the given time:
+// a suspension whose end date has passed counts as active.
+func EffectiveAccountStatus(status string, suspendedUntil *time.Time, now time.Time) string {
+	switch status {
+	case AccountStatusBanned:
+		return AccountStatusBanned
+	case AccountStatusSuspended:
+		if suspendedUntil != nil && !now.Before(*suspendedUntil) {
+			return AccountStatusActive
+		}
+		return AccountStatusSuspended
+	default:
+		return AccountStatusActive
+	}
 }
 
 type UserPreferences struct {
@@ -151,17 +199,23 @@ type BusinessDetails struct {
 	Description    string `json:"description" bson:"description" validate:"required,min=50,max=1000"`
 	Location       string `json:"location" bson:"location" validate:"required"`
 	ShipsFrom      string `json:"shipsFrom,omitempty" bson:"shipsFrom,omitempty"`
-	Url            string `json:"url,omitempty" bson:"url,omitempty" validate:"omitempty,url"`
+	Url            string `json:"url,omitempty" bson:"url,omitempty" validate:"omitempty,weburl"`
 	ShippingPolicy string `json:"shippingPolicy,omitempty" bson:"shippingPolicy,omitempty"`
 	ReturnPolicy   string `json:"returnPolicy,omitempty" bson:"returnPolicy,omitempty"`
 }
 type StoreDetails struct {
-	StoreName        string `json:"storeName" bson:"storeName"`
-	StoreDescription string `json:"storeDescription" bson:"storeDescription"`
-	StoreLogo        string `json:"storeLogo,omitempty" bson:"storeLogo,omitempty"`
-	StoreBanner      string `json:"storeBanner,omitempty" bson:"storeBanner,omitempty"`
-	PrimaryColor     string `json:"primaryColor,omitempty" bson:"primaryColor,omitempty"`
-	AccentColor      string `json:"accentColor,omitempty" bson:"accentColor,omitempty"`
+	StoreName        string `json:"storeName" bson:"storeName" validate:"max=50"`
+	StoreSlug        string `json:"storeSlug,omitempty" bson:"storeSlug,omitempty" validate:"omitempty,slug"`
+	StoreDescription string `json:"storeDescription" bson:"storeDescription" validate:"max=500"`
+	StoreLogo        string `json:"storeLogo,omitempty" bson:"storeLogo,omitempty" validate:"omitempty,weburl"`
+	StoreBanner      string `json:"storeBanner,omitempty" bson:"storeBanner,omitempty" validate:"omitempty,weburl"`
+	PrimaryColor     string `json:"primaryColor,omitempty" bson:"primaryColor,omitempty" validate:"omitempty,color"`
+	AccentColor      string `json:"accentColor,omitempty" bson:"accentColor,omitempty" validate:"omitempty,color"`
+
+	// Storefront customization
+	About       string            `json:"about,omitempty" bson:"about,omitempty"`
+	SocialLinks []SocialMediaLink `json:"socialLinks,omitempty" bson:"socialLinks,omitempty" validate:"dive"`
+	Sections    []StoreSection    `json:"sections,omitempty" bson:"sections,omitempty"`
 }
 
 type VendorApplication struct {
@@ -212,9 +266,10 @@ type VendorAccount struct {
 	DisputeCount    int `json:"disputeCount" bson:"disputeCount"`
 
 	// Financials
-	AvailableBalance float64 `json:"availableBalance" bson:"availableBalance"`
-	PendingBalance   float64 `json:"pendingBalance" bson:"pendingBalance"`
-	LifeTimeEarnings float64 `json:"lifeTimeEarnings" bson:"lifeTimeEarnings"`
+	AvailableBalance  Money `json:"availableBalance" bson:"availableBalance"`
+	PendingBalance    Money `json:"pendingBalance" bson:"pendingBalance"`
+	LifeTimeEarnings  Money `json:"lifeTimeEarnings" bson:"lifeTimeEarnings"`
+	LifeTimePromoCost Money `json:"lifeTimePromoCost" bson:"lifeTimePromoCost"` // Given away in the vendor's own sales and coupons
 
 	// Status
 	Status              string     `json:"status" bson:"status"` // "active", "suspended", "banned"
@@ -242,7 +297,7 @@ type UserOnboardingDraft struct {
 type SocialMediaLink struct {
 	Platform string `json:"platform" bson:"platform"`
 	Handle   string `json:"handle" bson:"handle"`
-	URL      string `json:"url" bson:"url"`
+	URL      string `json:"url" bson:"url" validate:"omitempty,weburl"`
 	Verified bool   `json:"verified" bson:"verified"`
 }
 
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookAllEvents subscribes a webhook to every event type.
+const WebhookAllEvents = "*"
+
+// Webhook delivery outcomes
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription sends the platform events an integrator picked to
+// their endpoint, signed with a secret only they and the platform know.
+type WebhookSubscription struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	URL         string             `json:"url" bson:"url"`
+	Events      []string           `json:"events" bson:"events"` // Event types, or "*" for all
+	Secret      string             `json:"-" bson:"secret"`      // Shown once, when created or rotated
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	Active      bool               `json:"active" bson:"active"`
+	CreatedBy   primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Wants reports whether the subscription is for events of eventType.
+func (s WebhookSubscription) Wants(eventType string) bool {
+	for _, t := range s.Events {
+		if t == eventType || t == WebhookAllEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscriptionInput creates or replaces a subscription. Active
+// defaults to true.
+type WebhookSubscriptionInput struct {
+	URL         string   `json:"url" validate:"required,http_url,max=2048"`
+	Events      []string `json:"events" validate:"required,min=1,max=50,dive,required"`
+	Description string   `json:"description" validate:"max=500"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookDelivery is the log of sending one event to one subscription. The
+// payload is kept so the delivery can be replayed byte for byte.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	SubscriptionID primitive.ObjectID  `json:"subscriptionId" bson:"subscriptionId"`
+	EventID        string              `json:"eventId" bson:"eventId"`
+	EventType      string              `json:"eventType" bson:"eventType"`
+	Payload        json.RawMessage     `json:"payload" bson:"payload"`
+	Status         string              `json:"status" bson:"status"`
+	Attempts       int                 `json:"attempts" bson:"attempts"`
+	ResponseStatus int                 `json:"responseStatus,omitempty" bson:"responseStatus,omitempty"` // The endpoint's last answer
+	Error          string              `json:"error,omitempty" bson:"error,omitempty"`
+	ReplayOf       *primitive.ObjectID `json:"replayOf,omitempty" bson:"replayOf,omitempty"`
+	CreatedAt      time.Time           `json:"createdAt" bson:"createdAt"`
+	DeliveredAt    *time.Time          `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+}
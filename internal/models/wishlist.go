@@ -11,6 +11,9 @@ type Wishlist struct {
 	UserID     primitive.ObjectID   `json:"userId" bson:"userId"`
 	ProductIDs []primitive.ObjectID `json:"productIds" bson:"productIds"`
 	CreatedAt  time.Time            `json:"createdAt" bson:"createdAt"`
+
+	// Prices at the last weekly digest, keyed by product ID, for spotting drops
+	DigestPrices map[string]Money `json:"-" bson:"digestPrices,omitempty"`
 }
 
 type PopulatedWishlist struct {
@@ -0,0 +1,486 @@
+// Package openapi generates the API's OpenAPI 3 document from the source:
+// routes from SetupRoutes, summaries from handler doc comments, and request
+// bodies, query parameters and status codes from what each handler reads
+// and writes. Run it with go generate ./internal/docs after changing routes
+// or handlers.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// scannedPackages are read for handlers and the types they bind.
+var scannedPackages = []string{"internal/handlers", "internal/models", "utils"}
+
+// Generate builds the OpenAPI document for the module rooted at root.
+func Generate(root string) ([]byte, error) {
+	g := &generator{
+		funcs: map[string]*funcDecl{},
+		schemas: &schemas{
+			types:      map[string]typeDecl{},
+			enums:      map[string][]string{},
+			components: map[string]Schema{},
+		},
+		paths:        map[string]map[string]interface{}{},
+		operationIDs: map[string]bool{},
+	}
+	for _, dir := range scannedPackages {
+		if err := g.load(filepath.Join(root, dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	setup, ok := g.funcs["handlers.SetupRoutes"]
+	if !ok {
+		return nil, fmt.Errorf("SetupRoutes not found in %s", filepath.Join(root, "internal/handlers"))
+	}
+	g.walkRoutes(setup.decl.Body)
+
+	g.schemas.components["Response"] = Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"success": Schema{"type": "boolean"},
+			"message": Schema{"type": "string"},
+			"data":    Schema{"description": "Varies by endpoint"},
+		},
+	}
+	g.schemas.components["Error"] = Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"success": Schema{"type": "boolean", "example": false},
+			"error":   Schema{"type": "string"},
+			"code":    Schema{"type": "string", "description": "Stable error code, e.g. CART_ITEM_OUT_OF_STOCK; see utils/error_codes.go", "example": "VALIDATION_FAILED"},
+			"fields": Schema{
+				"type":        "array",
+				"description": "The fields that failed validation",
+				"items": Schema{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"field":   Schema{"type": "string", "example": "shipTo.postalCode"},
+						"rule":    Schema{"type": "string", "example": "required"},
+						"param":   Schema{"type": "string"},
+						"message": Schema{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Vendora API",
+			"version":     "1.0.0",
+			"description": "Generated from the server source by go generate ./internal/docs. Responses are wrapped in the Response envelope, with the payload under data.",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": "/"}},
+		"paths":   g.paths,
+		"components": map[string]interface{}{
+			"schemas": g.schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+type funcDecl struct {
+	decl *ast.FuncDecl
+	file *ast.File
+}
+
+type generator struct {
+	funcs        map[string]*funcDecl // handlers.<Func> or handlers.<Type>.<Method>
+	schemas      *schemas
+	paths        map[string]map[string]interface{}
+	operationIDs map[string]bool
+}
+
+func (g *generator) load(dir string) error {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		pkg := file.Name.Name
+		for _, d := range file.Decls {
+			switch decl := d.(type) {
+			case *ast.FuncDecl:
+				key := pkg + "." + decl.Name.Name
+				if decl.Recv != nil && len(decl.Recv.List) > 0 {
+					key = pkg + "." + receiverType(decl.Recv.List[0].Type) + "." + decl.Name.Name
+				}
+				g.funcs[key] = &funcDecl{decl: decl, file: file}
+			case *ast.GenDecl:
+				g.loadGenDecl(pkg, file, decl)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) loadGenDecl(pkg string, file *ast.File, decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			g.schemas.types[pkg+"."+s.Name.Name] = typeDecl{pkg: pkg, name: s.Name.Name, expr: s.Type, file: file}
+		case *ast.ValueSpec:
+			// String constants of a named type make its enum
+			typ, ok := s.Type.(*ast.Ident)
+			if decl.Tok != token.CONST || !ok {
+				continue
+			}
+			for _, value := range s.Values {
+				if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					v, _ := strconv.Unquote(lit.Value)
+					g.schemas.enums[pkg+"."+typ.Name] = append(g.schemas.enums[pkg+"."+typ.Name], v)
+				}
+			}
+		}
+	}
+}
+
+func receiverType(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// group is a gin router group as SetupRoutes builds it.
+type group struct {
+	prefix string
+	auth   bool
+	roles  []string
+}
+
+// walkRoutes follows SetupRoutes in order, tracking groups, their
+// middleware and the handler variables, and documents every route.
+func (g *generator) walkRoutes(body *ast.BlockStmt) {
+	groups := map[string]group{"router": {}}
+	handlerTypes := map[string]string{} // variable -> handler type
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			if len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+				return true
+			}
+			lhs, ok := stmt.Lhs[0].(*ast.Ident)
+			call, isCall := stmt.Rhs[0].(*ast.CallExpr)
+			if !ok || !isCall {
+				return true
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Group" {
+				parent := groups[identName(sel.X)]
+				child := group{prefix: parent.prefix + stringArg(call, 0), auth: parent.auth, roles: parent.roles}
+				child.apply(call.Args[1:])
+				groups[lhs.Name] = child
+			} else if typ := g.constructed(call); typ != "" {
+				handlerTypes[lhs.Name] = typ
+			}
+		case *ast.CallExpr:
+			sel, ok := stmt.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			grp, isGroup := groups[identName(sel.X)]
+			if !isGroup {
+				return true
+			}
+			switch sel.Sel.Name {
+			case "Use":
+				grp.apply(stmt.Args)
+				groups[identName(sel.X)] = grp
+			case "GET", "POST", "PUT", "PATCH", "DELETE":
+				route := grp
+				route.roles = append([]string(nil), grp.roles...)
+				route.apply(stmt.Args[1 : len(stmt.Args)-1])
+				g.addRoute(sel.Sel.Name, grp.prefix+stringArg(stmt, 0), route, g.resolveHandler(stmt.Args[len(stmt.Args)-1], handlerTypes))
+			}
+		}
+		return true
+	})
+}
+
+// apply notes the auth and role middleware in a group's or route's
+// handlers.
+func (grp *group) apply(args []ast.Expr) {
+	for _, arg := range args {
+		call, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		switch sel.Sel.Name {
+		case "AuthMiddleware":
+			grp.auth = true
+		case "RoleMiddleware":
+			// A route's roles narrow its group's
+			grp.roles = nil
+			for i := range call.Args {
+				grp.roles = append(grp.roles, stringArg(call, i))
+			}
+		}
+	}
+}
+
+// constructed returns the handler type a NewXHandler call returns.
+func (g *generator) constructed(call *ast.CallExpr) string {
+	fn, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	f, ok := g.funcs["handlers."+fn.Name]
+	if !ok || f.decl.Type.Results == nil || len(f.decl.Type.Results.List) == 0 {
+		return ""
+	}
+	return receiverType(f.decl.Type.Results.List[0].Type)
+}
+
+func (g *generator) resolveHandler(expr ast.Expr, handlerTypes map[string]string) *funcDecl {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	var typ string
+	switch x := sel.X.(type) {
+	case *ast.Ident:
+		typ = handlerTypes[x.Name]
+	case *ast.CallExpr:
+		typ = g.constructed(x)
+	}
+	return g.funcs["handlers."+typ+"."+sel.Sel.Name]
+}
+
+func (g *generator) addRoute(method, path string, grp group, handler *funcDecl) {
+	var params []interface{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, map[string]interface{}{
+				"name": name, "in": "path", "required": true, "schema": Schema{"type": "string"},
+			})
+		}
+	}
+	path = strings.Join(segments, "/")
+
+	op := map[string]interface{}{"tags": []string{tagFor(path)}}
+	usage := &handlerUsage{queries: map[string]bool{}, statuses: map[int]bool{}, form: map[string]string{}}
+	name := strings.ToLower(method) + camel(path)
+	if handler != nil {
+		name = handler.decl.Name.Name
+		summary, description := docText(handler.decl)
+		op["summary"] = summary
+		if description != "" {
+			op["description"] = description
+		}
+		g.inspect(handler, usage, map[*ast.FuncDecl]bool{})
+	}
+	if !handlerUsageHasSuccess(usage) {
+		usage.statuses[http.StatusOK] = true
+	}
+
+	op["operationId"] = g.operationID(name)
+	if grp.auth {
+		op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		if len(grp.roles) > 0 {
+			desc, _ := op["description"].(string)
+			op["description"] = strings.TrimSpace("Requires role: " + strings.Join(grp.roles, " or ") + ".\n\n" + desc)
+		}
+	}
+
+	queries := make([]string, 0, len(usage.queries))
+	for q := range usage.queries {
+		queries = append(queries, q)
+	}
+	sort.Strings(queries)
+	for _, q := range queries {
+		params = append(params, map[string]interface{}{"name": q, "in": "query", "schema": Schema{"type": "string"}})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	switch {
+	case usage.body != nil:
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": usage.body}},
+		}
+	case usage.upload:
+		properties := map[string]interface{}{}
+		for field, kind := range usage.form {
+			if kind == "file" {
+				properties[field] = Schema{"type": "string", "format": "binary"}
+			} else {
+				properties[field] = Schema{"type": "string"}
+			}
+		}
+		op["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{"multipart/form-data": map[string]interface{}{
+				"schema": Schema{"type": "object", "properties": properties},
+			}},
+		}
+	}
+
+	responses := map[string]interface{}{}
+	for status := range usage.statuses {
+		schema := g.schemas.ref("Response")
+		if status >= http.StatusBadRequest {
+			schema = g.schemas.ref("Error")
+		}
+		responses[strconv.Itoa(status)] = map[string]interface{}{
+			"description": http.StatusText(status),
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+		}
+	}
+	op["responses"] = responses
+
+	if g.paths[path] == nil {
+		g.paths[path] = map[string]interface{}{}
+	}
+	g.paths[path][strings.ToLower(method)] = op
+}
+
+func handlerUsageHasSuccess(u *handlerUsage) bool {
+	for status := range u.statuses {
+		if status < http.StatusBadRequest {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) operationID(name string) string {
+	id := strings.ToLower(name[:1]) + name[1:]
+	for i := 2; g.operationIDs[id]; i++ {
+		id = strings.ToLower(name[:1]) + name[1:] + strconv.Itoa(i)
+	}
+	g.operationIDs[id] = true
+	return id
+}
+
+// tagFor groups operations by the first part of their path, and the second
+// for the vendor and admin areas.
+func tagFor(path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "api" || segment == "v1" || segment == "public" || strings.HasPrefix(segment, "{") {
+			continue
+		}
+		parts = append(parts, segment)
+	}
+	switch {
+	case len(parts) == 0:
+		return "service"
+	case (parts[0] == "vendor" || parts[0] == "admin") && len(parts) > 1:
+		return parts[0] + " " + parts[1]
+	}
+	return parts[0]
+}
+
+// docText splits a handler's doc comment into a summary and the rest,
+// turning "CreateProduct creates a product" into "Creates a product".
+func docText(decl *ast.FuncDecl) (string, string) {
+	text := ""
+	if decl.Doc != nil {
+		text = strings.TrimSpace(decl.Doc.Text())
+	}
+	if text == "" {
+		return humanize(decl.Name.Name), ""
+	}
+	text = strings.TrimPrefix(text, decl.Name.Name+" ")
+	runes := []rune(text)
+	runes[0] = unicode.ToUpper(runes[0])
+	text = string(runes)
+
+	summary, rest := text, ""
+	if i := strings.Index(text, ". "); i >= 0 {
+		summary, rest = text[:i+1], strings.TrimSpace(text[i+2:])
+	}
+	summary = strings.Join(strings.Fields(summary), " ")
+	return strings.TrimSuffix(summary, "."), rest
+}
+
+// humanize turns "GetVendorProducts" into "Get vendor products".
+func humanize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteRune(' ')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// camel turns "/api/v1/{id}/sub-path" into "ApiV1IdSubPath".
+func camel(path string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func identName(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func stringArg(call *ast.CallExpr, i int) string {
+	if i >= len(call.Args) {
+		return ""
+	}
+	if lit, ok := call.Args[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		s, _ := strconv.Unquote(lit.Value)
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package openapi
+
+import (
+	"go/ast"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is an OpenAPI schema object.
+type Schema map[string]interface{}
+
+// typeDecl is a named type declared in one of the scanned packages.
+type typeDecl struct {
+	pkg  string
+	name string
+	expr ast.Expr
+	file *ast.File
+}
+
+// schemas turns Go types into OpenAPI schemas, collecting named structs
+// under components.
+type schemas struct {
+	types      map[string]typeDecl // <pkg>.<Name>
+	enums      map[string][]string // <pkg>.<Name> -> string constants of the type
+	components map[string]Schema
+}
+
+func (s *schemas) ref(name string) Schema {
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+// componentName is the name a type is listed under; models keep their own
+// names and anything else is prefixed with its package.
+func componentName(pkg, name string) string {
+	if pkg == "models" {
+		return name
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+// forExpr builds the schema for a type expression written in pkg.
+func (s *schemas) forExpr(pkg string, file *ast.File, expr ast.Expr) Schema {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return s.forExpr(pkg, file, t.X)
+	case *ast.ParenExpr:
+		return s.forExpr(pkg, file, t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": s.forExpr(pkg, file, t.Elt)}
+	case *ast.MapType:
+		return Schema{"type": "object", "additionalProperties": s.forExpr(pkg, file, t.Value)}
+	case *ast.InterfaceType:
+		return Schema{}
+	case *ast.StructType:
+		return s.forStruct(pkg, file, t)
+	case *ast.Ident:
+		if schema, ok := builtin(t.Name); ok {
+			return schema
+		}
+		return s.named(pkg, t.Name)
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return Schema{}
+		}
+		if schema, ok := external(x.Name, t.Sel.Name); ok {
+			return schema
+		}
+		return s.named(importedPackage(file, x.Name), t.Sel.Name)
+	}
+	return Schema{}
+}
+
+// marshalled describes scanned types whose MarshalJSON writes them as
+// something other than their Go type.
+func marshalled(pkg, name string) (Schema, bool) {
+	switch pkg + "." + name {
+	case "models.Money":
+		return Schema{"type": "number", "format": "double"}, true
+	}
+	return nil, false
+}
+
+// named builds the schema for a type declared in a scanned package. Structs
+// become components; other types are described inline.
+func (s *schemas) named(pkg, name string) Schema {
+	if schema, ok := marshalled(pkg, name); ok {
+		return schema
+	}
+	decl, ok := s.types[pkg+"."+name]
+	if !ok {
+		return Schema{}
+	}
+	if _, isStruct := decl.expr.(*ast.StructType); !isStruct {
+		schema := s.forExpr(decl.pkg, decl.file, decl.expr)
+		if values := s.enums[pkg+"."+name]; len(values) > 0 {
+			schema["enum"] = values
+		}
+		return schema
+	}
+
+	component := componentName(pkg, name)
+	if _, ok := s.components[component]; !ok {
+		// Reserve the name first so self-referencing types terminate
+		s.components[component] = Schema{}
+		s.components[component] = s.forExpr(decl.pkg, decl.file, decl.expr)
+	}
+	return s.ref(component)
+}
+
+func (s *schemas) forStruct(pkg string, file *ast.File, st *ast.StructType) Schema {
+	properties := map[string]interface{}{}
+	var required []string
+	s.addFields(pkg, file, st, properties, &required)
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *schemas) addFields(pkg string, file *ast.File, st *ast.StructType, properties map[string]interface{}, required *[]string) {
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			if unquoted, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(unquoted)
+			}
+		}
+		jsonName, opts, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" && opts == "" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			// Embedded: its fields are promoted unless it is given a name
+			if jsonName == "" {
+				if embedded, ok := s.structOf(pkg, file, field.Type); ok {
+					s.addFields(embedded.pkg, embedded.file, embedded.expr.(*ast.StructType), properties, required)
+				}
+				continue
+			}
+		}
+
+		schema := s.forExpr(pkg, file, field.Type)
+		if doc := fieldDoc(field); doc != "" {
+			if _, isRef := schema["$ref"]; isRef {
+				// Siblings of $ref are ignored in OpenAPI 3.0
+				schema = Schema{"allOf": []interface{}{schema}, "description": doc}
+			} else {
+				schema["description"] = doc
+			}
+		}
+
+		names := []string{jsonName}
+		if jsonName == "" {
+			names = nil
+			for _, n := range field.Names {
+				names = append(names, n.Name)
+			}
+		}
+		for i, name := range names {
+			if !ast.IsExported(fieldName(field, i)) {
+				continue
+			}
+			properties[name] = schema
+			if isRequired(tag) {
+				*required = append(*required, name)
+			}
+		}
+	}
+}
+
+// structOf finds the struct declaration behind an embedded field's type.
+func (s *schemas) structOf(pkg string, file *ast.File, expr ast.Expr) (typeDecl, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	var key string
+	switch t := expr.(type) {
+	case *ast.Ident:
+		key = pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return typeDecl{}, false
+		}
+		key = importedPackage(file, x.Name) + "." + t.Sel.Name
+	default:
+		return typeDecl{}, false
+	}
+	decl, ok := s.types[key]
+	if !ok {
+		return typeDecl{}, false
+	}
+	_, isStruct := decl.expr.(*ast.StructType)
+	return decl, isStruct
+}
+
+func fieldName(field *ast.Field, i int) string {
+	if len(field.Names) == 0 {
+		switch t := field.Type.(type) {
+		case *ast.Ident:
+			return t.Name
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.StarExpr:
+			return fieldName(&ast.Field{Type: t.X}, 0)
+		}
+		return ""
+	}
+	return field.Names[i].Name
+}
+
+func fieldDoc(field *ast.Field) string {
+	if field.Comment != nil {
+		return strings.TrimSpace(field.Comment.Text())
+	}
+	if field.Doc != nil {
+		return strings.TrimSpace(field.Doc.Text())
+	}
+	return ""
+}
+
+func isRequired(tag reflect.StructTag) bool {
+	for _, key := range []string{"validate", "binding"} {
+		for _, rule := range strings.Split(tag.Get(key), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func builtin(name string) (Schema, bool) {
+	switch name {
+	case "string":
+		return Schema{"type": "string"}, true
+	case "bool":
+		return Schema{"type": "boolean"}, true
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "rune", "byte":
+		return Schema{"type": "integer"}, true
+	case "int64", "uint64":
+		return Schema{"type": "integer", "format": "int64"}, true
+	case "float32":
+		return Schema{"type": "number", "format": "float"}, true
+	case "float64":
+		return Schema{"type": "number", "format": "double"}, true
+	case "any", "error":
+		return Schema{}, true
+	}
+	return nil, false
+}
+
+// external describes the types from other modules that appear in models.
+func external(pkg, name string) (Schema, bool) {
+	switch pkg + "." + name {
+	case "time.Time", "primitive.DateTime":
+		return Schema{"type": "string", "format": "date-time"}, true
+	case "time.Duration":
+		return Schema{"type": "integer", "format": "int64", "description": "Nanoseconds"}, true
+	case "primitive.ObjectID":
+		return Schema{"type": "string", "pattern": "^[0-9a-f]{24}$"}, true
+	case "primitive.M", "bson.M", "gin.H":
+		return Schema{"type": "object", "additionalProperties": true}, true
+	case "primitive.A", "bson.A":
+		return Schema{"type": "array", "items": Schema{}}, true
+	case "json.RawMessage", "bson.Raw":
+		return Schema{}, true
+	case "multipart.FileHeader":
+		return Schema{"type": "string", "format": "binary"}, true
+	}
+	return nil, false
+}
+
+// importedPackage maps a file's name for an import to the scanned package
+// it refers to.
+func importedPackage(file *ast.File, name string) string {
+	if file == nil {
+		return name
+	}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		local := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			local = imp.Name.Name
+		}
+		if local == name {
+			return path[strings.LastIndex(path, "/")+1:]
+		}
+	}
+	return name
+}
@@ -0,0 +1,200 @@
+package openapi
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// handlerUsage is what a handler, and the helpers it hands its gin context
+// to, read from the request and answer with.
+type handlerUsage struct {
+	queries  map[string]bool
+	statuses map[int]bool
+	body     Schema
+	form     map[string]string // Field -> "file" or "field"
+	upload   bool              // Reads a multipart form
+}
+
+// statusCodes are the net/http status constants handlers respond with.
+var statusCodes = map[string]int{
+	"StatusOK":                    200,
+	"StatusCreated":               201,
+	"StatusAccepted":              202,
+	"StatusNoContent":             204,
+	"StatusMovedPermanently":      301,
+	"StatusFound":                 302,
+	"StatusSeeOther":              303,
+	"StatusNotModified":           304,
+	"StatusTemporaryRedirect":     307,
+	"StatusBadRequest":            400,
+	"StatusUnauthorized":          401,
+	"StatusPaymentRequired":       402,
+	"StatusForbidden":             403,
+	"StatusNotFound":              404,
+	"StatusMethodNotAllowed":      405,
+	"StatusConflict":              409,
+	"StatusGone":                  410,
+	"StatusPreconditionFailed":    412,
+	"StatusRequestEntityTooLarge": 413,
+	"StatusUnsupportedMediaType":  415,
+	"StatusUnprocessableEntity":   422,
+	"StatusLocked":                423,
+	"StatusTooManyRequests":       429,
+	"StatusInternalServerError":   500,
+	"StatusNotImplemented":        501,
+	"StatusBadGateway":            502,
+	"StatusServiceUnavailable":    503,
+	"StatusGatewayTimeout":        504,
+}
+
+// inspect records what f does with its gin context, following it into
+// helpers in the handlers package.
+func (g *generator) inspect(f *funcDecl, u *handlerUsage, visited map[*ast.FuncDecl]bool) {
+	if visited[f.decl] || f.decl.Body == nil {
+		return
+	}
+	visited[f.decl] = true
+	ctx := contextParam(f.decl)
+	recv := ""
+	if f.decl.Recv != nil && len(f.decl.Recv.List) > 0 && len(f.decl.Recv.List[0].Names) > 0 {
+		recv = f.decl.Recv.List[0].Names[0].Name
+	}
+
+	ast.Inspect(f.decl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			if identName(node.X) == "http" {
+				if code, ok := statusCodes[node.Sel.Name]; ok {
+					u.statuses[code] = true
+				}
+			}
+		case *ast.CallExpr:
+			sel, isSel := node.Fun.(*ast.SelectorExpr)
+			if isSel && ctx != "" && (identName(sel.X) == ctx || isRequestOf(sel.X, ctx)) {
+				g.contextCall(f, sel.Sel.Name, node, u)
+				return true
+			}
+			if !passes(node, ctx) {
+				return true
+			}
+			// A helper given the gin context
+			switch fn := node.Fun.(type) {
+			case *ast.Ident:
+				if callee, ok := g.funcs["handlers."+fn.Name]; ok {
+					g.inspect(callee, u, visited)
+				}
+			case *ast.SelectorExpr:
+				if recv != "" && identName(fn.X) == recv {
+					typ := receiverType(f.decl.Recv.List[0].Type)
+					if callee, ok := g.funcs["handlers."+typ+"."+fn.Sel.Name]; ok {
+						g.inspect(callee, u, visited)
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (g *generator) contextCall(f *funcDecl, method string, call *ast.CallExpr, u *handlerUsage) {
+	switch method {
+	case "Query", "DefaultQuery", "GetQuery", "QueryArray":
+		if name := stringArg(call, 0); name != "" {
+			u.queries[name] = true
+		}
+	case "FormFile":
+		u.upload = true
+		if name := stringArg(call, 0); name != "" {
+			u.form[name] = "file"
+		}
+	case "PostForm", "DefaultPostForm", "GetPostForm", "PostFormValue":
+		u.upload = true
+		if name := stringArg(call, 0); name != "" && u.form[name] == "" {
+			u.form[name] = "field"
+		}
+	case "MultipartForm", "ParseMultipartForm":
+		u.upload = true
+	case "ShouldBindJSON", "BindJSON", "ShouldBind", "Bind":
+		if u.body != nil || len(call.Args) == 0 {
+			return
+		}
+		unary, ok := call.Args[0].(*ast.UnaryExpr)
+		if !ok || unary.Op != token.AND {
+			return
+		}
+		if typ := declaredType(f.decl.Body, identName(unary.X)); typ != nil {
+			u.body = g.schemas.forExpr("handlers", f.file, typ)
+		}
+	}
+}
+
+// isRequestOf reports whether expr is <ctx>.Request.
+func isRequestOf(expr ast.Expr, ctx string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Request" && identName(sel.X) == ctx
+}
+
+// contextParam is the name f gives its *gin.Context parameter.
+func contextParam(decl *ast.FuncDecl) string {
+	for _, field := range decl.Type.Params.List {
+		star, ok := field.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if sel, ok := star.X.(*ast.SelectorExpr); ok && identName(sel.X) == "gin" && sel.Sel.Name == "Context" && len(field.Names) > 0 {
+			return field.Names[0].Name
+		}
+	}
+	return ""
+}
+
+func passes(call *ast.CallExpr, ctx string) bool {
+	if ctx == "" {
+		return false
+	}
+	for _, arg := range call.Args {
+		if identName(arg) == ctx {
+			return true
+		}
+	}
+	return false
+}
+
+// declaredType finds the type a local variable is declared with, from
+// "var name T", "name := T{}", "name := &T{}" or "name := new(T)".
+func declaredType(body *ast.BlockStmt, name string) ast.Expr {
+	var found ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.ValueSpec:
+			for _, ident := range node.Names {
+				if ident.Name == name && node.Type != nil {
+					found = node.Type
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if identName(lhs) != name || i >= len(node.Rhs) {
+					continue
+				}
+				rhs := node.Rhs[i]
+				if unary, ok := rhs.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+					rhs = unary.X
+				}
+				switch v := rhs.(type) {
+				case *ast.CompositeLit:
+					found = v.Type
+				case *ast.CallExpr:
+					if identName(v.Fun) == "new" && len(v.Args) == 1 {
+						found = v.Args[0]
+					}
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
+)
+
+// cloudinaryAPI is the part of the Cloudinary client the store uses.
+type cloudinaryAPI interface {
+	Upload(ctx context.Context, file interface{}, params uploader.UploadParams) (*uploader.UploadResult, error)
+	Destroy(ctx context.Context, params uploader.DestroyParams) (*uploader.DestroyResult, error)
+}
+
+// CloudinaryStore keeps media on Cloudinary, through one client shared by
+// every handler.
+type CloudinaryStore struct {
+	api cloudinaryAPI
+}
+
+// NewCloudinaryStore connects to the configured Cloudinary account. It
+// returns a nil store and ErrMediaNotConfigured if the account isn't set.
+func NewCloudinaryStore(cfg config.CloudinaryConfig) (MediaStore, error) {
+	if !cfg.Configured() {
+		return nil, ErrMediaNotConfigured
+	}
+
+	cld, err := cloudinary.NewFromParams(cfg.CloudName, cfg.APIKey, cfg.APISecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Cloudinary: %w", err)
+	}
+	return &CloudinaryStore{api: &cld.Upload}, nil
+}
+
+func (s *CloudinaryStore) Upload(ctx context.Context, file io.Reader, folder, publicID string) (url string, err error) {
+	ctx, call := telemetry.External(ctx, "cloudinary", "upload")
+	defer func() { call.End(err) }()
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	overwrite := true
+	result, err := s.api.Upload(ctx, file, uploader.UploadParams{
+		PublicID:     publicID,
+		Folder:       folder,
+		ResourceType: "auto",
+		Overwrite:    &overwrite,
+	})
+	if err != nil {
+		return "", err
+	}
+	if result == nil || result.SecureURL == "" {
+		return "", fmt.Errorf("cloudinary returned no URL for %s/%s", folder, publicID)
+	}
+	return result.SecureURL, nil
+}
+
+func (s *CloudinaryStore) Delete(ctx context.Context, asset MediaAsset) (err error) {
+	ctx, call := telemetry.External(ctx, "cloudinary", "destroy")
+	defer func() { call.End(err) }()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := s.api.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     asset.PublicID,
+		ResourceType: asset.ResourceType,
+	})
+	if err != nil {
+		return err
+	}
+	if result != nil && result.Result != "ok" && result.Result != "not found" {
+		return fmt.Errorf("cloudinary could not delete %s: %s", asset.PublicID, result.Result)
+	}
+	return nil
+}
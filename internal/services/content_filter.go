@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// What the content filter looks for
+const (
+	FilterProfanity   = "profanity"
+	FilterPII         = "pii"          // Email addresses, phone and card numbers
+	FilterContactInfo = "contact_info" // Links, social handles and messaging apps, used to take buyers off the platform
+)
+
+// What happens to text that matches
+const (
+	FilterModeMask   = "mask"   // Matches are blanked out and the text saved
+	FilterModeReject = "reject" // The text is refused
+)
+
+// FilterSurface is somewhere users write text that others read. Its mode
+// can be overridden with CONTENT_FILTER_MODE_<NAME>, e.g.
+// CONTENT_FILTER_MODE_MESSAGE=reject.
+type FilterSurface struct {
+	Name   string
+	Mode   string
+	Checks []string
+}
+
+var allFilterChecks = []string{FilterProfanity, FilterPII, FilterContactInfo}
+
+var (
+	FilterReview         = FilterSurface{Name: "review", Mode: FilterModeMask, Checks: allFilterChecks}
+	FilterReviewResponse = FilterSurface{Name: "review_response", Mode: FilterModeMask, Checks: allFilterChecks}
+	FilterMessage        = FilterSurface{Name: "message", Mode: FilterModeMask, Checks: allFilterChecks}
+	FilterStoreText      = FilterSurface{Name: "store", Mode: FilterModeReject, Checks: allFilterChecks}
+)
+
+// DefaultProfanity is always filtered, in addition to any words configured
+// through CONTENT_FILTER_WORDS.
+var DefaultProfanity = []string{
+	"fuck", "fucking", "fucker", "motherfucker", "shit", "bullshit", "bitch",
+	"bastard", "asshole", "cunt", "dick", "prick", "wanker", "slut", "whore",
+}
+
+// DefaultContactTerms are messaging apps and phrases used to move a
+// conversation off the platform, in addition to CONTENT_FILTER_CONTACT_WORDS.
+var DefaultContactTerms = []string{
+	"whatsapp", "telegram", "signal app", "wechat", "snapchat", "wa.me", "t.me",
+}
+
+var ErrContentRejected = errors.New("text contains content that isn't allowed")
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	digitsPattern = regexp.MustCompile(`\+?\d[\d\s().-]{7,}\d`)
+	linkPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|ng|io|co|me|shop|store)\b(?:/\S*)?`)
+	handlePattern = regexp.MustCompile(`(?:^|\s)@[A-Za-z0-9_.]{3,30}\b`)
+)
+
+// FilterMatch is one thing the filter found.
+type FilterMatch struct {
+	Check string `json:"check" bson:"check"`
+	Text  string `json:"text" bson:"text"`
+}
+
+// FilterResult is what the filter made of some text: the text to store,
+// masked if the surface masks, and everything it matched.
+type FilterResult struct {
+	Text    string
+	Matches []FilterMatch
+}
+
+// Flagged reports whether anything matched.
+func (r FilterResult) Flagged() bool {
+	return len(r.Matches) > 0
+}
+
+// Checks lists the kinds of thing that matched, each once.
+func (r FilterResult) Checks() []string {
+	var checks []string
+	seen := map[string]bool{}
+	for _, m := range r.Matches {
+		if !seen[m.Check] {
+			seen[m.Check] = true
+			checks = append(checks, m.Check)
+		}
+	}
+	return checks
+}
+
+// ContentFilter screens user-written text for profanity, personal
+// information and contact details.
+type ContentFilter struct {
+	profanity *regexp.Regexp
+	contact   *regexp.Regexp
+	modes     map[string]string // Surface name -> mode overrides
+}
+
+// NewContentFilter builds a filter from the default word lists plus
+// CONTENT_FILTER_WORDS and CONTENT_FILTER_CONTACT_WORDS, both
+// comma-separated.
+func NewContentFilter() *ContentFilter {
+	f := &ContentFilter{
+		profanity: wordPattern(append(append([]string{}, DefaultProfanity...), envList("CONTENT_FILTER_WORDS")...)),
+		contact:   wordPattern(append(append([]string{}, DefaultContactTerms...), envList("CONTENT_FILTER_CONTACT_WORDS")...)),
+		modes:     map[string]string{},
+	}
+	for _, s := range []FilterSurface{FilterReview, FilterReviewResponse, FilterMessage, FilterStoreText} {
+		mode := strings.ToLower(os.Getenv("CONTENT_FILTER_MODE_" + strings.ToUpper(s.Name)))
+		if mode == FilterModeMask || mode == FilterModeReject {
+			f.modes[s.Name] = mode
+		}
+	}
+	return f
+}
+
+// ModeFor is how the surface treats matches, after any override.
+func (f *ContentFilter) ModeFor(s FilterSurface) string {
+	if mode, ok := f.modes[s.Name]; ok {
+		return mode
+	}
+	return s.Mode
+}
+
+// Apply screens text for the surface. On a rejecting surface a match
+// returns ErrContentRejected along with the result, so the caller can say
+// what was found; otherwise the result's text has the matches masked.
+func (f *ContentFilter) Apply(s FilterSurface, text string) (FilterResult, error) {
+	result := FilterResult{Text: text}
+	for _, check := range s.Checks {
+		switch check {
+		case FilterPII:
+			// Emails first so their digits aren't taken for a phone number
+			result.mask(FilterPII, emailPattern, "[email removed]")
+			result.maskFunc(FilterPII, digitsPattern, isPhoneOrCard, "[number removed]")
+		case FilterContactInfo:
+			result.mask(FilterContactInfo, linkPattern, "[link removed]")
+			result.mask(FilterContactInfo, handlePattern, "[handle removed]")
+			result.mask(FilterContactInfo, f.contact, "[contact removed]")
+		case FilterProfanity:
+			result.maskFunc(FilterProfanity, f.profanity, nil, "")
+		}
+	}
+	if result.Flagged() && f.ModeFor(s) == FilterModeReject {
+		result.Text = text
+		return result, ErrContentRejected
+	}
+	return result, nil
+}
+
+// FilterText applies the filter to several fields of one piece of content,
+// such as a store's name and description, returning the masked texts and
+// every match across them.
+func (f *ContentFilter) FilterText(s FilterSurface, texts ...*string) (FilterResult, error) {
+	var all FilterResult
+	var rejected bool
+	for _, t := range texts {
+		if t == nil || *t == "" {
+			continue
+		}
+		result, err := f.Apply(s, *t)
+		if err != nil {
+			rejected = true
+		}
+		*t = result.Text
+		all.Matches = append(all.Matches, result.Matches...)
+	}
+	if rejected {
+		return all, ErrContentRejected
+	}
+	return all, nil
+}
+
+func (r *FilterResult) mask(check string, pattern *regexp.Regexp, replacement string) {
+	r.maskFunc(check, pattern, nil, replacement)
+}
+
+// maskFunc replaces every match of pattern that keep accepts. An empty
+// replacement stars out the match after its first letter.
+func (r *FilterResult) maskFunc(check string, pattern *regexp.Regexp, keep func(string) bool, replacement string) {
+	if pattern == nil {
+		return
+	}
+	r.Text = pattern.ReplaceAllStringFunc(r.Text, func(match string) string {
+		if keep != nil && !keep(match) {
+			return match
+		}
+		// Handles are matched with the space before them, which stays
+		trimmed := strings.TrimLeftFunc(match, unicode.IsSpace)
+		lead := match[:len(match)-len(trimmed)]
+		r.Matches = append(r.Matches, FilterMatch{Check: check, Text: trimmed})
+		if replacement != "" {
+			return lead + replacement
+		}
+		return lead + starOut(trimmed)
+	})
+}
+
+// isPhoneOrCard reports whether a run of digits is long enough to be a
+// phone number or a card number, rather than a price or quantity.
+func isPhoneOrCard(s string) bool {
+	digits := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits >= 10 && digits <= 19
+}
+
+func starOut(word string) string {
+	runes := []rune(word)
+	for i := 1; i < len(runes); i++ {
+		runes[i] = '*'
+	}
+	return string(runes)
+}
+
+// wordPattern matches any of words as whole words, case-insensitively.
+func wordPattern(words []string) *regexp.Regexp {
+	var quoted []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		quoted = append(quoted, regexp.QuoteMeta(w))
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+func envList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
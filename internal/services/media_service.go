@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MediaContext is somewhere uploads are used. It decides what may be
+// uploaded there and the Cloudinary folder it goes in. Each uploader gets
+// their own folder under it, unless the context keeps a single file per
+// owner, which a new upload replaces.
+type MediaContext struct {
+	Folder  string
+	MaxSize int64
+	Types   map[string]string // Allowed content type -> file extension
+	Single  bool              // One file per owner, stored as <Folder>/<owner>
+}
+
+var (
+	photoTypes = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/webp": ".webp",
+	}
+	imageTypes = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/webp": ".webp",
+		"image/gif":  ".gif",
+	}
+	documentTypes = map[string]string{
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"application/pdf": ".pdf",
+	}
+	attachmentTypes = map[string]string{
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"image/webp":      ".webp",
+		"image/gif":       ".gif",
+		"application/pdf": ".pdf",
+	}
+	exportTypes = map[string]string{
+		"text/csv": ".csv",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": ".xlsx",
+	}
+)
+
+var (
+	MediaProductImage         = MediaContext{Folder: "vendora/products", MaxSize: 10 << 20, Types: imageTypes}
+	MediaProfilePicture       = MediaContext{Folder: "vendora/users/profiles", MaxSize: 5 << 20, Types: photoTypes, Single: true}
+	MediaStoreLogo            = MediaContext{Folder: "vendora/stores/logos", MaxSize: 5 << 20, Types: photoTypes, Single: true}
+	MediaStoreBanner          = MediaContext{Folder: "vendora/stores/banners", MaxSize: 10 << 20, Types: photoTypes, Single: true}
+	MediaCategoryImage        = MediaContext{Folder: "vendora/categories/images", MaxSize: 10 << 20, Types: photoTypes, Single: true}
+	MediaCategoryIcon         = MediaContext{Folder: "vendora/categories/icons", MaxSize: 2 << 20, Types: photoTypes, Single: true}
+	MediaVerificationDocument = MediaContext{Folder: "vendora/seller-verification", MaxSize: 5 << 20, Types: documentTypes}
+	MediaMessageAttachment    = MediaContext{Folder: "vendora/messages", MaxSize: 10 << 20, Types: attachmentTypes}
+	MediaDisputeEvidence      = MediaContext{Folder: "vendora/disputes", MaxSize: 10 << 20, Types: attachmentTypes}
+	MediaExport               = MediaContext{Folder: "vendora/exports", MaxSize: 100 << 20, Types: exportTypes}
+)
+
+var (
+	ErrMediaTooLarge      = errors.New("file is too large")
+	ErrMediaType          = errors.New("file type is not allowed")
+	ErrMediaNotUploads    = errors.New("attachments must be uploaded first")
+	ErrMediaNotConfigured = errors.New("media storage is not configured")
+	ErrMediaNotStored     = errors.New("url is not a stored media file")
+)
+
+// FolderFor is where owner's uploads in this context are stored.
+func (m MediaContext) FolderFor(owner primitive.ObjectID) string {
+	if m.Single {
+		return m.Folder
+	}
+	return m.Folder + "/" + owner.Hex()
+}
+
+// Allows reports whether a file of contentType and size may be uploaded.
+func (m MediaContext) Allows(contentType string, size int64) error {
+	if size > m.MaxSize {
+		return ErrMediaTooLarge
+	}
+	if _, ok := m.Types[contentType]; !ok {
+		return ErrMediaType
+	}
+	return nil
+}
+
+// Describe lists the allowed types and size, for error messages.
+func (m MediaContext) Describe() string {
+	var exts []string
+	for _, ext := range m.Types {
+		exts = append(exts, strings.ToUpper(strings.TrimPrefix(ext, ".")))
+	}
+	sort.Strings(exts)
+	return fmt.Sprintf("%s up to %dMB", strings.Join(exts, ", "), m.MaxSize>>20)
+}
+
+// CheckAttachments makes sure attachments sent with a message were uploaded
+// by owner to this context, so nobody can attach someone else's files or
+// arbitrary links.
+func (m MediaContext) CheckAttachments(owner primitive.ObjectID, attachments []models.Attachment) error {
+	prefix := "/" + m.FolderFor(owner) + "/"
+	for _, a := range attachments {
+		u, err := url.Parse(a.URL)
+		if err != nil || u.Scheme != "https" || u.Host != "res.cloudinary.com" || !strings.Contains(u.Path, prefix) {
+			return ErrMediaNotUploads
+		}
+		if _, ok := m.Types[a.ContentType]; !ok {
+			return ErrMediaType
+		}
+	}
+	return nil
+}
+
+// MediaStore keeps uploaded files. CloudinaryStore is the one the app runs
+// on; tests can swap in their own.
+type MediaStore interface {
+	// Upload stores file as folder/publicID and returns its HTTPS URL.
+	Upload(ctx context.Context, file io.Reader, folder, publicID string) (string, error)
+	// Delete removes a stored file; deleting one that's gone isn't an error.
+	Delete(ctx context.Context, asset MediaAsset) error
+}
+
+// MediaService checks uploads against their context and stores them.
+type MediaService struct {
+	store MediaStore
+}
+
+// NewMediaService wraps store, which is created once at startup. A nil
+// store leaves uploads failing with ErrMediaNotConfigured.
+func NewMediaService(store MediaStore) *MediaService {
+	return &MediaService{store: store}
+}
+
+// Upload checks the file's real type, sniffed from its content, and size
+// against the context and uploads it to owner's folder, under a random name
+// or, in a single-file context, as owner's file.
+func (s *MediaService) Upload(ctx context.Context, m MediaContext, owner primitive.ObjectID, file multipart.File, header *multipart.FileHeader) (models.Attachment, error) {
+	if s.store == nil {
+		return models.Attachment{}, ErrMediaNotConfigured
+	}
+
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil && err != io.EOF {
+		return models.Attachment{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	contentType := http.DetectContentType(buffer[:n])
+	if err := m.Allows(contentType, header.Size); err != nil {
+		return models.Attachment{}, err
+	}
+
+	publicID := uuid.New().String()
+	if m.Single {
+		publicID = owner.Hex()
+	}
+	link, err := s.store.Upload(ctx, file, m.FolderFor(owner), publicID)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	return models.Attachment{
+		URL:         link,
+		Name:        filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+	}, nil
+}
+
+// Store uploads a file the server produced itself, such as a report export,
+// to owner's folder under a random name. Its type is taken as given rather
+// than sniffed.
+func (s *MediaService) Store(ctx context.Context, m MediaContext, owner primitive.ObjectID, file io.Reader, contentType string, size int64) (string, error) {
+	if s.store == nil {
+		return "", ErrMediaNotConfigured
+	}
+	if err := m.Allows(contentType, size); err != nil {
+		return "", err
+	}
+	// Non-image files are stored raw, which keeps the extension in the URL
+	return s.store.Upload(ctx, file, m.FolderFor(owner), uuid.New().String()+m.Types[contentType])
+}
+
+// Delete removes the file at rawURL, which must be one the store handed
+// out.
+func (s *MediaService) Delete(ctx context.Context, rawURL string) error {
+	if s.store == nil {
+		return ErrMediaNotConfigured
+	}
+	asset, ok := ParseMediaURL(rawURL)
+	if !ok {
+		return ErrMediaNotStored
+	}
+	return s.store.Delete(ctx, asset)
+}
+
+// Transform returns the URL of an image resized and converted on delivery.
+// Anything that isn't a stored image comes back unchanged.
+func (s *MediaService) Transform(rawURL string, t MediaTransform) string {
+	asset, ok := ParseMediaURL(rawURL)
+	if !ok || asset.ResourceType != "image" {
+		return rawURL
+	}
+	i := strings.Index(rawURL, "/upload/")
+	return rawURL[:i+len("/upload/")] + t.String() + "/" + rawURL[i+len("/upload/"):]
+}
+
+// MediaTransform is how an image is delivered. Zero fields are left as
+// uploaded.
+type MediaTransform struct {
+	Width   int
+	Height  int
+	Crop    string // "fill", "fit", "thumb", ...
+	Quality string // "auto" or 1-100
+	Format  string // "auto" picks WebP/AVIF for browsers that take them
+}
+
+var (
+	MediaThumbnail = MediaTransform{Width: 300, Height: 300, Crop: "fill", Quality: "auto", Format: "auto"}
+	MediaCard      = MediaTransform{Width: 800, Crop: "limit", Quality: "auto", Format: "auto"}
+)
+
+// String is the transform as Cloudinary URL parameters, e.g.
+// "c_fill,w_300,h_300,q_auto,f_auto".
+func (t MediaTransform) String() string {
+	var params []string
+	if t.Crop != "" {
+		params = append(params, "c_"+t.Crop)
+	}
+	if t.Width > 0 {
+		params = append(params, fmt.Sprintf("w_%d", t.Width))
+	}
+	if t.Height > 0 {
+		params = append(params, fmt.Sprintf("h_%d", t.Height))
+	}
+	if t.Quality != "" {
+		params = append(params, "q_"+t.Quality)
+	}
+	if t.Format != "" {
+		params = append(params, "f_"+t.Format)
+	}
+	return strings.Join(params, ",")
+}
+
+// MediaAsset identifies a stored file.
+type MediaAsset struct {
+	PublicID     string
+	ResourceType string // "image", "video" or "raw"
+}
+
+// ParseMediaURL reads the asset out of a Cloudinary delivery URL such as
+// https://res.cloudinary.com/<cloud>/image/upload/v123/vendora/products/abc.jpg,
+// skipping any transformation and version.
+func ParseMediaURL(rawURL string) (MediaAsset, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host != "res.cloudinary.com" {
+		return MediaAsset{}, false
+	}
+	// <cloud>/<resource type>/upload/<rest>
+	parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 4)
+	if len(parts) < 4 || parts[2] != "upload" {
+		return MediaAsset{}, false
+	}
+	resourceType := parts[1]
+
+	segments := strings.Split(parts[3], "/")
+	for len(segments) > 1 && isTransformOrVersion(segments[0]) {
+		segments = segments[1:]
+	}
+	publicID := strings.Join(segments, "/")
+	// Raw files keep their extension in the public ID
+	if resourceType != "raw" {
+		publicID = strings.TrimSuffix(publicID, filepath.Ext(publicID))
+	}
+	if publicID == "" {
+		return MediaAsset{}, false
+	}
+	return MediaAsset{PublicID: publicID, ResourceType: resourceType}, true
+}
+
+// isTransformOrVersion reports whether a path segment is a version
+// ("v1712345678") or transformation ("c_fill,w_300") rather than part of
+// the public ID. Our folders never look like either.
+func isTransformOrVersion(segment string) bool {
+	if len(segment) > 1 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == "" {
+		return true
+	}
+	return len(segment) > 2 && segment[1] == '_' && strings.IndexByte("abcdefghijklmnopqrstuvwxyz", segment[0]) >= 0
+}
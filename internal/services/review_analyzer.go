@@ -0,0 +1,270 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+)
+
+// reviewLexicon scores the words that carry sentiment in reviews, from -3
+// to 3.
+var reviewLexicon = map[string]float64{
+	"love": 3, "loved": 3, "loves": 3, "excellent": 3, "perfect": 3, "perfectly": 3, "amazing": 3,
+	"awesome": 3, "fantastic": 3, "outstanding": 3, "superb": 3, "best": 3,
+	"great": 2, "good": 2, "nice": 2, "happy": 2, "pleased": 2, "beautiful": 2, "recommend": 2,
+	"recommended": 2, "lovely": 2, "comfortable": 2, "sturdy": 2, "fast": 1.5, "quick": 1.5,
+	"quickly": 1.5, "satisfied": 2, "worth": 1.5, "impressed": 2, "fine": 1, "okay": 0.5, "ok": 0.5,
+	"decent": 1, "soft": 1, "durable": 2, "helpful": 2, "affordable": 1.5, "cheap": -0.5,
+	"bad": -2, "poor": -2, "poorly": -2, "terrible": -3, "horrible": -3, "awful": -3, "worst": -3,
+	"hate": -3, "hated": -3, "disappointed": -2.5, "disappointing": -2.5, "useless": -3,
+	"broken": -2.5, "broke": -2.5, "damaged": -2.5, "defective": -2.5, "faulty": -2.5,
+	"late": -1.5, "delayed": -1.5, "slow": -1.5, "wrong": -2, "missing": -2, "fake": -3,
+	"scam": -3, "refund": -1, "return": -0.5, "returned": -1, "uncomfortable": -2, "flimsy": -2,
+	"ripped": -2, "torn": -2, "smells": -1, "smell": -0.5, "rude": -2.5, "unhelpful": -2,
+	"waste": -2.5, "overpriced": -2, "tight": -1, "loose": -1, "small": -0.5, "big": -0.5,
+	"faded": -1.5, "stained": -2, "leaking": -2, "leaked": -2,
+}
+
+// reviewNegators flip the sentiment of the few words after them.
+var reviewNegators = map[string]bool{
+	"not": true, "no": true, "never": true, "nothing": true, "hardly": true, "barely": true,
+	"dont": true, "didnt": true, "doesnt": true, "isnt": true, "wasnt": true, "werent": true,
+	"arent": true, "wont": true, "cant": true, "couldnt": true, "wouldnt": true, "without": true,
+}
+
+// reviewIntensifiers scale the next sentiment word.
+var reviewIntensifiers = map[string]float64{
+	"very": 1.5, "really": 1.4, "extremely": 1.8, "super": 1.5, "so": 1.3, "absolutely": 1.6,
+	"totally": 1.4, "incredibly": 1.7, "quite": 1.2, "slightly": 0.6, "somewhat": 0.7, "bit": 0.7,
+}
+
+// negationReach is how many words after a negator it applies to.
+const negationReach = 3
+
+// reviewTopic is a keyword topic and the phrases that bring it up. Phrases
+// in Negative or Positive say how it went on their own ("too small"),
+// while Neutral ones only name it ("size") and take the sentiment of the
+// rest of the clause.
+type reviewTopic struct {
+	Name     string
+	Neutral  []string
+	Negative []string
+	Positive []string
+}
+
+var reviewTopics = []reviewTopic{
+	{
+		Name:     "sizing",
+		Neutral:  []string{"size", "sizes", "sizing", "fit", "fits", "fitting"},
+		Negative: []string{"too small", "too big", "too large", "too tight", "too loose", "runs small", "runs large", "runs big", "size up", "size down", "doesnt fit", "didnt fit"},
+		Positive: []string{"true to size", "fits perfectly", "perfect fit", "fits well"},
+	},
+	{
+		Name:     "late delivery",
+		Negative: []string{"late", "delayed", "delay", "took forever", "took weeks", "still waiting", "never arrived", "never came", "slow delivery", "slow shipping", "arrived late", "long time to arrive"},
+	},
+	{
+		Name:     "fast delivery",
+		Positive: []string{"fast delivery", "quick delivery", "fast shipping", "quick shipping", "arrived early", "arrived quickly", "arrived fast", "delivered fast", "delivered quickly", "on time", "next day"},
+	},
+	{
+		Name:     "packaging",
+		Neutral:  []string{"packaging", "packaged", "package", "packed", "box", "wrapping"},
+		Positive: []string{"well packaged", "well packed", "nicely packaged"},
+	},
+	{
+		Name:     "damaged item",
+		Negative: []string{"damaged", "broken", "broke", "cracked", "dented", "scratched", "defective", "faulty", "torn", "ripped", "stopped working", "doesnt work", "didnt work"},
+	},
+	{
+		Name:     "quality",
+		Neutral:  []string{"quality", "build", "made", "durable", "sturdy", "flimsy", "cheaply made"},
+		Negative: []string{"poor quality", "low quality", "bad quality", "cheaply made", "flimsy", "fell apart"},
+		Positive: []string{"high quality", "good quality", "great quality", "well made", "sturdy", "durable"},
+	},
+	{
+		Name:     "value for money",
+		Neutral:  []string{"price", "priced", "value", "money", "worth", "cost"},
+		Negative: []string{"overpriced", "waste of money", "not worth", "too expensive", "rip off"},
+		Positive: []string{"worth it", "worth every", "good value", "great value", "great price", "good price", "bargain", "affordable"},
+	},
+	{
+		Name:     "not as described",
+		Negative: []string{"not as described", "not as pictured", "different from the picture", "looks different", "wrong item", "not what i ordered", "fake", "not original", "misleading"},
+		Positive: []string{"as described", "as pictured", "exactly as", "same as the picture", "looks like the picture"},
+	},
+	{
+		Name:     "customer service",
+		Neutral:  []string{"seller", "vendor", "service", "support", "response", "responded", "communication"},
+		Negative: []string{"no response", "never responded", "rude", "unhelpful", "ignored"},
+		Positive: []string{"great service", "excellent service", "very helpful", "responded quickly"},
+	},
+	{
+		Name:     "color",
+		Neutral:  []string{"color", "colour", "colors", "colours", "shade"},
+		Negative: []string{"wrong color", "wrong colour", "faded", "different color", "different colour"},
+	},
+	{
+		Name:    "material",
+		Neutral: []string{"material", "fabric", "leather", "cotton", "plastic", "stitching"},
+	},
+	{
+		Name:     "comfort",
+		Neutral:  []string{"comfort", "comfortable", "uncomfortable", "comfy"},
+		Negative: []string{"uncomfortable", "hurts", "painful"},
+		Positive: []string{"comfortable", "comfy"},
+	},
+	{
+		Name:     "missing items",
+		Negative: []string{"missing", "incomplete", "only received", "not included", "wasnt included", "didnt come with"},
+	},
+}
+
+var (
+	clauseSplitter = regexp.MustCompile(`[.!?;\n]+|\s+(?:but|however|though|although|except)\s+`)
+	reviewWord     = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+// ratingSentiment maps a 1 to 5 star rating onto -1 to 1.
+func ratingSentiment(rating int) float64 {
+	if rating < 1 || rating > 5 {
+		return 0
+	}
+	return float64(rating-3) / 2
+}
+
+// normalizeSentiment squashes a summed lexicon score into (-1, 1).
+func normalizeSentiment(score float64) float64 {
+	return score / math.Sqrt(score*score+15)
+}
+
+func clampSentiment(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// reviewWords lowercases text and splits it into words, dropping
+// apostrophes so "didn't" reads as "didnt".
+func reviewWords(text string) []string {
+	text = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(text))
+	return reviewWord.FindAllString(text, -1)
+}
+
+// scoreWords sums the lexicon scores of words, applying negators and
+// intensifiers, and reports whether any word carried sentiment.
+func scoreWords(words []string) (float64, bool) {
+	var sum float64
+	found := false
+	negated := 0
+	boost := 1.0
+	for _, w := range words {
+		if reviewNegators[w] {
+			negated = negationReach
+			continue
+		}
+		if f, ok := reviewIntensifiers[w]; ok {
+			boost = f
+			continue
+		}
+		if score, ok := reviewLexicon[w]; ok {
+			score *= boost
+			if negated > 0 {
+				score *= -0.75 // "not good" is less bad than "bad"
+			}
+			sum += score
+			found = true
+			boost = 1
+		}
+		if negated > 0 {
+			negated--
+		}
+	}
+	return sum, found
+}
+
+// containsPhrase reports whether the space-padded clause holds phrase as
+// whole words.
+func containsPhrase(padded, phrase string) bool {
+	return strings.Contains(padded, " "+phrase+" ")
+}
+
+// clauseTopic reports whether a clause brings up topic and, if so, the
+// polarity of the phrases it used: -1, 0 or 1.
+func clauseTopic(padded string, topic reviewTopic) (bool, float64) {
+	for _, p := range topic.Negative {
+		if containsPhrase(padded, p) {
+			return true, -1
+		}
+	}
+	for _, p := range topic.Positive {
+		if containsPhrase(padded, p) {
+			return true, 1
+		}
+	}
+	for _, p := range topic.Neutral {
+		if containsPhrase(padded, p) {
+			return true, 0
+		}
+	}
+	return false, 0
+}
+
+// AnalyzeReview scores the sentiment of a review from its comment, blended
+// with its rating, and picks out the topics it talks about, each with the
+// sentiment of the clauses that bring it up. It's a word-list analyzer: it
+// understands negation ("not worth it") and contrast ("great shoes but
+// they run small") but not sarcasm.
+func AnalyzeReview(comment string, rating int, now time.Time) models.ReviewInsights {
+	stars := ratingSentiment(rating)
+	insights := models.ReviewInsights{Topics: []models.ReviewTopic{}, Version: models.ReviewAnalyzerVersion, AnalyzedAt: now}
+
+	var total float64
+	found := false
+	topicScores := map[string][]float64{}
+	for _, clause := range clauseSplitter.Split(strings.ToLower(comment), -1) {
+		words := reviewWords(clause)
+		if len(words) == 0 {
+			continue
+		}
+		sum, ok := scoreWords(words)
+		total += sum
+		found = found || ok
+
+		padded := " " + strings.Join(words, " ") + " "
+		for _, topic := range reviewTopics {
+			mentioned, polarity := clauseTopic(padded, topic)
+			if !mentioned {
+				continue
+			}
+			score := stars // Nothing else to go on
+			if ok || polarity != 0 {
+				score = clampSentiment(normalizeSentiment(sum) + polarity*0.5)
+			}
+			topicScores[topic.Name] = append(topicScores[topic.Name], score)
+		}
+	}
+
+	if found {
+		insights.Sentiment = roundScore(0.6*normalizeSentiment(total) + 0.4*stars)
+	} else {
+		insights.Sentiment = roundScore(stars)
+	}
+	insights.Label = models.SentimentLabel(insights.Sentiment)
+
+	for name, scores := range topicScores {
+		var sum float64
+		for _, s := range scores {
+			sum += s
+		}
+		insights.Topics = append(insights.Topics, models.ReviewTopic{Topic: name, Sentiment: roundScore(sum / float64(len(scores)))})
+	}
+	sort.Slice(insights.Topics, func(i, j int) bool { return insights.Topics[i].Topic < insights.Topics[j].Topic })
+	return insights
+}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 
+	"github.com/developia-II/ecommerce-backend/internal/config"
 	"github.com/google/generative-ai-go/genai"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/api/option"
@@ -17,14 +17,13 @@ type VerificationService struct {
 	client *genai.Client
 }
 
-func NewVerificationService() (*VerificationService, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
+func NewVerificationService(cfg config.GeminiConfig) (*VerificationService, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY is not set")
 	}
 
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.APIKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Call is a call in progress to a third-party service.
+type Call struct {
+	span      trace.Span
+	service   string
+	operation string
+	start     time.Time
+}
+
+// External starts timing and tracing a call to a third-party service, such
+// as ("stripe", "refund.create"). End it with the call's error.
+func External(ctx context.Context, service, operation string) (context.Context, *Call) {
+	ctx, span := tracer().Start(ctx, service+" "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", service),
+			attribute.String("operation", operation),
+		),
+	)
+	return ctx, &Call{span: span, service: service, operation: operation, start: time.Now()}
+}
+
+func (c *Call) End(err error) {
+	externalCallDuration.WithLabelValues(c.service, c.operation, outcome(err)).Observe(time.Since(c.start).Seconds())
+	End(c.span, err)
+}
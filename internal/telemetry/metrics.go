@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by route and status.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	httpRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_request_errors_total",
+		Help: "HTTP requests answered with a 4xx or 5xx, by route and class.",
+	}, []string{"method", "route", "class"})
+
+	mongoCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_command_duration_seconds",
+		Help:    "Time taken by MongoDB commands, by collection and outcome.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"command", "collection", "outcome"})
+
+	externalCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "external_call_duration_seconds",
+		Help:    "Time taken by calls to third-party services such as Stripe and Cloudinary.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"service", "operation", "outcome"})
+
+	ordersPlaced = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "orders_placed_total",
+		Help: "Orders placed.",
+	})
+
+	orderValue = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "orders_placed_value_total",
+		Help: "Total value of orders placed, in the store currency.",
+	})
+
+	paymentsSucceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "payments_succeeded_total",
+		Help: "Payments confirmed, by how they were confirmed.",
+	}, []string{"source"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestErrors,
+		mongoCommandDuration,
+		externalCallDuration,
+		ordersPlaced,
+		orderValue,
+		paymentsSucceeded,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a handled HTTP request.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+	switch {
+	case status >= http.StatusInternalServerError:
+		httpRequestErrors.WithLabelValues(method, route, "5xx").Inc()
+	case status >= http.StatusBadRequest:
+		httpRequestErrors.WithLabelValues(method, route, "4xx").Inc()
+	}
+}
+
+// OrderPlaced counts an order and its total.
+func OrderPlaced(total float64) {
+	ordersPlaced.Inc()
+	orderValue.Add(total)
+}
+
+// PaymentSucceeded counts a confirmed payment. Source is how it was
+// confirmed: "webhook" or "verify".
+func PaymentSucceeded(source string) {
+	paymentsSucceeded.WithLabelValues(source).Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
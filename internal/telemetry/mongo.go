@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mongoCommand is a command the driver has sent and not yet heard back on.
+type mongoCommand struct {
+	collection string
+	span       trace.Span // Nil outside a traced request
+}
+
+// MongoMonitor traces and times every command the driver sends, so each
+// repository call shows up under the request that made it. Commands made
+// outside a traced request, such as background jobs, are only timed.
+func MongoMonitor() *event.CommandMonitor {
+	var inFlight sync.Map // <connection>/<request ID> -> mongoCommand
+
+	key := func(connectionID string, requestID int64) string {
+		return connectionID + "/" + strconv.FormatInt(requestID, 10)
+	}
+	finish := func(e event.CommandFinishedEvent, err error) {
+		v, ok := inFlight.LoadAndDelete(key(e.ConnectionID, e.RequestID))
+		if !ok {
+			return
+		}
+		cmd := v.(mongoCommand)
+		mongoCommandDuration.WithLabelValues(e.CommandName, cmd.collection, outcome(err)).Observe(e.Duration.Seconds())
+		if cmd.span != nil {
+			End(cmd.span, err)
+		}
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			cmd := mongoCommand{}
+			// Most commands name their collection as the command's value
+			if v, err := e.Command.LookupErr(e.CommandName); err == nil {
+				cmd.collection, _ = v.StringValueOK()
+			}
+			if trace.SpanContextFromContext(ctx).IsValid() {
+				_, cmd.span = tracer().Start(ctx, "mongo."+e.CommandName,
+					trace.WithSpanKind(trace.SpanKindClient),
+					trace.WithAttributes(
+						semconv.DBSystemMongoDB,
+						semconv.DBNamespace(e.DatabaseName),
+						semconv.DBCollectionName(cmd.collection),
+						semconv.DBOperationName(e.CommandName),
+					),
+				)
+			}
+			inFlight.Store(key(e.ConnectionID, e.RequestID), cmd)
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			finish(e.CommandFinishedEvent, nil)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			finish(e.CommandFinishedEvent, errors.New(e.Failure))
+		},
+	}
+}
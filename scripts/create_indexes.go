@@ -5,12 +5,13 @@ import (
 	"log"
 	"time"
 
+	"github.com/developia-II/ecommerce-backend/internal/database"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Run this script once to create database indexes
+// Run this script once to create database indexes and schema validators
 // Usage: go run scripts/create_indexes.go
 func main() {
 	// Increase timeout for cloud connection (Atlas is slower than localhost)
@@ -112,6 +113,23 @@ func main() {
 		log.Println("✅ Created unique index: idx_status_category_createdAt on products")
 	}
 
+	// Storefront filters: brand and price ranges, plus wildcard indexes for
+	// attribute filters on variant options and metadata, whose keys are
+	// vendor-defined
+	filterIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "brand", Value: 1}}, Options: options.Index().SetName("idx_status_brand")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "price", Value: 1}}, Options: options.Index().SetName("idx_status_price")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "salePrice", Value: 1}}, Options: options.Index().SetName("idx_status_sale_price")},
+		{Keys: bson.D{{Key: "variants.options.$**", Value: 1}}, Options: options.Index().SetName("idx_variant_options")},
+		{Keys: bson.D{{Key: "metadata.$**", Value: 1}}, Options: options.Index().SetName("idx_metadata")},
+	}
+	_, err = productsCollection.Indexes().CreateMany(ctx, filterIndexes)
+	if err != nil {
+		log.Printf("Failed to create product filter indexes: %v", err)
+	} else {
+		log.Println("✅ Created product filter indexes on products")
+	}
+
 	// ========================================
 	// VENDOR_ACCOUNTS COLLECTION INDEXES
 	// ========================================
@@ -150,6 +168,619 @@ func main() {
 		log.Println("✅ Created index: idx_tier on vendorAccounts.tier")
 	}
 
+	// ========================================
+	// STORES COLLECTION INDEXES
+	// ========================================
+	storesCollection := db.Collection("stores")
+
+	// 1. Unique slug for storefront URLs
+	_, err = storesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetName("idx_store_slug").SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create store_slug index: %v", err)
+	} else {
+		log.Println("✅ Created unique index: idx_store_slug on stores.slug")
+	}
+
+	// 2. Old slugs, for redirects
+	_, err = storesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "previousSlugs", Value: 1}},
+		Options: options.Index().SetName("idx_store_previous_slugs"),
+	})
+	if err != nil {
+		log.Printf("Failed to create store_previous_slugs index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_store_previous_slugs on stores.previousSlugs")
+	}
+
+	// 3. One store per vendor
+	_, err = storesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorID", Value: 1}},
+		Options: options.Index().SetName("idx_store_vendorID").SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create store_vendorID index: %v", err)
+	} else {
+		log.Println("✅ Created unique index: idx_store_vendorID on stores.vendorID")
+	}
+
+	// Store announcements: live lookups by vendor and schedule
+	_, err = db.Collection("storeAnnouncements").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorID", Value: 1}, {Key: "active", Value: 1}, {Key: "startsAt", Value: -1}},
+		Options: options.Index().SetName("idx_announcement_vendor_active_starts"),
+	})
+	if err != nil {
+		log.Printf("Failed to create announcement_vendor_active_starts index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_announcement_vendor_active_starts on storeAnnouncements")
+	}
+
+	// Store collections: slug is unique within a vendor's store
+	_, err = db.Collection("storeCollections").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorID", Value: 1}, {Key: "slug", Value: 1}},
+		Options: options.Index().SetName("idx_collection_vendor_slug").SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create collection_vendor_slug index: %v", err)
+	} else {
+		log.Println("✅ Created unique index: idx_collection_vendor_slug on storeCollections")
+	}
+
+	// Moderation queue: at most one open case per product
+	_, err = db.Collection("moderationCases").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "productID", Value: 1}},
+		Options: options.Index().SetName("idx_moderation_open_product").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": "open"}),
+	})
+	if err != nil {
+		log.Printf("Failed to create moderation_open_product index: %v", err)
+	} else {
+		log.Println("✅ Created unique index: idx_moderation_open_product on moderationCases")
+	}
+
+	// Content reports: one active report per reporter per piece of content
+	_, err = db.Collection("contentReports").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "reporterID", Value: 1}, {Key: "targetType", Value: 1}, {Key: "targetID", Value: 1}},
+		Options: options.Index().SetName("idx_reports_active_dedup").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"active": true}),
+	})
+	if err != nil {
+		log.Printf("Failed to create reports_active_dedup index: %v", err)
+	} else {
+		log.Println("✅ Created unique index: idx_reports_active_dedup on contentReports")
+	}
+
+	// Content reports: a reporter's recent reports, for rate limiting
+	_, err = db.Collection("contentReports").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "reporterID", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("idx_reports_reporter_created"),
+	})
+	if err != nil {
+		log.Printf("Failed to create reports_reporter_created index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_reports_reporter_created on contentReports")
+	}
+
+	// Users by verified phone, for SMS STOP/START replies
+	_, err = db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "phone", Value: 1}, {Key: "phoneVerified", Value: 1}},
+		Options: options.Index().SetName("idx_users_phone_verified"),
+	})
+	if err != nil {
+		log.Printf("Failed to create users_phone_verified index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_users_phone_verified on users")
+	}
+
+	// Email send log: newest first, expiring after 30 days
+	_, err = db.Collection("emailLogs").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("idx_email_logs_created_ttl").SetExpireAfterSeconds(30 * 24 * 60 * 60),
+	})
+	if err != nil {
+		log.Printf("Failed to create email_logs_created_ttl index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_email_logs_created_ttl on emailLogs")
+	}
+
+	// Notifications: a user's inbox, newest first
+	_, err = db.Collection("notifications").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("idx_notifications_user_created"),
+	})
+	if err != nil {
+		log.Printf("Failed to create notifications_user_created index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_notifications_user_created on notifications")
+	}
+
+	// Cursor pagination walks lists newest first by createdAt then _id
+	// (models.CursorSort)
+	cursorIndexes := map[string][]mongo.IndexModel{
+		"notifications": {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_notifications_user_cursor")},
+		},
+		"orders": {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_orders_user_cursor")},
+			{Keys: bson.D{{Key: "items.vendorId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_orders_vendor_cursor")},
+		},
+		"reviews": {
+			{Keys: bson.D{{Key: "productId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_reviews_product_cursor")},
+			{Keys: bson.D{{Key: "vendorId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_reviews_vendor_cursor")},
+		},
+		"products": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_products_status_cursor")},
+		},
+		"messages": {
+			{Keys: bson.D{{Key: "conversationId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_messages_conversation_cursor")},
+		},
+	}
+	for collection, indexes := range cursorIndexes {
+		_, err = db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		if err != nil {
+			log.Printf("Failed to create %s cursor indexes: %v", collection, err)
+		} else {
+			log.Printf("✅ Created cursor pagination indexes on %s", collection)
+		}
+	}
+
+	// Conversations: one per buyer, vendor and product or order; listed by
+	// last activity for either side
+	_, err = db.Collection("conversations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "buyerId", Value: 1}, {Key: "vendorId", Value: 1}, {Key: "subject", Value: 1}, {Key: "productId", Value: 1}, {Key: "orderId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_conversations_subject"),
+		},
+		{Keys: bson.D{{Key: "buyerId", Value: 1}, {Key: "updatedAt", Value: -1}}, Options: options.Index().SetName("idx_conversations_buyer_updated")},
+		{Keys: bson.D{{Key: "vendorId", Value: 1}, {Key: "updatedAt", Value: -1}}, Options: options.Index().SetName("idx_conversations_vendor_updated")},
+	})
+	if err != nil {
+		log.Printf("Failed to create conversations indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes: idx_conversations_subject, idx_conversations_buyer_updated, idx_conversations_vendor_updated on conversations")
+	}
+
+	// Vendor response times are averaged over their recent replies
+	_, err = db.Collection("messages").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "senderId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("idx_messages_sender_created"),
+	})
+	if err != nil {
+		log.Printf("Failed to create messages sender index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_messages_sender_created on messages")
+	}
+
+	// Canned responses are listed per vendor; each vendor has one away message
+	_, err = db.Collection("cannedResponses").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorId", Value: 1}, {Key: "useCount", Value: -1}},
+		Options: options.Index().SetName("idx_canned_responses_vendor"),
+	})
+	if err != nil {
+		log.Printf("Failed to create cannedResponses index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_canned_responses_vendor on cannedResponses")
+	}
+	_, err = db.Collection("autoReplies").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idx_auto_replies_vendor"),
+	})
+	if err != nil {
+		log.Printf("Failed to create autoReplies index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_auto_replies_vendor on autoReplies")
+	}
+
+	// Disputes: one per order and vendor; listed by last activity for the
+	// buyer, the vendor and the admin queue
+	_, err = db.Collection("disputes").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "orderId", Value: 1}, {Key: "vendorId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("idx_disputes_order_vendor"),
+		},
+		{Keys: bson.D{{Key: "buyerId", Value: 1}, {Key: "updatedAt", Value: -1}}, Options: options.Index().SetName("idx_disputes_buyer_updated")},
+		{Keys: bson.D{{Key: "vendorId", Value: 1}, {Key: "updatedAt", Value: -1}}, Options: options.Index().SetName("idx_disputes_vendor_updated")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updatedAt", Value: -1}}, Options: options.Index().SetName("idx_disputes_status_updated")},
+	})
+	if err != nil {
+		log.Printf("Failed to create disputes indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes: idx_disputes_order_vendor, idx_disputes_buyer_updated, idx_disputes_vendor_updated, idx_disputes_status_updated on disputes")
+	}
+
+	// Orders: a vendor's recent orders, for order lists and the weekly digest
+	_, err = db.Collection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "items.vendorId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("idx_orders_vendor_created"),
+	})
+	if err != nil {
+		log.Printf("Failed to create orders_vendor_created index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_orders_vendor_created on orders")
+	}
+
+	// Order numbers: unique, so a reset sequence can't hand one out twice
+	_, err = db.Collection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderNumber", Value: 1}},
+		Options: options.Index().SetName("idx_orders_number").SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create orders_number index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_orders_number on orders")
+	}
+
+	// Wishlists: one per user
+	_, err = db.Collection("wishlists").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetName("idx_wishlists_user"),
+	})
+	if err != nil {
+		log.Printf("Failed to create wishlists_user index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_wishlists_user on wishlists")
+	}
+
+	// Product views: one bucket per product per day, kept long enough for the
+	// trending window
+	viewIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "productId", Value: 1}, {Key: "day", Value: 1}}, Options: options.Index().SetName("idx_product_views_day").SetUnique(true)},
+		{Keys: bson.D{{Key: "day", Value: 1}}, Options: options.Index().SetName("idx_product_views_ttl").SetExpireAfterSeconds(35 * 24 * 60 * 60)},
+	}
+	_, err = db.Collection("productViews").Indexes().CreateMany(ctx, viewIndexes)
+	if err != nil {
+		log.Printf("Failed to create productViews indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on productViews")
+	}
+
+	// Product rankings: top of a ranking, overall or by category
+	rankingIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "score", Value: -1}}, Options: options.Index().SetName("idx_rankings_kind_score")},
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "categoryIds", Value: 1}, {Key: "score", Value: -1}}, Options: options.Index().SetName("idx_rankings_kind_category_score")},
+	}
+	_, err = db.Collection("productRankings").Indexes().CreateMany(ctx, rankingIndexes)
+	if err != nil {
+		log.Printf("Failed to create productRankings indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on productRankings")
+	}
+
+	// Search events: reports group queries over a date range; raw events are
+	// dropped after 180 days
+	searchIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, Options: options.Index().SetName("idx_search_events_ttl").SetExpireAfterSeconds(180 * 24 * 60 * 60)},
+		{Keys: bson.D{{Key: "resultCount", Value: 1}, {Key: "createdAt", Value: 1}}, Options: options.Index().SetName("idx_search_events_results")},
+	}
+	_, err = db.Collection("searchEvents").Indexes().CreateMany(ctx, searchIndexes)
+	if err != nil {
+		log.Printf("Failed to create searchEvents indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on searchEvents")
+	}
+
+	// Search synonyms: loaded per language
+	_, err = db.Collection("searchSynonyms").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "language", Value: 1}},
+		Options: options.Index().SetName("idx_search_synonyms_language"),
+	})
+	if err != nil {
+		log.Printf("Failed to create searchSynonyms index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_search_synonyms_language on searchSynonyms")
+	}
+
+	// Categories: slugs are unique URLs; children are listed by parent
+	categoryIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetName("idx_categories_slug").SetUnique(true)},
+		{Keys: bson.D{{Key: "parentId", Value: 1}, {Key: "isActive", Value: 1}}, Options: options.Index().SetName("idx_categories_parent")},
+	}
+	_, err = db.Collection("categories").Indexes().CreateMany(ctx, categoryIndexes)
+	if err != nil {
+		log.Printf("Failed to create categories indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on categories")
+	}
+
+	// Category commissions: a category's rate history
+	_, err = db.Collection("categoryCommissions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "categoryId", Value: 1}, {Key: "effectiveFrom", Value: -1}},
+		Options: options.Index().SetName("idx_category_commissions"),
+	})
+	if err != nil {
+		log.Printf("Failed to create categoryCommissions index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_category_commissions on categoryCommissions")
+	}
+
+	// Orders: tracking webhooks find the order a parcel belongs to
+	_, err = db.Collection("orders").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "trackingNumber", Value: 1}},
+			Options: options.Index().SetName("idx_orders_tracking").SetPartialFilterExpression(bson.M{"trackingNumber": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys:    bson.D{{Key: "labels.trackingNumber", Value: 1}},
+			Options: options.Index().SetName("idx_orders_label_tracking").SetSparse(true),
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to create order tracking indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes: idx_orders_tracking, idx_orders_label_tracking on orders")
+	}
+
+	// Shipping rates: one table per vendor, plus the platform's
+	_, err = db.Collection("shippingRates").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idx_shipping_rates_vendor"),
+	})
+	if err != nil {
+		log.Printf("Failed to create shippingRates index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_shipping_rates_vendor on shippingRates")
+	}
+
+	// Free-shipping rules: one per vendor, plus the platform's
+	_, err = db.Collection("freeShippingRules").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "vendorId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idx_free_shipping_vendor"),
+	})
+	if err != nil {
+		log.Printf("Failed to create freeShippingRules index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_free_shipping_vendor on freeShippingRules")
+	}
+
+	// Coupons: codes are unique; redemptions are counted per buyer
+	_, err = db.Collection("coupons").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true).SetName("idx_coupons_code")},
+		{Keys: bson.D{{Key: "vendorId", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_coupons_vendor")},
+	})
+	if err != nil {
+		log.Printf("Failed to create coupons indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes: idx_coupons_code, idx_coupons_vendor on coupons")
+	}
+	_, err = db.Collection("couponRedemptions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "couponId", Value: 1}, {Key: "userId", Value: 1}}, Options: options.Index().SetName("idx_redemptions_coupon_user")},
+		{Keys: bson.D{{Key: "couponId", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_redemptions_coupon_created")},
+	})
+	if err != nil {
+		log.Printf("Failed to create couponRedemptions indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on couponRedemptions")
+	}
+
+	// Sale campaigns: a vendor's list, and the scheduler's due checks. Products
+	// are found by the campaign they are on when it ends.
+	_, err = db.Collection("saleCampaigns").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "vendorId", Value: 1}, {Key: "startsAt", Value: -1}}, Options: options.Index().SetName("idx_sales_vendor")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "startsAt", Value: 1}}, Options: options.Index().SetName("idx_sales_status_start")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "endsAt", Value: 1}}, Options: options.Index().SetName("idx_sales_status_end")},
+	})
+	if err != nil {
+		log.Printf("Failed to create saleCampaigns indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on saleCampaigns")
+	}
+	_, err = productsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "sale.campaignId", Value: 1}},
+		Options: options.Index().SetSparse(true).SetName("idx_products_sale_campaign"),
+	})
+	if err != nil {
+		log.Printf("Failed to create sale campaign index on products: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_products_sale_campaign on products")
+	}
+
+	// Flash sales: the public list and a product's overlap check. Purchases
+	// are unique per buyer so the per-customer limit can't be raced past.
+	_, err = db.Collection("flashSales").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "cancelled", Value: 1}, {Key: "endsAt", Value: 1}, {Key: "startsAt", Value: 1}}, Options: options.Index().SetName("idx_flash_sales_window")},
+		{Keys: bson.D{{Key: "productId", Value: 1}, {Key: "startsAt", Value: 1}}, Options: options.Index().SetName("idx_flash_sales_product")},
+		{Keys: bson.D{{Key: "vendorId", Value: 1}, {Key: "startsAt", Value: -1}}, Options: options.Index().SetName("idx_flash_sales_vendor")},
+	})
+	if err != nil {
+		log.Printf("Failed to create flashSales indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on flashSales")
+	}
+	_, err = db.Collection("flashSalePurchases").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "flashSaleId", Value: 1}, {Key: "userId", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idx_flash_purchases_sale_user"),
+	})
+	if err != nil {
+		log.Printf("Failed to create flashSalePurchases index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_flash_purchases_sale_user on flashSalePurchases")
+	}
+
+	// Automatic discounts: redemptions are reported per discount. Segments are
+	// decided on a buyer's orders, which idx_orders_user_created covers.
+	_, err = db.Collection("autoDiscountRedemptions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "autoDiscountId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("idx_auto_redemptions_discount"),
+	})
+	if err != nil {
+		log.Printf("Failed to create autoDiscountRedemptions index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_auto_redemptions_discount on autoDiscountRedemptions")
+	}
+
+	// Audit log: newest-first queries by actor, target or action
+	auditIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_audit_created")},
+		{Keys: bson.D{{Key: "actorID", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_audit_actor")},
+		{Keys: bson.D{{Key: "targetID", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_audit_target")},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_audit_action")},
+	}
+	_, err = db.Collection("auditLogs").Indexes().CreateMany(ctx, auditIndexes)
+	if err != nil {
+		log.Printf("Failed to create auditLogs indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on auditLogs")
+	}
+
+	// Client blocks: one block per rate limiter key
+	_, err = db.Collection("clientBlocks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetName("idx_client_blocks_key").SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create clientBlocks index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_client_blocks_key on clientBlocks")
+	}
+
+	// Idempotency keys: one per user and key, dropped once they can no longer
+	// be replayed
+	idempotencyIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetName("idx_idempotency_user_key").SetUnique(true)},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("idx_idempotency_expires").SetExpireAfterSeconds(0)},
+	}
+	_, err = db.Collection("idempotencyKeys").Indexes().CreateMany(ctx, idempotencyIndexes)
+	if err != nil {
+		log.Printf("Failed to create idempotencyKeys indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on idempotencyKeys")
+	}
+
+	// Webhook subscriptions: the active ones for an event type
+	_, err = db.Collection("webhookSubscriptions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "events", Value: 1}, {Key: "active", Value: 1}},
+		Options: options.Index().SetName("idx_webhook_subscriptions_events"),
+	})
+	if err != nil {
+		log.Printf("Failed to create webhookSubscriptions index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_webhook_subscriptions_events on webhookSubscriptions")
+	}
+
+	// Webhook deliveries: a subscription's log, newest first, expiring after
+	// 30 days
+	webhookDeliveryIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subscriptionId", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_webhook_deliveries_subscription_created")},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, Options: options.Index().SetName("idx_webhook_deliveries_created_ttl").SetExpireAfterSeconds(30 * 24 * 60 * 60)},
+	}
+	_, err = db.Collection("webhookDeliveries").Indexes().CreateMany(ctx, webhookDeliveryIndexes)
+	if err != nil {
+		log.Printf("Failed to create webhookDeliveries indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on webhookDeliveries")
+	}
+
+	// Outbox: the oldest unpublished events for the relay, published ones
+	// dropped after 7 days
+	outboxIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "publishedAt", Value: 1}, {Key: "createdAt", Value: 1}}, Options: options.Index().SetName("idx_outbox_pending")},
+		{Keys: bson.D{{Key: "publishedAt", Value: 1}}, Options: options.Index().SetName("idx_outbox_published_ttl").SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+	}
+	_, err = db.Collection("outbox").Indexes().CreateMany(ctx, outboxIndexes)
+	if err != nil {
+		log.Printf("Failed to create outbox indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on outbox")
+	}
+
+	// Order status history: one order's changes in sequence, which also
+	// stops two changes taking the same place in it
+	_, err = db.Collection("orderStatusEvents").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderId", Value: 1}, {Key: "sequence", Value: 1}},
+		Options: options.Index().SetName("idx_order_status_events_sequence").SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("Failed to create order status events index: %v", err)
+	} else {
+		log.Println("✅ Created index: idx_order_status_events_sequence on orderStatusEvents")
+	}
+
+	// Platform analytics: the nightly rollup reads a day of orders by
+	// payment, of refunds, of sales and of vendor activations at a time
+	analyticsIndexes := map[string][]mongo.IndexModel{
+		"orders": {
+			{Keys: bson.D{{Key: "createdAt", Value: 1}, {Key: "paymentStatus", Value: 1}}, Options: options.Index().SetName("idx_orders_created_payment")},
+			{Keys: bson.D{{Key: "refunds.createdAt", Value: 1}}, Options: options.Index().SetName("idx_orders_refunds_created").SetSparse(true)},
+		},
+		"transactions":   {{Keys: bson.D{{Key: "type", Value: 1}, {Key: "createdAt", Value: 1}}, Options: options.Index().SetName("idx_transactions_type_created")}},
+		"vendorAccounts": {{Keys: bson.D{{Key: "activatedAt", Value: 1}}, Options: options.Index().SetName("idx_vendor_activated")}},
+	}
+	for collection, indexes := range analyticsIndexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+			log.Printf("Failed to create %s analytics indexes: %v", collection, err)
+		} else {
+			log.Printf("✅ Created analytics indexes on %s", collection)
+		}
+	}
+
+	// Checkout funnel: one row per buyer, step and provider a day, and the
+	// days of a report's range
+	checkoutIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "day", Value: 1}, {Key: "step", Value: 1}, {Key: "provider", Value: 1}}, Options: options.Index().SetName("idx_checkout_events_user_day_step").SetUnique(true)},
+		{Keys: bson.D{{Key: "day", Value: 1}}, Options: options.Index().SetName("idx_checkout_events_day")},
+	}
+	if _, err := db.Collection("checkoutEvents").Indexes().CreateMany(ctx, checkoutIndexes); err != nil {
+		log.Printf("Failed to create checkout event indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on checkoutEvents")
+	}
+
+	// Export jobs: the oldest queued for the worker, expired files for the
+	// sweep, and each user's own, newest first
+	exportIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}, Options: options.Index().SetName("idx_export_jobs_status_created")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("idx_export_jobs_status_expires")},
+		{Keys: bson.D{{Key: "requestedBy", Value: 1}, {Key: "createdAt", Value: -1}}, Options: options.Index().SetName("idx_export_jobs_user_created")},
+	}
+	if _, err := db.Collection("exportJobs").Indexes().CreateMany(ctx, exportIndexes); err != nil {
+		log.Printf("Failed to create export job indexes: %v", err)
+	} else {
+		log.Println("✅ Created indexes on exportJobs")
+	}
+
+	// Reviews waiting for the sentiment analyzer, oldest first
+	_, err = db.Collection("reviews").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "insights.version", Value: 1}, {Key: "createdAt", Value: 1}},
+		Options: options.Index().SetName("idx_reviews_insights_version"),
+	})
+	if err != nil {
+		log.Printf("Failed to create review insights index: %v", err)
+	} else {
+		log.Println("✅ Created review insights index")
+	}
+
+	// Soft-deleted documents: the trash, newest first, and the purge of
+	// those past retention
+	for _, collection := range []string{"users", "products", "reviews", "stores"} {
+		_, err = db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "deletedAt", Value: -1}},
+			Options: options.Index().SetName("idx_" + collection + "_deleted").SetSparse(true),
+		})
+		if err != nil {
+			log.Printf("Failed to create %s deleted index: %v", collection, err)
+		} else {
+			log.Printf("✅ Created index: idx_%s_deleted on %s", collection, collection)
+		}
+	}
+
+	// Amounts of money from before they were kept in minor units; see
+	// internal/database/money.go
+	if migrated, err := database.MigrateMoney(ctx, db); err != nil {
+		log.Printf("Failed to migrate money fields: %v", err)
+	} else {
+		log.Printf("✅ Converted money fields to minor units: %v", migrated)
+	}
+
+	// Schema validators on the critical collections; see
+	// internal/database/schema.go
+	if err := database.ApplySchemas(ctx, db); err != nil {
+		log.Printf("Failed to apply collection schemas: %v", err)
+	} else {
+		log.Println("✅ Applied schema validators to orders, products, vendorAccounts and sellerApplications")
+	}
+
 	log.Println("\n🎉 All indexes created successfully!")
 	log.Println("Run 'db.products.getIndexes()' and 'db.vendorAccounts.getIndexes()' in MongoDB shell to verify")
 }
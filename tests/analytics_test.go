@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func analyticsDay(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNextAnalyticsRun(t *testing.T) {
+	assert.Equal(t, time.Date(2026, 3, 4, models.AnalyticsHour, 0, 0, 0, time.UTC), models.NextAnalyticsRun(analyticsDay(3, 4)))
+	// Exactly at the run time, the next one is tomorrow's
+	at := time.Date(2026, 3, 4, models.AnalyticsHour, 0, 0, 0, time.UTC)
+	assert.Equal(t, at.AddDate(0, 0, 1), models.NextAnalyticsRun(at))
+}
+
+func TestRollUpPlatformDays(t *testing.T) {
+	computed := time.Date(2026, 3, 20, 1, 0, 0, 0, time.UTC)
+	days := []models.PlatformDay{
+		{Day: analyticsDay(3, 2), GMV: 100000, Orders: 4, PlatformRevenue: 5000, NewVendors: 1, Vendors: 10, ComputedAt: computed},
+		{Day: analyticsDay(3, 4), GMV: 50000, Orders: 1, PlatformRevenue: 2500, RefundVolume: 15000, Refunds: 1, Vendors: 10, ComputedAt: computed.Add(-24 * time.Hour)},
+		{Day: analyticsDay(3, 16), GMV: 30000, Orders: 2, PlatformRevenue: 1500, NewVendors: 2, Vendors: 12, ComputedAt: computed},
+	}
+	a := models.RollUpPlatformDays(days, analyticsDay(3, 2), analyticsDay(3, 23), models.GranularityWeek)
+
+	assert.Len(t, a.Series, 3)
+	week := a.Series[0]
+	assert.Equal(t, analyticsDay(3, 2), week.Start)
+	assert.Equal(t, models.Money(150000), week.GMV)
+	assert.Equal(t, 5, week.Orders)
+	assert.Equal(t, models.Money(30000), week.AvgOrderValue)
+	assert.Equal(t, 5.0, week.TakeRate)
+	assert.Equal(t, 10.0, week.RefundRate)
+	assert.Equal(t, 10, week.Vendors)
+
+	// A week with no sales keeps the vendor count it ended the last one with
+	assert.Zero(t, a.Series[1].GMV)
+	assert.Equal(t, 10, a.Series[1].Vendors)
+	assert.Equal(t, 12, a.Series[2].Vendors)
+
+	assert.Equal(t, models.Money(180000), a.Totals.GMV)
+	assert.Equal(t, 3, a.Totals.NewVendors)
+	assert.Equal(t, 12, a.Totals.Vendors)
+	assert.Equal(t, 8.33, a.Totals.RefundRate)
+	assert.Equal(t, computed.Add(-24*time.Hour), *a.ComputedAt)
+
+	assert.Nil(t, models.RollUpPlatformDays(nil, analyticsDay(3, 2), analyticsDay(3, 3), models.GranularityDay).ComputedAt)
+}
+
+func TestRollUpCategoryDays(t *testing.T) {
+	lamps, rugs := primitive.NewObjectID(), primitive.NewObjectID()
+	days := []models.PlatformDay{
+		{Day: analyticsDay(1, 10), Categories: []models.CategoryDay{
+			{CategoryID: lamps, Name: "Lamps", Revenue: 40000, UnitsSold: 4, Orders: 3},
+			{CategoryID: rugs, Name: "Rugs", Revenue: 30000, UnitsSold: 1, Orders: 1},
+			{Name: "Uncategorized", Revenue: 100, UnitsSold: 1, Orders: 1},
+		}},
+		{Day: analyticsDay(2, 3), Categories: []models.CategoryDay{
+			{CategoryID: rugs, Name: "Rugs", Revenue: 90000, UnitsSold: 3, Orders: 2},
+		}},
+	}
+	a := models.RollUpCategoryDays(days, analyticsDay(1, 1), analyticsDay(3, 1), models.GranularityMonth, 2)
+
+	assert.Len(t, a.Series, 2)
+	assert.Len(t, a.Series[0].Categories, 2)
+	assert.Equal(t, "Lamps", a.Series[0].Categories[0].Name)
+	assert.Equal(t, lamps.Hex(), a.Series[0].Categories[0].CategoryID)
+	assert.Equal(t, []models.CategorySales{{CategoryID: rugs.Hex(), Name: "Rugs", Revenue: 90000, UnitsSold: 3, OrderCount: 2}}, a.Series[1].Categories)
+
+	assert.Equal(t, "Rugs", a.Totals[0].Name)
+	assert.Equal(t, models.Money(120000), a.Totals[0].Revenue)
+	assert.Equal(t, 3, a.Totals[0].OrderCount)
+	assert.Equal(t, "Lamps", a.Totals[1].Name)
+}
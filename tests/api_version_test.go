@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func versionedRouter(cfg config.APIConfig) http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.APIVersion(cfg))
+	v1 := router.Group("/api/v1")
+	v1.GET("/wrapped", func(c *gin.Context) {
+		c.JSON(http.StatusOK, utils.SuccessResponse("Fetched", gin.H{"id": 1}))
+	})
+	v1.GET("/raw", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": []int{1, 2}, "total": 2})
+	})
+	v1.GET("/bare", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []string{"a", "b"})
+	})
+	v1.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, utils.ErrorResponse("Product not found"))
+	})
+	v1.GET("/soft-failure", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"success": false, "message": "Coupon expired", "code": "COUPON_INACTIVE", "retry": false})
+	})
+	v1.GET("/export", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/csv", []byte("id\n1\n"))
+	})
+	return middleware.VersionedAPI(router)
+}
+
+func getEnvelope(t *testing.T, handler http.Handler, path string) (*httptest.ResponseRecorder, utils.EnvelopeV2) {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var envelope utils.EnvelopeV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	return w, envelope
+}
+
+func TestAPIv2Envelope(t *testing.T) {
+	handler := versionedRouter(config.APIConfig{})
+
+	w, envelope := getEnvelope(t, handler, "/api/v2/wrapped")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(middleware.APIVersionHeader))
+	assert.Equal(t, `{"id":1}`, string(envelope.Data))
+	assert.Equal(t, "Fetched", envelope.Message)
+	assert.Nil(t, envelope.Error)
+
+	_, envelope = getEnvelope(t, handler, "/api/v2/raw")
+	assert.Equal(t, `[1,2]`, string(envelope.Data))
+	assert.Equal(t, `2`, string(envelope.Meta["total"]))
+
+	_, envelope = getEnvelope(t, handler, "/api/v2/bare")
+	assert.Equal(t, `["a","b"]`, string(envelope.Data))
+
+	w, envelope = getEnvelope(t, handler, "/api/v2/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	if assert.NotNil(t, envelope.Error) {
+		assert.Equal(t, "not_found", envelope.Error.Code)
+		assert.Equal(t, "Product not found", envelope.Error.Message)
+	}
+	assert.Nil(t, envelope.Data)
+
+	// Failures reported with 200 get an error status in v2
+	w, envelope = getEnvelope(t, handler, "/api/v2/soft-failure")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	if assert.NotNil(t, envelope.Error) {
+		assert.Equal(t, "COUPON_INACTIVE", envelope.Error.Code)
+		assert.Equal(t, "Coupon expired", envelope.Error.Message)
+		assert.Equal(t, `false`, string(envelope.Error.Details["retry"]))
+		assert.NotContains(t, envelope.Error.Details, "code")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/export", nil))
+	assert.Equal(t, "id\n1\n", w.Body.String())
+}
+
+func TestAPIv1Unchanged(t *testing.T) {
+	handler := versionedRouter(config.APIConfig{})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/raw", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"data":[1,2],"success":true,"total":2}`, w.Body.String())
+	assert.Equal(t, "1", w.Header().Get(middleware.APIVersionHeader))
+	assert.Equal(t, `</api/v2/raw>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+}
+
+func TestAPIv1Retirement(t *testing.T) {
+	deprecated := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	sunset := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	handler := versionedRouter(config.APIConfig{V1DeprecatedAt: deprecated, V1Sunset: sunset})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/wrapped", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1769817600", w.Header().Get("Deprecation"))
+	assert.Equal(t, sunset.Format(http.TimeFormat), w.Header().Get("Sunset"))
+
+	// v2 is unaffected by v1's retirement
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/wrapped", nil))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	handler = versionedRouter(config.APIConfig{V1DeprecatedAt: deprecated, V1Sunset: time.Now().Add(-time.Hour)})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/wrapped", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "API v1 has been retired")
+}
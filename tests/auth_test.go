@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func authenticatedGet(t *testing.T, userID, role string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	token, err := utils.GenerateToken(userID, role, time.Hour)
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthFailsClosedWhenAccountCantBeChecked(t *testing.T) {
+	utils.SetJWTSecret("test-secret-key-12345")
+	defer utils.SetJWTSecret("")
+	defer middleware.SetAccountStateLookup(nil)
+
+	accounts := map[string]middleware.AccountState{
+		"64b0000000000000000000a1": {Status: models.AccountStatusActive, Role: "admin"},
+		"64b0000000000000000000a2": {Status: models.AccountStatusActive, Role: "customer"}, // Demoted
+	}
+	middleware.SetAccountStateLookup(func(ctx context.Context, userID string) (middleware.AccountState, error) {
+		if userID == "64b0000000000000000000a3" {
+			return middleware.AccountState{}, errors.New("connection refused")
+		}
+		state, ok := accounts[userID]
+		if !ok {
+			return middleware.AccountState{}, middleware.ErrAccountNotFound
+		}
+		return state, nil
+	})
+
+	assert.Equal(t, http.StatusOK, authenticatedGet(t, "64b0000000000000000000a1", "admin").Code)
+	assert.Equal(t, http.StatusForbidden, authenticatedGet(t, "64b0000000000000000000a2", "admin").Code)
+
+	// Purged since the token was issued
+	assert.Equal(t, http.StatusUnauthorized, authenticatedGet(t, "64b0000000000000000000a4", "admin").Code)
+
+	// The database is down: the token's role isn't taken on trust
+	assert.Equal(t, http.StatusServiceUnavailable, authenticatedGet(t, "64b0000000000000000000a3", "admin").Code)
+}
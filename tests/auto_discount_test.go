@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoDiscountInSegment(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-30 * 24 * time.Hour)
+	lapsed := now.Add(-100 * 24 * time.Hour)
+
+	firstOrder := models.AutoDiscount{Segment: models.SegmentFirstOrder}
+	assert.True(t, firstOrder.InSegment(models.BuyerHistory{}, now))
+	assert.False(t, firstOrder.InSegment(models.BuyerHistory{Orders: 1, LastOrderAt: &recent}, now))
+
+	winBack := models.AutoDiscount{Segment: models.SegmentWinBack}
+	assert.False(t, winBack.InSegment(models.BuyerHistory{}, now))
+	assert.False(t, winBack.InSegment(models.BuyerHistory{Orders: 2, LastOrderAt: &recent}, now))
+	assert.True(t, winBack.InSegment(models.BuyerHistory{Orders: 2, LastOrderAt: &lapsed}, now))
+
+	winBack.WinBackDays = 14
+	assert.True(t, winBack.InSegment(models.BuyerHistory{Orders: 2, LastOrderAt: &recent}, now))
+}
+
+func TestBestAutoDiscount(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	items := []models.DiscountItem{{Subtotal: 10000}}
+	discounts := []models.AutoDiscount{
+		{Name: "Welcome", Segment: models.SegmentFirstOrder, Type: models.CouponFixed, Value: 5, Active: true},
+		{Name: "Welcome big", Segment: models.SegmentFirstOrder, Type: models.CouponPercentage, Value: 10, Active: true},
+		{Name: "Big basket", Segment: models.SegmentFirstOrder, Type: models.CouponPercentage, Value: 50, MinSpend: 500, Active: true},
+		{Name: "Come back", Segment: models.SegmentWinBack, Type: models.CouponPercentage, Value: 30, Active: true},
+	}
+
+	best, discount := models.BestAutoDiscount(discounts, models.BuyerHistory{}, items, now)
+	assert.NotNil(t, best)
+	assert.Equal(t, "Welcome big", discount.Name)
+	assert.Equal(t, models.Money(1000), discount.Amount)
+
+	recent := now.Add(-time.Hour)
+	best, discount = models.BestAutoDiscount(discounts, models.BuyerHistory{Orders: 1, LastOrderAt: &recent}, items, now)
+	assert.Nil(t, best)
+	assert.Zero(t, discount.Amount)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchLoaderCoalescesLookups(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	loader := utils.NewBatchLoader(context.Background(), func(ctx context.Context, keys []int) (map[int]string, error) {
+		mu.Lock()
+		batches = append(batches, append([]int(nil), keys...))
+		mu.Unlock()
+		values := map[int]string{}
+		for _, k := range keys {
+			if k != 3 {
+				values[k] = string(rune('a' + k))
+			}
+		}
+		return values, nil
+	}, 20*time.Millisecond, 100)
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	found := make([]bool, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, ok, err := loader.Load(i % 4) // 0 is asked for twice
+			assert.NoError(t, err)
+			results[i], found[i] = v, ok
+		}(i)
+	}
+	wg.Wait()
+
+	if assert.Len(t, batches, 1) {
+		sort.Ints(batches[0])
+		assert.Equal(t, []int{0, 1, 2, 3}, batches[0])
+	}
+	assert.Equal(t, []string{"a", "b", "c", "", "a"}, results)
+	assert.Equal(t, []bool{true, true, true, false, true}, found)
+
+	// Keys already loaded are served without another fetch
+	v, ok, err := loader.Load(1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+	assert.Len(t, batches, 1)
+}
+
+func TestBatchLoaderSplitsFullBatches(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	loader := utils.NewBatchLoader(context.Background(), func(ctx context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		sizes = append(sizes, len(keys))
+		mu.Unlock()
+		return map[int]int{}, nil
+	}, 20*time.Millisecond, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			loader.Load(i)
+		}(i)
+	}
+	wg.Wait()
+
+	sort.Ints(sizes)
+	assert.Equal(t, []int{1, 2, 2}, sizes)
+}
+
+func TestBatchLoaderReportsFetchErrors(t *testing.T) {
+	failure := errors.New("database unavailable")
+	loader := utils.NewBatchLoader(context.Background(), func(ctx context.Context, keys []string) (map[string]int, error) {
+		return nil, failure
+	}, time.Millisecond, 10)
+
+	_, ok, err := loader.Load("a")
+	assert.ErrorIs(t, err, failure)
+	assert.False(t, ok)
+}
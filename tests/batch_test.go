@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func batchRouter() http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.APIVersion(config.APIConfig{}))
+	api := middleware.VersionedAPI(router)
+	v1 := router.Group("/api/v1")
+	v1.GET("/public/products/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.JSON(http.StatusNotFound, utils.ErrorResponse("Product not found"))
+			return
+		}
+		c.JSON(http.StatusOK, utils.SuccessResponse("Product fetched", gin.H{"id": c.Param("id")}))
+	})
+	v1.GET("/cart", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"auth": c.GetHeader("Authorization")})
+	})
+	v1.POST("/batch", handlers.NewBatchHandler(api).Batch)
+	return api
+}
+
+func postBatch(handler http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer buyer-token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestBatchRunsSubRequests(t *testing.T) {
+	w := postBatch(batchRouter(), `{"requests": {
+		"lamp":    {"path": "/api/v1/public/products/lamp"},
+		"missing": {"path": "/api/v1/public/products/missing"},
+		"cart":    {"method": "get", "path": "/api/v1/cart"},
+		"v2":      {"path": "/api/v2/public/products/desk"}
+	}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Results map[string]struct {
+				Status int             `json:"status"`
+				Body   json.RawMessage `json:"body"`
+			} `json:"results"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	results := resp.Data.Results
+	assert.Len(t, results, 4)
+
+	assert.Equal(t, http.StatusOK, results["lamp"].Status)
+	assert.Equal(t, `{"success":true,"message":"Product fetched","data":{"id":"lamp"}}`, string(results["lamp"].Body))
+	assert.Equal(t, http.StatusNotFound, results["missing"].Status)
+	// Sub-requests run as the caller
+	assert.Equal(t, `{"auth":"Bearer buyer-token"}`, string(results["cart"].Body))
+	// and in the version they ask for
+	assert.Equal(t, `{"data":{"id":"desk"},"message":"Product fetched"}`, string(results["v2"].Body))
+}
+
+func TestBatchRejectsUnsafeRequests(t *testing.T) {
+	handler := batchRouter()
+	for name, body := range map[string]string{
+		"write":    `{"requests": {"a": {"method": "POST", "path": "/api/v1/cart"}}}`,
+		"external": `{"requests": {"a": {"path": "https://example.com/api/v1/cart"}}}`,
+		"non-api":  `{"requests": {"a": {"path": "/metrics"}}}`,
+		"nested":   `{"requests": {"a": {"path": "/api/v1/batch"}}}`,
+		"bad key":  `{"requests": {"a b": {"path": "/api/v1/cart"}}}`,
+		"empty":    `{"requests": {}}`,
+	} {
+		w := postBatch(handler, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryImport(t *testing.T) {
+	rows := models.FlattenCategoryImport([]models.CategoryImportNode{
+		{Name: "Electronics", Children: []models.CategoryImportNode{
+			{Name: "Phones & Tablets", Children: []models.CategoryImportNode{{Name: "Android"}}},
+		}},
+		{Name: "Garden", Parent: "home"},
+	}, utils.GenerateSlug)
+	assert.Equal(t, []string{"electronics", "phones-tablets", "android", "garden"},
+		[]string{rows[0].Slug, rows[1].Slug, rows[2].Slug, rows[3].Slug})
+	assert.Equal(t, "phones-tablets", rows[2].ParentSlug)
+	assert.Equal(t, "home", rows[3].ParentSlug)
+
+	// CSV rows can come in any order; parents are put first
+	csvRows, err := models.ParseCategoryCSV(strings.NewReader(
+		"name,parent,sortOrder\nAndroid,phones,2\nPhones,electronics,\nElectronics,,1\n"), utils.GenerateSlug)
+	assert.NoError(t, err)
+	ordered, err := models.OrderCategoryImport(csvRows)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"electronics", "phones", "android"},
+		[]string{ordered[0].Slug, ordered[1].Slug, ordered[2].Slug})
+	assert.Equal(t, 2, ordered[2].SortOrder)
+
+	_, err = models.OrderCategoryImport([]models.CategoryImportRow{
+		{Name: "A", Slug: "a", ParentSlug: "b"},
+		{Name: "B", Slug: "b", ParentSlug: "a"},
+	})
+	assert.Error(t, err)
+
+	_, err = models.OrderCategoryImport([]models.CategoryImportRow{{Name: "A", Slug: "a"}, {Name: "A", Slug: "a"}})
+	assert.Error(t, err)
+}
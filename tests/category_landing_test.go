@@ -0,0 +1,18 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingAtLeast(t *testing.T) {
+	counts := models.RatingAtLeast(map[int]int{5: 2, 4: 3, 2: 1, 0: 7})
+	assert.Equal(t, []models.FacetCount{
+		{Value: "4", Count: 5},
+		{Value: "3", Count: 5},
+		{Value: "2", Count: 6},
+		{Value: "1", Count: 6},
+	}, counts)
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCategoryHierarchy(t *testing.T) {
+	electronics := models.Category{ID: primitive.NewObjectID(), Name: "Electronics", Slug: "electronics"}
+	phones := models.Category{ID: primitive.NewObjectID(), Name: "Phones", Slug: "phones", ParentID: &electronics.ID}
+	laptops := models.Category{ID: primitive.NewObjectID(), Name: "Laptops", Slug: "laptops", ParentID: &electronics.ID}
+	android := models.Category{ID: primitive.NewObjectID(), Name: "Android", Slug: "android", ParentID: &phones.ID}
+	missing := primitive.NewObjectID()
+	orphan := models.Category{ID: primitive.NewObjectID(), Name: "Orphan", Slug: "orphan", ParentID: &missing}
+
+	h := models.NewCategoryHierarchy([]models.Category{android, phones, orphan, laptops, electronics})
+
+	tree := h.Tree()
+	assert.Len(t, tree, 1)
+	assert.Equal(t, "Electronics", tree[0].Name)
+	assert.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "Laptops", tree[0].Children[0].Name)
+	assert.Equal(t, "Phones", tree[0].Children[1].Name)
+	assert.Equal(t, "Android", tree[0].Children[1].Children[0].Name)
+	assert.Empty(t, tree[0].Children[0].Children)
+
+	crumbs := h.Breadcrumbs(android.ID)
+	assert.Equal(t, []string{"electronics", "phones", "android"}, []string{crumbs[0].Slug, crumbs[1].Slug, crumbs[2].Slug})
+	assert.Nil(t, h.Breadcrumbs(orphan.ID))
+
+	assert.ElementsMatch(t, []primitive.ObjectID{electronics.ID, phones.ID, laptops.ID, android.ID}, h.WithDescendants(electronics.ID))
+	assert.Equal(t, []primitive.ObjectID{android.ID}, h.WithDescendants(android.ID))
+
+	// Display order beats name order
+	phones.SortOrder, laptops.SortOrder = 1, 2
+	tree = models.NewCategoryHierarchy([]models.Category{android, phones, laptops, electronics}).Tree()
+	assert.Equal(t, "Phones", tree[0].Children[0].Name)
+	assert.Equal(t, "Laptops", tree[0].Children[1].Name)
+}
+
+func TestApplyAttributeSchema(t *testing.T) {
+	schema, err := models.NormalizeAttributeSchema([]models.CategoryAttribute{
+		{Key: "Screen size", Name: "Screen size", Type: models.AttributeNumber, Unit: "in", Required: true},
+		{Key: "Color", Name: "Color", Type: models.AttributeSelect, Options: []string{"Black", " White ", "black"}, Required: true},
+		{Key: "Waterproof", Name: "Waterproof", Type: models.AttributeBoolean},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Black", "White"}, schema[1].Options)
+
+	out, err := models.ApplyAttributeSchema(schema, map[string]string{
+		"screen size": "6.10 in",
+		"color":       "white",
+		"waterproof":  "TRUE",
+		"warranty":    "2 years",
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Screen size": "6.1",
+		"Color":       "White",
+		"Waterproof":  "true",
+		"warranty":    "2 years",
+	}, out)
+
+	_, err = models.ApplyAttributeSchema(schema, map[string]string{"Screen size": "6", "Color": "Red"}, nil)
+	assert.EqualError(t, err, "Color must be one of: Black, White")
+
+	// A required attribute can come from the variants instead
+	_, err = models.ApplyAttributeSchema(schema, map[string]string{"Screen size": "6"},
+		[]models.VariantOption{{Name: "color", Values: []string{"Black"}}})
+	assert.NoError(t, err)
+
+	_, err = models.NormalizeAttributeSchema([]models.CategoryAttribute{{Key: "Size", Name: "Size", Type: models.AttributeSelect}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutStage(t *testing.T) {
+	assert.Equal(t, 0, models.CheckoutStage(models.CheckoutStepCartViewed))
+	assert.Equal(t, 3, models.CheckoutStage(models.CheckoutStepPaymentSucceeded))
+	assert.Equal(t, -1, models.CheckoutStage("wishlist_viewed"))
+}
+
+func TestBuildCheckoutFunnel(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	overall := []models.CheckoutReach{
+		{Furthest: 0, Users: 50}, // Looked at their cart and left
+		{Furthest: 1, Users: 30},
+		{Furthest: 2, Users: 5},
+		{Furthest: 3, Users: 15},
+	}
+	byProvider := []models.CheckoutReach{
+		{Provider: "stripe", Furthest: 2, Users: 5},
+		{Provider: "stripe", Furthest: 3, Users: 15},
+		{Provider: "paystack", Furthest: 2, Users: 2},
+	}
+	funnel := models.BuildCheckoutFunnel(overall, byProvider, from, to)
+
+	assert.Equal(t, []models.FunnelStep{
+		{Step: models.CheckoutStepCartViewed, Users: 100, ConversionRate: 100, OverallRate: 100},
+		{Step: models.CheckoutStepAddressEntered, Users: 50, ConversionRate: 50, DropOffRate: 50, OverallRate: 50},
+		{Step: models.CheckoutStepPaymentIntentCreated, Users: 20, ConversionRate: 40, DropOffRate: 60, OverallRate: 20},
+		{Step: models.CheckoutStepPaymentSucceeded, Users: 15, ConversionRate: 75, DropOffRate: 25, OverallRate: 15},
+	}, funnel.Steps)
+	assert.Equal(t, models.CheckoutStepPaymentIntentCreated, funnel.BiggestDropOff)
+
+	assert.Len(t, funnel.Providers, 2)
+	assert.Equal(t, "paystack", funnel.Providers[0].Provider)
+	assert.Equal(t, []models.FunnelStep{
+		{Step: models.CheckoutStepPaymentIntentCreated, Users: 2, ConversionRate: 100, OverallRate: 100},
+		{Step: models.CheckoutStepPaymentSucceeded, Users: 0, ConversionRate: 0, DropOffRate: 100, OverallRate: 0},
+	}, funnel.Providers[0].Steps)
+	assert.Equal(t, 75.0, funnel.Providers[1].Steps[1].ConversionRate)
+}
+
+func TestBuildCheckoutFunnelEmpty(t *testing.T) {
+	funnel := models.BuildCheckoutFunnel(nil, nil, time.Time{}, time.Time{})
+	assert.Len(t, funnel.Steps, len(models.CheckoutSteps))
+	assert.Equal(t, 0, funnel.Steps[0].Users)
+	assert.Equal(t, 0.0, funnel.Steps[1].DropOffRate)
+	assert.Equal(t, "", funnel.BiggestDropOff)
+	assert.Empty(t, funnel.Providers)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func cohortMonth(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildCohorts(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	counts := []models.CohortCounts{
+		{Month: cohortMonth(2026, 1), Segment: "personalized", Users: 10, Buyers: 5, RepeatBuyers: 2},
+		{Month: cohortMonth(2026, 1), Segment: "not_personalized", Users: 20, Buyers: 4, RepeatBuyers: 1},
+		{Month: cohortMonth(2026, 3), Segment: "personalized", Users: 4, Buyers: 1},
+	}
+	activity := []models.CohortActivity{
+		{Month: cohortMonth(2026, 1), Segment: "personalized", Active: cohortMonth(2026, 1), Buyers: 4},
+		{Month: cohortMonth(2026, 1), Segment: "personalized", Active: cohortMonth(2026, 3), Buyers: 3},
+		{Month: cohortMonth(2026, 1), Segment: "not_personalized", Active: cohortMonth(2026, 2), Buyers: 4},
+		{Month: cohortMonth(2026, 3), Segment: "personalized", Active: cohortMonth(2026, 3), Buyers: 1},
+		// A cohort that wasn't counted is ignored
+		{Month: cohortMonth(2025, 12), Segment: "personalized", Active: cohortMonth(2026, 1), Buyers: 9},
+	}
+	cohorts := models.BuildCohorts(counts, activity, now)
+
+	assert.Len(t, cohorts, 3)
+	assert.Equal(t, "2026-01", cohorts[0].Month)
+	assert.Equal(t, "not_personalized", cohorts[0].Segment)
+	assert.Equal(t, 20.0, cohorts[0].ConversionRate)
+	assert.Equal(t, 25.0, cohorts[0].RepeatRate)
+
+	personalized := cohorts[1]
+	assert.Equal(t, "personalized", personalized.Segment)
+	assert.Equal(t, 50.0, personalized.ConversionRate)
+	assert.Equal(t, 40.0, personalized.RepeatRate)
+	assert.Equal(t, []models.CohortRetention{
+		{MonthsSinceSignup: 0, Buyers: 4, Rate: 40},
+		{MonthsSinceSignup: 1},
+		{MonthsSinceSignup: 2, Buyers: 3, Rate: 30},
+	}, personalized.Retention)
+
+	// This month's cohort has only its first month so far
+	assert.Equal(t, "2026-03", cohorts[2].Month)
+	assert.Equal(t, []models.CohortRetention{{MonthsSinceSignup: 0, Buyers: 1, Rate: 25}}, cohorts[2].Retention)
+}
+
+func TestParseCohortRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	from, to, err := handlers.ParseCohortRange("", "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, cohortMonth(2025, 4), from)
+	assert.Equal(t, cohortMonth(2026, 4), to)
+
+	from, to, err = handlers.ParseCohortRange("2025-11", "2026-01", now)
+	assert.NoError(t, err)
+	assert.Equal(t, cohortMonth(2025, 11), from)
+	assert.Equal(t, cohortMonth(2026, 2), to)
+
+	_, _, err = handlers.ParseCohortRange("2026-02", "2026-01", now)
+	assert.Error(t, err)
+	_, _, err = handlers.ParseCohortRange("2020-01", "2026-01", now)
+	assert.Error(t, err)
+	_, _, err = handlers.ParseCohortRange("March", "", now)
+	assert.Error(t, err)
+}
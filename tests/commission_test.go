@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommissionRateAt(t *testing.T) {
+	rate := func(v float64) *float64 { return &v }
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []models.CategoryCommission{
+		{Rate: rate(8), EffectiveFrom: jan},
+		{Rate: rate(10), EffectiveFrom: jan.AddDate(0, 3, 0)},
+		{Rate: nil, EffectiveFrom: jan.AddDate(0, 6, 0)},
+	}
+
+	_, ok := models.CommissionRateAt(history, jan.Add(-time.Hour))
+	assert.False(t, ok)
+
+	r, ok := models.CommissionRateAt(history, jan.AddDate(0, 1, 0))
+	assert.True(t, ok)
+	assert.Equal(t, 8.0, *r)
+
+	// An order placed before a rate change keeps the old rate
+	r, _ = models.CommissionRateAt(history, jan.AddDate(0, 3, 0).Add(-time.Second))
+	assert.Equal(t, 8.0, *r)
+	r, _ = models.CommissionRateAt(history, jan.AddDate(0, 4, 0))
+	assert.Equal(t, 10.0, *r)
+
+	// Cleared: back to vendor rates
+	r, ok = models.CommissionRateAt(history, jan.AddDate(0, 7, 0))
+	assert.True(t, ok)
+	assert.Nil(t, r)
+}
+
+func TestSaleFee(t *testing.T) {
+	rate := 10.0
+	gross, fee := models.SaleFee([]models.SaleItem{
+		{Amount: 10000, Rate: &rate},
+		{Amount: 5000},
+	}, 5)
+	assert.Equal(t, models.Money(15000), gross)
+	assert.Equal(t, models.Money(1250), fee)
+
+	// Fees are rounded once, on the total, not item by item
+	_, fee = models.SaleFee([]models.SaleItem{{Amount: 333}, {Amount: 333}, {Amount: 333}}, 1.5)
+	assert.Equal(t, models.Money(15), fee)
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigLoadDevelopment(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("PORT", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("MONGO_URI", "")
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test_123")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "")
+	t.Setenv("CLOUDINARY_CLOUD_NAME", "")
+	t.Setenv("SENDER_EMAIL", "")
+
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config.EnvDevelopment, cfg.Env)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, "vendora", cfg.Mongo.Database)
+	// Missing services are warned about rather than fatal outside production
+	assert.Len(t, cfg.Warnings(), 4)
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := &config.Config{
+		Env:       config.EnvProduction,
+		Port:      "8080",
+		JWTSecret: "short",
+		Stripe:    config.StripeConfig{SecretKey: "pk_live_123", WebhookSecret: "whsec_123"},
+	}
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET must be at least 32 characters")
+	assert.Contains(t, err.Error(), "STRIPE_SECRET_KEY is not a Stripe secret key")
+	assert.Contains(t, err.Error(), "MONGO_URI is not set")
+	assert.Contains(t, err.Error(), "CLOUDINARY_* is not set")
+	assert.Contains(t, err.Error(), "SENDER_EMAIL is not set")
+
+	cfg.JWTSecret = "0123456789abcdef0123456789abcdef"
+	cfg.Mongo.URI = "mongodb://localhost:27017"
+	cfg.Stripe.SecretKey = "sk_live_123"
+	cfg.Cloudinary = config.CloudinaryConfig{CloudName: "demo", APIKey: "key", APISecret: "secret"}
+	cfg.Email = config.EmailConfig{Provider: "brevo", SenderEmail: "hello@vendora.test", BrevoAPIKey: "xkeysib-123"}
+	assert.NoError(t, cfg.Validate())
+	assert.Empty(t, cfg.Warnings())
+
+	cfg.Env = "prod"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestConfigRateLimitOverrides(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("RATE_LIMIT_SEARCH", "120/30s")
+
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config.RateLimit{Requests: 120, Window: 30 * time.Second}, cfg.RateLimits["search"])
+
+	t.Setenv("RATE_LIMIT_SEARCH", "lots")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
+
+func TestConfigAPIv1Retirement(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("API_V1_DEPRECATED_AT", "2026-01-31")
+	t.Setenv("API_V1_SUNSET", "2026-07-01T12:00:00Z")
+
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), cfg.API.V1DeprecatedAt)
+	assert.Equal(t, time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC), cfg.API.V1Sunset)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.API.V1Sunset = cfg.API.V1DeprecatedAt.AddDate(0, 0, -1)
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "API_V1_SUNSET must come after API_V1_DEPRECATED_AT")
+
+	t.Setenv("API_V1_SUNSET", "next summer")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
+
+func TestConfigProviders(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("SENDER_EMAIL", "hello@vendora.test")
+	t.Setenv("EMAIL_PROVIDER", "smtp")
+	t.Setenv("SMTP_HOST", "smtp.vendora.test")
+	t.Setenv("SMS_ROUTES", "+234:termii:Vendora, *:twilio:+15551234567")
+	t.Setenv("TERMII_API_KEY", "termii-key")
+	t.Setenv("TWILIO_ACCOUNT_SID", "AC123")
+	t.Setenv("TWILIO_AUTH_TOKEN", "twilio-token")
+	t.Setenv("SHIPPING_PROVIDER", "Shippo")
+	t.Setenv("SHIPPO_API_KEY", "shippo-key")
+	t.Setenv("WEBHOOK_URLS", "https://crm.vendora.test/hook, https://mail.vendora.test/hook")
+
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "587", cfg.Email.SMTP.Port)
+	assert.Equal(t, []config.SMSRoute{
+		{Prefix: "234", Provider: "termii", Sender: "Vendora"},
+		{Prefix: "*", Provider: "twilio", Sender: "+15551234567"},
+	}, cfg.SMS.Routes)
+	assert.Equal(t, "https://api.ng-termii.com", cfg.SMS.Termii.BaseURL)
+	assert.Equal(t, "shippo", cfg.Shipping.Provider)
+	assert.Len(t, cfg.Webhooks.URLs, 2)
+
+	// A provider that is picked must be set up completely, at startup
+	t.Setenv("TWILIO_AUTH_TOKEN", "")
+	t.Setenv("SHIPPO_API_KEY", "")
+	t.Setenv("GEOCODER_PROVIDER", "mapbox")
+	_, err = config.Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN must be set")
+	assert.Contains(t, err.Error(), "SHIPPO_API_KEY is not set")
+	assert.Contains(t, err.Error(), "GEOCODER_PROVIDER must be google")
+
+	t.Setenv("TWILIO_AUTH_TOKEN", "twilio-token")
+	t.Setenv("SHIPPO_API_KEY", "shippo-key")
+	t.Setenv("GEOCODER_PROVIDER", "")
+	t.Setenv("SMS_ROUTES", "234-termii")
+	_, err = config.Load()
+	assert.Error(t, err)
+
+	t.Setenv("SMS_ROUTES", "")
+	t.Setenv("EMAIL_PROVIDER", "sendgrid")
+	t.Setenv("WEBHOOK_URLS", "crm.vendora.test")
+	_, err = config.Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SENDGRID_API_KEY is not set")
+	assert.Contains(t, err.Error(), "WEBHOOK_URLS must list http(s) URLs")
+}
+
+func TestConfigTrashRetention(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("SOFT_DELETE_RETENTION_DAYS", "")
+
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, cfg.TrashRetention)
+
+	t.Setenv("SOFT_DELETE_RETENTION_DAYS", "7")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, cfg.TrashRetention)
+
+	for _, bad := range []string{"0", "-3", "a week"} {
+		t.Setenv("SOFT_DELETE_RETENTION_DAYS", bad)
+		_, err = config.Load()
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestConfigMerchantFeedCurrency(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("MERCHANT_FEED_CURRENCY", "")
+
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", cfg.MerchantFeedCurrency)
+
+	t.Setenv("MERCHANT_FEED_CURRENCY", "ngn")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "NGN", cfg.MerchantFeedCurrency)
+
+	t.Setenv("MERCHANT_FEED_CURRENCY", "naira")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
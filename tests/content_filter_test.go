@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentFilterMasks(t *testing.T) {
+	f := services.NewContentFilter()
+
+	result, err := f.Apply(services.FilterMessage, "Email me at jane.doe@example.com or call +234 803 123 4567")
+	assert.NoError(t, err)
+	assert.Equal(t, "Email me at [email removed] or call [number removed]", result.Text)
+	assert.Equal(t, []string{services.FilterPII}, result.Checks())
+
+	result, _ = f.Apply(services.FilterMessage, "Find me on WhatsApp or @janes_store, see www.janes.shop")
+	assert.Equal(t, "Find me on [contact removed] or [handle removed], see [link removed]", result.Text)
+	assert.Equal(t, []string{services.FilterContactInfo}, result.Checks())
+
+	result, _ = f.Apply(services.FilterReview, "This is shit, total bullshit")
+	assert.Equal(t, "This is s***, total b*******", result.Text)
+
+	// Prices, quantities and ordinary words are left alone
+	clean := "Arrived in 3 days, paid 25000 for 2. Classic Scunthorpe shirt"
+	result, _ = f.Apply(services.FilterReview, clean)
+	assert.False(t, result.Flagged())
+	assert.Equal(t, clean, result.Text)
+}
+
+func TestContentFilterRejects(t *testing.T) {
+	f := services.NewContentFilter()
+	about := "Handmade bags"
+	desc := "DM me on telegram"
+
+	result, err := f.FilterText(services.FilterStoreText, &about, nil, &desc)
+	assert.Equal(t, services.ErrContentRejected, err)
+	assert.Equal(t, []string{services.FilterContactInfo}, result.Checks())
+	assert.Equal(t, "DM me on telegram", desc)
+
+	t.Setenv("CONTENT_FILTER_MODE_STORE", "mask")
+	t.Setenv("CONTENT_FILTER_WORDS", "rubbish")
+	f = services.NewContentFilter()
+	assert.Equal(t, services.FilterModeMask, f.ModeFor(services.FilterStoreText))
+
+	desc = "No rubbish here"
+	_, err = f.FilterText(services.FilterStoreText, &desc)
+	assert.NoError(t, err)
+	assert.Equal(t, "No r****** here", desc)
+}
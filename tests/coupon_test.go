@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCouponApply(t *testing.T) {
+	vendorA, vendorB := primitive.NewObjectID(), primitive.NewObjectID()
+	mug, plate, lamp := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	items := []models.DiscountItem{
+		{ProductID: mug, VendorID: vendorA, Subtotal: 2000},
+		{ProductID: plate, VendorID: vendorA, Subtotal: 1000},
+		{ProductID: lamp, VendorID: vendorB, Subtotal: 7000},
+	}
+	now := time.Now()
+
+	// A vendor's percentage coupon only touches their own items
+	coupon := models.Coupon{Code: "TENOFF", VendorID: vendorA, Type: models.CouponPercentage, Value: 10, Active: true}
+	discount, err := coupon.Apply(items, now)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(300), discount.Amount)
+	assert.Equal(t, models.Money(200), discount.ByProduct[mug])
+	assert.Equal(t, models.Money(100), discount.ByProduct[plate])
+	assert.Zero(t, discount.ByProduct[lamp])
+	assert.True(t, discount.VendorFunded)
+
+	// Capped, and split so the shares add up
+	coupon = models.Coupon{Type: models.CouponPercentage, Value: 50, MaxDiscount: 10, Active: true}
+	discount, err = coupon.Apply(items, now)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(1000), discount.Amount)
+	assert.False(t, discount.VendorFunded)
+	assert.Equal(t, discount.Amount, discount.ByProduct[mug]+discount.ByProduct[plate]+discount.ByProduct[lamp])
+
+	// A fixed amount never takes off more than the items cost
+	coupon = models.Coupon{VendorID: vendorA, Type: models.CouponFixed, Value: 50, Active: true}
+	discount, err = coupon.Apply(items, now)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(3000), discount.Amount)
+
+	// Minimum spend is on the items it covers
+	coupon = models.Coupon{VendorID: vendorA, Type: models.CouponFixed, Value: 5, MinSpend: 40, Active: true}
+	_, err = coupon.Apply(items, now)
+	assert.ErrorIs(t, err, models.ErrCouponMinSpend)
+
+	coupon = models.Coupon{ProductIDs: []primitive.ObjectID{primitive.NewObjectID()}, Type: models.CouponFixed, Value: 5, Active: true}
+	_, err = coupon.Apply(items, now)
+	assert.ErrorIs(t, err, models.ErrCouponNotApplicable)
+}
+
+func TestCouponLive(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	assert.True(t, models.Coupon{Active: true}.Live(now))
+	assert.False(t, models.Coupon{Active: false}.Live(now))
+	assert.False(t, models.Coupon{Active: true, StartsAt: &later}.Live(now))
+	assert.False(t, models.Coupon{Active: true, ExpiresAt: &earlier}.Live(now))
+	assert.Equal(t, "SUMMER10", models.NormalizeCouponCode(" summer10 "))
+}
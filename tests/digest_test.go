@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNextDigestRun(t *testing.T) {
+	// Wednesday -> the following Monday
+	wed := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), models.NextDigestRun(wed))
+
+	// Monday before and at send time
+	monEarly := time.Date(2026, 10, 19, 7, 59, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), models.NextDigestRun(monEarly))
+	monAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 26, 8, 0, 0, 0, time.UTC), models.NextDigestRun(monAt))
+}
+
+func TestWishlistPriceDrops(t *testing.T) {
+	lamp := models.Product{ID: primitive.NewObjectID(), Name: "Lamp", Price: 5000, SalePrice: 3500, Status: models.ProductStatusActive}
+	rug := models.Product{ID: primitive.NewObjectID(), Name: "Rug", Price: 9000, Status: models.ProductStatusActive}
+	mug := models.Product{ID: primitive.NewObjectID(), Name: "Mug", Price: 1200, Status: models.ProductStatusActive}
+	gone := models.Product{ID: primitive.NewObjectID(), Name: "Vase", Price: 500, Status: models.ProductStatusArchived}
+	fresh := models.Product{ID: primitive.NewObjectID(), Name: "Chair", Price: 1000, Status: models.ProductStatusActive}
+
+	previous := map[string]models.Money{
+		lamp.ID.Hex(): 5000,
+		rug.ID.Hex():  10000,
+		mug.ID.Hex():  1000, // Went up
+		gone.ID.Hex(): 2000,
+	}
+	drops := models.WishlistPriceDrops([]models.Product{lamp, rug, mug, gone, fresh}, previous)
+
+	if assert.Len(t, drops, 2) {
+		assert.Equal(t, "Lamp", drops[0].Name) // Biggest drop first
+		assert.Equal(t, models.Money(3500), drops[0].Price)
+		assert.Equal(t, "Rug", drops[1].Name)
+	}
+
+	snapshot := models.WishlistPriceSnapshot([]models.Product{lamp, rug})
+	assert.Equal(t, models.Money(3500), snapshot[lamp.ID.Hex()])
+	assert.Equal(t, models.Money(9000), snapshot[rug.ID.Hex()])
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	utils.SetJWTSecret("test-secret-key-12345")
+	defer utils.SetJWTSecret("")
+
+	token, err := utils.GenerateUnsubscribeToken("64b7f0c2a1b2c3d4e5f60718", models.NotificationDigest)
+	assert.NoError(t, err)
+
+	userID, kind, err := utils.VerifyUnsubscribeToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "64b7f0c2a1b2c3d4e5f60718", userID)
+	assert.Equal(t, models.NotificationDigest, kind)
+
+	_, _, err = utils.VerifyUnsubscribeToken(token + "x")
+	assert.ErrorIs(t, err, utils.ErrInvalidUnsubscribeToken)
+}
+
+func TestRenderDigestTemplates(t *testing.T) {
+	subject, body, err := utils.RenderEmailTemplate(utils.EmailTemplateVendorDigest, utils.VendorDigestEmailData{
+		Name:            "Ada",
+		Revenue:         120,
+		UnitsSold:       4,
+		OrderCount:      3,
+		LowStock:        []utils.DigestEmailProduct{{Name: "Lamp", Quantity: 0}},
+		UnsubscribeLink: "https://example.com/unsubscribe?token=abc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Your Vendora week: 3 orders, $120.00 in sales", subject)
+	assert.Contains(t, body, "Out of stock")
+	assert.Contains(t, body, "Unsubscribe from weekly digests")
+
+	subject, _, err = utils.RenderEmailTemplate(utils.EmailTemplateBuyerDigest, utils.BuyerDigestEmailData{
+		Name:       "Ada",
+		PriceDrops: []utils.DigestEmailProduct{{Name: "Rug", Price: 90, PreviousPrice: 100}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Price drops on your Vendora wishlist", subject)
+}
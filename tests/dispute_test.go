@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckDisputable(t *testing.T) {
+	now := time.Now()
+	order := models.Order{PaymentStatus: "paid", Status: models.StatusShipped, CreatedAt: now.Add(-5 * 24 * time.Hour)}
+	assert.NoError(t, models.CheckDisputable(order, now))
+
+	unpaid := order
+	unpaid.PaymentStatus = "pending"
+	assert.Equal(t, models.ErrDisputeNotPaid, models.CheckDisputable(unpaid, now))
+
+	cancelled := order
+	cancelled.Status = models.StatusCancelled
+	assert.Equal(t, models.ErrDisputeNotPaid, models.CheckDisputable(cancelled, now))
+
+	// The window runs from delivery when there was one
+	delivered := now.Add(-31 * 24 * time.Hour)
+	late := order
+	late.Status = models.StatusDelivered
+	late.DeliveredAt = &delivered
+	assert.Equal(t, models.ErrDisputeWindowClosed, models.CheckDisputable(late, now))
+
+	recent := now.Add(-2 * 24 * time.Hour)
+	late.CreatedAt = now.Add(-60 * 24 * time.Hour)
+	late.DeliveredAt = &recent
+	assert.NoError(t, models.CheckDisputable(late, now))
+}
+
+func TestDisputeClaimAndClawback(t *testing.T) {
+	vendorA, vendorB := primitive.NewObjectID(), primitive.NewObjectID()
+	order := models.Order{
+		Items: []models.OrderItem{
+			{VendorID: vendorA, Subtotal: 6000, Discount: 600, DiscountFundedBy: models.FundedByPlatform},
+			{VendorID: vendorA, Subtotal: 4000, Discount: 400, DiscountFundedBy: models.FundedByVendor},
+			{VendorID: vendorB, Subtotal: 5000},
+		},
+		Total: 15000,
+	}
+
+	assert.Equal(t, models.Money(9000), models.DisputeClaimLimit(order, vendorA))
+	assert.Equal(t, models.Money(5000), models.DisputeClaimLimit(order, vendorB))
+
+	// Capped by what is left on the order
+	order.RefundedAmount = 12000
+	assert.Equal(t, models.Money(3000), models.DisputeClaimLimit(order, vendorA))
+
+	// Vendor A was credited 60 + 36; a platform-funded discount isn't theirs
+	// to repay beyond that
+	assert.Equal(t, models.Money(9000), models.DisputeClawback(order, vendorA, 9000))
+	assert.Equal(t, models.Money(9600), models.DisputeClawback(order, vendorA, 12000))
+	assert.Zero(t, models.DisputeClawback(order, primitive.NewObjectID(), 1000))
+}
+
+func TestDisputeEscalation(t *testing.T) {
+	buyer, vendor := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now()
+	dispute := models.Dispute{BuyerID: buyer, VendorID: vendor, Status: models.DisputeStatusOpen, RespondBy: now.Add(time.Hour)}
+
+	assert.Equal(t, models.DisputeRoleBuyer, dispute.RoleOf(buyer))
+	assert.Equal(t, models.DisputeRoleVendor, dispute.RoleOf(vendor))
+	assert.Equal(t, "", dispute.RoleOf(primitive.NewObjectID()))
+
+	// The buyer waits for the vendor's response window
+	assert.False(t, dispute.CanEscalate(models.DisputeRoleBuyer, now))
+	assert.True(t, dispute.CanEscalate(models.DisputeRoleVendor, now))
+	assert.True(t, dispute.CanEscalate(models.DisputeRoleBuyer, now.Add(2*time.Hour)))
+
+	dispute.Status = models.DisputeStatusVendorResponded
+	assert.True(t, dispute.CanEscalate(models.DisputeRoleBuyer, now))
+
+	dispute.Status = models.DisputeStatusEscalated
+	assert.False(t, dispute.CanEscalate(models.DisputeRoleVendor, now))
+	assert.True(t, dispute.IsActive())
+
+	dispute.Status = models.DisputeStatusResolved
+	assert.False(t, dispute.IsActive())
+}
+
+func TestDisputeInfoAndSplit(t *testing.T) {
+	assert.Equal(t, []string{models.DisputeRoleBuyer}, models.DisputeInfoParties("buyer"))
+	assert.Equal(t, []string{models.DisputeRoleBuyer, models.DisputeRoleVendor}, models.DisputeInfoParties("both"))
+	assert.Nil(t, models.DisputeInfoParties("admin"))
+
+	assert.True(t, models.DisputeResolution{Outcome: models.DisputeOutcomeRefund}.RefundsBuyer())
+	assert.True(t, models.DisputeResolution{Outcome: models.DisputeOutcomeSplit}.RefundsBuyer())
+	assert.False(t, models.DisputeResolution{Outcome: models.DisputeOutcomeRelease}.RefundsBuyer())
+}
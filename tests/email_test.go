@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingProvider struct {
+	sent []utils.EmailMessage
+}
+
+func (p *recordingProvider) Name() string { return "recording" }
+
+func (p *recordingProvider) Send(msg utils.EmailMessage) error {
+	p.sent = append(p.sent, msg)
+	return nil
+}
+
+func TestRenderEmailTemplateEscapesValues(t *testing.T) {
+	subject, body, err := utils.RenderEmailTemplate(utils.EmailTemplateOrderConfirmation, utils.OrderConfirmationEmailData{
+		Name:        "<Ada>",
+		OrderNumber: "ORD-1",
+		Items:       []utils.OrderEmailItem{{Name: "Lamp", Quantity: 2, Subtotal: 19.5}},
+		Total:       19.5,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Your Vendora order ORD-1 is confirmed", subject)
+	assert.Contains(t, body, "&lt;Ada&gt;")
+	assert.Contains(t, body, "$19.50")
+	assert.NotContains(t, body, "Notes from your sellers")
+}
+
+func TestRenderEmailTemplateUnknown(t *testing.T) {
+	_, _, err := utils.RenderEmailTemplate("missing", nil)
+	assert.Error(t, err)
+}
+
+func TestSendTemplateEmailUsesProvider(t *testing.T) {
+	provider := &recordingProvider{}
+	utils.SetEmailProvider(provider)
+	defer utils.SetEmailProvider(nil)
+
+	err := utils.SendTemplateEmail("ada@example.com", utils.EmailTemplatePasswordReset, utils.PasswordResetEmailData{Name: "Ada", Link: "https://example.com/reset"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, provider.sent, 1) {
+		assert.Equal(t, "ada@example.com", provider.sent[0].To)
+		assert.Equal(t, "Reset Your Vendora Password", provider.sent[0].Subject)
+		assert.Contains(t, provider.sent[0].HTML, "https://example.com/reset")
+	}
+}
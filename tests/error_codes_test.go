@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type signupAddress struct {
+	PostalCode string `json:"postalCode" validate:"required"`
+}
+
+type signupInput struct {
+	Email    string        `json:"email" validate:"required,email"`
+	Password string        `json:"password" validate:"min=8"`
+	Age      int           `json:"age" validate:"gte=18"`
+	Role     string        `json:"role" validate:"oneof=buyer vendor"`
+	Address  signupAddress `json:"address"`
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	err := utils.NewValidator().Struct(signupInput{Email: "not-an-email", Password: "short", Age: 12, Role: "admin"})
+	resp := utils.ValidationErrorResponse(err)
+
+	assert.False(t, resp.Success)
+	assert.Equal(t, utils.CodeValidationFailed, resp.Code)
+	assert.Equal(t, []utils.FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "password", Rule: "min", Param: "8", Message: "password must have at least 8 characters or items"},
+		{Field: "age", Rule: "gte", Param: "18", Message: "age must be at least 18"},
+		{Field: "role", Rule: "oneof", Param: "buyer vendor", Message: "role must be one of: buyer, vendor"},
+		{Field: "address.postalCode", Rule: "required", Message: "address.postalCode is required"},
+	}, resp.Fields)
+
+	// Errors from parsing the body don't leak their details
+	resp = utils.ValidationErrorResponse(errors.New("invalid character 'x' looking for beginning of value"))
+	assert.Equal(t, utils.CodeInvalidRequest, resp.Code)
+	assert.Equal(t, "Invalid request body", resp.Error)
+	assert.Empty(t, resp.Fields)
+}
+
+func TestCodedErrorResponseJSON(t *testing.T) {
+	body, err := json.Marshal(utils.CodedErrorResponse(utils.CodeCartItemOutOfStock, "Requested quantity exceeds available stock"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"success":false,"error":"Requested quantity exceeds available stock","code":"CART_ITEM_OUT_OF_STOCK"}`, string(body))
+
+	// Uncoded errors look as they always have
+	body, err = json.Marshal(utils.ErrorResponse("Product not found"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"success":false,"error":"Product not found"}`, string(body))
+}
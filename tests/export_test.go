@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableOf(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type row struct {
+		Name    string       `json:"name"`
+		Total   models.Money `json:"total"`
+		Address *address     `json:"address,omitempty"`
+		Tags    []string     `json:"tags"`
+		Paid    bool         `json:"paid"`
+	}
+	table, err := models.TableOf([]row{
+		{Name: "Lamp", Total: models.FromFloat(19.99), Tags: []string{"home"}},
+		{Name: "Rug", Total: models.FromFloat(90), Address: &address{City: "Lagos"}, Paid: true},
+	})
+	assert.NoError(t, err)
+
+	// Columns first seen in a later row go after the earlier ones
+	assert.Equal(t, []string{"name", "total", "tags", "paid", "address.city"}, table.Columns)
+	assert.Equal(t, []interface{}{"Lamp", json.Number("19.99"), `["home"]`, false, nil}, table.Rows[0])
+	assert.Equal(t, []interface{}{"Rug", json.Number("90.00"), nil, true, "Lagos"}, table.Rows[1])
+
+	_, err = models.TableOf([]int{1, 2})
+	assert.Error(t, err)
+}
+
+func TestExportTableCSV(t *testing.T) {
+	table := models.ExportTable{
+		Columns: []string{"name", "total"},
+		Rows: [][]interface{}{
+			{"Lamp, large", json.Number("19.99")},
+			{"=HYPERLINK(\"http://evil\")", nil},
+		},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, table.Write(&out, models.ExportFormatCSV))
+	assert.Equal(t, "name,total\n\"Lamp, large\",19.99\n\"'=HYPERLINK(\"\"http://evil\"\")\",\n", out.String())
+
+	assert.Error(t, table.Write(&out, "pdf"))
+}
+
+func TestExportTableXLSX(t *testing.T) {
+	table := models.ExportTable{
+		Columns: []string{"name", "total", "paid"},
+		Rows:    [][]interface{}{{"Tea & <biscuits>", json.Number("4.5"), true}},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, table.Write(&out, models.ExportFormatXLSX))
+
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(body)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">Tea &amp; &lt;biscuits&gt;</t>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>4.5</v></c>`)
+	assert.Contains(t, sheet, `<c r="C2" t="b"><v>1</v></c>`)
+}
+
+func TestExportToken(t *testing.T) {
+	utils.SetJWTSecret("test-secret-key-12345")
+	defer utils.SetJWTSecret("")
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	token, err := utils.GenerateExportToken("64b7f0c2a1b2c3d4e5f60718", now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	id, err := utils.VerifyExportToken(token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "64b7f0c2a1b2c3d4e5f60718", id)
+
+	_, err = utils.VerifyExportToken(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, utils.ErrInvalidExportToken)
+	_, err = utils.VerifyExportToken(token+"x", now)
+	assert.ErrorIs(t, err, utils.ErrInvalidExportToken)
+
+	// An unsubscribe token isn't a download link
+	unsubscribe, err := utils.GenerateUnsubscribeToken("64b7f0c2a1b2c3d4e5f60718", models.NotificationDigest)
+	assert.NoError(t, err)
+	_, err = utils.VerifyExportToken(unsubscribe, now)
+	assert.ErrorIs(t, err, utils.ErrInvalidExportToken)
+}
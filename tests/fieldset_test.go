@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseFieldSet(t *testing.T) {
+	fields, err := models.ParseFieldSet("name, price,images,breadcrumbs,name", models.Product{}, "createdAt")
+	assert.NoError(t, err)
+	assert.False(t, fields.All())
+	// Breadcrumbs aren't stored, so they're sent but not fetched
+	assert.Equal(t, bson.M{"_id": 1, "name": 1, "price": 1, "images": 1, "createdAt": 1}, fields.Projection())
+
+	all, err := models.ParseFieldSet("", models.Product{})
+	assert.NoError(t, err)
+	assert.True(t, all.All())
+	assert.Nil(t, all.Projection())
+
+	_, err = models.ParseFieldSet("name,secretSauce", models.Product{})
+	assert.Error(t, err)
+}
+
+func TestFieldSetSelect(t *testing.T) {
+	fields, err := models.ParseFieldSet("name,price", models.Product{}, "createdAt")
+	assert.NoError(t, err)
+
+	id := primitive.NewObjectID()
+	products := []models.Product{{ID: id, Name: "Lamp", Price: 2500, Description: "Warm light", Variants: []models.Variant{{SKU: "L-1"}}}}
+	selected, err := fields.Select(products)
+	assert.NoError(t, err)
+	body, err := json.Marshal(selected)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"id":"`+id.Hex()+`","name":"Lamp","price":25.00}]`, string(body))
+
+	// Every field leaves the list as it is
+	all, _ := models.ParseFieldSet("", models.Product{})
+	same, err := all.Select(products)
+	assert.NoError(t, err)
+	assert.Equal(t, products, same)
+}
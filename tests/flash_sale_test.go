@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlashSaleState(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	sale := models.FlashSale{Quantity: 50, Sold: 20, StartsAt: start, EndsAt: start.Add(2 * time.Hour)}
+
+	assert.Equal(t, models.FlashSaleUpcoming, sale.State(start.Add(-time.Minute)))
+	assert.Equal(t, models.FlashSaleLive, sale.State(start))
+	assert.Equal(t, models.FlashSaleEnded, sale.State(start.Add(2*time.Hour)))
+
+	sale.Sold = 50
+	assert.Equal(t, models.FlashSaleSoldOut, sale.State(start.Add(time.Hour)))
+	assert.Zero(t, sale.Remaining())
+
+	sale.Sold, sale.Cancelled = 0, true
+	assert.Equal(t, models.FlashSaleEnded, sale.State(start.Add(time.Hour)))
+}
+
+func TestFlashSaleView(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	sale := models.FlashSale{Quantity: 10, Sold: 3, StartsAt: start, EndsAt: start.Add(time.Hour)}
+
+	view := sale.View(start.Add(-90 * time.Second))
+	assert.Equal(t, int64(90), view.StartsIn)
+	assert.Equal(t, int64(3690), view.EndsIn)
+	assert.Equal(t, 7, view.Remaining)
+
+	view = sale.View(start.Add(30 * time.Minute))
+	assert.Zero(t, view.StartsIn)
+	assert.Equal(t, int64(1800), view.EndsIn)
+	assert.Equal(t, models.FlashSaleLive, view.State)
+}
+
+func TestFlashSaleCheckLimit(t *testing.T) {
+	sale := models.FlashSale{PerUserLimit: 2}
+	assert.NoError(t, sale.CheckLimit(0, 2))
+	assert.NoError(t, sale.CheckLimit(1, 1))
+	assert.ErrorIs(t, sale.CheckLimit(1, 2), models.ErrFlashSaleUserLimit)
+
+	unlimited := models.FlashSale{}
+	assert.NoError(t, unlimited.CheckLimit(100, 100))
+}
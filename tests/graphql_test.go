@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type graphQLResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func runGraphQL(t *testing.T, router *gin.Engine, body string) (int, graphQLResult) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var result graphQLResult
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	}
+	return w.Code, result
+}
+
+func TestGraphQLSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Parsing checks every field in the schema has a resolver
+	h := handlers.NewGraphQLHandler(nil)
+	router := gin.New()
+	router.POST("/graphql", h.Query)
+
+	// The cart needs a signed-in buyer, and is refused before any lookup
+	code, result := runGraphQL(t, router, `{"query":"{ cart { total items { quantity product { name } } } }"}`)
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Contains(t, result.Errors[0].Message, "sign in")
+	}
+
+	// Malformed IDs are rejected without a lookup
+	_, result = runGraphQL(t, router, `{"query":"query($id: ID!) { product(id: $id) { name } }","variables":{"id":"nope"}}`)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Contains(t, result.Errors[0].Message, "invalid ID")
+	}
+
+	// Unknown fields fail validation
+	_, result = runGraphQL(t, router, `{"query":"{ product(id: \"1\") { costPrice } }"}`)
+	assert.NotEmpty(t, result.Errors)
+
+	// Deeply nested queries are refused
+	nested := "{ categories { " + strings.Repeat("children { ", 10) + "name" + strings.Repeat(" }", 10) + " } }"
+	body, _ := json.Marshal(map[string]string{"query": nested})
+	_, result = runGraphQL(t, router, string(body))
+	assert.NotEmpty(t, result.Errors)
+
+	code, _ = runGraphQL(t, router, `{"variables":{}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
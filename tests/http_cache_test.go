@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func cachedCatalog(body *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Gzip(gzip.DefaultCompression))
+	router.GET("/catalog", middleware.HTTPCache(time.Minute), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"items": *body})
+	})
+	return router
+}
+
+func TestHTTPCacheRevalidates(t *testing.T) {
+	body := "first"
+	router := cachedCatalog(&body)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/catalog", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	lastModified := w.Header().Get("Last-Modified")
+	assert.NotEmpty(t, lastModified)
+
+	req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/catalog", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// Changed content gets a new ETag and the full body
+	body = "second"
+	req = httptest.NewRequest(http.MethodGet, "/catalog", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "second")
+}
+
+func TestGzipCompressesLargeResponses(t *testing.T) {
+	body := strings.Repeat("catalog ", 500)
+	router := cachedCatalog(&body)
+
+	req := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Less(t, w.Body.Len(), len(body))
+	gz, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	plain, _ := io.ReadAll(gz)
+	assert.Contains(t, string(plain), body)
+
+	// Too small to be worth it
+	body = "small"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Body.String(), "small")
+}
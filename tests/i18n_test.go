@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	assert.Equal(t, "fr", i18n.Negotiate("fr-CA,fr;q=0.9,en;q=0.8"))
+	assert.Equal(t, "es", i18n.Negotiate("de;q=0.9, es;q=0.5"))
+	assert.Equal(t, "en", i18n.Negotiate("en;q=0.4, es;q=0"))
+	assert.Equal(t, "en", i18n.Negotiate("de"))
+	assert.Equal(t, "en", i18n.Negotiate(""))
+	assert.Equal(t, []string{"en", "es", "fr"}, i18n.Supported())
+}
+
+func TestTranslateBody(t *testing.T) {
+	resp := utils.ValidationErrorResponse(utils.NewValidator().Struct(signupInput{Email: "nope", Password: "short", Age: 12, Role: "admin"}))
+	body, _ := json.Marshal(resp)
+
+	var translated utils.Response
+	assert.NoError(t, json.Unmarshal(i18n.TranslateBody("fr", body), &translated))
+	assert.Equal(t, "La validation a échoué", translated.Error)
+	assert.Equal(t, utils.CodeValidationFailed, translated.Code)
+	messages := map[string]string{}
+	for _, fe := range translated.Fields {
+		messages[fe.Field] = fe.Message
+	}
+	assert.Equal(t, "email doit être une adresse e-mail valide", messages["email"])
+	assert.Equal(t, "password doit contenir au moins 8 caractères ou éléments", messages["password"])
+	assert.Equal(t, "age doit être au moins 18", messages["age"])
+
+	// Unknown messages fall back to their code's, then stay in English
+	coded, _ := json.Marshal(utils.CodedErrorResponse(utils.CodeCouponUsedUp, "This coupon has been fully redeemed"))
+	assert.Contains(t, string(i18n.TranslateBody("es", coded)), "Este cupón se ha agotado")
+	plain := []byte(`{"success":false,"error":"Something odd"}`)
+	assert.Equal(t, string(plain), string(i18n.TranslateBody("es", plain)))
+	assert.Equal(t, string(body), string(i18n.TranslateBody("en", body)))
+}
+
+func TestLocalizeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Localize())
+	router.GET("/product", func(c *gin.Context) {
+		locale := i18n.FromContext(c.Request.Context())
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Product not found", "locale": locale})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/product", nil)
+	req.Header.Set("Accept-Language", "es-MX")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Producto no encontrado", body["error"])
+	assert.Equal(t, "es", body["locale"])
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/product", nil))
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Body.String(), `"Product not found"`)
+}
+
+func TestLocalizeContent(t *testing.T) {
+	product := models.Product{
+		Name:        "Rain jacket",
+		Description: "Keeps you dry",
+		Translations: models.Translations{
+			"fr": {Name: "Veste de pluie"},
+		},
+	}
+	fr := product
+	fr.Localize("fr")
+	assert.Equal(t, "Veste de pluie", fr.Name)
+	assert.Equal(t, "Keeps you dry", fr.Description) // Not translated
+	assert.Nil(t, fr.Translations)
+
+	es := product
+	es.Localize("es")
+	assert.Equal(t, "Rain jacket", es.Name)
+
+	category := models.Category{Name: "Outerwear", Translations: models.Translations{"es": {Name: "Abrigos", Description: "Para el frío"}}}
+	category.Localize("es")
+	assert.Equal(t, "Abrigos", category.Name)
+	assert.Equal(t, "Para el frío", category.Description)
+}
+
+func TestTranslationsValidation(t *testing.T) {
+	v := utils.NewValidator()
+	ok := models.UpdateCategoryInput{Translations: &models.Translations{"fr": {Name: "Vestes"}}}
+	assert.NoError(t, v.Struct(&ok))
+
+	for _, locale := range []string{"de", "en"} {
+		bad := models.UpdateCategoryInput{Translations: &models.Translations{locale: {Name: "Jacken"}}}
+		resp := utils.ValidationErrorResponse(v.Struct(&bad))
+		if assert.Equal(t, 1, len(resp.Fields), locale) {
+			assert.Equal(t, "locale", resp.Fields[0].Rule)
+			assert.Equal(t, "translations["+locale+"] must be one of the supported locales: es, fr", resp.Fields[0].Message)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[record.UserID+"|"+record.Key]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+	record.ID = primitive.NewObjectID()
+	copied := *record
+	s.records[record.UserID+"|"+record.Key] = &copied
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) find(id primitive.ObjectID) string {
+	for k, r := range s.records {
+		if r.ID == id {
+			return k
+		}
+	}
+	return ""
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, id primitive.ObjectID, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.records[s.find(id)]
+	r.Completed, r.Status, r.ContentType, r.Body = true, status, contentType, body
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, s.find(id))
+	return nil
+}
+
+func idempotentRouter(store middleware.IdempotencyStore, calls *int, status *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userId", c.GetHeader("X-User")) })
+	router.POST("/orders", middleware.Idempotency(store), func(c *gin.Context) {
+		*calls++
+		c.JSON(*status, gin.H{"order": *calls})
+	})
+	return router
+}
+
+func placeOrder(router *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*models.IdempotencyRecord{}}
+	calls, status := 0, http.StatusCreated
+	router := idempotentRouter(store, &calls, &status)
+
+	first := placeOrder(router, "u1", "abc", `{"items":1}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	retry := placeOrder(router, "u1", "abc", `{"items":1}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+
+	// The same key with a different body is a client bug
+	assert.Equal(t, http.StatusUnprocessableEntity, placeOrder(router, "u1", "abc", `{"items":2}`).Code)
+
+	// Keys belong to a user, and requests without one always run
+	assert.Equal(t, http.StatusCreated, placeOrder(router, "u2", "abc", `{"items":1}`).Code)
+	assert.Equal(t, http.StatusCreated, placeOrder(router, "u1", "", `{"items":1}`).Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotencyReleasesOnServerError(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*models.IdempotencyRecord{}}
+	calls, status := 0, http.StatusInternalServerError
+	router := idempotentRouter(store, &calls, &status)
+
+	assert.Equal(t, http.StatusInternalServerError, placeOrder(router, "u1", "abc", `{}`).Code)
+	status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, placeOrder(router, "u1", "abc", `{}`).Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*models.IdempotencyRecord{}}
+	calls, status := 0, http.StatusCreated
+	router := idempotentRouter(store, &calls, &status)
+
+	assert.Equal(t, http.StatusCreated, placeOrder(router, "u1", "abc", `{}`).Code)
+	record := store.records["u1|abc"]
+	record.Completed = false
+
+	w := placeOrder(router, "u1", "abc", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// A request that held its key too long is taken to have died
+	record.CreatedAt = time.Now().Add(-time.Hour)
+	assert.Equal(t, http.StatusCreated, placeOrder(router, "u1", "abc", `{}`).Code)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStockOnHand(t *testing.T) {
+	assert.Equal(t, 7, models.Product{Stock: 7}.StockOnHand())
+	assert.Equal(t, 5, models.Product{Stock: 7, HasVariants: true, Variants: []models.Variant{{Stock: 2}, {Stock: 3}}}.StockOnHand())
+}
+
+func TestBuildInventoryReport(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 30)
+	listed := from.AddDate(0, -2, 0)
+	lastSold := from.AddDate(0, -1, 0)
+
+	lamp := models.Product{ID: primitive.NewObjectID(), Name: "Lamp", Status: models.ProductStatusActive, Stock: 30, Price: models.FromFloat(20), CostPrice: models.FromFloat(8), CreatedAt: listed}
+	rug := models.Product{ID: primitive.NewObjectID(), Name: "Rug", Status: models.ProductStatusActive, Stock: 10, Price: models.FromFloat(90), CreatedAt: listed}
+	vase := models.Product{ID: primitive.NewObjectID(), Name: "Vase", Status: models.ProductStatusActive, Stock: 4, Price: models.FromFloat(15), CreatedAt: from.AddDate(0, 0, 10)}
+	mug := models.Product{ID: primitive.NewObjectID(), Name: "Mug", Status: models.ProductStatusArchived, Stock: 50, Price: models.FromFloat(5), CreatedAt: listed}
+	sales := []models.InventorySales{
+		{ProductID: lamp.ID, UnitsSold: 90, Revenue: models.FromFloat(1800), LastSoldAt: &to},
+		{ProductID: rug.ID, LastSoldAt: &lastSold},
+	}
+	report := models.BuildInventoryReport([]models.Product{lamp, rug, vase, mug}, sales, from, to)
+
+	assert.Equal(t, 30.0, report.Days)
+	assert.Len(t, report.Products, 4)
+	// Slowest first, the most stock value first among equals
+	assert.Equal(t, "Rug", report.Products[0].Name)
+	assert.Equal(t, "Lamp", report.Products[3].Name)
+
+	item := report.Products[3]
+	assert.Equal(t, 75.0, item.SellThroughRate)
+	assert.Equal(t, 3.0, item.DailySales)
+	assert.Equal(t, 10.0, *item.DaysOfCover)
+	assert.Equal(t, models.FromFloat(240), item.StockValue) // At cost
+	assert.False(t, item.DeadStock)
+
+	// Listed after the range began, or not for sale, isn't dead stock
+	assert.Len(t, report.DeadStock, 1)
+	dead := report.DeadStock[0]
+	assert.Equal(t, "Rug", dead.Name)
+	assert.Nil(t, dead.DaysOfCover)
+	assert.Equal(t, &lastSold, dead.LastSoldAt)
+	assert.Equal(t, models.FromFloat(900), dead.StockValue) // At price, no cost set
+
+	assert.Equal(t, models.InventoryTotals{
+		Products:        4,
+		Stock:           94,
+		StockValue:      models.FromFloat(240 + 900 + 60 + 250),
+		UnitsSold:       90,
+		SellThroughRate: 48.91,
+		DeadStock:       1,
+		DeadStockValue:  models.FromFloat(900),
+	}, report.Totals)
+}
@@ -1,7 +1,6 @@
 package tests
 
 import (
-	"os"
 	"testing"
 	"time"
 
@@ -10,8 +9,8 @@ import (
 )
 
 func TestGenerateToken(t *testing.T) {
-	os.Setenv("JWT_SECRET", "test-secret-key-12345")
-	defer os.Unsetenv("JWT_SECRET")
+	utils.SetJWTSecret("test-secret-key-12345")
+	defer utils.SetJWTSecret("")
 	userId := "0283721"
 	userRole := "vendor"
 	duration := time.Duration(24 * time.Hour)
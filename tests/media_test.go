@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMediaContextAllows(t *testing.T) {
+	m := services.MediaMessageAttachment
+
+	assert.NoError(t, m.Allows("image/png", 1<<20))
+	assert.NoError(t, m.Allows("application/pdf", 1<<20))
+	assert.Equal(t, services.ErrMediaType, m.Allows("application/zip", 1<<20))
+	assert.Equal(t, services.ErrMediaTooLarge, m.Allows("image/png", m.MaxSize+1))
+	assert.Equal(t, "GIF, JPG, PDF, PNG, WEBP up to 10MB", m.Describe())
+}
+
+func TestMediaContextCheckAttachments(t *testing.T) {
+	owner := primitive.NewObjectID()
+	m := services.MediaDisputeEvidence
+	mine := "https://res.cloudinary.com/demo/image/upload/v1/" + m.FolderFor(owner) + "/a.jpg"
+
+	assert.NoError(t, m.CheckAttachments(owner, nil))
+	assert.NoError(t, m.CheckAttachments(owner, []models.Attachment{{URL: mine, ContentType: "image/jpeg"}}))
+
+	// Someone else's upload, another context's folder, or any other link
+	other := "https://res.cloudinary.com/demo/image/upload/v1/" + m.FolderFor(primitive.NewObjectID()) + "/a.jpg"
+	messages := "https://res.cloudinary.com/demo/image/upload/v1/" + services.MediaMessageAttachment.FolderFor(owner) + "/a.jpg"
+	for _, link := range []string{other, messages, "https://example.com/" + m.FolderFor(owner) + "/a.jpg"} {
+		assert.Equal(t, services.ErrMediaNotUploads, m.CheckAttachments(owner, []models.Attachment{{URL: link, ContentType: "image/jpeg"}}))
+	}
+	assert.Equal(t, services.ErrMediaType, m.CheckAttachments(owner, []models.Attachment{{URL: mine, ContentType: "text/html"}}))
+}
+
+// fakeMediaStore records what the media service stores and deletes.
+type fakeMediaStore struct {
+	uploaded []string
+	deleted  []services.MediaAsset
+}
+
+func (s *fakeMediaStore) Upload(ctx context.Context, file io.Reader, folder, publicID string) (string, error) {
+	s.uploaded = append(s.uploaded, folder+"/"+publicID)
+	return "https://res.cloudinary.com/demo/image/upload/v1/" + folder + "/" + publicID + ".png", nil
+}
+
+func (s *fakeMediaStore) Delete(ctx context.Context, asset services.MediaAsset) error {
+	s.deleted = append(s.deleted, asset)
+	return nil
+}
+
+type memoryFile struct{ *bytes.Reader }
+
+func (memoryFile) Close() error { return nil }
+
+func TestMediaServiceUpload(t *testing.T) {
+	store := &fakeMediaStore{}
+	media := services.NewMediaService(store)
+	owner := primitive.NewObjectID()
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	header := &multipart.FileHeader{Filename: "../logo.png", Size: int64(len(png))}
+
+	logo, err := media.Upload(context.Background(), services.MediaStoreLogo, owner, memoryFile{bytes.NewReader(png)}, header)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", logo.ContentType)
+	assert.Equal(t, "logo.png", logo.Name)
+	// A store has one logo, named after the store's owner
+	assert.Equal(t, []string{"vendora/stores/logos/" + owner.Hex()}, store.uploaded)
+
+	// The type comes from the content, not the file name
+	_, err = media.Upload(context.Background(), services.MediaStoreLogo, owner, memoryFile{bytes.NewReader([]byte("%PDF-1.4 ..."))}, header)
+	assert.Equal(t, services.ErrMediaType, err)
+	assert.Len(t, store.uploaded, 1)
+
+	_, err = services.NewMediaService(nil).Upload(context.Background(), services.MediaStoreLogo, owner, memoryFile{bytes.NewReader(png)}, header)
+	assert.Equal(t, services.ErrMediaNotConfigured, err)
+}
+
+func TestMediaServiceDeleteAndTransform(t *testing.T) {
+	store := &fakeMediaStore{}
+	media := services.NewMediaService(store)
+	image := "https://res.cloudinary.com/demo/image/upload/v1712345678/vendora/products/abc/photo.jpg"
+
+	assert.NoError(t, media.Delete(context.Background(), image))
+	assert.NoError(t, media.Delete(context.Background(), "https://res.cloudinary.com/demo/raw/upload/vendora/disputes/abc/report.pdf"))
+	assert.Equal(t, []services.MediaAsset{
+		{PublicID: "vendora/products/abc/photo", ResourceType: "image"},
+		{PublicID: "vendora/disputes/abc/report.pdf", ResourceType: "raw"},
+	}, store.deleted)
+	assert.Equal(t, services.ErrMediaNotStored, media.Delete(context.Background(), "https://example.com/photo.jpg"))
+
+	thumb := media.Transform(image, services.MediaThumbnail)
+	assert.Equal(t, "https://res.cloudinary.com/demo/image/upload/c_fill,w_300,h_300,q_auto,f_auto/v1712345678/vendora/products/abc/photo.jpg", thumb)
+	// A transformed URL still points at the same file
+	asset, ok := services.ParseMediaURL(thumb)
+	assert.True(t, ok)
+	assert.Equal(t, "vendora/products/abc/photo", asset.PublicID)
+	assert.Equal(t, "https://example.com/photo.jpg", media.Transform("https://example.com/photo.jpg", services.MediaThumbnail))
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMerchantFeedItem(t *testing.T) {
+	p := models.Product{
+		ID:          primitive.NewObjectID(),
+		Name:        "Trail shoe",
+		Description: "Grippy\tsole,\nlight upper",
+		Brand:       "Stride",
+		SKU:         "TS-1",
+		Images:      []string{"https://img/1.jpg", "https://img/2.jpg"},
+		Price:       8000,
+		SalePrice:   6450,
+		HasVariants: true,
+		Variants:    []models.Variant{{Stock: 0}, {Stock: 3}},
+		Dimensions:  models.Dimensions{Weight: 0.8},
+	}
+	crumbs := []models.CategoryCrumb{{Name: "Fashion"}, {Name: "Shoes"}}
+	item := models.NewMerchantFeedItem(p, "https://shop/products/"+p.ID.Hex(), "USD", crumbs)
+
+	assert.Equal(t, models.FeedInStock, item.Availability)
+	assert.Equal(t, "80.00 USD", item.Price)
+	assert.Equal(t, "64.50 USD", item.SalePrice)
+	assert.Equal(t, "https://img/1.jpg", item.ImageLink)
+	assert.Equal(t, []string{"https://img/2.jpg"}, item.AdditionalImageLinks)
+	assert.Equal(t, "Fashion > Shoes", item.ProductType)
+	assert.Equal(t, "0.80 kg", item.ShippingWeight)
+	assert.Equal(t, "", item.IdentifierExists) // Brand and MPN identify it
+
+	row := item.TSVRow()
+	assert.Equal(t, len(models.MerchantFeedColumns), len(row))
+	assert.Equal(t, "Grippy sole, light upper", row[2])
+	for _, value := range row {
+		assert.False(t, strings.ContainsAny(value, "\t\n"))
+	}
+
+	out, err := xml.Marshal(item)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "<g:price>80.00 USD</g:price>")
+	assert.NotContains(t, string(out), "g:gtin")
+}
+
+func TestMerchantFeedAvailability(t *testing.T) {
+	p := models.Product{ID: primitive.NewObjectID(), Name: "Mug", Price: 1000, Stock: 0}
+	item := models.NewMerchantFeedItem(p, "", "EUR", nil)
+	assert.Equal(t, models.FeedOutOfStock, item.Availability)
+	assert.Equal(t, "", item.SalePrice)
+	assert.Equal(t, "no", item.IdentifierExists)
+	assert.Equal(t, "Mug", item.Description) // Falls back to the title
+
+	p.AllowBackorder = true
+	assert.Equal(t, models.FeedBackorder, models.NewMerchantFeedItem(p, "", "EUR", nil).Availability)
+
+	p.Stock = 2
+	p.SalePrice = 1200 // Not below the price, so not a sale
+	item = models.NewMerchantFeedItem(p, "", "EUR", nil)
+	assert.Equal(t, models.FeedInStock, item.Availability)
+	assert.Equal(t, "", item.SalePrice)
+}
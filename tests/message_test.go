@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestConversationParticipants(t *testing.T) {
+	buyer, vendor := primitive.NewObjectID(), primitive.NewObjectID()
+	conversation := models.Conversation{BuyerID: buyer, VendorID: vendor, BuyerUnread: 2, VendorUnread: 5}
+
+	assert.True(t, conversation.HasParticipant(buyer))
+	assert.True(t, conversation.HasParticipant(vendor))
+	assert.False(t, conversation.HasParticipant(primitive.NewObjectID()))
+
+	assert.Equal(t, vendor, conversation.Recipient(buyer))
+	assert.Equal(t, buyer, conversation.Recipient(vendor))
+	assert.Equal(t, 2, conversation.UnreadFor(buyer))
+	assert.Equal(t, 5, conversation.UnreadFor(vendor))
+}
+
+func TestMessagePreviewText(t *testing.T) {
+	assert.Equal(t, "Is this in stock?", models.MessagePreviewText("  Is this\n in   stock? "))
+
+	preview := models.MessagePreviewText(strings.Repeat("é", 200))
+	assert.Equal(t, 80, len([]rune(preview)))
+	assert.True(t, strings.HasSuffix(preview, "…"))
+}
+
+func TestMessagePreview(t *testing.T) {
+	photo := models.Attachment{URL: "https://res.cloudinary.com/x.jpg", ContentType: "image/jpeg"}
+	pdf := models.Attachment{URL: "https://res.cloudinary.com/x.pdf", ContentType: "application/pdf"}
+
+	assert.Equal(t, "Here it is", models.Message{Body: "Here it is", Attachments: []models.Attachment{photo}}.Preview())
+	assert.Equal(t, "Sent a photo", models.Message{Attachments: []models.Attachment{photo}}.Preview())
+	assert.Equal(t, "Sent a file", models.Message{Attachments: []models.Attachment{pdf}}.Preview())
+	assert.Equal(t, "Sent 2 attachments", models.Message{Attachments: []models.Attachment{photo, pdf}}.Preview())
+}
+
+func TestAutoReplyActiveAt(t *testing.T) {
+	now := time.Now()
+	var unset *models.AutoReply
+	assert.False(t, unset.ActiveAt(now))
+
+	reply := &models.AutoReply{Enabled: true, Message: "Away until Monday"}
+	assert.True(t, reply.ActiveAt(now))
+
+	reply.Enabled = false
+	assert.False(t, reply.ActiveAt(now))
+
+	// Limited to a window
+	reply.Enabled = true
+	starts, ends := now.Add(time.Hour), now.Add(48*time.Hour)
+	reply.StartsAt, reply.EndsAt = &starts, &ends
+	assert.False(t, reply.ActiveAt(now))
+	assert.True(t, reply.ActiveAt(now.Add(2*time.Hour)))
+	assert.False(t, reply.ActiveAt(ends))
+}
@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchModerationKeywords(t *testing.T) {
+	keywords := []string{"meth", "fake id", "Replica", "replica"}
+
+	hits := models.MatchModerationKeywords(keywords, "Designer REPLICA handbag", "Ships with a fake ID holder")
+	assert.ElementsMatch(t, []string{"replica", "fake id"}, hits)
+
+	// Whole words only
+	assert.Empty(t, models.MatchModerationKeywords(keywords, "A new method for brewing coffee"))
+	assert.Empty(t, models.MatchModerationKeywords(keywords))
+}
+
+func TestListingReportReasonsAreReportCategories(t *testing.T) {
+	// Listing reports are filed as content reports, so every reason must be
+	// a category the report queue knows
+	for _, reason := range models.ListingReportReasons {
+		assert.Contains(t, models.ReportCategories, reason)
+	}
+}
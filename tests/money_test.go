@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	// Adding in floats drifts; adding minor units doesn't
+	a, b := 0.1, 0.2
+	assert.NotEqual(t, 0.3, a+b)
+	assert.Equal(t, models.FromFloat(0.3), models.FromFloat(0.1)+models.FromFloat(0.2))
+
+	assert.Equal(t, models.Money(1999), models.FromFloat(19.99))
+	assert.Equal(t, models.Money(-50), models.FromFloat(-0.5))
+	assert.Equal(t, int64(5997), models.Money(1999).Mul(3).Cents())
+	assert.Equal(t, models.Money(300), models.Money(1999).Percent(15))
+	assert.Equal(t, models.Money(333), models.Money(1000).Share(1, 3))
+	assert.Zero(t, models.Money(1000).Share(1, 0))
+
+	assert.Equal(t, "19.99", models.Money(1999).String())
+	assert.Equal(t, "0.05", models.Money(5).String())
+	assert.Equal(t, "-1.50", models.Money(-150).String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var body struct {
+		Price  models.Money  `json:"price"`
+		Refund *models.Money `json:"refund"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"price": 19.99, "refund": 0.125}`), &body))
+	assert.Equal(t, models.Money(1999), body.Price)
+	assert.Equal(t, models.Money(13), *body.Refund, "rounded to the nearest minor unit")
+
+	out, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"price":19.99,"refund":0.13}`, string(out))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": "19.99"}`), &body))
+	assert.Error(t, json.Unmarshal([]byte(`{"price": true}`), &body))
+}
+
+func TestMoneyBSON(t *testing.T) {
+	type doc struct {
+		Price models.Money `bson:"price"`
+	}
+	raw, err := bson.Marshal(doc{Price: 1999})
+	assert.NoError(t, err)
+	value := bson.Raw(raw).Lookup("price")
+	assert.Equal(t, bsontype.Int64, value.Type)
+	assert.Equal(t, int64(1999), value.Int64())
+
+	// Documents from before the migration hold major units as doubles
+	legacy, _ := primitive.ParseDecimal128("12.345")
+	for stored, want := range map[interface{}]models.Money{
+		19.99:      1999,
+		int32(250): 250,
+		int64(250): 250,
+		legacy:     1235,
+	} {
+		raw, err := bson.Marshal(bson.M{"price": stored})
+		assert.NoError(t, err)
+		var d doc
+		assert.NoError(t, bson.Unmarshal(raw, &d))
+		assert.Equal(t, want, d.Price, "%T %v", stored, stored)
+	}
+}
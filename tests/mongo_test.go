@@ -1,15 +1,14 @@
 package tests
 
 import (
-	"os"
 	"testing"
 
+	"github.com/developia-II/ecommerce-backend/internal/config"
 	"github.com/developia-II/ecommerce-backend/internal/database"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestConnectToDB_InvalidURI(t *testing.T) {
-	os.Setenv("MONGO_URI", "invalid_uri")
-	_, err := database.ConnectToDB()
+	_, err := database.ConnectToDB(config.MongoConfig{URI: "invalid_uri", Database: "vendora"})
 	assert.Error(t, err)
 }
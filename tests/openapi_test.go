@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/docs"
+	"github.com/developia-II/ecommerce-backend/internal/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPISpecIsUpToDate(t *testing.T) {
+	spec, err := openapi.Generate("..")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, bytes.Equal(spec, docs.Spec), "internal/docs/openapi.json is stale; run go generate ./internal/docs")
+
+	var doc struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(spec, &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	create := doc.Paths["/api/v1/products"]["post"]
+	if assert.NotNil(t, create) {
+		assert.Contains(t, create, "requestBody")
+		assert.Contains(t, create, "security")
+	}
+	assert.Contains(t, doc.Paths, "/api/v1/products/{id}")
+	assert.NotContains(t, doc.Paths["/health"]["get"], "security")
+}
+
+func TestDocsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	docs.Register(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "swagger-ui")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, docs.Spec, w.Body.Bytes())
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func statusEvent(seq int, from, to models.OrderStatus, at time.Time) models.OrderStatusEvent {
+	e := models.NewOrderStatusEvent(primitive.NilObjectID, seq, from, to, models.OrderStatusActor{Role: models.OrderActorSystem})
+	e.At = at
+	return e
+}
+
+func TestDeriveOrderStatus(t *testing.T) {
+	assert.Equal(t, models.StatusPending, models.DeriveOrderStatus(nil))
+
+	placed := time.Now().Add(-72 * time.Hour)
+	history := []models.OrderStatusEvent{
+		statusEvent(3, models.StatusPaid, models.StatusShipped, placed.Add(26*time.Hour)),
+		statusEvent(1, "", models.StatusPending, placed),
+		statusEvent(2, models.StatusPending, models.StatusPaid, placed.Add(2*time.Hour)),
+	}
+	assert.Equal(t, models.StatusShipped, models.DeriveOrderStatus(history))
+}
+
+func TestOrderStatusTimeline(t *testing.T) {
+	placed := time.Now().Add(-72 * time.Hour)
+	timeline := models.NewOrderStatusTimeline([]models.OrderStatusEvent{
+		statusEvent(3, models.StatusPaid, models.StatusShipped, placed.Add(26*time.Hour)),
+		statusEvent(1, "", models.StatusPending, placed),
+		statusEvent(2, models.StatusPending, models.StatusPaid, placed.Add(2*time.Hour)),
+	})
+
+	assert.Equal(t, models.StatusShipped, timeline.Status)
+	assert.Equal(t, []int{1, 2, 3}, []int{timeline.Events[0].Sequence, timeline.Events[1].Sequence, timeline.Events[2].Sequence})
+	if assert.NotNil(t, timeline.Timings.HoursToPay) {
+		assert.InDelta(t, 2, *timeline.Timings.HoursToPay, 0.001)
+	}
+	if assert.NotNil(t, timeline.Timings.HoursToShip) {
+		assert.InDelta(t, 24, *timeline.Timings.HoursToShip, 0.001)
+	}
+	assert.Nil(t, timeline.Timings.HoursToDeliver, "not delivered yet")
+}
+
+func TestOrderStatusActor(t *testing.T) {
+	vendor := primitive.NewObjectID()
+	actor := models.ActingUser(models.OrderActorVendor, vendor)
+	actor.Metadata = map[string]string{"trackingNumber": "1Z999"}
+
+	e := models.NewOrderStatusEvent(primitive.NewObjectID(), 4, models.StatusConfirmed, models.StatusShipped, actor)
+	assert.Equal(t, models.OrderActorVendor, e.ActorRole)
+	assert.Equal(t, vendor, *e.ActorID)
+	assert.Equal(t, "1Z999", e.Metadata["trackingNumber"])
+}
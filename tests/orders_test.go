@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVendorRefundShares(t *testing.T) {
+	vendorA, vendorB := primitive.NewObjectID(), primitive.NewObjectID()
+	order := models.Order{
+		Items: []models.OrderItem{
+			{VendorID: vendorA, Subtotal: 6000},
+			{VendorID: vendorB, Subtotal: 3000},
+			{VendorID: vendorA, Subtotal: 1000},
+		},
+		Total: 10000, // includes shipping and tax
+	}
+
+	// A full refund takes back exactly what each vendor was credited
+	shares := models.VendorRefundShares(order, 10000)
+	assert.Equal(t, models.Money(7000), shares[vendorA])
+	assert.Equal(t, models.Money(3000), shares[vendorB])
+
+	shares = models.VendorRefundShares(order, 2500)
+	assert.Equal(t, models.Money(1750), shares[vendorA])
+	assert.Equal(t, models.Money(750), shares[vendorB])
+
+	assert.Empty(t, models.VendorRefundShares(order, 0))
+}
+
+func TestVendorRefundSharesRounding(t *testing.T) {
+	vendorA, vendorB, vendorC := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	order := models.Order{
+		Items: []models.OrderItem{
+			{VendorID: vendorA, Subtotal: 1000},
+			{VendorID: vendorB, Subtotal: 1000},
+			{VendorID: vendorC, Subtotal: 1000},
+		},
+		Total: 3000,
+	}
+
+	// Thirds don't round evenly; the last vendor takes the odd cent so the
+	// clawbacks still add up to the refund
+	shares := models.VendorRefundShares(order, 1000)
+	assert.Equal(t, models.Money(333), shares[vendorA])
+	assert.Equal(t, models.Money(333), shares[vendorB])
+	assert.Equal(t, models.Money(334), shares[vendorC])
+
+	// With shipping on the order the vendors only give back their part
+	order.Total = 4000
+	shares = models.VendorRefundShares(order, 4000)
+	assert.Equal(t, models.Money(1000), shares[vendorA])
+	assert.Equal(t, models.Money(1000), shares[vendorB])
+	assert.Equal(t, models.Money(1000), shares[vendorC])
+}
+
+func TestRefundableAmount(t *testing.T) {
+	order := models.Order{Total: 4999, RefundedAmount: 2000}
+	assert.Equal(t, models.Money(2999), order.RefundableAmount())
+
+	order.RefundedAmount = 4999
+	assert.Equal(t, models.Money(0), order.RefundableAmount())
+}
+
+func TestOrderTrackedStatus(t *testing.T) {
+	order := models.Order{Status: models.StatusPaid, TrackingStatus: models.TrackingInTransit}
+	status, ok := order.TrackedStatus()
+	assert.True(t, ok)
+	assert.Equal(t, models.StatusShipped, status)
+
+	// Never backwards
+	order.Status = models.StatusDelivered
+	_, ok = order.TrackedStatus()
+	assert.False(t, ok)
+
+	// Cancelled orders are left alone
+	order.Status = models.StatusCancelled
+	_, ok = order.TrackedStatus()
+	assert.False(t, ok)
+
+	// With several parcels the order follows the slowest
+	order = models.Order{Status: models.StatusShipped, Labels: []models.ShippingLabel{
+		{TrackingStatus: models.TrackingDelivered},
+		{TrackingStatus: models.TrackingOutForDelivery},
+	}}
+	status, ok = order.TrackedStatus()
+	assert.True(t, ok)
+	assert.Equal(t, models.StatusOutForDelivery, status)
+
+	order.Labels[1].TrackingStatus = models.TrackingPreTransit
+	_, ok = order.TrackedStatus()
+	assert.False(t, ok)
+}
+
+func TestOrderPickups(t *testing.T) {
+	vendor, courier := primitive.NewObjectID(), primitive.NewObjectID()
+	order := models.Order{
+		Status:         models.StatusShipped,
+		TrackingStatus: models.TrackingDelivered,
+		Shipments: []models.Shipment{
+			{VendorIDs: []primitive.ObjectID{vendor}, Method: models.ShippingMethodPickup},
+			{VendorIDs: []primitive.ObjectID{courier}},
+		},
+	}
+	assert.Equal(t, 0, order.VendorPickup(vendor))
+	assert.Equal(t, -1, order.VendorPickup(courier))
+	assert.False(t, order.PickupOnly())
+
+	// The parcel arriving doesn't deliver what hasn't been collected
+	_, ok := order.TrackedStatus()
+	assert.False(t, ok)
+
+	now := time.Now()
+	order.Shipments[0].CollectedAt = &now
+	status, ok := order.TrackedStatus()
+	assert.True(t, ok)
+	assert.Equal(t, models.StatusDelivered, status)
+}
+
+func TestMergeTimeline(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	timeline := []models.OrderTimelineEvent{
+		{TrackingNumber: "A", Status: models.TrackingPreTransit, At: day},
+		{TrackingNumber: "B", Status: models.TrackingInTransit, At: day.Add(time.Hour)},
+	}
+	merged := models.MergeTimeline(timeline, "A", []models.OrderTimelineEvent{
+		{TrackingNumber: "A", Status: models.TrackingPreTransit, At: day},
+		{TrackingNumber: "A", Status: models.TrackingInTransit, At: day.Add(2 * time.Hour)},
+	})
+	assert.Len(t, merged, 3)
+	assert.Equal(t, "B", merged[1].TrackingNumber)
+	assert.Equal(t, models.TrackingInTransit, merged[2].Status)
+}
+
+func TestOrderItemPromotionAccounting(t *testing.T) {
+	// On sale at 8 from 10, with a vendor coupon taking 3 more
+	item := models.OrderItem{
+		Price: 800, ListPrice: 1000, Quantity: 2, Subtotal: 1600,
+		Discount: 300, DiscountFundedBy: models.FundedByVendor,
+	}
+	assert.Equal(t, models.Money(1300), item.VendorAmount())
+	assert.Equal(t, models.Money(700), item.PromoCost())
+
+	// The platform's coupon comes out of the platform's take, not the vendor's
+	item.DiscountFundedBy = models.FundedByPlatform
+	assert.Equal(t, models.Money(1600), item.VendorAmount())
+	assert.Equal(t, models.Money(400), item.PromoCost())
+
+	plain := models.OrderItem{Price: 1000, Quantity: 1, Subtotal: 1000}
+	assert.Equal(t, models.Money(1000), plain.VendorAmount())
+	assert.Zero(t, plain.PromoCost())
+}
+
+func TestVendorRefundSharesVendorFundedDiscount(t *testing.T) {
+	vendorA, vendorB := primitive.NewObjectID(), primitive.NewObjectID()
+	order := models.Order{
+		Items: []models.OrderItem{
+			{VendorID: vendorA, Subtotal: 6000, Discount: 1000, DiscountFundedBy: models.FundedByVendor},
+			{VendorID: vendorB, Subtotal: 4000},
+		},
+		Total: 9000,
+	}
+
+	shares := models.VendorRefundShares(order, 9000)
+	assert.Equal(t, models.Money(5000), shares[vendorA])
+	assert.Equal(t, models.Money(4000), shares[vendorB])
+}
+
+func TestFormatOrderNumber(t *testing.T) {
+	assert.Equal(t, "VEN-100000001", models.FormatOrderNumber(1))
+	assert.Equal(t, "VEN-100123456", models.FormatOrderNumber(123456))
+
+	// Numbers from the old scheme were VEN- plus at most eight digits
+	legacy := "VEN-99999999"
+	assert.True(t, len(models.FormatOrderNumber(1)) > len(legacy))
+}
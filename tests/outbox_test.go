@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryOutboxRepo hands out its pending events in order.
+type memoryOutboxRepo struct {
+	repository.OutboxRepository // Methods the tests don't use panic
+
+	mu        sync.Mutex
+	pending   []models.OutboxEvent
+	published chan primitive.ObjectID
+	failed    chan primitive.ObjectID
+}
+
+func (r *memoryOutboxRepo) Claim(ctx context.Context, lease time.Duration) (*models.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return nil, nil
+	}
+	e := r.pending[0]
+	r.pending = r.pending[1:]
+	return &e, nil
+}
+
+func (r *memoryOutboxRepo) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	r.published <- id
+	return nil
+}
+
+func (r *memoryOutboxRepo) MarkFailed(ctx context.Context, id primitive.ObjectID, cause error) error {
+	r.failed <- id
+	return nil
+}
+
+func outboxEntry(t *testing.T, e events.Event) models.OutboxEvent {
+	payload, err := json.Marshal(e)
+	assert.NoError(t, err)
+	return models.OutboxEvent{ID: primitive.NewObjectID(), EventID: e.ID, Type: e.Type, Payload: string(payload)}
+}
+
+func TestBusDispatchWaitsForHandlers(t *testing.T) {
+	bus := events.NewBus()
+	var handled int32
+	bus.Subscribe(events.OrderPaid, func(ctx context.Context, e events.Event) {
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&handled, 1)
+	})
+	bus.Subscribe(events.AllEvents, func(ctx context.Context, e events.Event) {
+		panic("subscriber bug")
+	})
+	bus.Subscribe(events.OrderPlaced, func(ctx context.Context, e events.Event) {
+		atomic.AddInt32(&handled, 100)
+	})
+
+	bus.Dispatch(context.Background(), events.New(events.OrderPaid, nil))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handled))
+}
+
+func TestOutboxRelayPublishesWithOriginalID(t *testing.T) {
+	bus := events.NewBus()
+	received := make(chan events.Event, 1)
+	bus.Subscribe(events.OrderPlaced, func(ctx context.Context, e events.Event) { received <- e })
+
+	order := models.Order{ID: primitive.NewObjectID(), OrderNumber: "VEN-1", UserID: primitive.NewObjectID(), Items: []models.OrderItem{
+		{ProductID: primitive.NewObjectID(), VendorID: primitive.NewObjectID(), Name: "Lamp", Quantity: 2, Subtotal: 4000},
+	}}
+	data := order.EventData()
+	data["items"] = order.EventItems()
+	placed := events.New(events.OrderPlaced, data)
+
+	good := outboxEntry(t, placed)
+	bad := models.OutboxEvent{ID: primitive.NewObjectID(), Type: events.OrderPlaced, Payload: "{"}
+	repo := &memoryOutboxRepo{
+		pending:   []models.OutboxEvent{bad, good},
+		published: make(chan primitive.ObjectID, 2),
+		failed:    make(chan primitive.ObjectID, 2),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handlers.StartOutboxRelay(ctx, repo, bus)
+
+	select {
+	case id := <-repo.failed:
+		assert.Equal(t, bad.ID, id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("undecodable event was not marked failed")
+	}
+	select {
+	case e := <-received:
+		assert.Equal(t, placed.ID, e.ID)
+		assert.Equal(t, order.OrderNumber, e.Data["orderNumber"])
+		items, _ := e.Data["items"].([]interface{})
+		assert.Len(t, items, 1)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not dispatched")
+	}
+	select {
+	case id := <-repo.published:
+		assert.Equal(t, good.ID, id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not marked published")
+	}
+}
+
+func TestOrderEventData(t *testing.T) {
+	vendor := primitive.NewObjectID()
+	order := models.Order{ID: primitive.NewObjectID(), Items: []models.OrderItem{
+		{VendorID: vendor, Name: "Lamp", Quantity: 1},
+		{VendorID: vendor, Name: "Shade", Quantity: 3},
+	}}
+	assert.Equal(t, []string{vendor.Hex()}, order.EventData()["vendorIds"])
+	assert.Len(t, order.EventItems(), 2)
+}
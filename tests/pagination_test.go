@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	cursor := models.PageCursor{CreatedAt: time.UnixMilli(1760000000123), ID: primitive.NewObjectID()}
+	decoded, err := models.DecodeCursor(cursor.Encode())
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	first, err := models.DecodeCursor("")
+	assert.NoError(t, err)
+	assert.True(t, first.IsZero())
+
+	for _, bad := range []string{"!!", "bm9wZQ", "MTIz.zzz"} {
+		_, err := models.DecodeCursor(bad)
+		assert.ErrorIs(t, err, models.ErrInvalidCursor, bad)
+	}
+}
+
+func TestCursorPage(t *testing.T) {
+	type item struct {
+		at time.Time
+		id primitive.ObjectID
+	}
+	key := func(i item) (time.Time, primitive.ObjectID) { return i.at, i.id }
+	items := []item{{time.UnixMilli(3000), primitive.NewObjectID()}, {time.UnixMilli(2000), primitive.NewObjectID()}, {time.UnixMilli(1000), primitive.NewObjectID()}}
+
+	page, meta := models.CursorPage(items, 2, key)
+	assert.Len(t, page, 2)
+	assert.True(t, meta.HasNext)
+	assert.True(t, meta.HasMore)
+	next, err := models.DecodeCursor(meta.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, items[1].id, next.ID)
+
+	last, meta := models.CursorPage(items[2:], 2, key)
+	assert.Len(t, last, 1)
+	assert.False(t, meta.HasNext)
+	assert.False(t, meta.HasMore)
+	assert.Empty(t, meta.NextCursor)
+
+	empty, _ := models.CursorPage[item](nil, 2, key)
+	assert.NotNil(t, empty)
+}
+
+func TestPageMeta(t *testing.T) {
+	assert.True(t, models.NewPageMeta(45, 2, 20).HasNext)
+	assert.False(t, models.NewPageMeta(45, 3, 20).HasNext)
+	assert.False(t, models.NewPageMeta(40, 2, 20).HasNext)
+	assert.False(t, models.NewPageMeta(0, 1, 20).HasNext)
+}
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"createdAt", "price", "name"}
+	def := bson.D{{Key: "createdAt", Value: -1}}
+
+	sort, err := models.ParseSort("", models.Product{}, allowed, def)
+	assert.NoError(t, err)
+	assert.Equal(t, def, sort)
+
+	sort, err = models.ParseSort("-price, name,price", models.Product{}, allowed, def)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "price", Value: -1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}, sort)
+
+	sort, err = models.ParseSort("-createdAt", models.Product{}, allowed, def)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}, sort)
+
+	for _, bad := range []string{"stock", "-costPrice", "breadcrumbs", "nope"} {
+		_, err := models.ParseSort(bad, models.Product{}, append(allowed, "breadcrumbs"), def)
+		assert.Error(t, err, bad)
+	}
+	_, err = models.ParseSort("price,name,createdAt,-id", models.Product{}, append(allowed, "id"), def)
+	assert.Error(t, err)
+}
+
+func TestListsRejectBadSorts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", primitive.NewObjectID().Hex())
+		c.Set("role", "customer")
+	})
+	router.GET("/categories", (&handlers.CategoryHandler{}).GetAllProductCategories)
+	router.GET("/orders", (&handlers.OrderHandler{}).GetUserOrders)
+
+	for _, url := range []string{
+		"/categories?sort=-slug",
+		"/orders?sort=paymentId",
+		"/orders?cursor=&sort=-total", // Cursor pages are newest first
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+
+		var resp utils.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, utils.CodeInvalidSort, resp.Code, url)
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func limitedRouter(limiter *middleware.RateLimiter, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set("userId", userID)
+		}
+	})
+	router.POST("/reviews", limiter.Limit(middleware.RateLimitReview), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func postReview(router *gin.Engine, ip string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reviews", nil)
+	req.RemoteAddr = ip + ":4000"
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRefusesWithRetryAfter(t *testing.T) {
+	limiter := middleware.NewRateLimiter(map[string]config.RateLimit{
+		"review": {Requests: 2, Window: time.Minute},
+	})
+	router := limitedRouter(limiter, "")
+
+	assert.Equal(t, http.StatusCreated, postReview(router, "203.0.113.7").Code)
+	w := postReview(router, "203.0.113.7")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = postReview(router, "203.0.113.7")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Other clients have their own allowance
+	assert.Equal(t, http.StatusCreated, postReview(router, "203.0.113.8").Code)
+
+	offenders := limiter.Offenders(10)
+	assert.Len(t, offenders, 1)
+	assert.Equal(t, "ip:203.0.113.7", offenders[0].Key)
+	assert.Equal(t, 1, offenders[0].Policies["review"])
+}
+
+func TestRateLimitCountsSignedInUsersByUser(t *testing.T) {
+	limiter := middleware.NewRateLimiter(map[string]config.RateLimit{
+		"review": {Requests: 1, Window: time.Minute},
+	})
+	router := limitedRouter(limiter, "64b000000000000000000001")
+
+	assert.Equal(t, http.StatusCreated, postReview(router, "203.0.113.7").Code)
+	// Changing address doesn't reset a user's allowance
+	assert.Equal(t, http.StatusTooManyRequests, postReview(router, "203.0.113.8").Code)
+}
+
+func TestBlockedClientsAreRefused(t *testing.T) {
+	limiter := middleware.NewRateLimiter(nil)
+	router := limitedRouter(limiter, "64b000000000000000000001")
+
+	limiter.Block(models.ClientBlock{Key: "user:64b000000000000000000001"})
+	assert.Equal(t, http.StatusForbidden, postReview(router, "203.0.113.7").Code)
+
+	limiter.Unblock("user:64b000000000000000000001")
+	assert.Equal(t, http.StatusCreated, postReview(router, "203.0.113.7").Code)
+
+	expired := time.Now().Add(-time.Minute)
+	limiter.SetBlocks([]models.ClientBlock{
+		{Key: "ip:203.0.113.9"},
+		{Key: "ip:203.0.113.7", ExpiresAt: &expired},
+	})
+	assert.Equal(t, http.StatusForbidden, postReview(router, "203.0.113.9").Code)
+	assert.Equal(t, http.StatusCreated, postReview(router, "203.0.113.7").Code)
+}
+
+func TestBlockClientInputKey(t *testing.T) {
+	assert.Equal(t, "ip:203.0.113.7", models.BlockClientInput{IP: "203.0.113.7"}.ClientKey())
+	assert.Equal(t, "user:64b000000000000000000001", models.BlockClientInput{IP: "203.0.113.7", UserID: "64b000000000000000000001"}.ClientKey())
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("TRUSTED_PROXIES", "")
+	cfg, err := config.Load()
+	assert.NoError(t, err)
+
+	limiter := middleware.NewRateLimiter(map[string]config.RateLimit{
+		"review": {Requests: 1, Window: time.Minute},
+	})
+	router := limitedRouter(limiter, "")
+	assert.NoError(t, router.SetTrustedProxies(cfg.TrustedProxies))
+
+	post := func(remote, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/reviews", nil)
+		req.RemoteAddr = remote + ":4000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-IP", forwardedFor)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusCreated, post("203.0.113.7", "198.51.100.1"))
+	// A new forwarded address from the same connection is the same client
+	assert.Equal(t, http.StatusTooManyRequests, post("203.0.113.7", "198.51.100.2"))
+
+	// Behind a trusted proxy, the address it forwards is the client
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1")
+	cfg, err = config.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.1"}, cfg.TrustedProxies)
+	limiter = middleware.NewRateLimiter(map[string]config.RateLimit{
+		"review": {Requests: 1, Window: time.Minute},
+	})
+	router = limitedRouter(limiter, "")
+	assert.NoError(t, router.SetTrustedProxies(cfg.TrustedProxies))
+	assert.Equal(t, http.StatusCreated, post("10.0.0.5", "198.51.100.1"))
+	assert.Equal(t, http.StatusCreated, post("10.0.0.5", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, post("10.0.0.6", "198.51.100.2"))
+
+	t.Setenv("TRUSTED_PROXIES", "load-balancer")
+	_, err = config.Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRankRecommendations(t *testing.T) {
+	shoes, bags, books := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	product := func(category primitive.ObjectID, sales int) models.Product {
+		p := models.Product{ID: primitive.NewObjectID(), CategoryID: category}
+		p.Price = 5000
+		p.TotalSales = sales
+		return p
+	}
+	s1, s2, s3 := product(shoes, 100), product(shoes, 90), product(shoes, 80)
+	bag := product(bags, 1)
+	book := product(books, 1000)
+	owned := product(shoes, 500)
+	alsoBought := product(books, 0)
+
+	signals := models.RecommendationSignals{
+		InterestCategories: map[primitive.ObjectID]bool{shoes: true},
+		PurchaseCategories: map[primitive.ObjectID]int{bags: 2},
+		CoPurchases:        map[primitive.ObjectID]int{alsoBought.ID: 2},
+		Exclude:            map[primitive.ObjectID]bool{owned.ID: true},
+	}
+	recs := models.RankRecommendations([]models.Product{s1, s2, s3, bag, book, owned, alsoBought}, signals, 10)
+
+	ids := make([]primitive.ObjectID, len(recs))
+	for i, r := range recs {
+		ids[i] = r.ID
+	}
+	// Co-purchase ranks first; the third shoe waits until other categories
+	// are in; owned and unrelated products are left out
+	assert.Equal(t, []primitive.ObjectID{alsoBought.ID, s1.ID, s2.ID, bag.ID, s3.ID}, ids)
+	assert.Equal(t, models.ReasonAlsoBought, recs[0].Reason)
+	assert.Equal(t, models.ReasonInterest, recs[1].Reason)
+	assert.Equal(t, models.ReasonPurchases, recs[3].Reason)
+
+	assert.Len(t, models.RankRecommendations([]models.Product{s1, s2, s3, bag}, signals, 2), 2)
+	assert.True(t, models.RecommendationSignals{}.Empty())
+}
+
+func TestParseBudgetRange(t *testing.T) {
+	cases := []struct {
+		in       string
+		min, max float64
+		ok       bool
+	}{
+		{"50-200", 50, 200, true},
+		{"$1,000 - $500", 500, 1000, true},
+		{"Under $100", 0, 100, true},
+		{"500+", 500, 0, true},
+		{"flexible", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tc := range cases {
+		lo, hi, ok := models.ParseBudgetRange(tc.in)
+		assert.Equal(t, tc.ok, ok, tc.in)
+		assert.Equal(t, tc.min, lo, tc.in)
+		assert.Equal(t, tc.max, hi, tc.in)
+	}
+}
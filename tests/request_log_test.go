@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.AccessLog())
+	router.GET("/orders/:id", func(c *gin.Context) {
+		c.Set("userId", "u1")
+		assert.Equal(t, c.GetString("requestId"), middleware.RequestIDFrom(c.Request.Context()))
+		c.Status(http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+	id := w.Header().Get(middleware.RequestIDHeader)
+	assert.Len(t, id, 32)
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, id, line["requestId"])
+	assert.Equal(t, "u1", line["userId"])
+	assert.Equal(t, "/orders/:id", line["route"])
+	assert.Equal(t, float64(http.StatusNotFound), line["status"])
+	assert.Equal(t, "warning", line["level"])
+
+	// An ID from a proxy in front is kept, unless it could forge log lines
+	req := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "edge-abc123")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "edge-abc123", w.Header().Get(middleware.RequestIDHeader))
+
+	req.Header.Set(middleware.RequestIDHeader, "bad id\nlevel=error")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotEqual(t, "bad id\nlevel=error", w.Header().Get(middleware.RequestIDHeader))
+}
+
+func TestAccessLogRedactsTokens(t *testing.T) {
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	defer logrus.SetOutput(os.Stderr)
+	defer logrus.SetFormatter(&logrus.TextFormatter{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.AccessLog())
+	router.GET("/exports/download/:token", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/orders/:id/items/:item", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/exports/download/signed.secret-token", nil))
+	assert.NotContains(t, out.String(), "signed.secret-token")
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "/exports/download/REDACTED", line["path"])
+
+	// Other parameters are logged as they came
+	out.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/42/items/7", nil))
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "/orders/42/items/7", line["path"])
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func reviewTopics(insights models.ReviewInsights) map[string]float64 {
+	topics := map[string]float64{}
+	for _, t := range insights.Topics {
+		topics[t.Topic] = t.Sentiment
+	}
+	return topics
+}
+
+func TestAnalyzeReview(t *testing.T) {
+	now := time.Now()
+
+	insights := services.AnalyzeReview("Love these shoes, great quality! But they run small, order a size up.", 4, now)
+	assert.Equal(t, models.SentimentPositive, insights.Label)
+	assert.Equal(t, models.ReviewAnalyzerVersion, insights.Version)
+	topics := reviewTopics(insights)
+	assert.True(t, topics["quality"] > 0)
+	assert.True(t, topics["sizing"] < 0)
+
+	insights = services.AnalyzeReview("Took forever to arrive and the box was damaged. Very disappointed.", 1, now)
+	assert.Equal(t, models.SentimentNegative, insights.Label)
+	topics = reviewTopics(insights)
+	assert.True(t, topics["late delivery"] < 0)
+	assert.True(t, topics["damaged item"] < 0)
+	assert.NotContains(t, topics, "sizing")
+}
+
+func TestAnalyzeReviewNegation(t *testing.T) {
+	now := time.Now()
+	good := services.AnalyzeReview("The fabric is good", 3, now)
+	notGood := services.AnalyzeReview("The fabric isn't good", 3, now)
+	assert.True(t, good.Sentiment > 0)
+	assert.True(t, notGood.Sentiment < 0)
+	assert.True(t, reviewTopics(notGood)["material"] < 0)
+}
+
+func TestAnalyzeReviewFallsBackToRating(t *testing.T) {
+	insights := services.AnalyzeReview("Got it on Tuesday.", 5, time.Now())
+	assert.Equal(t, 1.0, insights.Sentiment)
+	assert.Empty(t, insights.Topics)
+
+	insights = services.AnalyzeReview("About the packaging.", 2, time.Now())
+	assert.Equal(t, -0.5, reviewTopics(insights)["packaging"])
+}
+
+func TestBuildReviewInsightsSummary(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 30)
+	sentiments := []models.ReviewSentimentCount{
+		{Period: models.ReviewPeriodCurrent, Label: models.SentimentPositive, Reviews: 6, SentimentSum: 4.2},
+		{Period: models.ReviewPeriodCurrent, Label: models.SentimentNegative, Reviews: 4, SentimentSum: -2.2},
+		{Period: models.ReviewPeriodPrevious, Label: models.SentimentPositive, Reviews: 5, SentimentSum: 3},
+	}
+	topics := []models.ReviewTopicCount{
+		{Period: models.ReviewPeriodCurrent, Topic: "late delivery", Mentions: 4, Negative: 4, SentimentSum: -2.4},
+		{Period: models.ReviewPeriodPrevious, Topic: "late delivery", Mentions: 1, Negative: 1, SentimentSum: -0.5},
+		{Period: models.ReviewPeriodCurrent, Topic: "sizing", Mentions: 5, Positive: 1, Negative: 3, SentimentSum: -1},
+		{Period: models.ReviewPeriodPrevious, Topic: "sizing", Mentions: 4, Positive: 3, SentimentSum: 1.6},
+		{Period: models.ReviewPeriodCurrent, Topic: "packaging", Mentions: 2, Negative: 2, SentimentSum: -1},
+		{Period: models.ReviewPeriodPrevious, Topic: "quality", Mentions: 3, Positive: 3, SentimentSum: 2},
+	}
+	summary := models.BuildReviewInsightsSummary(sentiments, topics, from, to)
+
+	assert.Equal(t, 10, summary.Reviews)
+	assert.Equal(t, 6, summary.Positive)
+	assert.Equal(t, 4, summary.Negative)
+	assert.Equal(t, 0.2, summary.Sentiment)
+	assert.Equal(t, 0.6, *summary.PreviousSentiment)
+
+	// Topics that didn't come up this time are left out
+	assert.Len(t, summary.Topics, 3)
+	sizing := summary.Topics[0]
+	assert.Equal(t, "sizing", sizing.Topic)
+	assert.Equal(t, -0.2, sizing.Sentiment)
+	assert.Equal(t, 0.4, *sizing.PreviousSentiment)
+	assert.Equal(t, "worsening", sizing.Trend)
+	assert.Equal(t, "steady", summary.Topics[1].Trend) // late delivery
+	assert.Equal(t, "new", summary.Topics[2].Trend)    // packaging
+	assert.Nil(t, summary.Topics[2].PreviousSentiment)
+
+	// Both gained three negative mentions; packaging has too few to trend
+	assert.Equal(t, []string{"sizing", "late delivery"}, summary.TrendingNegative)
+}
+
+func TestBuildReviewInsightsSummaryEmpty(t *testing.T) {
+	summary := models.BuildReviewInsightsSummary(nil, nil, time.Time{}, time.Time{})
+	assert.Equal(t, 0, summary.Reviews)
+	assert.Nil(t, summary.PreviousSentiment)
+	assert.Empty(t, summary.Topics)
+	assert.Empty(t, summary.TrendingNegative)
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaleCampaignSalePriceFor(t *testing.T) {
+	percent := models.SaleCampaign{Type: models.CouponPercentage, Value: 15}
+	assert.Equal(t, models.Money(1699), percent.SalePriceFor(models.Product{Price: 1999}))
+
+	// A lower sale price the vendor set themselves is left alone
+	assert.Zero(t, percent.SalePriceFor(models.Product{Price: 2000, SalePrice: 1500}))
+	// A higher one is beaten
+	assert.Equal(t, models.Money(1700), percent.SalePriceFor(models.Product{Price: 2000, SalePrice: 1800}))
+
+	fixed := models.SaleCampaign{Type: models.CouponFixed, Value: 5}
+	assert.Equal(t, models.Money(1500), fixed.SalePriceFor(models.Product{Price: 2000}))
+	// Nothing is given away for free
+	assert.Zero(t, fixed.SalePriceFor(models.Product{Price: 500}))
+}
+
+func TestSaleCampaignOverlaps(t *testing.T) {
+	start := time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC)
+	blackFriday := models.SaleCampaign{StartsAt: start, EndsAt: start.Add(24 * time.Hour)}
+
+	weekend := models.SaleCampaign{StartsAt: start.Add(12 * time.Hour), EndsAt: start.Add(72 * time.Hour)}
+	assert.True(t, blackFriday.Overlaps(weekend))
+	assert.True(t, weekend.Overlaps(blackFriday))
+
+	// Back to back is fine
+	cyberMonday := models.SaleCampaign{StartsAt: start.Add(24 * time.Hour), EndsAt: start.Add(48 * time.Hour)}
+	assert.False(t, blackFriday.Overlaps(cyberMonday))
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/database"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var schemaTypes = map[string][]bsontype.Type{
+	"objectId": {bsontype.ObjectID},
+	"string":   {bsontype.String},
+	"date":     {bsontype.DateTime},
+	"bool":     {bsontype.Boolean},
+	"array":    {bsontype.Array},
+	"null":     {bsontype.Null},
+	"number":   {bsontype.Int32, bsontype.Int64, bsontype.Double, bsontype.Decimal128},
+	"long":     {bsontype.Int64},
+}
+
+// schemaViolations checks doc's top-level fields against schema's required
+// fields, types and enums, as the database would.
+func schemaViolations(t *testing.T, schema bson.M, doc interface{}) []string {
+	raw, err := bson.Marshal(doc)
+	assert.NoError(t, err)
+	var violations []string
+
+	for _, field := range schema["required"].(bson.A) {
+		if _, err := bson.Raw(raw).LookupErr(field.(string)); err != nil {
+			violations = append(violations, field.(string)+" missing")
+		}
+	}
+	for field, rule := range schema["properties"].(bson.M) {
+		value, err := bson.Raw(raw).LookupErr(field)
+		if err != nil {
+			continue
+		}
+		rule := rule.(bson.M)
+		if enum, ok := rule["enum"].(bson.A); ok {
+			if value.Type != bsontype.String || !containsString(enum, value.StringValue()) {
+				violations = append(violations, field+" not in enum")
+			}
+		}
+		var names []string
+		switch typ := rule["bsonType"].(type) {
+		case string:
+			names = []string{typ}
+		case bson.A:
+			for _, name := range typ {
+				names = append(names, name.(string))
+			}
+		}
+		if len(names) > 0 && !matchesType(names, value.Type) {
+			violations = append(violations, field+" is "+value.Type.String())
+		}
+	}
+	return violations
+}
+
+func matchesType(names []string, typ bsontype.Type) bool {
+	for _, name := range names {
+		for _, allowed := range schemaTypes[name] {
+			if typ == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values bson.A, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCollectionSchemasMatchModels(t *testing.T) {
+	now := time.Now()
+	user := primitive.NewObjectID()
+
+	order := models.Order{
+		OrderNumber:   "VEN-100234",
+		UserID:        user,
+		Items:         []models.OrderItem{{ProductID: primitive.NewObjectID(), VendorID: primitive.NewObjectID(), Price: 2000, Quantity: 2, Subtotal: 4000}},
+		Subtotal:      4000,
+		Total:         4500,
+		Status:        models.StatusPending,
+		PaymentStatus: "pending",
+		CreatedAt:     now,
+	}
+	product := models.Product{VendorID: user, Name: "Lamp", Price: 2000, Status: models.ProductStatusDraft, CreatedAt: now}
+	account := models.VendorAccount{UserID: user, Tier: "individual", Status: "active", TransactionFee: 5}
+	application := models.SellerApplication{UserID: user, RequestedTier: "individual", StoreName: "Ada's Lamps", Status: "pending"}
+
+	docs := map[string]interface{}{
+		"orders":             order,
+		"products":           product,
+		"vendorAccounts":     account,
+		"sellerApplications": application,
+	}
+	assert.Len(t, database.CollectionSchemas, len(docs))
+	for name, doc := range docs {
+		assert.Empty(t, schemaViolations(t, database.CollectionSchemas[name], doc), name)
+	}
+
+	order.Status = "lost"
+	assert.Equal(t, []string{"status not in enum"}, schemaViolations(t, database.CollectionSchemas["orders"], order))
+
+	product.Status = ""
+	assert.Equal(t, []string{"status not in enum"}, schemaViolations(t, database.CollectionSchemas["products"], product))
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordPrefixPattern(t *testing.T) {
+	re := regexp.MustCompile("(?i)" + models.WordPrefixPattern("lam"))
+	assert.True(t, re.MatchString("Desk Lamp"))
+	assert.True(t, re.MatchString("lamp shade"))
+	assert.False(t, re.MatchString("Clamp"))
+
+	multi := regexp.MustCompile("(?i)" + models.WordPrefixPattern("  desk   la "))
+	assert.True(t, multi.MatchString("Oak Desk Lamp"))
+
+	// Regex syntax in the query is matched literally
+	literal := regexp.MustCompile("(?i)" + models.WordPrefixPattern("c++"))
+	assert.True(t, literal.MatchString("C++ Primer"))
+	assert.False(t, literal.MatchString("cc"))
+}
+
+func TestNormalizeSearchQuery(t *testing.T) {
+	assert.Equal(t, "desk lamp", models.NormalizeSearchQuery("  Desk \t LAMP "))
+	assert.Equal(t, "", models.NormalizeSearchQuery("   "))
+
+	long := models.NormalizeSearchQuery(strings.Repeat("é", 150))
+	assert.Equal(t, 100, utf8.RuneCountInString(long))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, models.EditDistance("lamp", "lamp"))
+	assert.Equal(t, 1, models.EditDistance("sneekers", "sneakers"))
+	assert.Equal(t, 1, models.EditDistance("lmap", "lamp")) // Swapped letters are one edit
+	assert.Equal(t, 3, models.EditDistance("kitten", "sitting"))
+	assert.Equal(t, 3, models.EditDistance("", "abc"))
+}
+
+func TestExpandSearchQuery(t *testing.T) {
+	vocabulary := map[string]bool{"sneakers": true, "lamp": true, "desk": true, "mobile": true}
+	synonyms := models.NewSynonymIndex([]models.SearchSynonym{
+		{Terms: []string{"sneakers", "trainers"}},
+		{Terms: []string{"cell phone", "mobile"}},
+	})
+
+	// Misspellings are corrected, and the correction's synonyms come along
+	assert.Equal(t, [][]string{{"sneekers", "sneakers", "trainers"}}, models.ExpandSearchQuery("Sneekers", synonyms, vocabulary))
+	assert.Equal(t, [][]string{{"trainers", "sneakers"}}, models.ExpandSearchQuery("trainers", synonyms, vocabulary))
+
+	// Phrases are matched as a whole
+	assert.Equal(t, [][]string{{"red"}, {"cell phone", "mobile"}}, models.ExpandSearchQuery("red cell  phone", synonyms, vocabulary))
+
+	// Short words and words still being typed are left alone
+	assert.Equal(t, [][]string{{"lam"}}, models.ExpandSearchQuery("lam", synonyms, vocabulary))
+	assert.Equal(t, [][]string{{"des"}}, models.ExpandSearchQuery("des", synonyms, vocabulary))
+}
+
+func TestParseProductFilters(t *testing.T) {
+	q, _ := url.ParseQuery("minPrice=10&maxPrice=50&brand=Acme,+Zed&attributes[color]=red&attributes[Size]=M,L")
+	f, err := models.ParseProductFilters(q)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(1000), *f.MinPrice)
+	assert.Equal(t, models.Money(5000), *f.MaxPrice)
+	assert.Equal(t, []string{"Acme", "Zed"}, f.Brands)
+	assert.Equal(t, map[string][]string{"color": {"red"}, "Size": {"M", "L"}}, f.Attributes)
+	assert.Len(t, f.Conditions(), 3)
+
+	for _, bad := range []string{"minPrice=abc", "minPrice=-1", "minPrice=60&maxPrice=50", "attributes[$where]=1"} {
+		q, _ := url.ParseQuery(bad)
+		_, err := models.ParseProductFilters(q)
+		assert.Error(t, err, bad)
+	}
+
+	none, err := models.ParseProductFilters(url.Values{})
+	assert.NoError(t, err)
+	assert.Empty(t, none.Conditions())
+}
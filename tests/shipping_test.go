@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestShippingZoneMatching(t *testing.T) {
+	table := models.ShippingRateTable{Zones: []models.ShippingZone{
+		{Name: "Rest of world", BaseFee: 60},
+		{Name: "Nigeria", Regions: []string{"NG"}, BaseFee: 20},
+		{Name: "Lagos", Regions: []string{"ng-la"}, BaseFee: 10},
+	}}
+
+	zone, ok := table.Zone("NG-LA")
+	assert.True(t, ok)
+	assert.Equal(t, "Lagos", zone.Name)
+	zone, _ = table.Zone(" ng-ab ")
+	assert.Equal(t, "Nigeria", zone.Name)
+	zone, _ = table.Zone("GH")
+	assert.Equal(t, "Rest of world", zone.Name)
+
+	_, ok = models.ShippingRateTable{Zones: table.Zones[1:]}.Zone("GH")
+	assert.False(t, ok)
+}
+
+func TestQuoteShipping(t *testing.T) {
+	platformVendor := primitive.NewObjectID()
+	ownRates := primitive.NewObjectID()
+	vendorTables := map[primitive.ObjectID]models.ShippingRateTable{
+		ownRates: {Zones: []models.ShippingZone{
+			{Name: "Lagos", Regions: []string{"NG-LA"}, BaseFee: 5, IncludedKg: 1, PerKg: 2},
+		}},
+	}
+	items := []models.ShippingItem{
+		{VendorID: platformVendor, Quantity: 1, Subtotal: 100},
+		// 40x30x20cm is 4.8kg volumetric, billed over its 2kg weight
+		{VendorID: ownRates, Quantity: 1, Subtotal: 80, Dimensions: models.Dimensions{Length: 40, Width: 30, Height: 20, Weight: 2}},
+	}
+
+	rates := models.ShippingRates{Platform: models.DefaultShippingRates, Vendors: vendorTables}
+	quote, err := models.QuoteShipping(items, rates, "ng-la", nil)
+	assert.NoError(t, err)
+	assert.Len(t, quote.Shipments, 2)
+	assert.Equal(t, 25.0, quote.Shipments[0].Fee)
+	assert.Equal(t, 4.8, quote.Shipments[1].WeightKg)
+	assert.Equal(t, 12.6, quote.Shipments[1].Fee)
+	assert.Equal(t, 37.6, quote.Fee)
+
+	// Free over the platform threshold
+	items[0].Subtotal = 600
+	quote, _ = models.QuoteShipping(items[:1], models.ShippingRates{Platform: models.DefaultShippingRates, FreeShipping: models.DefaultFreeShipping}, "", nil)
+	assert.Equal(t, 0.0, quote.Fee)
+	assert.True(t, quote.Shipments[0].FreeShipping)
+
+	_, err = models.QuoteShipping(items, rates, "GH", nil)
+	assert.ErrorIs(t, err, models.ErrNotShippable)
+}
+
+func TestFreeShippingRules(t *testing.T) {
+	platformVendor, generous := primitive.NewObjectID(), primitive.NewObjectID()
+	rates := models.ShippingRates{
+		Platform:     models.DefaultShippingRates,
+		FreeShipping: models.FreeShippingRule{Threshold: 300, Regions: []string{"NG"}},
+		VendorFreeShipping: map[primitive.ObjectID]models.FreeShippingRule{
+			generous: {VendorID: generous, Threshold: 50},
+		},
+	}
+	items := []models.ShippingItem{
+		{VendorID: platformVendor, Quantity: 1, Subtotal: 400},
+		{VendorID: generous, Quantity: 1, Subtotal: 80},
+	}
+
+	// The vendor's own rule ships their items separately, free
+	quote, err := models.QuoteShipping(items, rates, "NG-LA", nil)
+	assert.NoError(t, err)
+	assert.Len(t, quote.Shipments, 2)
+	assert.Equal(t, 0.0, quote.Fee)
+
+	// The platform's only covers Nigeria
+	quote, _ = models.QuoteShipping(items, rates, "GH", nil)
+	assert.Equal(t, 25.0, quote.Shipments[0].Fee)
+	assert.Equal(t, 0.0, quote.Shipments[1].Fee)
+
+	items[0].Subtotal = 120
+	progress := models.FreeShippingProgressFor(items, rates)
+	assert.Len(t, progress, 2)
+	assert.Equal(t, 300.0, progress[0].Threshold)
+	assert.Equal(t, 180.01, progress[0].Remaining)
+	assert.Equal(t, []string{"NG"}, progress[0].Regions)
+	assert.Zero(t, progress[1].Remaining)
+
+	// A zero threshold turns it off
+	rates.FreeShipping = models.FreeShippingRule{}
+	assert.Len(t, models.FreeShippingProgressFor(items, rates), 1)
+	assert.False(t, rates.FreeShipping.Applies("NG", 1e6))
+}
+
+func TestQuoteShippingMethods(t *testing.T) {
+	vendor := primitive.NewObjectID()
+	rates := models.ShippingRates{
+		Platform: models.DefaultShippingRates,
+		Methods: map[primitive.ObjectID][]models.ShippingMethod{vendor: {
+			{Code: models.ShippingMethodExpress, Name: "Express", Fee: 40, HandlingDays: 1},
+			{Code: models.ShippingMethodStandard, Name: "Standard", Fee: 15, FreeOver: 200, HandlingDays: 3},
+			{Code: models.ShippingMethodPickup, Name: "Pickup", Regions: []string{"NG-LA"}},
+		}},
+	}
+	items := []models.ShippingItem{{VendorID: vendor, Quantity: 1, Subtotal: 100}}
+
+	// Cheapest served by default
+	quote, err := models.QuoteShipping(items, rates, "NG-AB", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShippingMethodStandard, quote.Shipments[0].Method)
+	assert.Equal(t, 3, quote.Shipments[0].HandlingDays)
+	assert.Len(t, quote.Shipments[0].Methods, 2)
+	assert.Equal(t, 15.0, quote.Fee)
+
+	selected := map[primitive.ObjectID]string{vendor: models.ShippingMethodExpress}
+	quote, _ = models.QuoteShipping(items, rates, "NG-AB", selected)
+	assert.Equal(t, 40.0, quote.Fee)
+
+	selected[vendor] = models.ShippingMethodPickup
+	_, err = models.QuoteShipping(items, rates, "NG-AB", selected)
+	assert.ErrorIs(t, err, models.ErrUnknownShippingMethod)
+
+	items[0].Subtotal = 250
+	quote, _ = models.QuoteShipping(items, rates, "NG-AB", nil)
+	assert.Equal(t, 0.0, quote.Fee)
+}
+
+func TestPickupMethod(t *testing.T) {
+	vendor := primitive.NewObjectID()
+	rates := models.ShippingRates{Methods: map[primitive.ObjectID][]models.ShippingMethod{vendor: {
+		{Code: models.ShippingMethodPickup, Name: "Collect in store", Fee: 10, HandlingDays: 1},
+		{Code: models.ShippingMethodStandard, Name: "Standard", Fee: 15, HandlingDays: 2},
+	}}}
+	items := []models.ShippingItem{{VendorID: vendor, Quantity: 1, Subtotal: 100}}
+
+	// Free, but only chosen when asked for
+	quote, err := models.QuoteShipping(items, rates, "NG-LA", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShippingMethodStandard, quote.Shipments[0].Method)
+	quote, _ = models.QuoteShipping(items, rates, "NG-LA", map[primitive.ObjectID]string{vendor: models.ShippingMethodPickup})
+	assert.Equal(t, 0.0, quote.Fee)
+
+	only := primitive.NewObjectID()
+	store := models.Store{PickupLocations: []models.PickupLocation{{ID: only, Name: "Yaba"}}}
+	location, ok := store.PickupLocation(primitive.NilObjectID)
+	assert.True(t, ok)
+	assert.Equal(t, only, location.ID)
+	store.PickupLocations = append(store.PickupLocations, models.PickupLocation{ID: primitive.NewObjectID()})
+	_, ok = store.PickupLocation(primitive.NilObjectID)
+	assert.False(t, ok)
+}
+
+func TestEstimateDelivery(t *testing.T) {
+	fast, slow := primitive.NewObjectID(), primitive.NewObjectID()
+	friday := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Monday, models.AddBusinessDays(friday, 1).Weekday())
+	assert.Equal(t, friday, models.AddBusinessDays(friday, 0))
+
+	quote := models.ShippingQuote{Shipments: []models.Shipment{
+		{VendorIDs: []primitive.ObjectID{fast, slow}, MinTransitDays: 2, MaxTransitDays: 5},
+		{VendorIDs: []primitive.ObjectID{fast}, Method: models.ShippingMethodExpress, HandlingDays: 1, MinTransitDays: 1, MaxTransitDays: 1},
+	}}
+	quote.EstimateDelivery(friday, map[primitive.ObjectID]models.ProcessingTime{
+		fast: {MinDays: 0, MaxDays: 1},
+		slow: {MinDays: 1, MaxDays: 3},
+	})
+
+	// The slowest vendor's handling, then transit
+	assert.Equal(t, friday.AddDate(0, 0, 5), quote.Shipments[0].EstimatedDelivery.Earliest)
+	assert.Equal(t, friday.AddDate(0, 0, 12), quote.Shipments[0].EstimatedDelivery.Latest)
+	// A method's own handling time, regardless of the store's
+	assert.Equal(t, friday.AddDate(0, 0, 4), quote.Shipments[1].EstimatedDelivery.Latest)
+	assert.Equal(t, *quote.Shipments[0].EstimatedDelivery, *quote.EstimatedDelivery)
+}
+
+func TestShippingRestrictions(t *testing.T) {
+	r := models.ShippingRestrictions{ExcludedRegions: []string{" ng-bo", "US", "NG-BO"}}
+	r.Normalize()
+	assert.Equal(t, []string{"NG-BO", "US"}, r.ExcludedRegions)
+	assert.NotEmpty(t, r.Reason("NG-BO", "NG"))
+	assert.NotEmpty(t, r.Reason("us-tx", "NG"))
+	assert.Empty(t, r.Reason("NG-LA", "NG"))
+	assert.Empty(t, r.Reason("", "NG"))
+
+	domestic := models.ShippingRestrictions{DomesticOnly: true}
+	assert.Empty(t, domestic.Reason("NG-LA", "ng"))
+	assert.NotEmpty(t, domestic.Reason("GH", "NG"))
+
+	// Held back when either end is unknown
+	assert.NotEmpty(t, domestic.Reason("GH", ""))
+	assert.NotEmpty(t, domestic.Reason("", "NG"))
+
+	vendor := primitive.NewObjectID()
+	items := []models.ShippingItem{
+		{Name: "Kettle", VendorID: vendor},
+		{Name: "Knife", VendorID: vendor, Restrictions: domestic},
+	}
+	restricted := models.RestrictedItems(items, "GH", map[primitive.ObjectID]string{vendor: "NG"})
+	assert.Len(t, restricted, 1)
+	assert.Equal(t, "Knife", restricted[0].Name)
+}
+
+func TestPostalAddressRegion(t *testing.T) {
+	assert.Equal(t, "NG-LA", models.PostalAddress{Country: "ng", State: "la"}.Region())
+	assert.Equal(t, "NG", models.PostalAddress{Country: "NG", State: "Lagos"}.Region())
+}
+
+func TestPostalAddressString(t *testing.T) {
+	addr := models.PostalAddress{Name: "Ada", Street1: "1 Marina", City: "Lagos", State: "LA", PostalCode: "101001", Country: "NG"}
+	assert.Equal(t, "Ada, 1 Marina, Lagos, LA 101001, NG", addr.String())
+}
+
+func TestPostalAddressNormalize(t *testing.T) {
+	addr := models.PostalAddress{Street1: " 10  Downing St ", City: "London", Country: "gb", PostalCode: "sw1a2aa"}
+	assert.NoError(t, addr.Normalize())
+	assert.Equal(t, "GB", addr.Country)
+	assert.Equal(t, "SW1A 2AA", addr.PostalCode)
+	assert.Equal(t, "10 Downing St", addr.Street1)
+
+	// Country-specific requirements
+	us := models.PostalAddress{Street1: "1 Main St", City: "Austin", Country: "US", PostalCode: "78701"}
+	assert.Error(t, us.Normalize())
+	us.State = "tx"
+	assert.NoError(t, us.Normalize())
+	assert.Equal(t, "TX", us.State)
+	us.PostalCode = "7870"
+	assert.Error(t, us.Normalize())
+
+	// Countries without postal codes, or without rules, get the basics
+	gh := models.PostalAddress{Street1: "Oxford St", City: "Accra", Country: "GH"}
+	assert.NoError(t, gh.Normalize())
+	assert.Error(t, (&models.PostalAddress{Street1: "x", City: "y", Country: "G1"}).Normalize())
+}
+
+func TestCarrierTrackingStatus(t *testing.T) {
+	assert.Equal(t, utils.TrackingOutForDelivery, utils.ShippoStatus("TRANSIT", "out_for_delivery"))
+	assert.Equal(t, utils.TrackingInTransit, utils.ShippoStatus("TRANSIT", "package_accepted"))
+	assert.Equal(t, utils.TrackingDelivered, utils.ShippoStatus("DELIVERED", ""))
+	assert.Equal(t, utils.TrackingUnknown, utils.ShippoStatus("UNKNOWN", ""))
+	assert.Equal(t, utils.TrackingReturned, utils.EasyPostStatus("return_to_sender"))
+	assert.Equal(t, utils.TrackingOutForDelivery, utils.EasyPostStatus("out_for_delivery"))
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type namedSMSProvider string
+
+func (p namedSMSProvider) Name() string              { return string(p) }
+func (p namedSMSProvider) Send(_, _, _ string) error { return nil }
+
+func TestNormalizePhone(t *testing.T) {
+	phone, err := utils.NormalizePhone(" +234 (801) 234-5678 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "+2348012345678", phone)
+
+	phone, err = utils.NormalizePhone("0044 20 7946 0958")
+	assert.NoError(t, err)
+	assert.Equal(t, "+442079460958", phone)
+
+	for _, bad := range []string{"08012345678", "+12", "+234abc12345", "+0123456789"} {
+		_, err := utils.NormalizePhone(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSMSRouterPicksLongestPrefix(t *testing.T) {
+	router := utils.NewSMSRouter([]utils.SMSRoute{
+		{Prefix: "*", Provider: namedSMSProvider("twilio"), Sender: "+15550000000"},
+		{Prefix: "234", Provider: namedSMSProvider("termii"), Sender: "Vendora"},
+		{Prefix: "1", Provider: namedSMSProvider("twilio"), Sender: "+15551111111"},
+	})
+
+	route, ok := router.Route("+2348012345678")
+	assert.True(t, ok)
+	assert.Equal(t, "termii", route.Provider.Name())
+	assert.Equal(t, "Vendora", route.Sender)
+
+	route, _ = router.Route("+14155550100")
+	assert.Equal(t, "+15551111111", route.Sender)
+
+	route, _ = router.Route("+442079460958")
+	assert.Equal(t, "+15550000000", route.Sender)
+}
+
+func TestNotificationPreferencesAllows(t *testing.T) {
+	var none *models.NotificationPreferences
+	assert.True(t, none.Allows(models.NotificationPayoutSent, models.ChannelSMS))
+	assert.False(t, none.Allows(models.NotificationNewReview, models.ChannelSMS), "reviews are never texted")
+
+	optedOut := &models.NotificationPreferences{SMSOptOut: true}
+	assert.False(t, optedOut.Allows(models.NotificationOutForDelivery, models.ChannelSMS))
+	assert.True(t, optedOut.Allows(models.NotificationOutForDelivery, models.ChannelEmail))
+	assert.True(t, optedOut.Allows(models.NotificationSecurity, models.ChannelSMS))
+
+	partial := &models.NotificationPreferences{Channels: map[string]map[string]bool{
+		models.NotificationPayoutSent: {models.ChannelSMS: false},
+	}}
+	assert.False(t, partial.Allows(models.NotificationPayoutSent, models.ChannelSMS))
+	assert.True(t, partial.Allows(models.NotificationPayoutSent, models.ChannelInApp))
+	assert.True(t, partial.Allows(models.NotificationOutForDelivery, models.ChannelSMS))
+
+	matrix := partial.Matrix()
+	assert.False(t, matrix[models.NotificationPayoutSent][models.ChannelSMS])
+	_, hasSMS := matrix[models.NotificationRefund][models.ChannelSMS]
+	assert.False(t, hasSMS)
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryTrashRepo keeps the trash in memory.
+type memoryTrashRepo struct {
+	repository.TrashRepository // Methods the tests don't use panic
+
+	items   []models.TrashedItem
+	deleted []primitive.ObjectID
+}
+
+func (r *memoryTrashRepo) SoftDelete(ctx context.Context, kind string, id, deletedBy primitive.ObjectID) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *memoryTrashRepo) ListDeleted(ctx context.Context, kind string, limit, skip int64) ([]models.TrashedItem, int64, error) {
+	return r.items, int64(len(r.items)), nil
+}
+
+func TestNotDeleted(t *testing.T) {
+	filter := bson.M{"status": models.ProductStatusActive}
+	narrowed := repository.NotDeleted(filter)
+	assert.Equal(t, bson.M{"status": models.ProductStatusActive, "deletedAt": nil}, narrowed)
+	assert.NotContains(t, filter, "deletedAt")
+
+	// A filter that asks for deleted documents is left alone
+	trash := bson.M{"deletedAt": bson.M{"$ne": nil}}
+	assert.Equal(t, trash, repository.NotDeleted(trash))
+}
+
+func TestIsTrashKind(t *testing.T) {
+	for _, kind := range []string{"users", "products", "reviews", "stores"} {
+		assert.True(t, models.IsTrashKind(kind), kind)
+	}
+	assert.False(t, models.IsTrashKind("orders"))
+	assert.False(t, models.IsTrashKind(""))
+}
+
+func trashRouter(h *handlers.TrashHandler, adminID primitive.ObjectID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userId", adminID.Hex()) })
+	router.DELETE("/admin/users/:id", h.DeleteUser)
+	router.GET("/admin/trash/:kind", h.ListTrash)
+	return router
+}
+
+func TestListTrashReportsPurgeDate(t *testing.T) {
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &memoryTrashRepo{items: []models.TrashedItem{{ID: primitive.NewObjectID(), Kind: "products", Label: "Lamp", DeletedAt: deletedAt}}}
+	router := trashRouter(&handlers.TrashHandler{Repo: repo, Retention: 7 * 24 * time.Hour}, primitive.NewObjectID())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/trash/products", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Data struct {
+			Items []models.TrashedItem `json:"items"`
+			Meta  models.PageMeta      `json:"meta"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	if assert.Len(t, body.Data.Items, 1) {
+		assert.True(t, deletedAt.AddDate(0, 0, 7).Equal(body.Data.Items[0].PurgeAt))
+	}
+	assert.Equal(t, int64(1), body.Data.Meta.Total)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/trash/orders", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminCantDeleteOwnAccount(t *testing.T) {
+	adminID := primitive.NewObjectID()
+	repo := &memoryTrashRepo{}
+	router := trashRouter(&handlers.TrashHandler{Repo: repo}, adminID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/users/"+adminID.Hex(), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, repo.deleted)
+}
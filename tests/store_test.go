@@ -0,0 +1,21 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnPolicy_AllowsReturn(t *testing.T) {
+	delivered := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	policy := models.ReturnPolicy{AcceptsReturns: true, WindowDays: 14}
+
+	assert.True(t, policy.AllowsReturn(delivered, delivered.AddDate(0, 0, 14)))
+	assert.False(t, policy.AllowsReturn(delivered, delivered.AddDate(0, 0, 15)))
+	assert.False(t, policy.AllowsReturn(time.Time{}, delivered))
+
+	policy.AcceptsReturns = false
+	assert.False(t, policy.AllowsReturn(delivered, delivered.AddDate(0, 0, 1)))
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/middleware"
+	"github.com/developia-II/ecommerce-backend/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Telemetry())
+	router.GET("/metrics", middleware.MetricsAuth("scrape-token"), gin.WrapH(telemetry.Handler()))
+	router.GET("/widgets/:id", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/widgets/7", nil))
+	telemetry.OrderPlaced(25)
+	telemetry.PaymentSucceeded("webhook")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-token")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	// Latency is labelled by route, not by path, to keep series bounded
+	assert.True(t, strings.Contains(body, `http_request_duration_seconds_count{method="GET",route="/widgets/:id",status="500"} 1`), body)
+	assert.True(t, strings.Contains(body, `http_request_errors_total{class="5xx",method="GET",route="/widgets/:id"} 1`))
+	assert.True(t, strings.Contains(body, "orders_placed_total 1"))
+	assert.True(t, strings.Contains(body, `payments_succeeded_total{source="webhook"} 1`))
+}
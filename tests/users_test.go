@@ -3,6 +3,7 @@ package tests
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,15 @@ func TestJSON_UNMarshall_User_Struct(t *testing.T) {
 	assert.Equal(t, "favour", user.Name)
 
 }
+
+func TestEffectiveAccountStatus(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.Equal(t, models.AccountStatusActive, models.EffectiveAccountStatus("", nil, now))
+	assert.Equal(t, models.AccountStatusBanned, models.EffectiveAccountStatus(models.AccountStatusBanned, &past, now))
+	assert.Equal(t, models.AccountStatusSuspended, models.EffectiveAccountStatus(models.AccountStatusSuspended, nil, now))
+	assert.Equal(t, models.AccountStatusSuspended, models.EffectiveAccountStatus(models.AccountStatusSuspended, &future, now))
+	assert.Equal(t, models.AccountStatusActive, models.EffectiveAccountStatus(models.AccountStatusSuspended, &past, now))
+}
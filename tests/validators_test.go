@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/developia-II/ecommerce-backend/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainRules(t *testing.T) {
+	v := utils.NewValidator()
+	cases := []struct {
+		rule string
+		good []string
+		bad  []string
+	}{
+		{"color", []string{"#fff", "#1A2B3C"}, []string{"fff", "#ffff", "#12345g", "red"}},
+		{"weburl", []string{"https://shop.example.com/a?b=c", "http://localhost:8080"}, []string{"javascript:alert(1)", "ftp://example.com", "https://", "example.com", "https://exa mple.com"}},
+		{"phone", []string{"+2348012345678", "+1 (415) 555-0100", "0044 20 7946 0958"}, []string{"08012345678", "+0123456789", "+234-abc", "+123"}},
+		{"country", []string{"NG", "gb", "US"}, []string{"XX", "NGA", "N"}},
+		{"slug", []string{"lamps", "ada-s-lamps-2"}, []string{"Lamps", "ada--lamps", "-lamps", "lamps-", "ada lamps"}},
+	}
+	for _, tc := range cases {
+		for _, s := range tc.good {
+			assert.NoError(t, v.Var(s, tc.rule), "%s should accept %q", tc.rule, s)
+		}
+		for _, s := range tc.bad {
+			assert.Error(t, v.Var(s, tc.rule), "%s should reject %q", tc.rule, s)
+		}
+	}
+}
+
+func TestDomainRulesOnModels(t *testing.T) {
+	v := utils.NewValidator()
+
+	addr := models.PostalAddress{Name: "Ada", Street1: "1 Marina", City: "Lagos", Country: "ng", Phone: "+234 801 234 5678"}
+	assert.NoError(t, v.Struct(addr))
+	addr.Country, addr.Phone = "ZZ", "0801"
+	resp := utils.ValidationErrorResponse(v.Struct(addr))
+	assert.Equal(t, []utils.FieldError{
+		{Field: "country", Rule: "country", Message: "country must be a two-letter ISO country code"},
+		{Field: "phone", Rule: "phone", Message: "phone must be a phone number with its country code, e.g. +2348012345678"},
+	}, resp.Fields)
+
+	store := models.StoreDetails{
+		StoreName:    "Ada's Lamps",
+		StoreSlug:    "ada-s-lamps",
+		PrimaryColor: "#123abc",
+		SocialLinks:  []models.SocialMediaLink{{Platform: "instagram", Handle: "adalamps"}},
+	}
+	assert.NoError(t, v.Struct(store))
+	store.AccentColor = "blue"
+	store.SocialLinks[0].URL = "javascript:alert(1)"
+	resp = utils.ValidationErrorResponse(v.Struct(store))
+	if assert.Len(t, resp.Fields, 2) {
+		assert.Equal(t, "accentColor", resp.Fields[0].Field)
+		assert.Equal(t, "socialLinks[0].url", resp.Fields[1].Field)
+	}
+
+	product := models.Product{Name: "Lamp", Price: 10, Images: []string{"https://cdn.example.com/lamp.jpg", "lamp.jpg"}}
+	resp = utils.ValidationErrorResponse(v.Struct(product))
+	if assert.Len(t, resp.Fields, 1) {
+		assert.Equal(t, "images[1]", resp.Fields[0].Field)
+	}
+}
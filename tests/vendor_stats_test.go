@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSalesBuckets(t *testing.T) {
+	// Wednesday 2026-03-04, late evening
+	at := time.Date(2026, 3, 4, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), models.BucketStart(at, models.GranularityDay))
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), models.BucketStart(at, models.GranularityWeek))
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), models.BucketStart(at, models.GranularityMonth))
+
+	// A Sunday belongs to the week starting the Monday before
+	sunday := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), models.BucketStart(sunday, models.GranularityWeek))
+
+	// Buckets cover the partial ones at either end of the range
+	from := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	months := models.SalesBuckets(from, to, models.GranularityMonth)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, months)
+	assert.Len(t, models.SalesBuckets(from, to, models.GranularityDay), 46)
+	assert.Len(t, models.SalesBuckets(from, to, models.GranularityWeek), 7)
+}
+
+func TestParseVendorStatsQuery(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	q, err := handlers.ParseVendorStatsQuery("", "", "", "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, models.GranularityDay, q.Granularity)
+	assert.Equal(t, now.AddDate(0, 0, -30), q.From)
+	assert.Equal(t, now, q.To)
+	assert.True(t, q.TopProducts > 0)
+
+	q, err = handlers.ParseVendorStatsQuery("", "2026-01-01", "2026-04-01", "week", now)
+	assert.NoError(t, err)
+	assert.Equal(t, models.GranularityWeek, q.Granularity)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), q.From)
+
+	_, err = handlers.ParseVendorStatsQuery("", "", "", "hour", now)
+	assert.Error(t, err)
+
+	// Two years of days is too many buckets; months are fine
+	_, err = handlers.ParseVendorStatsQuery("", "2024-01-01", "2026-01-01", "day", now)
+	assert.Error(t, err)
+	_, err = handlers.ParseVendorStatsQuery("", "2024-01-01", "2026-01-01", "month", now)
+	assert.NoError(t, err)
+}
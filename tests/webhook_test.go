@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/adapters/repository"
+	"github.com/developia-II/ecommerce-backend/internal/events"
+	"github.com/developia-II/ecommerce-backend/internal/handlers"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryWebhookRepo keeps subscriptions and deliveries in memory.
+type memoryWebhookRepo struct {
+	repository.WebhookRepository // Methods the tests don't use panic
+
+	mu         sync.Mutex
+	subs       []models.WebhookSubscription
+	deliveries map[primitive.ObjectID]models.WebhookDelivery
+}
+
+func (r *memoryWebhookRepo) SubscriptionsFor(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	for _, sub := range r.subs {
+		if sub.Active && sub.Wants(eventType) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *memoryWebhookRepo) RecordDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d.ID = primitive.NewObjectID()
+	r.deliveries[d.ID] = *d
+	return nil
+}
+
+func (r *memoryWebhookRepo) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[d.ID] = *d
+	return nil
+}
+
+func (r *memoryWebhookRepo) ListDeliveries(ctx context.Context, filter bson.M, limit, skip int64) ([]models.WebhookDelivery, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []models.WebhookDelivery
+	for _, d := range r.deliveries {
+		list = append(list, d)
+	}
+	return list, int64(len(list)), nil
+}
+
+func TestDeliverSignsAndRetries(t *testing.T) {
+	var calls int32
+	var signature, event, deliveryID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		signature = r.Header.Get("X-Vendora-Signature")
+		event = r.Header.Get("X-Vendora-Event")
+		deliveryID = r.Header.Get("X-Vendora-Delivery")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	body := []byte(`{"type":"order.paid"}`)
+	result := events.Deliver(context.Background(), server.Client(), server.URL, "s3cret", events.OrderPaid, "d-1", body, 1)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	assert.Equal(t, "sha256="+events.Sign("s3cret", body), signature)
+	assert.Equal(t, events.OrderPaid, event)
+	assert.Equal(t, "d-1", deliveryID)
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	result := events.Deliver(context.Background(), server.Client(), server.URL, "", events.OrderPaid, "d-1", []byte(`{}`), 3)
+	assert.Error(t, result.Err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, http.StatusGone, result.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookSubscriptionWants(t *testing.T) {
+	sub := models.WebhookSubscription{Events: []string{events.OrderPaid, events.ProductCreated}}
+	assert.True(t, sub.Wants(events.ProductCreated))
+	assert.False(t, sub.Wants(events.VendorApproved))
+
+	all := models.WebhookSubscription{Events: []string{models.WebhookAllEvents}}
+	assert.True(t, all.Wants(events.VendorApproved))
+}
+
+func TestWebhookHandlerLogsDeliveries(t *testing.T) {
+	received := make(chan []byte, 2)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Vendora-Signature") == "sha256="+events.Sign("paid-secret", body) {
+			received <- body
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	repo := &memoryWebhookRepo{
+		deliveries: map[primitive.ObjectID]models.WebhookDelivery{},
+		subs: []models.WebhookSubscription{
+			{ID: primitive.NewObjectID(), URL: ok.URL, Events: []string{events.OrderPaid}, Secret: "paid-secret", Active: true},
+			{ID: primitive.NewObjectID(), URL: failing.URL, Events: []string{models.WebhookAllEvents}, Secret: "other", Active: true},
+			{ID: primitive.NewObjectID(), URL: ok.URL, Events: []string{events.OrderPaid}, Secret: "paused", Active: false},
+			{ID: primitive.NewObjectID(), URL: ok.URL, Events: []string{events.ProductCreated}, Secret: "products", Active: true},
+		},
+	}
+	h := &handlers.WebhookHandler{Repo: repo, Client: ok.Client(), MaxRetries: 2}
+
+	e := events.Event{ID: "evt-1", Type: events.OrderPaid, OccurredAt: time.Now(), Data: map[string]interface{}{"orderId": "o1"}}
+	h.HandleEvent(context.Background(), e)
+
+	deliveries, total, _ := repo.ListDeliveries(context.Background(), nil, 50, 0)
+	assert.Equal(t, int64(2), total)
+	for _, d := range deliveries {
+		assert.Equal(t, "evt-1", d.EventID)
+		if d.SubscriptionID == repo.subs[0].ID {
+			assert.Equal(t, models.WebhookDeliverySucceeded, d.Status)
+			assert.NotNil(t, d.DeliveredAt)
+		} else {
+			assert.Equal(t, models.WebhookDeliveryFailed, d.Status)
+			assert.Equal(t, http.StatusBadRequest, d.ResponseStatus)
+			assert.Equal(t, 1, d.Attempts)
+		}
+	}
+	assert.Equal(t, 1, len(received))
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values for a batch of keys. Keys it has no value
+// for are left out of the map.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// BatchLoader coalesces lookups made around the same time into one fetch,
+// so resolving a field on every item of a list costs one query rather than
+// one per item. Results are kept for the loader's lifetime; make one per
+// request.
+type BatchLoader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	batches map[K]*loaderBatch[K, V] // The batch each key was, or will be, fetched in
+	pending *loaderBatch[K, V]
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	full    chan struct{}
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+// NewBatchLoader returns a loader that waits up to wait after the first
+// lookup for more before fetching, or until maxBatch keys are waiting.
+// Fetches run with ctx.
+func NewBatchLoader[K comparable, V any](ctx context.Context, fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *BatchLoader[K, V] {
+	return &BatchLoader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		batches:  map[K]*loaderBatch[K, V]{},
+	}
+}
+
+// Load returns the value for key, and false if there is none.
+func (l *BatchLoader[K, V]) Load(key K) (V, bool, error) {
+	l.mu.Lock()
+	b, ok := l.batches[key]
+	if !ok {
+		if l.pending == nil {
+			l.pending = &loaderBatch[K, V]{full: make(chan struct{}), done: make(chan struct{})}
+			go l.dispatch(l.pending)
+		}
+		b = l.pending
+		b.keys = append(b.keys, key)
+		l.batches[key] = b
+		if len(b.keys) >= l.maxBatch {
+			l.pending = nil
+			close(b.full)
+		}
+	}
+	l.mu.Unlock()
+
+	var zero V
+	select {
+	case <-b.done:
+	case <-l.ctx.Done():
+		return zero, false, l.ctx.Err()
+	}
+	if b.err != nil {
+		return zero, false, b.err
+	}
+	value, found := b.results[key]
+	return value, found, nil
+}
+
+func (l *BatchLoader[K, V]) dispatch(b *loaderBatch[K, V]) {
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-b.full:
+	}
+
+	l.mu.Lock()
+	if l.pending == b {
+		l.pending = nil
+	}
+	keys := b.keys
+	l.mu.Unlock()
+
+	b.results, b.err = l.fetch(l.ctx, keys)
+	close(b.done)
+}
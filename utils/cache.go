@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache is a small in-memory cache for expensive read-mostly results such
+// as aggregation pipelines. Entries expire after the configured TTL.
+type TTLCache[V any] struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[string]cacheEntry[V]
+}
+
+func NewTTLCache[V any](ttl time.Duration) *TTLCache[V] {
+	return &TTLCache[V]{ttl: ttl, items: make(map[string]cacheEntry[V])}
+}
+
+func (c *TTLCache[V]) Get(key string) (V, bool) {
+	c.mu.RLock()
+	entry, ok := c.items[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *TTLCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Sweep expired entries occasionally so the map can't grow unbounded
+	if len(c.items) > 1000 {
+		now := time.Now()
+		for k, e := range c.items {
+			if now.After(e.expiresAt) {
+				delete(c.items, k)
+			}
+		}
+	}
+	c.items[key] = cacheEntry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *TTLCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
@@ -67,7 +67,7 @@ func CheckVendorLimits(ctx context.Context, vendorID primitive.ObjectID, db *mon
 	}, nil
 }
 
-func CheckPayoutEligibility(ctx context.Context, vendorID primitive.ObjectID, amount float64, db *mongo.Database) (bool, error) {
+func CheckPayoutEligibility(ctx context.Context, vendorID primitive.ObjectID, amount models.Money, db *mongo.Database) (bool, error) {
 	var vendor models.VendorAccount
 	collection := db.Collection("vendorAccounts")
 	err := collection.FindOne(ctx, bson.M{"userID": vendorID}).Decode(&vendor)
@@ -81,7 +81,7 @@ func CheckPayoutEligibility(ctx context.Context, vendorID primitive.ObjectID, am
 
 	// Range limit check for Tier 1 and 2
 	if vendor.Tier != "business" {
-		if amount > vendor.MaxMonthlySales {
+		if amount.Float64() > vendor.MaxMonthlySales {
 			return false, fmt.Errorf("withdrawal amount exceeds your monthly limit of %f for %s tier", vendor.MaxMonthlySales, vendor.Tier)
 		}
 	}
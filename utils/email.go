@@ -1,90 +1,123 @@
 package utils
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"errors"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/developia-II/ecommerce-backend/internal/models"
 	"github.com/sirupsen/logrus"
 )
 
-type BrevoEmailRequest struct {
-	Sender      BrevoSender    `json:"sender"`
-	To          []BrevoContact `json:"to"`
-	Subject     string         `json:"subject"`
-	HtmlContent string         `json:"htmlContent"`
+// EmailMessage is a single outgoing HTML email.
+type EmailMessage struct {
+	To      string
+	Subject string
+	HTML    string
 }
 
-type BrevoSender struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+// EmailProvider delivers email through one backend.
+type EmailProvider interface {
+	Name() string
+	Send(msg EmailMessage) error
 }
 
-type BrevoContact struct {
-	Email string `json:"email"`
-	Name  string `json:"name,omitempty"`
+// EmailSendLogger persists email send attempts, typically to the emailLogs
+// collection.
+type EmailSendLogger func(entry models.EmailLog)
+
+var (
+	emailMu       sync.RWMutex
+	emailProvider EmailProvider
+	emailLogger   EmailSendLogger
+)
+
+// SetEmailProvider sets the provider used by SendEmail. The server installs
+// the configured one at startup; pass nil to turn email off.
+func SetEmailProvider(p EmailProvider) {
+	emailMu.Lock()
+	defer emailMu.Unlock()
+	emailProvider = p
 }
 
-func SendEmail(to, subject, body string) error {
-	apiKey := os.Getenv("BREVO_API_KEY")
-	senderEmail := os.Getenv("SENDER_EMAIL")
-	senderName := os.Getenv("SENDER_NAME")
+// SetEmailSendLogger installs the function called after every send attempt.
+func SetEmailSendLogger(fn EmailSendLogger) {
+	emailMu.Lock()
+	defer emailMu.Unlock()
+	emailLogger = fn
+}
 
-	logrus.WithField("apiKey", apiKey[:10]+"...").Info("Using API key")
-	logrus.WithField("senderEmail", senderEmail).Info("Using sender email")
+// ErrEmailNotConfigured is returned when no email provider is set up.
+var ErrEmailNotConfigured = errors.New("email is not configured")
 
-	if apiKey == "" || senderEmail == "" {
-		return fmt.Errorf("BREVO_API_KEY or SENDER_EMAIL not set")
+// currentEmailProvider returns the installed provider.
+func currentEmailProvider() (EmailProvider, error) {
+	emailMu.RLock()
+	defer emailMu.RUnlock()
+	if emailProvider == nil {
+		return nil, ErrEmailNotConfigured
 	}
+	return emailProvider, nil
+}
 
-	// Prepare request payload
-	payload := BrevoEmailRequest{
-		Sender: BrevoSender{
-			Name:  senderName,
-			Email: senderEmail,
-		},
-		To: []BrevoContact{
-			{Email: to},
-		},
-		Subject:     subject,
-		HtmlContent: body,
-	}
+// SendEmail sends a free-form HTML email through the configured provider.
+func SendEmail(to, subject, body string) error {
+	return sendEmail(EmailMessage{To: to, Subject: subject, HTML: body}, "")
+}
 
-	jsonData, err := json.Marshal(payload)
+// SendTemplateEmail renders one of the built-in templates and sends it.
+func SendTemplateEmail(to, template string, data interface{}) error {
+	subject, body, err := RenderEmailTemplate(template, data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal email payload: %w", err)
+		return err
 	}
+	return sendEmail(EmailMessage{To: to, Subject: subject, HTML: body}, template)
+}
 
-	// Send HTTP request to Brevo API
-	req, err := http.NewRequest("POST", "https://api.brevo.com/v3/smtp/email", bytes.NewBuffer(jsonData))
+func sendEmail(msg EmailMessage, template string) error {
+	provider, err := currentEmailProvider()
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		logEmailSend("", msg, template, 0, err)
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("api-key", apiKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
-	}
-	defer resp.Body.Close()
+	start := time.Now()
+	err = provider.Send(msg)
+	logEmailSend(provider.Name(), msg, template, time.Since(start), err)
+	return err
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("brevo API returned status %d", resp.StatusCode)
+func logEmailSend(provider string, msg EmailMessage, template string, took time.Duration, sendErr error) {
+	entry := models.EmailLog{
+		Provider:   provider,
+		To:         msg.To,
+		Subject:    msg.Subject,
+		Template:   template,
+		Status:     models.EmailStatusSent,
+		DurationMs: took.Milliseconds(),
+		CreatedAt:  time.Now(),
 	}
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("brevo API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	if sendErr != nil {
+		entry.Status = models.EmailStatusFailed
+		entry.Error = sendErr.Error()
 	}
 
-	return nil
+	logrus.WithFields(logrus.Fields{
+		"provider": provider,
+		"to":       msg.To,
+		"template": template,
+		"status":   entry.Status,
+	}).Debug("Email send attempt")
+
+	emailMu.RLock()
+	fn := emailLogger
+	emailMu.RUnlock()
+	if fn != nil {
+		fn(entry)
+	}
 }
+
 func IsDisposableEmail(email string) bool {
 	disposableDomains := []string{
 		"10minutemail.com", "tempmail.org", "guerrillamail.com",
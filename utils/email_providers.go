@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+)
+
+var emailHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// NewEmailProvider builds the configured email provider, or returns nil
+// when email isn't configured. The configuration has been validated, so
+// the provider's credentials are there.
+func NewEmailProvider(cfg config.EmailConfig) (EmailProvider, error) {
+	if !cfg.Configured() {
+		return nil, nil
+	}
+	from, name := cfg.SenderEmail, cfg.SenderName
+
+	switch cfg.Provider {
+	case "", "brevo":
+		return &BrevoProvider{APIKey: cfg.BrevoAPIKey, FromEmail: from, FromName: name}, nil
+	case "smtp":
+		return &SMTPProvider{
+			Host:      cfg.SMTP.Host,
+			Port:      cfg.SMTP.Port,
+			Username:  cfg.SMTP.Username,
+			Password:  cfg.SMTP.Password,
+			FromEmail: from,
+			FromName:  name,
+		}, nil
+	case "sendgrid":
+		return &SendGridProvider{APIKey: cfg.SendGridAPIKey, FromEmail: from, FromName: name}, nil
+	case "ses":
+		return &SESProvider{
+			Region:       cfg.SES.Region,
+			AccessKeyID:  cfg.SES.AccessKeyID,
+			SecretKey:    cfg.SES.SecretKey,
+			SessionToken: cfg.SES.SessionToken,
+			FromEmail:    from,
+			FromName:     name,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+}
+
+// formatAddress renders "Name <email>", or just the email without a name.
+func formatAddress(name, email string) string {
+	if name == "" {
+		return email
+	}
+	return mime.QEncoding.Encode("utf-8", name) + " <" + email + ">"
+}
+
+// postEmailJSON posts a JSON payload and fails on any non-2xx response.
+func postEmailJSON(url string, payload interface{}, headers map[string]string) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal email payload: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := emailHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("provider returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// BrevoProvider sends through the Brevo transactional email API.
+type BrevoProvider struct {
+	APIKey    string
+	FromEmail string
+	FromName  string
+}
+
+type BrevoEmailRequest struct {
+	Sender      BrevoSender    `json:"sender"`
+	To          []BrevoContact `json:"to"`
+	Subject     string         `json:"subject"`
+	HtmlContent string         `json:"htmlContent"`
+}
+
+type BrevoSender struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type BrevoContact struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+func (p *BrevoProvider) Name() string { return "brevo" }
+
+func (p *BrevoProvider) Send(msg EmailMessage) error {
+	payload := BrevoEmailRequest{
+		Sender:      BrevoSender{Name: p.FromName, Email: p.FromEmail},
+		To:          []BrevoContact{{Email: msg.To}},
+		Subject:     msg.Subject,
+		HtmlContent: msg.HTML,
+	}
+	return postEmailJSON("https://api.brevo.com/v3/smtp/email", payload, map[string]string{"api-key": p.APIKey})
+}
+
+// SMTPProvider sends through any SMTP server, using STARTTLS when offered.
+type SMTPProvider struct {
+	Host      string
+	Port      string
+	Username  string
+	Password  string
+	FromEmail string
+	FromName  string
+}
+
+func (p *SMTPProvider) Name() string { return "smtp" }
+
+func (p *SMTPProvider) Send(msg EmailMessage) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", formatAddress(p.FromName, p.FromEmail))
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n\r\n")
+	buf.WriteString(msg.HTML)
+
+	var auth smtp.Auth
+	if p.Username != "" {
+		auth = smtp.PlainAuth("", p.Username, p.Password, p.Host)
+	}
+	if err := smtp.SendMail(net.JoinHostPort(p.Host, p.Port), auth, p.FromEmail, []string{msg.To}, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// SendGridProvider sends through the SendGrid v3 mail API.
+type SendGridProvider struct {
+	APIKey    string
+	FromEmail string
+	FromName  string
+}
+
+func (p *SendGridProvider) Name() string { return "sendgrid" }
+
+func (p *SendGridProvider) Send(msg EmailMessage) error {
+	type address struct {
+		Email string `json:"email"`
+		Name  string `json:"name,omitempty"`
+	}
+	payload := map[string]interface{}{
+		"personalizations": []map[string]interface{}{{"to": []address{{Email: msg.To}}}},
+		"from":             address{Email: p.FromEmail, Name: p.FromName},
+		"subject":          msg.Subject,
+		"content":          []map[string]string{{"type": "text/html", "value": msg.HTML}},
+	}
+	return postEmailJSON("https://api.sendgrid.com/v3/mail/send", payload, map[string]string{"Authorization": "Bearer " + p.APIKey})
+}
+
+// SESProvider sends through the Amazon SES v2 API, signing requests with
+// AWS Signature Version 4.
+type SESProvider struct {
+	Region       string
+	AccessKeyID  string
+	SecretKey    string
+	SessionToken string
+	FromEmail    string
+	FromName     string
+}
+
+func (p *SESProvider) Name() string { return "ses" }
+
+func (p *SESProvider) Send(msg EmailMessage) error {
+	payload := map[string]interface{}{
+		"FromEmailAddress": formatAddress(p.FromName, p.FromEmail),
+		"Destination":      map[string]interface{}{"ToAddresses": []string{msg.To}},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{
+				"Subject": map[string]string{"Data": msg.Subject, "Charset": "UTF-8"},
+				"Body": map[string]interface{}{
+					"Html": map[string]string{"Data": msg.HTML, "Charset": "UTF-8"},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal email payload: %w", err)
+	}
+
+	host := "email." + p.Region + ".amazonaws.com"
+	path := "/v2/email/outbound-emails"
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	bodyHash := sha256Hex(body)
+
+	headers := map[string]string{
+		"content-type":         "application/json",
+		"host":                 host,
+		"x-amz-content-sha256": bodyHash,
+		"x-amz-date":           amzDate,
+	}
+	if p.SessionToken != "" {
+		headers["x-amz-security-token"] = p.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost, path, "", canonicalHeaders.String(), signedHeaders, bodyHash,
+	}, "\n")
+	scope := day + "/" + p.Region + "/ses/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+p.SecretKey), day)
+	key = hmacSHA256(key, p.Region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req, err := http.NewRequest(http.MethodPost, "https://"+host+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		if k != "host" {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.AccessKeyID, scope, signedHeaders, signature))
+
+	resp, err := emailHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("provider returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Built-in email templates
+const (
+	EmailTemplateVerification      = "verification"
+	EmailTemplatePasswordReset     = "password_reset"
+	EmailTemplateOrderPlaced       = "order_placed"
+	EmailTemplateOrderConfirmation = "order_confirmation"
+	EmailTemplateShippingUpdate    = "shipping_update"
+	EmailTemplateVendorNewOrder    = "vendor_new_order"
+	EmailTemplateVendorApproval    = "vendor_approval"
+	EmailTemplateVendorDigest      = "vendor_digest"
+	EmailTemplateBuyerDigest       = "buyer_digest"
+)
+
+// VerificationEmailData fills the verification template.
+type VerificationEmailData struct {
+	Name   string
+	Link   string
+	Resend bool // Worded as a reminder rather than a welcome
+}
+
+// PasswordResetEmailData fills the password reset template.
+type PasswordResetEmailData struct {
+	Name string
+	Link string
+}
+
+type OrderEmailItem struct {
+	Name     string
+	Quantity int
+	Subtotal float64
+}
+
+type OrderEmailNote struct {
+	StoreName string
+	Message   string
+}
+
+// OrderConfirmationEmailData fills the order placed and order confirmation
+// templates.
+type OrderConfirmationEmailData struct {
+	Name        string
+	OrderNumber string
+	Items       []OrderEmailItem
+	Total       float64
+	Notes       []OrderEmailNote // Announcements from the order's stores
+}
+
+// ShippingUpdateEmailData fills the shipping update template.
+type ShippingUpdateEmailData struct {
+	Name           string
+	OrderNumber    string
+	Status         string
+	TrackingNumber string
+	TrackingURL    string // Carrier tracking page, when there is a tracking number
+	Link           string
+}
+
+// VendorNewOrderEmailData fills the vendor new order template. Items and
+// Subtotal cover only the vendor's own lines.
+type VendorNewOrderEmailData struct {
+	Name        string
+	OrderNumber string
+	Items       []OrderEmailItem
+	Subtotal    float64
+	Link        string
+}
+
+// VendorApprovalEmailData fills the vendor approval template.
+type VendorApprovalEmailData struct {
+	Name string
+	Tier string
+	Link string
+}
+
+// DigestEmailProduct is one product line in a digest email.
+type DigestEmailProduct struct {
+	Name          string
+	Price         float64
+	PreviousPrice float64 // Price drops only
+	Quantity      int     // Units sold, or units left for low stock
+	Link          string
+}
+
+// VendorDigestEmailData fills the weekly vendor digest template.
+type VendorDigestEmailData struct {
+	Name            string
+	Revenue         float64
+	UnitsSold       int
+	OrderCount      int
+	TopProducts     []DigestEmailProduct
+	LowStock        []DigestEmailProduct
+	Link            string
+	UnsubscribeLink string
+}
+
+// BuyerDigestEmailData fills the weekly buyer digest template.
+type BuyerDigestEmailData struct {
+	Name            string
+	PriceDrops      []DigestEmailProduct
+	Recommendations []DigestEmailProduct
+	Link            string
+	UnsubscribeLink string
+}
+
+const emailLayout = `{{define "layout"}}<html>
+<body style="font-family: Arial, sans-serif;">
+{{template "content" .}}
+<p>Best regards,<br>The Vendora Team</p>
+</body>
+</html>{{end}}`
+
+const emailUnsubscribe = `{{define "unsubscribe"}}<p style="font-size: 12px; color: #888;">You're receiving this weekly summary because of your Vendora notification settings. <a href="{{.}}" style="color: #888;">Unsubscribe from weekly digests</a>.</p>{{end}}`
+
+const emailButton = `{{define "button"}}<a href="{{.Link}}" style="background-color: #4CAF50; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px;">{{.Label}}</a>{{end}}`
+
+var emailTemplateSources = map[string]struct {
+	Subject string
+	Body    string
+}{
+	EmailTemplateVerification: {
+		Subject: "Verify Your Vendora Account",
+		Body: `{{define "content"}}<h2>Welcome to Vendora, {{.Name}}!</h2>
+{{if .Resend}}<p>Here is your verification link again. Please verify your email by clicking the button below:</p>
+{{else}}<p>Thank you for registering. Please verify your email by clicking the button below:</p>
+{{end}}{{template "button" (button .Link "Verify Email")}}
+<p>If you didn't create this account, please ignore this email.</p>{{end}}`,
+	},
+	EmailTemplatePasswordReset: {
+		Subject: "Reset Your Vendora Password",
+		Body: `{{define "content"}}<h2>Password Reset Request</h2>
+<p>Hi {{.Name}},</p>
+<p>You requested to reset your password. Click the button below to reset it:</p>
+{{template "button" (button .Link "Reset Password")}}
+<p>This link will expire in 1 hour.</p>
+<p>If you didn't request this, please ignore this email.</p>{{end}}`,
+	},
+	EmailTemplateOrderPlaced: {
+		Subject: "We've received your Vendora order {{.OrderNumber}}",
+		Body: `{{define "content"}}<h2>Thanks for your order, {{.Name}}!</h2>
+<p>We've received order <strong>{{.OrderNumber}}</strong>. We'll email you again as soon as your payment is confirmed.</p>
+<table cellpadding="6">{{range .Items}}<tr><td>{{.Name}}</td><td>x{{.Quantity}}</td><td>{{money .Subtotal}}</td></tr>{{end}}</table>
+<p><strong>Total: {{money .Total}}</strong></p>{{end}}`,
+	},
+	EmailTemplateOrderConfirmation: {
+		Subject: "Your Vendora order {{.OrderNumber}} is confirmed",
+		Body: `{{define "content"}}<h2>Thanks for your order, {{.Name}}!</h2>
+<p>We've received your payment for order <strong>{{.OrderNumber}}</strong>.</p>
+<table cellpadding="6">{{range .Items}}<tr><td>{{.Name}}</td><td>x{{.Quantity}}</td><td>{{money .Subtotal}}</td></tr>{{end}}</table>
+<p><strong>Total: {{money .Total}}</strong></p>
+{{if .Notes}}<h3>Notes from your sellers</h3><ul>{{range .Notes}}<li>{{if .StoreName}}<strong>{{.StoreName}}:</strong> {{end}}{{.Message}}</li>{{end}}</ul>{{end}}{{end}}`,
+	},
+	EmailTemplateShippingUpdate: {
+		Subject: "Your Vendora order {{.OrderNumber}} is {{.Status}}",
+		Body: `{{define "content"}}<h2>Good news, {{.Name}}!</h2>
+<p>Your order <strong>{{.OrderNumber}}</strong> is now <strong>{{.Status}}</strong>.</p>
+{{if .TrackingNumber}}<p>Tracking number: {{if .TrackingURL}}<a href="{{.TrackingURL}}">{{.TrackingNumber}}</a>{{else}}<strong>{{.TrackingNumber}}</strong>{{end}}</p>{{end}}
+{{if .Link}}{{template "button" (button .Link "View Order")}}{{end}}{{end}}`,
+	},
+	EmailTemplateVendorNewOrder: {
+		Subject: "New paid order {{.OrderNumber}} to fulfil",
+		Body: `{{define "content"}}<h2>You have a new order, {{.Name}}!</h2>
+<p>Order <strong>{{.OrderNumber}}</strong> has been paid and is ready to fulfil.</p>
+<table cellpadding="6">{{range .Items}}<tr><td>{{.Name}}</td><td>x{{.Quantity}}</td><td>{{money .Subtotal}}</td></tr>{{end}}</table>
+<p><strong>Your items: {{money .Subtotal}}</strong></p>
+{{if .Link}}{{template "button" (button .Link "Fulfil Order")}}{{end}}{{end}}`,
+	},
+	EmailTemplateVendorApproval: {
+		Subject: "Your Vendora seller application was approved",
+		Body: `{{define "content"}}<h2>Congratulations, {{.Name}}!</h2>
+<p>Your seller application has been approved{{if .Tier}} on the <strong>{{.Tier}}</strong> tier{{end}}. You can now set up your store and start listing products.</p>
+{{if .Link}}{{template "button" (button .Link "Go to Dashboard")}}{{end}}{{end}}`,
+	},
+	EmailTemplateVendorDigest: {
+		Subject: "Your Vendora week: {{.OrderCount}} orders, {{money .Revenue}} in sales",
+		Body: `{{define "content"}}<h2>Your week on Vendora, {{.Name}}</h2>
+<p>Over the last 7 days you sold <strong>{{.UnitsSold}}</strong> items across <strong>{{.OrderCount}}</strong> orders, for <strong>{{money .Revenue}}</strong> in sales.</p>
+{{if .TopProducts}}<h3>Top sellers</h3>
+<table cellpadding="6">{{range .TopProducts}}<tr><td>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td>{{.Quantity}} sold</td><td>{{money .Price}}</td></tr>{{end}}</table>{{end}}
+{{if .LowStock}}<h3>Running low</h3>
+<table cellpadding="6">{{range .LowStock}}<tr><td>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td>{{if .Quantity}}{{.Quantity}} left{{else}}<strong>Out of stock</strong>{{end}}</td></tr>{{end}}</table>{{end}}
+{{if .Link}}{{template "button" (button .Link "Go to Dashboard")}}{{end}}
+{{if .UnsubscribeLink}}{{template "unsubscribe" .UnsubscribeLink}}{{end}}{{end}}`,
+	},
+	EmailTemplateBuyerDigest: {
+		Subject: "{{if .PriceDrops}}Price drops on your Vendora wishlist{{else}}Picked for you on Vendora this week{{end}}",
+		Body: `{{define "content"}}<h2>Hi {{.Name}}, here's your week on Vendora</h2>
+{{if .PriceDrops}}<h3>Price drops on your wishlist</h3>
+<table cellpadding="6">{{range .PriceDrops}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td><td><s>{{money .PreviousPrice}}</s></td><td><strong>{{money .Price}}</strong></td></tr>{{end}}</table>{{end}}
+{{if .Recommendations}}<h3>You might also like</h3>
+<table cellpadding="6">{{range .Recommendations}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td><td>{{money .Price}}</td></tr>{{end}}</table>{{end}}
+{{if .Link}}{{template "button" (button .Link "View Wishlist")}}{{end}}
+{{if .UnsubscribeLink}}{{template "unsubscribe" .UnsubscribeLink}}{{end}}{{end}}`,
+	},
+}
+
+var emailFuncs = map[string]interface{}{
+	"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"button": func(link, label string) map[string]string {
+		return map[string]string{"Link": link, "Label": label}
+	},
+}
+
+type emailTemplate struct {
+	subject *texttemplate.Template
+	body    *htmltemplate.Template
+}
+
+// emailTemplates are parsed once at startup so a broken template fails fast.
+var emailTemplates = func() map[string]emailTemplate {
+	out := make(map[string]emailTemplate, len(emailTemplateSources))
+	for name, src := range emailTemplateSources {
+		body := htmltemplate.Must(htmltemplate.New(name).Funcs(emailFuncs).Parse(emailLayout + emailButton + emailUnsubscribe + src.Body))
+		subject := texttemplate.Must(texttemplate.New(name).Funcs(emailFuncs).Parse(src.Subject))
+		out[name] = emailTemplate{subject: subject, body: body}
+	}
+	return out
+}()
+
+// RenderEmailTemplate renders a built-in template's subject and HTML body.
+// Values are HTML-escaped in the body.
+func RenderEmailTemplate(name string, data interface{}) (string, string, error) {
+	tmpl, ok := emailTemplates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.body.ExecuteTemplate(&body, "layout", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	return subject.String(), body.String(), nil
+}
+
+// TrackingURL links a tracking number to a carrier tracking page. The page
+// comes from TRACKING_URL_TEMPLATE, with {tracking} replaced by the number;
+// by default a multi-carrier tracker is used.
+func TrackingURL(trackingNumber string) string {
+	if trackingNumber == "" {
+		return ""
+	}
+	tmpl := os.Getenv("TRACKING_URL_TEMPLATE")
+	if tmpl == "" {
+		tmpl = "https://t.17track.net/en#nums={tracking}"
+	}
+	return strings.ReplaceAll(tmpl, "{tracking}", url.QueryEscape(trackingNumber))
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorCode identifies why a request failed. Clients branch on codes and
+// look up their own wording for them, so a code is never renamed or reused
+// once shipped; add a new one instead.
+type ErrorCode string
+
+const (
+	// Requests
+	CodeInvalidRequest    ErrorCode = "INVALID_REQUEST"
+	CodeValidationFailed  ErrorCode = "VALIDATION_FAILED"
+	CodeInvalidCursor     ErrorCode = "INVALID_CURSOR"
+	CodeUnknownField      ErrorCode = "UNKNOWN_FIELD"
+	CodeInvalidSort       ErrorCode = "INVALID_SORT"
+	CodeRateLimited       ErrorCode = "RATE_LIMITED"
+	CodeAPIVersionRetired ErrorCode = "API_VERSION_RETIRED"
+
+	// Accounts
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeForbidden        ErrorCode = "FORBIDDEN"
+	CodeAccountBanned    ErrorCode = "ACCOUNT_BANNED"
+	CodeAccountSuspended ErrorCode = "ACCOUNT_SUSPENDED"
+
+	// Vendors
+	CodeVendorLimitReached ErrorCode = "VENDOR_LIMIT_REACHED"
+	CodeVendorInactive     ErrorCode = "VENDOR_INACTIVE"
+
+	// Cart and checkout
+	CodeCartEmpty             ErrorCode = "CART_EMPTY"
+	CodeCartItemOutOfStock    ErrorCode = "CART_ITEM_OUT_OF_STOCK"
+	CodeCartItemUnavailable   ErrorCode = "CART_ITEM_UNAVAILABLE"
+	CodeAddressUndeliverable  ErrorCode = "ADDRESS_UNDELIVERABLE"
+	CodeShippingRestricted    ErrorCode = "SHIPPING_RESTRICTED"
+	CodeShippingUnavailable   ErrorCode = "SHIPPING_UNAVAILABLE"
+	CodeShippingMethodUnknown ErrorCode = "SHIPPING_METHOD_UNKNOWN"
+	CodePickupLocationUnknown ErrorCode = "PICKUP_LOCATION_UNKNOWN"
+
+	// Coupons
+	CodeCouponNotFound      ErrorCode = "COUPON_NOT_FOUND"
+	CodeCouponInactive      ErrorCode = "COUPON_INACTIVE"
+	CodeCouponUsedUp        ErrorCode = "COUPON_USED_UP"
+	CodeCouponUserLimit     ErrorCode = "COUPON_USER_LIMIT"
+	CodeCouponMinSpend      ErrorCode = "COUPON_MIN_SPEND"
+	CodeCouponNotApplicable ErrorCode = "COUPON_NOT_APPLICABLE"
+
+	// Flash sales
+	CodeFlashSaleNotFound  ErrorCode = "FLASH_SALE_NOT_FOUND"
+	CodeFlashSaleNotLive   ErrorCode = "FLASH_SALE_NOT_LIVE"
+	CodeFlashSaleSoldOut   ErrorCode = "FLASH_SALE_SOLD_OUT"
+	CodeFlashSaleUserLimit ErrorCode = "FLASH_SALE_USER_LIMIT"
+
+	// Disputes
+	CodeDisputeNotPaid      ErrorCode = "DISPUTE_NOT_PAID"
+	CodeDisputeWindowClosed ErrorCode = "DISPUTE_WINDOW_CLOSED"
+)
+
+// FieldError is one field of a request that failed validation. Field is its
+// JSON path, e.g. "shipTo.postalCode"; Rule is the validation tag it broke,
+// with Param its argument, e.g. "max" and "100".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// CodedErrorResponse is ErrorResponse with a code from the catalog above.
+func CodedErrorResponse(code ErrorCode, message string) Response {
+	return Response{Success: false, Error: message, Code: code}
+}
+
+// ValidationErrorResponse reports a request that failed binding or
+// validation, listing each field that failed. Errors other than validation
+// failures, such as malformed JSON, are reported without detail.
+func ValidationErrorResponse(err error) Response {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return CodedErrorResponse(CodeInvalidRequest, "Invalid request body")
+	}
+	fields := make([]FieldError, 0, len(invalid))
+	for _, fe := range invalid {
+		field := fieldPath(fe)
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: field + " " + ruleMessage(fe),
+		})
+	}
+	resp := CodedErrorResponse(CodeValidationFailed, "Validation failed")
+	resp.Fields = fields
+	return resp
+}
+
+// fieldPath is the failed field's namespace without the struct it started
+// from: "Input.shipTo.city" becomes "shipTo.city".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "gte":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must have at least %s characters or items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if isSized(fe.Kind()) {
+			return fmt.Sprintf("must have at most %s characters or items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "len":
+		return fmt.Sprintf("must have exactly %s characters or items", fe.Param())
+	case "numeric", "number":
+		return "must be a number"
+	case "hexcolor", "color":
+		return "must be a hex colour such as #1A2B3C"
+	case "weburl":
+		return "must be a web address starting with http:// or https://"
+	case "phone":
+		return "must be a phone number with its country code, e.g. +2348012345678"
+	case "country":
+		return "must be a two-letter ISO country code"
+	case "slug":
+		return "must be lower-case letters and numbers separated by single hyphens"
+	case "locale":
+		return "must be one of the supported locales: " + strings.Join(i18n.Supported()[1:], ", ")
+	}
+	return "is invalid"
+}
+
+func isSized(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+}
+
+// NewValidator returns a validator that names fields by their JSON names,
+// as clients know them, with this domain's rules; see RegisterRules.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	UseJSONFieldNames(v)
+	RegisterRules(v)
+	return v
+}
+
+// UseJSONFieldNames makes v report fields by their JSON names, for
+// validators made elsewhere such as gin's.
+func UseJSONFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+}
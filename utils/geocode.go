@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+)
+
+// Geocoder finds addresses on the map. Geocode returns nil, nil for an
+// address that can't be found.
+type Geocoder interface {
+	Geocode(addr models.PostalAddress) (*models.GeoPoint, error)
+}
+
+// NewGeocoder builds the configured geocoder. It returns nil when there
+// isn't one, in which case addresses are only checked for format.
+func NewGeocoder(cfg config.GeocoderConfig) (Geocoder, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "google":
+		return &GoogleGeocoder{APIKey: cfg.GoogleMapsAPIKey}, nil
+	}
+	return nil, fmt.Errorf("unknown geocoder %q", cfg.Provider)
+}
+
+var (
+	geocoderMu sync.RWMutex
+	geocoder   Geocoder
+)
+
+// SetGeocoder sets the geocoder returned by CurrentGeocoder. The server
+// installs the configured one at startup; pass nil to turn geocoding off.
+func SetGeocoder(g Geocoder) {
+	geocoderMu.Lock()
+	defer geocoderMu.Unlock()
+	geocoder = g
+}
+
+// CurrentGeocoder returns the installed geocoder, or nil if there isn't one.
+func CurrentGeocoder() Geocoder {
+	geocoderMu.RLock()
+	defer geocoderMu.RUnlock()
+	return geocoder
+}
+
+var geocodeHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// GoogleGeocoder uses the Google Maps Geocoding API.
+type GoogleGeocoder struct {
+	APIKey string
+}
+
+const googleGeocodeURL = "https://maps.googleapis.com/maps/api/geocode/json"
+
+func (g *GoogleGeocoder) Geocode(addr models.PostalAddress) (*models.GeoPoint, error) {
+	components := "country:" + addr.Country
+	if addr.PostalCode != "" {
+		components += "|postal_code:" + addr.PostalCode
+	}
+	q := url.Values{
+		"address":    {joinLocation(addr.Street1, addr.Street2, addr.City, addr.State)},
+		"components": {components},
+		"key":        {g.APIKey},
+	}
+	req, err := http.NewRequest(http.MethodGet, googleGeocodeURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+		Results      []struct {
+			Geometry struct {
+				Location models.GeoPoint `json:"location"`
+			} `json:"geometry"`
+		} `json:"results"`
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := geocodeHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoder returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode geocoder response: %w", err)
+	}
+
+	switch out.Status {
+	case "OK":
+		if len(out.Results) == 0 {
+			return nil, nil
+		}
+		point := out.Results[0].Geometry.Location
+		return &point, nil
+	case "ZERO_RESULTS":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("geocoder returned %s: %s", out.Status, out.ErrorMessage)
+}
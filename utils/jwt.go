@@ -4,7 +4,6 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,32 +12,54 @@ import (
 type JWTClaims struct {
 	UserID string `json:"userId"`
 	Role   string `json:"role"`
+	// ImpersonatorID is set on tokens an admin issued to act as this user
+	ImpersonatorID string `json:"impersonatorId,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// jwtSecret is set from the loaded configuration at startup.
+var jwtSecret string
+
+// SetJWTSecret sets the key tokens and unsubscribe links are signed with.
+func SetJWTSecret(secret string) {
+	jwtSecret = secret
+}
+
+func signingSecret() (string, error) {
+	if jwtSecret == "" {
+		return "", errors.New("JWT secret not set")
+	}
+	return jwtSecret, nil
+}
+
 func GenerateToken(userId string, userRole string, duration time.Duration) (string, error) {
+	return signToken(JWTClaims{UserID: userId, Role: userRole}, duration)
+}
 
-	JWT_SECRET := os.Getenv("JWT_SECRET")
-	if JWT_SECRET == "" {
-		return "", errors.New("JWT_SECRET not set in environment")
+// GenerateImpersonationToken issues a token that acts as userId on behalf of
+// the admin adminId. AuthMiddleware recognises it by the impersonatorId claim.
+func GenerateImpersonationToken(userId, userRole, adminId string, duration time.Duration) (string, error) {
+	return signToken(JWTClaims{UserID: userId, Role: userRole, ImpersonatorID: adminId}, duration)
+}
+
+func signToken(claims JWTClaims, duration time.Duration) (string, error) {
+	JWT_SECRET, err := signingSecret()
+	if err != nil {
+		return "", err
 	}
-	claims := JWTClaims{
-		UserID: userId,
-		Role:   userRole,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "vendora",
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    "vendora",
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(JWT_SECRET))
 }
 
 func VerifyToken(tokenString string) (*JWTClaims, error) {
-	JWT_SECRET := os.Getenv("JWT_SECRET")
-	if JWT_SECRET == "" {
-		return nil, errors.New("JWT_SECRET not set in environment")
+	JWT_SECRET, err := signingSecret()
+	if err != nil {
+		return nil, err
 	}
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(JWT_SECRET), nil
@@ -64,6 +85,29 @@ func GenerateSecureToken(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// GenerateNumericCode returns a random code of the given number of digits,
+// for one-time passcodes.
+func GenerateNumericCode(digits int) (string, error) {
+	bytes := make([]byte, digits)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	code := make([]byte, digits)
+	for i, b := range bytes {
+		// 250 is the largest multiple of 10 below 256; skipping the rest
+		// keeps every digit equally likely
+		for b >= 250 {
+			var one [1]byte
+			if _, err := rand.Read(one[:]); err != nil {
+				return "", err
+			}
+			b = one[0]
+		}
+		code[i] = '0' + b%10
+	}
+	return string(code), nil
+}
+
 func GenerateRefreshToken() (string, error) {
 	return GenerateSecureToken(32)
 }
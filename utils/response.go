@@ -1,10 +1,18 @@
 package utils
 
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success bool         `json:"success"`
+	Message string       `json:"message,omitempty"`
+	Data    interface{}  `json:"data,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Code    ErrorCode    `json:"code,omitempty"`   // See error_codes.go
+	Fields  []FieldError `json:"fields,omitempty"` // The fields that failed validation
 }
 
 func SuccessResponse(message string, data interface{}) Response {
@@ -14,3 +22,106 @@ func SuccessResponse(message string, data interface{}) Response {
 func ErrorResponse(message string) Response {
 	return Response{Success: false, Error: message}
 }
+
+// EnvelopeV2 is the body of every JSON response from API v2. Successful
+// responses carry Data, and Meta for anything the handler returned beside
+// it; failed ones carry Error instead.
+type EnvelopeV2 struct {
+	Data    json.RawMessage            `json:"data,omitempty"`
+	Message string                     `json:"message,omitempty"`
+	Meta    map[string]json.RawMessage `json:"meta,omitempty"`
+	Error   *APIError                  `json:"error,omitempty"`
+}
+
+// APIError says why a v2 request failed. Code is the handler's ErrorCode,
+// or else the status as a word, e.g. "not_found"; Details holds anything
+// else the handler reported, such as the fields that failed validation.
+type APIError struct {
+	Status  int                        `json:"status"`
+	Code    string                     `json:"code"`
+	Message string                     `json:"message"`
+	Details map[string]json.RawMessage `json:"details,omitempty"`
+}
+
+// envelopeKeys are the fields of the v1 shapes that ToEnvelopeV2 maps onto
+// the envelope; any others are kept in Meta or Details.
+var envelopeKeys = map[string]bool{"success": true, "message": true, "data": true, "error": true}
+
+// ToEnvelopeV2 puts a v1 JSON response in the v2 envelope. v1 handlers
+// answer with Response, with their own gin.H carrying a "success" flag, or
+// with the payload alone; status decides between success and error.
+func ToEnvelopeV2(status int, body []byte) EnvelopeV2 {
+	var fields map[string]json.RawMessage
+	isObject := json.Unmarshal(body, &fields) == nil && fields != nil
+
+	var success *bool
+	if isObject {
+		if raw, ok := fields["success"]; ok {
+			var flag bool
+			if json.Unmarshal(raw, &flag) == nil {
+				success = &flag
+			}
+		}
+	}
+
+	if status >= http.StatusBadRequest || (success != nil && !*success) {
+		if status < http.StatusBadRequest {
+			// A failure reported with a success status
+			status = http.StatusBadRequest
+		}
+		apiErr := &APIError{
+			Status:  status,
+			Code:    strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")),
+			Message: http.StatusText(status),
+		}
+		if isObject {
+			if code := jsonString(fields["code"]); code != "" {
+				apiErr.Code = code
+			}
+			if msg := jsonString(fields["error"]); msg != "" {
+				apiErr.Message = msg
+			} else if msg := jsonString(fields["message"]); msg != "" {
+				apiErr.Message = msg
+			}
+			apiErr.Details = extraFields(fields)
+			delete(apiErr.Details, "code")
+		}
+		return EnvelopeV2{Error: apiErr}
+	}
+
+	if success == nil {
+		// The payload alone
+		return EnvelopeV2{Data: json.RawMessage(body)}
+	}
+	envelope := EnvelopeV2{
+		Data:    fields["data"],
+		Message: jsonString(fields["message"]),
+		Meta:    extraFields(fields),
+	}
+	if envelope.Data == nil {
+		envelope.Data = json.RawMessage("null")
+	}
+	return envelope
+}
+
+func jsonString(raw json.RawMessage) string {
+	var s string
+	if raw != nil && json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return ""
+}
+
+func extraFields(fields map[string]json.RawMessage) map[string]json.RawMessage {
+	var extra map[string]json.RawMessage
+	for key, value := range fields {
+		if envelopeKeys[key] {
+			continue
+		}
+		if extra == nil {
+			extra = map[string]json.RawMessage{}
+		}
+		extra[key] = value
+	}
+	return extra
+}
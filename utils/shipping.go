@@ -0,0 +1,569 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+	"github.com/developia-II/ecommerce-backend/internal/models"
+)
+
+// CarrierRate is a price a carrier offers for a shipment. ID and ShipmentID
+// are the provider's, needed to buy it.
+type CarrierRate struct {
+	ID            string  `json:"id"`
+	ShipmentID    string  `json:"shipmentId,omitempty"`
+	Carrier       string  `json:"carrier"`
+	Service       string  `json:"service"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	EstimatedDays int     `json:"estimatedDays,omitempty"`
+}
+
+// CarrierShipment is a bought shipment.
+type CarrierShipment struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"trackingNumber"`
+	TrackingURL    string `json:"trackingUrl,omitempty"`
+	LabelURL       string `json:"labelUrl"`
+}
+
+// Tracking statuses, the same whichever provider reported them
+const (
+	TrackingPreTransit     = models.TrackingPreTransit
+	TrackingInTransit      = models.TrackingInTransit
+	TrackingOutForDelivery = models.TrackingOutForDelivery
+	TrackingDelivered      = models.TrackingDelivered
+	TrackingReturned       = models.TrackingReturned
+	TrackingFailure        = models.TrackingFailure
+	TrackingUnknown        = models.TrackingUnknown
+)
+
+// TrackingEvent is one scan in a parcel's journey.
+type TrackingEvent struct {
+	Status   string    `json:"status"`
+	Details  string    `json:"details"`
+	Location string    `json:"location,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// TrackingInfo is where a parcel has got to, with its history oldest first.
+type TrackingInfo struct {
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"trackingNumber"`
+	Status         string          `json:"status"`
+	Events         []TrackingEvent `json:"events"`
+}
+
+// ShippingProvider fetches rates, buys shipments and tracks parcels through
+// one carrier aggregator.
+type ShippingProvider interface {
+	Name() string
+	Rates(from, to models.PostalAddress, parcel models.Dimensions) ([]CarrierRate, error)
+	Purchase(rate CarrierRate) (CarrierShipment, error)
+	Track(carrier, trackingNumber string) (TrackingInfo, error)
+}
+
+// NewShippingProvider builds the configured provider. It returns nil when
+// there isn't one, in which case shipping is priced from the rate tables
+// alone.
+func NewShippingProvider(cfg config.ShippingConfig) (ShippingProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "shippo":
+		return &ShippoProvider{APIKey: cfg.ShippoAPIKey}, nil
+	case "easypost":
+		return &EasyPostProvider{APIKey: cfg.EasyPostAPIKey}, nil
+	}
+	return nil, fmt.Errorf("unknown shipping provider %q", cfg.Provider)
+}
+
+var (
+	shippingMu       sync.RWMutex
+	shippingProvider ShippingProvider
+)
+
+// SetShippingProvider sets the provider returned by CurrentShippingProvider.
+// The server installs the configured one at startup; pass nil to turn
+// carrier integration off.
+func SetShippingProvider(p ShippingProvider) {
+	shippingMu.Lock()
+	defer shippingMu.Unlock()
+	shippingProvider = p
+}
+
+// CurrentShippingProvider returns the installed provider, or nil if there
+// isn't one.
+func CurrentShippingProvider() ShippingProvider {
+	shippingMu.RLock()
+	defer shippingMu.RUnlock()
+	return shippingProvider
+}
+
+// CheapestRate returns the lowest-priced rate.
+func CheapestRate(rates []CarrierRate) (CarrierRate, bool) {
+	if len(rates) == 0 {
+		return CarrierRate{}, false
+	}
+	cheapest := rates[0]
+	for _, r := range rates[1:] {
+		if r.Amount < cheapest.Amount {
+			cheapest = r
+		}
+	}
+	return cheapest, true
+}
+
+var shippingHTTPClient = &http.Client{Timeout: 20 * time.Second}
+
+// doShippingJSON sends a JSON request and decodes a JSON reply into out.
+func doShippingJSON(req *http.Request, payload, out interface{}) error {
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := shippingHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("shipping provider request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("shipping provider returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func joinLocation(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// ShippoProvider uses the Shippo API.
+type ShippoProvider struct {
+	APIKey string
+}
+
+const shippoBaseURL = "https://api.goshippo.com"
+
+func (p *ShippoProvider) Name() string { return "shippo" }
+
+func (p *ShippoProvider) newRequest(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, shippoBaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "ShippoToken "+p.APIKey)
+	return req, nil
+}
+
+// carrierAddress is an address in the fields both Shippo and EasyPost use.
+func carrierAddress(a models.PostalAddress) map[string]string {
+	return map[string]string{
+		"name":    a.Name,
+		"street1": a.Street1,
+		"street2": a.Street2,
+		"city":    a.City,
+		"state":   a.State,
+		"zip":     a.PostalCode,
+		"country": a.Country,
+		"phone":   a.Phone,
+		"email":   a.Email,
+	}
+}
+
+func (p *ShippoProvider) Rates(from, to models.PostalAddress, parcel models.Dimensions) ([]CarrierRate, error) {
+	req, err := p.newRequest(http.MethodPost, "/shipments/")
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{
+		"address_from": carrierAddress(from),
+		"address_to":   carrierAddress(to),
+		"parcels": []map[string]string{{
+			"length":        strconv.FormatFloat(parcel.Length, 'f', 2, 64),
+			"width":         strconv.FormatFloat(parcel.Width, 'f', 2, 64),
+			"height":        strconv.FormatFloat(parcel.Height, 'f', 2, 64),
+			"distance_unit": "cm",
+			"weight":        strconv.FormatFloat(parcel.Weight, 'f', 3, 64),
+			"mass_unit":     "kg",
+		}},
+		"async": false,
+	}
+	var resp struct {
+		Rates []struct {
+			ObjectID      string `json:"object_id"`
+			Provider      string `json:"provider"`
+			Amount        string `json:"amount"`
+			Currency      string `json:"currency"`
+			EstimatedDays int    `json:"estimated_days"`
+			ServiceLevel  struct {
+				Name string `json:"name"`
+			} `json:"servicelevel"`
+		} `json:"rates"`
+	}
+	if err := doShippingJSON(req, payload, &resp); err != nil {
+		return nil, err
+	}
+
+	rates := make([]CarrierRate, 0, len(resp.Rates))
+	for _, r := range resp.Rates {
+		amount, err := strconv.ParseFloat(r.Amount, 64)
+		if err != nil {
+			continue
+		}
+		rates = append(rates, CarrierRate{
+			ID:            r.ObjectID,
+			Carrier:       r.Provider,
+			Service:       r.ServiceLevel.Name,
+			Amount:        amount,
+			Currency:      r.Currency,
+			EstimatedDays: r.EstimatedDays,
+		})
+	}
+	return rates, nil
+}
+
+func (p *ShippoProvider) Purchase(rate CarrierRate) (CarrierShipment, error) {
+	req, err := p.newRequest(http.MethodPost, "/transactions/")
+	if err != nil {
+		return CarrierShipment{}, err
+	}
+	payload := map[string]interface{}{"rate": rate.ID, "label_file_type": "PDF", "async": false}
+	var resp struct {
+		Status         string `json:"status"`
+		TrackingNumber string `json:"tracking_number"`
+		TrackingURL    string `json:"tracking_url_provider"`
+		LabelURL       string `json:"label_url"`
+		Messages       []struct {
+			Text string `json:"text"`
+		} `json:"messages"`
+	}
+	if err := doShippingJSON(req, payload, &resp); err != nil {
+		return CarrierShipment{}, err
+	}
+	if resp.Status != "SUCCESS" {
+		reason := resp.Status
+		if len(resp.Messages) > 0 {
+			reason = resp.Messages[0].Text
+		}
+		return CarrierShipment{}, fmt.Errorf("shippo could not buy the label: %s", reason)
+	}
+	return CarrierShipment{
+		Carrier:        rate.Carrier,
+		TrackingNumber: resp.TrackingNumber,
+		TrackingURL:    resp.TrackingURL,
+		LabelURL:       resp.LabelURL,
+	}, nil
+}
+
+// shippoStatuses maps Shippo tracking statuses onto ours. Shippo reports
+// out-for-delivery as TRANSIT, so it is read from the substatus.
+var shippoStatuses = map[string]string{
+	"PRE_TRANSIT": TrackingPreTransit,
+	"TRANSIT":     TrackingInTransit,
+	"DELIVERED":   TrackingDelivered,
+	"RETURNED":    TrackingReturned,
+	"FAILURE":     TrackingFailure,
+}
+
+// ShippoStatus converts a Shippo tracking status and substatus code.
+func ShippoStatus(status, substatus string) string {
+	if strings.EqualFold(substatus, "out_for_delivery") {
+		return TrackingOutForDelivery
+	}
+	if s, ok := shippoStatuses[strings.ToUpper(status)]; ok {
+		return s
+	}
+	return TrackingUnknown
+}
+
+// ShippoTrack is the tracking object Shippo returns and posts to webhooks.
+type ShippoTrack struct {
+	Carrier        string                `json:"carrier"`
+	TrackingNumber string                `json:"tracking_number"`
+	TrackingStatus ShippoTrackingEvent   `json:"tracking_status"`
+	History        []ShippoTrackingEvent `json:"tracking_history"`
+}
+
+type ShippoTrackingEvent struct {
+	Status        string    `json:"status"`
+	StatusDetails string    `json:"status_details"`
+	StatusDate    time.Time `json:"status_date"`
+	Substatus     *struct {
+		Code string `json:"code"`
+	} `json:"substatus"`
+	Location *struct {
+		City    string `json:"city"`
+		State   string `json:"state"`
+		Country string `json:"country"`
+	} `json:"location"`
+}
+
+func (e ShippoTrackingEvent) event() TrackingEvent {
+	substatus := ""
+	if e.Substatus != nil {
+		substatus = e.Substatus.Code
+	}
+	ev := TrackingEvent{Status: ShippoStatus(e.Status, substatus), Details: e.StatusDetails, At: e.StatusDate}
+	if e.Location != nil {
+		ev.Location = joinLocation(e.Location.City, e.Location.State, e.Location.Country)
+	}
+	return ev
+}
+
+// Info converts the track to TrackingInfo.
+func (t ShippoTrack) Info() TrackingInfo {
+	info := TrackingInfo{
+		Carrier:        t.Carrier,
+		TrackingNumber: t.TrackingNumber,
+		Status:         t.TrackingStatus.event().Status,
+		Events:         make([]TrackingEvent, 0, len(t.History)),
+	}
+	for _, e := range t.History {
+		info.Events = append(info.Events, e.event())
+	}
+	return info
+}
+
+func (p *ShippoProvider) Track(carrier, trackingNumber string) (TrackingInfo, error) {
+	req, err := p.newRequest(http.MethodGet, "/tracks/"+url.PathEscape(carrier)+"/"+url.PathEscape(trackingNumber))
+	if err != nil {
+		return TrackingInfo{}, err
+	}
+	var track ShippoTrack
+	if err := doShippingJSON(req, nil, &track); err != nil {
+		return TrackingInfo{}, err
+	}
+	return track.Info(), nil
+}
+
+// EasyPostProvider uses the EasyPost API.
+type EasyPostProvider struct {
+	APIKey string
+}
+
+const easyPostBaseURL = "https://api.easypost.com/v2"
+
+// EasyPost works in inches and ounces
+const (
+	cmPerInch = 2.54
+	ozPerKg   = 35.274
+)
+
+func (p *EasyPostProvider) Name() string { return "easypost" }
+
+func (p *EasyPostProvider) newRequest(method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, easyPostBaseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(p.APIKey, "")
+	return req, nil
+}
+
+func (p *EasyPostProvider) Rates(from, to models.PostalAddress, parcel models.Dimensions) ([]CarrierRate, error) {
+	req, err := p.newRequest(http.MethodPost, "/shipments")
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{
+		"shipment": map[string]interface{}{
+			"from_address": carrierAddress(from),
+			"to_address":   carrierAddress(to),
+			"parcel": map[string]float64{
+				"length": parcel.Length / cmPerInch,
+				"width":  parcel.Width / cmPerInch,
+				"height": parcel.Height / cmPerInch,
+				"weight": parcel.Weight * ozPerKg,
+			},
+		},
+	}
+	var resp struct {
+		ID    string `json:"id"`
+		Rates []struct {
+			ID           string `json:"id"`
+			Carrier      string `json:"carrier"`
+			Service      string `json:"service"`
+			Rate         string `json:"rate"`
+			Currency     string `json:"currency"`
+			DeliveryDays int    `json:"delivery_days"`
+		} `json:"rates"`
+	}
+	if err := doShippingJSON(req, payload, &resp); err != nil {
+		return nil, err
+	}
+
+	rates := make([]CarrierRate, 0, len(resp.Rates))
+	for _, r := range resp.Rates {
+		amount, err := strconv.ParseFloat(r.Rate, 64)
+		if err != nil {
+			continue
+		}
+		rates = append(rates, CarrierRate{
+			ID:            r.ID,
+			ShipmentID:    resp.ID,
+			Carrier:       r.Carrier,
+			Service:       r.Service,
+			Amount:        amount,
+			Currency:      r.Currency,
+			EstimatedDays: r.DeliveryDays,
+		})
+	}
+	return rates, nil
+}
+
+func (p *EasyPostProvider) Purchase(rate CarrierRate) (CarrierShipment, error) {
+	req, err := p.newRequest(http.MethodPost, "/shipments/"+url.PathEscape(rate.ShipmentID)+"/buy")
+	if err != nil {
+		return CarrierShipment{}, err
+	}
+	payload := map[string]interface{}{"rate": map[string]string{"id": rate.ID}}
+	var resp struct {
+		TrackingCode string `json:"tracking_code"`
+		PostageLabel struct {
+			LabelURL string `json:"label_url"`
+		} `json:"postage_label"`
+		Tracker struct {
+			PublicURL string `json:"public_url"`
+		} `json:"tracker"`
+	}
+	if err := doShippingJSON(req, payload, &resp); err != nil {
+		return CarrierShipment{}, err
+	}
+	return CarrierShipment{
+		Carrier:        rate.Carrier,
+		TrackingNumber: resp.TrackingCode,
+		TrackingURL:    resp.Tracker.PublicURL,
+		LabelURL:       resp.PostageLabel.LabelURL,
+	}, nil
+}
+
+// EasyPostTracker is the tracker object EasyPost returns and posts to
+// webhooks.
+type EasyPostTracker struct {
+	Carrier         string `json:"carrier"`
+	TrackingCode    string `json:"tracking_code"`
+	Status          string `json:"status"`
+	TrackingDetails []struct {
+		Message          string    `json:"message"`
+		Status           string    `json:"status"`
+		Datetime         time.Time `json:"datetime"`
+		TrackingLocation struct {
+			City    string `json:"city"`
+			State   string `json:"state"`
+			Country string `json:"country"`
+		} `json:"tracking_location"`
+	} `json:"tracking_details"`
+}
+
+// EasyPostStatus converts an EasyPost tracker status.
+func EasyPostStatus(status string) string {
+	switch status {
+	case "pre_transit":
+		return TrackingPreTransit
+	case "in_transit", "available_for_pickup":
+		return TrackingInTransit
+	case "out_for_delivery":
+		return TrackingOutForDelivery
+	case "delivered":
+		return TrackingDelivered
+	case "return_to_sender":
+		return TrackingReturned
+	case "failure", "error":
+		return TrackingFailure
+	}
+	return TrackingUnknown
+}
+
+// Info converts the tracker to TrackingInfo.
+func (t EasyPostTracker) Info() TrackingInfo {
+	info := TrackingInfo{
+		Carrier:        t.Carrier,
+		TrackingNumber: t.TrackingCode,
+		Status:         EasyPostStatus(t.Status),
+		Events:         make([]TrackingEvent, 0, len(t.TrackingDetails)),
+	}
+	for _, d := range t.TrackingDetails {
+		info.Events = append(info.Events, TrackingEvent{
+			Status:   EasyPostStatus(d.Status),
+			Details:  d.Message,
+			Location: joinLocation(d.TrackingLocation.City, d.TrackingLocation.State, d.TrackingLocation.Country),
+			At:       d.Datetime,
+		})
+	}
+	return info
+}
+
+func (p *EasyPostProvider) Track(carrier, trackingNumber string) (TrackingInfo, error) {
+	// Creating a tracker for a code EasyPost already tracks returns the
+	// existing one
+	req, err := p.newRequest(http.MethodPost, "/trackers")
+	if err != nil {
+		return TrackingInfo{}, err
+	}
+	payload := map[string]interface{}{
+		"tracker": map[string]string{"tracking_code": trackingNumber, "carrier": carrier},
+	}
+	var tracker EasyPostTracker
+	if err := doShippingJSON(req, payload, &tracker); err != nil {
+		return TrackingInfo{}, err
+	}
+	return tracker.Info(), nil
+}
+
+// ParseTrackingWebhook reads a tracking update a provider posted. ok is
+// false for events that aren't tracking updates.
+func ParseTrackingWebhook(provider string, body []byte) (info TrackingInfo, ok bool, err error) {
+	switch provider {
+	case "shippo":
+		var event struct {
+			Event string      `json:"event"`
+			Data  ShippoTrack `json:"data"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return info, false, err
+		}
+		if event.Event != "track_updated" || event.Data.TrackingNumber == "" {
+			return info, false, nil
+		}
+		return event.Data.Info(), true, nil
+	case "easypost":
+		var event struct {
+			Description string          `json:"description"`
+			Result      EasyPostTracker `json:"result"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return info, false, err
+		}
+		if !strings.HasPrefix(event.Description, "tracker.") || event.Result.TrackingCode == "" {
+			return info, false, nil
+		}
+		return event.Result.Info(), true, nil
+	}
+	return info, false, fmt.Errorf("unknown shipping provider %q", provider)
+}
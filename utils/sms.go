@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/developia-II/ecommerce-backend/internal/config"
+)
+
+// SMSProvider delivers text messages through one backend.
+type SMSProvider interface {
+	Name() string
+	Send(from, to, body string) error
+}
+
+// SMSRoute sends numbers starting with a country calling code through a
+// provider and sender. A "*" prefix matches every number.
+type SMSRoute struct {
+	Prefix   string
+	Provider SMSProvider
+	Sender   string // Phone number or alphanumeric sender ID
+}
+
+// SMSRouter picks the route with the longest matching prefix.
+type SMSRouter struct {
+	routes []SMSRoute
+}
+
+func NewSMSRouter(routes []SMSRoute) *SMSRouter {
+	sorted := append([]SMSRoute(nil), routes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return routePrefixLen(sorted[i].Prefix) > routePrefixLen(sorted[j].Prefix)
+	})
+	return &SMSRouter{routes: sorted}
+}
+
+func routePrefixLen(prefix string) int {
+	if prefix == "*" {
+		return 0
+	}
+	return len(prefix)
+}
+
+// Route returns the route for an E.164 phone number.
+func (r *SMSRouter) Route(phone string) (SMSRoute, bool) {
+	digits := strings.TrimPrefix(phone, "+")
+	for _, route := range r.routes {
+		if route.Prefix == "*" || strings.HasPrefix(digits, route.Prefix) {
+			return route, true
+		}
+	}
+	return SMSRoute{}, false
+}
+
+// NewSMSRouterFromConfig builds the configured routes. It returns nil when
+// SMS is not configured.
+func NewSMSRouterFromConfig(cfg config.SMSConfig) (*SMSRouter, error) {
+	if len(cfg.Routes) == 0 {
+		return nil, nil
+	}
+
+	providers := map[string]SMSProvider{}
+	var routes []SMSRoute
+	for _, r := range cfg.Routes {
+		provider, ok := providers[r.Provider]
+		if !ok {
+			var err error
+			if provider, err = newSMSProvider(r.Provider, cfg); err != nil {
+				return nil, err
+			}
+			providers[r.Provider] = provider
+		}
+		routes = append(routes, SMSRoute{Prefix: r.Prefix, Provider: provider, Sender: r.Sender})
+	}
+	return NewSMSRouter(routes), nil
+}
+
+func newSMSProvider(name string, cfg config.SMSConfig) (SMSProvider, error) {
+	switch name {
+	case "twilio":
+		return &TwilioProvider{AccountSID: cfg.Twilio.AccountSID, AuthToken: cfg.Twilio.AuthToken}, nil
+	case "termii":
+		return &TermiiProvider{APIKey: cfg.Termii.APIKey, BaseURL: cfg.Termii.BaseURL}, nil
+	}
+	return nil, fmt.Errorf("unknown SMS provider %q", name)
+}
+
+var (
+	smsMu     sync.RWMutex
+	smsRouter *SMSRouter
+)
+
+// SetSMSRouter sets the router used by SendSMS. The server installs the
+// configured one at startup; pass nil to disable SMS.
+func SetSMSRouter(r *SMSRouter) {
+	smsMu.Lock()
+	defer smsMu.Unlock()
+	smsRouter = r
+}
+
+func currentSMSRouter() *SMSRouter {
+	smsMu.RLock()
+	defer smsMu.RUnlock()
+	return smsRouter
+}
+
+// ErrSMSNotConfigured is returned when no route covers the number.
+var ErrSMSNotConfigured = fmt.Errorf("SMS is not configured for this number")
+
+// SendSMS texts an E.164 phone number through the route for its country.
+func SendSMS(to, body string) error {
+	router := currentSMSRouter()
+	if router == nil {
+		return ErrSMSNotConfigured
+	}
+	route, ok := router.Route(to)
+	if !ok {
+		return ErrSMSNotConfigured
+	}
+	return route.Provider.Send(route.Sender, to, body)
+}
+
+// NormalizePhone reduces a phone number to E.164 (+ followed by 8-15
+// digits), dropping spaces, dashes, dots and parentheses.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !strings.HasPrefix(phone, "+") {
+		return "", fmt.Errorf("phone number must include the country code, e.g. +2348012345678")
+	}
+
+	var digits strings.Builder
+	for _, r := range phone[1:] {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("phone number contains invalid characters")
+		}
+	}
+	if n := digits.Len(); n < 8 || n > 15 || strings.HasPrefix(digits.String(), "0") {
+		return "", fmt.Errorf("phone number is not a valid international number")
+	}
+	return "+" + digits.String(), nil
+}
+
+var smsHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// TwilioProvider sends through the Twilio Messages API.
+type TwilioProvider struct {
+	AccountSID string
+	AuthToken  string
+}
+
+func (p *TwilioProvider) Name() string { return "twilio" }
+
+func (p *TwilioProvider) Send(from, to, body string) error {
+	form := url.Values{"To": {to}, "From": {from}, "Body": {body}}
+	endpoint := "https://api.twilio.com/2010-04-01/Accounts/" + p.AccountSID + "/Messages.json"
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.AccountSID, p.AuthToken)
+	return doSMSRequest(req)
+}
+
+// TermiiProvider sends through the Termii messaging API, which covers
+// Nigerian and other African networks.
+type TermiiProvider struct {
+	APIKey  string
+	BaseURL string
+}
+
+func (p *TermiiProvider) Name() string { return "termii" }
+
+func (p *TermiiProvider) Send(from, to, body string) error {
+	payload := map[string]string{
+		"api_key": p.APIKey,
+		"to":      strings.TrimPrefix(to, "+"),
+		"from":    from,
+		"sms":     body,
+		"type":    "plain",
+		"channel": "dnd", // Transactional route; reaches numbers on do-not-disturb
+	}
+	return postEmailJSON(strings.TrimRight(p.BaseURL, "/")+"/api/sms/send", payload, nil)
+}
+
+func doSMSRequest(req *http.Request) error {
+	resp, err := smsHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("provider returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// ValidTwilioSignature checks the X-Twilio-Signature header of an inbound
+// webhook: base64(HMAC-SHA1(authToken, url + sorted key/value pairs)).
+func ValidTwilioSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k + v)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Unsubscribe tokens go in email footers, so they don't expire: a link in an
+// old email must still work. They are signed with JWT_SECRET and only carry
+// the user and the notification type to turn off.
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe link")
+
+// GenerateUnsubscribeToken signs a one-click unsubscribe token for a user and
+// notification type.
+func GenerateUnsubscribeToken(userID, kind string) (string, error) {
+	secret, err := signingSecret()
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID + ":" + kind))
+	return payload + "." + unsubscribeSignature(secret, payload), nil
+}
+
+// VerifyUnsubscribeToken returns the user and notification type a token was
+// issued for.
+func VerifyUnsubscribeToken(token string) (userID, kind string, err error) {
+	secret, err := signingSecret()
+	if err != nil {
+		return "", "", err
+	}
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(secret, payload))) {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	userID, kind, ok = strings.Cut(string(raw), ":")
+	if !ok || userID == "" || kind == "" {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	return userID, kind, nil
+}
+
+func unsubscribeSignature(secret, payload string) string {
+	// Domain-separated from JWTs signed with the same secret
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/developia-II/ecommerce-backend/internal/i18n"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+	// isoCodes checks country codes against the validator's own ISO 3166 list
+	isoCodes = validator.New()
+)
+
+// RegisterRules adds the rules for this domain's fields to v:
+//
+//   - locale: a locale content can be translated into, any supported one
+//     but the default
+//   - color: a CSS hex colour, #RGB or #RRGGBB, as storefronts use
+//   - weburl: an http or https address with a host, unlike "url", which
+//     takes javascript: and file: too
+//   - phone: an international number NormalizePhone accepts
+//   - country: an ISO 3166-1 alpha-2 code, in either case
+//   - slug: lower-case letters and numbers in runs joined by single hyphens
+//
+// Empty values fail every rule, so optional fields use omitempty.
+func RegisterRules(v *validator.Validate) {
+	v.RegisterValidation("locale", func(fl validator.FieldLevel) bool {
+		locale := fl.Field().String()
+		return locale != i18n.DefaultLocale && i18n.IsSupported(locale)
+	})
+	v.RegisterValidation("color", func(fl validator.FieldLevel) bool {
+		return colorPattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("weburl", func(fl validator.FieldLevel) bool {
+		return IsWebURL(fl.Field().String())
+	})
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		_, err := NormalizePhone(fl.Field().String())
+		return err == nil
+	})
+	v.RegisterValidation("country", func(fl validator.FieldLevel) bool {
+		code := strings.ToUpper(strings.TrimSpace(fl.Field().String()))
+		return len(code) == 2 && isoCodes.Var(code, "iso3166_1_alpha2") == nil
+	})
+	v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
+}
+
+// IsWebURL reports whether s is an absolute http or https URL with a host.
+func IsWebURL(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != ""
+}
+
+// IsHexColor reports whether s is a colour the "color" rule accepts.
+func IsHexColor(s string) bool {
+	return colorPattern.MatchString(s)
+}